@@ -0,0 +1,298 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/tokens"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+const (
+	defaultCompactionThreshold = 0.8
+	defaultCompactionKeepTurns = 2
+
+	// maxTranscriptItemLength limits how much of a single message or tool result is sent to the
+	// summarizer, long tool results are rarely needed to understand the conversation.
+	maxTranscriptItemLength = 4000
+
+	// SummaryPrefix starts the message that replaces the summarized turns of a session.
+	SummaryPrefix = "Summary of the earlier conversation:"
+)
+
+const compactionInstructions = `You summarize the earlier part of a conversation between a user and an AI assistant, so that the assistant can continue the conversation without the full transcript.
+
+- Keep every fact, decision, requirement, preference, name, number, file, and URL that may matter later.
+- Keep the open tasks and questions, and what the assistant has already done or promised to do.
+- Mention the tools that were called and what they returned only if the results are still relevant.
+- If the transcript starts with an earlier summary, merge it into the new summary.
+- Write in the third person, as a concise list of bullet points. Do not add anything that is not in the transcript.`
+
+// Compact replaces the older turns of the current thread of the session with a summary, keeping
+// the most recent turns of the agent. The original thread is kept in the session next to the
+// compacted one. It returns the number of messages that were summarized.
+func (a *Agents) Compact(ctx context.Context, agentName string) (int, error) {
+	var (
+		config  = types.ConfigFromContext(ctx)
+		session = mcp.SessionFromContext(ctx)
+		key     = types.PreviousExecutionKey
+	)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	if session == nil {
+		return 0, fmt.Errorf("compacting requires a session")
+	}
+
+	if threadName := config.Agents[agentName].ThreadName; threadName != "" {
+		key = fmt.Sprintf("%s/%s", key, threadName)
+	}
+
+	var run types.Execution
+	if !session.Get(key, &run) {
+		return 0, nil
+	}
+
+	compacted, summarized, err := a.compact(ctx, config, agentName, &run)
+	if err != nil || compacted == nil {
+		return 0, err
+	}
+	saveCompacted(session, key, &run, compacted)
+	return summarized, nil
+}
+
+// compactIfNeeded compacts the previous run of a chat if its history fills more than the threshold
+// of the context window. It returns nil if the run was not compacted.
+func (a *Agents) compactIfNeeded(ctx context.Context, config types.Config, agentName string, session *mcp.Session, key string, run *types.Execution) *types.Execution {
+	agent, ok := config.Agents[agentName]
+	if !ok || agent.Compaction == nil || run.PopulatedRequest == nil {
+		return nil
+	}
+
+	var cw types.AgentContextWindow
+	if agent.ContextWindow != nil {
+		cw = *agent.ContextWindow
+	}
+	limit := promptLimit(types.CompletionRequest{Model: agent.Model, MaxTokens: agent.MaxTokens}, cw)
+	if limit == 0 {
+		return nil
+	}
+
+	threshold := agent.Compaction.Threshold
+	if threshold == 0 {
+		threshold = defaultCompactionThreshold
+	}
+	used := tokens.CountMessages(agent.Model, run.Messages()...)
+	if float64(used) < threshold*float64(limit) {
+		return nil
+	}
+
+	compacted, summarized, err := a.compact(ctx, config, agentName, run)
+	if err != nil {
+		// The history is still truncated to fit, so the turn can continue without the summary.
+		log.Errorf(ctx, "failed to compact history of agent %s: %v", agentName, err)
+		return nil
+	} else if compacted == nil {
+		return nil
+	}
+
+	log.Infof(ctx, "compacted history of agent %s, summarized %d messages of about %d tokens", agentName, summarized, used)
+	saveCompacted(session, key, run, compacted)
+	return compacted
+}
+
+// saveCompacted stores the compacted run as the current thread and keeps the original next to it,
+// in the same way old threads are kept when a new thread is started.
+func saveCompacted(session *mcp.Session, key string, original, compacted *types.Execution) {
+	session.Set(key+"/compacted/"+time.Now().Format(time.RFC3339Nano), original)
+	session.Set(key, compacted)
+}
+
+// compact summarizes all but the most recent turns of the run. System messages are kept as they
+// are. It returns nil if there are not enough turns to compact.
+func (a *Agents) compact(ctx context.Context, config types.Config, agentName string, run *types.Execution) (*types.Execution, int, error) {
+	if run.PopulatedRequest == nil {
+		return nil, 0, nil
+	}
+
+	keepTurns := defaultCompactionKeepTurns
+	if c := config.Agents[agentName].Compaction; c != nil && c.KeepTurns > 0 {
+		keepTurns = c.KeepTurns
+	}
+
+	var (
+		input  = run.PopulatedRequest.Input
+		starts = turnStarts(input)
+	)
+	if len(starts) <= keepTurns {
+		return nil, 0, nil
+	}
+	cut := starts[len(starts)-keepTurns]
+
+	// The oldest messages of long threads are not kept in the session, they are summarized too.
+	older, err := storedHistory(ctx, run)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var system []types.Message
+	for _, msg := range input[:cut] {
+		if msg.Role == "system" {
+			system = append(system, msg)
+		} else {
+			older = append(older, msg)
+		}
+	}
+	if len(older) == 0 {
+		return nil, 0, nil
+	}
+
+	summary, err := a.summarize(ctx, config, agentName, older)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	compactedInput := append(system, types.Message{
+		ID:      uuid.String(),
+		Created: &now,
+		Role:    "user",
+		Items: []types.CompletionItem{
+			{
+				Content: &mcp.Content{
+					Type: "text",
+					Text: SummaryPrefix + "\n\n" + summary,
+				},
+			},
+		},
+	})
+	compactedInput = append(compactedInput, input[cut:]...)
+
+	req := *run.PopulatedRequest
+	req.Input = compactedInput
+
+	compacted := *run
+	compacted.PopulatedRequest = &req
+	compacted.History = nil
+	return &compacted, len(older), nil
+}
+
+// storedHistory loads the messages of the thread of the run that were moved out of the session.
+func storedHistory(ctx context.Context, run *types.Execution) ([]types.Message, error) {
+	var (
+		session = mcp.SessionFromContext(ctx)
+		loader  types.HistoryLoader
+	)
+	if run.History == nil || run.History.Messages == 0 || !session.Get(types.ManagerSessionKey, &loader) {
+		return nil, nil
+	}
+	for session.Parent != nil {
+		session = session.Parent
+	}
+
+	messages, err := loader.LoadHistory(ctx, session.ID(), run.History)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	return messages, nil
+}
+
+// summarize asks the summarizer to summarize the messages. The compaction agent is used if one is
+// configured, otherwise the model of the agent.
+func (a *Agents) summarize(ctx context.Context, config types.Config, agentName string, messages []types.Message) (string, error) {
+	agent := config.Agents[agentName]
+
+	// The structured summary of the session, if the agent keeps one, seeds the summary.
+	text := "Summarize this conversation:\n\n" + transcript(messages)
+	var seed types.SessionSummary
+	if mcp.SessionFromContext(ctx).Get(types.SummarySessionKey, &seed) && !seed.IsZero() {
+		text = "Summary of the topics, decisions, and open questions of the conversation so far:\n\n" +
+			renderSummary(seed) + "\n\n" + text
+	}
+
+	prompt := types.Message{
+		Role: "user",
+		Items: []types.CompletionItem{
+			{
+				Content: &mcp.Content{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}
+
+	var (
+		resp *types.CompletionResponse
+		err  error
+	)
+	if agent.Compaction != nil && agent.Compaction.Agent != "" {
+		chat := false
+		resp, err = a.Complete(ctx, types.CompletionRequest{
+			Model: agent.Compaction.Agent,
+			Input: []types.Message{prompt},
+		}, types.CompletionOptions{Chat: &chat})
+	} else {
+		resp, err = a.completer.Complete(ctx, types.CompletionRequest{
+			Model:        agent.Model,
+			Agent:        agentName,
+			SystemPrompt: compactionInstructions,
+			Input:        []types.Message{prompt},
+		})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize history: %w", err)
+	}
+
+	summary := strings.TrimSpace(outputText(resp.Output))
+	if summary == "" {
+		return "", fmt.Errorf("failed to summarize history: the summary is empty")
+	}
+	return summary, nil
+}
+
+// transcript renders the messages as plain text for the summarizer.
+func transcript(messages []types.Message) string {
+	var buf strings.Builder
+	for _, msg := range messages {
+		for _, item := range msg.Items {
+			switch {
+			case item.Content != nil && item.Content.Type == "text":
+				fmt.Fprintf(&buf, "%s: %s\n\n", transcriptRole(msg.Role), clip(item.Content.Text))
+			case item.Content != nil:
+				fmt.Fprintf(&buf, "%s: [%s attachment]\n\n", transcriptRole(msg.Role), item.Content.Type)
+			case item.ToolCall != nil:
+				fmt.Fprintf(&buf, "Assistant called tool %s with %s\n\n", item.ToolCall.Name, clip(item.ToolCall.Arguments))
+			case item.ToolCallResult != nil:
+				var text []string
+				for _, content := range item.ToolCallResult.Output.Content {
+					if content.Type == "text" {
+						text = append(text, content.Text)
+					}
+				}
+				fmt.Fprintf(&buf, "Tool result: %s\n\n", clip(strings.Join(text, "\n")))
+			}
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func transcriptRole(role string) string {
+	if role == "assistant" {
+		return "Assistant"
+	}
+	return "User"
+}
+
+func clip(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= maxTranscriptItemLength {
+		return text
+	}
+	return strings.ToValidUTF8(text[:maxTranscriptItemLength], "") + " [...]"
+}
@@ -0,0 +1,71 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type summarizer struct {
+	transcript string
+}
+
+func (s *summarizer) Complete(_ context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	s.transcript = outputText(req.Input[0])
+	return &types.CompletionResponse{
+		Output: textMessage("assistant", "the user asked two questions"),
+	}, nil
+}
+
+func TestCompact(t *testing.T) {
+	var (
+		completer = &summarizer{}
+		a         = &Agents{completer: completer}
+		config    = types.Config{
+			Agents: map[string]types.Agent{
+				"bot": {Model: "gpt-4o", Compaction: &types.AgentCompaction{KeepTurns: 1}},
+			},
+		}
+		run = &types.Execution{
+			PopulatedRequest: &types.CompletionRequest{
+				Input: []types.Message{
+					textMessage("system", "be brief"),
+					textMessage("user", "first question"),
+					textMessage("assistant", "first answer"),
+					textMessage("user", "second question"),
+					textMessage("assistant", "second answer"),
+					textMessage("user", "third question"),
+				},
+			},
+		}
+	)
+
+	compacted, summarized, err := a.compact(context.Background(), config, "bot", run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summarized != 4 {
+		t.Errorf("got %d summarized messages, want 4", summarized)
+	}
+	if !strings.Contains(completer.transcript, "Assistant: second answer") || strings.Contains(completer.transcript, "third question") {
+		t.Errorf("unexpected transcript %q", completer.transcript)
+	}
+
+	var texts []string
+	for _, msg := range compacted.PopulatedRequest.Input {
+		texts = append(texts, msg.Role+": "+outputText(msg))
+	}
+	want := []string{
+		"system: be brief",
+		"user: " + SummaryPrefix + "\n\nthe user asked two questions",
+		"user: third question",
+	}
+	if got := strings.Join(texts, "|"); got != strings.Join(want, "|") {
+		t.Errorf("got messages %q, want %q", got, want)
+	}
+	if len(run.PopulatedRequest.Input) != 6 {
+		t.Errorf("the original run was modified")
+	}
+}
@@ -0,0 +1,127 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+var (
+	markdownTable  = regexp.MustCompile(`(?m)^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)+\|?\s*$`)
+	markdownSyntax = regexp.MustCompile("(?m)^#{1,6}\\s|^\\s*```|\\*\\*[^*\\n]+\\*\\*|__[^_\\n]+__|\\[[^\\]\\n]+\\]\\([^)\\n]+\\)")
+	bulletPoint    = regexp.MustCompile(`^\s*([-*•]|\d+[.)])\s+`)
+)
+
+// constraintInstructions describes the constraints to the model, to be appended to the system prompt.
+func constraintInstructions(c *types.AgentConstraints) string {
+	var rules []string
+	if c.MaxLength > 0 {
+		rules = append(rules, fmt.Sprintf("Keep every response under %d characters.", c.MaxLength))
+	}
+	if c.NoMarkdown {
+		rules = append(rules, "Respond in plain text, do not use any markdown formatting.")
+	} else if c.NoMarkdownTables {
+		rules = append(rules, "Do not use markdown tables.")
+	}
+	if c.BulletPoints {
+		rules = append(rules, "Always format your response as a list of bullet points, every line must be a bullet point.")
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return "Your responses must follow these rules:\n- " + strings.Join(rules, "\n- ")
+}
+
+// constraintViolations returns a description of every constraint the text violates.
+func constraintViolations(c *types.AgentConstraints, text string) (violations []string) {
+	if length := utf8.RuneCountInString(text); c.MaxLength > 0 && length > c.MaxLength {
+		violations = append(violations, fmt.Sprintf("it is %d characters long, the limit is %d", length, c.MaxLength))
+	}
+	if (c.NoMarkdown || c.NoMarkdownTables) && markdownTable.MatchString(text) {
+		violations = append(violations, "it contains a markdown table")
+	}
+	if c.NoMarkdown && markdownSyntax.MatchString(text) {
+		violations = append(violations, "it uses markdown formatting")
+	}
+	if c.BulletPoints {
+		for line := range strings.SplitSeq(text, "\n") {
+			if strings.TrimSpace(line) != "" && !bulletPoint.MatchString(line) {
+				violations = append(violations, "not every line is a bullet point")
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// reviseForConstraints checks the final response of run against the constraints of the agent and,
+// if they are violated, asks the model once to revise it. The revised response replaces the
+// original one, the revision request is not added to the history.
+func (a *Agents) reviseForConstraints(ctx context.Context, config types.Config, run *types.Execution, opts []types.CompletionOptions) error {
+	if run.PopulatedRequest == nil || run.Response == nil {
+		return nil
+	}
+
+	constraints := config.Agents[run.PopulatedRequest.Agent].Constraints
+	if constraints == nil {
+		return nil
+	}
+
+	violations := constraintViolations(constraints, outputText(run.Response.Output))
+	if len(violations) == 0 {
+		return nil
+	}
+
+	req := *run.PopulatedRequest
+	req.ToolChoice = "none"
+	req.Input = append(slices.Clone(req.Input), run.Response.Output, types.Message{
+		ID:   uuid.String(),
+		Role: "user",
+		Items: []types.CompletionItem{
+			{
+				Content: &mcp.Content{
+					Type: "text",
+					Text: fmt.Sprintf("Your last response can not be delivered because %s. Rewrite it so that it follows all of these rules, keeping the same meaning. Only reply with the rewritten response.\n\n%s",
+						strings.Join(violations, ", "), constraintInstructions(constraints)),
+				},
+			},
+		},
+	})
+
+	resp, err := a.completer.Complete(ctx, req, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to revise response to follow the constraints of agent %s: %w", req.Agent, err)
+	}
+
+	if slices.ContainsFunc(resp.Output.Items, func(item types.CompletionItem) bool {
+		return item.ToolCall != nil
+	}) {
+		// The revision must be a final response, keep the original if the model tried to call tools.
+		return nil
+	}
+
+	if remaining := constraintViolations(constraints, outputText(resp.Output)); len(remaining) > 0 {
+		log.Debugf(ctx, "revised response of agent %s still violates its constraints: %s", req.Agent, strings.Join(remaining, ", "))
+	}
+
+	run.Response = resp
+	return nil
+}
+
+func outputText(msg types.Message) string {
+	var text strings.Builder
+	for _, item := range msg.Items {
+		if item.Content != nil && item.Content.Type == "text" {
+			text.WriteString(item.Content.Text)
+		}
+	}
+	return text.String()
+}
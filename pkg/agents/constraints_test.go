@@ -0,0 +1,33 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestConstraintViolations(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints types.AgentConstraints
+		text        string
+		violations  int
+	}{
+		{"length ok", types.AgentConstraints{MaxLength: 5}, "héllo", 0},
+		{"too long", types.AgentConstraints{MaxLength: 4}, "hello", 1},
+		{"table", types.AgentConstraints{NoMarkdownTables: true}, "| a | b |\n|---|---|\n| 1 | 2 |", 1},
+		{"table allowed", types.AgentConstraints{}, "| a | b |\n|---|---|", 0},
+		{"markdown", types.AgentConstraints{NoMarkdown: true}, "# Title\nsome **bold** text", 1},
+		{"plain text", types.AgentConstraints{NoMarkdown: true}, "2 * 3 = 6, see example.com", 0},
+		{"bullets", types.AgentConstraints{BulletPoints: true}, "- one\n* two\n\n1. three", 0},
+		{"not bullets", types.AgentConstraints{BulletPoints: true}, "- one\nsummary", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if violations := constraintViolations(&tt.constraints, tt.text); len(violations) != tt.violations {
+				t.Errorf("expected %d violations, got %v", tt.violations, violations)
+			}
+		})
+	}
+}
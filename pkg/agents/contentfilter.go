@@ -0,0 +1,50 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// checkContentFilter runs the guardrail action of the agent for the results of the content filter
+// of the provider on the response. Without an action, the results are only kept on the response.
+func checkContentFilter(ctx context.Context, config types.Config, agent string, resp *types.CompletionResponse) error {
+	filter := config.Agents[agent].ContentFilter
+	if filter == nil || resp == nil {
+		return nil
+	}
+
+	triggered := filter.Triggered(resp.Output.ContentFilter)
+	if len(triggered) == 0 {
+		return nil
+	}
+
+	switch filter.Action {
+	case types.ContentFilterWarn:
+		log.Infof(ctx, "warning: the content filter of the provider flagged the response of agent %s: %s", agent, describeContentFilter(triggered))
+	case types.ContentFilterBlock:
+		return fmt.Errorf("the response of agent %s was blocked by the content filter of the provider: %s", agent, describeContentFilter(triggered))
+	}
+	return nil
+}
+
+func describeContentFilter(results []types.ContentFilterResult) string {
+	descriptions := make([]string, 0, len(results))
+	for _, result := range results {
+		description := result.Target + " " + result.Category
+		switch {
+		case result.Filtered:
+			description += " filtered"
+		case result.Detected:
+			description += " detected"
+		}
+		if result.Severity != "" {
+			description += " (" + result.Severity + ")"
+		}
+		descriptions = append(descriptions, description)
+	}
+	return strings.Join(descriptions, ", ")
+}
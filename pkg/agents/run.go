@@ -10,18 +10,22 @@ import (
 	"time"
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/events"
 	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/schema"
+	"github.com/nanobot-ai/nanobot/pkg/servers/outputs"
 	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
 	"github.com/nanobot-ai/nanobot/pkg/tools"
 	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uifeatures"
 	"github.com/nanobot-ai/nanobot/pkg/uuid"
 )
 
 type Agents struct {
 	completer types.Completer
 	registry  *tools.Service
+	schemas   *schemaCache
 }
 
 type ToolListOptions struct {
@@ -33,6 +37,7 @@ func New(completer types.Completer, registry *tools.Service) *Agents {
 	return &Agents{
 		completer: completer,
 		registry:  registry,
+		schemas:   newSchemaCache(),
 	}
 }
 
@@ -42,13 +47,26 @@ func (a *Agents) addTools(ctx context.Context, req *types.CompletionRequest, age
 		return nil, fmt.Errorf("failed to build tool mappings: %w", err)
 	}
 
+	config := types.ConfigFromContext(ctx)
+	for _, toolMapping := range toolMappings {
+		if tools.Policy(config, toolMapping.MCPServer, toolMapping.TargetName).MaxOutputSize > 0 {
+			// Outputs of the tool can be truncated, the model pages through them with this tool.
+			toolMappings[outputs.ReadTool] = types.TargetMapping[mcp.Tool]{
+				MCPServer:  outputs.ServerName,
+				TargetName: outputs.ReadTool,
+				Target:     outputs.ReadToolDefinition(),
+			}
+			break
+		}
+	}
+
 	for _, key := range slices.Sorted(maps.Keys(toolMappings)) {
 		toolMapping := toolMappings[key]
 
 		tool := toolMapping.Target
 		req.Tools = append(req.Tools, types.ToolUseDefinition{
 			Name:        key,
-			Parameters:  schema.ValidateAndFixToolSchema(tool.InputSchema),
+			Parameters:  a.schemas.fix(ctx, req.Agent, tool.InputSchema),
 			Description: tool.Description,
 			Attributes:  agent.ToolExtensions[toolMapping.Target.Name],
 		})
@@ -90,6 +108,28 @@ func populateToolCallResult(previousRun *types.Execution, req *types.CompletionR
 	req.Input = nil
 }
 
+// toolOutputOrder returns the call IDs of the tool outputs of the run in the order the model called
+// the tools, followed by any other outputs.
+func toolOutputOrder(run *types.Execution) []string {
+	var result []string
+	if run.Response != nil {
+		for _, item := range run.Response.Output.Items {
+			if item.ToolCall == nil {
+				continue
+			}
+			if _, ok := run.ToolOutputs[item.ToolCall.CallID]; ok && !slices.Contains(result, item.ToolCall.CallID) {
+				result = append(result, item.ToolCall.CallID)
+			}
+		}
+	}
+	for _, callID := range slices.Sorted(maps.Keys(run.ToolOutputs)) {
+		if !slices.Contains(result, callID) {
+			result = append(result, callID)
+		}
+	}
+	return result
+}
+
 func (a *Agents) populateRequest(ctx context.Context, config types.Config, run *types.Execution, previousRun *types.Execution) (types.CompletionRequest, types.ToolMappings, error) {
 	req := run.Request
 
@@ -118,7 +158,7 @@ func (a *Agents) populateRequest(ctx context.Context, config types.Config, run *
 			}
 		}
 
-		for _, callID := range slices.Sorted(maps.Keys(previousRun.ToolOutputs)) {
+		for _, callID := range toolOutputOrder(previousRun) {
 			toolCall := previousRun.ToolOutputs[callID]
 			if toolCall.Done {
 				input = append(input, toolCall.Output)
@@ -141,6 +181,7 @@ func (a *Agents) populateRequest(ctx context.Context, config types.Config, run *
 
 	req.Agent = agentName
 	req.Reasoning = agent.Reasoning
+	req.Audio = agent.Audio
 
 	if req.SystemPrompt != "" {
 		var agentInstructions types.DynamicInstructions
@@ -175,21 +216,37 @@ func (a *Agents) populateRequest(ctx context.Context, config types.Config, run *
 		req.MaxTokens = agent.MaxTokens
 	}
 
-	if req.ToolChoice == "" && agent.ToolChoice != "" {
+	if !req.Logprobs && req.TopLogprobs == 0 {
+		req.Logprobs = agent.Logprobs
+		req.TopLogprobs = agent.TopLogprobs
+	}
+
+	if c := agent.Constraints; c != nil {
+		if c.MaxTokens > 0 && (req.MaxTokens == 0 || c.MaxTokens < req.MaxTokens) {
+			req.MaxTokens = c.MaxTokens
+		}
+		if instructions := constraintInstructions(c); instructions != "" {
+			req.SystemPrompt = strings.TrimSpace(req.SystemPrompt + "\n\n" + instructions)
+		}
+	}
+
+	if req.ToolChoice == "" && agent.ToolChoice != "" && previousRun == nil {
+		// Don't apply the tool choice of the agent if this is a follow-on request, a tool choice
+		// set on the request only applies to the first completion of a call.
 		req.ToolChoice = agent.ToolChoice
 	}
 
-	if previousRun != nil {
-		// Don't allow tool choice if this is a follow-on request
-		req.ToolChoice = ""
+	if req.ParallelToolCalls == nil {
+		req.ParallelToolCalls = agent.ParallelToolCalls
 	}
 
-	if req.OutputSchema == nil && agent.Output != nil && len(agent.Output.ToSchema()) > 0 {
+	if req.OutputSchema == nil && agent.Output != nil && (agent.Output.IsJSONObject() || len(agent.Output.ToSchema()) > 0) {
 		req.OutputSchema = &types.OutputSchema{
 			Name:        agent.Output.Name,
 			Description: agent.Output.Description,
 			Schema:      agent.Output.ToSchema(),
 			Strict:      agent.Output.Strict,
+			Format:      agent.Output.Format,
 		}
 	}
 
@@ -203,6 +260,21 @@ func (a *Agents) populateRequest(ctx context.Context, config types.Config, run *
 
 	req.Model = agent.Model
 
+	if req.API == "" {
+		req.API = agent.API
+	}
+
+	if req.BuiltinTools == nil {
+		req.BuiltinTools = agent.BuiltinTools
+	}
+
+	if req.CacheTTL == nil && agent.CacheTTL != "" {
+		// The TTL is validated when the config is loaded
+		if ttl, err := time.ParseDuration(agent.CacheTTL); err == nil {
+			req.CacheTTL = &ttl
+		}
+	}
+
 	toolMapping, err := a.addTools(ctx, &req, &agent)
 	if err != nil {
 		return req, nil, fmt.Errorf("failed to add tools: %w", err)
@@ -210,8 +282,7 @@ func (a *Agents) populateRequest(ctx context.Context, config types.Config, run *
 
 	// Validate and fix tool input schemas
 	for i, tool := range req.Tools {
-		fixedSchema := schema.ValidateAndFixToolSchema(tool.Parameters)
-		req.Tools[i].Parameters = fixedSchema
+		req.Tools[i].Parameters = a.schemas.fix(ctx, req.Agent, tool.Parameters)
 	}
 
 	return req, toolMapping, nil
@@ -392,6 +463,17 @@ func (a *Agents) Complete(ctx context.Context, req types.CompletionRequest, opts
 		session = session.Parent
 	}
 
+	ctx, finishTurn := events.Turn(ctx, complete.First(req.Agent, req.Model))
+	defer func() {
+		finishTurn(err)
+	}()
+
+	if !uifeatures.Supported(ctx, uifeatures.Reasoning) {
+		ctx = progress.WithFilter(ctx, func(p *types.CompletionProgress) bool {
+			return p.Item.Reasoning == nil
+		})
+	}
+
 	if len(req.Input) > 0 {
 		startID = req.Input[0].ID
 		if startID == "" {
@@ -408,8 +490,17 @@ func (a *Agents) Complete(ctx context.Context, req types.CompletionRequest, opts
 		isChat = false
 	}
 
-	if ch := complete.Complete(opts...).Chat; ch != nil {
-		isChat = *ch
+	opt := complete.Complete(opts...)
+	if opt.Chat != nil {
+		isChat = *opt.Chat
+	}
+
+	if opt.ToolChoice != "" {
+		req.ToolChoice = opt.ToolChoice
+	}
+
+	if opt.ParallelToolCalls != nil {
+		req.ParallelToolCalls = opt.ParallelToolCalls
 	}
 
 	if isChat && req.InputAsToolResult == nil {
@@ -429,6 +520,16 @@ func (a *Agents) Complete(ctx context.Context, req types.CompletionRequest, opts
 		if req.NewThread && previousRun != nil {
 			session.Set(previousExecutionKey+"/"+time.Now().Format(time.RFC3339), previousRun)
 			session.Set(previousExecutionKey, nil)
+		} else if previousRun != nil {
+			if compacted := a.compactIfNeeded(ctx, config, complete.First(req.Agent, req.Model), session, previousExecutionKey, previousRun); compacted != nil {
+				fallBack = compacted
+				previousRun = compacted
+			}
+		}
+
+		// All runs of a thread share the history that the older messages are stored in.
+		if previousRun == nil || previousRun.History == nil || req.NewThread {
+			currentRun.History = &types.ExecutionHistory{ID: uuid.String()}
 		}
 
 		defer func() {
@@ -470,9 +571,28 @@ func (a *Agents) Complete(ctx context.Context, req types.CompletionRequest, opts
 		}
 
 		if currentRun.Done {
+			if err := a.reviseForConstraints(ctx, config, currentRun, opts); err != nil {
+				return nil, err
+			}
+
+			if currentRun.PopulatedRequest != nil && currentRun.Response != nil {
+				if err := schema.ValidateOutput(currentRun.PopulatedRequest.OutputSchema, currentRun.Response.Output); err != nil {
+					return nil, err
+				}
+			}
+
 			if isChat {
 				currentRun.Response.ChatResponse = true
 				session.Set(previousExecutionKey, currentRun)
+
+				agentName := complete.First(req.Agent, req.Model)
+				if config.Agents[agentName].Summary != nil {
+					a.summarizeTurn(ctx, config, agentName, session, currentRun)
+				}
+				if config.Agents[agentName].Suggestions != nil && opt.ProgressToken != nil &&
+					uifeatures.Supported(ctx, uifeatures.Suggestions) {
+					a.suggest(ctx, config, agentName, currentRun, opt.ProgressToken)
+				}
 			}
 
 			finalResponse := *currentRun.Response
@@ -519,6 +639,9 @@ func (a *Agents) run(ctx context.Context, config types.Config, run *types.Execut
 	maps.Copy(allToolMappings, toolMapping)
 
 	run.ToolToMCPServer = allToolMappings
+	if run.History == nil && prev != nil {
+		run.History = prev.History
+	}
 
 	completionRequest, resp, err := a.runBefore(ctx, config, completionRequest)
 	if err != nil {
@@ -539,11 +662,20 @@ func (a *Agents) run(ctx context.Context, config types.Config, run *types.Execut
 		return nil
 	}
 
+	modifiedRequest = truncate(ctx, config, modifiedRequest)
+
 	resp, err = a.completer.Complete(ctx, modifiedRequest, opts...)
+	if truncated, ok := truncateAfterError(ctx, config, modifiedRequest, err); ok {
+		resp, err = a.completer.Complete(ctx, truncated, opts...)
+	}
 	if err != nil {
 		return err
 	}
 
+	if err := checkContentFilter(ctx, config, completionRequest.Agent, resp); err != nil {
+		return err
+	}
+
 	resp, err = a.runAfter(ctx, config, completionRequest, resp)
 	if err != nil {
 		return fmt.Errorf("failed to run after agent: %w", err)
@@ -0,0 +1,69 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/schema"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// maxCachedSchemas bounds the schemas cached for an agent, the tools passed with requests can
+// differ on every call.
+const maxCachedSchemas = 1024
+
+// schemaCache holds the fixed input schemas of the tools of each agent, so that the schemas are not
+// parsed and marshaled again on every turn. The schemas of an agent are dropped when the config of
+// the session changes. Schemas are looked up by their content, a tool whose schema changes without
+// a change of the config, such as the tool of an MCP server that was updated, is fixed again.
+type schemaCache struct {
+	lock   sync.Mutex
+	agents map[string]*agentSchemas
+}
+
+type agentSchemas struct {
+	configHash string
+	fixed      map[string]json.RawMessage
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{
+		agents: map[string]*agentSchemas{},
+	}
+}
+
+// fix returns schema.ValidateAndFixToolSchema of raw for the tools of agent. The result is shared
+// and must not be modified.
+func (c *schemaCache) fix(ctx context.Context, agent string, raw json.RawMessage) json.RawMessage {
+	var configHash string
+	mcp.SessionFromContext(ctx).Get(types.ConfigHashSessionKey, &configHash)
+
+	c.lock.Lock()
+	entry := c.agents[agent]
+	if entry != nil && entry.configHash == configHash {
+		if fixed, ok := entry.fixed[string(raw)]; ok {
+			c.lock.Unlock()
+			return fixed
+		}
+	}
+	c.lock.Unlock()
+
+	fixed := schema.ValidateAndFixToolSchema(raw)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry = c.agents[agent]
+	if entry == nil || entry.configHash != configHash || len(entry.fixed) >= maxCachedSchemas {
+		entry = &agentSchemas{
+			configHash: configHash,
+			fixed:      map[string]json.RawMessage{},
+		}
+		c.agents[agent] = entry
+	}
+	entry.fixed[string(raw)] = fixed
+	// The fixed schema is fixed again when the tools of the request are validated.
+	entry.fixed[string(fixed)] = fixed
+	return fixed
+}
@@ -0,0 +1,131 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+const (
+	defaultSuggestionCount     = 3
+	defaultSuggestionMaxTokens = 100
+)
+
+const suggestionInstructions = `You suggest what the user may want to say next in a conversation with an AI assistant.
+
+- Write %d short follow-up prompts from the point of view of the user, each at most ten words.
+- Base them on the last response of the assistant, such as questions it asked or next steps it offered.
+- Do not repeat what the user already asked.
+
+Respond with only a JSON array of strings.`
+
+// suggest sends prompts the user may want to send next as a suggestions item of the response of
+// the run. Failing to write them does not fail the turn.
+func (a *Agents) suggest(ctx context.Context, config types.Config, agentName string, run *types.Execution, progressToken any) {
+	messages := run.Messages()
+	if starts := turnStarts(messages); len(starts) > 0 {
+		messages = messages[starts[len(starts)-1]:]
+	}
+
+	prompts, err := a.writeSuggestions(ctx, config, agentName, messages)
+	if err != nil {
+		log.Errorf(ctx, "failed to suggest follow-up prompts for agent %s: %v", agentName, err)
+		return
+	} else if len(prompts) == 0 {
+		return
+	}
+
+	progress.Send(ctx, &types.CompletionProgress{
+		Agent:     agentName,
+		MessageID: run.Response.Output.ID,
+		Role:      "assistant",
+		Item: types.CompletionItem{
+			ID: uuid.String(),
+			Suggestions: &types.Suggestions{
+				Prompts: prompts,
+			},
+		},
+	}, progressToken)
+}
+
+// writeSuggestions asks the suggestions agent, or the model of the agent, for follow-up prompts to
+// the messages of the last turn.
+func (a *Agents) writeSuggestions(ctx context.Context, config types.Config, agentName string, messages []types.Message) ([]string, error) {
+	var (
+		agent     = config.Agents[agentName]
+		count     = defaultSuggestionCount
+		maxTokens = defaultSuggestionMaxTokens
+	)
+	if agent.Suggestions.Count > 0 {
+		count = agent.Suggestions.Count
+	}
+	if agent.Suggestions.MaxTokens > 0 {
+		maxTokens = agent.Suggestions.MaxTokens
+	}
+
+	req := types.CompletionRequest{
+		SystemPrompt: fmt.Sprintf(suggestionInstructions, count),
+		MaxTokens:    maxTokens,
+		Input: []types.Message{
+			{
+				Role: "user",
+				Items: []types.CompletionItem{
+					{
+						Content: &mcp.Content{
+							Type: "text",
+							Text: "Conversation:\n\n" + transcript(messages),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var (
+		resp *types.CompletionResponse
+		err  error
+	)
+	if agent.Suggestions.Agent != "" {
+		chat := false
+		req.Model = agent.Suggestions.Agent
+		resp, err = a.Complete(ctx, req, types.CompletionOptions{Chat: &chat})
+	} else {
+		req.Model = agent.Model
+		req.Agent = agentName
+		resp, err = a.completer.Complete(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSuggestions(outputText(resp.Output), count)
+}
+
+// parseSuggestions reads the JSON array of the model, which models sometimes wrap in a code block,
+// and keeps at most count non-empty prompts.
+func parseSuggestions(text string, count int) ([]string, error) {
+	start, end := strings.Index(text, "["), strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse suggestions: no JSON array in %q", text)
+	}
+
+	var prompts []string
+	if err := json.Unmarshal([]byte(text[start:end+1]), &prompts); err != nil {
+		return nil, fmt.Errorf("failed to parse suggestions: %w", err)
+	}
+
+	result := make([]string, 0, count)
+	for _, prompt := range prompts {
+		if prompt = strings.TrimSpace(prompt); prompt != "" && len(result) < count {
+			result = append(result, prompt)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package agents
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type suggester struct {
+	req types.CompletionRequest
+}
+
+func (s *suggester) Complete(_ context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	s.req = req
+	return &types.CompletionResponse{
+		Output: textMessage("assistant", "```json\n"+`["Book the 9am flight", " ", "Show hotels near the center", "What about trains?"]`+"\n```"),
+	}, nil
+}
+
+func TestWriteSuggestions(t *testing.T) {
+	var (
+		completer = &suggester{}
+		a         = &Agents{completer: completer}
+		config    = types.Config{
+			Agents: map[string]types.Agent{
+				"bot": {Model: "gpt-4o", Suggestions: &types.AgentSuggestions{Count: 2}},
+			},
+		}
+	)
+
+	prompts, err := a.writeSuggestions(context.Background(), config, "bot", []types.Message{
+		textMessage("user", "find me a flight to Rome"),
+		textMessage("assistant", "there is one at 9am and one at noon"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"Book the 9am flight", "Show hotels near the center"}; !reflect.DeepEqual(prompts, want) {
+		t.Errorf("got prompts %q, want %q", prompts, want)
+	}
+	if completer.req.MaxTokens != defaultSuggestionMaxTokens {
+		t.Errorf("got max tokens %d, want %d", completer.req.MaxTokens, defaultSuggestionMaxTokens)
+	}
+	if !strings.Contains(completer.req.SystemPrompt, "Write 2 short follow-up prompts") {
+		t.Errorf("unexpected instructions %q", completer.req.SystemPrompt)
+	}
+}
@@ -0,0 +1,193 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/lifecycle"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const summaryInstructions = `You keep a structured summary of a conversation between a user and an AI assistant.
+
+- topics are the subjects the conversation is about, a few words each.
+- decisions are what the user and the assistant agreed on or settled, including chosen options, names, and values.
+- openQuestions are the questions and tasks that are still unanswered or unfinished.
+- If an earlier summary is given, update it with the new messages: keep what still holds, drop questions that were answered, and add what is new.
+- Keep every entry short. Do not add anything that is not in the conversation.
+
+Respond with only a JSON object with the fields "topics", "decisions", and "openQuestions", each a list of strings.`
+
+// summarizeTurn updates the summary of the session with the last turn of the run in the
+// background, so the response is not delayed by it.
+func (a *Agents) summarizeTurn(ctx context.Context, config types.Config, agentName string, session *mcp.Session, run *types.Execution) {
+	messages := run.Messages()
+	starts := turnStarts(messages)
+	if len(starts) == 0 {
+		return
+	}
+	messages = messages[starts[len(starts)-1]:]
+
+	lifecycle.Go(context.WithoutCancel(ctx), session.ID(), lifecycle.KindJob, "update summary", func(ctx context.Context) {
+		var previous types.SessionSummary
+		session.Get(types.SummarySessionKey, &previous)
+
+		summary, err := a.writeSummary(ctx, config, agentName, previous, messages)
+		if err != nil {
+			log.Errorf(ctx, "failed to update summary of session %s: %v", session.ID(), err)
+			return
+		}
+		saveSummary(ctx, session, summary)
+	})
+}
+
+// RefreshSummary rewrites the summary of the session from the whole current thread of the agent
+// and returns it.
+func (a *Agents) RefreshSummary(ctx context.Context, agentName string) (*types.SessionSummary, error) {
+	var (
+		config  = types.ConfigFromContext(ctx)
+		session = mcp.SessionFromContext(ctx)
+		key     = types.PreviousExecutionKey
+	)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	if session == nil {
+		return nil, fmt.Errorf("summarizing requires a session")
+	}
+
+	if threadName := config.Agents[agentName].ThreadName; threadName != "" {
+		key = fmt.Sprintf("%s/%s", key, threadName)
+	}
+
+	var run types.Execution
+	if !session.Get(key, &run) {
+		return nil, fmt.Errorf("the session has no messages to summarize")
+	}
+
+	messages, err := storedHistory(ctx, &run)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, run.Messages()...)
+
+	summary, err := a.writeSummary(ctx, config, agentName, types.SessionSummary{}, messages)
+	if err != nil {
+		return nil, err
+	}
+	saveSummary(ctx, session, summary)
+	return &summary, nil
+}
+
+// saveSummary sets the summary on the session and stores it right away, the session may already
+// have been stored when the summary is updated in the background.
+func saveSummary(ctx context.Context, session *mcp.Session, summary types.SessionSummary) {
+	session.Set(types.SummarySessionKey, summary)
+	if types.IsEphemeral(session) {
+		return
+	}
+
+	var store types.SummaryStore
+	if session.Get(types.ManagerSessionKey, &store) {
+		if err := store.StoreSummary(ctx, session.ID(), summary); err != nil {
+			log.Errorf(ctx, "%v", err)
+		}
+	}
+}
+
+// writeSummary asks the summarizer to merge the messages into the previous summary. The summary
+// agent is used if one is configured, otherwise the model of the agent.
+func (a *Agents) writeSummary(ctx context.Context, config types.Config, agentName string, previous types.SessionSummary, messages []types.Message) (types.SessionSummary, error) {
+	var text strings.Builder
+	if !previous.IsZero() {
+		text.WriteString("Earlier summary:\n\n")
+		text.WriteString(renderSummary(previous))
+		text.WriteString("\n\nNew messages:\n\n")
+	} else {
+		text.WriteString("Conversation:\n\n")
+	}
+	text.WriteString(transcript(messages))
+
+	prompt := types.Message{
+		Role: "user",
+		Items: []types.CompletionItem{
+			{
+				Content: &mcp.Content{
+					Type: "text",
+					Text: text.String(),
+				},
+			},
+		},
+	}
+
+	var (
+		agent = config.Agents[agentName]
+		resp  *types.CompletionResponse
+		err   error
+	)
+	if agent.Summary != nil && agent.Summary.Agent != "" {
+		chat := false
+		resp, err = a.Complete(ctx, types.CompletionRequest{
+			Model:        agent.Summary.Agent,
+			SystemPrompt: summaryInstructions,
+			Input:        []types.Message{prompt},
+		}, types.CompletionOptions{Chat: &chat})
+	} else {
+		resp, err = a.completer.Complete(ctx, types.CompletionRequest{
+			Model:        agent.Model,
+			Agent:        agentName,
+			SystemPrompt: summaryInstructions,
+			Input:        []types.Message{prompt},
+		})
+	}
+	if err != nil {
+		return types.SessionSummary{}, fmt.Errorf("failed to summarize session: %w", err)
+	}
+
+	return parseSummary(outputText(resp.Output))
+}
+
+// parseSummary reads the JSON object of the summarizer, which models sometimes wrap in a code
+// block or a sentence.
+func parseSummary(text string) (types.SessionSummary, error) {
+	var summary types.SessionSummary
+
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return summary, fmt.Errorf("failed to parse summary: no JSON object in %q", text)
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &summary); err != nil {
+		return summary, fmt.Errorf("failed to parse summary: %w", err)
+	}
+
+	summary.Updated = time.Now()
+	return summary, nil
+}
+
+// renderSummary renders the summary as plain text for prompts.
+func renderSummary(summary types.SessionSummary) string {
+	var buf strings.Builder
+	for _, section := range []struct {
+		title   string
+		entries []string
+	}{
+		{"Topics", summary.Topics},
+		{"Decisions", summary.Decisions},
+		{"Open questions", summary.OpenQuestions},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s:\n", section.title)
+		for _, entry := range section.entries {
+			fmt.Fprintf(&buf, "- %s\n", entry)
+		}
+		buf.WriteString("\n")
+	}
+	return strings.TrimSpace(buf.String())
+}
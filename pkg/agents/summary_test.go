@@ -0,0 +1,71 @@
+package agents
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type summaryWriter struct {
+	prompt string
+}
+
+func (s *summaryWriter) Complete(_ context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	s.prompt = outputText(req.Input[0])
+	return &types.CompletionResponse{
+		Output: textMessage("assistant", "```json\n"+`{"topics": ["trip to Rome"], "decisions": ["fly on May 3"], "openQuestions": ["which hotel"]}`+"\n```"),
+	}, nil
+}
+
+func TestWriteSummary(t *testing.T) {
+	var (
+		completer = &summaryWriter{}
+		a         = &Agents{completer: completer}
+		config    = types.Config{
+			Agents: map[string]types.Agent{
+				"bot": {Model: "gpt-4o", Summary: &types.AgentSummary{}},
+			},
+		}
+		previous = types.SessionSummary{
+			Topics:        []string{"trip to Rome"},
+			OpenQuestions: []string{"which day to fly"},
+			Updated:       time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		}
+	)
+
+	summary, err := a.writeSummary(context.Background(), config, "bot", previous, []types.Message{
+		textMessage("user", "let's fly on May 3"),
+		textMessage("assistant", "done, which hotel?"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"Earlier summary", "- which day to fly", "User: let's fly on May 3"} {
+		if !strings.Contains(completer.prompt, want) {
+			t.Errorf("prompt %q does not contain %q", completer.prompt, want)
+		}
+	}
+	if summary.IsZero() {
+		t.Error("the summary has no update time")
+	}
+	summary.Updated = previous.Updated
+	if want := (types.SessionSummary{
+		Topics:        []string{"trip to Rome"},
+		Decisions:     []string{"fly on May 3"},
+		OpenQuestions: []string{"which hotel"},
+		Updated:       previous.Updated,
+	}); !reflect.DeepEqual(summary, want) {
+		t.Errorf("got summary %+v, want %+v", summary, want)
+	}
+}
+
+func TestParseSummaryWithoutJSON(t *testing.T) {
+	if _, err := parseSummary("nothing to summarize"); err == nil {
+		t.Error("expected an error for a response without a JSON object")
+	}
+}
@@ -3,15 +3,30 @@ package agents
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/confirm"
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/servers/outputs"
 	"github.com/nanobot-ai/nanobot/pkg/tools"
 	"github.com/nanobot-ai/nanobot/pkg/types"
 )
 
 func (a *Agents) toolCalls(ctx context.Context, config types.Config, run *types.Execution, opts []types.CompletionOptions) error {
+	type pendingCall struct {
+		item   types.CompletionItem
+		target types.TargetMapping[mcp.Tool]
+		output *types.Message
+		err    error
+	}
+
+	var calls []*pendingCall
 	for _, output := range run.Response.Output.Items {
 		functionCall := output.ToolCall
 
@@ -28,24 +43,83 @@ func (a *Agents) toolCalls(ctx context.Context, config types.Config, run *types.
 			return fmt.Errorf("can not map tool %s to a MCP server", functionCall.Name)
 		}
 
-		callOutput, err := a.invoke(ctx, config, targetServer, tools.ToolCallInvocation{
-			MessageID: run.Response.Output.ID,
-			ItemID:    output.ID,
-			ToolCall:  *functionCall,
-		}, opts)
-		if err != nil {
-			return fmt.Errorf("failed to invoke tool %s on MCP server %s: %w", functionCall.Name, targetServer.MCPServer, err)
+		calls = append(calls, &pendingCall{
+			item:   output,
+			target: targetServer,
+		})
+	}
+
+	// The calls are independent, so they run at the same time, limited by the concurrency of the
+	// runtime and of their servers. A call only takes a slot of the runtime once its server has a
+	// free one, so calls waiting for a busy server do not hold up the others. The results are added
+	// in the order of the calls.
+	var (
+		agent   = config.Agents[run.PopulatedRequest.Agent]
+		limit   = max(a.registry.Concurrency(), 1)
+		servers = map[string]chan struct{}{}
+		wg      sync.WaitGroup
+	)
+	if agent.ParallelToolCalls != nil && !*agent.ParallelToolCalls {
+		limit = 1
+	}
+	workers := make(chan struct{}, limit)
+
+	attribution := types.CallAttribution{
+		Agent: run.PopulatedRequest.Agent,
+	}
+	if len(run.Request.Input) > 0 {
+		attribution.TurnID = run.Request.Input[0].ID
+	}
+	ctx = types.WithCallAttribution(ctx, attribution)
+	for _, call := range calls {
+		if limit := config.MCPServers[call.target.MCPServer].MaxConcurrency; limit > 0 && servers[call.target.MCPServer] == nil {
+			servers[call.target.MCPServer] = make(chan struct{}, limit)
+		}
+	}
+
+	for _, call := range calls {
+		wg.Go(func() {
+			for _, slots := range []chan struct{}{servers[call.target.MCPServer], workers} {
+				if slots == nil {
+					continue
+				}
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				case <-ctx.Done():
+					call.err = ctx.Err()
+					return
+				}
+			}
+			call.output, call.err = a.invoke(ctx, config, agent, call.target, tools.ToolCallInvocation{
+				MessageID: run.Response.Output.ID,
+				ItemID:    call.item.ID,
+				ToolCall:  *call.item.ToolCall,
+			}, opts)
+		})
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, call := range calls {
+		if call.err != nil {
+			errs = append(errs, fmt.Errorf("failed to invoke tool %s on MCP server %s: %w", call.item.ToolCall.Name, call.target.MCPServer, call.err))
+			continue
 		}
 
 		if run.ToolOutputs == nil {
 			run.ToolOutputs = make(map[string]types.ToolOutput)
 		}
 
-		run.ToolOutputs[functionCall.CallID] = types.ToolOutput{
-			Output: *callOutput,
+		run.ToolOutputs[call.item.ToolCall.CallID] = types.ToolOutput{
+			Output: *call.output,
 			Done:   true,
 		}
 	}
+	if len(errs) > 0 {
+		// The outputs of the calls that succeeded are kept, they are not run again.
+		return errs[0]
+	}
 
 	if len(run.ToolOutputs) == 0 {
 		run.Done = true
@@ -54,7 +128,7 @@ func (a *Agents) toolCalls(ctx context.Context, config types.Config, run *types.
 	return nil
 }
 
-func (a *Agents) invoke(ctx context.Context, config types.Config, target types.TargetMapping[mcp.Tool], funcCall tools.ToolCallInvocation, opts []types.CompletionOptions) (*types.Message, error) {
+func (a *Agents) invoke(ctx context.Context, config types.Config, agent types.Agent, target types.TargetMapping[mcp.Tool], funcCall tools.ToolCallInvocation, opts []types.CompletionOptions) (*types.Message, error) {
 	var (
 		data map[string]any
 	)
@@ -66,21 +140,45 @@ func (a *Agents) invoke(ctx context.Context, config types.Config, target types.T
 		}
 	}
 
-	response, err := a.registry.Call(ctx, target.MCPServer, target.TargetName, data, tools.CallOptions{
-		ProgressToken:      complete.Complete(opts...).ProgressToken,
-		ToolCallInvocation: &funcCall,
-	})
+	var (
+		response *types.CallResult
+		approval *types.ToolApproval
+		err      error
+	)
+	if requiresConfirmation(agent, target) {
+		approval, err = confirm.ToolCall(ctx, target.MCPServer, target.Target, funcCall.ToolCall)
+		if approval != nil {
+			log.Infof(ctx, "call of tool %s/%s approved=%v by %q", target.MCPServer, target.TargetName, approval.Approved, approval.User)
+			if !approval.Approved {
+				err = fmt.Errorf("the user did not approve the call")
+			}
+		}
+	}
+	if err == nil {
+		response, err = a.registry.Call(ctx, target.MCPServer, target.TargetName, data, tools.CallOptions{
+			ProgressToken:      complete.Complete(opts...).ProgressToken,
+			ToolCallInvocation: &funcCall,
+		})
+		if err != nil && tools.Policy(config, target.MCPServer, target.TargetName).OnError == types.ToolErrorFail {
+			// The policy of the tool fails the turn instead of letting the model handle the error.
+			return nil, err
+		}
+	}
 	if err != nil {
 		response = &types.CallResult{
 			Content: []mcp.Content{
 				{
 					Type: "text",
-					Text: fmt.Sprintf("Error calling %s: %v", target.TargetName, err),
+					Text: fmt.Sprintf("Error calling %s: %s", target.TargetName, toolErrorText(err)),
 				},
 			},
 			IsError: true,
 		}
 	}
+	if target.MCPServer != outputs.ServerName {
+		response = outputs.Truncate(ctx, target.TargetName, response, tools.Policy(config, target.MCPServer, target.TargetName).MaxOutputSize)
+	}
+	response.Approval = approval
 	return &types.Message{
 		Role: "user",
 		Items: []types.CompletionItem{
@@ -94,3 +192,20 @@ func (a *Agents) invoke(ctx context.Context, config types.Config, target types.T
 		},
 	}, nil
 }
+
+// requiresConfirmation reports if the agent only calls the tool after the user approves it.
+func requiresConfirmation(agent types.Agent, target types.TargetMapping[mcp.Tool]) bool {
+	return slices.ContainsFunc(agent.Confirm, func(ref string) bool {
+		return ref == target.MCPServer || ref == target.MCPServer+"/"+target.TargetName
+	})
+}
+
+// toolErrorText describes a failed tool call to the model. Remediation hints for provider errors
+// are only included when the model can act on them, other hints are meant for the user.
+func toolErrorText(err error) string {
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.ForModel() {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s returned %s %q", apiErr.API, apiErr.Status, apiErr.Body)
+}
@@ -0,0 +1,111 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/tools"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestRequiresConfirmation(t *testing.T) {
+	agent := types.Agent{Confirm: []string{"shell", "filesystem/write_file"}}
+
+	for _, tt := range []struct {
+		server, tool string
+		expected     bool
+	}{
+		{"shell", "exec", true},
+		{"filesystem", "write_file", true},
+		{"filesystem", "read_file", false},
+		{"search", "query", false},
+	} {
+		target := types.TargetMapping[mcp.Tool]{MCPServer: tt.server, TargetName: tt.tool}
+		if got := requiresConfirmation(agent, target); got != tt.expected {
+			t.Errorf("%s/%s: expected %v, got %v", tt.server, tt.tool, tt.expected, got)
+		}
+	}
+}
+
+func TestInvokeWithoutApproval(t *testing.T) {
+	// Without a client that can ask the user the tool is not called, the registry is not needed.
+	a := &Agents{}
+	msg, err := a.invoke(context.Background(), types.Config{}, types.Agent{Confirm: []string{"shell"}},
+		types.TargetMapping[mcp.Tool]{MCPServer: "shell", TargetName: "exec", Target: mcp.Tool{Name: "exec"}},
+		tools.ToolCallInvocation{ToolCall: types.ToolCall{CallID: "call-1", Name: "exec"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := msg.Items[0].ToolCallResult
+	if !result.Output.IsError || !strings.Contains(result.Output.Content[0].Text, "requires approval") {
+		t.Fatalf("expected the call to be rejected, got %+v", result.Output)
+	}
+}
+
+type slowServer struct {
+	mcp.ServerHandler
+}
+
+func TestToolCallsInParallel(t *testing.T) {
+	var (
+		lock              sync.Mutex
+		running, maxSeen  = map[string]int{}, map[string]int{}
+		registry          = tools.NewToolsService(tools.Options{Concurrency: 4})
+		config            = types.Config{MCPServers: map[string]mcp.Server{"fast": {}, "serial": {MaxConcurrency: 1}}}
+		session           = mcp.NewEmptySession(context.Background())
+		a                 = &Agents{registry: registry}
+		run               = &types.Execution{PopulatedRequest: &types.CompletionRequest{}, Response: &types.CompletionResponse{}}
+		toolMappings      = types.ToolMappings{}
+		expectedMaxByName = map[string]int{"fast": 3, "serial": 1}
+	)
+	session.Set(types.ConfigSessionKey, config)
+
+	for _, server := range []string{"fast", "serial"} {
+		registry.AddServer(server, func(string) mcp.MessageHandler {
+			s := &slowServer{}
+			s.Tools = mcp.NewServerTools(mcp.NewServerTool("wait", "Waits", func(ctx context.Context, _ struct{}) (string, error) {
+				lock.Lock()
+				running[server]++
+				maxSeen[server] = max(maxSeen[server], running[server])
+				lock.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				lock.Lock()
+				running[server]--
+				lock.Unlock()
+				return server, nil
+			}))
+			return s
+		})
+		toolMappings[server] = types.TargetMapping[mcp.Tool]{MCPServer: server, TargetName: "wait"}
+	}
+	run.ToolToMCPServer = toolMappings
+
+	for i := range 6 {
+		server := []string{"fast", "serial"}[i%2]
+		run.Response.Output.Items = append(run.Response.Output.Items, types.CompletionItem{
+			ToolCall: &types.ToolCall{CallID: fmt.Sprintf("call-%d", 5-i), Name: server},
+		})
+	}
+
+	if err := a.toolCalls(session.Context(), config, run, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for server, expected := range expectedMaxByName {
+		if maxSeen[server] != expected {
+			t.Errorf("expected at most %d calls of %s at the same time, got %d", expected, server, maxSeen[server])
+		}
+	}
+	if order := toolOutputOrder(run); !slices.Equal(order, []string{"call-5", "call-4", "call-3", "call-2", "call-1", "call-0"}) {
+		t.Errorf("expected the outputs in the order of the calls, got %v", order)
+	}
+}
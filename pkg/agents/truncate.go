@@ -0,0 +1,183 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/tokens"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// defaultReserve is the number of tokens left for the response if the agent does not set maxTokens.
+const defaultReserve = 4096
+
+// truncate drops messages from the history of the request so that its prompt fits the context
+// window of the model, according to the context window settings of the agent. The full history
+// stays in the execution, only the request sent to the model is changed.
+func truncate(ctx context.Context, config types.Config, req types.CompletionRequest) types.CompletionRequest {
+	cw := contextWindow(config, req)
+	if cw.Strategy == types.TruncateDisabled {
+		return req
+	}
+	return truncateTo(ctx, req, cw, promptLimit(req, cw))
+}
+
+// truncateAfterError truncates the request further after the provider rejected it for being too
+// long, which happens if the model counts more tokens than estimated. It reports false if nothing
+// more can be dropped.
+func truncateAfterError(ctx context.Context, config types.Config, req types.CompletionRequest, err error) (types.CompletionRequest, bool) {
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.Kind() != apierror.KindContextLength {
+		return req, false
+	}
+	cw := contextWindow(config, req)
+	if cw.Strategy == types.TruncateDisabled {
+		return req, false
+	}
+	truncated := truncateTo(ctx, req, cw, tokens.CountRequest(req)*3/4)
+	return truncated, len(truncated.Input) < len(req.Input)
+}
+
+func contextWindow(config types.Config, req types.CompletionRequest) types.AgentContextWindow {
+	var cw types.AgentContextWindow
+	if agentCW := config.Agents[req.Agent].ContextWindow; agentCW != nil {
+		cw = *agentCW
+	}
+	if cw.Strategy == "" {
+		cw.Strategy = types.TruncateKeepSystem
+	}
+	return cw
+}
+
+// promptLimit returns the number of tokens available for the prompt, which is the context window
+// without the tokens reserved for the response. It returns 0 if the context window is not known.
+func promptLimit(req types.CompletionRequest, cw types.AgentContextWindow) int {
+	window := cw.MaxTokens
+	if window == 0 {
+		window = tokens.ContextWindow(req.Model)
+	}
+	if window == 0 {
+		return 0
+	}
+
+	reserve := req.MaxTokens
+	if reserve == 0 {
+		reserve = min(defaultReserve, window/4)
+	}
+	return max(window-reserve, 0)
+}
+
+func truncateTo(ctx context.Context, req types.CompletionRequest, cw types.AgentContextWindow, limit int) types.CompletionRequest {
+	var (
+		turns = droppableTurns(req.Input, cw.Strategy)
+		drop  int
+	)
+	if len(turns) == 0 {
+		return req
+	}
+
+	if cw.Strategy == types.TruncateSlidingWindow && cw.Messages > 0 {
+		for drop < len(turns) && len(dropTurns(req.Input, turns[:drop], cw.Strategy)) > cw.Messages {
+			drop++
+		}
+	}
+
+	if limit > 0 {
+		var (
+			counts = make([]int, len(req.Input))
+			total  = tokens.CountRequest(types.CompletionRequest{
+				Model:        req.Model,
+				SystemPrompt: req.SystemPrompt,
+				Tools:        req.Tools,
+			})
+		)
+		for i, msg := range req.Input {
+			counts[i] = tokens.CountMessages(req.Model, msg)
+			total += counts[i]
+		}
+		for _, turn := range turns[:drop] {
+			total -= turn.tokens(req.Input, counts, cw.Strategy)
+		}
+		for drop < len(turns) && total > limit {
+			total -= turns[drop].tokens(req.Input, counts, cw.Strategy)
+			drop++
+		}
+	}
+
+	if drop == 0 {
+		return req
+	}
+
+	input := dropTurns(req.Input, turns[:drop], cw.Strategy)
+	log.Debugf(ctx, "truncated history of agent %s with strategy %s from %d to %d messages", req.Agent, cw.Strategy, len(req.Input), len(input))
+	req.Input = input
+	return req
+}
+
+// turn is the range of messages of the history that starts with a prompt of the user and contains
+// the responses and tool calls that followed it. Turns are dropped as a whole so that tool calls are
+// never separated from their results.
+type turn struct {
+	start, end int
+}
+
+func (t turn) tokens(input []types.Message, counts []int, strategy string) (result int) {
+	for i := t.start; i < t.end; i++ {
+		if !keepMessage(input[i], strategy) {
+			result += counts[i]
+		}
+	}
+	return result
+}
+
+// droppableTurns returns the turns of the history that can be dropped, oldest first. The last turn
+// is the one that is currently being answered and is always kept.
+func droppableTurns(input []types.Message, strategy string) (result []turn) {
+	starts := turnStarts(input)
+	if len(starts) == 0 {
+		return nil
+	}
+
+	if starts[0] > 0 {
+		// Messages before the first prompt, such as the output of a previous agent.
+		result = append(result, turn{start: 0, end: starts[0]})
+	}
+	for i := 0; i < len(starts)-1; i++ {
+		if i == 0 && strategy == types.TruncateKeepSystem {
+			continue
+		}
+		result = append(result, turn{start: starts[i], end: starts[i+1]})
+	}
+	return result
+}
+
+// turnStarts returns the indexes of the prompts of the user in the history, which start the turns.
+func turnStarts(input []types.Message) (starts []int) {
+	for i, msg := range input {
+		if msg.Role == "user" && !slices.ContainsFunc(msg.Items, func(item types.CompletionItem) bool {
+			return item.ToolCallResult != nil
+		}) {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+func dropTurns(input []types.Message, turns []turn, strategy string) []types.Message {
+	result := make([]types.Message, 0, len(input))
+	for i, msg := range input {
+		if keepMessage(msg, strategy) || !slices.ContainsFunc(turns, func(t turn) bool {
+			return i >= t.start && i < t.end
+		}) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func keepMessage(msg types.Message, strategy string) bool {
+	return strategy == types.TruncateKeepSystem && msg.Role == "system"
+}
@@ -0,0 +1,60 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func textMessage(role, text string) types.Message {
+	return types.Message{
+		Role:  role,
+		Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: text}}},
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	long := strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	input := []types.Message{
+		textMessage("system", "be brief"),
+		textMessage("user", "first task "+long),
+		textMessage("assistant", long),
+		textMessage("user", "second "+long),
+		{Role: "assistant", Items: []types.CompletionItem{{ToolCall: &types.ToolCall{Name: "search", CallID: "1"}}}},
+		{Role: "user", Items: []types.CompletionItem{{ToolCallResult: &types.ToolCallResult{CallID: "1", Output: types.CallResult{
+			Content: []mcp.Content{{Type: "text", Text: long}},
+		}}}}},
+		textMessage("assistant", long),
+		textMessage("user", "current"),
+	}
+	req := types.CompletionRequest{Model: "gpt-4o", Input: input}
+
+	tests := []struct {
+		name  string
+		cw    types.AgentContextWindow
+		limit int
+		roles string
+	}{
+		{"fits", types.AgentContextWindow{Strategy: types.TruncateDropOldest}, 100_000, "system user assistant user assistant user assistant user"},
+		{"drop oldest", types.AgentContextWindow{Strategy: types.TruncateDropOldest}, 4_000, "user assistant user assistant user"},
+		{"keep system", types.AgentContextWindow{Strategy: types.TruncateKeepSystem}, 2_500, "system user assistant user"},
+		{"too small", types.AgentContextWindow{Strategy: types.TruncateDropOldest}, 10, "user"},
+		{"sliding window", types.AgentContextWindow{Strategy: types.TruncateSlidingWindow, Messages: 5}, 0, "user assistant user assistant user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := truncateTo(context.Background(), req, tt.cw, tt.limit)
+			var roles []string
+			for _, msg := range result.Input {
+				roles = append(roles, msg.Role)
+			}
+			if got := strings.Join(roles, " "); got != tt.roles {
+				t.Errorf("got messages %q, want %q", got, tt.roles)
+			}
+		})
+	}
+}
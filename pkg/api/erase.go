@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// EraseUserData permanently deletes all data of a user and returns a report of what was deleted.
+// Users can only erase their own data. With ?dryRun=true nothing is deleted and the report lists
+// what would be.
+func (s *server) EraseUserData(rw http.ResponseWriter, req *http.Request) error {
+	userID := req.PathValue("user_id")
+	if nctx := types.NanobotContext(req.Context()); userID == "" || (userID != nctx.User.ID && !nctx.Admin) {
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return nil
+	}
+
+	dryRun, _ := strconv.ParseBool(req.URL.Query().Get("dryRun"))
+	if !dryRun {
+		// Close live sessions first so they are not written back after the erase.
+		if err := s.sessionManager.CloseAccount(req.Context(), userID); err != nil {
+			return err
+		}
+	}
+
+	report, err := s.eraser.Erase(req.Context(), userID, dryRun)
+	if err != nil {
+		return err
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(rw).Encode(report)
+}
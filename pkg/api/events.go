@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/nanobot-ai/nanobot/pkg/eventqueue"
+	"github.com/nanobot-ai/nanobot/pkg/lifecycle"
 	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/types"
@@ -172,30 +174,17 @@ func Events(rw http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
-	events := make(chan mcp.Message)
+	// Events are buffered so a slow browser never blocks the session that sends them.
+	events := eventqueue.New()
 	subClient, err := mcp.NewClient(req.Context(), "nanobot.ui", apiContext.MCPServer, mcp.ClientOption{
 		OnElicit: func(ctx context.Context, msg mcp.Message, _ mcp.ElicitRequest) (mcp.ElicitResult, error) {
-			select {
-			case events <- msg:
-				return mcp.ElicitResult{
-					Action: "handled",
-				}, nil
-			case <-req.Context().Done():
-				return mcp.ElicitResult{}, req.Context().Err()
-			case <-ctx.Done():
-				// I'm pretty sure ctx and req.Context() are the same, but just in case...
-				return mcp.ElicitResult{}, ctx.Err()
-			}
+			events.Push(msg)
+			return mcp.ElicitResult{
+				Action: "handled",
+			}, nil
 		},
 		OnNotify: func(ctx context.Context, msg mcp.Message) error {
-			select {
-			case events <- msg:
-			case <-req.Context().Done():
-				return req.Context().Err()
-			case <-ctx.Done():
-				// I'm pretty sure ctx and req.Context() are the same, but just in case...
-				return ctx.Err()
-			}
+			events.Push(msg)
 			return nil
 		},
 		SessionState: state,
@@ -219,21 +208,29 @@ func Events(rw http.ResponseWriter, req *http.Request) error {
 	ids := map[string]struct{}{}
 	wl := sync.Mutex{}
 
-	go func() {
+	ctx, done := lifecycle.Track(req.Context(), state.ID, lifecycle.KindProgress, "events")
+	defer done()
+
+	lifecycle.Go(ctx, state.ID, lifecycle.KindStream, "history", func(ctx context.Context) {
 		// Transform chat messages into SSE events
 		if err := printHistory(&wl, rw, req, subClient, ids); err != nil {
-			log.Errorf(req.Context(), "failed to print history: %v", err)
+			log.Errorf(ctx, "failed to print history: %v", err)
 		}
-	}()
+	})
 
-	for msg := range events {
-		err := printProgressMessage(&wl, rw, req, msg, subClient, ids)
-		if err != nil {
-			return err
+	// The stream ends with the request or the session.
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-events.Ready():
+			for _, msg := range events.Pop() {
+				if err := printProgressMessage(&wl, rw, req, msg, subClient, ids); err != nil {
+					return err
+				}
+			}
 		}
 	}
-
-	return nil
 }
 
 func printProgressMessage(wl *sync.Mutex, rw http.ResponseWriter, req *http.Request, msg mcp.Message, client *mcp.Client, printedIDs map[string]struct{}) error {
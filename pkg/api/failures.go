@@ -0,0 +1,27 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/failures"
+)
+
+// ToolFailures returns a heat map of failed tool calls by server, tool, and error class. The
+// period defaults to the last 24 hours and can be changed with ?since=DURATION, e.g. since=168h.
+func ToolFailures(rw http.ResponseWriter, req *http.Request) error {
+	since := 24 * time.Hour
+	if v := req.URL.Query().Get("since"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(rw, fmt.Sprintf("invalid since duration %q", v), http.StatusBadRequest)
+			return nil
+		}
+		since = d
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(rw).Encode(failures.Report(time.Now().Add(-since)))
+}
@@ -7,12 +7,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/nanobot-ai/nanobot/pkg/erase"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/session"
 	"github.com/nanobot-ai/nanobot/pkg/types"
 )
 
-func Handler(sessionManager *session.Manager, callBackAddress string) http.Handler {
+func Handler(sessionManager *session.Manager, eraser *erase.Store, callBackAddress string) http.Handler {
 	callBackAddress = strings.ReplaceAll(callBackAddress, "127.0.0.1", "localhost")
 	callBackAddress = strings.ReplaceAll(callBackAddress, "0.0.0.0", "localhost")
 
@@ -21,6 +22,7 @@ func Handler(sessionManager *session.Manager, callBackAddress string) http.Handl
 			BaseURL: fmt.Sprintf("http://%s/mcp/ui", callBackAddress),
 		},
 		sessionManager: sessionManager,
+		eraser:         eraser,
 	}
 	mux := http.NewServeMux()
 
@@ -47,6 +49,7 @@ func cors(h http.Handler) http.Handler {
 type server struct {
 	server         mcp.Server
 	sessionManager *session.Manager
+	eraser         *erase.Store
 }
 
 func (s *server) setupContext(_ http.ResponseWriter, req *http.Request) (Context, error) {
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/quota"
+)
+
+// ProviderQuota returns what is left of the rate limits of each LLM provider, as reported by the
+// headers of its last response.
+func ProviderQuota(rw http.ResponseWriter, _ *http.Request) error {
+	rw.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(rw).Encode(quota.List())
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/rerun"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"gorm.io/gorm"
+)
+
+// auditorRole is the OIDC role that may read the turns of all sessions without being an admin.
+const auditorRole = "auditor"
+
+// TurnSummary describes a turn of a session in the list of turns.
+type TurnSummary struct {
+	Turn     int    `json:"turn"`
+	Model    string `json:"model,omitempty"`
+	Agent    string `json:"agent,omitempty"`
+	Messages int    `json:"messages"`
+	Tools    int    `json:"tools"`
+	// RequestDigest is the SHA-256 of the request of the turn as JSON.
+	RequestDigest string `json:"requestDigest"`
+}
+
+// TurnReplay is what the model saw in a turn and what it answered.
+type TurnReplay struct {
+	SessionID string `json:"sessionID"`
+	AccountID string `json:"accountID,omitempty"`
+	Turn      int    `json:"turn"`
+	// Request is the completion request of the turn: the system prompt, the messages before the
+	// turn, the tool schemas, and the model parameters.
+	Request  types.CompletionRequest `json:"request"`
+	Output   types.Message           `json:"output"`
+	Manifest TurnManifest            `json:"manifest"`
+}
+
+// TurnManifest records where the request of a turn came from, so a review can tie it to a config
+// and to the MCP servers that provided the tools.
+type TurnManifest struct {
+	RequestDigest string `json:"requestDigest"`
+	// ConfigHash is the hash of the config the session ran with.
+	ConfigHash string `json:"configHash,omitempty"`
+	// Tools maps the tools of the request to the MCP server and the name of the tool on it.
+	Tools map[string]string `json:"tools,omitempty"`
+}
+
+// SessionTurns lists the turns of the stored thread of a session.
+func (s *server) SessionTurns(rw http.ResponseWriter, req *http.Request) error {
+	stored, execution, ok, err := s.storedExecution(rw, req)
+	if err != nil || !ok {
+		return err
+	}
+
+	turns := rerun.Turns(execution)
+	result := make([]TurnSummary, 0, len(turns))
+	for _, turn := range turns {
+		digest, err := rerun.Digest(turn.Request)
+		if err != nil {
+			return err
+		}
+		result = append(result, TurnSummary{
+			Turn:          turn.Index,
+			Model:         turn.Request.Model,
+			Agent:         turn.Request.Agent,
+			Messages:      len(turn.Request.Input),
+			Tools:         len(turn.Request.Tools),
+			RequestDigest: digest,
+		})
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(rw).Encode(map[string]any{
+		"sessionID": stored.SessionID,
+		"turns":     result,
+	})
+}
+
+// SessionTurn reconstructs a turn of a session from the stored thread, exactly as it was sent to
+// the model. Negative turns count back from the last turn.
+func (s *server) SessionTurn(rw http.ResponseWriter, req *http.Request) error {
+	index, err := strconv.Atoi(req.PathValue("turn"))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid turn %q", req.PathValue("turn")), http.StatusBadRequest)
+		return nil
+	}
+
+	stored, execution, ok, err := s.storedExecution(rw, req)
+	if err != nil || !ok {
+		return err
+	}
+
+	turn, err := rerun.Find(rerun.Turns(execution), index)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return nil
+	}
+
+	digest, err := rerun.Digest(turn.Request)
+	if err != nil {
+		return err
+	}
+
+	replay := TurnReplay{
+		SessionID: stored.SessionID,
+		AccountID: stored.AccountID,
+		Turn:      turn.Index,
+		Request:   turn.Request,
+		Output:    turn.Output,
+		Manifest: TurnManifest{
+			RequestDigest: digest,
+		},
+	}
+	if hash, ok := stored.State.Attributes[types.ConfigHashSessionKey]; ok {
+		_ = mcp.JSONCoerce(hash, &replay.Manifest.ConfigHash)
+	}
+	for _, tool := range turn.Request.Tools {
+		if mapping, ok := execution.ToolToMCPServer[tool.Name]; ok {
+			if replay.Manifest.Tools == nil {
+				replay.Manifest.Tools = map[string]string{}
+			}
+			replay.Manifest.Tools[tool.Name] = mapping.MCPServer + "/" + mapping.TargetName
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(rw).Encode(replay)
+}
+
+// storedExecution reads the thread of the session of the request from the store. The turns are
+// only readable by admins and auditors, so deployments without authentication cannot read them. ok
+// is false if the request was rejected.
+func (s *server) storedExecution(rw http.ResponseWriter, req *http.Request) (stored *session.Session, execution types.Execution, ok bool, err error) {
+	if nctx := types.NanobotContext(req.Context()); !nctx.Admin && !slices.Contains(nctx.Roles, auditorRole) {
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return nil, execution, false, nil
+	}
+
+	sessionID := req.PathValue("session_id")
+	stored, err = s.sessionManager.DB.Get(req.Context(), sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(rw, fmt.Sprintf("session %s not found", sessionID), http.StatusNotFound)
+		return nil, execution, false, nil
+	} else if err != nil {
+		return nil, execution, false, fmt.Errorf("failed to get session %s: %w", sessionID, err)
+	}
+	if err := s.sessionManager.DB.ExpandHistory(req.Context(), stored); err != nil {
+		return nil, execution, false, err
+	}
+
+	if thread, found := stored.State.Attributes[types.PreviousExecutionKey]; found {
+		if err := mcp.JSONCoerce(thread, &execution); err != nil {
+			return nil, execution, false, fmt.Errorf("failed to decode history of session %s: %w", sessionID, err)
+		}
+	}
+	return stored, execution, true, nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func text(role, s string) types.Message {
+	return types.Message{
+		Role:  role,
+		Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: s}}},
+	}
+}
+
+// replayServer returns a server with a stored session of two turns.
+func replayServer(t *testing.T) *server {
+	t.Helper()
+
+	m, err := session.NewManager(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DB.Create(t.Context(), &session.Session{
+		SessionID: "s1",
+		AccountID: "alice",
+		State: session.State{
+			Attributes: map[string]any{
+				types.PreviousExecutionKey: types.Execution{
+					PopulatedRequest: &types.CompletionRequest{
+						Model:        "gpt-4.1",
+						SystemPrompt: "be nice",
+						Input:        []types.Message{text("user", "hi"), text("assistant", "hello"), text("user", "bye")},
+					},
+					Response: &types.CompletionResponse{Output: text("assistant", "goodbye")},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return &server{sessionManager: m}
+}
+
+func TestSessionTurns(t *testing.T) {
+	s := replayServer(t)
+
+	for _, tt := range []struct {
+		name      string
+		nctx      types.Context
+		sessionID string
+		status    int
+	}{
+		{"anonymous", types.Context{}, "s1", http.StatusForbidden},
+		{"owner", types.Context{User: types.User{ID: "alice"}}, "s1", http.StatusForbidden},
+		{"auditor", types.Context{User: types.User{ID: "bob"}, Roles: []string{auditorRole}}, "s1", http.StatusOK},
+		{"admin", types.Context{User: types.User{ID: "root"}, Admin: true}, "s1", http.StatusOK},
+		{"missing", types.Context{User: types.User{ID: "root"}, Admin: true}, "s2", http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/sessions/"+tt.sessionID+"/turns", nil)
+			req = req.WithContext(types.WithNanobotContext(req.Context(), tt.nctx))
+			req.SetPathValue("session_id", tt.sessionID)
+			rec := httptest.NewRecorder()
+			s.api(s.SessionTurns).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var result struct {
+				SessionID string        `json:"sessionID"`
+				Turns     []TurnSummary `json:"turns"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.SessionID != "s1" || len(result.Turns) != 2 || result.Turns[1].Messages != 3 || result.Turns[1].Model != "gpt-4.1" {
+				t.Errorf("unexpected turns %+v", result)
+			}
+		})
+	}
+}
+
+func TestSessionTurn(t *testing.T) {
+	s := replayServer(t)
+
+	for _, tt := range []struct {
+		name   string
+		nctx   types.Context
+		turn   string
+		status int
+	}{
+		{"anonymous", types.Context{}, "-1", http.StatusForbidden},
+		{"user", types.Context{User: types.User{ID: "bob"}}, "-1", http.StatusForbidden},
+		{"last", types.Context{User: types.User{ID: "root"}, Admin: true}, "-1", http.StatusOK},
+		{"missing turn", types.Context{User: types.User{ID: "root"}, Admin: true}, "2", http.StatusNotFound},
+		{"invalid turn", types.Context{User: types.User{ID: "root"}, Admin: true}, "last", http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/sessions/s1/turns/"+tt.turn, nil)
+			req = req.WithContext(types.WithNanobotContext(req.Context(), tt.nctx))
+			req.SetPathValue("session_id", "s1")
+			req.SetPathValue("turn", tt.turn)
+			rec := httptest.NewRecorder()
+			s.api(s.SessionTurn).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var replay TurnReplay
+			if err := json.Unmarshal(rec.Body.Bytes(), &replay); err != nil {
+				t.Fatal(err)
+			}
+			if replay.SessionID != "s1" || replay.AccountID != "alice" || replay.Turn != 1 ||
+				replay.Request.SystemPrompt != "be nice" || len(replay.Request.Input) != 3 || replay.Manifest.RequestDigest == "" {
+				t.Errorf("unexpected replay %+v", replay)
+			}
+		})
+	}
+}
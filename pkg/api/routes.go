@@ -5,4 +5,10 @@ import "net/http"
 func routes(s *server, mux *http.ServeMux) {
 	mux.Handle("GET /api/events/{thread_id}", s.withContext(Events))
 	mux.Handle("GET /api/version", s.api(Version))
+	mux.Handle("GET /api/tool-failures", s.api(ToolFailures))
+	mux.Handle("GET /api/debug/tasks", s.api(LiveTasks))
+	mux.Handle("GET /api/providers/quota", s.api(ProviderQuota))
+	mux.Handle("DELETE /api/users/{user_id}/data", s.api(s.EraseUserData))
+	mux.Handle("GET /api/sessions/{session_id}/turns", s.api(s.SessionTurns))
+	mux.Handle("GET /api/sessions/{session_id}/turns/{turn}", s.api(s.SessionTurn))
 }
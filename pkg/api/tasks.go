@@ -0,0 +1,15 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nanobot-ai/nanobot/pkg/lifecycle"
+)
+
+// LiveTasks lists the streaming goroutines, progress subscriptions, and background jobs that are
+// running, grouped by session. It can be limited to a session with ?session=ID.
+func LiveTasks(rw http.ResponseWriter, req *http.Request) error {
+	rw.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(rw).Encode(lifecycle.List(req.URL.Query().Get("session")))
+}
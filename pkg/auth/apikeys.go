@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultAPIKeysEnv = "NANOBOT_API_KEYS"
+	// keysFileCheckInterval is how often the key file is checked for changes.
+	keysFileCheckInterval = 5 * time.Second
+)
+
+// apiKeyStore holds the API keys of the config, of the key file, and of the environment. Keys are
+// looked up by their hash, the keys themselves are not kept.
+type apiKeyStore struct {
+	static []types.APIKey
+	file   string
+
+	lock      sync.Mutex
+	keys      map[[sha256.Size]byte]*apiKey
+	fileMod   time.Time
+	fileCheck time.Time
+}
+
+type apiKey struct {
+	types.APIKey
+	limiter *limiter
+}
+
+func newAPIKeyStore(env map[string]string, cfg *types.AuthAPIKeys) (*apiKeyStore, error) {
+	store := &apiKeyStore{
+		static: cfg.Keys,
+		file:   cfg.File,
+	}
+
+	envName := cfg.Env
+	if envName == "" {
+		envName = defaultAPIKeysEnv
+	}
+	value, ok := env[envName]
+	if !ok {
+		value = os.Getenv(envName)
+	}
+	for i, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, key, ok := strings.Cut(entry, "=")
+		if !ok {
+			name, key = envName+"-"+strconv.Itoa(i), entry
+		}
+		store.static = append(store.static, types.APIKey{Name: name, Key: key})
+	}
+
+	var fileKeys []types.APIKey
+	if store.file != "" {
+		var err error
+		fileKeys, store.fileMod, err = readKeysFile(store.file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	keys, err := indexKeys(nil, append(store.static, fileKeys...))
+	if err != nil {
+		return nil, err
+	}
+	store.keys = keys
+	store.fileCheck = time.Now()
+	return store, nil
+}
+
+func readKeysFile(file string) ([]types.APIKey, time.Time, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+	var keys []types.APIKey
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse API keys file %s: %w", file, err)
+	}
+	return keys, info.ModTime(), nil
+}
+
+// indexKeys validates keys and indexes them by hash. The rate limiters of the keys in previous are
+// kept, so reloading the keys does not reset the limits.
+func indexKeys(previous map[[sha256.Size]byte]*apiKey, keys []types.APIKey) (map[[sha256.Size]byte]*apiKey, error) {
+	result := make(map[[sha256.Size]byte]*apiKey, len(keys))
+	for i, key := range keys {
+		if key.Name == "" || key.Key == "" {
+			return nil, fmt.Errorf("API key %d requires a name and a key", i)
+		}
+		if key.User == "" {
+			key.User = "apikey:" + key.Name
+		}
+		hash := sha256.Sum256([]byte(key.Key))
+		if _, ok := result[hash]; ok {
+			return nil, fmt.Errorf("API key %s is not unique", key.Name)
+		}
+		key.Key = ""
+
+		entry := &apiKey{APIKey: key}
+		if old, ok := previous[hash]; ok && old.RateLimit == key.RateLimit {
+			entry.limiter = old.limiter
+		} else if key.RateLimit > 0 {
+			entry.limiter = newLimiter(key.RateLimit, time.Minute)
+		}
+		result[hash] = entry
+	}
+	return result, nil
+}
+
+// lookup returns the key with the value key, reloading the key file first if it changed.
+func (s *apiKeyStore) lookup(req *http.Request, key string) (*apiKey, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file != "" && time.Since(s.fileCheck) > keysFileCheckInterval {
+		s.fileCheck = time.Now()
+		if info, err := os.Stat(s.file); err == nil && !info.ModTime().Equal(s.fileMod) {
+			s.reload(req)
+		}
+	}
+
+	result, ok := s.keys[sha256.Sum256([]byte(key))]
+	return result, ok
+}
+
+func (s *apiKeyStore) reload(req *http.Request) {
+	fileKeys, mod, err := readKeysFile(s.file)
+	if err == nil {
+		var keys map[[sha256.Size]byte]*apiKey
+		keys, err = indexKeys(s.keys, append(s.static, fileKeys...))
+		if err == nil {
+			s.keys, s.fileMod = keys, mod
+			return
+		}
+	}
+	// The previous keys stay valid until the file is fixed.
+	log.Errorf(req.Context(), "failed to reload API keys: %v", err)
+}
+
+// defaultAPIKeyPaths are the paths of the MCP endpoints, of the OpenAI compatible API, and of the
+// gRPC service.
+var defaultAPIKeyPaths = []string{"/mcp", "/v1/", "/nanobot.v1.Nanobot/"}
+
+// apiKeyAuth requires an API key for the requests to the protected paths. Requests with a valid key
+// in the X-API-Key or Authorization header are passed to next as the user of the key. Other requests
+// to the protected paths are passed to fallback, or rejected if there is none. Requests to other
+// paths are passed to unprotected.
+func apiKeyAuth(store *apiKeyStore, paths []string, next, fallback, unprotected http.Handler) http.Handler {
+	if len(paths) == 0 {
+		paths = defaultAPIKeyPaths
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		key := req.Header.Get("X-API-Key")
+		fromHeader := key != ""
+		if !fromHeader {
+			key, _ = strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		}
+
+		if key != "" {
+			if apiKey, ok := store.lookup(req, key); ok {
+				if wait, ok := apiKey.limiter.allow(); !ok {
+					rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+					http.Error(rw, "Too Many Requests", http.StatusTooManyRequests)
+					return
+				}
+				nctx := types.NanobotContext(req.Context())
+				nctx.User = types.User{
+					ID:   apiKey.User,
+					Name: apiKey.Name,
+				}
+				nctx.Agents = apiKey.Agents
+				next.ServeHTTP(rw, req.WithContext(types.WithNanobotContext(req.Context(), nctx)))
+				return
+			} else if fromHeader {
+				http.Error(rw, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		protected := false
+		for _, path := range paths {
+			protected = protected || strings.HasPrefix(req.URL.Path, path)
+		}
+		if !protected {
+			unprotected.ServeHTTP(rw, req)
+			return
+		}
+		if fallback != nil {
+			fallback.ServeHTTP(rw, req)
+			return
+		}
+		rw.Header().Set("WWW-Authenticate", `Bearer realm="nanobot"`)
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// limiter is a token bucket that allows rate requests per interval, in bursts of up to rate.
+type limiter struct {
+	lock     sync.Mutex
+	rate     float64
+	interval time.Duration
+	tokens   float64
+	last     time.Time
+}
+
+func newLimiter(rate int, interval time.Duration) *limiter {
+	return &limiter{
+		rate:     float64(rate),
+		interval: interval,
+		tokens:   float64(rate),
+		last:     time.Now(),
+	}
+}
+
+// allow takes a token, or returns how long to wait for the next one. A nil limiter allows every
+// request.
+func (l *limiter) allow() (time.Duration, bool) {
+	if l == nil {
+		return 0, true
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	perToken := l.interval / time.Duration(l.rate)
+	l.tokens = min(l.rate, l.tokens+float64(now.Sub(l.last))/float64(perToken))
+	l.last = now
+
+	if l.tokens < 1 {
+		return time.Duration((1 - l.tokens) * float64(perToken)), false
+	}
+	l.tokens--
+	return 0, true
+}
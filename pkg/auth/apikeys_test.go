@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestAPIKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(file, []byte("- name: ci\n  key: ci-key\n  agents: [reviewer]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := types.Config{
+		Auth: &types.Auth{
+			APIKeys: &types.AuthAPIKeys{
+				Keys: []types.APIKey{
+					{Name: "bot", Key: "${BOT_KEY}", User: "bot-user", RateLimit: 2},
+				},
+				File: file,
+			},
+		},
+	}
+
+	var got types.Context
+	handler, err := Wrap(map[string]string{
+		"BOT_KEY":          "bot-key",
+		"NANOBOT_API_KEYS": "ops=ops-key",
+	}, cfg, "test.db", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		got = types.NanobotContext(req.Context())
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		status  int
+		user    string
+		agents  []string
+	}{
+		{name: "missing", path: "/mcp", status: http.StatusUnauthorized},
+		{name: "unknown", path: "/mcp", headers: map[string]string{"X-API-Key": "nope"}, status: http.StatusUnauthorized},
+		{name: "unprotected path", path: "/healthz", status: http.StatusOK},
+		{name: "openai", path: "/v1/chat/completions", status: http.StatusUnauthorized},
+		{name: "grpc", path: "/nanobot.v1.Nanobot/Chat", status: http.StatusUnauthorized},
+		{name: "grpc key", path: "/nanobot.v1.Nanobot/Chat", headers: map[string]string{"X-API-Key": "ops-key"}, status: http.StatusOK, user: "apikey:ops"},
+		{name: "config", path: "/mcp", headers: map[string]string{"X-API-Key": "bot-key"}, status: http.StatusOK, user: "bot-user"},
+		{name: "rate limit", path: "/mcp", headers: map[string]string{"Authorization": "Bearer bot-key"}, status: http.StatusOK, user: "bot-user"},
+		{name: "rate limited", path: "/mcp", headers: map[string]string{"X-API-Key": "bot-key"}, status: http.StatusTooManyRequests},
+		{name: "file", path: "/mcp/sse", headers: map[string]string{"Authorization": "Bearer ci-key"}, status: http.StatusOK, user: "apikey:ci", agents: []string{"reviewer"}},
+		{name: "env", path: "/mcp", headers: map[string]string{"X-API-Key": "ops-key"}, status: http.StatusOK, user: "apikey:ops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = types.Context{}
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.user != "" && got.User.ID != tt.user {
+				t.Errorf("expected user %q, got %q", tt.user, got.User.ID)
+			}
+			if len(got.Agents) != len(tt.agents) || (len(tt.agents) > 0 && got.Agents[0] != tt.agents[0]) {
+				t.Errorf("expected agents %v, got %v", tt.agents, got.Agents)
+			}
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(2, time.Second)
+	for i := range 2 {
+		if _, ok := l.allow(); !ok {
+			t.Fatalf("expected request %d to be allowed", i)
+		}
+	}
+	wait, ok := l.allow()
+	if ok || wait <= 0 || wait > 500*time.Millisecond {
+		t.Fatalf("expected to wait up to 500ms, got %s and %v", wait, ok)
+	}
+	time.Sleep(wait)
+	if _, ok := l.allow(); !ok {
+		t.Fatal("expected a token to be refilled")
+	}
+}
@@ -30,7 +30,14 @@ func Wrap(env map[string]string, cfg types.Config, dsn string, next http.Handler
 		return nil, fmt.Errorf("failed to replace variables in auth config: %w", err)
 	}
 
-	result = setupContext(auth, result)
+	for i, token := range auth.Tokens {
+		if token.Token == "" || token.User == "" {
+			return nil, fmt.Errorf("token %d requires a token and a user", i)
+		}
+	}
+
+	next = withAdmins(auth, next)
+	result = setupContext(auth, next)
 
 	if auth.OAuthClientID != "" {
 		if auth.OAuthClientSecret == "" {
@@ -53,6 +60,43 @@ func Wrap(env map[string]string, cfg types.Config, dsn string, next http.Handler
 		panic("not implemented")
 	}
 
+	if len(auth.Tokens) > 0 {
+		// Without OAuth a token is required, the user headers of a proxy are not trusted.
+		var fallback http.Handler
+		if auth.OAuthClientID != "" {
+			fallback = result
+		}
+		result = tokenAuth(auth, next, fallback)
+	}
+
+	if auth.OIDC != nil {
+		login, err := newOIDCLogin(auth)
+		if err != nil {
+			return nil, err
+		}
+		// Bearer tokens and OAuth still authenticate the API, the user headers of a proxy are not
+		// trusted.
+		var fallback http.Handler
+		if len(auth.Tokens) > 0 || auth.OAuthClientID != "" {
+			fallback = result
+		}
+		result = oidcAuth(login, next, fallback)
+	}
+
+	if auth.APIKeys != nil {
+		store, err := newAPIKeyStore(env, auth.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		// Requests to the MCP endpoints without a key can still authenticate with the other methods
+		// that are configured.
+		var fallback http.Handler
+		if len(auth.Tokens) > 0 || auth.OAuthClientID != "" || auth.OIDC != nil {
+			fallback = result
+		}
+		result = apiKeyAuth(store, auth.APIKeys.Paths, next, fallback, result)
+	}
+
 	return result, nil
 }
 
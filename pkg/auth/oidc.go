@@ -0,0 +1,561 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcLoginPath    = "/oidc/login"
+	oidcCallbackPath = "/oidc/callback"
+	oidcLogoutPath   = "/oidc/logout"
+
+	sessionCookie = "nanobot_session"
+	loginCookie   = "nanobot_oidc_login"
+
+	// sessionTTL is how long a login lasts, loginTTL how long the user has to complete the login at
+	// the provider.
+	sessionTTL = 12 * time.Hour
+	loginTTL   = 10 * time.Minute
+
+	// keysMinRefresh is the minimum time between fetches of the signing keys of the provider,
+	// which are refreshed when an ID token is signed with an unknown key.
+	keysMinRefresh = 5 * time.Minute
+
+	roleAdmin   = "admin"
+	roleAuditor = "auditor"
+	roleUser    = "user"
+)
+
+var errUnauthorized = errors.New("unauthorized")
+
+// oidcLogin is an OpenID Connect relying party. It logs users in with the authorization code flow
+// and PKCE, and keeps them logged in with a signed session cookie.
+type oidcLogin struct {
+	cfg    types.AuthOIDC
+	key    []byte
+	client *http.Client
+
+	lock      sync.Mutex
+	provider  *oidcProvider
+	keys      map[string]any
+	keysFetch time.Time
+}
+
+// oidcProvider is the discovered configuration of the issuer.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcSession is the content of the session cookie.
+type oidcSession struct {
+	User    types.User `json:"user"`
+	Admin   bool       `json:"admin,omitempty"`
+	Roles   []string   `json:"roles,omitempty"`
+	Expires int64      `json:"exp"`
+}
+
+// oidcState is the content of the login cookie, which carries the secrets of a login to the
+// callback.
+type oidcState struct {
+	State    string `json:"state"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	Redirect string `json:"redirect"`
+	Expires  int64  `json:"exp"`
+}
+
+func newOIDCLogin(auth *types.Auth) (*oidcLogin, error) {
+	cfg := *auth.OIDC
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("oidc issuer is required")
+	}
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("oidc clientId is required")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	} else if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+
+	// The cookies are signed with a key derived from a secret of the config, so they stay valid
+	// across restarts and replicas.
+	secret := auth.EncryptionKey
+	if secret == "" {
+		secret = cfg.ClientSecret
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("oidc requires a clientSecret or the encryptionKey of auth to sign session cookies")
+	}
+	key := sha256.Sum256([]byte("nanobot-oidc-session:" + strings.TrimSpace(secret)))
+
+	return &oidcLogin{
+		cfg:    cfg,
+		key:    key[:],
+		client: http.DefaultClient,
+	}, nil
+}
+
+// oidcAuth serves the login routes and passes requests with a valid session cookie to next as the
+// logged in user. Browsers without a session are redirected to the login, other requests are
+// passed to fallback, or rejected if there is none.
+func oidcAuth(login *oidcLogin, next, fallback http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+oidcLoginPath, login.login)
+	mux.HandleFunc("GET "+oidcCallbackPath, login.callback)
+	mux.HandleFunc("GET "+oidcLogoutPath, login.logout)
+	mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+		if session, ok := login.session(req); ok {
+			nctx := types.NanobotContext(req.Context())
+			nctx.User = session.User
+			nctx.Admin = session.Admin
+			nctx.Roles = session.Roles
+			next.ServeHTTP(rw, req.WithContext(types.WithNanobotContext(req.Context(), nctx)))
+			return
+		}
+
+		if req.Header.Get("Authorization") == "" && isBrowserNavigation(req) {
+			http.Redirect(rw, req, oidcLoginPath+"?"+url.Values{"rd": {req.URL.RequestURI()}}.Encode(), http.StatusFound)
+			return
+		}
+		if fallback != nil {
+			fallback.ServeHTTP(rw, req)
+			return
+		}
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+	})
+	return mux
+}
+
+// isBrowserNavigation reports if req is a page loaded by a browser, as opposed to a call of the API.
+func isBrowserNavigation(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	if mode := req.Header.Get("Sec-Fetch-Mode"); mode != "" {
+		return mode == "navigate"
+	}
+	return strings.Contains(req.Header.Get("Accept"), "text/html")
+}
+
+func (o *oidcLogin) login(rw http.ResponseWriter, req *http.Request) {
+	provider, err := o.discover(req.Context())
+	if err != nil {
+		log.Errorf(req.Context(), "failed to discover OIDC provider: %v", err)
+		http.Error(rw, "OIDC provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	state := oidcState{
+		State:    rand.Text(),
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    rand.Text(),
+		Redirect: localRedirect(req.URL.Query().Get("rd")),
+		Expires:  time.Now().Add(loginTTL).Unix(),
+	}
+	if err := o.setCookie(rw, req, loginCookie, oidcCallbackPath, state, loginTTL); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	authURL := o.oauth2Config(req, provider).AuthCodeURL(state.State,
+		oauth2.S256ChallengeOption(state.Verifier),
+		oauth2.SetAuthURLParam("nonce", state.Nonce))
+	http.Redirect(rw, req, authURL, http.StatusFound)
+}
+
+func (o *oidcLogin) callback(rw http.ResponseWriter, req *http.Request) {
+	var state oidcState
+	if !o.readCookie(req, loginCookie, &state) || state.Expires < time.Now().Unix() {
+		http.Error(rw, "Login expired, please try again", http.StatusBadRequest)
+		return
+	}
+	clearCookie(rw, loginCookie, oidcCallbackPath)
+
+	query := req.URL.Query()
+	if e := query.Get("error"); e != "" {
+		http.Error(rw, fmt.Sprintf("Login failed: %s %s", e, query.Get("error_description")), http.StatusUnauthorized)
+		return
+	}
+	if !hmac.Equal([]byte(query.Get("state")), []byte(state.State)) {
+		http.Error(rw, "Invalid login state", http.StatusBadRequest)
+		return
+	}
+
+	session, err := o.exchange(req, query.Get("code"), state)
+	if errors.Is(err, errUnauthorized) {
+		log.Infof(req.Context(), "OIDC login rejected: %v", err)
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	} else if err != nil {
+		log.Errorf(req.Context(), "failed to complete OIDC login: %v", err)
+		http.Error(rw, "Login failed", http.StatusBadGateway)
+		return
+	}
+
+	if err := o.setCookie(rw, req, sessionCookie, "/", session, sessionTTL); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(rw, req, state.Redirect, http.StatusFound)
+}
+
+func (o *oidcLogin) logout(rw http.ResponseWriter, req *http.Request) {
+	clearCookie(rw, sessionCookie, "/")
+	http.Redirect(rw, req, "/", http.StatusFound)
+}
+
+// exchange redeems the authorization code and returns the session of the user of the ID token.
+func (o *oidcLogin) exchange(req *http.Request, code string, state oidcState) (*oidcSession, error) {
+	ctx := context.WithValue(req.Context(), oauth2.HTTPClient, o.client)
+
+	provider, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := o.oauth2Config(req, provider).Exchange(ctx, code, oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	idToken, _ := token.Extra("id_token").(string)
+	if idToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return o.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(provider.Issuer),
+		jwt.WithAudience(o.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid id_token: %v", errUnauthorized, err)
+	}
+	if nonce, _ := claims["nonce"].(string); !hmac.Equal([]byte(nonce), []byte(state.Nonce)) {
+		return nil, fmt.Errorf("%w: id_token nonce does not match", errUnauthorized)
+	}
+
+	return o.newSession(claims)
+}
+
+// newSession maps the claims of an ID token to the user and its roles.
+func (o *oidcLogin) newSession(claims jwt.MapClaims) (*oidcSession, error) {
+	var user types.User
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("failed to read id_token claims: %w", err)
+	}
+	user.ID = user.Sub
+	if user.Login == "" {
+		user.Login, _ = claims["preferred_username"].(string)
+	}
+	if user.ID == "" {
+		return nil, fmt.Errorf("%w: id_token has no subject", errUnauthorized)
+	}
+
+	groups := claimStrings(claims[o.cfg.GroupsClaim])
+	var roles []string
+	for _, role := range slices.Sorted(maps.Keys(o.cfg.Roles)) {
+		if slices.ContainsFunc(o.cfg.Roles[role], func(group string) bool {
+			return slices.Contains(groups, group)
+		}) {
+			roles = append(roles, role)
+		}
+	}
+
+	admin := slices.Contains(roles, roleAdmin)
+	if _, restricted := o.cfg.Roles[roleUser]; restricted && !admin && !slices.Contains(roles, roleUser) && !slices.Contains(roles, roleAuditor) {
+		return nil, fmt.Errorf("%w: user %s is not a member of the groups of the user role", errUnauthorized, user.ID)
+	}
+
+	return &oidcSession{
+		User:    user,
+		Admin:   admin,
+		Roles:   roles,
+		Expires: time.Now().Add(sessionTTL).Unix(),
+	}, nil
+}
+
+// claimStrings reads a claim that is a list of strings, or a single string.
+func claimStrings(claim any) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(strings.ReplaceAll(v, ",", " "))
+	case []any:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func (o *oidcLogin) session(req *http.Request) (*oidcSession, bool) {
+	var session oidcSession
+	if !o.readCookie(req, sessionCookie, &session) || session.Expires < time.Now().Unix() {
+		return nil, false
+	}
+	return &session, true
+}
+
+func (o *oidcLogin) oauth2Config(req *http.Request, provider *oidcProvider) *oauth2.Config {
+	redirectURL := o.cfg.RedirectURL
+	if redirectURL == "" {
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+		if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+		redirectURL = scheme + "://" + req.Host + oidcCallbackPath
+	}
+	return &oauth2.Config{
+		ClientID:     o.cfg.ClientID,
+		ClientSecret: o.cfg.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       o.cfg.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.AuthorizationEndpoint,
+			TokenURL: provider.TokenEndpoint,
+		},
+	}
+}
+
+// discover fetches the configuration of the provider once it is first needed.
+func (o *oidcLogin) discover(ctx context.Context) (*oidcProvider, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+
+	var provider oidcProvider
+	if err := o.getJSON(ctx, strings.TrimSuffix(o.cfg.Issuer, "/")+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, fmt.Errorf("failed to get OpenID configuration of %s: %w", o.cfg.Issuer, err)
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != strings.TrimSuffix(o.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("OpenID configuration is for issuer %q, not %q", provider.Issuer, o.cfg.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, fmt.Errorf("OpenID configuration of %s is missing endpoints", o.cfg.Issuer)
+	}
+	o.provider = &provider
+	return o.provider, nil
+}
+
+func (o *oidcLogin) signingKey(ctx context.Context, kid string) (any, error) {
+	provider, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(o.keysFetch) < keysMinRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := o.fetchKeys(ctx, provider.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	o.keys, o.keysFetch = keys, time.Now()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (o *oidcLogin) fetchKeys(ctx context.Context, jwksURI string) (map[string]any, error) {
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := o.getJSON(ctx, jwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to get OIDC signing keys: %w", err)
+	}
+
+	keys := make(map[string]any, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		switch key.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(key.N)
+			if err != nil {
+				continue
+			}
+			e, err := base64.RawURLEncoding.DecodeString(key.E)
+			if err != nil {
+				continue
+			}
+			keys[key.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "EC":
+			var curve elliptic.Curve
+			switch key.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, err := base64.RawURLEncoding.DecodeString(key.X)
+			if err != nil {
+				continue
+			}
+			y, err := base64.RawURLEncoding.DecodeString(key.Y)
+			if err != nil {
+				continue
+			}
+			keys[key.Kid] = &ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}
+		}
+	}
+	return keys, nil
+}
+
+func (o *oidcLogin) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// setCookie stores value in a cookie signed with the key of the login.
+func (o *oidcLogin) setCookie(rw http.ResponseWriter, req *http.Request, name, path string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cookie: %w", err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	http.SetCookie(rw, &http.Cookie{
+		Name:     name,
+		Value:    payload + "." + base64.RawURLEncoding.EncodeToString(o.sign(payload)),
+		Path:     path,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// readCookie reads a cookie written by setCookie into out, it returns false if the cookie is
+// missing or its signature is invalid.
+func (o *oidcLogin) readCookie(req *http.Request, name string, out any) bool {
+	cookie, err := req.Cookie(name)
+	if err != nil {
+		return false
+	}
+	payload, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, o.sign(payload)) {
+		return false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, out) == nil
+}
+
+func (o *oidcLogin) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, o.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func clearCookie(rw http.ResponseWriter, name, path string) {
+	http.SetCookie(rw, &http.Cookie{
+		Name:     name,
+		Path:     path,
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// localRedirect returns redirect if it is a path on this server, so the login can not be used to
+// send users to another site.
+func localRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return "/"
+	}
+	return redirect
+}
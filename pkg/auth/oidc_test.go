@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// fakeProvider is an OpenID Connect provider that logs in the user of the next authorization.
+type fakeProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	user   string
+	groups []string
+	nonces map[string]string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakeProvider{key: key, nonces: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(rw http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(rw).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("GET /keys", func(rw http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(rw).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("code_verifier") == "" {
+			http.Error(rw, "missing code_verifier", http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":    p.URL,
+			"aud":    "nanobot",
+			"sub":    p.user,
+			"email":  p.user + "@example.com",
+			"groups": p.groups,
+			"nonce":  p.nonces[req.FormValue("code")],
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// authorize plays the part of the user at the provider and returns the callback URL.
+func (p *fakeProvider) authorize(t *testing.T, location string) string {
+	t.Helper()
+	authURL, err := url.Parse(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("expected a PKCE challenge, got %s", location)
+	}
+	code := "code-" + p.user
+	p.nonces[code] = query.Get("nonce")
+	return query.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+}
+
+func TestOIDC(t *testing.T) {
+	provider := newFakeProvider(t)
+	cfg := types.Config{
+		Auth: &types.Auth{
+			Tokens: []types.AuthToken{{Token: "service-token", User: "service"}},
+			OIDC: &types.AuthOIDC{
+				Issuer:       provider.URL,
+				ClientID:     "nanobot",
+				ClientSecret: "secret",
+				Roles: map[string]types.StringList{
+					"admin":   {"ops"},
+					"auditor": {"compliance"},
+					"user":    {"staff"},
+				},
+			},
+		},
+	}
+
+	var got types.Context
+	handler, err := Wrap(nil, cfg, "test.db", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		got = types.NanobotContext(req.Context())
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	login := func(t *testing.T, user string, groups ...string) *httptest.ResponseRecorder {
+		provider.user, provider.groups = user, groups
+
+		page := httptest.NewRequest(http.MethodGet, "http://nanobot.test/chat/1", nil)
+		page.Header.Set("Accept", "text/html")
+		rec := serve(page)
+		if rec.Code != http.StatusFound || !strings.HasPrefix(rec.Header().Get("Location"), oidcLoginPath) {
+			t.Fatalf("expected a redirect to the login, got %d %s", rec.Code, rec.Header().Get("Location"))
+		}
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "http://nanobot.test"+rec.Header().Get("Location"), nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("expected a redirect to the provider, got %d: %s", rec.Code, rec.Body)
+		}
+		callback := httptest.NewRequest(http.MethodGet, provider.authorize(t, rec.Header().Get("Location")), nil)
+		for _, cookie := range rec.Result().Cookies() {
+			callback.AddCookie(cookie)
+		}
+		return serve(callback)
+	}
+
+	t.Run("admin", func(t *testing.T) {
+		rec := login(t, "alice", "staff", "ops")
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/chat/1" {
+			t.Fatalf("expected a redirect back to the page, got %d %s: %s", rec.Code, rec.Header().Get("Location"), rec.Body)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "http://nanobot.test/api/chats", nil)
+		for _, cookie := range rec.Result().Cookies() {
+			req.AddCookie(cookie)
+		}
+		got = types.Context{}
+		if rec := serve(req); rec.Code != http.StatusOK {
+			t.Fatalf("expected the session cookie to authenticate, got %d", rec.Code)
+		}
+		if got.User.ID != "alice" || got.User.Email != "alice@example.com" || !got.Admin {
+			t.Errorf("expected admin alice, got %+v", got)
+		}
+	})
+
+	t.Run("auditor", func(t *testing.T) {
+		if rec := login(t, "ada", "compliance"); rec.Code != http.StatusFound {
+			t.Fatalf("expected auditors to log in without the user role, got %d: %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		if rec := login(t, "mallory", "contractors"); rec.Code != http.StatusForbidden {
+			t.Fatalf("expected the login to be rejected, got %d", rec.Code)
+		}
+	})
+
+	t.Run("api", func(t *testing.T) {
+		if rec := serve(httptest.NewRequest(http.MethodGet, "http://nanobot.test/api/chats", nil)); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected API calls without a session to be rejected, got %d", rec.Code)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "http://nanobot.test/api/chats", nil)
+		req.Header.Set("Authorization", "Bearer service-token")
+		got = types.Context{}
+		if rec := serve(req); rec.Code != http.StatusOK || got.User.ID != "service" {
+			t.Errorf("expected the token to authenticate, got %d as %q", rec.Code, got.User.ID)
+		}
+
+		forged := httptest.NewRequest(http.MethodGet, "http://nanobot.test/api/chats", nil)
+		forged.AddCookie(&http.Cookie{Name: sessionCookie, Value: "eyJ1c2VyIjp7InN1YiI6ImV2ZSJ9fQ.c2ln"})
+		if rec := serve(forged); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected a forged cookie to be rejected, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// tokenAuth authenticates requests with the static tokens of the config. Requests with a known
+// token are passed to next as the user of the token. Other requests are passed to fallback, or
+// rejected if there is none.
+func tokenAuth(auth *types.Auth, next, fallback http.Handler) http.Handler {
+	hashes := make([][sha256.Size]byte, len(auth.Tokens))
+	for i, token := range auth.Tokens {
+		hashes[i] = sha256.Sum256([]byte(token.Token))
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if ok && bearer != "" {
+			// Comparing hashes in constant time does not leak the tokens through timing.
+			hash := sha256.Sum256([]byte(bearer))
+			for i, token := range auth.Tokens {
+				if subtle.ConstantTimeCompare(hash[:], hashes[i][:]) != 1 {
+					continue
+				}
+				nctx := types.NanobotContext(req.Context())
+				nctx.User = types.User{
+					ID:    token.User,
+					Email: token.Email,
+					Name:  token.Name,
+				}
+				nctx.Admin = token.Admin
+				next.ServeHTTP(rw, req.WithContext(types.WithNanobotContext(req.Context(), nctx)))
+				return
+			}
+		}
+
+		if fallback != nil {
+			fallback.ServeHTTP(rw, req)
+			return
+		}
+		rw.Header().Set("WWW-Authenticate", `Bearer realm="nanobot"`)
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// withAdmins marks the users listed as admins in the config.
+func withAdmins(auth *types.Auth, next http.Handler) http.Handler {
+	if len(auth.Admins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		nctx := types.NanobotContext(req.Context())
+		if !nctx.Admin && isAdmin(auth, nctx.User) {
+			nctx.Admin = true
+			req = req.WithContext(types.WithNanobotContext(req.Context(), nctx))
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+func isAdmin(auth *types.Auth, user types.User) bool {
+	if user.ID != "" && slices.Contains(auth.Admins, user.ID) {
+		return true
+	}
+	return user.Email != "" && slices.ContainsFunc(auth.Admins, func(admin string) bool {
+		return strings.EqualFold(admin, user.Email)
+	})
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestTokens(t *testing.T) {
+	cfg := types.Config{
+		Auth: &types.Auth{
+			Tokens: []types.AuthToken{
+				{Token: "${ALICE_TOKEN}", User: "alice"},
+				{Token: "root-token", User: "root", Admin: true},
+				{Token: "bob-token", User: "bob", Email: "Bob@example.com"},
+			},
+			Admins: []string{"bob@example.com"},
+		},
+	}
+
+	var got types.Context
+	handler, err := Wrap(map[string]string{"ALICE_TOKEN": "alice-token"}, cfg, "test.db",
+		http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			got = types.NanobotContext(req.Context())
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		user   string
+		admin  bool
+	}{
+		{name: "missing", status: http.StatusUnauthorized},
+		{name: "unknown", header: "Bearer nope", status: http.StatusUnauthorized},
+		{name: "user", header: "Bearer alice-token", status: http.StatusOK, user: "alice"},
+		{name: "admin token", header: "Bearer root-token", status: http.StatusOK, user: "root", admin: true},
+		{name: "admin by email", header: "Bearer bob-token", status: http.StatusOK, user: "bob", admin: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = types.Context{}
+			req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+			// The user headers of a proxy must not authenticate a request.
+			req.Header.Set("X-Forwarded-Id", "mallory")
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if got.User.ID != tt.user || got.Admin != tt.admin {
+				t.Errorf("expected user %q with admin %v, got %q with admin %v", tt.user, tt.admin, got.User.ID, got.Admin)
+			}
+		})
+	}
+}
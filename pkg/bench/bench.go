@@ -0,0 +1,289 @@
+// Package bench drives concurrent synthetic sessions against a running nanobot to measure its
+// capacity. It is meant to be used with an instance that answers completions with the mock
+// provider, so that the runtime and the session store are measured rather than the LLM.
+//
+// RunProviders measures the LLM instead, comparing the streamed responses of providers and models
+// to the same prompts.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+// Stages of a session. The time to the first progress notification of a turn is only reported if
+// the called tool streams progress.
+const (
+	StageInitialize    = "initialize"
+	StageListTools     = "list_tools"
+	StageFirstProgress = "first_progress"
+	StageTurn          = "turn"
+)
+
+// stages is the order stages are reported in.
+var stages = []string{StageInitialize, StageListTools, StageFirstProgress, StageTurn}
+
+type Options struct {
+	// Sessions is the number of concurrent sessions.
+	Sessions int
+	// Turns is the number of prompts sent in each session.
+	Turns int
+	// Prompt is sent in every turn.
+	Prompt string
+	// Tool is called in every turn, defaults to the first tool of the instance.
+	Tool string
+	// MetricsURL is the Prometheus endpoint of the instance, scraped to report store contention.
+	MetricsURL string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Sessions = complete.Last(o.Sessions, other.Sessions)
+	result.Turns = complete.Last(o.Turns, other.Turns)
+	result.Prompt = complete.Last(o.Prompt, other.Prompt)
+	result.Tool = complete.Last(o.Tool, other.Tool)
+	result.MetricsURL = complete.Last(o.MetricsURL, other.MetricsURL)
+	return
+}
+
+func (o Options) Complete() Options {
+	if o.Sessions <= 0 {
+		o.Sessions = 10
+	}
+	if o.Turns <= 0 {
+		o.Turns = 5
+	}
+	if o.Prompt == "" {
+		o.Prompt = "Hello, this is a load test."
+	}
+	return o
+}
+
+type Report struct {
+	Sessions int           `json:"sessions"`
+	Turns    int           `json:"turns"`
+	Errors   int           `json:"errors"`
+	Duration time.Duration `json:"duration"`
+	// Throughput is the number of successful turns per second.
+	Throughput float64 `json:"throughput"`
+	Stages     []Stage `json:"stages"`
+	// Store holds the operations of the session store during the run, if metrics were scraped.
+	Store []StoreOperation `json:"store,omitempty"`
+	// FirstError is an example of the errors, if there were any.
+	FirstError string `json:"firstError,omitempty"`
+}
+
+// Stage holds the latency percentiles of a stage of the sessions.
+type Stage struct {
+	Name  string        `json:"name"`
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// StoreOperation is the activity of the session store for an operation, load or save.
+type StoreOperation struct {
+	Operation string        `json:"operation"`
+	Count     int           `json:"count"`
+	Errors    int           `json:"errors"`
+	Mean      time.Duration `json:"mean"`
+}
+
+type recorder struct {
+	lock       sync.Mutex
+	samples    map[string][]time.Duration
+	turns      int
+	errors     int
+	firstError error
+}
+
+func (r *recorder) observe(stage string, d time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.samples[stage] = append(r.samples[stage], d)
+	if stage == StageTurn {
+		r.turns++
+	}
+}
+
+func (r *recorder) fail(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.errors++
+	if r.firstError == nil {
+		r.firstError = err
+	}
+}
+
+// Run starts the sessions against the MCP endpoint at url and waits until all of them finished
+// their turns or ctx is done.
+func Run(ctx context.Context, url string, opts ...Options) (*Report, error) {
+	opt := complete.Complete(opts...)
+
+	var before map[string]storeTotals
+	if opt.MetricsURL != "" {
+		var err error
+		before, err = scrapeStore(ctx, opt.MetricsURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rec := &recorder{samples: map[string][]time.Duration{}}
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for range opt.Sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := runSession(ctx, url, opt, rec); err != nil {
+				rec.fail(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{
+		Sessions: opt.Sessions,
+		Turns:    rec.turns,
+		Errors:   rec.errors,
+		Duration: time.Since(start),
+	}
+	if rec.firstError != nil {
+		report.FirstError = rec.firstError.Error()
+	}
+	if report.Duration > 0 {
+		report.Throughput = float64(rec.turns) / report.Duration.Seconds()
+	}
+	for _, name := range stages {
+		if samples := rec.samples[name]; len(samples) > 0 {
+			report.Stages = append(report.Stages, newStage(name, samples))
+		}
+	}
+
+	if opt.MetricsURL != "" {
+		after, err := scrapeStore(ctx, opt.MetricsURL)
+		if err != nil {
+			return nil, err
+		}
+		report.Store = storeDelta(before, after)
+	}
+	return report, nil
+}
+
+func runSession(ctx context.Context, url string, opt Options, rec *recorder) error {
+	var (
+		progressLock sync.Mutex
+		turnStart    time.Time
+		seen         bool
+	)
+
+	start := time.Now()
+	client, err := mcp.NewClient(ctx, "bench", mcp.Server{BaseURL: url}, mcp.ClientOption{
+		ClientName: "nanobot-bench",
+		OnNotify: func(ctx context.Context, msg mcp.Message) error {
+			if msg.Method != "notifications/progress" {
+				return nil
+			}
+			progressLock.Lock()
+			defer progressLock.Unlock()
+			if !seen && !turnStart.IsZero() {
+				seen = true
+				rec.observe(StageFirstProgress, time.Since(turnStart))
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize session: %w", err)
+	}
+	defer client.Close(true)
+	rec.observe(StageInitialize, time.Since(start))
+
+	start = time.Now()
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tools: %w", err)
+	}
+	rec.observe(StageListTools, time.Since(start))
+
+	tool := opt.Tool
+	if tool == "" {
+		if len(tools.Tools) == 0 {
+			return errors.New("the instance has no tools to call")
+		}
+		tool = tools.Tools[0].Name
+	} else if !slices.ContainsFunc(tools.Tools, func(t mcp.Tool) bool { return t.Name == tool }) {
+		return fmt.Errorf("the instance has no tool %q", tool)
+	}
+
+	for range opt.Turns {
+		progressLock.Lock()
+		turnStart, seen = time.Now(), false
+		progressLock.Unlock()
+
+		result, err := client.Call(ctx, tool, types.SampleCallRequest{Prompt: opt.Prompt}, mcp.CallOption{
+			ProgressToken: uuid.String(),
+		})
+		if err == nil && result.IsError {
+			err = fmt.Errorf("tool %s returned an error: %s", tool, resultText(result))
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			rec.fail(err)
+			continue
+		}
+
+		progressLock.Lock()
+		rec.observe(StageTurn, time.Since(turnStart))
+		turnStart = time.Time{}
+		progressLock.Unlock()
+	}
+	return nil
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if content.Text != "" {
+			return content.Text
+		}
+	}
+	data, _ := json.Marshal(result.Content)
+	return string(data)
+}
+
+func newStage(name string, samples []time.Duration) Stage {
+	slices.Sort(samples)
+	return Stage{
+		Name:  name,
+		Count: len(samples),
+		P50:   percentile(samples, 0.50),
+		P90:   percentile(samples, 0.90),
+		P99:   percentile(samples, 0.99),
+		Max:   samples[len(samples)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile p of the sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
@@ -0,0 +1,47 @@
+package bench
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var samples []time.Duration
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	stage := newStage("turn", samples)
+	if stage.P50 != 50*time.Millisecond || stage.P90 != 90*time.Millisecond || stage.P99 != 99*time.Millisecond || stage.Max != 100*time.Millisecond {
+		t.Errorf("unexpected percentiles %+v", stage)
+	}
+}
+
+func TestStoreDelta(t *testing.T) {
+	before, err := parseStore(strings.NewReader(`# HELP nanobot_session_store_duration_seconds Duration.
+nanobot_session_store_duration_seconds_bucket{operation="save",status="ok",le="0.001"} 1
+nanobot_session_store_duration_seconds_sum{operation="save",status="ok"} 0.5
+nanobot_session_store_duration_seconds_count{operation="save",status="ok"} 10
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := parseStore(strings.NewReader(`nanobot_session_store_duration_seconds_sum{operation="save",status="ok"} 2.5
+nanobot_session_store_duration_seconds_count{operation="save",status="ok"} 48
+nanobot_session_store_duration_seconds_sum{operation="save",status="error"} 0.1
+nanobot_session_store_duration_seconds_count{operation="save",status="error"} 2
+nanobot_session_store_duration_seconds_sum{operation="load",status="ok"} 0
+nanobot_session_store_duration_seconds_count{operation="load",status="ok"} 0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delta := storeDelta(before, after)
+	if len(delta) != 1 {
+		t.Fatalf("expected only the save operation, got %+v", delta)
+	}
+	if delta[0].Operation != "save" || delta[0].Count != 40 || delta[0].Errors != 2 || delta[0].Mean != 52500*time.Microsecond {
+		t.Errorf("unexpected store operation %+v", delta[0])
+	}
+}
@@ -0,0 +1,113 @@
+package bench
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const storeMetric = "nanobot_session_store_duration_seconds"
+
+// storeTotals are the cumulative values of the store histogram for an operation.
+type storeTotals struct {
+	count  float64
+	errors float64
+	sum    float64
+}
+
+// scrapeStore reads the totals of the session store histogram from the metrics of the instance.
+func scrapeStore(ctx context.Context, url string) (map[string]storeTotals, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrape metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to scrape metrics: %s", resp.Status)
+	}
+	return parseStore(resp.Body)
+}
+
+// parseStore parses the _sum and _count series of the store histogram in the Prometheus text
+// format.
+func parseStore(r io.Reader) (map[string]storeTotals, error) {
+	result := map[string]storeTotals{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, storeMetric) {
+			continue
+		}
+		name, rest, ok := strings.Cut(line, "{")
+		if !ok {
+			continue
+		}
+		labelText, valueText, ok := strings.Cut(rest, "} ")
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.Fields(valueText)[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metric %q: %w", line, err)
+		}
+
+		labels := parseLabels(labelText)
+		totals := result[labels["operation"]]
+		switch name {
+		case storeMetric + "_sum":
+			totals.sum += value
+		case storeMetric + "_count":
+			totals.count += value
+			if labels["status"] == "error" {
+				totals.errors += value
+			}
+		default:
+			continue
+		}
+		result[labels["operation"]] = totals
+	}
+	return result, scanner.Err()
+}
+
+func parseLabels(text string) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(text, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok {
+			labels[strings.TrimSpace(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return labels
+}
+
+// storeDelta returns the store operations between two scrapes.
+func storeDelta(before, after map[string]storeTotals) []StoreOperation {
+	var result []StoreOperation
+	for operation, totals := range after {
+		count := totals.count - before[operation].count
+		if count <= 0 {
+			continue
+		}
+		result = append(result, StoreOperation{
+			Operation: operation,
+			Count:     int(count),
+			Errors:    int(totals.errors - before[operation].errors),
+			Mean:      time.Duration((totals.sum - before[operation].sum) / count * float64(time.Second)),
+		})
+	}
+	slices.SortFunc(result, func(a, b StoreOperation) int {
+		return strings.Compare(a.Operation, b.Operation)
+	})
+	return result
+}
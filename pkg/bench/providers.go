@@ -0,0 +1,255 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/pricing"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+type ProviderOptions struct {
+	// Models are the models compared. The provider of a model is selected by its name, as for the
+	// completions of agents.
+	Models []string
+	// Prompts are sent to every model, each in a new conversation.
+	Prompts []string
+	// Runs is the number of times each prompt is sent to each model.
+	Runs int
+	// Concurrency is the number of requests in flight at the same time. Requests run one at a
+	// time by default so they do not compete for the rate limits of the providers.
+	Concurrency int
+	// MaxTokens limits the length of the responses, unlimited if zero.
+	MaxTokens int
+	// Pricing is used to estimate the cost of the responses.
+	Pricing pricing.Table
+}
+
+func (o ProviderOptions) Merge(other ProviderOptions) (result ProviderOptions) {
+	result.Models = o.Models
+	if len(other.Models) > 0 {
+		result.Models = other.Models
+	}
+	result.Prompts = o.Prompts
+	if len(other.Prompts) > 0 {
+		result.Prompts = other.Prompts
+	}
+	result.Runs = complete.Last(o.Runs, other.Runs)
+	result.Concurrency = complete.Last(o.Concurrency, other.Concurrency)
+	result.MaxTokens = complete.Last(o.MaxTokens, other.MaxTokens)
+	result.Pricing = complete.MergeMap(o.Pricing, other.Pricing)
+	return
+}
+
+func (o ProviderOptions) Complete() ProviderOptions {
+	if len(o.Prompts) == 0 {
+		o.Prompts = []string{"Explain in three paragraphs how a hash map works."}
+	}
+	if o.Runs <= 0 {
+		o.Runs = 3
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	return o
+}
+
+type ProviderReport struct {
+	Duration time.Duration `json:"duration"`
+	// Models summarizes the responses of each model, in the order the models were given.
+	Models    []ModelSummary `json:"models"`
+	Responses []Response     `json:"responses"`
+}
+
+// ModelSummary holds the percentiles of the responses of a model that did not fail.
+type ModelSummary struct {
+	Model     string `json:"model"`
+	Responses int    `json:"responses"`
+	Errors    int    `json:"errors"`
+	// TTFT is the time to the first streamed token. It is only reported if the provider streamed
+	// the responses.
+	TTFTP50    time.Duration `json:"ttftP50,omitempty"`
+	TTFTP90    time.Duration `json:"ttftP90,omitempty"`
+	LatencyP50 time.Duration `json:"latencyP50"`
+	LatencyP90 time.Duration `json:"latencyP90"`
+	// TokensPerSecond is the mean rate output tokens were generated at.
+	TokensPerSecond float64 `json:"tokensPerSecond"`
+	// OutputTokens is the mean number of output tokens of a response.
+	OutputTokens int `json:"outputTokens"`
+	// CostPerResponse is the mean cost of a response in USD, if the price of the model is known.
+	CostPerResponse float64 `json:"costPerResponse,omitempty"`
+	// FirstError is an example of the errors, if there were any.
+	FirstError string `json:"firstError,omitempty"`
+}
+
+// Response is the measurement of a single response.
+type Response struct {
+	Model string `json:"model"`
+	// Prompt is the index of the prompt in the options.
+	Prompt  int           `json:"prompt"`
+	Run     int           `json:"run"`
+	TTFT    time.Duration `json:"ttft,omitempty"`
+	Latency time.Duration `json:"latency"`
+	// TokensPerSecond is the number of output tokens divided by the time from the first streamed
+	// token to the end of the response, or by the latency if the response was not streamed.
+	TokensPerSecond float64     `json:"tokensPerSecond"`
+	Usage           types.Usage `json:"usage"`
+	Cost            float64     `json:"cost,omitempty"`
+	Error           string      `json:"error,omitempty"`
+}
+
+// RunProviders sends every prompt to every model and measures the responses. Prompts are sent to
+// the models in turn, so that the models are measured over the same period of time.
+func RunProviders(ctx context.Context, completer types.Completer, opts ...ProviderOptions) (*ProviderReport, error) {
+	opt := complete.Complete(opts...)
+	if len(opt.Models) == 0 {
+		return nil, errors.New("no models to benchmark")
+	}
+
+	var jobs []Response
+	for run := range opt.Runs {
+		for prompt := range opt.Prompts {
+			for _, model := range opt.Models {
+				jobs = append(jobs, Response{Model: model, Prompt: prompt, Run: run})
+			}
+		}
+	}
+
+	var (
+		start = time.Now()
+		sem   = make(chan struct{}, opt.Concurrency)
+		wg    sync.WaitGroup
+	)
+	for i := range jobs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			measure(ctx, completer, opt, &jobs[i])
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &ProviderReport{
+		Duration:  time.Since(start),
+		Responses: jobs,
+	}
+	for _, model := range opt.Models {
+		report.Models = append(report.Models, summarize(model, jobs))
+	}
+	return report, nil
+}
+
+// measure sends the prompt of the response to its model and records the measurements in it.
+func measure(ctx context.Context, completer types.Completer, opt ProviderOptions, response *Response) {
+	var (
+		lock  sync.Mutex
+		first time.Time
+		start = time.Now()
+	)
+	ctx = progress.WithListener(ctx, func(p *types.CompletionProgress) {
+		if p.Role == "user" || (p.Item.Content == nil && p.Item.Reasoning == nil && p.Item.ToolCall == nil) {
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if first.IsZero() {
+			first = time.Now()
+		}
+	})
+
+	resp, err := completer.Complete(ctx, types.CompletionRequest{
+		Model:     response.Model,
+		MaxTokens: opt.MaxTokens,
+		Input: []types.Message{{
+			Role: "user",
+			Items: []types.CompletionItem{{
+				Content: &mcp.Content{
+					Type: "text",
+					Text: opt.Prompts[response.Prompt],
+				},
+			}},
+		}},
+	}, types.CompletionOptions{
+		ProgressToken: uuid.String(),
+	})
+	end := time.Now()
+	response.Latency = end.Sub(start)
+	if err != nil {
+		response.Error = err.Error()
+		return
+	}
+
+	if resp.Usage != nil {
+		response.Usage = *resp.Usage
+	}
+	response.Cost = opt.Pricing.Cost(response.Model, response.Usage)
+
+	lock.Lock()
+	generation := response.Latency
+	if !first.IsZero() {
+		response.TTFT = first.Sub(start)
+		generation = end.Sub(first)
+	}
+	lock.Unlock()
+	if generation > 0 {
+		response.TokensPerSecond = float64(response.Usage.OutputTokens) / generation.Seconds()
+	}
+}
+
+func summarize(model string, responses []Response) ModelSummary {
+	var (
+		summary         = ModelSummary{Model: model}
+		ttft, latencies []time.Duration
+		tokensPerSecond float64
+		outputTokens    int
+		cost            float64
+	)
+	for _, response := range responses {
+		if response.Model != model {
+			continue
+		}
+		if response.Error != "" {
+			summary.Errors++
+			summary.FirstError = complete.First(summary.FirstError, response.Error)
+			continue
+		}
+		summary.Responses++
+		latencies = append(latencies, response.Latency)
+		if response.TTFT > 0 {
+			ttft = append(ttft, response.TTFT)
+		}
+		tokensPerSecond += response.TokensPerSecond
+		outputTokens += response.Usage.OutputTokens
+		cost += response.Cost
+	}
+	if summary.Responses == 0 {
+		return summary
+	}
+
+	slices.Sort(ttft)
+	slices.Sort(latencies)
+	summary.TTFTP50, summary.TTFTP90 = percentile(ttft, 0.50), percentile(ttft, 0.90)
+	summary.LatencyP50, summary.LatencyP90 = percentile(latencies, 0.50), percentile(latencies, 0.90)
+	summary.TokensPerSecond = tokensPerSecond / float64(summary.Responses)
+	summary.OutputTokens = outputTokens / summary.Responses
+	summary.CostPerResponse = cost / float64(summary.Responses)
+	return summary
+}
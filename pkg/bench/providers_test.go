@@ -0,0 +1,71 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/pricing"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// streamingCompleter streams a response after a delay, or fails for the model "broken".
+type streamingCompleter struct {
+	firstToken, generation time.Duration
+}
+
+func (s streamingCompleter) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	if req.Model == "broken" {
+		return nil, errors.New("provider unavailable")
+	}
+	time.Sleep(s.firstToken)
+	for _, opt := range opts {
+		progress.Send(ctx, &types.CompletionProgress{
+			Role: "assistant",
+			Item: types.CompletionItem{Partial: true, Content: &mcp.Content{Type: "text", Text: "Hi"}},
+		}, opt.ProgressToken)
+	}
+	time.Sleep(s.generation)
+	return &types.CompletionResponse{
+		Model: req.Model,
+		Usage: &types.Usage{InputTokens: 1000, OutputTokens: 100},
+	}, nil
+}
+
+func TestRunProviders(t *testing.T) {
+	report, err := RunProviders(t.Context(), streamingCompleter{firstToken: 20 * time.Millisecond, generation: 100 * time.Millisecond}, ProviderOptions{
+		Models:      []string{"fast", "broken"},
+		Prompts:     []string{"a", "b"},
+		Runs:        2,
+		Concurrency: 4,
+		Pricing:     pricing.Table{"fast": {Input: 2, Output: 8}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Responses) != 8 || report.Responses[1].Model != "broken" || report.Responses[2].Prompt != 1 || report.Responses[4].Run != 1 {
+		t.Fatalf("unexpected responses %+v", report.Responses)
+	}
+
+	fast, broken := report.Models[0], report.Models[1]
+	if broken.Responses != 0 || broken.Errors != 4 || broken.FirstError != "provider unavailable" {
+		t.Errorf("unexpected summary of the failing model %+v", broken)
+	}
+	if fast.Responses != 4 || fast.Errors != 0 {
+		t.Fatalf("unexpected summary %+v", fast)
+	}
+	if fast.TTFTP50 < 20*time.Millisecond || fast.TTFTP50 > fast.LatencyP50-90*time.Millisecond {
+		t.Errorf("unexpected time to first token %s with latency %s", fast.TTFTP50, fast.LatencyP50)
+	}
+	// 100 tokens generated in a bit more than 100ms.
+	if fast.TokensPerSecond > 1000 || fast.TokensPerSecond < 500 {
+		t.Errorf("unexpected throughput %.1f tokens/s", fast.TokensPerSecond)
+	}
+	if want := (1000*2 + 100*8) / 1_000_000.0; fast.CostPerResponse != want {
+		t.Errorf("got cost %v, want %v", fast.CostPerResponse, want)
+	}
+}
@@ -0,0 +1,348 @@
+// Package channel runs agent turns for messages received from chat apps such as Microsoft Teams,
+// Telegram, or WhatsApp. Every sender gets a nanobot session per conversation, which is driven by
+// the messages they send.
+package channel
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
+	"github.com/nanobot-ai/nanobot/pkg/tools"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"gorm.io/gorm"
+)
+
+// Options are shared by the chat apps and embedded in the options of each bot.
+type Options struct {
+	// Agent answers the messages, the default agent of the config is used if not set.
+	Agent string
+	// DSN is the database attachments are stored in.
+	DSN string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Agent = complete.Last(o.Agent, other.Agent)
+	result.DSN = complete.Last(o.DSN, other.DSN)
+	return
+}
+
+// Message is a message received from a chat app.
+type Message struct {
+	// Channel is the name of the chat app shown to users, such as "Telegram".
+	Channel string
+	// SessionType is the type of the session created for the conversation.
+	SessionType string
+	SessionID   string
+	User        types.User
+	// Description of the session if it is created, usually the name of the conversation.
+	Description string
+	Prompt      string
+	Files       []File
+	// ProgressToken is sent with the progress notifications of the turn. Filter sees every message
+	// of the session during the turn, which can be used to stream the response.
+	ProgressToken string
+	Filter        mcp.MessageFilter
+	// Attributes are stored in the session before the turn, so that the tools of the chat app can
+	// read them.
+	Attributes map[string]any
+	// Agent answers the message unless another agent was selected in the session, such as an agent
+	// the sender picked for all conversations in a channel.
+	Agent string
+}
+
+// File is a file attached to a message.
+type File struct {
+	Name     string
+	MimeType string
+	Data     []byte
+}
+
+// Response is the final response of the agent to a message.
+type Response struct {
+	// Text is the markdown of all text content of the response.
+	Text   string
+	Images []mcp.Content
+}
+
+// Runner drives the sessions of a chat app.
+type Runner struct {
+	opt       Options
+	runtime   *runtime.Runtime
+	config    types.ConfigFactory
+	sessions  *session.Manager
+	server    mcp.MessageHandler
+	data      *sessiondata.Data
+	resources *resources.Store
+
+	locksLock sync.Mutex
+	locks     map[string]*sync.Mutex
+}
+
+func NewRunner(runt *runtime.Runtime, config types.ConfigFactory, sessions *session.Manager, server mcp.MessageHandler, opts ...Options) (*Runner, error) {
+	opt := complete.Complete(opts...)
+
+	var store *resources.Store
+	if opt.DSN != "" {
+		var err error
+		store, err = resources.NewStoreFromDSN(opt.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create resources store: %w", err)
+		}
+	}
+
+	return &Runner{
+		opt:       opt,
+		runtime:   runt,
+		config:    config,
+		sessions:  sessions,
+		server:    server,
+		data:      sessiondata.NewData(runt),
+		resources: store,
+		locks:     map[string]*sync.Mutex{},
+	}, nil
+}
+
+// Run sends msg to the agent as the sender of the message and returns the response. Turns of the
+// same session are run one after the other.
+func (r *Runner) Run(ctx context.Context, msg Message) (*Response, error) {
+	nctx := types.NanobotContext(ctx)
+	nctx.User = msg.User
+	nctx.Config = r.config
+	ctx = types.WithNanobotContext(ctx, nctx)
+
+	lock := r.lock(msg.SessionID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	serverSession, err := r.acquire(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	defer r.sessions.Release(serverSession)
+
+	ctx = mcp.WithSession(ctx, serverSession.GetSession())
+	for key, value := range msg.Attributes {
+		serverSession.GetSession().Set(key, value)
+	}
+	if err := r.data.Sync(ctx, r.config); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	attachments, err := r.attachments(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Prompt == "" && len(attachments) == 0 {
+		return nil, fmt.Errorf("the message is empty")
+	}
+
+	if msg.Filter != nil {
+		defer serverSession.GetSession().AddFilter(msg.Filter)()
+	}
+
+	// An agent selected in the session, for example with a command of the chat app, takes
+	// precedence over the agent of the runner.
+	agent := complete.First(msg.Agent, r.opt.Agent)
+	var selected string
+	if serverSession.GetSession().Get(types.CurrentAgentSessionKey, &selected) && selected != "" {
+		agent = selected
+	}
+	agent = complete.First(agent, r.data.CurrentAgent(ctx))
+	if !types.AgentAllowed(ctx, agent) {
+		return nil, fmt.Errorf("agent %s is not allowed for this client", agent)
+	}
+
+	result, err := r.runtime.Call(ctx, agent, types.AgentTool, map[string]any{
+		"prompt":      msg.Prompt,
+		"attachments": attachments,
+	}, tools.CallOptions{
+		ProgressToken: msg.ProgressToken,
+		// Like calls of the chat UI, the call of the agent itself is not reported as a tool call.
+		LogData: map[string]any{
+			"mcpToolName": agent,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.sessions.Store(ctx, msg.SessionID, serverSession); err != nil {
+		log.Errorf(ctx, "failed to store %s session %s: %v", msg.Channel, msg.SessionID, err)
+	}
+
+	var (
+		resp Response
+		text []string
+	)
+	for _, content := range result.Content {
+		switch content.Type {
+		case "text":
+			text = append(text, content.Text)
+		case "image":
+			resp.Images = append(resp.Images, content)
+		}
+	}
+	resp.Text = strings.Join(text, "\n\n")
+	return &resp, nil
+}
+
+// SetAgent selects the agent that answers the messages of the session of msg, creating the
+// session if needed. The agent must be one of the entrypoints of the config, an empty agent
+// selects the agent of the runner again.
+func (r *Runner) SetAgent(ctx context.Context, msg Message, agent string) error {
+	return r.withSession(ctx, msg, func(ctx context.Context, serverSession *mcp.ServerSession) error {
+		if agent == "" {
+			serverSession.GetSession().Delete(types.CurrentAgentSessionKey)
+			return nil
+		}
+		return r.data.SetCurrentAgent(ctx, agent)
+	})
+}
+
+// Agents returns the agents that can be selected in the session of msg and the agent that answers
+// its messages.
+func (r *Runner) Agents(ctx context.Context, msg Message) (agents []types.AgentDisplay, current string, err error) {
+	err = r.withSession(ctx, msg, func(ctx context.Context, serverSession *mcp.ServerSession) error {
+		if !serverSession.GetSession().Get(types.CurrentAgentSessionKey, &current) || current == "" {
+			current = complete.First(msg.Agent, r.opt.Agent, r.data.CurrentAgent(ctx))
+		}
+		agents, err = r.data.Agents(ctx)
+		return err
+	})
+	return
+}
+
+// SelectedAgent returns the agent selected in the stored session, if any, without loading it.
+func (r *Runner) SelectedAgent(ctx context.Context, sessionID string) string {
+	stored, err := r.sessions.DB.Get(ctx, sessionID)
+	if err != nil {
+		return ""
+	}
+	agent, _ := stored.State.Attributes[types.CurrentAgentSessionKey].(string)
+	return agent
+}
+
+// withSession runs fn with the session of msg and stores the session afterward.
+func (r *Runner) withSession(ctx context.Context, msg Message, fn func(ctx context.Context, serverSession *mcp.ServerSession) error) error {
+	nctx := types.NanobotContext(ctx)
+	nctx.User = msg.User
+	nctx.Config = r.config
+	ctx = types.WithNanobotContext(ctx, nctx)
+
+	lock := r.lock(msg.SessionID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	serverSession, err := r.acquire(ctx, msg)
+	if err != nil {
+		return err
+	}
+	defer r.sessions.Release(serverSession)
+
+	ctx = mcp.WithSession(ctx, serverSession.GetSession())
+	if err := r.data.Sync(ctx, r.config); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := fn(ctx, serverSession); err != nil {
+		return err
+	}
+	return r.sessions.Store(ctx, msg.SessionID, serverSession)
+}
+
+// acquire loads the session of the conversation, creating it on the first message.
+func (r *Runner) acquire(ctx context.Context, msg Message) (*mcp.ServerSession, error) {
+	_, err := r.sessions.DB.Get(ctx, msg.SessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = r.sessions.DB.Create(ctx, &session.Session{
+			Type:        msg.SessionType,
+			SessionID:   msg.SessionID,
+			AccountID:   msg.User.ID,
+			Description: msg.Description,
+			State: session.State{
+				ID: msg.SessionID,
+				InitializeRequest: mcp.InitializeRequest{
+					ClientInfo: mcp.ClientInfo{
+						Name: msg.SessionType,
+					},
+				},
+			},
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	serverSession, ok, err := r.sessions.Acquire(ctx, r.server, msg.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	} else if !ok {
+		return nil, fmt.Errorf("session %s is not owned by the sender", msg.SessionID)
+	}
+	return serverSession, nil
+}
+
+// attachments stores the files of the message as resources of the session, so that they are
+// available after the turn. They are passed to the agent as data URIs.
+func (r *Runner) attachments(ctx context.Context, msg Message) ([]map[string]any, error) {
+	var result []map[string]any
+	for _, file := range msg.Files {
+		blob := base64.StdEncoding.EncodeToString(file.Data)
+		if r.resources != nil && !types.IsEphemeral(mcp.SessionFromContext(ctx)) {
+			if err := r.resources.Create(ctx, &resources.Resource{
+				UUID:        uuid.String(),
+				SessionID:   msg.SessionID,
+				AccountID:   msg.User.ID,
+				Blob:        blob,
+				MimeType:    file.MimeType,
+				Name:        file.Name,
+				Description: "Attachment sent in " + msg.Channel,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to store attachment %s: %w", file.Name, err)
+			}
+		}
+
+		result = append(result, map[string]any{
+			"url":      "data:" + file.MimeType + ";base64," + blob,
+			"mimeType": file.MimeType,
+		})
+	}
+	return result, nil
+}
+
+// lock returns the lock that serializes the turns of a session.
+func (r *Runner) lock(id string) *sync.Mutex {
+	r.locksLock.Lock()
+	defer r.locksLock.Unlock()
+
+	lock, ok := r.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.locks[id] = lock
+	}
+	return lock
+}
+
+// SessionID derives a session ID from the parts that identify a conversation and its sender. The
+// ID is keyed with a secret of the chat app so that it can not be guessed from the conversation.
+func SessionID(secret string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(parts, "\x00")))
+	sum := hex.EncodeToString(mac.Sum(nil)[:16])
+	return sum[:8] + "-" + sum[8:12] + "-" + sum[12:16] + "-" + sum[16:20] + "-" + sum[20:]
+}
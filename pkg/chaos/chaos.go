@@ -0,0 +1,99 @@
+// Package chaos injects failures into calls of model providers and tools at configured rates, so
+// that operators can check that retries, fallbacks, and the error states of the UI work before a
+// real outage does it for them.
+package chaos
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+)
+
+const defaultChunkDelay = 2 * time.Second
+
+// Config is the rate, between 0 and 1, at which each kind of failure is injected.
+type Config struct {
+	// RateLimit fails a completion with a 429 of the provider before it is sent.
+	RateLimit float64
+	// SlowChunks delays every streamed chunk of a completion by ChunkDelay.
+	SlowChunks float64
+	// Truncate cuts the stream of a completion off after a few chunks and fails it.
+	Truncate float64
+	// ToolTimeout fails a tool call with a timeout instead of calling the tool.
+	ToolTimeout float64
+	// ChunkDelay is the delay of a slow chunk. Defaults to 2s.
+	ChunkDelay time.Duration
+}
+
+func (c Config) Merge(other Config) (result Config) {
+	result.RateLimit = complete.Last(c.RateLimit, other.RateLimit)
+	result.SlowChunks = complete.Last(c.SlowChunks, other.SlowChunks)
+	result.Truncate = complete.Last(c.Truncate, other.Truncate)
+	result.ToolTimeout = complete.Last(c.ToolTimeout, other.ToolTimeout)
+	result.ChunkDelay = complete.Last(c.ChunkDelay, other.ChunkDelay)
+	return
+}
+
+// Enabled reports if any failure is injected.
+func (c Config) Enabled() bool {
+	return c.RateLimit > 0 || c.SlowChunks > 0 || c.Truncate > 0 || c.ToolTimeout > 0
+}
+
+// Delay returns the delay of a slow chunk.
+func (c Config) Delay() time.Duration {
+	if c.ChunkDelay > 0 {
+		return c.ChunkDelay
+	}
+	return defaultChunkDelay
+}
+
+// Hit reports if a failure with the rate is injected this time.
+func Hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// Parse reads a config from a comma separated list of name=value pairs, such as
+// "rateLimit=0.1,toolTimeout=0.05,chunkDelay=5s". An empty spec disables chaos mode.
+func Parse(spec string) (Config, error) {
+	var result Config
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		if name == "chunkDelay" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return Config{}, fmt.Errorf("invalid chaos chunkDelay %q, must be a duration such as 2s", value)
+			}
+			result.ChunkDelay = d
+			continue
+		}
+
+		var rate *float64
+		switch name {
+		case "rateLimit":
+			rate = &result.RateLimit
+		case "slowChunks":
+			rate = &result.SlowChunks
+		case "truncate":
+			rate = &result.Truncate
+		case "toolTimeout":
+			rate = &result.ToolTimeout
+		default:
+			return Config{}, fmt.Errorf("invalid chaos setting %q, must be rateLimit, slowChunks, truncate, toolTimeout, or chunkDelay", name)
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 || f > 1 {
+			return Config{}, fmt.Errorf("invalid chaos rate %s=%q, must be between 0 and 1", name, value)
+		}
+		*rate = f
+	}
+	return result, nil
+}
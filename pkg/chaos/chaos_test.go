@@ -0,0 +1,26 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse("rateLimit=0.1, toolTimeout=1,chunkDelay=5s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Config{RateLimit: 0.1, ToolTimeout: 1, ChunkDelay: 5 * time.Second}); cfg != want {
+		t.Fatalf("expected %+v, got %+v", want, cfg)
+	}
+
+	if cfg, err := Parse(""); err != nil || cfg.Enabled() {
+		t.Fatalf("expected an empty spec to disable chaos mode, got %+v %v", cfg, err)
+	}
+
+	for _, spec := range []string{"rateLimit=2", "truncate=x", "latency=0.1", "chunkDelay=0s"} {
+		if _, err := Parse(spec); err == nil {
+			t.Fatalf("expected %q to be invalid", spec)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/bench"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+type Bench struct {
+	Sessions int    `usage:"Number of concurrent sessions" default:"10" short:"c"`
+	Turns    int    `usage:"Number of prompts sent in each session" default:"5" short:"t"`
+	Prompt   string `usage:"Prompt sent in every turn"`
+	Tool     string `usage:"Tool called in every turn (default: the first tool of the instance)"`
+	Metrics  string `usage:"URL of the Prometheus metrics of the instance, to report store contention"`
+	Output   string `usage:"Output format (json, yaml, table)" short:"o" default:"table"`
+	n        *Nanobot
+}
+
+func NewBench(n *Nanobot) *Bench {
+	return &Bench{
+		n: n,
+	}
+}
+
+func (b *Bench) Customize(cmd *cobra.Command) {
+	cmd.Use = "bench [flags] [URL]"
+	cmd.Short = "Drive concurrent synthetic sessions against a running nanobot for capacity planning"
+	cmd.Example = `
+  # Start an instance that answers completions with the mock provider after 500ms
+  nanobot run --llm-replay mock:500ms --metrics-path /metrics ./nanobot.yaml
+
+  # Run 50 sessions with 10 turns each against it
+  nanobot bench -c 50 -t 10 --metrics http://localhost:8080/metrics http://localhost:8080/mcp
+`
+	cmd.Args = cobra.MaximumNArgs(1)
+}
+
+func (b *Bench) Run(cmd *cobra.Command, args []string) error {
+	// Logging every message of every session would slow the load generator down.
+	if !b.n.Debug && !b.n.Trace {
+		log.EnableMessages = false
+	}
+
+	url := "http://localhost:8080/mcp"
+	if len(args) > 0 {
+		url = args[0]
+	}
+
+	report, err := bench.Run(cmd.Context(), url, bench.Options{
+		Sessions:   b.Sessions,
+		Turns:      b.Turns,
+		Prompt:     b.Prompt,
+		Tool:       b.Tool,
+		MetricsURL: b.Metrics,
+	})
+	if err != nil {
+		return err
+	}
+
+	if display(report, b.Output) {
+		return nil
+	}
+
+	fmt.Printf("Sessions:   %d\n", report.Sessions)
+	fmt.Printf("Turns:      %d in %s\n", report.Turns, report.Duration.Round(time.Millisecond))
+	fmt.Printf("Throughput: %.2f turns/s\n", report.Throughput)
+	fmt.Printf("Errors:     %d\n", report.Errors)
+	if report.FirstError != "" {
+		fmt.Printf("First error: %s\n", report.FirstError)
+	}
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "STAGE\tCOUNT\tP50\tP90\tP99\tMAX")
+	for _, stage := range report.Stages {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", stage.Name, stage.Count,
+			roundDuration(stage.P50), roundDuration(stage.P90), roundDuration(stage.P99), roundDuration(stage.Max))
+	}
+	if len(report.Store) > 0 {
+		_, _ = fmt.Fprintln(tw, "\nSTORE\tCOUNT\tERRORS\tMEAN")
+		for _, op := range report.Store {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", op.Operation, op.Count, op.Errors, roundDuration(op.Mean))
+		}
+	}
+	return tw.Flush()
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d > time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/bench"
+	"github.com/nanobot-ai/nanobot/pkg/llm"
+	"github.com/nanobot-ai/nanobot/pkg/llm/pricing"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type BenchProviders struct {
+	Model       []string `usage:"Model to benchmark (can be repeated), the provider is selected by the name of the model" short:"m"`
+	Prompt      []string `usage:"Prompt sent to every model (can be repeated)" short:"p"`
+	Prompts     string   `usage:"YAML or JSON file with a list of prompts sent to every model"`
+	Runs        int      `usage:"Number of times each prompt is sent to each model" default:"3" short:"n"`
+	Concurrency int      `usage:"Number of requests in flight at the same time" default:"1"`
+	MaxTokens   int      `usage:"Maximum number of output tokens of a response (default: unlimited)"`
+	Pricing     string   `usage:"YAML or JSON file with the price of each model in USD per million tokens, to report the cost of responses"`
+	Output      string   `usage:"Output format (json, yaml, table)" short:"o" default:"table"`
+	n           *Nanobot
+}
+
+func NewBenchProviders(n *Nanobot) *BenchProviders {
+	return &BenchProviders{
+		n: n,
+	}
+}
+
+func (b *BenchProviders) Customize(cmd *cobra.Command) {
+	cmd.Use = "providers [flags]"
+	cmd.Short = "Compare the time to first token, throughput, latency, and cost of LLM providers and models"
+	cmd.Example = `
+  # Send the default prompt three times to two models
+  nanobot bench providers -m gpt-4.1 -m claude-sonnet-4-5
+
+  # Send the prompts of prompts.yaml five times to each model and report the cost of the responses
+  nanobot bench providers -m gpt-4.1 -m gpt-4.1-mini -n 5 --prompts prompts.yaml --pricing pricing.yaml
+
+  # pricing.yaml:
+  #   gpt-4.1: {input: 2, cachedInput: 0.5, output: 8}
+  #   gpt-4.1-mini: {input: 0.4, cachedInput: 0.1, output: 1.6}
+`
+	cmd.Args = cobra.NoArgs
+}
+
+func (b *BenchProviders) Run(cmd *cobra.Command, _ []string) error {
+	if len(b.Model) == 0 {
+		return fmt.Errorf("no models to benchmark, select them with --model")
+	}
+
+	prompts := b.Prompt
+	if b.Prompts != "" {
+		data, err := os.ReadFile(b.Prompts)
+		if err != nil {
+			return fmt.Errorf("failed to read prompts %s: %w", b.Prompts, err)
+		}
+		var filePrompts []string
+		if err := yaml.Unmarshal(data, &filePrompts); err != nil {
+			return fmt.Errorf("failed to parse prompts %s, expected a list of strings: %w", b.Prompts, err)
+		}
+		prompts = append(prompts, filePrompts...)
+	}
+
+	var (
+		table pricing.Table
+		err   error
+	)
+	if b.Pricing != "" {
+		table, err = pricing.Load(b.Pricing)
+		if err != nil {
+			return err
+		}
+	}
+
+	llmConfig, err := b.n.llmConfig()
+	if err != nil {
+		return err
+	}
+
+	report, err := bench.RunProviders(cmd.Context(), llm.NewClient(llmConfig), bench.ProviderOptions{
+		Models:      b.Model,
+		Prompts:     prompts,
+		Runs:        b.Runs,
+		Concurrency: b.Concurrency,
+		MaxTokens:   b.MaxTokens,
+		Pricing:     table,
+	})
+	if err != nil {
+		return err
+	}
+
+	if display(report, b.Output) {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "MODEL\tRESPONSES\tERRORS\tTTFT P50\tTTFT P90\tLATENCY P50\tLATENCY P90\tTOKENS/S\tOUTPUT TOKENS\tCOST/RESPONSE")
+	for _, model := range report.Models {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%.1f\t%d\t%s\n", model.Model, model.Responses, model.Errors,
+			roundDuration(model.TTFTP50), roundDuration(model.TTFTP90), roundDuration(model.LatencyP50),
+			roundDuration(model.LatencyP90), model.TokensPerSecond, model.OutputTokens, cost(model.CostPerResponse))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, model := range report.Models {
+		if model.FirstError != "" {
+			fmt.Printf("\n%s first error: %s\n", model.Model, model.FirstError)
+		}
+	}
+	fmt.Printf("\nDuration: %s\n", report.Duration.Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type Config struct {
+	Nanobot *Nanobot
+}
+
+func NewConfig(n *Nanobot) *Config {
+	return &Config{
+		Nanobot: n,
+	}
+}
+
+func (c *Config) Customize(cmd *cobra.Command) {
+	cmd.Use = "config"
+	cmd.Short = "Manage nanobot configs"
+	cmd.Args = cobra.NoArgs
+}
+
+func (c *Config) Run(cmd *cobra.Command, _ []string) error {
+	return cmd.Help()
+}
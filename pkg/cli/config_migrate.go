@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nanobot-ai/nanobot/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+type ConfigMigrate struct {
+	Nanobot *Nanobot
+	Write   bool   `usage:"Rewrite the config files in place" short:"w"`
+	Check   bool   `usage:"Fail if the config uses deprecated fields, without changing it"`
+	Output  string `usage:"Output format of the report (text, json, yaml)" short:"o" default:"text"`
+}
+
+func NewConfigMigrate(n *Nanobot) *ConfigMigrate {
+	return &ConfigMigrate{
+		Nanobot: n,
+	}
+}
+
+func (m *ConfigMigrate) Customize(cmd *cobra.Command) {
+	cmd.Use = "migrate [flags] NANOBOT"
+	cmd.Short = "Rewrite the deprecated fields of a config to the current schema"
+	cmd.Long = `Rewrite the deprecated fields of a config, and the local files it extends, to the current
+schema. Without --write the migrated files are printed and nothing is changed. Comments are kept.
+Deprecated fields that can not be rewritten automatically are reported as manual steps. Run
+nanobot with --strict-config to refuse to load configs that still use deprecated fields.`
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `
+  # Show what would change in the nanobot.yaml in the current directory
+  nanobot config migrate .
+
+  # Rewrite the files
+  nanobot config migrate --write .
+
+  # Fail in CI if the config uses deprecated fields
+  nanobot config migrate --check ./nanobot.yaml
+`
+}
+
+func (m *ConfigMigrate) Run(cmd *cobra.Command, args []string) error {
+	migrations, err := config.Migrate(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+
+	var migrated, manual int
+	for _, migration := range migrations {
+		migrated += len(migration.Migrated)
+		manual += len(migration.Manual)
+	}
+
+	if m.Write && !m.Check {
+		for _, migration := range migrations {
+			if migration.Data == nil {
+				continue
+			}
+			info, err := os.Stat(migration.File)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(migration.File, migration.Data, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to write %s: %w", migration.File, err)
+			}
+		}
+	}
+
+	if display(migrations, m.Output) {
+		return m.result(args[0], migrated, manual)
+	}
+
+	for _, migration := range migrations {
+		for _, problem := range migration.Migrated {
+			problem.Warning = false
+			fmt.Fprintln(os.Stderr, "migrated:", problem)
+		}
+		for _, problem := range migration.Manual {
+			problem.Warning = false
+			fmt.Fprintln(os.Stderr, "manual step:", problem)
+		}
+		if migration.Data != nil && !m.Write && !m.Check {
+			fmt.Printf("# %s\n%s", migration.File, migration.Data)
+		}
+	}
+
+	switch {
+	case migrated+manual == 0:
+		fmt.Fprintf(os.Stderr, "config %s uses no deprecated fields\n", args[0])
+	case m.Write && !m.Check:
+		fmt.Fprintf(os.Stderr, "migrated %d field(s), %d need to be migrated by hand\n", migrated, manual)
+	case !m.Check && migrated > 0:
+		fmt.Fprintln(os.Stderr, "run with --write to rewrite the files")
+	}
+	return m.result(args[0], migrated, manual)
+}
+
+// result fails with --check if anything is deprecated, and otherwise if manual steps are left.
+func (m *ConfigMigrate) result(path string, migrated, manual int) error {
+	if m.Check && migrated+manual > 0 {
+		return fmt.Errorf("config %s uses %d deprecated field(s)", path, migrated+manual)
+	}
+	if manual > 0 {
+		return fmt.Errorf("config %s has %d deprecated field(s) to migrate by hand", path, manual)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nanobot-ai/nanobot/pkg/secrets"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type EncryptSecrets struct {
+	Nanobot *Nanobot
+}
+
+func NewEncryptSecrets(n *Nanobot) *EncryptSecrets {
+	return &EncryptSecrets{
+		Nanobot: n,
+	}
+}
+
+func (e *EncryptSecrets) Customize(cmd *cobra.Command) {
+	cmd.Use = "encrypt-secrets [flags] INPUT OUTPUT"
+	cmd.Short = "Encrypt a file of secrets for encfile:// references"
+	cmd.Long = `Encrypt a YAML or JSON file of keys and secrets with the passphrase in ` + secrets.KeyEnv + `.
+The secrets of the encrypted file can be referenced as encfile://OUTPUT#KEY in the env of the
+config and of MCP servers, and in provider API keys and headers.`
+	cmd.Args = cobra.ExactArgs(2)
+	cmd.Example = `
+  # Encrypt the secrets and use one of them as the OpenAI API key
+  export ` + secrets.KeyEnv + `=...
+  nanobot encrypt-secrets secrets.yaml secrets.enc
+  OPENAI_API_KEY=encfile://./secrets.enc#openai nanobot run .
+`
+}
+
+func (e *EncryptSecrets) Run(_ *cobra.Command, args []string) error {
+	passphrase := os.Getenv(secrets.KeyEnv)
+	if passphrase == "" {
+		return fmt.Errorf("%s must be set to encrypt secrets", secrets.KeyEnv)
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+	var values map[string]string
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse %s, expected keys with string values: %w", args[0], err)
+	}
+
+	sealed, err := secrets.Seal(passphrase, values)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if err := os.WriteFile(args[1], sealed, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", args[1], err)
+	}
+	fmt.Printf("Encrypted %d secrets to %s\n", len(values), args[1])
+	return nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/nanobot-ai/nanobot/pkg/erase"
+	"github.com/spf13/cobra"
+)
+
+type Erase struct {
+	Nanobot *Nanobot
+	DryRun  bool `usage:"Only report what would be deleted"`
+}
+
+func NewErase(n *Nanobot) *Erase {
+	return &Erase{
+		Nanobot: n,
+	}
+}
+
+func (e *Erase) Customize(cmd *cobra.Command) {
+	cmd.Use = "erase [flags] USER_ID"
+	cmd.Short = "Permanently delete all sessions and resources of a user"
+	cmd.Long = `Permanently delete all sessions, resources, and stored tokens of a user. A tombstone is
+recorded for every deleted object so that downstream consumers can erase their copies too.
+The server should not be running for the user while erasing, or live sessions may be written back.`
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `
+  # Show what would be deleted for a user
+  nanobot erase --dry-run 1234
+
+  # Delete everything stored for the user
+  nanobot erase 1234
+`
+}
+
+func (e *Erase) Run(cmd *cobra.Command, args []string) error {
+	store, err := erase.NewStoreFromDSN(e.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	report, err := store.Erase(cmd.Context(), args[0], e.DryRun)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/cmd"
+	"github.com/nanobot-ai/nanobot/pkg/eval"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/spf13/cobra"
+)
+
+type Eval struct {
+	Agent       []string `usage:"Agent to run the cases against, overrides the agents of the suite (can be repeated)" short:"a"`
+	Concurrency int      `usage:"Number of cases run in parallel" default:"4" short:"c"`
+	Judge       string   `usage:"Model or agent that grades judge assertions if the suite sets none (default: the default model)"`
+	Output      string   `usage:"Output format (json, yaml, table)" short:"o" default:"table"`
+	n           *Nanobot
+}
+
+func NewEval(n *Nanobot) *Eval {
+	return &Eval{
+		n: n,
+	}
+}
+
+func (e *Eval) Customize(cmd *cobra.Command) {
+	cmd.Use = "eval [flags] NANOBOT_CONFIG SUITE"
+	cmd.Short = "Run a suite of test cases against agents and report which passed"
+	cmd.Example = `
+  # Run the cases of evals.yaml against the agents of the suite, 8 at a time
+  nanobot eval -c 8 ./nanobot.yaml ./evals.yaml
+
+  # Compare two agents on the same cases and print the report as JSON
+  nanobot eval -a main -a main-mini -o json ./nanobot.yaml ./evals.yaml
+
+  # evals.yaml:
+  #   agents: [main]
+  #   pricing:
+  #     gpt-4.1: {input: 2, cachedInput: 0.5, output: 8}
+  #   cases:
+  #   - name: weather
+  #     prompt: What is the weather in Paris?
+  #     toolCalls:
+  #     - name: weather/forecast
+  #       arguments: {city: Paris}
+  #     assertions:
+  #     - regex: (?i)paris
+  #     - judge: The answer gives a temperature.
+`
+	cmd.Args = cobra.ExactArgs(2)
+}
+
+func (e *Eval) Run(cmd *cobra.Command, args []string) error {
+	// The answers are reported once the cases are done, logging every message would bury them.
+	if !e.n.Debug && !e.n.Trace {
+		log.EnableMessages = false
+	}
+
+	suite, err := eval.LoadSuite(args[1])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := e.n.ReadConfig(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read config file %q: %w", args[0], err)
+	}
+
+	runt, err := e.n.GetRuntime(runtime.Options{
+		MaxConcurrency: e.n.MaxConcurrency,
+		DSN:            e.n.DSN(),
+	})
+	if err != nil {
+		return err
+	}
+
+	report, err := eval.Run(cmd.Context(), runt, cfg, *suite, eval.Options{
+		Agents:      e.Agent,
+		Concurrency: e.Concurrency,
+		Judge:       e.Judge,
+	}, eval.Options{
+		Judge: e.n.DefaultModel,
+	})
+	if err != nil {
+		return err
+	}
+	return e.finish(report)
+}
+
+// finish prints the report and fails if any case failed.
+func (e *Eval) finish(report *eval.Report) error {
+	if !display(report, e.Output) {
+		if err := printEvalReport(report); err != nil {
+			return err
+		}
+	}
+
+	if report.Failed > 0 {
+		return &cmd.ExitError{Code: 1, Err: fmt.Errorf("%d of %d case(s) failed", report.Failed, report.Passed+report.Failed)}
+	}
+	return nil
+}
+
+func printEvalReport(report *eval.Report) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CASE\tAGENT\tRESULT\tTOOL CALLS\tINPUT\tOUTPUT\tCOST\tDURATION")
+	for _, result := range report.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		var calls []string
+		for _, call := range result.ToolCalls {
+			calls = append(calls, call.Name)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n", result.Case, result.Agent, status,
+			strings.Join(calls, ","), result.Usage.InputTokens, result.Usage.OutputTokens, cost(result.Cost),
+			result.Duration.Round(time.Millisecond))
+	}
+
+	_, _ = fmt.Fprintln(tw, "\nAGENT\tPASSED\tFAILED\tINPUT\tOUTPUT\tCOST")
+	for _, agent := range report.Agents {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", agent.Agent, agent.Passed, agent.Failed,
+			agent.Usage.InputTokens, agent.Usage.OutputTokens, cost(agent.Cost))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, result := range report.Results {
+		if result.Passed {
+			continue
+		}
+		fmt.Printf("\n%s (%s):\n", result.Case, result.Agent)
+		if result.Error != "" {
+			fmt.Printf("  error: %s\n", result.Error)
+		}
+		for _, failure := range result.Failures {
+			fmt.Printf("  %s\n", failure)
+		}
+	}
+
+	fmt.Printf("\nPassed: %d, Failed: %d in %s, %d input and %d output tokens", report.Passed, report.Failed,
+		report.Duration.Round(time.Millisecond), report.Usage.InputTokens, report.Usage.OutputTokens)
+	if report.Cost > 0 {
+		fmt.Printf(", %s", cost(report.Cost))
+	}
+	fmt.Println()
+	return nil
+}
+
+func cost(usd float64) string {
+	if usd == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.4f", usd)
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/nanobot-ai/nanobot/pkg/templates"
+	"github.com/spf13/cobra"
+)
+
+type Init struct {
+	Nanobot *Nanobot
+	Force   bool   `usage:"Overwrite existing files"`
+	Output  string `usage:"Output format of the list of templates (json, yaml, table)" short:"o" default:"table"`
+}
+
+func NewInit(n *Nanobot) *Init {
+	return &Init{
+		Nanobot: n,
+	}
+}
+
+func (i *Init) Customize(cmd *cobra.Command) {
+	cmd.Use = "init [flags] [TEMPLATE] [DIR]"
+	cmd.Short = "Create a new nanobot from a template"
+	cmd.Long = `Create a new nanobot in DIR, by default a directory named after the template, from one of the
+example configs. Tools that need external services are replaced with sample data, so the nanobot
+runs right away. Without a template, the available templates are listed.`
+	cmd.Args = cobra.MaximumNArgs(2)
+	cmd.Example = `
+  # List the templates
+  nanobot init
+
+  # Create a RAG assistant in ./rag-assistant and run it without an API key
+  nanobot init rag-assistant
+  cd rag-assistant && nanobot run --llm-replay mock .
+`
+}
+
+func (i *Init) Run(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		list := templates.List()
+		if display(list, i.Output) {
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = tw.Write([]byte("TEMPLATE\tDESCRIPTION\n"))
+		for _, template := range list {
+			_, _ = tw.Write([]byte(template.Name + "\t" + template.Description + "\n"))
+		}
+		return tw.Flush()
+	}
+
+	name, dir := args[0], args[0]
+	if len(args) > 1 {
+		dir = args[1]
+	}
+
+	written, err := templates.Scaffold(name, dir, i.Force)
+	if err != nil {
+		return err
+	}
+	for _, file := range written {
+		fmt.Println("Created", file)
+	}
+	fmt.Printf(`
+Run it without an API key, with synthetic completions:
+  cd %[1]s && nanobot run --llm-replay mock .
+
+Run it with a model, after exporting OPENAI_API_KEY or ANTHROPIC_API_KEY:
+  cd %[1]s && nanobot run .
+
+The comments in %[1]s/nanobot.yaml show how to connect real tools.
+`, dir)
+	return nil
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/cmd"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/tools"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+// Exit codes of the non-interactive mode, besides 0 for success and 1 if the prompt could not be
+// sent, for example because the config is invalid.
+const (
+	exitAgentError = 2
+	exitToolError  = 3
+)
+
+// runNonInteractive sends a single prompt to the agent, streams the answer to stdout, and exits.
+// Tool calls are printed to stderr.
+func (r *Run) runNonInteractive(ctx context.Context, cfgPath string, promptArgs []string, runtimeOpt runtime.Options) error {
+	prompt, err := readPrompt(ctx, promptArgs, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := r.n.ReadConfig(ctx, cfgPath, runtimeOpt)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %q: %w", cfgPath, err)
+	}
+
+	agent := r.Agent
+	if agent == "" && len(cfg.Publish.Entrypoint) > 0 {
+		agent = cfg.Publish.Entrypoint[0]
+	}
+	if _, ok := cfg.Agents[agent]; !ok {
+		if agent == "" {
+			return fmt.Errorf("config %s has no default agent, select one with --agent", cfgPath)
+		}
+		return fmt.Errorf("agent %q not found in config %s", agent, cfgPath)
+	}
+
+	runt, err := r.n.GetRuntime(runtimeOpt, runtime.Options{
+		DSN: r.n.DSN(),
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := newTurnWriter(r.Output, os.Stdout, os.Stderr, r.n.Quiet)
+	if err != nil {
+		return err
+	}
+	out.record.Agent, out.record.Prompt = agent, prompt
+
+	ctx = runt.WithTempSession(ctx, cfg)
+	ctx = progress.WithListener(ctx, out.progress)
+	ctx = types.WithUsageListener(ctx, out.usage)
+
+	result, err := runt.Call(ctx, agent, types.AgentTool, map[string]any{
+		"prompt": prompt,
+	}, tools.CallOptions{
+		ProgressToken: uuid.String(),
+		// Like calls of MCP clients, the call of the agent itself is not reported as a tool call.
+		LogData: map[string]any{
+			"mcpToolName": agent,
+		},
+	})
+	if err == nil && result.IsError {
+		err = fmt.Errorf("agent %s failed: %s", agent, resultText(result))
+	}
+	if err != nil {
+		out.fail(err)
+		return &cmd.ExitError{Code: exitAgentError, Err: err}
+	}
+
+	toolErrors := out.finish(result)
+	if toolErrors > 0 {
+		return &cmd.ExitError{Code: exitToolError, Err: fmt.Errorf("%d tool call(s) failed", toolErrors)}
+	}
+	return nil
+}
+
+// readPrompt joins the prompt arguments with the input piped to stdin. A prompt of "-" only reads
+// stdin. Reading stdin stops when ctx is done, for pipes that are never closed.
+func readPrompt(ctx context.Context, args []string, stdin *os.File) (string, error) {
+	prompt := strings.Join(args, " ")
+	if prompt == "-" {
+		prompt = ""
+	} else if info, err := stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		// Nothing is piped to a terminal.
+		stdin = nil
+	}
+
+	if stdin != nil {
+		var (
+			data []byte
+			err  error
+			done = make(chan struct{})
+		)
+		go func() {
+			defer close(done)
+			data, err = io.ReadAll(stdin)
+		}()
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-done:
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt from stdin: %w", err)
+		}
+		if input := strings.TrimSpace(string(data)); input != "" && prompt != "" {
+			prompt += "\n\n" + input
+		} else if input != "" {
+			prompt = input
+		}
+	}
+
+	if strings.TrimSpace(prompt) == "" {
+		return "", errors.New("no prompt, pass it as an argument or on stdin")
+	}
+	return prompt, nil
+}
+
+func resultText(result *types.CallResult) string {
+	var text []string
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			text = append(text, content.Text)
+		}
+	}
+	return strings.Join(text, "\n\n")
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stdinFile returns a file that reads like input piped to stdin.
+func stdinFile(t *testing.T, input string) *os.File {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	return f
+}
+
+func TestReadPrompt(t *testing.T) {
+	terminal, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer terminal.Close()
+
+	for _, test := range []struct {
+		name  string
+		args  []string
+		stdin *os.File
+		want  string
+	}{
+		{name: "args", args: []string{"Review", "this"}, stdin: terminal, want: "Review this"},
+		{name: "args and stdin", args: []string{"Review this"}, stdin: stdinFile(t, "\ndiff\n"), want: "Review this\n\ndiff"},
+		{name: "empty stdin", args: []string{"Review this"}, stdin: stdinFile(t, " \n"), want: "Review this"},
+		{name: "only stdin", args: []string{"-"}, stdin: stdinFile(t, "diff\n"), want: "diff"},
+		{name: "no args", stdin: stdinFile(t, "diff"), want: "diff"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := readPrompt(t.Context(), test.args, test.stdin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got prompt %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if _, err := readPrompt(t.Context(), nil, terminal); err == nil {
+		t.Error("expected an error without a prompt")
+	}
+	if _, err := readPrompt(t.Context(), []string{"-"}, stdinFile(t, "")); err == nil {
+		t.Error("expected an error for empty stdin")
+	}
+}
+
+func TestReadPromptCanceled(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	// The pipe is never closed, so reading stops when ctx is done.
+	if _, err := readPrompt(ctx, []string{"Review this"}, r); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
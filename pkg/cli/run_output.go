@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// turnFormats are the output formats of the non-interactive mode. text streams the answer,
+// markdown prints the tool calls and the answer as a document once the turn is done, json prints
+// the turn record, and ndjson-events prints an event per line while the turn runs and the turn
+// record last.
+var turnFormats = []string{"text", "markdown", "json", "ndjson-events"}
+
+// turnRecord is a non-interactive turn in the json and ndjson-events output.
+type turnRecord struct {
+	Agent      string           `json:"agent"`
+	Model      string           `json:"model,omitempty"`
+	Prompt     string           `json:"prompt"`
+	Answer     string           `json:"answer"`
+	ToolCalls  []toolCallRecord `json:"toolCalls,omitempty"`
+	Usage      types.Usage      `json:"usage"`
+	StopReason string           `json:"stopReason,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+type toolCallRecord struct {
+	CallID    string `json:"callID"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+	Result    string `json:"result,omitempty"`
+	IsError   bool   `json:"isError,omitempty"`
+}
+
+// turnEvent is a line of the ndjson-events output. Its type is text for a delta of the answer,
+// tool_call and tool_result for tool calls, usage for every completion, and turn for the record of
+// the turn, which is always the last line.
+type turnEvent struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ToolCall *toolCallRecord `json:"toolCall,omitempty"`
+	Usage    *types.Usage    `json:"usage,omitempty"`
+	Turn     *turnRecord     `json:"turn,omitempty"`
+}
+
+// turnWriter prints a non-interactive turn in one of the turnFormats. Progress is reported
+// concurrently by parallel tool calls.
+type turnWriter struct {
+	lock   sync.Mutex
+	format string
+	stdout io.Writer
+	stderr io.Writer
+	quiet  bool
+	record turnRecord
+	// calls are the indexes of the tool calls in the record by call ID.
+	calls map[string]int
+	// announced are the tool calls whose tool_call event was printed.
+	announced  map[string]bool
+	toolErrors int
+	streamed   bool
+	lastItem   string
+}
+
+func newTurnWriter(format string, stdout, stderr io.Writer, quiet bool) (*turnWriter, error) {
+	if !slices.Contains(turnFormats, format) {
+		return nil, fmt.Errorf("invalid output format %q, must be one of %s", format, strings.Join(turnFormats, ", "))
+	}
+	return &turnWriter{
+		format:    format,
+		stdout:    stdout,
+		stderr:    stderr,
+		quiet:     quiet,
+		calls:     map[string]int{},
+		announced: map[string]bool{},
+	}, nil
+}
+
+func (w *turnWriter) progress(p *types.CompletionProgress) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if p.Role == "user" {
+		return
+	}
+	if w.record.Model == "" {
+		w.record.Model = p.Model
+	}
+
+	item := p.Item
+	switch {
+	case item.ToolCallResult != nil:
+		call := w.toolCall(item.ToolCallResult.CallID, item.ToolCall)
+		call.Result = resultText(&item.ToolCallResult.Output)
+		call.IsError = item.ToolCallResult.Output.IsError
+		w.announce(call)
+		w.event(turnEvent{Type: "tool_result", ToolCall: call})
+		if call.IsError {
+			w.toolErrors++
+			w.status("[tool error] %s: %s\n", call.Name, call.Result)
+		}
+	case item.ToolCall != nil && item.ToolCall.CallID != "":
+		_, seen := w.calls[item.ToolCall.CallID]
+		call := w.toolCall(item.ToolCall.CallID, item.ToolCall)
+		if item.Partial {
+			call.Arguments += item.ToolCall.Arguments
+		} else if item.ToolCall.Arguments != "" {
+			call.Arguments = item.ToolCall.Arguments
+		}
+		if !seen {
+			w.status("[tool call] %s\n", call.Name)
+		}
+		if !item.Partial {
+			w.announce(call)
+		}
+	case item.Content != nil && item.Content.Type == "text" && item.Partial:
+		w.event(turnEvent{Type: "text", Text: item.Content.Text})
+		if w.format != "text" {
+			return
+		}
+		if w.lastItem != "" && w.lastItem != item.ID {
+			_, _ = fmt.Fprint(w.stdout, "\n\n")
+		}
+		w.lastItem, w.streamed = item.ID, true
+		_, _ = fmt.Fprint(w.stdout, item.Content.Text)
+	}
+}
+
+// toolCall returns the record of a tool call, adding it on the first progress of the call. The
+// caller must hold the lock.
+func (w *turnWriter) toolCall(callID string, toolCall *types.ToolCall) *toolCallRecord {
+	i, ok := w.calls[callID]
+	if !ok {
+		i = len(w.record.ToolCalls)
+		w.calls[callID] = i
+		w.record.ToolCalls = append(w.record.ToolCalls, toolCallRecord{CallID: callID})
+	}
+	call := &w.record.ToolCalls[i]
+	if toolCall != nil && call.Name == "" {
+		call.Name = toolCall.Name
+	}
+	return call
+}
+
+// announce prints the tool_call event of a tool call once its arguments are complete, or before
+// its result for tool calls that are only streamed. The caller must hold the lock.
+func (w *turnWriter) announce(call *toolCallRecord) {
+	if !w.announced[call.CallID] {
+		w.announced[call.CallID] = true
+		announced := *call
+		announced.Result, announced.IsError = "", false
+		w.event(turnEvent{Type: "tool_call", ToolCall: &announced})
+	}
+}
+
+func (w *turnWriter) usage(usage types.Usage) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.record.Usage.InputTokens += usage.InputTokens
+	w.record.Usage.OutputTokens += usage.OutputTokens
+	w.record.Usage.CachedInputTokens += usage.CachedInputTokens
+	w.record.Usage.ReasoningTokens += usage.ReasoningTokens
+	w.event(turnEvent{Type: "usage", Usage: &usage})
+}
+
+// event prints an event in the ndjson-events format. The caller must hold the lock.
+func (w *turnWriter) event(event turnEvent) {
+	if w.format != "ndjson-events" {
+		return
+	}
+	if event.ToolCall != nil {
+		call := *event.ToolCall
+		event.ToolCall = &call
+	}
+	_ = json.NewEncoder(w.stdout).Encode(event)
+}
+
+// status prints the progress of tool calls to stderr in the text format. The caller must hold
+// the lock.
+func (w *turnWriter) status(format string, args ...any) {
+	if w.quiet || w.format != "text" {
+		return
+	}
+	if w.lastItem != "" {
+		// Keep the status off the line of the streamed text.
+		_, _ = fmt.Fprintln(w.stdout)
+		w.lastItem = ""
+	}
+	_, _ = fmt.Fprintf(w.stderr, format, args...)
+}
+
+// finish prints the result of the turn and returns the number of tool calls that failed.
+func (w *turnWriter) finish(result *types.CallResult) int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.record.Answer = resultText(result)
+	w.record.Model = complete.First(result.Model, w.record.Model)
+	w.record.StopReason = result.StopReason
+
+	switch w.format {
+	case "text":
+		if !w.streamed {
+			_, _ = fmt.Fprint(w.stdout, w.record.Answer)
+			w.lastItem = "answer"
+		}
+		if w.lastItem != "" {
+			_, _ = fmt.Fprintln(w.stdout)
+		}
+	case "markdown":
+		_, _ = fmt.Fprint(w.stdout, w.markdown())
+	default:
+		w.print()
+	}
+	return w.toolErrors
+}
+
+// fail prints the record of a turn that failed in the json formats.
+func (w *turnWriter) fail(err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.record.Error = err.Error()
+	if w.format == "json" || w.format == "ndjson-events" {
+		w.print()
+	}
+}
+
+// print prints the turn record. The caller must hold the lock.
+func (w *turnWriter) print() {
+	if w.format == "ndjson-events" {
+		_ = json.NewEncoder(w.stdout).Encode(turnEvent{Type: "turn", Turn: &w.record})
+		return
+	}
+	enc := json.NewEncoder(w.stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(w.record)
+}
+
+// markdown renders the tool calls and the answer of the turn. The caller must hold the lock.
+func (w *turnWriter) markdown() string {
+	var sb strings.Builder
+	for _, call := range w.record.ToolCalls {
+		fmt.Fprintf(&sb, "**Tool call:** `%s`\n\n", call.Name)
+		if call.Arguments != "" {
+			fmt.Fprintf(&sb, "```json\n%s\n```\n\n", call.Arguments)
+		}
+		label := "Result"
+		if call.IsError {
+			label = "Error"
+		}
+		fmt.Fprintf(&sb, "**%s:**\n\n```\n%s\n```\n\n", label, call.Result)
+	}
+	if len(w.record.ToolCalls) > 0 {
+		sb.WriteString("---\n\n")
+	}
+	sb.WriteString(strings.TrimSpace(w.record.Answer))
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// writeTurn prints a turn that streams text, calls a tool that fails, and streams the answer.
+func writeTurn(t *testing.T, format string, quiet bool) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	w, err := newTurnWriter(format, &stdout, &stderr, quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.record.Agent, w.record.Prompt = "main", "Weather in Paris?"
+
+	text := func(id, text string) types.CompletionItem {
+		return types.CompletionItem{ID: id, Partial: true, Content: &mcp.Content{Type: "text", Text: text}}
+	}
+	for _, item := range []types.CompletionItem{
+		text("m1", "Let me "),
+		text("m1", "check."),
+		{Partial: true, ToolCall: &types.ToolCall{CallID: "c1", Name: "forecast", Arguments: `{"city": `}},
+		{Partial: true, ToolCall: &types.ToolCall{CallID: "c1", Arguments: `"Paris"}`}},
+		{ToolCall: &types.ToolCall{CallID: "c1", Name: "forecast", Arguments: `{"city": "Paris"}`}},
+		{ToolCallResult: &types.ToolCallResult{CallID: "c1", Output: types.CallResult{
+			IsError: true,
+			Content: []mcp.Content{{Type: "text", Text: "unavailable"}},
+		}}},
+		text("m2", "It is sunny."),
+	} {
+		w.progress(&types.CompletionProgress{Model: "gpt-4.1", Role: "assistant", Item: item})
+		if item.ToolCallResult != nil {
+			w.usage(types.Usage{InputTokens: 10, OutputTokens: 5})
+		}
+	}
+	w.progress(&types.CompletionProgress{Role: "user", Item: text("u1", "ignored")})
+
+	toolErrors := w.finish(&types.CallResult{
+		Content:    []mcp.Content{{Type: "text", Text: "It is sunny."}},
+		StopReason: "end_turn",
+	})
+	return stdout.String(), stderr.String(), toolErrors
+}
+
+func TestTurnWriterText(t *testing.T) {
+	stdout, stderr, toolErrors := writeTurn(t, "text", false)
+	if want := "Let me check.\nIt is sunny.\n"; stdout != want {
+		t.Errorf("got stdout %q, want %q", stdout, want)
+	}
+	if want := "[tool call] forecast\n[tool error] forecast: unavailable\n"; stderr != want {
+		t.Errorf("got stderr %q, want %q", stderr, want)
+	}
+	if toolErrors != 1 {
+		t.Errorf("got %d tool errors, want 1", toolErrors)
+	}
+
+	if _, stderr, _ := writeTurn(t, "text", true); stderr != "" {
+		t.Errorf("expected no status when quiet, got %q", stderr)
+	}
+}
+
+func TestTurnWriterMarkdown(t *testing.T) {
+	stdout, stderr, _ := writeTurn(t, "markdown", false)
+	want := "**Tool call:** `forecast`\n\n```json\n{\"city\": \"Paris\"}\n```\n\n" +
+		"**Error:**\n\n```\nunavailable\n```\n\n---\n\nIt is sunny.\n"
+	if stdout != want {
+		t.Errorf("got stdout\n%s\nwant\n%s", stdout, want)
+	}
+	if stderr != "" {
+		t.Errorf("expected no status, got %q", stderr)
+	}
+}
+
+func TestTurnWriterJSON(t *testing.T) {
+	stdout, _, _ := writeTurn(t, "json", false)
+
+	var record turnRecord
+	if err := json.Unmarshal([]byte(stdout), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Agent != "main" || record.Model != "gpt-4.1" || record.Answer != "It is sunny." || record.StopReason != "end_turn" {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if record.Usage.InputTokens != 10 || record.Usage.OutputTokens != 5 {
+		t.Errorf("unexpected usage %+v", record.Usage)
+	}
+	want := toolCallRecord{CallID: "c1", Name: "forecast", Arguments: `{"city": "Paris"}`, Result: "unavailable", IsError: true}
+	if len(record.ToolCalls) != 1 || record.ToolCalls[0] != want {
+		t.Errorf("got tool calls %+v, want %+v", record.ToolCalls, want)
+	}
+}
+
+func TestTurnWriterEvents(t *testing.T) {
+	stdout, _, _ := writeTurn(t, "ndjson-events", false)
+
+	var (
+		kinds  []string
+		events []turnEvent
+	)
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		var event turnEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid event %s: %v", line, err)
+		}
+		kinds = append(kinds, event.Type)
+		events = append(events, event)
+	}
+	if got, want := strings.Join(kinds, ","), "text,text,tool_call,tool_result,usage,text,turn"; got != want {
+		t.Fatalf("got events %s, want %s", got, want)
+	}
+	// The tool call is announced once its arguments are complete, without the result.
+	if call := events[2].ToolCall; call.Arguments != `{"city": "Paris"}` || call.Result != "" || call.IsError {
+		t.Errorf("unexpected tool call %+v", call)
+	}
+	if call := events[3].ToolCall; call.Result != "unavailable" || !call.IsError {
+		t.Errorf("unexpected tool result %+v", call)
+	}
+	if turn := events[6].Turn; turn.Answer != "It is sunny." || len(turn.ToolCalls) != 1 {
+		t.Errorf("unexpected turn %+v", turn)
+	}
+}
+
+func TestTurnWriterFail(t *testing.T) {
+	var stdout bytes.Buffer
+	w, err := newTurnWriter("json", &stdout, &stdout, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.fail(errors.New("model unavailable"))
+
+	var record turnRecord
+	if err := json.Unmarshal(stdout.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Error != "model unavailable" {
+		t.Errorf("got error %q, want %q", record.Error, "model unavailable")
+	}
+
+	if _, err := newTurnWriter("yaml", &stdout, &stdout, false); err == nil {
+		t.Error("expected an invalid format to fail")
+	}
+}
@@ -5,14 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/nanobot-ai/nanobot/pkg/channel"
+	"github.com/nanobot-ai/nanobot/pkg/config"
 	"github.com/nanobot-ai/nanobot/pkg/confirm"
+	"github.com/nanobot-ai/nanobot/pkg/failures"
+	"github.com/nanobot-ai/nanobot/pkg/github"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/printer"
 	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/servers/slack"
+	"github.com/nanobot-ai/nanobot/pkg/teams"
+	"github.com/nanobot-ai/nanobot/pkg/telegram"
+	"github.com/nanobot-ai/nanobot/pkg/twilio"
 	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/whatsapp"
 	"github.com/spf13/cobra"
 )
 
@@ -20,8 +29,62 @@ type Run struct {
 	ListenAddress string   `usage:"Address to listen on" default:"localhost:8080" short:"a"`
 	DisableUI     bool     `usage:"Disable the UI"`
 	HealthzPath   string   `usage:"Path to serve healthz on"`
+	MetricsPath   string   `usage:"Path to serve Prometheus metrics on, unauthenticated (default: disabled)"`
 	Roots         []string `usage:"Roots to expose the MCP server in the form of name:directory" short:"r"`
-	n             *Nanobot
+	WatchInterval string   `usage:"How often to check the config files for changes to reload, 0 to only reload on SIGHUP and POST /api/config/reload by admins" default:"2s"`
+	OpenAIAPI     bool     `usage:"Serve the entrypoint agents as models of an OpenAI compatible API on /v1/models and /v1/chat/completions, with the same auth as MCP" name:"openai-api"`
+	GRPC          bool     `usage:"Serve the gRPC API nanobot.v1.Nanobot on the listen address over HTTP/2 without TLS, with the same auth as MCP" name:"grpc"`
+
+	ToolFailureDigestWebhook  string `usage:"Webhook URL to post a periodic digest of recurring tool failures to (default: disabled)" env:"NANOBOT_TOOL_FAILURE_DIGEST_WEBHOOK"`
+	ToolFailureDigestAgent    string `usage:"Agent that summarizes recurring tool failures into the digest (default: list the failures)"`
+	ToolFailureDigestInterval string `usage:"How often to post the tool failure digest" default:"24h"`
+	ToolFailureDigestMinCount int    `usage:"Failures of the same kind within the interval to be included in the digest" default:"3"`
+	ToolFailureDigestFormat   string `usage:"Payload format of the tool failure digest webhook (slack, teams, text)" default:"slack"`
+
+	TeamsAppID       string `usage:"Microsoft App ID of the Azure Bot to serve Microsoft Teams on /api/teams/messages (default: disabled)" env:"MICROSOFT_APP_ID"`
+	TeamsAppPassword string `usage:"Client secret of the Azure Bot" env:"MICROSOFT_APP_PASSWORD"`
+	TeamsTenantID    string `usage:"Tenant ID of a single tenant Azure Bot, messages from other tenants are rejected" env:"MICROSOFT_APP_TENANT_ID"`
+	TeamsAgent       string `usage:"Agent that answers in Microsoft Teams (default: the default agent)"`
+
+	SlackBotToken      string   `usage:"Bot token of the Slack app to serve Slack on /api/slack/events and /api/slack/commands (default: disabled)" env:"SLACK_BOT_TOKEN"`
+	SlackSigningSecret string   `usage:"Signing secret of the Slack app, used to verify the requests of Slack" env:"SLACK_SIGNING_SECRET"`
+	SlackAllowedUsers  []string `usage:"IDs of the Slack users allowed to talk to the app (default: everyone)"`
+	SlackAgent         string   `usage:"Agent that answers in Slack unless users pick another one with the slash command (default: the default agent)"`
+
+	TelegramBotToken      string   `usage:"Token of the Telegram bot to serve on /api/telegram/webhook (default: disabled)" env:"TELEGRAM_BOT_TOKEN"`
+	TelegramWebhookSecret string   `usage:"Secret token Telegram sends with every update of the webhook" env:"TELEGRAM_WEBHOOK_SECRET"`
+	TelegramWebhookURL    string   `usage:"Public URL of /api/telegram/webhook to register as the webhook of the bot on startup"`
+	TelegramAllowedUsers  []string `usage:"IDs or usernames of the Telegram users allowed to talk to the bot (default: everyone)"`
+	TelegramAgent         string   `usage:"Agent that answers in Telegram (default: the default agent)"`
+
+	WhatsAppAccessToken    string   `usage:"Access token of the WhatsApp Cloud API to serve WhatsApp on /api/whatsapp/webhook (default: disabled)" env:"WHATSAPP_ACCESS_TOKEN"`
+	WhatsAppAppSecret      string   `usage:"Secret of the Meta app, used to verify WhatsApp notifications" env:"WHATSAPP_APP_SECRET"`
+	WhatsAppVerifyToken    string   `usage:"Verify token of the WhatsApp webhook" env:"WHATSAPP_VERIFY_TOKEN"`
+	WhatsAppAllowedNumbers []string `usage:"Phone numbers allowed to talk to the WhatsApp bot, in international format without + (default: everyone)"`
+	WhatsAppAgent          string   `usage:"Agent that answers in WhatsApp (default: the default agent)"`
+
+	TwilioAuthToken      string   `usage:"Auth token of the Twilio account to answer phone calls on /api/twilio/voice (default: disabled)" env:"TWILIO_AUTH_TOKEN"`
+	TwilioPublicURL      string   `usage:"Public URL nanobot is reachable at from Twilio, such as https://bot.example.com" env:"TWILIO_PUBLIC_URL"`
+	TwilioAllowedNumbers []string `usage:"Phone numbers allowed to call, in E.164 format (default: everyone)"`
+	TwilioAgent          string   `usage:"Agent that answers phone calls (default: the default agent)"`
+	TwilioGreeting       string   `usage:"Greeting spoken when a call is answered" default:"Hello, how can I help you?"`
+	TwilioLanguage       string   `usage:"Language of the speech recognition and synthesis of phone calls" default:"en-US"`
+	TwilioVoice          string   `usage:"Voice of the speech synthesis of phone calls (default: the default voice of Twilio)"`
+
+	GitHubAppID         string   `usage:"ID of the GitHub App whose events are handled on /api/github/webhook (default: disabled)" env:"GITHUB_APP_ID"`
+	GitHubPrivateKey    string   `usage:"Private key of the GitHub App, PEM encoded or the path of the key file" env:"GITHUB_APP_PRIVATE_KEY"`
+	GitHubWebhookSecret string   `usage:"Secret of the webhook of the GitHub App" env:"GITHUB_WEBHOOK_SECRET"`
+	GitHubAgent         string   `usage:"Agent that reviews pull requests and triages issues (default: the default agent)"`
+	GitHubTriageLabels  []string `usage:"Labels that start the triage of an issue (default: every label)"`
+	GitHubDryRun        bool     `usage:"Log the reviews and comments of the GitHub App instead of posting them"`
+	GitHubRunsPerHour   int      `usage:"Maximum number of GitHub events handled per repository and hour" default:"20"`
+	GitHubAPIURL        string   `usage:"URL of the GitHub REST API, for GitHub Enterprise Server" default:"https://api.github.com"`
+
+	NonInteractive bool   `usage:"Send the PROMPT, or the input piped to stdin, to the agent, stream the answer to stdout, and exit"`
+	Agent          string `usage:"Agent that answers in non-interactive mode (default: the default agent)"`
+	Output         string `usage:"Output format of non-interactive mode (text, markdown, json, ndjson-events), other formats than text imply --non-interactive" short:"o" default:"text"`
+
+	n *Nanobot
 }
 
 func NewRun(n *Nanobot) *Run {
@@ -42,7 +105,24 @@ func (r *Run) Customize(cmd *cobra.Command) {
 
   # Run the nanobot.yaml at the URL
   nanobot run https://....
+
+  # Answer a single prompt and exit, for scripts and CI jobs
+  nanobot run --non-interactive . "Summarize the open issues"
+  git diff | nanobot run --non-interactive . "Review this change"
+
+  # Print the answer, the tool calls, and the token usage as JSON
+  nanobot run -o json . "What is the weather like today?"
 `
+	cmd.Long = `Run the nanobot with the specified config file, serving its agents on the UI and MCP.
+
+With --non-interactive the PROMPT, followed by the input piped to stdin, is sent to the agent
+instead, and the answer is streamed to stdout while tool calls are printed to stderr. The exit code
+is 0 on success, 1 if the prompt could not be sent, 2 if the model or the agent failed, and 3 if
+the agent answered but a tool call failed.
+
+--output selects the format of the answer: text streams it, markdown prints the tool calls and the
+answer as a document, json prints a record of the turn with the tool calls and the token usage,
+and ndjson-events prints an event per line as the turn runs, with the record of the turn last.`
 }
 
 func (r *Run) getRoots() ([]mcp.Root, error) {
@@ -55,41 +135,20 @@ func (r *Run) getRoots() ([]mcp.Root, error) {
 		rootDefs = []string{"cwd:."}
 	}
 
-	for _, root := range rootDefs {
-		name, directory, ok := strings.Cut(root, ":")
-		if !ok {
-			name = filepath.Base(root)
-			directory = root
-		}
-		if !filepath.IsAbs(directory) {
-			wd, err := os.Getwd()
-			if err != nil {
-				return nil, fmt.Errorf("failed to get current working directory: %w", err)
-			}
-			directory = filepath.Join(wd, directory)
+	for _, def := range rootDefs {
+		root, err := mcp.ParseRoot(def)
+		if err != nil {
+			return nil, err
 		}
-		if _, err := os.Stat(directory); err != nil {
-			return nil, fmt.Errorf("failed to stat directory root (%s): %w", name, err)
+		if _, err := os.Stat(strings.TrimPrefix(root.URI, "file://")); err != nil {
+			return nil, fmt.Errorf("failed to stat directory root (%s): %w", root.Name, err)
 		}
-
-		roots = append(roots, mcp.Root{
-			Name: name,
-			URI:  "file://" + directory,
-		})
+		roots = append(roots, root)
 	}
 
 	return roots, nil
 }
 
-func (r *Run) reload(ctx context.Context, client *mcp.Client, cfgPath string, runtimeOpt runtime.Options) error {
-	_, err := r.n.ReadConfig(ctx, cfgPath, runtimeOpt)
-	if err != nil {
-		return fmt.Errorf("failed to reload config: %w", err)
-	}
-
-	return client.Session.Exchange(ctx, "initialize", mcp.InitializeRequest{}, &mcp.InitializeResult{})
-}
-
 func (r *Run) Run(cmd *cobra.Command, args []string) (err error) {
 	roots, err := r.getRoots()
 	if err != nil {
@@ -108,7 +167,22 @@ func (r *Run) Run(cmd *cobra.Command, args []string) (err error) {
 		cfgPath = args[0]
 	}
 
-	cfgFactory := types.ConfigFactory(func(ctx context.Context, profiles string) (types.Config, error) {
+	if r.NonInteractive || r.Output != "text" {
+		var promptArgs []string
+		if len(args) > 1 {
+			promptArgs = args[1:]
+		}
+		return r.runNonInteractive(cmd.Context(), cfgPath, promptArgs, runtimeOpt)
+	}
+
+	watchInterval, err := time.ParseDuration(r.WatchInterval)
+	if err != nil || watchInterval < 0 {
+		return fmt.Errorf("invalid watch interval %q", r.WatchInterval)
+	}
+
+	// The watcher keeps the config in memory and swaps it when it changes, new turns pick up the
+	// new config while running turns finish with the old one.
+	watcher := config.NewWatcher(cfgPath, func(ctx context.Context, profiles string) (types.Config, error) {
 		optCopy := runtimeOpt
 		if profiles != "" {
 			optCopy.Profiles = append(optCopy.Profiles, strings.Split(profiles, ",")...)
@@ -119,6 +193,7 @@ func (r *Run) Run(cmd *cobra.Command, args []string) (err error) {
 		}
 		return *cfg, nil
 	})
+	cfgFactory := types.ConfigFactory(watcher.Config)
 
 	once, err := cfgFactory(cmd.Context(), "")
 	if err != nil {
@@ -128,13 +203,137 @@ func (r *Run) Run(cmd *cobra.Command, args []string) (err error) {
 	cfg, _ := json.MarshalIndent(once, "", "  ")
 	printer.Prefix("config", string(cfg))
 
-	runtime, err := r.n.GetRuntime(runtimeOpt, runtime.Options{
-		OAuthRedirectURL: "http://" + strings.Replace(r.ListenAddress, "127.0.0.1", "localhost", 1) + "/oauth/callback",
-		DSN:              r.n.DSN(),
-	})
+	runtime, err := r.n.GetRuntime(runtimeOpt, r.runtimeOptions())
 	if err != nil {
 		return err
 	}
 
-	return r.n.runMCP(cmd.Context(), cfgFactory, runtime, callbackHandler, r.ListenAddress, r.HealthzPath, !r.DisableUI)
+	if err := r.startToolFailureDigest(cmd.Context(), cfgFactory, runtime); err != nil {
+		return err
+	}
+
+	go watcher.Watch(cmd.Context(), watchInterval)
+
+	var channels channelOptions
+	if r.TeamsAppID != "" {
+		channels.teams = &teams.Options{
+			Options:     channel.Options{Agent: r.TeamsAgent, DSN: r.n.DSN()},
+			AppID:       r.TeamsAppID,
+			AppPassword: r.TeamsAppPassword,
+			TenantID:    r.TeamsTenantID,
+		}
+	}
+
+	if r.SlackBotToken != "" {
+		channels.slack = &slack.Options{
+			Options:       channel.Options{Agent: r.SlackAgent, DSN: r.n.DSN()},
+			BotToken:      r.SlackBotToken,
+			SigningSecret: r.SlackSigningSecret,
+			AllowedUsers:  r.SlackAllowedUsers,
+		}
+	}
+
+	if r.TelegramBotToken != "" {
+		channels.telegram = &telegram.Options{
+			Options:       channel.Options{Agent: r.TelegramAgent, DSN: r.n.DSN()},
+			Token:         r.TelegramBotToken,
+			WebhookSecret: r.TelegramWebhookSecret,
+			WebhookURL:    r.TelegramWebhookURL,
+			AllowedUsers:  r.TelegramAllowedUsers,
+		}
+	}
+	if r.WhatsAppAccessToken != "" {
+		channels.whatsApp = &whatsapp.Options{
+			Options:        channel.Options{Agent: r.WhatsAppAgent, DSN: r.n.DSN()},
+			AccessToken:    r.WhatsAppAccessToken,
+			AppSecret:      r.WhatsAppAppSecret,
+			VerifyToken:    r.WhatsAppVerifyToken,
+			AllowedNumbers: r.WhatsAppAllowedNumbers,
+		}
+	}
+	if r.TwilioAuthToken != "" {
+		channels.twilio = &twilio.Options{
+			Options:        channel.Options{Agent: r.TwilioAgent, DSN: r.n.DSN()},
+			AuthToken:      r.TwilioAuthToken,
+			PublicURL:      r.TwilioPublicURL,
+			AllowedNumbers: r.TwilioAllowedNumbers,
+			Greeting:       r.TwilioGreeting,
+			Language:       r.TwilioLanguage,
+			Voice:          r.TwilioVoice,
+		}
+	}
+
+	if r.GitHubAppID != "" {
+		channels.github = &github.Options{
+			Options:       channel.Options{Agent: r.GitHubAgent, DSN: r.n.DSN()},
+			AppID:         r.GitHubAppID,
+			PrivateKey:    r.GitHubPrivateKey,
+			WebhookSecret: r.GitHubWebhookSecret,
+			TriageLabels:  r.GitHubTriageLabels,
+			DryRun:        r.GitHubDryRun,
+			RunsPerHour:   r.GitHubRunsPerHour,
+			APIURL:        r.GitHubAPIURL,
+		}
+	}
+
+	return r.n.runMCP(cmd.Context(), cfgFactory, runtime, callbackHandler, r.ListenAddress, r.HealthzPath, r.MetricsPath, !r.DisableUI, r.OpenAIAPI, r.GRPC, watcher, channels)
+}
+
+// runtimeOptions are the options of the runtime that serves the config. The DSN stores the OAuth
+// tokens of MCP servers and enables the nanobot.resources server of the UI.
+func (r *Run) runtimeOptions() runtime.Options {
+	return runtime.Options{
+		OAuthRedirectURL: "http://" + strings.Replace(r.ListenAddress, "127.0.0.1", "localhost", 1) + "/oauth/callback",
+		DSN:              r.n.DSN(),
+	}
+}
+
+func (r *Run) startToolFailureDigest(ctx context.Context, cfgFactory types.ConfigFactory, runt *runtime.Runtime) error {
+	if r.ToolFailureDigestWebhook == "" {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(r.ToolFailureDigestInterval)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid tool failure digest interval %q", r.ToolFailureDigestInterval)
+	}
+
+	switch r.ToolFailureDigestFormat {
+	case "slack", "teams", "text":
+	default:
+		return fmt.Errorf("invalid tool failure digest format %q, must be slack, teams, or text", r.ToolFailureDigestFormat)
+	}
+
+	digest := failures.Digest{
+		Interval: interval,
+		MinCount: r.ToolFailureDigestMinCount,
+		Webhook:  r.ToolFailureDigestWebhook,
+		Format:   r.ToolFailureDigestFormat,
+	}
+
+	if agent := r.ToolFailureDigestAgent; agent != "" {
+		digest.Summarize = func(ctx context.Context, prompt string) (string, error) {
+			cfg, err := cfgFactory(ctx, "")
+			if err != nil {
+				return "", err
+			}
+			result, err := runt.Call(runt.WithTempSession(ctx, &cfg), agent, agent, types.SampleCallRequest{
+				Prompt: prompt,
+			})
+			if err != nil {
+				return "", err
+			}
+			var text strings.Builder
+			for _, content := range result.Content {
+				text.WriteString(content.Text)
+			}
+			if result.IsError {
+				return "", fmt.Errorf("agent %s failed: %s", agent, text.String())
+			}
+			return text.String(), nil
+		}
+	}
+
+	digest.Start(ctx)
+	return nil
 }
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+)
+
+func TestRunRuntimeResources(t *testing.T) {
+	r := NewRun(&Nanobot{
+		State:                 filepath.Join(t.TempDir(), "nanobot.db"),
+		CircuitBreakerTimeout: "30s",
+	})
+	r.ListenAddress = "127.0.0.1:8080"
+
+	if opt := r.runtimeOptions(); opt.OAuthRedirectURL != "http://localhost:8080/oauth/callback" || opt.DSN != r.n.DSN() {
+		t.Errorf("unexpected runtime options %+v", opt)
+	}
+
+	runt, err := r.n.GetRuntime(r.runtimeOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := mcp.NewEmptySession(t.Context()).Context()
+
+	// The UI lists nanobot.resources, which is only registered with a DSN.
+	client, err := runt.GetClient(ctx, "nanobot.resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if !slices.Contains(names, "create_resource") {
+		t.Errorf("expected create_resource in the tools of nanobot.resources, got %v", names)
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/spf13/cobra"
+)
+
+type SessionDelete struct {
+	Nanobot *Nanobot
+}
+
+func NewSessionDelete(n *Nanobot) *SessionDelete {
+	return &SessionDelete{
+		Nanobot: n,
+	}
+}
+
+func (d *SessionDelete) Customize(cmd *cobra.Command) {
+	cmd.Use = "delete [flags] SESSION_ID..."
+	cmd.Short = "Permanently delete sessions with their attachments and history"
+	cmd.Long = `Permanently delete sessions with their attachments and history. The server should not be
+running with the sessions open while deleting, or they may be written back.`
+	cmd.Aliases = []string{"rm"}
+	cmd.Args = cobra.MinimumNArgs(1)
+	cmd.Example = `
+  # Delete the most recent session
+  nanobot sessions delete last
+
+  # Delete two sessions by a prefix of their IDs
+  nanobot sessions delete 3f2a 9c1e
+`
+}
+
+func (d *SessionDelete) Run(cmd *cobra.Command, args []string) error {
+	manager, err := session.NewManager(d.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	// All sessions are looked up first, so an unknown ID deletes nothing.
+	ids := make([]string, 0, len(args))
+	for _, arg := range args {
+		stored, err := findSession(cmd.Context(), manager.DB, arg)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, stored.SessionID)
+	}
+
+	if err := manager.Delete(cmd.Context(), ids...); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		fmt.Println("Deleted session", id)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/notebook"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+type SessionExport struct {
+	Nanobot *Nanobot
+	File    string `usage:"File to write the export to (default: stdout)" short:"f"`
+	Format  string `usage:"Export format, notebook for the code execution history or archive for the whole session to import with 'sessions import'" default:"notebook"`
+}
+
+func NewSessionExport(n *Nanobot) *SessionExport {
+	return &SessionExport{
+		Nanobot: n,
+	}
+}
+
+func (e *SessionExport) Customize(cmd *cobra.Command) {
+	cmd.Use = "export [flags] SESSION_ID"
+	cmd.Short = "Export a session's code execution history as a Jupyter notebook (.ipynb), or the whole session as an archive"
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `
+  # Export the most recent session to analysis.ipynb
+  nanobot sessions export last -f analysis.ipynb
+
+  # Export the messages, tool calls, usage, and attachments of the most recent session
+  nanobot sessions export last --format archive -f session.tar.gz
+`
+}
+
+func (e *SessionExport) Run(cmd *cobra.Command, args []string) error {
+	store, err := session.NewStoreFromDSN(e.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	stored, err := findSession(cmd.Context(), store, args[0])
+	if err != nil {
+		return err
+	}
+
+	switch e.Format {
+	case "notebook":
+	case "archive":
+		return e.exportArchive(cmd.Context(), stored.SessionID)
+	default:
+		return fmt.Errorf("invalid format %q, must be notebook or archive", e.Format)
+	}
+
+	if err := store.ExpandHistory(cmd.Context(), stored); err != nil {
+		return err
+	}
+
+	var execution types.Execution
+	if thread, ok := stored.State.Attributes[types.PreviousExecutionKey]; ok {
+		if err := mcp.JSONCoerce(thread, &execution); err != nil {
+			return fmt.Errorf("failed to decode session history: %w", err)
+		}
+	}
+
+	data, err := notebook.Marshal(execution.Messages())
+	if err != nil {
+		return fmt.Errorf("failed to marshal notebook: %w", err)
+	}
+
+	if e.File == "" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	return os.WriteFile(e.File, append(data, '\n'), 0644)
+}
+
+func (e *SessionExport) exportArchive(ctx context.Context, id string) error {
+	manager, err := session.NewManager(e.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	if e.File == "" {
+		return manager.Export(ctx, id, os.Stdout)
+	}
+
+	f, err := os.OpenFile(e.File, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := manager.Export(ctx, id, f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
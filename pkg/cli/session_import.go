@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/spf13/cobra"
+)
+
+type SessionImport struct {
+	Nanobot *Nanobot
+	Account string `usage:"Account to own the imported session (default: the account in the archive)"`
+}
+
+func NewSessionImport(n *Nanobot) *SessionImport {
+	return &SessionImport{
+		Nanobot: n,
+	}
+}
+
+func (i *SessionImport) Customize(cmd *cobra.Command) {
+	cmd.Use = "import [flags] FILE"
+	cmd.Short = "Import a session archive written by 'sessions export --format archive'"
+	cmd.Long = `Import a session with its messages, tool calls, usage, and attachments from an archive. The IDs
+of the archive are kept unless a session or attachment with the same ID already exists, then the
+imported one gets a new ID. Use - as FILE to read the archive from stdin.`
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `
+  # Copy a session to another nanobot
+  nanobot sessions export last --format archive -f session.tar.gz
+  nanobot --state postgres://db.example.com/nanobot sessions import session.tar.gz
+`
+}
+
+func (i *SessionImport) Run(cmd *cobra.Command, args []string) error {
+	manager, err := session.NewManager(i.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	imported, err := manager.Import(cmd.Context(), in, session.ImportOptions{
+		AccountID: i.Account,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(imported.SessionID)
+	return nil
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/spf13/cobra"
+)
+
+type SessionMigrate struct {
+	Nanobot    *Nanobot
+	Serializer string `usage:"Format to encode the stored messages with (json, cbor, protobuf), defaults to --session-serializer"`
+}
+
+func NewSessionMigrate(n *Nanobot) *SessionMigrate {
+	return &SessionMigrate{
+		Nanobot: n,
+	}
+}
+
+func (m *SessionMigrate) Customize(cmd *cobra.Command) {
+	cmd.Use = "migrate [flags]"
+	cmd.Short = "Encode the stored older messages of threads with another serializer"
+	cmd.Long = `Encode the older messages of threads that are stored in the history table with the serializer,
+in batches. Messages are read in the format they were written with, so nanobot keeps working while
+the migration runs and it can be stopped and run again.`
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `
+  # Store the messages as CBOR, which is smaller and faster to decode than JSON
+  nanobot sessions migrate --serializer cbor
+
+  # Go back to JSON
+  nanobot sessions migrate --serializer json
+`
+}
+
+func (m *SessionMigrate) Run(cmd *cobra.Command, _ []string) error {
+	manager, err := session.NewManager(m.Nanobot.DSN(), session.ManagerOptions{
+		Serializer: cmp.Or(m.Serializer, m.Nanobot.SessionSerializer),
+	})
+	if err != nil {
+		return err
+	}
+
+	migrated, err := manager.DB.MigrateHistory(cmd.Context())
+	fmt.Printf("Migrated %d messages\n", migrated)
+	return err
+}
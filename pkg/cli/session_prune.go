@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/spf13/cobra"
+)
+
+type SessionPrune struct {
+	Nanobot   *Nanobot
+	OlderThan string `usage:"Delete the sessions that were not active for this long, such as 720h" default:"720h"`
+	DryRun    bool   `usage:"Only list the sessions that would be deleted"`
+	Output    string `usage:"Output format (json, yaml, table)" short:"o" default:"table"`
+}
+
+func NewSessionPrune(n *Nanobot) *SessionPrune {
+	return &SessionPrune{
+		Nanobot: n,
+	}
+}
+
+func (p *SessionPrune) Customize(cmd *cobra.Command) {
+	cmd.Use = "prune [flags]"
+	cmd.Short = "Delete the sessions that were not active for a while"
+	cmd.Long = `Permanently delete the sessions that were not active for longer than --older-than, with their
+attachments and history, regardless of the retention of their agents. Sessions that were deleted
+before are purged too.`
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `
+  # Show the sessions that were not active for a week
+  nanobot sessions prune --older-than 168h --dry-run
+
+  # Delete them
+  nanobot sessions prune --older-than 168h
+`
+}
+
+func (p *SessionPrune) Run(cmd *cobra.Command, _ []string) error {
+	olderThan, err := time.ParseDuration(p.OlderThan)
+	if err != nil || olderThan <= 0 {
+		return fmt.Errorf("invalid --older-than %q, must be a duration such as 720h", p.OlderThan)
+	}
+
+	manager, err := session.NewManager(p.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	report, err := manager.Expire(cmd.Context(), session.RetentionOptions{
+		Policy:   session.RetentionPolicy{MaxAge: olderThan},
+		DryRun:   p.DryRun,
+		Override: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if display(report, p.Output) {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tACCT\tREASON")
+	for _, expired := range report.Sessions {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", expired.SessionID, trim(expired.AccountID), expired.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if p.DryRun {
+		fmt.Printf("\n%d sessions would be deleted\n", len(report.Sessions))
+	} else {
+		fmt.Printf("\nDeleted %d sessions\n", len(report.Sessions))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/spf13/cobra"
+)
+
+type SessionRename struct {
+	Nanobot *Nanobot
+}
+
+func NewSessionRename(n *Nanobot) *SessionRename {
+	return &SessionRename{
+		Nanobot: n,
+	}
+}
+
+func (r *SessionRename) Customize(cmd *cobra.Command) {
+	cmd.Use = "rename [flags] SESSION_ID DESCRIPTION..."
+	cmd.Short = "Change the description of a session, which is its name in the list of chats"
+	cmd.Args = cobra.MinimumNArgs(2)
+	cmd.Example = `
+  # Rename the most recent session
+  nanobot sessions rename last Quarterly report draft
+`
+}
+
+func (r *SessionRename) Run(cmd *cobra.Command, args []string) error {
+	manager, err := session.NewManager(r.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	stored, err := findSession(cmd.Context(), manager.DB, args[0])
+	if err != nil {
+		return err
+	}
+
+	description := strings.Join(args[1:], " ")
+	if err := manager.Rename(cmd.Context(), stored.SessionID, description); err != nil {
+		return err
+	}
+	fmt.Printf("Renamed session %s to %q\n", stored.SessionID, description)
+	return nil
+}
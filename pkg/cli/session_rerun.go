@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/rerun"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+type SessionRerun struct {
+	Nanobot *Nanobot
+	Turn    int    `usage:"Turn to re-run, counting from 0, negative values count back from the last turn" default:"-1"`
+	Dump    bool   `usage:"Print the request of the turn as JSON and exit without re-running it"`
+	Request string `usage:"File with an edited request, as printed by --dump, to run instead of the stored one"`
+	Edit    bool   `usage:"Open the request of the turn in $EDITOR before re-running it"`
+}
+
+func NewSessionRerun(n *Nanobot) *SessionRerun {
+	return &SessionRerun{
+		Nanobot: n,
+	}
+}
+
+func (r *SessionRerun) Customize(cmd *cobra.Command) {
+	cmd.Use = "rerun [flags] SESSION_ID"
+	cmd.Short = "Re-run a single turn of a session with modified inputs and show how the output changed"
+	cmd.Long = `Re-run a single completion of a stored session against the provider and print a diff of the
+original and new output. The system prompt, history, tool results, and settings of the turn can be
+edited first with --edit, or dumped with --dump, modified, and passed back with --request. Tools
+are not executed, only the completion of the selected turn is repeated. Combine with --llm-replay
+to run the turn against recorded completions.`
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `
+  # Re-run the last turn of the most recent session
+  nanobot sessions rerun last
+
+  # Change the system prompt of the second turn and re-run it
+  nanobot sessions rerun --turn 1 --edit last
+
+  # Dump a turn, edit it, and re-run it
+  nanobot sessions rerun --dump last > turn.json
+  nanobot sessions rerun --request turn.json last
+`
+}
+
+func (r *SessionRerun) Run(cmd *cobra.Command, args []string) error {
+	store, err := session.NewStoreFromDSN(r.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	stored, err := findSession(cmd.Context(), store, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := store.ExpandHistory(cmd.Context(), stored); err != nil {
+		return err
+	}
+
+	var execution types.Execution
+	if thread, ok := stored.State.Attributes[types.PreviousExecutionKey]; ok {
+		if err := mcp.JSONCoerce(thread, &execution); err != nil {
+			return fmt.Errorf("failed to decode session history: %w", err)
+		}
+	}
+
+	turn, err := rerun.Find(rerun.Turns(execution), r.Turn)
+	if err != nil {
+		return err
+	}
+
+	if r.Dump {
+		data, err := json.MarshalIndent(turn.Request, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+
+	req := turn.Request
+	if r.Request != "" {
+		if req, err = readRequest(r.Request); err != nil {
+			return err
+		}
+	} else if r.Edit {
+		if req, err = editRequest(req); err != nil {
+			return err
+		}
+	}
+
+	llmConfig, err := r.Nanobot.llmConfig()
+	if err != nil {
+		return err
+	}
+
+	resp, err := llm.NewClient(llmConfig).Complete(cmd.Context(), req)
+	if err != nil {
+		return fmt.Errorf("failed to re-run turn %d: %w", turn.Index, err)
+	}
+
+	diff := rerun.Diff(turn.Output, resp.Output)
+	if diff == "" {
+		fmt.Printf("Turn %d: output unchanged\n", turn.Index)
+		return nil
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+func readRequest(file string) (req types.CompletionRequest, _ error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return req, err
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, fmt.Errorf("failed to parse request %s: %w", file, err)
+	}
+	return req, nil
+}
+
+func editRequest(req types.CompletionRequest) (types.CompletionRequest, error) {
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return req, err
+	}
+
+	f, err := os.CreateTemp("", "nanobot-turn-*.json")
+	if err != nil {
+		return req, err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return req, err
+	}
+	if err := f.Close(); err != nil {
+		return req, err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	editCmd := exec.Command(editor, f.Name())
+	editCmd.Stdin, editCmd.Stdout, editCmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return req, fmt.Errorf("failed to run editor %s: %w", editor, err)
+	}
+
+	return readRequest(f.Name())
+}
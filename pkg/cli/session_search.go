@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/spf13/cobra"
+)
+
+type SessionSearch struct {
+	Nanobot *Nanobot
+	Account string `usage:"Only search the sessions of this account"`
+	Limit   int    `usage:"Number of sessions to show" default:"20" short:"n"`
+	Offset  int    `usage:"Number of ranked sessions to skip"`
+	Output  string `usage:"Output format (json, yaml, table)" short:"o" default:"table"`
+}
+
+func NewSessionSearch(n *Nanobot) *SessionSearch {
+	return &SessionSearch{
+		Nanobot: n,
+	}
+}
+
+func (s *SessionSearch) Customize(cmd *cobra.Command) {
+	cmd.Use = "search [flags] QUERY..."
+	cmd.Short = "Search the descriptions and messages of the sessions, best matches first"
+	cmd.Args = cobra.MinimumNArgs(1)
+	cmd.Example = `
+  # Find the session about the billing migration
+  nanobot sessions search billing migration
+
+  # Show the next page of results
+  nanobot sessions search --offset 20 billing migration
+`
+}
+
+func (s *SessionSearch) Run(cmd *cobra.Command, args []string) error {
+	manager, err := session.NewManager(s.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	result, err := manager.Search(cmd.Context(), strings.Join(args, " "), session.SearchOptions{
+		AccountID: s.Account,
+		Limit:     s.Limit,
+		Offset:    s.Offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	if display(result, s.Output) {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tDATE\tSCORE\tDESCRIPTION\tMATCH")
+	for _, hit := range result.Sessions {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%.2f\t%s\t%s\n", hit.SessionID, hit.Updated.Format(time.RFC3339),
+			hit.Score, trim(hit.Description), trim(hit.Snippet))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if shown := s.Offset + len(result.Sessions); shown < result.Total {
+		fmt.Printf("\n%d of %d sessions shown, use --offset %d for more\n", len(result.Sessions), result.Total, shown)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+type SessionShow struct {
+	Nanobot *Nanobot
+	Output  string `usage:"Output format (json, yaml, text)" short:"o" default:"text"`
+}
+
+func NewSessionShow(n *Nanobot) *SessionShow {
+	return &SessionShow{
+		Nanobot: n,
+	}
+}
+
+func (s *SessionShow) Customize(cmd *cobra.Command) {
+	cmd.Use = "show [flags] SESSION_ID"
+	cmd.Short = "Print the transcript of a session, with its tool calls and their results"
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `
+  # Show the most recent session
+  nanobot sessions show last
+
+  # Show a session as JSON, by a prefix of its ID
+  nanobot sessions show -o json 3f2a
+`
+}
+
+func (s *SessionShow) Run(cmd *cobra.Command, args []string) error {
+	manager, err := session.NewManager(s.Nanobot.DSN())
+	if err != nil {
+		return err
+	}
+
+	stored, err := findSession(cmd.Context(), manager.DB, args[0])
+	if err != nil {
+		return err
+	}
+
+	messages, err := manager.Transcript(cmd.Context(), stored)
+	if err != nil {
+		return err
+	}
+
+	if display(struct {
+		sessionInfo
+		Messages []types.Message `json:"messages"`
+	}{newSessionInfo(*stored), messages}, s.Output) {
+		return nil
+	}
+
+	fmt.Printf("Session %s", stored.SessionID)
+	if stored.Description != "" {
+		fmt.Printf(" %q", stored.Description)
+	}
+	fmt.Printf(", last activity %s\n", stored.UpdatedAt.Format(time.RFC3339))
+	for _, msg := range messages {
+		printMessage(msg)
+	}
+	return nil
+}
+
+func printMessage(msg types.Message) {
+	for _, item := range msg.Items {
+		switch {
+		case item.Content != nil && item.Content.Text != "":
+			fmt.Printf("\n[%s] %s\n", msg.Role, item.Content.Text)
+		case item.Content != nil:
+			fmt.Printf("\n[%s] (%s)\n", msg.Role, item.Content.Type)
+		case item.ToolCall != nil:
+			fmt.Printf("\n[tool call] %s %s\n", item.ToolCall.Name, item.ToolCall.Arguments)
+		}
+		if item.ToolCallResult != nil {
+			var texts []string
+			for _, content := range item.ToolCallResult.Output.Content {
+				if content.Text != "" {
+					texts = append(texts, content.Text)
+				}
+			}
+			label := "tool result"
+			if item.ToolCallResult.Output.IsError {
+				label = "tool error"
+			}
+			fmt.Printf("[%s] %s\n", label, strings.Join(texts, "\n"))
+		}
+	}
+}
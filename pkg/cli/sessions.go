@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"text/tabwriter"
 	"time"
@@ -22,10 +24,35 @@ func NewSessions(n *Nanobot) *Sessions {
 
 func (t *Sessions) Customize(cmd *cobra.Command) {
 	cmd.Use = "sessions [flags]"
-	cmd.Short = "List all existing sessions"
+	cmd.Short = "List all existing sessions, most recently active first"
 	cmd.Aliases = []string{"session", "s"}
 	cmd.Args = cobra.NoArgs
-	cmd.Hidden = true
+	cmd.Example = `
+  # List the sessions
+  nanobot sessions
+
+  # List the sessions as JSON, for scripts
+  nanobot sessions -o json
+`
+}
+
+// sessionInfo is a session as listed, without its state.
+type sessionInfo struct {
+	SessionID    string    `json:"sessionID"`
+	Description  string    `json:"description,omitempty"`
+	AccountID    string    `json:"accountID,omitempty"`
+	Created      time.Time `json:"created"`
+	LastActivity time.Time `json:"lastActivity"`
+}
+
+func newSessionInfo(s session.Session) sessionInfo {
+	return sessionInfo{
+		SessionID:    s.SessionID,
+		Description:  s.Description,
+		AccountID:    s.AccountID,
+		Created:      s.CreatedAt,
+		LastActivity: s.UpdatedAt,
+	}
 }
 
 func (t *Sessions) Run(cmd *cobra.Command, args []string) error {
@@ -39,17 +66,40 @@ func (t *Sessions) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	infos := make([]sessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, newSessionInfo(s))
+	}
+	if display(infos, t.Output) {
+		return nil
+	}
+
 	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	_, err = tw.Write([]byte("ID\tDATE\tACCT\tDESCRIPTION\n"))
+	_, err = tw.Write([]byte("ID\tLAST ACTIVITY\tACCT\tDESCRIPTION\n"))
 	if err != nil {
 		return err
 	}
 
-	for _, session := range sessions {
-		_, _ = tw.Write([]byte(session.SessionID + "\t" + session.UpdatedAt.Format(time.RFC3339) +
-			"\t" + trim(session.AccountID) +
-			"\t" + trim(session.Description) + "\n"))
+	for _, info := range infos {
+		_, _ = tw.Write([]byte(info.SessionID + "\t" + info.LastActivity.Format(time.RFC3339) +
+			"\t" + trim(info.AccountID) +
+			"\t" + trim(info.Description) + "\n"))
 	}
 
 	return tw.Flush()
 }
+
+// findSession returns the session whose ID starts with prefix, or the most recent session for
+// "last".
+func findSession(ctx context.Context, store *session.Store, prefix string) (*session.Session, error) {
+	sessions, err := store.FindByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("session %s not found", prefix)
+	} else if len(sessions) > 1 {
+		return nil, fmt.Errorf("session prefix %s matches %d sessions", prefix, len(sessions))
+	}
+	return &sessions[0], nil
+}
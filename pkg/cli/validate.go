@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nanobot-ai/nanobot/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+type Validate struct {
+	Nanobot  *Nanobot
+	Profiles []string `usage:"Profiles of the config to validate" short:"p" name:"profile"`
+	Output   string   `usage:"Output format (text, json, yaml)" short:"o" default:"text"`
+}
+
+func NewValidate(n *Nanobot) *Validate {
+	return &Validate{
+		Nanobot: n,
+	}
+}
+
+func (v *Validate) Customize(cmd *cobra.Command) {
+	cmd.Use = "validate [flags] NANOBOT"
+	cmd.Short = "Check a config for errors without running it"
+	cmd.Long = `Check a config against the schema, the references between its agents, tools, flows, and MCP
+servers, the environment variables its MCP servers use, and if the models of its agents are
+supported by their providers. Errors are reported with the file, line, and column of the value.
+MCP servers are not started, so the tools they provide are not checked. Deprecated fields are
+warnings, or errors with --strict-config. The command fails if there are errors, warnings alone
+do not fail it.`
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `
+  # Validate the nanobot.yaml in the current directory
+  nanobot validate .
+
+  # Validate the config with a profile applied, and print the problems as JSON
+  nanobot validate --profile prod -o json ./nanobot.yaml
+`
+}
+
+func (v *Validate) Run(cmd *cobra.Command, args []string) error {
+	env, err := v.Nanobot.loadEnv()
+	if err != nil {
+		return fmt.Errorf("failed to load environment: %w", err)
+	}
+
+	opts := config.ValidateOptions{
+		Profiles:     v.Profiles,
+		Env:          env,
+		DefaultModel: v.Nanobot.DefaultModel,
+		// Recorded or synthetic completions do not need credentials.
+		CheckProviders: v.Nanobot.LLMReplay == "",
+		Strict:         v.Nanobot.StrictConfig,
+	}
+	if v.Nanobot.OpenAIAPIKey != "" || v.Nanobot.OpenAIBaseURL != "" {
+		opts.Providers = append(opts.Providers, "openai")
+	}
+	if v.Nanobot.AnthropicAPIKey != "" || v.Nanobot.AnthropicBaseURL != "" {
+		opts.Providers = append(opts.Providers, "anthropic")
+	}
+
+	problems, err := config.Validate(cmd.Context(), args[0], opts)
+	if err != nil {
+		return err
+	}
+
+	var errs int
+	for _, problem := range problems {
+		if !problem.Warning {
+			errs++
+		}
+	}
+
+	if !display(problems, v.Output) {
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+	}
+
+	if errs > 0 {
+		return fmt.Errorf("config %s has %d error(s)", args[0], errs)
+	}
+	return nil
+}
@@ -73,11 +73,36 @@ func Name(obj any) string {
 	return commandName
 }
 
+// ExitError is returned by commands that exit with a specific code. Err is printed unless it is
+// nil.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
 func MainCtx(ctx context.Context, cmd *cobra.Command) {
 	if err := cmd.ExecuteContext(ctx); err != nil {
 		if strings.EqualFold("interrupt", err.Error()) || errors.Is(err, context.Canceled) {
 			os.Exit(1)
 		}
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			if exitErr.Err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "%v\n", exitErr.Err)
+			}
+			os.Exit(exitErr.Code)
+		}
 		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
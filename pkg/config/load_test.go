@@ -9,7 +9,7 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-func TestSchema(t *testing.T) {
+func compileSchema(t *testing.T) *jsonschema.Schema {
 	data, err := os.ReadFile("./schema.yaml")
 	if err != nil {
 		t.Fatalf("Failed to read schema file: %v", err)
@@ -29,9 +29,14 @@ func TestSchema(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to compile schema: %v", err)
 	}
+	return s
+}
+
+func TestSchema(t *testing.T) {
+	s := compileSchema(t)
 
 	obj := map[string]any{}
-	err = json.Unmarshal([]byte(`
+	err := json.Unmarshal([]byte(`
 {
 	"auth": {
 		"oauthClientId": "clientid",
@@ -325,3 +330,24 @@ func TestSchema(t *testing.T) {
 		t.Fatalf("Failed to validate schema: %v", err)
 	}
 }
+
+func TestSchemaTopLogprobs(t *testing.T) {
+	s := compileSchema(t)
+
+	for value, valid := range map[string]bool{
+		"0":   true,
+		"5":   true,
+		"20":  true,
+		"2.5": false,
+		"-1":  false,
+		"21":  false,
+	} {
+		obj := map[string]any{}
+		if err := json.Unmarshal([]byte(`{"agents": {"a": {"topLogprobs": `+value+`}}}`), &obj); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Validate(obj); (err == nil) != valid {
+			t.Errorf("topLogprobs %s: got error %v, want valid %v", value, err, valid)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	yamlv3 "go.yaml.in/yaml/v3"
+	"sigs.k8s.io/yaml"
+)
+
+// Deprecation is a field of the config that is still read, but will be removed in a later
+// release.
+type Deprecation struct {
+	// Path of the field, where * matches any name, such as mcpServers.*.sandboxed. Fields of
+	// profiles are matched too.
+	Path string
+	// Message says what replaces the field.
+	Message string
+	// Migrate rewrites the field in the mapping that has it to the new schema. It returns false if
+	// the field must be migrated by hand. Deprecations without Migrate are always migrated by hand.
+	Migrate func(parent *yamlv3.Node, key string) bool
+}
+
+// Deprecations are the deprecated fields of the config. Configs that use them still load with a
+// warning, unless deprecated fields are rejected with strict mode.
+var Deprecations = []Deprecation{
+	{
+		Path:    "mcpServers.*.sandboxed",
+		Message: "sandboxed is deprecated, use sandbox: {} to run the server in a container",
+		Migrate: func(parent *yamlv3.Node, key string) bool {
+			value := mappingValue(parent, key)
+			if value.Tag != "!!bool" {
+				return false
+			}
+			if value.Value == "false" || mappingValue(parent, "sandbox") != nil {
+				deleteKey(parent, key)
+				return true
+			}
+			return renameKey(parent, key, "sandbox", &yamlv3.Node{
+				Kind:  yamlv3.MappingNode,
+				Tag:   "!!map",
+				Style: yamlv3.FlowStyle,
+			})
+		},
+	},
+	{
+		Path:    "mcpServers.*.unsandboxed",
+		Message: "unsandboxed is deprecated and has no effect, servers only run in a container if sandbox is set",
+		Migrate: func(parent *yamlv3.Node, key string) bool {
+			deleteKey(parent, key)
+			return true
+		},
+	},
+	{
+		Path:    "agents.*.builtinTools.web_search_preview",
+		Message: "web_search_preview is deprecated, use web_search",
+		Migrate: func(parent *yamlv3.Node, key string) bool {
+			if mappingValue(parent, "web_search") != nil {
+				return false
+			}
+			return renameKey(parent, key, "web_search", nil)
+		},
+	},
+	{
+		Path: "agents.*.truncation",
+		Message: "truncation is deprecated, it is only supported by OpenAI models; use contextWindow to " +
+			"truncate the history for every provider",
+	},
+}
+
+// Migration is the result of migrating a config file to the new schema.
+type Migration struct {
+	File string `json:"file"`
+	// Data is the migrated file, it is nil if the file has no deprecated fields that could be
+	// migrated.
+	Data []byte `json:"-"`
+	// Migrated are the deprecated fields that were rewritten.
+	Migrated []Problem `json:"migrated,omitempty"`
+	// Manual are the deprecated fields that must be migrated by hand.
+	Manual []Problem `json:"manual,omitempty"`
+}
+
+// Deprecated returns the deprecated fields that the config at path and the local files it extends
+// use.
+func Deprecated(ctx context.Context, path string) ([]Problem, error) {
+	files, err := Files(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	for _, file := range files {
+		doc, err := readNode(file)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, findDeprecated(file, doc, nil)...)
+	}
+	return problems, nil
+}
+
+// Migrate rewrites the deprecated fields of the config at path and the local files it extends to
+// the new schema. The files are not written, the migrated content is returned instead. Comments
+// of YAML files are kept.
+func Migrate(ctx context.Context, path string) ([]Migration, error) {
+	files, err := Files(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Migration
+	for _, file := range files {
+		doc, err := readNode(file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration := Migration{File: file}
+		findDeprecated(file, doc, func(problem Problem, deprecation Deprecation, parent *yamlv3.Node, key string) {
+			if deprecation.Migrate != nil && deprecation.Migrate(parent, key) {
+				migration.Migrated = append(migration.Migrated, problem)
+			} else {
+				migration.Manual = append(migration.Manual, problem)
+			}
+		})
+
+		if len(migration.Migrated) > 0 {
+			migration.Data, err = encodeNode(file, doc)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, migration)
+	}
+	return result, nil
+}
+
+// CheckDeprecated returns an error listing the deprecated fields of the config at path, if it
+// uses any.
+func CheckDeprecated(ctx context.Context, path string) error {
+	problems, err := Deprecated(ctx, path)
+	if err != nil || len(problems) == 0 {
+		return err
+	}
+	lines := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		problem.Warning = false
+		lines = append(lines, "  "+problem.String())
+	}
+	return fmt.Errorf("config %s uses deprecated fields, run \"nanobot config migrate\" to update it:\n%s",
+		path, strings.Join(lines, "\n"))
+}
+
+func readNode(file string) (*yamlv3.Node, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return &doc, nil
+}
+
+// encodeNode encodes a document in the format of the file it was read from.
+func encodeNode(file string, doc *yamlv3.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", file, err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", file, err)
+	}
+	if !strings.EqualFold(filepath.Ext(file), ".json") {
+		return buf.Bytes(), nil
+	}
+
+	data, err := yaml.YAMLToJSON(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", file, err)
+	}
+	buf.Reset()
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", file, err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// findDeprecated returns the deprecated fields of a document as warnings, and calls fn with each
+// of them if it is set. The fields are found before fn is called, so fn can rewrite them.
+func findDeprecated(file string, doc *yamlv3.Node, fn func(Problem, Deprecation, *yamlv3.Node, string)) []Problem {
+	root := doc
+	if root.Kind == yamlv3.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	type match struct {
+		problem     Problem
+		deprecation Deprecation
+		parent      *yamlv3.Node
+		key         string
+	}
+	var matches []match
+
+	// The profiles of a config can set the same fields as the config.
+	for _, prefix := range []string{"", "profiles.*."} {
+		for _, deprecation := range Deprecations {
+			matchPath(root, strings.Split(prefix+deprecation.Path, "."), nil, func(parent, keyNode *yamlv3.Node, location []string) {
+				matches = append(matches, match{
+					problem: Problem{
+						File:    file,
+						Line:    keyNode.Line,
+						Column:  keyNode.Column,
+						Path:    pointer(location),
+						Message: deprecation.Message,
+						Warning: true,
+					},
+					deprecation: deprecation,
+					parent:      parent,
+					key:         keyNode.Value,
+				})
+			})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		if a.problem.Line != b.problem.Line {
+			return a.problem.Line - b.problem.Line
+		}
+		return a.problem.Column - b.problem.Column
+	})
+
+	problems := make([]Problem, 0, len(matches))
+	for _, m := range matches {
+		problems = append(problems, m.problem)
+	}
+	if fn != nil {
+		for _, m := range matches {
+			fn(m.problem, m.deprecation, m.parent, m.key)
+		}
+	}
+	return problems
+}
+
+// matchPath calls fn with the mapping and key of every field below node that matches the segments
+// of a deprecation path.
+func matchPath(node *yamlv3.Node, segments, location []string, fn func(parent, key *yamlv3.Node, location []string)) {
+	if node == nil || node.Kind != yamlv3.MappingNode || len(segments) == 0 {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if segments[0] != "*" && segments[0] != key.Value {
+			continue
+		}
+		keyLocation := append(append([]string{}, location...), key.Value)
+		if len(segments) == 1 {
+			fn(node, key, keyLocation)
+		} else {
+			matchPath(node.Content[i+1], segments[1:], keyLocation, fn)
+		}
+	}
+}
+
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func deleteKey(node *yamlv3.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// renameKey renames a key of a mapping in place, and replaces its value if value is set.
+func renameKey(node *yamlv3.Node, key, newKey string, value *yamlv3.Node) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i].Value = newKey
+			if value != nil {
+				value.HeadComment, value.LineComment = node.Content[i+1].HeadComment, node.Content[i+1].LineComment
+				node.Content[i+1] = value
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nanobot.yaml")
+	if err := os.WriteFile(path, []byte(`agents:
+  main:
+    model: gpt-4.1
+    truncation: auto
+    builtinTools:
+      web_search_preview: {}
+mcpServers:
+  shell:
+    command: npx
+    # run it isolated
+    sandboxed: true
+  other:
+    command: npx
+    unsandboxed: true
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := Validate(t.Context(), path, ValidateOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 4 || problems[0].Path != "/agents/main/truncation" || problems[0].Line != 4 || problems[0].Warning {
+		t.Fatalf("expected 4 deprecated fields as errors, got %+v", problems)
+	}
+	if err := CheckDeprecated(t.Context(), path); err == nil {
+		t.Fatal("expected deprecated fields to be rejected")
+	}
+
+	migrations, err := Migrate(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 1 || len(migrations[0].Migrated) != 3 || len(migrations[0].Manual) != 1 {
+		t.Fatalf("expected 3 migrated fields and 1 manual step, got %+v", migrations)
+	}
+
+	want := `agents:
+  main:
+    model: gpt-4.1
+    truncation: auto
+    builtinTools:
+      web_search: {}
+mcpServers:
+  shell:
+    command: npx
+    # run it isolated
+    sandbox: {}
+  other:
+    command: npx
+`
+	if got := string(migrations[0].Data); got != want {
+		t.Fatalf("unexpected migrated config:\n%s", got)
+	}
+
+	if err := os.WriteFile(path, migrations[0].Data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	problems, err = Deprecated(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, "contextWindow") {
+		t.Fatalf("expected only truncation to be left, got %+v", problems)
+	}
+}
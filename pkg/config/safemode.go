@@ -0,0 +1,60 @@
+package config
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// SafeMode removes every MCP server that runs a process, container, or connects to a remote URL
+// from the config, along with all references to them from agents, so that only the built-in
+// nanobot servers remain and agents talk to the model without external tools. The names of the
+// removed servers are returned.
+func SafeMode(cfg *types.Config) (disabled []string) {
+	for name, server := range cfg.MCPServers {
+		if isExternal(server) {
+			disabled = append(disabled, name)
+		}
+	}
+	if len(disabled) == 0 {
+		return nil
+	}
+	slices.Sort(disabled)
+
+	for _, name := range disabled {
+		delete(cfg.MCPServers, name)
+	}
+
+	references := func(list types.StringList) types.StringList {
+		return slices.DeleteFunc(slices.Clone(list), func(ref string) bool {
+			server, _, _ := strings.Cut(ref, "/")
+			return slices.Contains(disabled, server)
+		})
+	}
+
+	for name, agent := range cfg.Agents {
+		agent.MCPServers = references(agent.MCPServers)
+		agent.Tools = references(agent.Tools)
+		agent.Prompts = references(agent.Prompts)
+		agent.Resources = references(agent.Resources)
+		agent.Before = references(agent.Before)
+		agent.After = references(agent.After)
+		cfg.Agents[name] = agent
+	}
+
+	return disabled
+}
+
+// isExternal reports if the server runs or connects to anything, mocked servers never do.
+func isExternal(server mcp.Server) bool {
+	if server.Mock != nil {
+		return false
+	}
+	return server.Command != "" ||
+		server.BaseURL != "" ||
+		server.Image != "" ||
+		server.Dockerfile != "" ||
+		server.Source.Repo != ""
+}
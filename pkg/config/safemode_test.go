@@ -0,0 +1,41 @@
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestSafeMode(t *testing.T) {
+	cfg := &types.Config{
+		MCPServers: map[string]mcp.Server{
+			"nanobot.meta": {},
+			"shell":        {Command: "npx", Args: []string{"shell-mcp"}},
+			"search":       {BaseURL: "https://example.com/mcp"},
+		},
+		Agents: map[string]types.Agent{
+			"main": {
+				MCPServers: []string{"nanobot.meta", "shell"},
+				Tools:      []string{"search/query", "helper"},
+				After:      []string{"shell/cleanup"},
+			},
+		},
+	}
+
+	disabled := SafeMode(cfg)
+	if !slices.Equal(disabled, []string{"search", "shell"}) {
+		t.Fatalf("unexpected disabled servers: %v", disabled)
+	}
+	if _, ok := cfg.MCPServers["nanobot.meta"]; !ok || len(cfg.MCPServers) != 1 {
+		t.Fatalf("expected only built-in servers to remain, got %v", cfg.MCPServers)
+	}
+
+	agent := cfg.Agents["main"]
+	if !slices.Equal(agent.MCPServers, []string{"nanobot.meta"}) ||
+		!slices.Equal(agent.Tools, []string{"helper"}) ||
+		len(agent.After) != 0 {
+		t.Fatalf("expected references to disabled servers to be removed, got %+v", agent)
+	}
+}
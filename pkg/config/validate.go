@@ -0,0 +1,433 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/expr"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	yamlv3 "go.yaml.in/yaml/v3"
+	"sigs.k8s.io/yaml"
+)
+
+type ValidateOptions struct {
+	Profiles []string
+	// Env is the environment the config runs with. References to variables that are neither set
+	// in it nor declared in the env of the config are reported as warnings.
+	Env map[string]string
+	// DefaultModel is the model of the agents that do not set one.
+	DefaultModel string
+	// Providers are the LLM providers that have credentials, such as openai and anthropic. With
+	// CheckProviders set, agents with a model of another provider are reported.
+	Providers      []string
+	CheckProviders bool
+	// Strict reports deprecated fields as errors rather than warnings.
+	Strict bool
+}
+
+func (o ValidateOptions) Merge(other ValidateOptions) (result ValidateOptions) {
+	result.Profiles = append(o.Profiles, other.Profiles...)
+	result.Env = complete.MergeMap(o.Env, other.Env)
+	result.DefaultModel = complete.Last(o.DefaultModel, other.DefaultModel)
+	result.Providers = append(o.Providers, other.Providers...)
+	result.CheckProviders = o.CheckProviders || other.CheckProviders
+	result.Strict = o.Strict || other.Strict
+	return
+}
+
+// Problem is an error in a config. File, Line, and Column are the position of the value it is about,
+// when it is known.
+type Problem struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	// Path is the JSON pointer of the value in the config, such as /agents/main/model.
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+	// Warning is set for problems that do not stop the config from loading, but are likely to
+	// fail once it runs.
+	Warning bool `json:"warning,omitempty"`
+}
+
+func (p Problem) String() string {
+	var sb strings.Builder
+	if p.File != "" {
+		sb.WriteString(p.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&sb, ":%d:%d", p.Line, p.Column)
+		}
+		sb.WriteString(": ")
+	}
+	if p.Warning {
+		sb.WriteString("warning: ")
+	}
+	if p.Path != "" {
+		sb.WriteString(p.Path + ": ")
+	}
+	sb.WriteString(p.Message)
+	return sb.String()
+}
+
+// Validate checks the config at path without running it: the files against the schema, the
+// references between agents, tools, and MCP servers, the deprecated fields, the environment
+// variables the MCP servers use, and if the models of the agents work with their providers. It returns an error if the config
+// could not be read at all.
+func Validate(ctx context.Context, path string, opts ...ValidateOptions) ([]Problem, error) {
+	opt := complete.Complete(opts...)
+
+	files, err := Files(ctx, path)
+	if err != nil && len(files) == 0 {
+		return nil, err
+	}
+
+	var (
+		problems []Problem
+		docs     = map[string]*yamlv3.Node{}
+	)
+	for _, file := range files {
+		doc, fileProblems, err := validateFile(file)
+		if err != nil {
+			return nil, err
+		}
+		docs[file] = doc
+		problems = append(problems, fileProblems...)
+		if doc != nil {
+			for _, problem := range findDeprecated(file, doc, nil) {
+				problem.Warning = !opt.Strict
+				problems = append(problems, problem)
+			}
+		}
+	}
+	if slices.ContainsFunc(problems, func(p Problem) bool { return !p.Warning }) {
+		// The config can not be loaded before the files are valid.
+		return problems, nil
+	}
+
+	// locate finds the value of a problem in the files, the config file first, then the files it
+	// extends. Without an exact match it points at the closest parent of the value.
+	locate := func(problem Problem) Problem {
+		if len(files) > 0 {
+			problem.File = files[0]
+		}
+		if problem.Path == "" {
+			return problem
+		}
+		var closest *yamlv3.Node
+		for _, file := range files {
+			node, exact := findNode(docs[file], splitPointer(problem.Path), false)
+			if exact {
+				problem.File, problem.Line, problem.Column = file, node.Line, node.Column
+				return problem
+			} else if node != nil && closest == nil && file == files[0] {
+				closest = node
+			}
+		}
+		if closest != nil {
+			problem.Line, problem.Column = closest.Line, closest.Column
+		}
+		return problem
+	}
+
+	cfg, _, err := Load(ctx, path, opt.Profiles...)
+	if err != nil {
+		for _, err := range splitErrors(err) {
+			problems = append(problems, locate(Problem{
+				Path:    errorPath(err.Error()),
+				Message: err.Error(),
+			}))
+		}
+	}
+	if cfg == nil {
+		return problems, nil
+	}
+
+	for _, problem := range checkEnv(*cfg, opt.Env) {
+		problems = append(problems, locate(problem))
+	}
+	for _, problem := range checkModels(*cfg, opt) {
+		problems = append(problems, locate(problem))
+	}
+	return problems, nil
+}
+
+var yamlLine = regexp.MustCompile(`^line (\d+): `)
+
+// validateFile parses a config file and validates it against the schema.
+func validateFile(file string) (*yamlv3.Node, []Problem, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		problem := Problem{
+			File:    file,
+			Message: strings.TrimPrefix(err.Error(), "yaml: "),
+		}
+		if m := yamlLine.FindStringSubmatch(problem.Message); m != nil {
+			problem.Line, _ = strconv.Atoi(m[1])
+			problem.Column = 1
+			problem.Message = strings.TrimPrefix(problem.Message, m[0])
+		}
+		return nil, []Problem{problem}, nil
+	}
+
+	obj := map[string]any{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, []Problem{{File: file, Message: err.Error()}}, nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if err := getSchema().Validate(obj); errors.As(err, &validationErr) {
+		var problems []Problem
+		for _, leaf := range leafErrors(validationErr) {
+			problem := Problem{
+				File:    file,
+				Path:    pointer(leaf.InstanceLocation),
+				Message: leaf.BasicOutput().Error.String(),
+			}
+			location, key := leaf.InstanceLocation, false
+			if additional, ok := leaf.ErrorKind.(*kind.AdditionalProperties); ok && len(additional.Properties) > 0 {
+				// Point at the first unknown property rather than at the object that has it.
+				location, key = append(slices.Clone(location), additional.Properties[0]), true
+			}
+			if node, _ := findNode(&doc, location, key); node != nil {
+				problem.Line, problem.Column = node.Line, node.Column
+			}
+			if !slices.Contains(problems, problem) {
+				problems = append(problems, problem)
+			}
+		}
+		slices.SortStableFunc(problems, func(a, b Problem) int {
+			if a.Line != b.Line {
+				return a.Line - b.Line
+			}
+			return a.Column - b.Column
+		})
+		return &doc, problems, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to validate %s: %w", file, err)
+	}
+
+	return &doc, nil, nil
+}
+
+// leafErrors returns the errors of the schema validation that have no causes. The alternatives of
+// a oneOf or anyOf are not expanded, the error says that none of them matched.
+func leafErrors(err *jsonschema.ValidationError) (result []*jsonschema.ValidationError) {
+	switch err.ErrorKind.(type) {
+	case *kind.OneOf, *kind.AnyOf:
+		return []*jsonschema.ValidationError{err}
+	}
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	for _, cause := range err.Causes {
+		result = append(result, leafErrors(cause)...)
+	}
+	return result
+}
+
+// findNode returns the node at the location in a YAML document and true, or the deepest node on
+// the way to it and false if it does not exist. Objects and lists are returned as the key they are
+// the value of, which is where they start. With key set the key is returned for any value.
+func findNode(doc *yamlv3.Node, location []string, key bool) (*yamlv3.Node, bool) {
+	if doc == nil {
+		return nil, false
+	}
+	node := doc
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	// nodeKey is the key of the mapping entry node is the value of.
+	var nodeKey *yamlv3.Node
+	for i, segment := range location {
+		if node.Kind == yamlv3.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		var next, nextKey *yamlv3.Node
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value == segment {
+					next, nextKey = node.Content[j+1], node.Content[j]
+					break
+				}
+			}
+		case yamlv3.SequenceNode:
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+			}
+		}
+		if next == nil {
+			return position(node, nodeKey), false
+		}
+		node, nodeKey = next, nextKey
+		if key && i == len(location)-1 && nodeKey != nil {
+			return nodeKey, true
+		}
+	}
+	return position(node, nodeKey), true
+}
+
+// position returns the key of a value that is an object or a list, and the value otherwise.
+func position(value, key *yamlv3.Node) *yamlv3.Node {
+	if key != nil && (value.Kind == yamlv3.MappingNode || value.Kind == yamlv3.SequenceNode) {
+		return key
+	}
+	return value
+}
+
+func pointer(location []string) string {
+	if len(location) == 0 {
+		return ""
+	}
+	escaped := make([]string, len(location))
+	for i, segment := range location {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1")
+	}
+	return "/" + strings.Join(escaped, "/")
+}
+
+func splitPointer(p string) []string {
+	if p == "" {
+		return nil
+	}
+	location := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, segment := range location {
+		location[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+	}
+	return location
+}
+
+func splitErrors(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var result []error
+		for _, err := range joined.Unwrap() {
+			result = append(result, splitErrors(err)...)
+		}
+		return result
+	}
+	return []error{err}
+}
+
+var errorSubject = regexp.MustCompile(`^(?:error validating )?(agent|mcpServer|flow) "([^"]+)"`)
+
+// errorPath returns the pointer of the agent, MCP server, or flow an error of Config.Validate is
+// about.
+func errorPath(msg string) string {
+	m := errorSubject.FindStringSubmatch(msg)
+	if m == nil {
+		return ""
+	}
+	return pointer([]string{m[1] + "s", m[2]})
+}
+
+var envReference = regexp.MustCompile(`\$\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\}`)
+
+// checkEnv reports the environment variables that the MCP servers use but that are neither set nor
+// declared in the env of the config, which would let nanobot ask for them.
+func checkEnv(cfg types.Config, env map[string]string) (problems []Problem) {
+	for _, name := range slices.Sorted(maps.Keys(cfg.MCPServers)) {
+		server := cfg.MCPServers[name]
+		values := map[string]string{
+			"command": server.Command,
+			"url":     server.BaseURL,
+			"cwd":     server.Cwd,
+		}
+		for i, arg := range server.Args {
+			values["args/"+strconv.Itoa(i)] = arg
+		}
+		for k, v := range server.Env {
+			values["env/"+k] = v
+		}
+		for k, v := range server.Headers {
+			values["headers/"+k] = v
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(values)) {
+			for _, m := range envReference.FindAllStringSubmatch(values[field], -1) {
+				if _, declared := cfg.Env[m[1]]; declared {
+					continue
+				}
+				if _, ok := expr.Lookup(env, m[1]); ok {
+					continue
+				}
+				problems = append(problems, Problem{
+					Path:    pointer(append([]string{"mcpServers", name}, strings.Split(field, "/")...)),
+					Message: fmt.Sprintf("environment variable %s is not set and not declared in env", m[1]),
+					Warning: true,
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// modelProvider returns the provider that the completions of an agent are sent to. Agents that
+// select an API always use the OpenAI client.
+func modelProvider(agent types.Agent, model string) string {
+	if agent.API == "" && strings.HasPrefix(model, "claude") {
+		return "anthropic"
+	}
+	return "openai"
+}
+
+// checkModels reports agents whose model has no configured provider, or that use options the
+// provider of their model does not support.
+func checkModels(cfg types.Config, opt ValidateOptions) (problems []Problem) {
+	for _, name := range slices.Sorted(maps.Keys(cfg.Agents)) {
+		agent := cfg.Agents[name]
+		model, field := agent.Model, "model"
+		if model == "" {
+			model, field = opt.DefaultModel, ""
+		}
+		if model == "" {
+			continue
+		}
+
+		location := []string{"agents", name}
+		if field != "" {
+			location = append(location, field)
+		}
+
+		provider := modelProvider(agent, model)
+		if opt.CheckProviders && !slices.Contains(opt.Providers, provider) {
+			problems = append(problems, Problem{
+				Path:    pointer(location),
+				Message: fmt.Sprintf("agent %q uses model %s of provider %s, which has no API key", name, model, provider),
+			})
+		}
+
+		if provider != "anthropic" {
+			continue
+		}
+		for _, option := range []struct {
+			name string
+			set  bool
+		}{
+			{"builtinTools", len(agent.BuiltinTools) > 0},
+			{"audio", agent.Audio != nil},
+			{"logprobs", agent.Logprobs},
+		} {
+			if option.set {
+				problems = append(problems, Problem{
+					Path:    pointer([]string{"agents", name, option.name}),
+					Message: fmt.Sprintf("agent %q sets %s, which the anthropic provider of model %s does not support", name, option.name, model),
+				})
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		t.Helper()
+		path := filepath.Join(dir, "nanobot.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := write(`agents:
+  main:
+    model: gpt-4.1
+    temprature: 0.5
+`)
+	problems, err := Validate(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Line != 4 || problems[0].Column != 5 || problems[0].Path != "/agents/main" {
+		t.Fatalf("expected the unknown property at 4:5, got %+v", problems)
+	}
+
+	path = write(`agents:
+  main:
+    model: claude-sonnet-4
+    mcpServers: [search, missing]
+    builtinTools:
+      web_search: {}
+mcpServers:
+  search:
+    url: https://example.com/mcp
+    headers:
+      Authorization: Bearer ${SEARCH_TOKEN}
+`)
+	problems, err = Validate(t.Context(), path, ValidateOptions{
+		Env:            map[string]string{},
+		Providers:      []string{"openai"},
+		CheckProviders: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][2]int{
+		"/agents/main": {2, 3},
+		"/mcpServers/search/headers/Authorization": {11, 22},
+		"/agents/main/model":                       {3, 12},
+		"/agents/main/builtinTools":                {5, 5},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %+v", len(want), problems)
+	}
+	for _, problem := range problems {
+		pos, ok := want[problem.Path]
+		if !ok || problem.Line != pos[0] || problem.Column != pos[1] {
+			t.Errorf("unexpected problem %s", problem)
+		}
+		if problem.Warning != (problem.Path == "/mcpServers/search/headers/Authorization") {
+			t.Errorf("only the unset env variable should be a warning, got %s", problem)
+		}
+	}
+}
+
+func TestValidateAgentAPI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nanobot.yaml")
+	if err := os.WriteFile(path, []byte(`publish:
+  entrypoint: [main]
+agents:
+  main:
+    model: gpt-4.1
+    api: completions
+    builtinTools:
+      web_search: {}
+  speaker:
+    model: gpt-4o-audio-preview
+    api: responses
+    audio:
+      voice: alloy
+  researcher:
+    model: gpt-4.1
+    builtinTools:
+      web_search: {}
+    toolChoice: web_search
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := Validate(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The researcher may choose a built-in tool, the other agents use an API without their feature.
+	want := map[string]string{
+		"/agents/main":    `agent "main" has built-in tools which are only available in the "responses" API`,
+		"/agents/speaker": `agent "speaker" has audio output which is only available in the "completions" API`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %+v", len(want), problems)
+	}
+	for _, problem := range problems {
+		if msg, ok := want[problem.Path]; !ok || !strings.Contains(problem.String(), msg) {
+			t.Errorf("unexpected problem %s", problem)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Files returns the local files the config at path is read from, the config file and the files it
+// extends. Configs from HTTP or git have none.
+func Files(ctx context.Context, path string) ([]string, error) {
+	configResource, err := resolve(path)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving config path %s: %w", path, err)
+	}
+	if configResource.resourceType != "path" {
+		return nil, nil
+	}
+
+	file, err := configResource.fileToRead()
+	if err != nil {
+		return nil, err
+	}
+	files := []string{file}
+
+	cfg, err := configResource.Load(ctx)
+	if err != nil {
+		return files, err
+	}
+	for _, parentRef := range cfg.Extends {
+		parentResource, err := configResource.Rel(parentRef)
+		if err != nil || parentResource.resourceType != "path" {
+			continue
+		}
+		if parentFile, err := parentResource.fileToRead(); err == nil {
+			files = append(files, parentFile)
+		}
+	}
+	return files, nil
+}
+
+// Watcher serves the config of a file and reloads it when the file or a file it extends changes,
+// on SIGHUP, or when Reload is called. A new config is only swapped in once it loaded and validated,
+// a broken edit is logged and the previous config is kept. Turns that are running finish with the
+// config they started with, new turns use the new one.
+type Watcher struct {
+	path string
+	load types.ConfigFactory
+
+	lock     sync.Mutex
+	configs  map[string]types.Config
+	modTimes map[string]time.Time
+}
+
+// NewWatcher returns a watcher of the config at path, load reads the config for a list of profiles.
+func NewWatcher(path string, load types.ConfigFactory) *Watcher {
+	return &Watcher{
+		path:    path,
+		load:    load,
+		configs: map[string]types.Config{},
+	}
+}
+
+// Config is the types.ConfigFactory of the watcher, it returns the current config of the profiles.
+func (w *Watcher) Config(ctx context.Context, profiles string) (types.Config, error) {
+	w.lock.Lock()
+	cfg, ok := w.configs[profiles]
+	w.lock.Unlock()
+	if ok {
+		return cfg, nil
+	}
+
+	cfg, err := w.load(ctx, profiles)
+	if err != nil {
+		return types.Config{}, err
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.configs[profiles] = cfg
+	return cfg, nil
+}
+
+// Reload loads the configs of all profiles that were used again. Either all of them are swapped, or
+// none is and the error of the first config that failed is returned.
+func (w *Watcher) Reload(ctx context.Context) error {
+	w.lock.Lock()
+	profiles := slices.Collect(maps.Keys(w.configs))
+	w.lock.Unlock()
+
+	configs := make(map[string]types.Config, len(profiles))
+	for _, p := range profiles {
+		cfg, err := w.load(ctx, p)
+		if err != nil {
+			return fmt.Errorf("failed to reload config %s: %w", w.path, err)
+		}
+		configs[p] = cfg
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.configs = configs
+	return nil
+}
+
+// Watch checks the config files for changes every interval and reloads the config on changes and
+// on SIGHUP, until ctx is done. With an interval of 0 the files are not checked.
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Remember the current versions of the files, so only later changes reload.
+	w.changed(ctx)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Infof(ctx, "reloading config %s on SIGHUP", w.path)
+		case <-tick:
+			if !w.changed(ctx) {
+				continue
+			}
+			log.Infof(ctx, "config %s changed, reloading", w.path)
+		}
+		if err := w.Reload(ctx); err != nil {
+			log.Errorf(ctx, "keeping the previous config: %v", err)
+		}
+	}
+}
+
+// changed reports if the config files were modified, added or removed since the last check.
+func (w *Watcher) changed(ctx context.Context) bool {
+	// The files can change with the config, when it extends other files.
+	files, err := Files(ctx, w.path)
+	if err != nil && len(files) == 0 {
+		return false
+	}
+
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		if s, err := os.Stat(file); err == nil {
+			modTimes[file] = s.ModTime()
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	changed := w.modTimes != nil && !maps.Equal(w.modTimes, modTimes)
+	w.modTimes = modTimes
+	return changed
+}
+
+// ServeHTTP reloads the config for POST /api/config/reload. Only admins can reload the config, so
+// deployments without authentication can only reload it on SIGHUP or when the files change.
+func (w *Watcher) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !types.NanobotContext(req.Context()).Admin {
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := w.Reload(req.Context()); err != nil {
+		http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(map[string]any{
+		"reloaded": true,
+	})
+}
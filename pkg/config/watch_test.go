@@ -0,0 +1,110 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nanobot.yaml")
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	model := func(w *Watcher) string {
+		t.Helper()
+		cfg, err := w.Config(t.Context(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg.Agents["main"].Model
+	}
+
+	now := time.Now()
+	write("agents:\n  main:\n    model: gpt-4.1\n", now)
+
+	w := NewWatcher(path, func(ctx context.Context, _ string) (types.Config, error) {
+		cfg, _, err := Load(ctx, path)
+		if err != nil {
+			return types.Config{}, err
+		}
+		return *cfg, nil
+	})
+	if got := model(w); got != "gpt-4.1" {
+		t.Fatalf("expected model gpt-4.1, got %q", got)
+	}
+	w.changed(t.Context())
+
+	write("agents:\n  main: [\n", now.Add(time.Second))
+	if !w.changed(t.Context()) {
+		t.Fatal("expected the edit to be detected")
+	}
+	if err := w.Reload(t.Context()); err == nil {
+		t.Fatal("expected the broken config to fail to reload")
+	}
+	if got := model(w); got != "gpt-4.1" {
+		t.Fatalf("expected the previous config to be kept, got model %q", got)
+	}
+
+	write("agents:\n  main:\n    model: claude-sonnet-4\n", now.Add(2*time.Second))
+	if !w.changed(t.Context()) {
+		t.Fatal("expected the fix to be detected")
+	}
+	if err := w.Reload(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if got := model(w); got != "claude-sonnet-4" {
+		t.Fatalf("expected the reloaded model claude-sonnet-4, got %q", got)
+	}
+	if w.changed(t.Context()) {
+		t.Fatal("expected no change without an edit")
+	}
+}
+
+func TestWatcherServeHTTP(t *testing.T) {
+	var loads int
+	w := NewWatcher(filepath.Join(t.TempDir(), "nanobot.yaml"), func(context.Context, string) (types.Config, error) {
+		loads++
+		return types.Config{}, nil
+	})
+	if _, err := w.Config(t.Context(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		nctx   types.Context
+		status int
+	}{
+		{"anonymous", types.Context{}, http.StatusForbidden},
+		{"user", types.Context{User: types.User{ID: "alice"}}, http.StatusForbidden},
+		{"admin", types.Context{User: types.User{ID: "root"}, Admin: true}, http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			loads = 0
+			req := httptest.NewRequest(http.MethodPost, "/api/config/reload", nil)
+			req = req.WithContext(types.WithNanobotContext(req.Context(), tt.nctx))
+			rec := httptest.NewRecorder()
+			w.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if reloaded := loads > 0; reloaded != (tt.status == http.StatusOK) {
+				t.Errorf("expected reloaded %v, got %d loads", tt.status == http.StatusOK, loads)
+			}
+		})
+	}
+}
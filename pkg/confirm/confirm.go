@@ -1,6 +1,7 @@
 package confirm
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uifeatures"
 )
 
 const Timeout = 15 * time.Minute
@@ -59,3 +61,75 @@ func (*Service) HandleAuthURL(ctx context.Context, mcpServerName, url string) (b
 		return false, fmt.Errorf("user has canceled authorization for server %s", mcpServerName)
 	}
 }
+
+// Approve asks the user of the session to approve an action of an agent, such as sending an email.
+// It returns an error unless the user accepts, or if the client of the session can not ask the user.
+func Approve(ctx context.Context, action string) error {
+	session := mcp.SessionFromContext(ctx)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	if session == nil || session.InitializeRequest.Capabilities.Elicitation == nil || !uifeatures.Supported(ctx, uifeatures.Approvals) {
+		return fmt.Errorf("%s requires approval, but the client can not ask the user", action)
+	}
+
+	var result mcp.ElicitResult
+	if err := session.Exchange(ctx, "elicitation/create", mcp.ElicitRequest{
+		Message: "Allow the agent to " + action + "?",
+		RequestedSchema: mcp.PrimitiveSchema{
+			Type:       "object",
+			Properties: map[string]mcp.PrimitiveProperty{},
+		},
+	}, &result); err != nil {
+		return fmt.Errorf("failed to ask for approval: %w", err)
+	}
+	if result.Action != "accept" {
+		return fmt.Errorf("the user did not approve to %s", action)
+	}
+	return nil
+}
+
+// ToolCall asks the user of the session to approve a call of a tool that requires confirmation,
+// and waits until the user answers or the Timeout passes. The returned approval records the
+// decision and who made it. It returns an error if the client of the session can not ask the user.
+func ToolCall(ctx context.Context, server string, tool mcp.Tool, call types.ToolCall) (*types.ToolApproval, error) {
+	session := mcp.SessionFromContext(ctx)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	if session == nil || session.InitializeRequest.Capabilities.Elicitation == nil || !uifeatures.Supported(ctx, uifeatures.Approvals) {
+		return nil, fmt.Errorf("tool %s requires approval, but the client can not ask the user", call.Name)
+	}
+
+	request := types.ToolCallConfirm{
+		MCPServer:  server,
+		Tool:       tool,
+		Invocation: &call,
+	}
+	meta, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	var result mcp.ElicitResult
+	if err := session.Exchange(ctx, "elicitation/create", mcp.ElicitRequest{
+		Message: request.Message(),
+		RequestedSchema: mcp.PrimitiveSchema{
+			Type:       "object",
+			Properties: map[string]mcp.PrimitiveProperty{},
+		},
+		Meta: meta,
+	}, &result); err != nil {
+		return nil, fmt.Errorf("failed to ask for approval: %w", err)
+	}
+
+	user := types.NanobotContext(ctx).User
+	return &types.ToolApproval{
+		Approved: result.Action == "accept",
+		User:     cmp.Or(user.ID, user.Email),
+		Time:     time.Now(),
+	}, nil
+}
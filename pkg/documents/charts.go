@@ -0,0 +1,110 @@
+package documents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ImageFormatSVG = "svg"
+	ImageFormatPNG = "png"
+)
+
+var ImageMimeTypes = map[string]string{
+	ImageFormatSVG: "image/svg+xml",
+	ImageFormatPNG: "image/png",
+}
+
+func imageFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	if format == "" {
+		return ImageFormatPNG, nil
+	}
+	if _, ok := ImageMimeTypes[format]; !ok {
+		return "", fmt.Errorf("unsupported image format %q, must be png or svg", format)
+	}
+	return format, nil
+}
+
+func lookupTool(env, name string) (string, error) {
+	if bin := os.Getenv(env); bin != "" {
+		return exec.LookPath(bin)
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s not found, install it or set %s: %w", name, env, err)
+	}
+	return bin, nil
+}
+
+// Mermaid renders a Mermaid diagram with the mermaid CLI (mmdc). The binary can be overridden
+// with the NANOBOT_MERMAID_RENDERER environment variable.
+func Mermaid(ctx context.Context, spec, format string) ([]byte, string, error) {
+	format, err := imageFormat(format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bin, err := lookupTool("NANOBOT_MERMAID_RENDERER", "mmdc")
+	if err != nil {
+		return nil, "", err
+	}
+
+	dir, err := os.MkdirTemp("", "nanobot-mermaid-")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "diagram.mmd")
+	output := filepath.Join(dir, "diagram."+format)
+	if err := os.WriteFile(input, []byte(spec), 0o600); err != nil {
+		return nil, "", err
+	}
+
+	cmd := exec.CommandContext(ctx, bin, "--quiet", "--input", input, "--output", output, "--backgroundColor", "white")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, "", fmt.Errorf("failed to render mermaid diagram: %w: %s", err, out)
+	}
+
+	data, err := os.ReadFile(output)
+	return data, ImageMimeTypes[format], err
+}
+
+// VegaLite renders a Vega-Lite specification with the vega-lite CLI (vl2svg or vl2png). The
+// directory containing the binaries can be overridden with NANOBOT_VEGALITE_RENDERER_DIR.
+func VegaLite(ctx context.Context, spec json.RawMessage, format string) ([]byte, string, error) {
+	format, err := imageFormat(format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !json.Valid(spec) {
+		return nil, "", fmt.Errorf("vega-lite spec is not valid JSON")
+	}
+
+	name := "vl2" + format
+	if dir := os.Getenv("NANOBOT_VEGALITE_RENDERER_DIR"); dir != "" {
+		name = filepath.Join(dir, name)
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s not found, install vega-lite or set NANOBOT_VEGALITE_RENDERER_DIR: %w", name, err)
+	}
+
+	cmd := exec.CommandContext(ctx, bin)
+	cmd.Stdin = strings.NewReader(string(spec))
+	stderr := &strings.Builder{}
+	cmd.Stderr = stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render vega-lite chart: %w: %s", err, stderr.String())
+	}
+
+	return data, ImageMimeTypes[format], nil
+}
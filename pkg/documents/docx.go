@@ -0,0 +1,164 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const (
+	docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+	docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+	docxCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>%s</dc:title>
+<dc:creator>nanobot</dc:creator>
+</cp:coreProperties>`
+
+	docxStylesHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:pPr><w:spacing w:after="120"/></w:pPr><w:rPr><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/><w:shd w:val="clear" w:fill="F6F8FA"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:pPr><w:ind w:left="720"/></w:pPr><w:rPr><w:i/><w:color w:val="59636E"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="40"/></w:pPr></w:style>
+`
+	docxHeadingStyle = `<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="%d"/></w:pPr><w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>
+`
+	wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+)
+
+// DOCX renders markdown as an Office Open XML word processing document.
+func DOCX(title, markdown string) ([]byte, error) {
+	body := &strings.Builder{}
+	numbers := map[int]int{}
+
+	if title != "" {
+		writeParagraph(body, "Heading1", "", []Span{{Text: title}})
+	}
+
+	for _, block := range Parse(markdown) {
+		if block.Type != BlockNumbered {
+			clear(numbers)
+		}
+
+		indent := strings.Repeat("    ", block.Level)
+		switch block.Type {
+		case BlockHeading:
+			writeParagraph(body, fmt.Sprintf("Heading%d", block.Level), "", ParseInline(block.Text))
+		case BlockParagraph:
+			writeParagraph(body, "", "", ParseInline(block.Text))
+		case BlockQuote:
+			writeParagraph(body, "Quote", "", ParseInline(block.Text))
+		case BlockRule:
+			body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="D1D9E0"/></w:pBdr></w:pPr></w:p>`)
+		case BlockCode:
+			for _, line := range strings.Split(block.Text, "\n") {
+				writeParagraph(body, "Code", "", []Span{{Text: line}})
+			}
+		case BlockBullet:
+			writeParagraph(body, "ListParagraph", indent+"•\t", ParseInline(block.Text))
+		case BlockNumbered:
+			for level := range numbers {
+				if level > block.Level {
+					delete(numbers, level)
+				}
+			}
+			numbers[block.Level]++
+			writeParagraph(body, "ListParagraph", fmt.Sprintf("%s%d.\t", indent, numbers[block.Level]), ParseInline(block.Text))
+		}
+	}
+
+	styles := &strings.Builder{}
+	styles.WriteString(docxStylesHeader)
+	for i := 1; i <= 6; i++ {
+		fmt.Fprintf(styles, docxHeadingStyle, i, i, i-1, 40-4*i)
+	}
+	styles.WriteString("</w:styles>")
+
+	document := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="%s"><w:body>%s<w:sectPr/></w:body></w:document>`, wordNamespace, body.String())
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, part := range []struct {
+		name, data string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"docProps/core.xml", fmt.Sprintf(docxCore, escapeXML(title))},
+		{"word/styles.xml", styles.String()},
+		{"word/document.xml", document},
+	} {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.data)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeParagraph(buf *strings.Builder, style, prefix string, spans []Span) {
+	buf.WriteString("<w:p>")
+	if style != "" {
+		fmt.Fprintf(buf, `<w:pPr><w:pStyle w:val="%s"/></w:pPr>`, style)
+	}
+	if prefix != "" {
+		spans = append([]Span{{Text: prefix}}, spans...)
+	}
+	for _, span := range spans {
+		buf.WriteString("<w:r>")
+		if span.Bold || span.Italic || span.Code || span.Link != "" {
+			buf.WriteString("<w:rPr>")
+			if span.Code {
+				buf.WriteString(`<w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/>`)
+			}
+			if span.Bold {
+				buf.WriteString("<w:b/>")
+			}
+			if span.Italic {
+				buf.WriteString("<w:i/>")
+			}
+			if span.Link != "" {
+				buf.WriteString(`<w:color w:val="0969DA"/><w:u w:val="single"/>`)
+			}
+			buf.WriteString("</w:rPr>")
+		}
+		for i, part := range strings.Split(span.Text, "\t") {
+			if i > 0 {
+				buf.WriteString("<w:tab/>")
+			}
+			if part != "" {
+				fmt.Fprintf(buf, `<w:t xml:space="preserve">%s</w:t>`, escapeXML(part))
+			}
+		}
+		buf.WriteString("</w:r>")
+	}
+	buf.WriteString("</w:p>")
+}
+
+func escapeXML(s string) string {
+	buf := &strings.Builder{}
+	_ = xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
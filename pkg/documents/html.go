@@ -0,0 +1,111 @@
+package documents
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+const htmlStyle = `body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; max-width: 48em; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; border-radius: 6px; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.9em; }
+blockquote { margin: 0; padding: 0 1em; color: #59636e; border-left: 0.25em solid #d1d9e0; }
+h1, h2 { border-bottom: 1px solid #d1d9e0; padding-bottom: 0.3em; }`
+
+// HTML renders markdown as a standalone HTML document.
+func HTML(title, markdown string) []byte {
+	buf := &strings.Builder{}
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	if title != "" {
+		fmt.Fprintf(buf, "<title>%s</title>\n", html.EscapeString(title))
+	}
+	fmt.Fprintf(buf, "<style>\n%s\n</style>\n</head>\n<body>\n", htmlStyle)
+
+	var openList []BlockType
+	closeLists := func(level int) {
+		for len(openList) > level {
+			if openList[len(openList)-1] == BlockNumbered {
+				buf.WriteString("</ol>\n")
+			} else {
+				buf.WriteString("</ul>\n")
+			}
+			openList = openList[:len(openList)-1]
+		}
+	}
+
+	for _, block := range Parse(markdown) {
+		if block.Type != BlockBullet && block.Type != BlockNumbered {
+			closeLists(0)
+		}
+
+		switch block.Type {
+		case BlockHeading:
+			fmt.Fprintf(buf, "<h%d>%s</h%d>\n", block.Level, inlineHTML(block.Text), block.Level)
+		case BlockParagraph:
+			fmt.Fprintf(buf, "<p>%s</p>\n", inlineHTML(block.Text))
+		case BlockQuote:
+			fmt.Fprintf(buf, "<blockquote><p>%s</p></blockquote>\n", inlineHTML(block.Text))
+		case BlockRule:
+			buf.WriteString("<hr>\n")
+		case BlockCode:
+			class := ""
+			if block.Lang != "" {
+				class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(block.Lang))
+			}
+			fmt.Fprintf(buf, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(block.Text))
+		case BlockBullet, BlockNumbered:
+			closeLists(block.Level + 1)
+			if len(openList) == block.Level+1 && openList[block.Level] != block.Type {
+				closeLists(block.Level)
+			}
+			for len(openList) <= block.Level {
+				if block.Type == BlockNumbered {
+					buf.WriteString("<ol>\n")
+				} else {
+					buf.WriteString("<ul>\n")
+				}
+				openList = append(openList, block.Type)
+			}
+			fmt.Fprintf(buf, "<li>%s</li>\n", inlineHTML(block.Text))
+		}
+	}
+
+	closeLists(0)
+	buf.WriteString("</body>\n</html>\n")
+	return []byte(buf.String())
+}
+
+func inlineHTML(text string) string {
+	buf := &strings.Builder{}
+	for _, span := range ParseInline(text) {
+		s := html.EscapeString(span.Text)
+		switch {
+		case span.Code:
+			s = "<code>" + s + "</code>"
+		case span.Bold:
+			s = "<strong>" + s + "</strong>"
+		case span.Italic:
+			s = "<em>" + s + "</em>"
+		case span.Link != "" && allowedLink(span.Link):
+			s = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(span.Link), s)
+		}
+		buf.WriteString(s)
+	}
+	return buf.String()
+}
+
+// allowedLink returns whether a link is rendered as a link, which is only done for http, https,
+// and mailto URLs so that links such as javascript: URLs can not run in the document.
+func allowedLink(link string) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	default:
+		return false
+	}
+}
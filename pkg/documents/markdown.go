@@ -0,0 +1,171 @@
+package documents
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BlockType is the kind of block level element parsed from markdown.
+type BlockType string
+
+const (
+	BlockHeading   BlockType = "heading"
+	BlockParagraph BlockType = "paragraph"
+	BlockBullet    BlockType = "bullet"
+	BlockNumbered  BlockType = "numbered"
+	BlockCode      BlockType = "code"
+	BlockQuote     BlockType = "quote"
+	BlockRule      BlockType = "rule"
+)
+
+// Block is a single block level element of a markdown document. Only the subset of markdown that
+// models commonly produce for reports is supported: headings, paragraphs, lists, block quotes,
+// fenced code and horizontal rules.
+type Block struct {
+	Type  BlockType
+	Level int
+	Text  string
+	Lang  string
+}
+
+var (
+	headingRegexp  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRegexp   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	numberedRegexp = regexp.MustCompile(`^(\s*)\d+[.)]\s+(.*)$`)
+	ruleRegexp     = regexp.MustCompile(`^\s*(-\s*){3,}$|^\s*(\*\s*){3,}$|^\s*(_\s*){3,}$`)
+)
+
+// Parse splits markdown into blocks.
+func Parse(markdown string) []Block {
+	var (
+		blocks    []Block
+		paragraph []string
+		lines     = strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	)
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, Block{
+				Type: BlockParagraph,
+				Text: strings.Join(paragraph, " "),
+			})
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			flush()
+			var code []string
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, Block{
+				Type: BlockCode,
+				Text: strings.Join(code, "\n"),
+				Lang: lang,
+			})
+			continue
+		}
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		if m := headingRegexp.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			blocks = append(blocks, Block{
+				Type:  BlockHeading,
+				Level: len(m[1]),
+				Text:  m[2],
+			})
+		} else if ruleRegexp.MatchString(trimmed) {
+			flush()
+			blocks = append(blocks, Block{Type: BlockRule})
+		} else if m := bulletRegexp.FindStringSubmatch(line); m != nil {
+			flush()
+			blocks = append(blocks, Block{
+				Type:  BlockBullet,
+				Level: len(m[1]) / 2,
+				Text:  m[2],
+			})
+		} else if m := numberedRegexp.FindStringSubmatch(line); m != nil {
+			flush()
+			blocks = append(blocks, Block{
+				Type:  BlockNumbered,
+				Level: len(m[1]) / 2,
+				Text:  m[2],
+			})
+		} else if strings.HasPrefix(trimmed, ">") {
+			flush()
+			blocks = append(blocks, Block{
+				Type: BlockQuote,
+				Text: strings.TrimSpace(strings.TrimPrefix(trimmed, ">")),
+			})
+		} else {
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+
+	flush()
+	return blocks
+}
+
+// SpanStyle is the inline formatting applied to a run of text.
+type SpanStyle struct {
+	Bold   bool
+	Italic bool
+	Code   bool
+	Link   string
+}
+
+// Span is a run of text with uniform inline formatting.
+type Span struct {
+	Text string
+	SpanStyle
+}
+
+var inlineRegexp = regexp.MustCompile("`([^`]+)`|\\*\\*([^*]+)\\*\\*|__([^_]+)__|\\*([^*]+)\\*|_([^_]+)_|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)")
+
+// ParseInline splits the text of a block into styled spans.
+func ParseInline(text string) (result []Span) {
+	for text != "" {
+		loc := inlineRegexp.FindStringSubmatchIndex(text)
+		if loc == nil {
+			result = append(result, Span{Text: text})
+			break
+		}
+		if loc[0] > 0 {
+			result = append(result, Span{Text: text[:loc[0]]})
+		}
+
+		group := func(i int) string {
+			if loc[2*i] < 0 {
+				return ""
+			}
+			return text[loc[2*i]:loc[2*i+1]]
+		}
+
+		switch {
+		case loc[2] >= 0:
+			result = append(result, Span{Text: group(1), SpanStyle: SpanStyle{Code: true}})
+		case loc[4] >= 0:
+			result = append(result, Span{Text: group(2), SpanStyle: SpanStyle{Bold: true}})
+		case loc[6] >= 0:
+			result = append(result, Span{Text: group(3), SpanStyle: SpanStyle{Bold: true}})
+		case loc[8] >= 0:
+			result = append(result, Span{Text: group(4), SpanStyle: SpanStyle{Italic: true}})
+		case loc[10] >= 0:
+			result = append(result, Span{Text: group(5), SpanStyle: SpanStyle{Italic: true}})
+		case loc[12] >= 0:
+			result = append(result, Span{Text: group(6), SpanStyle: SpanStyle{Link: group(7)}})
+		}
+		text = text[loc[1]:]
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrNoRenderer is returned when no headless browser is available to render PDF documents.
+var ErrNoRenderer = errors.New("no headless renderer found, install chromium or set NANOBOT_PDF_RENDERER")
+
+var browserCandidates = []string{
+	"chromium",
+	"chromium-browser",
+	"google-chrome",
+	"google-chrome-stable",
+	"microsoft-edge",
+}
+
+func findRenderer() (string, error) {
+	if bin := os.Getenv("NANOBOT_PDF_RENDERER"); bin != "" {
+		return exec.LookPath(bin)
+	}
+	for _, candidate := range browserCandidates {
+		if bin, err := exec.LookPath(candidate); err == nil {
+			return bin, nil
+		}
+	}
+	return "", ErrNoRenderer
+}
+
+// PDF renders markdown to PDF by printing the HTML rendering with a headless Chromium based
+// browser. The browser binary can be overridden with the NANOBOT_PDF_RENDERER environment variable.
+// The browser sandbox is only disabled if NANOBOT_PDF_NO_SANDBOX is true, which is needed to run
+// as root in some containers.
+func PDF(ctx context.Context, title, markdown string) ([]byte, error) {
+	bin, err := findRenderer()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "nanobot-document-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "document.html")
+	output := filepath.Join(dir, "document.pdf")
+	if err := os.WriteFile(input, HTML(title, markdown), 0o600); err != nil {
+		return nil, err
+	}
+
+	args := []string{"--headless", "--disable-gpu"}
+	if noSandbox, _ := strconv.ParseBool(os.Getenv("NANOBOT_PDF_NO_SANDBOX")); noSandbox {
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args,
+		"--no-pdf-header-footer",
+		"--user-data-dir="+filepath.Join(dir, "profile"),
+		"--print-to-pdf="+output,
+		"file://"+input)
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to render PDF with %s: %w: %s", bin, err, out)
+	}
+
+	return os.ReadFile(output)
+}
@@ -0,0 +1,54 @@
+package documents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+	FormatDOCX = "docx"
+)
+
+var MimeTypes = map[string]string{
+	FormatHTML: "text/html",
+	FormatPDF:  "application/pdf",
+	FormatDOCX: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// NormalizeFormat returns the format in lower case and without a leading dot, or html if it is
+// empty.
+func NormalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+	if format == "" {
+		return FormatHTML
+	}
+	return format
+}
+
+// Render converts markdown into the requested format and returns the data and its mime type.
+func Render(ctx context.Context, format, title, markdown string) ([]byte, string, error) {
+	format = NormalizeFormat(format)
+
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case FormatHTML:
+		data = HTML(title, markdown)
+	case FormatDOCX:
+		data, err = DOCX(title, markdown)
+	case FormatPDF:
+		data, err = PDF(ctx, title, markdown)
+	default:
+		return nil, "", fmt.Errorf("unsupported document format %q, must be one of html, pdf, or docx", format)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render %s document: %w", format, err)
+	}
+
+	return data, MimeTypes[format], nil
+}
@@ -0,0 +1,113 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeFormat(t *testing.T) {
+	for format, want := range map[string]string{
+		"":      FormatHTML,
+		".pdf":  FormatPDF,
+		" PDF ": FormatPDF,
+		"DOCX":  FormatDOCX,
+		".Html": FormatHTML,
+	} {
+		if got := NormalizeFormat(format); got != want {
+			t.Errorf("NormalizeFormat(%q) = %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got := string(HTML("A & B", "# Title\n\nSee [docs](https://example.com/?a=1&b=2), "+
+		"[mail](mailto:a@example.com), [x](javascript:alert(1)) and [y]( JavaScript:alert(1)).\n\n- one\n- two"))
+
+	for _, want := range []string{
+		"<title>A &amp; B</title>",
+		"<h1>Title</h1>",
+		`<a href="https://example.com/?a=1&amp;b=2">docs</a>`,
+		`<a href="mailto:a@example.com">mail</a>`,
+		"<ul>\n<li>one</li>\n<li>two</li>\n</ul>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(strings.ToLower(got), "href=\"javascript") {
+		t.Errorf("javascript link rendered in\n%s", got)
+	}
+}
+
+func TestDOCX(t *testing.T) {
+	data, mimeType, err := Render(t.Context(), ".DOCX", "Report", "# Title\n\nSome **bold** text.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mimeType != MimeTypes[FormatDOCX] {
+		t.Errorf("got mime type %s", mimeType)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, _ := io.ReadAll(f)
+	for _, want := range []string{`<w:pStyle w:val="Heading1"/>`, "<w:b/>", ">bold</w:t>"} {
+		if !bytes.Contains(document, []byte(want)) {
+			t.Errorf("expected %q in\n%s", want, document)
+		}
+	}
+}
+
+// fakeBrowser writes a browser script that records its arguments and prints a fake PDF.
+func fakeBrowser(t *testing.T) (bin, argsFile string) {
+	dir := t.TempDir()
+	bin = filepath.Join(dir, "chromium")
+	argsFile = filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\nfor arg; do\n  case $arg in --print-to-pdf=*) echo '%PDF-1.4' > \"${arg#--print-to-pdf=}\";; esac\ndone\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return bin, argsFile
+}
+
+func TestPDF(t *testing.T) {
+	bin, argsFile := fakeBrowser(t)
+	t.Setenv("NANOBOT_PDF_RENDERER", bin)
+
+	data, mimeType, err := Render(t.Context(), "pdf", "Report", "Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "%PDF-1.4\n" || mimeType != "application/pdf" {
+		t.Errorf("got %q with mime type %s", data, mimeType)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(args), "--headless") || strings.Contains(string(args), "--no-sandbox") {
+		t.Errorf("unexpected arguments %s", args)
+	}
+
+	t.Setenv("NANOBOT_PDF_NO_SANDBOX", "true")
+	if _, _, err := Render(t.Context(), "pdf", "Report", "Hello"); err != nil {
+		t.Fatal(err)
+	}
+	if args, _ := os.ReadFile(argsFile); !strings.Contains(string(args), "--no-sandbox") {
+		t.Errorf("expected --no-sandbox in %s", args)
+	}
+}
+
+func TestRenderUnsupportedFormat(t *testing.T) {
+	if _, _, err := Render(t.Context(), "odt", "", "Hello"); err == nil || !strings.Contains(err.Error(), `"odt"`) {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package elicit lets the MCP servers of a session ask the user questions during a chat turn of the
+// agent UI. Clients that can not answer elicitation requests get the question as a form in the
+// progress of the turn, and send the answer back with the answer_elicitation tool.
+package elicit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/confirm"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+var (
+	lock sync.Mutex
+	// turns are the progress tokens of the running chat turns, by session ID.
+	turns = map[string]any{}
+	// pending are the questions waiting for an answer, by session ID and question ID.
+	pending = map[string]chan mcp.ElicitResult{}
+)
+
+// StartTurn records that the chat turn of the session of ctx streams its progress to the progress
+// token, so questions asked during the turn are sent with it. The returned function ends the turn.
+func StartTurn(ctx context.Context, progressToken any) (end func()) {
+	session := rootSession(ctx)
+	if session == nil || progressToken == nil || progressToken == "" {
+		return func() {}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	previous, hadPrevious := turns[session.ID()]
+	turns[session.ID()] = progressToken
+
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if hadPrevious {
+			turns[session.ID()] = previous
+		} else {
+			delete(turns, session.ID())
+		}
+	}
+}
+
+// Ask sends the question of the server to the user as a form in the progress of the running chat
+// turn and waits for the answer. The question is cancelled if no turn is running or the user does
+// not answer within confirm.Timeout.
+func Ask(ctx context.Context, server string, request mcp.ElicitRequest) (mcp.ElicitResult, error) {
+	session := rootSession(ctx)
+	if session == nil {
+		return mcp.ElicitResult{}, fmt.Errorf("session not found in context")
+	}
+
+	lock.Lock()
+	progressToken, ok := turns[session.ID()]
+	lock.Unlock()
+	if !ok {
+		return mcp.ElicitResult{Action: "cancel"}, nil
+	}
+
+	var (
+		id      = uuid.String()
+		key     = session.ID() + "/" + id
+		answers = make(chan mcp.ElicitResult, 1)
+	)
+
+	lock.Lock()
+	pending[key] = answers
+	lock.Unlock()
+	defer func() {
+		lock.Lock()
+		defer lock.Unlock()
+		delete(pending, key)
+	}()
+
+	progress.Send(mcp.WithSession(ctx, session), &types.CompletionProgress{
+		Role: "assistant",
+		Event: &types.ProgressEvent{
+			Type:    types.ProgressEventElicitation,
+			Message: request.Message,
+			Elicitation: &types.ProgressElicitation{
+				ID:              id,
+				Server:          server,
+				RequestedSchema: request.RequestedSchema,
+			},
+		},
+	}, progressToken)
+
+	ctx, cancel := context.WithTimeout(ctx, confirm.Timeout)
+	defer cancel()
+
+	select {
+	case result := <-answers:
+		return result, nil
+	case <-ctx.Done():
+		return mcp.ElicitResult{Action: "cancel"}, nil
+	}
+}
+
+// Answer passes the answer of the user to the question with the ID that a server of the session of
+// ctx is waiting for.
+func Answer(ctx context.Context, id string, result mcp.ElicitResult) error {
+	switch result.Action {
+	case "accept", "decline", "cancel":
+	default:
+		return mcp.ErrRPCInvalidParams.WithMessage("invalid action %q, must be accept, decline, or cancel", result.Action)
+	}
+
+	session := rootSession(ctx)
+	if session == nil {
+		return fmt.Errorf("session not found in context")
+	}
+
+	lock.Lock()
+	answers, ok := pending[session.ID()+"/"+id]
+	delete(pending, session.ID()+"/"+id)
+	lock.Unlock()
+	if !ok {
+		return mcp.ErrRPCInvalidParams.WithMessage("no question %s is waiting for an answer", id)
+	}
+
+	answers <- result
+	return nil
+}
+
+func rootSession(ctx context.Context) *mcp.Session {
+	session := mcp.SessionFromContext(ctx)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	return session
+}
@@ -0,0 +1,52 @@
+package elicit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestAskAndAnswer(t *testing.T) {
+	session := mcp.NewEmptySession(context.Background())
+	ctx := mcp.WithSession(context.Background(), session)
+
+	if result, err := Ask(ctx, "weather", mcp.ElicitRequest{Message: "Which city?"}); err != nil || result.Action != "cancel" {
+		t.Fatalf("expected a question outside of a turn to be cancelled, got %v, %v", result, err)
+	}
+
+	end := StartTurn(ctx, "turn-1")
+	defer end()
+
+	events := make(chan *types.ProgressElicitation, 1)
+	ctx = progress.WithListener(ctx, func(p *types.CompletionProgress) {
+		if p.Event != nil && p.Event.Type == types.ProgressEventElicitation {
+			events <- p.Event.Elicitation
+		}
+	})
+
+	go func() {
+		event := <-events
+		if event.Server != "weather" {
+			t.Errorf("expected the question of weather, got %s", event.Server)
+		}
+		if err := Answer(ctx, "unknown", mcp.ElicitResult{Action: "accept"}); err == nil {
+			t.Error("expected an answer to an unknown question to fail")
+		}
+		if err := Answer(ctx, event.ID, mcp.ElicitResult{Action: "accept", Content: map[string]any{"city": "Paris"}}); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	result, err := Ask(ctx, "weather", mcp.ElicitRequest{Message: "Which city?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(result)
+	if string(data) != `{"action":"accept","content":{"city":"Paris"}}` {
+		t.Fatalf("unexpected answer %s", data)
+	}
+}
@@ -0,0 +1,129 @@
+package erase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/gormdsn"
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"gorm.io/gorm"
+)
+
+// Tombstone records that an object owned by an account was erased, so that consumers of exported
+// events or replicated data can erase their copies too. It holds no user data besides the IDs.
+type Tombstone struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	AccountID string    `json:"accountID" gorm:"index;not null"`
+	Kind      string    `json:"kind"`
+	ObjectID  string    `json:"objectID"`
+	ErasedAt  time.Time `json:"erasedAt" gorm:"index"`
+}
+
+// Report describes what was, or for a dry run would be, erased for an account.
+type Report struct {
+	AccountID  string         `json:"accountID"`
+	DryRun     bool           `json:"dryRun"`
+	Counts     map[string]int `json:"counts"`
+	Tombstones []Tombstone    `json:"tombstones,omitempty"`
+}
+
+// targets lists every table that holds data owned by an account, along with the column that
+// identifies a row to downstream consumers. New tables holding user data must be added here.
+var targets = []struct {
+	kind   string
+	model  any
+	column string
+}{
+	{"session", &session.Session{}, "session_id"},
+	{"history", &session.HistoryMessage{}, "history_id"},
+	{"resource", &resources.Resource{}, "uuid"},
+	{"token", &session.Token{}, "url"},
+}
+
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+func NewStoreFromDSN(dsn string) (*Store, error) {
+	db, err := gormdsn.NewDBFromDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	s := NewStore(db)
+	return s, s.Init()
+}
+
+// Init migrates the tombstone table and makes sure all erasable tables exist.
+func (s *Store) Init() error {
+	models := []any{&Tombstone{}}
+	for _, target := range targets {
+		models = append(models, target.model)
+	}
+	return gormdsn.Migrate(s.db, models...)
+}
+
+// Erase permanently deletes all data owned by accountID and records a tombstone for every deleted
+// object. Rows are hard deleted, including ones that were previously soft deleted. With dryRun
+// nothing is changed and the report lists what would be deleted.
+func (s *Store) Erase(ctx context.Context, accountID string, dryRun bool) (*Report, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+
+	report := &Report{
+		AccountID: accountID,
+		DryRun:    dryRun,
+		Counts:    map[string]int{},
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		for _, target := range targets {
+			var ids []string
+			if err := tx.Unscoped().Model(target.model).Where("account_id = ?", accountID).Distinct(target.column).Pluck(target.column, &ids).Error; err != nil {
+				return fmt.Errorf("failed to find %s records: %w", target.kind, err)
+			}
+
+			report.Counts[target.kind] = len(ids)
+			for _, id := range ids {
+				report.Tombstones = append(report.Tombstones, Tombstone{
+					AccountID: accountID,
+					Kind:      target.kind,
+					ObjectID:  id,
+					ErasedAt:  now,
+				})
+			}
+
+			if dryRun || len(ids) == 0 {
+				continue
+			}
+			if err := tx.Unscoped().Where("account_id = ?", accountID).Delete(target.model).Error; err != nil {
+				return fmt.Errorf("failed to delete %s records: %w", target.kind, err)
+			}
+		}
+
+		if dryRun || len(report.Tombstones) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(report.Tombstones, 100).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Tombstones returns the tombstones recorded after the tombstone with ID after, oldest first, so
+// that consumers can page through them and remember the last ID they processed.
+func (s *Store) Tombstones(ctx context.Context, after uint, limit int) ([]Tombstone, error) {
+	var tombstones []Tombstone
+	err := s.db.WithContext(ctx).Where("id > ?", after).Order("id").Limit(limit).Find(&tombstones).Error
+	return tombstones, err
+}
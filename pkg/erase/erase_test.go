@@ -0,0 +1,68 @@
+package erase
+
+import (
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+)
+
+func TestErase(t *testing.T) {
+	store, err := NewStoreFromDSN("sqlite::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessions := session.NewStore(store.db)
+	for _, s := range []*session.Session{
+		{SessionID: "s1", AccountID: "alice"},
+		{SessionID: "s2", AccountID: "alice"},
+		{SessionID: "s3", AccountID: "bob"},
+	} {
+		if err := sessions.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Soft deleted records must be erased too.
+	if err := sessions.Delete(ctx, "s2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := resources.NewStore(store.db).Create(ctx, &resources.Resource{UUID: "r1", AccountID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := store.Erase(ctx, "alice", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Counts["session"] != 2 || report.Counts["resource"] != 1 || len(report.Tombstones) != 3 {
+		t.Fatalf("unexpected dry run report: %+v", report)
+	}
+	if tombstones, err := store.Tombstones(ctx, 0, 10); err != nil || len(tombstones) != 0 {
+		t.Fatalf("dry run recorded tombstones: %v %v", tombstones, err)
+	}
+
+	if _, err := store.Erase(ctx, "alice", false); err != nil {
+		t.Fatal(err)
+	}
+
+	var remaining []session.Session
+	if err := store.db.Unscoped().Find(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].AccountID != "bob" {
+		t.Fatalf("unexpected remaining sessions: %+v", remaining)
+	}
+
+	tombstones, err := store.Tombstones(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tombstones) != 3 {
+		t.Fatalf("expected 3 tombstones, got %d", len(tombstones))
+	}
+	if rest, _ := store.Tombstones(ctx, tombstones[1].ID, 10); len(rest) != 1 {
+		t.Fatalf("expected 1 tombstone after %d, got %d", tombstones[1].ID, len(rest))
+	}
+}
@@ -0,0 +1,288 @@
+// Package errreport sends panics and failed provider and tool calls to a Sentry compatible error
+// tracker. Events only carry IDs and classifications, such as the session, account, agent, model,
+// tool, and the kind of a provider error, never prompts, tool arguments, or the messages of errors,
+// which can contain the content of a conversation.
+package errreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"github.com/nanobot-ai/nanobot/pkg/version"
+)
+
+const (
+	queueSize = 100
+	// throttle is the minimum time between two events of the same fingerprint, so that a failing
+	// provider or tool does not flood the error tracker.
+	throttle = time.Minute
+)
+
+// reporter is the reporter installed by Setup, nil if reporting is disabled.
+var reporter *Reporter
+
+type Config struct {
+	// DSN is the Sentry DSN of the project, such as https://key@o1.ingest.sentry.io/2. Reporting is
+	// disabled when empty.
+	DSN         string
+	Environment string
+}
+
+// Setup installs the global reporter. The returned function sends the queued events and must be
+// called before the process exits.
+func Setup(cfg Config) (func(context.Context) error, error) {
+	if cfg.DSN == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	r, err := NewReporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	reporter = r
+	return r.Flush, nil
+}
+
+// Reporter queues events and sends them in the background.
+type Reporter struct {
+	dsn         *dsn
+	environment string
+	http        *http.Client
+	queue       chan *event
+	pending     sync.WaitGroup
+
+	lock      sync.Mutex
+	lastSent  map[string]time.Time
+	lastPanic any
+}
+
+func NewReporter(cfg Config) (*Reporter, error) {
+	d, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reporter{
+		dsn:         d,
+		environment: cfg.Environment,
+		http:        &http.Client{Timeout: 10 * time.Second},
+		queue:       make(chan *event, queueSize),
+		lastSent:    map[string]time.Time{},
+	}
+	go r.run()
+	return r, nil
+}
+
+func (r *Reporter) run() {
+	for e := range r.queue {
+		if err := r.send(context.Background(), e); err != nil {
+			log.Errorf(context.Background(), "failed to report error: %v", err)
+		}
+		r.pending.Done()
+	}
+}
+
+// Flush waits until the queued events are sent or ctx is done.
+func (r *Reporter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueue queues the event unless an event with the same key was queued recently or the queue is
+// full.
+func (r *Reporter) enqueue(key string, e *event) bool {
+	r.lock.Lock()
+	now := time.Now()
+	if last, ok := r.lastSent[key]; ok && now.Sub(last) < throttle {
+		r.lock.Unlock()
+		return false
+	}
+	r.lastSent[key] = now
+	for k, last := range r.lastSent {
+		if now.Sub(last) >= throttle {
+			delete(r.lastSent, k)
+		}
+	}
+	r.lock.Unlock()
+
+	r.pending.Add(1)
+	select {
+	case r.queue <- e:
+		return true
+	default:
+		r.pending.Done()
+		return false
+	}
+}
+
+// newEvent returns an event with the scrubbed context of the session of ctx.
+func (r *Reporter) newEvent(ctx context.Context, level, message string) *event {
+	e := &event{
+		EventID:     strings.ReplaceAll(uuid.String(), "-", ""),
+		Timestamp:   time.Now().UTC(),
+		Platform:    "go",
+		Level:       level,
+		Logger:      version.Name,
+		Release:     version.Name + "@" + version.Get().String(),
+		Environment: r.environment,
+		Message:     message,
+		Tags:        map[string]string{},
+	}
+
+	session := mcp.SessionFromContext(ctx)
+	if session == nil {
+		return e
+	}
+	var accountID string
+	if session.Get(types.AccountIDSessionKey, &accountID) && accountID != "" {
+		e.User = &eventUser{ID: accountID}
+	}
+	for session.Parent != nil {
+		session = session.Parent
+	}
+	if id := session.ID(); id != "" {
+		e.Tags["session_id"] = id
+	}
+	return e
+}
+
+// Provider reports a failed completion. Cancelled completions are not reported.
+func Provider(ctx context.Context, agent, model string, err error) {
+	if reporter == nil || err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	kind, api, status := "error", "", ""
+	var apiErr *apierror.Error
+	switch {
+	case errors.As(err, &apiErr):
+		kind = string(apiErr.Kind())
+		if kind == "" {
+			kind = "unknown"
+		}
+		api, status = apiErr.API, fmt.Sprint(apiErr.Code)
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = "timeout"
+	}
+
+	e := reporter.newEvent(ctx, "error", fmt.Sprintf("completion with %s failed: %s", model, kind))
+	e.Tags["agent"] = agent
+	e.Tags["model"] = model
+	e.Tags["error.kind"] = kind
+	if status != "" {
+		e.Tags["api"] = api
+		e.Tags["http.status_code"] = status
+	}
+	e.Fingerprint = []string{"provider", model, kind}
+	reporter.enqueue(strings.Join(e.Fingerprint, " "), e)
+}
+
+// Tool reports a failed tool call, either an error or an error result. Cancelled calls are not
+// reported.
+func Tool(ctx context.Context, server, tool string, result *types.CallResult, err error) {
+	if reporter == nil || errors.Is(err, context.Canceled) || (err == nil && (result == nil || !result.IsError)) {
+		return
+	}
+
+	kind := "error_result"
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = "timeout"
+	case err != nil:
+		kind = "error"
+	}
+
+	e := reporter.newEvent(ctx, "error", fmt.Sprintf("tool %s/%s failed: %s", server, tool, kind))
+	e.Tags["server"] = server
+	e.Tags["tool"] = tool
+	e.Tags["error.kind"] = kind
+	e.Fingerprint = []string{"tool", server, tool, kind}
+	reporter.enqueue(strings.Join(e.Fingerprint, " "), e)
+}
+
+// Recover reports a panic and panics again, it must be deferred. The event is sent before the
+// panic continues, as it may end the process. A panic passing through several deferred calls of
+// Recover is reported once.
+func Recover(ctx context.Context) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if reporter != nil && recovered != http.ErrAbortHandler && reporter.firstRecover(recovered) {
+		value, stack := panicValue(recovered), stacktrace(debug.Stack())
+		e := reporter.newEvent(ctx, "fatal", "panic: "+value)
+		e.Exception = []exception{{
+			Type:       "panic",
+			Value:      value,
+			Stacktrace: stack,
+		}}
+		key := "panic " + value
+		if len(stack.Frames) > 0 {
+			origin := stack.Frames[len(stack.Frames)-1]
+			key += fmt.Sprintf(" %s.%s:%d", origin.Module, origin.Function, origin.Lineno)
+		}
+		if reporter.enqueue(key, e) {
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			_ = reporter.Flush(flushCtx)
+			cancel()
+		}
+	}
+	panic(recovered)
+}
+
+// firstRecover returns false if the recovered value was already seen by Recover.
+func (r *Reporter) firstRecover(recovered any) bool {
+	if !reflect.TypeOf(recovered).Comparable() {
+		return true
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.lastPanic == recovered {
+		return false
+	}
+	r.lastPanic = recovered
+	return true
+}
+
+// panicValue returns the type of the recovered value, or the message if it is a runtime error, as
+// other values may contain content.
+func panicValue(recovered any) string {
+	var runtimeErr interface {
+		error
+		RuntimeError()
+	}
+	if err, ok := recovered.(error); ok && errors.As(err, &runtimeErr) {
+		return runtimeErr.Error()
+	}
+	return fmt.Sprintf("%T", recovered)
+}
+
+// Middleware reports panics of the handler.
+func Middleware(next http.Handler) http.Handler {
+	if reporter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		defer Recover(req.Context())
+		next.ServeHTTP(rw, req)
+	})
+}
@@ -0,0 +1,96 @@
+package errreport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+)
+
+func TestParseDSN(t *testing.T) {
+	for raw, expected := range map[string]string{
+		"https://abc@o1.ingest.sentry.io/42":      "https://o1.ingest.sentry.io/api/42/envelope/",
+		"http://abc@localhost:9000/sentry/7":      "http://localhost:9000/sentry/api/7/envelope/",
+		"https://abc@errors.example.com/a/b/1234": "https://errors.example.com/a/b/api/1234/envelope/",
+	} {
+		d, err := parseDSN(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+		if d.key != "abc" || d.envelope != expected {
+			t.Errorf("%s: expected key abc and %s, got %s and %s", raw, expected, d.key, d.envelope)
+		}
+	}
+
+	for _, raw := range []string{"https://o1.ingest.sentry.io/42", "https://abc@o1.ingest.sentry.io/", "ftp://abc@host/1"} {
+		if _, err := parseDSN(raw); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	events := make(chan map[string]any, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !strings.Contains(req.Header.Get("X-Sentry-Auth"), "sentry_key=key") {
+			t.Errorf("missing key in %q", req.Header.Get("X-Sentry-Auth"))
+		}
+		scanner := bufio.NewScanner(req.Body)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if len(lines) != 3 {
+			t.Errorf("expected an envelope with 3 lines, got %d", len(lines))
+			return
+		}
+		event := map[string]any{}
+		if err := json.Unmarshal([]byte(lines[2]), &event); err != nil {
+			t.Error(err)
+		}
+		events <- event
+	}))
+	defer server.Close()
+
+	r, err := NewReporter(Config{DSN: strings.Replace(server.URL, "://", "://key@", 1) + "/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter = r
+	defer func() { reporter = nil }()
+
+	apiErr := &apierror.Error{API: "openai", Code: 429, Status: "429 Too Many Requests", Body: "the secret prompt"}
+	Provider(context.Background(), "planner", "gpt-4.1", apiErr)
+	// Identical errors are throttled.
+	Provider(context.Background(), "planner", "gpt-4.1", apiErr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	close(events)
+
+	var received []map[string]any
+	for event := range events {
+		received = append(received, event)
+	}
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(received))
+	}
+
+	data, _ := json.Marshal(received[0])
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("expected the body of the error to be scrubbed, got %s", data)
+	}
+	tags, _ := received[0]["tags"].(map[string]any)
+	if tags["model"] != "gpt-4.1" || tags["agent"] != "planner" || tags["http.status_code"] != "429" || tags["error.kind"] != "rate_limited" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+}
@@ -0,0 +1,181 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/version"
+)
+
+// dsn is a parsed Sentry DSN, https://<key>@<host>[/<path>]/<project>.
+type dsn struct {
+	raw      string
+	key      string
+	envelope string
+}
+
+func parseDSN(raw string) (*dsn, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Sentry DSN: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("invalid Sentry DSN, expected an http or https URL")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN, missing the public key")
+	}
+	prefix, projectID := "", strings.Trim(u.Path, "/")
+	if i := strings.LastIndex(projectID, "/"); i >= 0 {
+		prefix, projectID = projectID[:i], projectID[i+1:]
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN, missing the project ID")
+	}
+
+	envelope := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/api/" + projectID + "/envelope/"}
+	if prefix != "" {
+		envelope.Path = "/" + prefix + envelope.Path
+	}
+	return &dsn{
+		raw:      raw,
+		key:      u.User.Username(),
+		envelope: envelope.String(),
+	}, nil
+}
+
+// event is the subset of the Sentry event payload sent by the reporter.
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   []exception       `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+	User        *eventUser        `json:"user,omitempty"`
+}
+
+type eventUser struct {
+	ID string `json:"id"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stackTrace `json:"stacktrace,omitempty"`
+}
+
+type stackTrace struct {
+	Frames []frame `json:"frames"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// stacktrace parses the output of debug.Stack, the frames of the reporter and of the runtime that
+// recovered the panic are dropped.
+func stacktrace(stack []byte) *stackTrace {
+	var (
+		frames []frame
+		lines  = strings.Split(string(stack), "\n")
+	)
+	for i := 1; i+1 < len(lines); i += 2 {
+		function, location := lines[i], strings.TrimSpace(lines[i+1])
+		if idx := strings.LastIndex(function, "("); idx > 0 {
+			function = function[:idx]
+		}
+		if idx := strings.LastIndex(location, " +0x"); idx > 0 {
+			location = location[:idx]
+		}
+		file, line := location, ""
+		if idx := strings.LastIndex(location, ":"); idx > 0 {
+			file, line = location[:idx], location[idx+1:]
+		}
+		lineno, _ := strconv.Atoi(line)
+
+		module := function
+		if idx := strings.LastIndex(module, "/"); idx >= 0 {
+			if dot := strings.Index(module[idx:], "."); dot >= 0 {
+				module = module[:idx+dot]
+			}
+		} else if dot := strings.Index(module, "."); dot >= 0 {
+			module = module[:dot]
+		}
+
+		if module == "runtime/debug" || strings.HasSuffix(module, "/pkg/errreport") ||
+			(module == "runtime" && function == "runtime.gopanic") {
+			continue
+		}
+		frames = append(frames, frame{
+			Function: strings.TrimPrefix(function, module+"."),
+			Module:   module,
+			AbsPath:  file,
+			Lineno:   lineno,
+			InApp:    strings.HasPrefix(module, "github.com/nanobot-ai/nanobot/"),
+		})
+	}
+	// Sentry expects the oldest frame first.
+	slices.Reverse(frames)
+	return &stackTrace{Frames: frames}
+}
+
+// send posts the event to the envelope endpoint of the project.
+func (r *Reporter) send(ctx context.Context, e *event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var body bytes.Buffer
+	for _, line := range []any{
+		map[string]any{"event_id": e.EventID, "sent_at": time.Now().UTC(), "dsn": r.dsn.raw},
+		map[string]any{"type": "event", "length": len(payload)},
+	} {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		body.Write(data)
+		body.WriteByte('\n')
+	}
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.dsn.envelope, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s/%s, sentry_key=%s",
+		version.Name, version.Get().Tag, r.dsn.key))
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send event: %s", resp.Status)
+	}
+	return nil
+}
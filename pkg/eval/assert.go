@@ -0,0 +1,350 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Assertion checks the answer of the agent. Exactly one of Regex, JSONPath, and Judge is set.
+type Assertion struct {
+	// Regex must match the answer.
+	Regex string `json:"regex,omitempty"`
+	// JSONPath selects a value of the answer parsed as JSON, such as $.items[0].name. The value
+	// must equal Equals if it is set, otherwise it must exist. A JSON object or array in a code
+	// block or surrounded by text is parsed too.
+	JSONPath string `json:"jsonPath,omitempty"`
+	Equals   any    `json:"equals,omitempty"`
+	// Judge is a criterion the answer must meet, graded by the judge of the suite.
+	Judge string `json:"judge,omitempty"`
+	// Not negates the assertion.
+	Not bool `json:"not,omitempty"`
+}
+
+func (a Assertion) validate() error {
+	var kinds int
+	for _, set := range []bool{a.Regex != "", a.JSONPath != "", a.Judge != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return errors.New("exactly one of regex, jsonPath, and judge must be set")
+	}
+	if a.Equals != nil && a.JSONPath == "" {
+		return errors.New("equals is only supported with jsonPath")
+	}
+	if a.Regex != "" {
+		if _, err := regexp.Compile(a.Regex); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	if a.JSONPath != "" {
+		if _, err := parseJSONPath(a.JSONPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a Assertion) String() string {
+	var s string
+	switch {
+	case a.Regex != "":
+		s = fmt.Sprintf("regex %q", a.Regex)
+	case a.JSONPath != "" && a.Equals != nil:
+		equals, _ := json.Marshal(a.Equals)
+		s = fmt.Sprintf("jsonPath %s equals %s", a.JSONPath, equals)
+	case a.JSONPath != "":
+		s = fmt.Sprintf("jsonPath %s", a.JSONPath)
+	default:
+		s = fmt.Sprintf("judge %q", a.Judge)
+	}
+	if a.Not {
+		return "not " + s
+	}
+	return s
+}
+
+// assert checks the answer of a case and returns the assertions it does not meet.
+func (r *runner) assert(ctx context.Context, c Case, output string) (failures []string) {
+	for _, assertion := range c.Assertions {
+		var (
+			ok     bool
+			reason string
+			err    error
+		)
+		switch {
+		case assertion.Regex != "":
+			ok = regexp.MustCompile(assertion.Regex).MatchString(output)
+		case assertion.JSONPath != "":
+			ok, reason = matchJSONPath(assertion, output)
+		default:
+			ok, reason, err = r.grade(ctx, c.Prompt, output, assertion.Judge)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", assertion, err))
+			continue
+		}
+		if ok == assertion.Not {
+			failure := fmt.Sprintf("%s failed", assertion)
+			if reason != "" {
+				failure += ": " + reason
+			}
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+// checkToolCalls returns the expected tool calls that were not made.
+func checkToolCalls(expected []ExpectedToolCall, calls []ToolCall) (failures []string) {
+	for _, want := range expected {
+		if !containsToolCall(calls, want) {
+			failure := fmt.Sprintf("expected a call of tool %s", want.Name)
+			if len(want.Arguments) > 0 {
+				args, _ := json.Marshal(want.Arguments)
+				failure += fmt.Sprintf(" with arguments %s", args)
+			}
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+func containsToolCall(calls []ToolCall, want ExpectedToolCall) bool {
+	for _, call := range calls {
+		if call.Name != want.Name && call.Target+"/"+call.Name != want.Name {
+			continue
+		}
+		if len(want.Arguments) == 0 {
+			return true
+		}
+		var args any
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err == nil && contains(args, normalize(want.Arguments)) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains returns whether the objects of want are contained in got, and its other values are
+// equal.
+func contains(got, want any) bool {
+	wantObj, ok := want.(map[string]any)
+	if !ok {
+		return reflect.DeepEqual(got, want)
+	}
+	gotObj, ok := got.(map[string]any)
+	if !ok {
+		return false
+	}
+	for k, v := range wantObj {
+		if gotValue, ok := gotObj[k]; !ok || !contains(gotValue, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize converts a value to the types encoding/json decodes to, so it compares equal to
+// decoded JSON.
+func normalize(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var result any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return v
+	}
+	return result
+}
+
+func matchJSONPath(assertion Assertion, output string) (bool, string) {
+	doc, err := parseJSON(output)
+	if err != nil {
+		return false, err.Error()
+	}
+	path, _ := parseJSONPath(assertion.JSONPath)
+	value, ok := path.get(doc)
+	if !ok {
+		return false, fmt.Sprintf("%s not found", assertion.JSONPath)
+	}
+	if assertion.Equals == nil {
+		return true, ""
+	}
+	if contains(value, normalize(assertion.Equals)) && contains(normalize(assertion.Equals), value) {
+		return true, ""
+	}
+	data, _ := json.Marshal(value)
+	return false, fmt.Sprintf("got %s", data)
+}
+
+// parseJSON parses the answer as JSON. If the whole answer is not JSON, the first code block or
+// the text from the first { or [ to the last } or ] is parsed.
+func parseJSON(output string) (any, error) {
+	candidates := []string{strings.TrimSpace(output)}
+	if _, block, ok := strings.Cut(output, "```"); ok {
+		block, _, _ = strings.Cut(block, "```")
+		// Skip the language of the block.
+		if lang, rest, ok := strings.Cut(block, "\n"); ok && !strings.ContainsAny(lang, "{[") {
+			block = rest
+		}
+		candidates = append(candidates, block)
+	}
+	if start, end := strings.IndexAny(output, "{["), strings.LastIndexAny(output, "}]"); start >= 0 && end > start {
+		candidates = append(candidates, output[start:end+1])
+	}
+
+	for _, candidate := range candidates {
+		var doc any
+		if err := json.Unmarshal([]byte(candidate), &doc); err == nil {
+			return doc, nil
+		}
+	}
+	return nil, errors.New("the answer is not JSON")
+}
+
+// jsonPath is a subset of JSONPath: the root $ followed by .key, ['key'], and [index] segments.
+// Negative indexes count from the end of arrays.
+type jsonPath []any
+
+var jsonPathSegment = regexp.MustCompile(`^(?:\.([A-Za-z_$][\w$-]*)|\['((?:[^'\\]|\\.)*)'\]|\["((?:[^"\\]|\\.)*)"\]|\[(-?\d+)\])`)
+
+func parseJSONPath(s string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(s), "$")
+	if !ok {
+		return nil, fmt.Errorf("invalid jsonPath %q: must start with $", s)
+	}
+
+	var path jsonPath
+	for rest != "" {
+		m := jsonPathSegment.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("invalid jsonPath %q at %q", s, rest)
+		}
+		switch {
+		case m[1] != "":
+			path = append(path, m[1])
+		case m[4] != "":
+			i, _ := strconv.Atoi(m[4])
+			path = append(path, i)
+		default:
+			key := m[2] + m[3]
+			key = strings.NewReplacer(`\'`, `'`, `\"`, `"`, `\\`, `\`).Replace(key)
+			path = append(path, key)
+		}
+		rest = rest[len(m[0]):]
+	}
+	return path, nil
+}
+
+func (p jsonPath) get(doc any) (any, bool) {
+	for _, segment := range p {
+		switch s := segment.(type) {
+		case string:
+			obj, ok := doc.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = obj[s]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := doc.([]any)
+			if !ok {
+				return nil, false
+			}
+			if s < 0 {
+				s += len(arr)
+			}
+			if s < 0 || s >= len(arr) {
+				return nil, false
+			}
+			doc = arr[s]
+		}
+	}
+	return doc, true
+}
+
+const judgeSystemPrompt = `You grade the answers of an AI agent. You are given the prompt the agent received, its answer, and a criterion. Decide whether the answer meets the criterion, judging only the criterion and not the style or other qualities of the answer. Reply with a JSON object with a boolean "pass" and a short "reason".`
+
+var judgeSchema = &types.OutputSchema{
+	Name:   "judgement",
+	Strict: true,
+	Schema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "pass": {"type": "boolean"},
+    "reason": {"type": "string"}
+  },
+  "required": ["pass", "reason"],
+  "additionalProperties": false
+}`),
+}
+
+// grade asks the judge whether the answer to the prompt meets the criterion.
+func (r *runner) grade(ctx context.Context, prompt, output, criterion string) (bool, string, error) {
+	if r.judge == "" {
+		return false, "", errors.New("no judge model is configured")
+	}
+
+	resp, err := r.runt.Complete(r.runt.WithTempSession(ctx, r.config), types.CompletionRequest{
+		Model:        r.judge,
+		SystemPrompt: judgeSystemPrompt,
+		OutputSchema: judgeSchema,
+		Input: []types.Message{{
+			Role: "user",
+			Items: []types.CompletionItem{{
+				Content: &mcp.Content{
+					Type: "text",
+					Text: fmt.Sprintf("<prompt>\n%s\n</prompt>\n\n<answer>\n%s\n</answer>\n\n<criterion>\n%s\n</criterion>", prompt, output, criterion),
+				},
+			}},
+		}},
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to grade answer: %w", err)
+	}
+
+	var (
+		content   []mcp.Content
+		judgement struct {
+			Pass   bool   `json:"pass"`
+			Reason string `json:"reason"`
+		}
+	)
+	for _, item := range resp.Output.Items {
+		if item.Content != nil {
+			content = append(content, *item.Content)
+		}
+	}
+	doc, err := parseJSON(text(content))
+	if err == nil {
+		data, _ := json.Marshal(doc)
+		err = json.Unmarshal(data, &judgement)
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("invalid judgement %q: %w", text(content), err)
+	}
+	return judgement.Pass, judgement.Reason, nil
+}
+
+func text(content []mcp.Content) string {
+	var text []string
+	for _, c := range content {
+		if c.Type == "text" {
+			text = append(text, c.Text)
+		}
+	}
+	return strings.Join(text, "\n\n")
+}
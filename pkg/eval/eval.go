@@ -0,0 +1,393 @@
+// Package eval runs a suite of test cases against the agents of a nanobot. Each case sends a
+// prompt to an agent in a temporary session and checks the tool calls the agent made and its
+// answer, so that changes to prompts, models, and tools can be checked for regressions.
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/pricing"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/tools"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"sigs.k8s.io/yaml"
+)
+
+// Runtime runs the prompts of the cases and the completions of the LLM judge.
+type Runtime interface {
+	types.Completer
+	Call(ctx context.Context, server, tool string, args any, opts ...tools.CallOptions) (*types.CallResult, error)
+	WithTempSession(ctx context.Context, config *types.Config) context.Context
+}
+
+// Suite is a set of cases, read from a YAML or JSON file.
+type Suite struct {
+	// Agents the cases are run against, unless a case sets its own. Defaults to the first
+	// entrypoint of the config.
+	Agents []string `json:"agents,omitempty"`
+	// Judge is the model or agent that grades judge assertions.
+	Judge string `json:"judge,omitempty"`
+	// Pricing is the price of the tokens of a model in USD per million tokens, by model name, to
+	// estimate the cost of the cases.
+	Pricing pricing.Table `json:"pricing,omitempty"`
+	Cases   []Case        `json:"cases"`
+}
+
+type Case struct {
+	Name   string `json:"name"`
+	Prompt string `json:"prompt"`
+	// Agents the case is run against, the agents of the suite if not set.
+	Agents []string `json:"agents,omitempty"`
+	// ToolCalls are the tool calls the agent is expected to make, in any order.
+	ToolCalls  []ExpectedToolCall `json:"toolCalls,omitempty"`
+	Assertions []Assertion        `json:"assertions,omitempty"`
+}
+
+type ExpectedToolCall struct {
+	// Name is the name of the tool, optionally prefixed with its server as in server/tool.
+	Name string `json:"name"`
+	// Arguments must be contained in the arguments of the call. Nested objects are matched the
+	// same way, other values must be equal.
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// LoadSuite reads and validates a suite.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite %s: %w", path, err)
+	}
+
+	var suite Suite
+	if err := yaml.UnmarshalStrict(data, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse suite %s: %w", path, err)
+	}
+	if err := suite.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid suite %s: %w", path, err)
+	}
+	return &suite, nil
+}
+
+func (s Suite) Validate() error {
+	if len(s.Cases) == 0 {
+		return errors.New("no cases")
+	}
+
+	var errs []error
+	names := map[string]bool{}
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			errs = append(errs, fmt.Errorf("case %d has no name", i))
+		} else if names[c.Name] {
+			errs = append(errs, fmt.Errorf("case %s is defined more than once", c.Name))
+		}
+		names[c.Name] = true
+
+		if strings.TrimSpace(c.Prompt) == "" {
+			errs = append(errs, fmt.Errorf("case %s has no prompt", c.Name))
+		}
+		for _, call := range c.ToolCalls {
+			if call.Name == "" {
+				errs = append(errs, fmt.Errorf("case %s expects a tool call without a name", c.Name))
+			}
+		}
+		for j, assertion := range c.Assertions {
+			if err := assertion.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("assertion %d of case %s: %w", j, c.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type Options struct {
+	// Agents the cases are run against, overriding the agents of the suite and its cases.
+	Agents []string
+	// Concurrency is the number of cases that run at the same time.
+	Concurrency int
+	// Judge is the model or agent that grades judge assertions, if the suite does not set one.
+	Judge string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Agents = o.Agents
+	if len(other.Agents) > 0 {
+		result.Agents = other.Agents
+	}
+	result.Concurrency = complete.Last(o.Concurrency, other.Concurrency)
+	result.Judge = complete.Last(o.Judge, other.Judge)
+	return
+}
+
+func (o Options) Complete() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	return o
+}
+
+type Report struct {
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Duration time.Duration `json:"duration"`
+	// Usage is the usage of the agents in all cases. The usage of the judge is not included.
+	Usage types.Usage `json:"usage"`
+	// Cost is the estimated cost in USD, if the suite has the pricing of the models.
+	Cost    float64        `json:"cost,omitempty"`
+	Agents  []AgentSummary `json:"agents"`
+	Results []Result       `json:"results"`
+}
+
+type AgentSummary struct {
+	Agent  string      `json:"agent"`
+	Passed int         `json:"passed"`
+	Failed int         `json:"failed"`
+	Usage  types.Usage `json:"usage"`
+	Cost   float64     `json:"cost,omitempty"`
+}
+
+type Result struct {
+	Case     string        `json:"case"`
+	Agent    string        `json:"agent"`
+	Model    string        `json:"model,omitempty"`
+	Passed   bool          `json:"passed"`
+	Duration time.Duration `json:"duration"`
+	// Failures are the expectations and assertions the answer did not meet.
+	Failures  []string    `json:"failures,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Output    string      `json:"output,omitempty"`
+	ToolCalls []ToolCall  `json:"toolCalls,omitempty"`
+	Usage     types.Usage `json:"usage"`
+	Cost      float64     `json:"cost,omitempty"`
+}
+
+type ToolCall struct {
+	Name      string `json:"name"`
+	Target    string `json:"target,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	IsError   bool   `json:"isError,omitempty"`
+}
+
+// Run runs every case of the suite against each of its agents and reports the results in the
+// order of the cases.
+func Run(ctx context.Context, runt Runtime, config *types.Config, suite Suite, opts ...Options) (*Report, error) {
+	opt := complete.Complete(opts...)
+
+	type job struct {
+		c     Case
+		agent string
+	}
+	var jobs []job
+	for _, c := range suite.Cases {
+		agents := opt.Agents
+		if len(agents) == 0 {
+			agents = c.Agents
+		}
+		if len(agents) == 0 {
+			agents = suite.Agents
+		}
+		if len(agents) == 0 && len(config.Publish.Entrypoint) > 0 {
+			agents = config.Publish.Entrypoint[:1]
+		}
+		if len(agents) == 0 {
+			return nil, fmt.Errorf("case %s has no agent and the config has no default agent", c.Name)
+		}
+		for _, agent := range agents {
+			if _, ok := config.Agents[agent]; !ok {
+				return nil, fmt.Errorf("agent %q of case %s not found in config", agent, c.Name)
+			}
+			jobs = append(jobs, job{c: c, agent: agent})
+		}
+	}
+
+	r := &runner{
+		runt:   runt,
+		config: config,
+		suite:  suite,
+		judge:  complete.First(suite.Judge, opt.Judge),
+	}
+
+	var (
+		start   = time.Now()
+		results = make([]Result, len(jobs))
+		sem     = make(chan struct{}, opt.Concurrency)
+		wg      sync.WaitGroup
+	)
+	for i, j := range jobs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = r.run(ctx, j.c, j.agent)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return newReport(results, time.Since(start)), nil
+}
+
+func newReport(results []Result, duration time.Duration) *Report {
+	report := &Report{
+		Duration: duration,
+		Results:  results,
+	}
+
+	summaries := map[string]*AgentSummary{}
+	for _, result := range results {
+		summary, ok := summaries[result.Agent]
+		if !ok {
+			report.Agents = append(report.Agents, AgentSummary{Agent: result.Agent})
+			summary = &AgentSummary{Agent: result.Agent}
+			summaries[result.Agent] = summary
+		}
+		if result.Passed {
+			report.Passed++
+			summary.Passed++
+		} else {
+			report.Failed++
+			summary.Failed++
+		}
+		addUsage(&report.Usage, result.Usage)
+		addUsage(&summary.Usage, result.Usage)
+		report.Cost += result.Cost
+		summary.Cost += result.Cost
+	}
+	for i, summary := range report.Agents {
+		report.Agents[i] = *summaries[summary.Agent]
+	}
+	return report
+}
+
+type runner struct {
+	runt   Runtime
+	config *types.Config
+	suite  Suite
+	judge  string
+}
+
+// run runs a case against an agent in a new temporary session.
+func (r *runner) run(ctx context.Context, c Case, agent string) Result {
+	var (
+		start    = time.Now()
+		recorder = newRecorder()
+		result   = Result{
+			Case:  c.Name,
+			Agent: agent,
+		}
+	)
+
+	callCtx := r.runt.WithTempSession(ctx, r.config)
+	callCtx = progress.WithListener(callCtx, recorder.progress)
+	callCtx = types.WithUsageListener(callCtx, recorder.usage)
+
+	callResult, err := r.runt.Call(callCtx, agent, types.AgentTool, map[string]any{
+		"prompt": c.Prompt,
+	}, tools.CallOptions{
+		ProgressToken: uuid.String(),
+		LogData: map[string]any{
+			"mcpToolName": agent,
+		},
+	})
+
+	result.ToolCalls, result.Usage = recorder.result()
+	result.Model = r.config.Agents[agent].Model
+	if callResult != nil {
+		result.Model = complete.First(callResult.Model, result.Model)
+		result.Output = text(callResult.Content)
+	}
+	result.Cost = r.suite.Pricing.Cost(result.Model, result.Usage)
+
+	if err == nil && callResult.IsError {
+		err = fmt.Errorf("agent %s failed: %s", agent, result.Output)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Failures = append(checkToolCalls(c.ToolCalls, result.ToolCalls), r.assert(ctx, c, result.Output)...)
+		result.Passed = len(result.Failures) == 0
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// recorder records the tool calls and the usage of a case. Progress is reported concurrently by
+// parallel tool calls.
+type recorder struct {
+	lock      sync.Mutex
+	toolCalls []ToolCall
+	// calls are the indexes of the tool calls by call ID.
+	calls map[string]int
+	total types.Usage
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		calls: map[string]int{},
+	}
+}
+
+func (r *recorder) progress(p *types.CompletionProgress) {
+	item := p.Item
+	if p.Role == "user" || item.ToolCall == nil || item.ToolCall.CallID == "" {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	i, ok := r.calls[item.ToolCall.CallID]
+	if !ok {
+		i = len(r.toolCalls)
+		r.calls[item.ToolCall.CallID] = i
+		r.toolCalls = append(r.toolCalls, ToolCall{})
+	}
+	call := &r.toolCalls[i]
+	call.Name = complete.First(call.Name, item.ToolCall.Name)
+	call.Target = complete.First(call.Target, item.ToolCall.Target)
+	switch {
+	case item.ToolCallResult != nil:
+		call.IsError = item.ToolCallResult.Output.IsError
+	case item.Partial:
+		call.Arguments += item.ToolCall.Arguments
+	case item.ToolCall.Arguments != "":
+		call.Arguments = item.ToolCall.Arguments
+	}
+}
+
+func (r *recorder) usage(usage types.Usage) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	addUsage(&r.total, usage)
+}
+
+func (r *recorder) result() ([]ToolCall, types.Usage) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return slices.Clone(r.toolCalls), r.total
+}
+
+func addUsage(total *types.Usage, usage types.Usage) {
+	total.InputTokens += usage.InputTokens
+	total.OutputTokens += usage.OutputTokens
+	total.CachedInputTokens += usage.CachedInputTokens
+	total.ReasoningTokens += usage.ReasoningTokens
+}
@@ -0,0 +1,179 @@
+package eval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/pricing"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/tools"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type fakeRuntime struct {
+	answers map[string]string
+}
+
+func (f *fakeRuntime) WithTempSession(ctx context.Context, _ *types.Config) context.Context {
+	return ctx
+}
+
+func (f *fakeRuntime) Call(ctx context.Context, server, _ string, args any, opts ...tools.CallOptions) (*types.CallResult, error) {
+	prompt := args.(map[string]any)["prompt"].(string)
+	if server == "main" && prompt == "Weather in Paris?" {
+		for _, item := range []types.CompletionItem{
+			{Partial: true, ToolCall: &types.ToolCall{CallID: "c1", Name: "forecast", Target: "weather", Arguments: `{"city": "Pa`}},
+			{Partial: true, ToolCall: &types.ToolCall{CallID: "c1", Arguments: `ris", "days": 3}`}},
+		} {
+			progress.Send(ctx, &types.CompletionProgress{Role: "assistant", Item: item}, opts[0].ProgressToken)
+		}
+	}
+	types.RecordUsage(ctx, &types.Usage{InputTokens: 1000, CachedInputTokens: 500, OutputTokens: 100})
+	return &types.CallResult{
+		Agent:   server,
+		Content: []mcp.Content{{Type: "text", Text: f.answers[server+": "+prompt]}},
+	}, nil
+}
+
+func (f *fakeRuntime) Complete(_ context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	pass := strings.Contains(req.Input[0].Items[0].Content.Text, "sunny")
+	answer := `{"pass": false, "reason": "no weather"}`
+	if pass {
+		answer = `{"pass": true, "reason": "ok"}`
+	}
+	return &types.CompletionResponse{
+		Output: types.Message{Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: answer}}}},
+	}, nil
+}
+
+func TestRun(t *testing.T) {
+	runt := &fakeRuntime{answers: map[string]string{
+		"main: Weather in Paris?": "It is sunny in Paris.",
+		"mini: Weather in Paris?": "I don't know.",
+		"main: List the planets.": "```json\n{\"planets\": [\"Mercury\", \"Venus\"]}\n```",
+		"mini: List the planets.": `Here you go: {"planets": ["Mercury"]}`,
+	}}
+	config := &types.Config{Agents: map[string]types.Agent{"main": {Model: "gpt-4.1"}, "mini": {Model: "gpt-4.1-mini"}}}
+	suite := Suite{
+		Agents: []string{"main", "mini"},
+		Pricing: pricing.Table{
+			"gpt-4.1": {Input: 2, CachedInput: 0.5, Output: 8},
+		},
+		Cases: []Case{{
+			Name:      "weather",
+			Prompt:    "Weather in Paris?",
+			ToolCalls: []ExpectedToolCall{{Name: "weather/forecast", Arguments: map[string]any{"city": "Paris"}}},
+			Assertions: []Assertion{
+				{Regex: "(?i)paris"},
+				{Judge: "The answer describes the weather."},
+			},
+		}, {
+			Name:   "planets",
+			Prompt: "List the planets.",
+			Assertions: []Assertion{
+				{JSONPath: "$.planets[1]", Equals: "Venus"},
+				{JSONPath: "$.planets[5]", Not: true},
+			},
+		}},
+	}
+
+	report, err := Run(t.Context(), runt, config, suite, Options{Concurrency: 3, Judge: "judge"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, result := range report.Results {
+		got = append(got, result.Case+"/"+result.Agent+": "+strings.Join(result.Failures, "; "))
+	}
+	want := []string{
+		"weather/main: ",
+		`weather/mini: expected a call of tool weather/forecast with arguments {"city":"Paris"}; regex "(?i)paris" failed; judge "The answer describes the weather." failed: no weather`,
+		"planets/main: ",
+		"planets/mini: jsonPath $.planets[1] equals \"Venus\" failed: $.planets[1] not found",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got results\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if report.Passed != 2 || report.Failed != 2 || len(report.Agents) != 2 || report.Agents[0].Agent != "main" || report.Agents[0].Passed != 2 {
+		t.Errorf("unexpected summary %+v", report)
+	}
+	// Only gpt-4.1 has a price: 500 uncached and 500 cached input tokens and 100 output tokens per case.
+	if want := 2 * (500*2 + 500*0.5 + 100*8) / 1_000_000.0; report.Cost != want || report.Agents[1].Cost != 0 {
+		t.Errorf("got cost %v, want %v", report.Cost, want)
+	}
+	if report.Usage.InputTokens != 4000 {
+		t.Errorf("got %d input tokens, want 4000", report.Usage.InputTokens)
+	}
+	if calls := report.Results[0].ToolCalls; len(calls) != 1 || calls[0].Arguments != `{"city": "Paris", "days": 3}` {
+		t.Errorf("unexpected tool calls %+v", calls)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	doc, err := parseJSON("Result:\n[{\"name\": \"a\", \"tags\": {\"x y\": [1, 2]}}]\nDone.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]any{
+		"$":                    nil,
+		"$[0].name":            "a",
+		"$[-1]['name']":        "a",
+		`$[0].tags["x y"][1]`:  2.0,
+		"$[0].tags['x y'][-2]": 1.0,
+	} {
+		p, err := parseJSONPath(path)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", path, err)
+		}
+		got, ok := p.get(doc)
+		if !ok || (want != nil && got != want) {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+
+	for _, path := range []string{"$[1]", "$[0].missing", "$[0].name[0]"} {
+		p, _ := parseJSONPath(path)
+		if got, ok := p.get(doc); ok {
+			t.Errorf("%s: expected no value, got %v", path, got)
+		}
+	}
+	for _, path := range []string{"name", "$.", "$[x]"} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Errorf("expected %q to be invalid", path)
+		}
+	}
+}
+
+func TestLoadSuite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "suite.yaml")
+	if err := os.WriteFile(path, []byte(`
+cases:
+- name: a
+  prompt: Hi
+  assertions:
+  - regex: "("
+  - regex: a
+    judge: b
+- name: a
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadSuite(path)
+	if err == nil {
+		t.Fatal("expected the suite to be invalid")
+	}
+	for _, want := range []string{"invalid regex", "exactly one of", "defined more than once", "has no prompt"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
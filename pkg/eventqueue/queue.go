@@ -0,0 +1,267 @@
+// Package eventqueue buffers the events sent to a subscriber, such as a UI following the progress
+// of a chat, so that a slow subscriber never blocks the agent loop that produces the events. The
+// buffer of each subscriber is bounded: adjacent content deltas are merged into one event, and
+// once the buffer is full the oldest events that can be recovered from later ones are dropped.
+package eventqueue
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/metrics"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const defaultSize = 256
+
+type Options struct {
+	// Size is the number of events buffered before events are dropped, defaults to 256.
+	Size int
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Size = complete.Last(o.Size, other.Size)
+	return
+}
+
+func (o Options) Complete() Options {
+	if o.Size <= 0 {
+		o.Size = defaultSize
+	}
+	return o
+}
+
+// Queue is the buffer of a subscriber. Push never blocks, the subscriber waits on Ready and takes
+// the buffered events with Pop.
+type Queue struct {
+	lock    sync.Mutex
+	size    int
+	entries []*entry
+	ready   chan struct{}
+	dropped int
+}
+
+type entry struct {
+	msg mcp.Message
+	// delta is set if the event is a partial content delta of a completion.
+	delta *delta
+}
+
+func New(opts ...Options) *Queue {
+	opt := complete.Complete(opts...)
+	return &Queue{
+		size:  opt.Size,
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// Ready receives a value when events were pushed since the last Pop.
+func (q *Queue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Dropped returns the number of events dropped because the subscriber fell behind.
+func (q *Queue) Dropped() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.dropped
+}
+
+// Push buffers msg for the subscriber.
+func (q *Queue) Push(msg mcp.Message) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.signal()
+
+	if uri := updatedURI(msg); uri != "" && slices.ContainsFunc(q.entries, func(e *entry) bool {
+		return updatedURI(e.msg) == uri
+	}) {
+		// The subscriber reads the resource when it gets to the pending update, which will include
+		// this change too.
+		metrics.ProgressCoalesced()
+		return
+	}
+
+	d := newDelta(msg)
+	if d != nil && len(q.entries) > 0 {
+		if last := q.entries[len(q.entries)-1]; last.delta != nil && last.delta.merge(d) {
+			metrics.ProgressCoalesced()
+			return
+		}
+	}
+
+	q.entries = append(q.entries, &entry{msg: msg, delta: d})
+	if len(q.entries) > q.size {
+		q.dropOldest()
+	}
+}
+
+// dropOldest removes the oldest event that is not critical. Critical events, such as requests,
+// errors and completed items, are never dropped, the buffer may exceed its size for them.
+func (q *Queue) dropOldest() {
+	i := slices.IndexFunc(q.entries, func(e *entry) bool {
+		return !critical(e)
+	})
+	if i < 0 {
+		return
+	}
+	q.entries = slices.Delete(q.entries, i, i+1)
+	q.dropped++
+	metrics.ProgressDropped()
+}
+
+func (q *Queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Pop returns the buffered events in the order they were pushed and empties the buffer.
+func (q *Queue) Pop() []mcp.Message {
+	q.lock.Lock()
+	entries := q.entries
+	q.entries = nil
+	q.lock.Unlock()
+
+	result := make([]mcp.Message, 0, len(entries))
+	for _, e := range entries {
+		if e.delta != nil && e.delta.merged {
+			if params, err := e.delta.params(); err == nil {
+				e.msg.Params = params
+			}
+		}
+		result = append(result, e.msg)
+	}
+	return result
+}
+
+// critical reports if the subscriber can not recover from missing the event. Partial deltas are
+// followed by an update of the progress resource that holds the whole content, and log messages
+// are informational.
+func critical(e *entry) bool {
+	if e.msg.ID != nil || e.msg.Error != nil {
+		return true
+	}
+	switch e.msg.Method {
+	case "notifications/message":
+		return false
+	case "notifications/progress":
+		return e.delta == nil
+	}
+	return true
+}
+
+func updatedURI(msg mcp.Message) string {
+	if msg.Method != "notifications/resources/updated" || msg.ID != nil {
+		return ""
+	}
+	var params struct {
+		URI string `json:"uri"`
+	}
+	_ = json.Unmarshal(msg.Params, &params)
+	return params.URI
+}
+
+// Fields of an item a delta appends to.
+const (
+	fieldText      = "text"
+	fieldArguments = "arguments"
+	fieldReasoning = "reasoning"
+)
+
+type delta struct {
+	request  map[string]json.RawMessage
+	meta     map[string]json.RawMessage
+	progress types.CompletionProgress
+	field    string
+	text     strings.Builder
+	merged   bool
+}
+
+// newDelta returns the delta of msg, or nil if msg is not a partial content delta.
+func newDelta(msg mcp.Message) *delta {
+	if msg.Method != "notifications/progress" || msg.ID != nil || msg.Error != nil {
+		return nil
+	}
+
+	d := &delta{}
+	if err := json.Unmarshal(msg.Params, &d.request); err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(d.request["_meta"], &d.meta); err != nil {
+		return nil
+	}
+	data, ok := d.meta[types.CompletionProgressMetaKey]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(data, &d.progress); err != nil {
+		return nil
+	}
+
+	item := d.progress.Item
+	if d.progress.Event != nil || !item.Partial || item.ToolCallResult != nil {
+		return nil
+	}
+	switch {
+	case item.Content != nil && (item.Content.Type == "" || item.Content.Type == "text"):
+		d.field = fieldText
+		d.text.WriteString(item.Content.Text)
+	case item.ToolCall != nil:
+		d.field = fieldArguments
+		d.text.WriteString(item.ToolCall.Arguments)
+	case item.Reasoning != nil && len(item.Reasoning.Summary) == 1:
+		d.field = fieldReasoning
+		d.text.WriteString(item.Reasoning.Summary[0].Text)
+	default:
+		return nil
+	}
+	return d
+}
+
+// merge appends other to d if it continues the same item.
+func (d *delta) merge(other *delta) bool {
+	if d.field != other.field ||
+		d.progress.MessageID != other.progress.MessageID ||
+		d.progress.Item.ID != other.progress.Item.ID ||
+		!bytes.Equal(d.request["progressToken"], other.request["progressToken"]) {
+		return false
+	}
+	// A tool call delta with a name starts a new call.
+	if d.field == fieldArguments && other.progress.Item.ToolCall.Name != "" {
+		return false
+	}
+	d.text.WriteString(other.text.String())
+	d.progress.Item.HasMore = other.progress.Item.HasMore
+	d.request["progress"] = other.request["progress"]
+	d.merged = true
+	return true
+}
+
+// params returns the parameters of the notification with the merged content.
+func (d *delta) params() (json.RawMessage, error) {
+	item := &d.progress.Item
+	switch d.field {
+	case fieldText:
+		item.Content.Text = d.text.String()
+	case fieldArguments:
+		item.ToolCall.Arguments = d.text.String()
+	case fieldReasoning:
+		item.Reasoning.Summary[0].Text = d.text.String()
+	}
+
+	var err error
+	if d.meta[types.CompletionProgressMetaKey], err = json.Marshal(d.progress); err != nil {
+		return nil, err
+	}
+	if d.request["_meta"], err = json.Marshal(d.meta); err != nil {
+		return nil, err
+	}
+	return json.Marshal(d.request)
+}
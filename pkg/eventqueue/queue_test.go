@@ -0,0 +1,110 @@
+package eventqueue
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func progress(t *testing.T, itemID, text string, partial bool) mcp.Message {
+	t.Helper()
+	params, err := json.Marshal(mcp.NotificationProgressRequest{
+		ProgressToken: "token",
+		Progress:      "1",
+		Meta: map[string]any{
+			types.CompletionProgressMetaKey: types.CompletionProgress{
+				MessageID: "m1",
+				Item: types.CompletionItem{
+					ID:      itemID,
+					Partial: partial,
+					HasMore: partial,
+					Content: &mcp.Content{Type: "text", Text: text},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mcp.Message{JSONRPC: "2.0", Method: "notifications/progress", Params: params}
+}
+
+func progressText(t *testing.T, msg mcp.Message) string {
+	t.Helper()
+	var params struct {
+		Meta struct {
+			Progress types.CompletionProgress `json:"ai.nanobot.progress/completion"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		t.Fatal(err)
+	}
+	return params.Meta.Progress.Item.Content.Text
+}
+
+func updated(uri string) mcp.Message {
+	params, _ := json.Marshal(map[string]string{"uri": uri})
+	return mcp.Message{JSONRPC: "2.0", Method: "notifications/resources/updated", Params: params}
+}
+
+func TestCoalesce(t *testing.T) {
+	q := New()
+	q.Push(progress(t, "i1", "Hello", true))
+	q.Push(updated(types.ProgressURI))
+	q.Push(progress(t, "i1", ", ", true))
+	q.Push(updated(types.ProgressURI))
+	q.Push(progress(t, "i1", "world", true))
+	q.Push(progress(t, "i2", "other item", true))
+	q.Push(progress(t, "i2", "done", false))
+
+	select {
+	case <-q.Ready():
+	default:
+		t.Fatal("expected the queue to be ready")
+	}
+
+	events := q.Pop()
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(events))
+	}
+	if text := progressText(t, events[0]); text != "Hello" {
+		t.Errorf("expected the first delta to be unchanged, got %q", text)
+	}
+	if events[1].Method != "notifications/resources/updated" {
+		t.Errorf("expected a single resource update, got %s", events[1].Method)
+	}
+	if text := progressText(t, events[2]); text != ", world" {
+		t.Errorf("expected adjacent deltas to be merged, got %q", text)
+	}
+	if text := progressText(t, events[4]); text != "done" {
+		t.Errorf("expected the complete item to be kept, got %q", text)
+	}
+	if len(q.Pop()) != 0 {
+		t.Error("expected the queue to be empty")
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	q := New(Options{Size: 3})
+	q.Push(mcp.Message{JSONRPC: "2.0", ID: "1", Method: "elicitation/create"})
+	for i, item := range []string{"a", "b", "c", "d"} {
+		// Deltas of different items are never merged.
+		q.Push(progress(t, item, string(rune('0'+i)), true))
+	}
+
+	events := q.Pop()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Method != "elicitation/create" {
+		t.Errorf("expected the request to be kept, got %s", events[0].Method)
+	}
+	if text := progressText(t, events[1]); text != "2" {
+		t.Errorf("expected the oldest deltas to be dropped, got %q", text)
+	}
+	if q.Dropped() != 2 {
+		t.Errorf("expected 2 dropped events, got %d", q.Dropped())
+	}
+}
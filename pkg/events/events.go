@@ -0,0 +1,314 @@
+// Package events emits lifecycle events, such as a session that was created, a turn of an agent
+// that started or completed, or a tool call that was executed, to sinks like a webhook, NATS, or
+// stdout, so that external systems can react to the activity of agents. Events carry IDs, names,
+// durations, and token usage, never prompts, answers, or tool arguments and results.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+// Types of events.
+const (
+	// SessionCreated is emitted when a session is created.
+	SessionCreated = "session.created"
+	// TurnStarted is emitted when an agent starts to answer.
+	TurnStarted = "turn.started"
+	// TurnCompleted is emitted when an agent answered or failed to answer.
+	TurnCompleted = "turn.completed"
+	// ToolCalled is emitted when a tool call returned, including calls that failed.
+	ToolCalled = "tool.called"
+	// Error is emitted when a completion of an LLM provider failed.
+	Error = "error"
+	// BudgetExceeded is emitted when a request was refused because a budget is used up, either the
+	// sampling budget of an MCP server or the quota of an LLM provider.
+	BudgetExceeded = "budget.exceeded"
+)
+
+// Types are all types of events.
+var Types = []string{SessionCreated, TurnStarted, TurnCompleted, ToolCalled, Error, BudgetExceeded}
+
+const queueSize = 1000
+
+// bus is the bus installed by Setup, nil if no sink is configured.
+var bus *Bus
+
+type Event struct {
+	ID         string       `json:"id"`
+	Type       string       `json:"type"`
+	Time       time.Time    `json:"time"`
+	SessionID  string       `json:"sessionID,omitempty"`
+	AccountID  string       `json:"accountID,omitempty"`
+	Agent      string       `json:"agent,omitempty"`
+	Model      string       `json:"model,omitempty"`
+	Server     string       `json:"server,omitempty"`
+	Tool       string       `json:"tool,omitempty"`
+	DurationMS int64        `json:"durationMs,omitempty"`
+	Usage      *types.Usage `json:"usage,omitempty"`
+	IsError    bool         `json:"isError,omitempty"`
+	ErrorKind  string       `json:"errorKind,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	// Budget is the budget that was used up, in tokens.
+	Budget int `json:"budget,omitempty"`
+}
+
+// Sink sends events to an external system.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+type Config struct {
+	// Webhook is the URL events are posted to.
+	Webhook string
+	// WebhookSecret signs the events posted to the webhook.
+	WebhookSecret string
+	// NATS is the URL of the NATS server events are published to.
+	NATS string
+	// NATSSubject is the prefix of the subjects events are published to, defaults to
+	// nanobot.events.
+	NATSSubject string
+	// Stdout prints the events to stdout as JSON lines.
+	Stdout bool
+	// Types are the types of events that are emitted, all if empty.
+	Types []string
+}
+
+// Setup installs the global bus with the sinks of cfg. The returned function sends the queued
+// events and must be called before the process exits.
+func Setup(cfg Config) (func(context.Context) error, error) {
+	for _, t := range cfg.Types {
+		if !slices.Contains(Types, t) {
+			return nil, fmt.Errorf("invalid event type %q, must be one of %v", t, Types)
+		}
+	}
+
+	sinks := map[string]Sink{}
+	if cfg.Webhook != "" {
+		sinks["webhook"] = NewWebhook(cfg.Webhook, cfg.WebhookSecret)
+	}
+	if cfg.NATS != "" {
+		nats, err := NewNATS(cfg.NATS, cfg.NATSSubject)
+		if err != nil {
+			return nil, err
+		}
+		sinks["nats"] = nats
+	}
+	if cfg.Stdout {
+		sinks["stdout"] = NewStdout(nil)
+	}
+	if len(sinks) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	bus = NewBus(sinks, cfg.Types...)
+	return bus.Flush, nil
+}
+
+// Bus queues events and sends them to each of its sinks in the background, so that a slow sink
+// neither blocks the agents nor the other sinks.
+type Bus struct {
+	types   []string
+	queues  []*queue
+	pending sync.WaitGroup
+}
+
+type queue struct {
+	name   string
+	sink   Sink
+	events chan Event
+}
+
+// NewBus returns a bus that sends events of the given types, or of all types if none are given,
+// to the sinks by name.
+func NewBus(sinks map[string]Sink, types ...string) *Bus {
+	b := &Bus{
+		types: types,
+	}
+	for name, sink := range sinks {
+		q := &queue{
+			name:   name,
+			sink:   sink,
+			events: make(chan Event, queueSize),
+		}
+		b.queues = append(b.queues, q)
+		go b.run(q)
+	}
+	return b
+}
+
+func (b *Bus) run(q *queue) {
+	for event := range q.events {
+		if err := q.sink.Send(context.Background(), event); err != nil {
+			log.Errorf(context.Background(), "failed to send %s event to %s: %v", event.Type, q.name, err)
+		}
+		b.pending.Done()
+	}
+}
+
+// Emit queues the event for all sinks. The event is dropped for sinks whose queue is full.
+func (b *Bus) Emit(event Event) {
+	if len(b.types) > 0 && !slices.Contains(b.types, event.Type) {
+		return
+	}
+	for _, q := range b.queues {
+		b.pending.Add(1)
+		select {
+		case q.events <- event:
+		default:
+			b.pending.Done()
+			log.Errorf(context.Background(), "dropped %s event, the queue of %s is full", event.Type, q.name)
+		}
+	}
+}
+
+// Flush waits until the queued events are sent or ctx is done.
+func (b *Bus) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newEvent returns an event with the IDs of the root session of ctx and its account.
+func newEvent(ctx context.Context, eventType string) Event {
+	e := Event{
+		ID:   uuid.String(),
+		Type: eventType,
+		Time: time.Now().UTC(),
+	}
+
+	session := mcp.SessionFromContext(ctx)
+	if session == nil {
+		return e
+	}
+	session.Get(types.AccountIDSessionKey, &e.AccountID)
+	for session.Parent != nil {
+		session = session.Parent
+	}
+	e.SessionID = session.ID()
+	return e
+}
+
+// Session emits a SessionCreated event.
+func Session(ctx context.Context, sessionID, accountID string) {
+	if bus == nil {
+		return
+	}
+	e := newEvent(ctx, SessionCreated)
+	e.SessionID, e.AccountID = sessionID, accountID
+	bus.Emit(e)
+}
+
+// Turn emits a TurnStarted event for the agent and returns a context that counts the tokens used
+// in the turn and a function that emits the TurnCompleted event, it must be called with the error
+// of the turn.
+func Turn(ctx context.Context, agent string) (context.Context, func(err error)) {
+	if bus == nil {
+		return ctx, func(error) {}
+	}
+
+	start := newEvent(ctx, TurnStarted)
+	start.Agent = agent
+	bus.Emit(start)
+
+	var (
+		lock  sync.Mutex
+		usage types.Usage
+	)
+	ctx = types.WithUsageListener(ctx, func(used types.Usage) {
+		lock.Lock()
+		defer lock.Unlock()
+		usage.InputTokens += used.InputTokens
+		usage.OutputTokens += used.OutputTokens
+		usage.CachedInputTokens += used.CachedInputTokens
+		usage.ReasoningTokens += used.ReasoningTokens
+	})
+
+	return ctx, func(err error) {
+		e := newEvent(ctx, TurnCompleted)
+		e.Agent = agent
+		e.DurationMS = time.Since(start.Time).Milliseconds()
+		lock.Lock()
+		e.Usage = &usage
+		lock.Unlock()
+		setError(&e, err)
+		bus.Emit(e)
+	}
+}
+
+// Tool emits a ToolCalled event for a call that started at start.
+func Tool(ctx context.Context, server, tool string, start time.Time, result *types.CallResult, err error) {
+	if bus == nil {
+		return
+	}
+	e := newEvent(ctx, ToolCalled)
+	e.Server, e.Tool = server, tool
+	e.DurationMS = time.Since(start).Milliseconds()
+	setError(&e, err)
+	if result != nil && result.IsError {
+		e.IsError = true
+		e.ErrorKind = "error_result"
+	}
+	bus.Emit(e)
+}
+
+// Provider emits an Error event for a failed completion, or a BudgetExceeded event if the quota of
+// the provider is used up. Cancelled completions are ignored.
+func Provider(ctx context.Context, agent, model string, err error) {
+	if bus == nil || err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	e := newEvent(ctx, Error)
+	e.Agent, e.Model = agent, model
+	setError(&e, err)
+	if e.ErrorKind == string(apierror.KindQuotaExceeded) {
+		e.Type = BudgetExceeded
+	}
+	bus.Emit(e)
+}
+
+// SamplingBudget emits a BudgetExceeded event for an MCP server that used up its sampling budget.
+func SamplingBudget(ctx context.Context, server string, budget int) {
+	if bus == nil {
+		return
+	}
+	e := newEvent(ctx, BudgetExceeded)
+	e.Server, e.Budget = server, budget
+	e.IsError, e.ErrorKind = true, "sampling_budget"
+	bus.Emit(e)
+}
+
+// setError sets the error of the event and classifies it.
+func setError(e *Event, err error) {
+	if err == nil {
+		return
+	}
+	e.IsError, e.Error, e.ErrorKind = true, err.Error(), "error"
+	var apiErr *apierror.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		e.ErrorKind = "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		e.ErrorKind = "timeout"
+	case errors.As(err, &apiErr) && apiErr.Kind() != "":
+		e.ErrorKind = string(apiErr.Kind())
+	}
+}
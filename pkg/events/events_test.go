@@ -0,0 +1,106 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhook(t *testing.T) {
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if req.Header.Get(SignatureHeader) != Sign("secret", body) {
+			http.Error(rw, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		var event Event
+		_ = json.Unmarshal(body, &event)
+		received <- event
+	}))
+	defer srv.Close()
+
+	b := NewBus(map[string]Sink{"webhook": NewWebhook(srv.URL, "secret")}, TurnCompleted)
+	b.Emit(Event{ID: "1", Type: TurnStarted})
+	b.Emit(Event{ID: "2", Type: TurnCompleted, Agent: "main"})
+	if err := b.Flush(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-received:
+		if event.ID != "2" || event.Agent != "main" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("expected the turn.completed event to be posted")
+	}
+	if len(received) != 0 {
+		t.Fatal("expected turn.started to be filtered")
+	}
+
+	if err := NewWebhook(srv.URL, "wrong").Send(t.Context(), Event{Type: TurnCompleted}); err == nil {
+		t.Fatal("expected the webhook to reject a wrong signature")
+	}
+}
+
+func TestNATS(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	published := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.WriteString(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "CONNECT ") && !strings.Contains(line, `"auth_token":"token"`):
+				_, _ = io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+			case line == "PING\r\n":
+				_, _ = io.WriteString(conn, "PONG\r\n")
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := r.ReadString('\n')
+				published <- line + payload
+			}
+		}
+	}()
+
+	sink, err := NewNATS("nats://token@"+l.Addr().String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(Event{ID: "1", Type: ToolCalled})
+	if err := sink.Send(context.Background(), Event{ID: "1", Type: ToolCalled}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-published:
+		want := "PUB nanobot.events.tool.called " + strconv.Itoa(len(data)) + "\r\n" + string(data) + "\r\n"
+		if msg != want {
+			t.Fatalf("unexpected message %q, want %q", msg, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the event to be published")
+	}
+}
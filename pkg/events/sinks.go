@@ -0,0 +1,96 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// SignatureHeader is the header of webhook requests with the HMAC-SHA256 of the body, keyed with
+// the secret of the webhook, in the form sha256=<hex>.
+const SignatureHeader = "X-Nanobot-Signature-256"
+
+// Webhook posts each event as JSON to a URL.
+type Webhook struct {
+	url    string
+	secret string
+	http   *http.Client
+}
+
+// NewWebhook returns a sink that posts events to url. If secret is set, the requests are signed
+// with it in the SignatureHeader.
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{
+		url:    url,
+		secret: secret,
+		http:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *Webhook) Send(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Nanobot-Event", event.Type)
+	req.Header.Set("X-Nanobot-Delivery", event.ID)
+	if w.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.secret, data))
+	}
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// Sign returns the value of the SignatureHeader of a webhook request with the body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Stdout prints each event as a JSON line.
+type Stdout struct {
+	lock sync.Mutex
+	out  io.Writer
+}
+
+// NewStdout returns a sink that prints events to out, or to stdout if out is nil.
+func NewStdout(out io.Writer) *Stdout {
+	if out == nil {
+		out = os.Stdout
+	}
+	return &Stdout{
+		out: out,
+	}
+}
+
+func (s *Stdout) Send(_ context.Context, event Event) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return json.NewEncoder(s.out).Encode(event)
+}
@@ -0,0 +1,138 @@
+package failures
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/render"
+)
+
+const digestPrompt = `The following JSON lists the tool calls that failed repeatedly in the last %s, grouped by
+MCP server, tool, and error class, with a sample error message for each. Write a short, actionable
+digest for the maintainers: group related failures, name the most likely cause of each, and suggest
+what to fix or check first. Only reply with the digest.
+
+%s`
+
+// Digest periodically posts a summary of recurring tool failures to a webhook.
+type Digest struct {
+	// Interval is how often a digest is posted and the period each digest covers.
+	Interval time.Duration
+	// MinCount is the number of failures in the period after which a failure is recurring.
+	MinCount int
+	// Webhook is the URL the digest is posted to.
+	Webhook string
+	// Format is the payload format of the webhook, "slack" for Block Kit (default), "teams" for an
+	// Adaptive Card, or "text" for a JSON object with a single text field.
+	Format string
+	// Summarize, if set, is used to turn the report into the digest, typically by asking a
+	// maintenance agent. Otherwise the recurring failures are listed as is.
+	Summarize func(ctx context.Context, prompt string) (string, error)
+	// Report returns the failures since the given time, the default tracker is used if not set.
+	Report func(from time.Time) HeatMap
+}
+
+// Start posts a digest every interval until ctx is done.
+func (d Digest) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.Post(ctx); err != nil {
+					log.Errorf(ctx, "failed to post tool failure digest: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Post sends a digest of the failures of the last interval, if any of them are recurring.
+func (d Digest) Post(ctx context.Context) error {
+	report := d.Report
+	if report == nil {
+		report = Report
+	}
+
+	var recurring []Row
+	for _, row := range report(time.Now().Add(-d.Interval)).Rows {
+		if row.Total >= max(d.MinCount, 1) {
+			recurring = append(recurring, row)
+		}
+	}
+	if len(recurring) == 0 {
+		return nil
+	}
+
+	text := listRows(recurring)
+	if d.Summarize != nil {
+		data, err := json.MarshalIndent(recurring, "", "  ")
+		if err != nil {
+			return err
+		}
+		text, err = d.Summarize(ctx, fmt.Sprintf(digestPrompt, d.Interval, data))
+		if err != nil {
+			return fmt.Errorf("failed to summarize tool failures: %w", err)
+		}
+	}
+
+	return postWebhook(ctx, d.Webhook, payload(d.Format, text))
+}
+
+func listRows(rows []Row) string {
+	var buf strings.Builder
+	buf.WriteString("Recurring tool failures:\n")
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(&buf, "- %s/%s failed %d times: %s (last seen %s)\n",
+			row.Server, row.Tool, row.Total, row.Class, row.LastSeen.UTC().Format(time.RFC3339))
+	}
+	return buf.String()
+}
+
+func payload(format, text string) any {
+	switch format {
+	case "teams":
+		return render.TeamsMessage(render.Teams(text))
+	case "text":
+		return map[string]string{
+			"text": text,
+		}
+	default:
+		return render.Slack(text)
+	}
+}
+
+func postWebhook(ctx context.Context, url string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, body)
+	}
+	return nil
+}
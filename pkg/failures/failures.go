@@ -0,0 +1,235 @@
+package failures
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const (
+	maxClassLength  = 120
+	maxSampleLength = 1000
+)
+
+var (
+	defaultTracker = NewTracker(time.Hour, 7*24*time.Hour)
+	numbers        = regexp.MustCompile(`[0-9]+`)
+)
+
+// Record counts a failed tool call on the default tracker. Successful calls are ignored.
+func Record(ctx context.Context, server, tool string, result *types.CallResult, err error) {
+	defaultTracker.Record(ctx, server, tool, result, err)
+}
+
+// Report returns the failures recorded by the default tracker since from.
+func Report(from time.Time) HeatMap {
+	return defaultTracker.Report(from)
+}
+
+// HeatMap counts tool failures by server, tool, and error class in fixed size time buckets.
+type HeatMap struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Bucket  time.Duration `json:"bucket"`
+	Buckets []time.Time   `json:"buckets"`
+	Rows    []Row         `json:"rows"`
+}
+
+// Row holds the failures of one error class of a tool. Counts has one entry per bucket of the
+// heat map.
+type Row struct {
+	Server   string    `json:"server"`
+	Tool     string    `json:"tool"`
+	Class    string    `json:"class"`
+	Total    int       `json:"total"`
+	Counts   []int     `json:"counts"`
+	Sample   string    `json:"sample,omitempty"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+type key struct {
+	server, tool, class string
+}
+
+type entry struct {
+	counts   map[int64]int
+	sample   string
+	lastSeen time.Time
+}
+
+// Tracker aggregates tool failures in memory. Counts older than the retention are dropped.
+type Tracker struct {
+	lock      sync.Mutex
+	bucket    time.Duration
+	retention time.Duration
+	entries   map[key]*entry
+	now       func() time.Time
+}
+
+func NewTracker(bucket, retention time.Duration) *Tracker {
+	return &Tracker{
+		bucket:    bucket,
+		retention: retention,
+		entries:   map[key]*entry{},
+		now:       time.Now,
+	}
+}
+
+// Record counts a failed tool call. A call failed if err is set or the result is an error result.
+// The error message is kept as a sample unless content logging is suppressed for ctx.
+func (t *Tracker) Record(ctx context.Context, server, tool string, result *types.CallResult, err error) {
+	if err == nil && (result == nil || !result.IsError) {
+		return
+	}
+
+	message := errorMessage(result, err)
+	k := key{
+		server: server,
+		tool:   tool,
+		class:  Class(message, err),
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	e := t.entries[k]
+	if e == nil {
+		e = &entry{
+			counts: map[int64]int{},
+		}
+		t.entries[k] = e
+	}
+	e.counts[t.bucketOf(now)]++
+	e.lastSeen = now
+	if !log.ContentSuppressed(ctx) {
+		e.sample = truncate(message, maxSampleLength)
+	}
+
+	t.prune(now)
+}
+
+// Report builds a heat map of the failures recorded since from, or since the start of the retention
+// if that is later, with the rows that failed most often first.
+func (t *Tracker) Report(from time.Time) HeatMap {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	t.prune(now)
+	if oldest := now.Add(-t.retention); from.Before(oldest) {
+		from = oldest
+	}
+
+	first, last := t.bucketOf(from), t.bucketOf(now)
+	result := HeatMap{
+		From:   from,
+		To:     now,
+		Bucket: t.bucket,
+	}
+	for b := first; b <= last; b++ {
+		result.Buckets = append(result.Buckets, time.Unix(0, b*int64(t.bucket)).UTC())
+	}
+
+	for k, e := range t.entries {
+		row := Row{
+			Server:   k.server,
+			Tool:     k.tool,
+			Class:    k.class,
+			Counts:   make([]int, len(result.Buckets)),
+			Sample:   e.sample,
+			LastSeen: e.lastSeen,
+		}
+		for b, count := range e.counts {
+			if b < first || b > last {
+				continue
+			}
+			row.Counts[b-first] += count
+			row.Total += count
+		}
+		if row.Total > 0 {
+			result.Rows = append(result.Rows, row)
+		}
+	}
+
+	slices.SortFunc(result.Rows, func(a, b Row) int {
+		return cmp.Or(
+			cmp.Compare(b.Total, a.Total),
+			cmp.Compare(a.Server, b.Server),
+			cmp.Compare(a.Tool, b.Tool),
+			cmp.Compare(a.Class, b.Class),
+		)
+	})
+	return result
+}
+
+func (t *Tracker) bucketOf(ts time.Time) int64 {
+	return ts.UnixNano() / int64(t.bucket)
+}
+
+func (t *Tracker) prune(now time.Time) {
+	oldest := t.bucketOf(now.Add(-t.retention))
+	for k, e := range t.entries {
+		for b := range e.counts {
+			if b < oldest {
+				delete(e.counts, b)
+			}
+		}
+		if len(e.counts) == 0 {
+			delete(t.entries, k)
+		}
+	}
+}
+
+// Class groups similar failures together. Cancellations and timeouts get their own classes, other
+// errors are classified by the first line of their message with all numbers masked, so that IDs,
+// ports, and durations do not split one recurring failure into many.
+func Class(message string, err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+
+	message, _, _ = strings.Cut(strings.TrimSpace(message), "\n")
+	message = numbers.ReplaceAllString(message, "N")
+	if message == "" {
+		if err != nil {
+			return "error"
+		}
+		return "error result"
+	}
+	return truncate(message, maxClassLength)
+}
+
+func errorMessage(result *types.CallResult, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	for _, content := range result.Content {
+		if content.Text != "" {
+			return content.Text
+		}
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,70 @@
+package failures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC)
+	tracker := NewTracker(time.Hour, 24*time.Hour)
+	tracker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	tracker.Record(ctx, "github", "search", nil, fmt.Errorf("connection refused to port %d", 8080))
+	tracker.Record(ctx, "github", "search", nil, nil)
+	tracker.Record(ctx, "github", "search", &types.CallResult{}, nil)
+
+	now = now.Add(time.Hour)
+	tracker.Record(ctx, "github", "search", nil, fmt.Errorf("connection refused to port %d", 9090))
+	tracker.Record(log.WithoutContent(ctx), "fs", "read", &types.CallResult{
+		IsError: true,
+		Content: []mcp.Content{{Type: "text", Text: "secret not found"}},
+	}, nil)
+	tracker.Record(ctx, "fs", "read", nil, fmt.Errorf("call failed: %w", context.DeadlineExceeded))
+
+	report := tracker.Report(now.Add(-2 * time.Hour))
+	if len(report.Buckets) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(report.Buckets))
+	}
+	if len(report.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d: %+v", len(report.Rows), report.Rows)
+	}
+
+	first := report.Rows[0]
+	if first.Class != "connection refused to port N" || first.Total != 2 || fmt.Sprint(first.Counts) != "[0 1 1]" {
+		t.Errorf("unexpected first row: %+v", first)
+	}
+	if first.Sample != "connection refused to port 9090" {
+		t.Errorf("expected latest sample, got %q", first.Sample)
+	}
+	for _, row := range report.Rows[1:] {
+		if row.Class == "secret not found" && row.Sample != "" {
+			t.Errorf("expected no sample without content, got %q", row.Sample)
+		}
+	}
+
+	now = now.Add(25 * time.Hour)
+	if rows := tracker.Report(now.Add(-48 * time.Hour)).Rows; len(rows) != 0 {
+		t.Errorf("expected expired failures to be pruned, got %+v", rows)
+	}
+}
+
+func TestClass(t *testing.T) {
+	if class := Class("x", errors.Join(context.Canceled)); class != "canceled" {
+		t.Errorf("expected canceled, got %q", class)
+	}
+	if class := Class("", nil); class != "error result" {
+		t.Errorf("expected error result, got %q", class)
+	}
+	if class := Class("request 42 failed\nstack trace", errors.New("")); class != "request N failed" {
+		t.Errorf("unexpected class %q", class)
+	}
+}
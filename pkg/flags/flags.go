@@ -0,0 +1,76 @@
+// Package flags carries the feature flags of a single request in its context, from the HTTP
+// request that starts it through the completions and tool calls it makes, so experimental behavior
+// can be tried on some requests without changing the config of all of them.
+//
+// A request enables flags with the Header header or the Param query parameter, as a comma
+// separated list. Only the flags the server allows are kept, the others are ignored.
+package flags
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// Header is the HTTP header that lists the flags of a request.
+	Header = "X-Nanobot-Flags"
+	// Param is the query parameter that lists the flags of a request.
+	Param = "flags"
+	// MetaKey is the key of the flags in the _meta of the tool calls, so MCP servers can follow them.
+	MetaKey = "ai.nanobot.flags"
+)
+
+type contextKey struct{}
+
+// With returns a context with the flags enabled in addition to those of ctx.
+func With(ctx context.Context, flags ...string) context.Context {
+	all := List(ctx)
+	for _, flag := range flags {
+		if flag != "" && !slices.Contains(all, flag) {
+			all = append(all, flag)
+		}
+	}
+	return context.WithValue(ctx, contextKey{}, all)
+}
+
+// List returns the flags enabled in ctx.
+func List(ctx context.Context) []string {
+	flags, _ := ctx.Value(contextKey{}).([]string)
+	return slices.Clone(flags)
+}
+
+// Enabled reports if the flag is enabled in ctx.
+func Enabled(ctx context.Context, flag string) bool {
+	flags, _ := ctx.Value(contextKey{}).([]string)
+	return slices.Contains(flags, flag)
+}
+
+// FromRequest returns the allowed flags listed by the request.
+func FromRequest(req *http.Request, allowed []string) []string {
+	var result []string
+	for _, value := range append(req.Header.Values(Header), req.URL.Query()[Param]...) {
+		for flag := range strings.SplitSeq(value, ",") {
+			flag = strings.TrimSpace(flag)
+			if slices.Contains(allowed, flag) && !slices.Contains(result, flag) {
+				result = append(result, flag)
+			}
+		}
+	}
+	return result
+}
+
+// Middleware enables the allowed flags listed by each request in its context. It passes requests
+// through unchanged if no flags are allowed.
+func Middleware(allowed []string, next http.Handler) http.Handler {
+	if len(allowed) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if flags := FromRequest(req, allowed); len(flags) > 0 {
+			req = req.WithContext(With(req.Context(), flags...))
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
@@ -0,0 +1,29 @@
+package flags
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var got []string
+	handler := Middleware([]string{"new-compaction", "new-provider"}, http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		got = List(req.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp?flags=new-provider", nil)
+	req.Header.Set(Header, "new-compaction, unknown")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want := []string{"new-compaction", "new-provider"}; !slices.Equal(got, want) {
+		t.Fatalf("expected the allowed flags %v, got %v", want, got)
+	}
+
+	ctx := With(context.Background(), "a")
+	if !Enabled(With(ctx, "b"), "a") || Enabled(ctx, "b") {
+		t.Fatal("expected flags to be added to those of the parent context only")
+	}
+}
@@ -0,0 +1,268 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultAPIURL = "https://api.github.com"
+	// maxResponseSize limits diffs and files read from the API. Larger content is cut off, it would
+	// not fit in the context window of the agent anyway.
+	maxResponseSize = 256 << 10
+)
+
+// event is the payload of a webhook event. Only the fields of pull_request and issues events are
+// decoded.
+type event struct {
+	Action       string        `json:"action"`
+	Installation *installation `json:"installation,omitempty"`
+	Repository   repository    `json:"repository"`
+	Sender       account       `json:"sender"`
+	PullRequest  *pullRequest  `json:"pull_request,omitempty"`
+	Issue        *issue        `json:"issue,omitempty"`
+	Label        *label        `json:"label,omitempty"`
+}
+
+type installation struct {
+	ID int64 `json:"id"`
+}
+
+type repository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+}
+
+type account struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+	Type  string `json:"type,omitempty"`
+}
+
+type pullRequest struct {
+	Number  int     `json:"number"`
+	Title   string  `json:"title"`
+	Body    string  `json:"body,omitempty"`
+	Draft   bool    `json:"draft,omitempty"`
+	HTMLURL string  `json:"html_url,omitempty"`
+	User    account `json:"user"`
+	Head    gitRef  `json:"head"`
+	Base    gitRef  `json:"base"`
+}
+
+type gitRef struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+type issue struct {
+	Number  int     `json:"number"`
+	Title   string  `json:"title"`
+	Body    string  `json:"body,omitempty"`
+	HTMLURL string  `json:"html_url,omitempty"`
+	User    account `json:"user"`
+	Labels  []label `json:"labels,omitempty"`
+	// PullRequest is set if the issue is a pull request.
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+type label struct {
+	Name string `json:"name"`
+}
+
+// client is a client of the GitHub REST API that authenticates as an installation of the app.
+type client struct {
+	apiURL string
+	appID  string
+	key    *rsa.PrivateKey
+	http   *http.Client
+
+	lock   sync.Mutex
+	tokens map[int64]installationToken
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// parsePrivateKey reads the private key of the app, which is either the PEM encoded key or the path
+// of a file containing it.
+func parsePrivateKey(key string) (*rsa.PrivateKey, error) {
+	data := []byte(key)
+	if !strings.Contains(key, "-----BEGIN") {
+		var err error
+		data, err = os.ReadFile(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+	}
+	result, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return result, nil
+}
+
+// appToken returns a JWT that authenticates as the app, which is only used to create installation
+// tokens.
+func (c *client) appToken() (string, error) {
+	now := time.Now()
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer: c.appID,
+		// Allow for clock drift, as recommended by GitHub.
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}).SignedString(c.key)
+}
+
+// installationToken returns a token of the installation, tokens are reused until shortly before
+// they expire.
+func (c *client) installationToken(ctx context.Context, installationID int64) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if token, ok := c.tokens[installationID]; ok && time.Until(token.ExpiresAt) > 5*time.Minute {
+		return token.Token, nil
+	}
+
+	appToken, err := c.appToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to sign app token: %w", err)
+	}
+
+	var token installationToken
+	if err := c.do(ctx, appToken, http.MethodPost, fmt.Sprintf("/app/installations/%d/access_tokens", installationID), "", nil, &token); err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+
+	if c.tokens == nil {
+		c.tokens = map[int64]installationToken{}
+	}
+	c.tokens[installationID] = token
+	return token.Token, nil
+}
+
+// call sends a request as the installation. The response is decoded into out if it is not nil, a
+// string pointer receives the raw body.
+func (c *client) call(ctx context.Context, installationID int64, method, path, accept string, in, out any) error {
+	token, err := c.installationToken(ctx, installationID)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, token, method, path, accept, in, out)
+}
+
+func (c *client) do(ctx context.Context, token, method, path, accept string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&apiErr)
+		return fmt.Errorf("%s %s failed: %s: %s", method, path, resp.Status, apiErr.Message)
+	}
+
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *string:
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxResponseSize {
+			data = append(data[:maxResponseSize], "\n[... cut off, the content is too long]"...)
+		}
+		*out = strings.ToValidUTF8(string(data), "")
+		return nil
+	default:
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+// diff returns the unified diff of a pull request.
+func (c *client) diff(ctx context.Context, installationID int64, repo string, number int) (string, error) {
+	var diff string
+	err := c.call(ctx, installationID, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), "application/vnd.github.diff", nil, &diff)
+	return diff, err
+}
+
+// file returns the content of a file of the repository at ref, the default branch if ref is empty.
+func (c *client) file(ctx context.Context, installationID int64, repo, path, ref string) (string, error) {
+	apiPath := fmt.Sprintf("/repos/%s/contents/%s", repo, escapePath(path))
+	if ref != "" {
+		apiPath += "?ref=" + url.QueryEscape(ref)
+	}
+	var content string
+	err := c.call(ctx, installationID, http.MethodGet, apiPath, "application/vnd.github.raw+json", nil, &content)
+	return content, err
+}
+
+// comment adds a comment to an issue or pull request.
+func (c *client) comment(ctx context.Context, installationID int64, repo string, number int, body string) error {
+	return c.call(ctx, installationID, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), "", map[string]any{
+		"body": body,
+	}, nil)
+}
+
+// review submits a review that comments on a pull request, without approving it or requesting
+// changes.
+func (c *client) review(ctx context.Context, installationID int64, repo string, number int, commitID, body string) error {
+	review := map[string]any{
+		"body":  body,
+		"event": "COMMENT",
+	}
+	if commitID != "" {
+		review["commit_id"] = commitID
+	}
+	return c.call(ctx, installationID, http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/reviews", repo, number), "", review, nil)
+}
+
+func escapePath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,329 @@
+// Package github runs agents for the webhook events of a GitHub App. Opened pull requests are
+// reviewed and labeled issues are triaged, the response of the agent is posted back as a review or
+// comment. Every pull request and issue is a nanobot session, so later events continue the same
+// conversation.
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/channel"
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/errreport"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// SessionType is the type of the sessions created for pull requests and issues.
+const SessionType = "github"
+
+// maxPayloadSize is the largest webhook payload GitHub sends.
+const maxPayloadSize = 25 << 20
+
+type Options struct {
+	// Options.Agent handles the events and needs the ServerName MCP server to read the repository.
+	channel.Options
+
+	// AppID is the ID of the GitHub App.
+	AppID string
+	// PrivateKey is the PEM encoded private key of the app, or the path of a file containing it.
+	PrivateKey string
+	// WebhookSecret is used to verify that events come from GitHub.
+	WebhookSecret string
+	// TriageLabels are the labels that start the triage of an issue. If empty every label does.
+	TriageLabels []string
+	// DryRun logs reviews and comments instead of posting them.
+	DryRun bool
+	// RunsPerHour limits how many events of a repository are handled per hour. Default 20.
+	RunsPerHour int
+	// APIURL is the URL of the REST API, set for GitHub Enterprise Server. Default
+	// https://api.github.com.
+	APIURL string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Options = o.Options.Merge(other.Options)
+	result.AppID = complete.Last(o.AppID, other.AppID)
+	result.PrivateKey = complete.Last(o.PrivateKey, other.PrivateKey)
+	result.WebhookSecret = complete.Last(o.WebhookSecret, other.WebhookSecret)
+	result.TriageLabels = append(o.TriageLabels, other.TriageLabels...)
+	result.DryRun = o.DryRun || other.DryRun
+	result.RunsPerHour = complete.Last(o.RunsPerHour, other.RunsPerHour)
+	result.APIURL = complete.Last(o.APIURL, other.APIURL)
+	return
+}
+
+func (o Options) Complete() Options {
+	if o.RunsPerHour == 0 {
+		o.RunsPerHour = 20
+	}
+	if o.APIURL == "" {
+		o.APIURL = defaultAPIURL
+	}
+	o.APIURL = strings.TrimSuffix(o.APIURL, "/")
+	return o
+}
+
+// Bot handles the events GitHub sends to the webhook of the app.
+type Bot struct {
+	ctx     context.Context
+	opt     Options
+	runner  *channel.Runner
+	client  *client
+	limiter *limiter
+}
+
+func NewBot(ctx context.Context, runt *runtime.Runtime, config types.ConfigFactory, sessions *session.Manager, server mcp.MessageHandler, opts ...Options) (*Bot, error) {
+	opt := complete.Complete(opts...)
+	if opt.AppID == "" || opt.PrivateKey == "" || opt.WebhookSecret == "" {
+		return nil, fmt.Errorf("the app ID, private key, and webhook secret of the GitHub App are required")
+	}
+
+	key, err := parsePrivateKey(opt.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	runner, err := channel.NewRunner(runt, config, sessions, server, opt.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bot{
+		ctx:    ctx,
+		opt:    opt,
+		runner: runner,
+		client: &client{
+			apiURL: opt.APIURL,
+			appID:  opt.AppID,
+			key:    key,
+			http:   http.DefaultClient,
+		},
+		limiter: newLimiter(opt.RunsPerHour, time.Hour),
+	}
+
+	runt.AddServer(ServerName, func(string) mcp.MessageHandler {
+		return newServer(b.client, opt.DryRun)
+	})
+	return b, nil
+}
+
+func (b *Bot) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(req.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(rw, "Failed to read event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !b.verify(payload, req.Header.Get("X-Hub-Signature-256")) {
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var e event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		http.Error(rw, "Failed to decode event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	run, ok := b.runFromEvent(req.Header.Get("X-GitHub-Event"), e)
+	if !ok {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !b.limiter.allow(e.Repository.FullName) {
+		log.Infof(req.Context(), "ignoring GitHub event for %s#%d, the repository reached the limit of %d runs per hour",
+			e.Repository.FullName, run.target.Number, b.opt.RunsPerHour)
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// GitHub expects a response within 10 seconds, so the agent runs in the background.
+	go b.handle(context.WithoutCancel(req.Context()), run)
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// verify checks the X-Hub-Signature-256 of the payload.
+func (b *Bot) verify(payload []byte, signature string) bool {
+	sum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(b.opt.WebhookSecret))
+	mac.Write(payload)
+	return hmac.Equal([]byte(sum), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+// run is an agent run for an event.
+type run struct {
+	target target
+	prompt string
+	title  string
+	user   account
+}
+
+// runFromEvent returns the run for an event, or false if the event is not handled. Pull requests
+// are reviewed when they are opened or ready for review, issues are triaged when they are labeled.
+func (b *Bot) runFromEvent(name string, e event) (run, bool) {
+	if e.Installation == nil || e.Repository.FullName == "" || e.Sender.Type == "Bot" {
+		// Events caused by bots, including the app itself, are ignored to avoid loops.
+		return run{}, false
+	}
+
+	switch {
+	case name == "pull_request" && e.PullRequest != nil:
+		pr := e.PullRequest
+		if pr.Draft || !slices.Contains([]string{"opened", "reopened", "ready_for_review"}, e.Action) {
+			return run{}, false
+		}
+		return run{
+			target: target{
+				InstallationID: e.Installation.ID,
+				Repository:     e.Repository.FullName,
+				Number:         pr.Number,
+				PullRequest:    true,
+				Ref:            pr.Head.SHA,
+			},
+			prompt: fmt.Sprintf(`Review pull request #%d of %s, which was %s by @%s. It merges %s into %s.
+
+Title: %s
+
+%s
+
+Use the get_diff tool to read the changes and the read_file tool to read files for context. Your response is posted as the review of the pull request.`,
+				pr.Number, e.Repository.FullName, strings.ReplaceAll(e.Action, "_", " "), pr.User.Login, pr.Head.Ref, pr.Base.Ref,
+				pr.Title, complete.First(pr.Body, "The pull request has no description.")),
+			title: fmt.Sprintf("%s#%d: %s", e.Repository.FullName, pr.Number, pr.Title),
+			user:  e.Sender,
+		}, true
+	case name == "issues" && e.Issue != nil && e.Action == "labeled" && e.Label != nil:
+		is := e.Issue
+		if len(b.opt.TriageLabels) > 0 && !slices.Contains(b.opt.TriageLabels, e.Label.Name) {
+			return run{}, false
+		}
+		var labels []string
+		for _, l := range is.Labels {
+			labels = append(labels, l.Name)
+		}
+		return run{
+			target: target{
+				InstallationID: e.Installation.ID,
+				Repository:     e.Repository.FullName,
+				Number:         is.Number,
+				PullRequest:    is.PullRequest != nil,
+			},
+			prompt: fmt.Sprintf(`Triage issue #%d of %s, which was opened by @%s and labeled %q by @%s. Its labels are: %s.
+
+Title: %s
+
+%s
+
+Use the read_file tool to read files of the repository for context. Your response is posted as a comment on the issue.`,
+				is.Number, e.Repository.FullName, is.User.Login, e.Label.Name, e.Sender.Login, strings.Join(labels, ", "),
+				is.Title, complete.First(is.Body, "The issue has no description.")),
+			title: fmt.Sprintf("%s#%d: %s", e.Repository.FullName, is.Number, is.Title),
+			user:  e.Sender,
+		}, true
+	}
+	return run{}, false
+}
+
+func (b *Bot) handle(ctx context.Context, r run) {
+	defer errreport.Recover(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	context.AfterFunc(b.ctx, cancel)
+
+	t := r.target
+	resp, err := b.runner.Run(ctx, channel.Message{
+		Channel:     "GitHub",
+		SessionType: SessionType,
+		SessionID:   channel.SessionID(b.opt.WebhookSecret, t.Repository, strconv.Itoa(t.Number)),
+		User: types.User{
+			ID:    "github:" + strconv.FormatInt(r.user.ID, 10),
+			Login: r.user.Login,
+			Name:  r.user.Login,
+		},
+		Description: r.title,
+		Prompt:      r.prompt,
+		Attributes: map[string]any{
+			targetSessionKey: t,
+		},
+	})
+	if err != nil {
+		log.Errorf(ctx, "failed to handle GitHub event for %s#%d: %v", t.Repository, t.Number, err)
+		return
+	}
+
+	body := strings.TrimSpace(resp.Text)
+	if body == "" {
+		log.Infof(ctx, "the agent did not respond to the GitHub event for %s#%d", t.Repository, t.Number)
+		return
+	}
+
+	if b.opt.DryRun {
+		log.Infof(ctx, "dry run, not posting response on %s#%d: %s", t.Repository, t.Number, body)
+		return
+	}
+
+	if t.PullRequest && t.Ref != "" {
+		err = b.client.review(ctx, t.InstallationID, t.Repository, t.Number, t.Ref, body)
+	} else {
+		err = b.client.comment(ctx, t.InstallationID, t.Repository, t.Number, body)
+	}
+	if err != nil {
+		log.Errorf(ctx, "failed to post response on %s#%d: %v", t.Repository, t.Number, err)
+	}
+}
+
+// limiter limits the number of runs per key within a sliding window.
+type limiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	lock sync.Mutex
+	runs map[string][]time.Time
+}
+
+func newLimiter(limit int, window time.Duration) *limiter {
+	return &limiter{
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+		runs:   map[string][]time.Time{},
+	}
+}
+
+// allow records a run for key and returns true, or returns false if key reached the limit.
+func (l *limiter) allow(key string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	runs := slices.DeleteFunc(l.runs[key], func(t time.Time) bool {
+		return now.Sub(t) >= l.window
+	})
+	if len(runs) >= l.limit {
+		l.runs[key] = runs
+		return false
+	}
+	l.runs[key] = append(runs, now)
+	return true
+}
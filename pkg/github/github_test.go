@@ -0,0 +1,95 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeHTTP(t *testing.T) {
+	b := &Bot{opt: Options{WebhookSecret: "secret"}, limiter: newLimiter(1, time.Hour)}
+
+	payload := `{"action":"created","installation":{"id":1},"repository":{"full_name":"acme/app"}}`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(payload))
+
+	for _, tt := range []struct {
+		signature string
+		code      int
+	}{
+		{"", http.StatusUnauthorized},
+		{"sha256=" + strings.Repeat("0", 64), http.StatusUnauthorized},
+		{"sha256=" + hex.EncodeToString(mac.Sum(nil)), http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/github/webhook", strings.NewReader(payload))
+		req.Header.Set("X-GitHub-Event", "issues")
+		req.Header.Set("X-Hub-Signature-256", tt.signature)
+		rw := httptest.NewRecorder()
+		b.ServeHTTP(rw, req)
+		if rw.Code != tt.code {
+			t.Errorf("got status %d for signature %q, want %d", rw.Code, tt.signature, tt.code)
+		}
+	}
+}
+
+func TestRunFromEvent(t *testing.T) {
+	b := &Bot{opt: Options{TriageLabels: []string{"bug"}}}
+	base := event{
+		Installation: &installation{ID: 7},
+		Repository:   repository{FullName: "acme/app"},
+		Sender:       account{Login: "alice", Type: "User"},
+	}
+
+	pr := base
+	pr.Action = "opened"
+	pr.PullRequest = &pullRequest{Number: 3, Title: "Fix login", Head: gitRef{Ref: "fix", SHA: "abc"}, Base: gitRef{Ref: "main"}}
+	r, ok := b.runFromEvent("pull_request", pr)
+	if !ok || !r.target.PullRequest || r.target.Ref != "abc" || r.target.InstallationID != 7 || !strings.Contains(r.prompt, "Fix login") {
+		t.Errorf("unexpected run for opened pull request: %+v", r)
+	}
+
+	pr.PullRequest.Draft = true
+	if _, ok := b.runFromEvent("pull_request", pr); ok {
+		t.Error("expected draft pull requests to be ignored")
+	}
+
+	issueEvent := base
+	issueEvent.Action = "labeled"
+	issueEvent.Issue = &issue{Number: 5, Title: "Crash"}
+	issueEvent.Label = &label{Name: "question"}
+	if _, ok := b.runFromEvent("issues", issueEvent); ok {
+		t.Error("expected issues with other labels to be ignored")
+	}
+	issueEvent.Label = &label{Name: "bug"}
+	if r, ok := b.runFromEvent("issues", issueEvent); !ok || r.target.PullRequest || r.target.Number != 5 {
+		t.Errorf("unexpected run for labeled issue: %+v", r)
+	}
+
+	issueEvent.Sender.Type = "Bot"
+	if _, ok := b.runFromEvent("issues", issueEvent); ok {
+		t.Error("expected events of bots to be ignored")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	l := newLimiter(2, time.Hour)
+	l.now = func() time.Time { return now }
+
+	if !l.allow("acme/app") || !l.allow("acme/app") || l.allow("acme/app") {
+		t.Error("expected the third run within the window to be limited")
+	}
+	if !l.allow("acme/other") {
+		t.Error("expected repositories to be limited separately")
+	}
+
+	now = now.Add(time.Hour)
+	if !l.allow("acme/app") {
+		t.Error("expected runs to be allowed after the window")
+	}
+}
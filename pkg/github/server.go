@@ -0,0 +1,138 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+)
+
+// ServerName is the name of the MCP server with the repository tools. Agents that handle GitHub
+// events list it in their mcpServers, and the config declares it as an empty server:
+//
+//	mcpServers:
+//	  nanobot.github: {}
+const ServerName = "nanobot.github"
+
+// targetSessionKey is the key of the target of a GitHub session.
+const targetSessionKey = "github"
+
+// target is the pull request or issue a session works on.
+type target struct {
+	InstallationID int64  `json:"installationID"`
+	Repository     string `json:"repository"`
+	Number         int    `json:"number"`
+	PullRequest    bool   `json:"pullRequest,omitempty"`
+	// Ref is the commit files are read from by default, the head of a pull request.
+	Ref string `json:"ref,omitempty"`
+}
+
+func (t *target) Deserialize(data any) (any, error) {
+	return t, mcp.JSONCoerce(data, t)
+}
+
+// Server gives agents access to the repository of the pull request or issue they work on. The tools
+// only work in sessions created for GitHub events.
+type Server struct {
+	mcp.ServerHandler
+	client *client
+	dryRun bool
+}
+
+func newServer(client *client, dryRun bool) *Server {
+	s := &Server{
+		client: client,
+		dryRun: dryRun,
+	}
+
+	s.Tools = mcp.NewServerTools(
+		mcp.NewServerTool("get_diff", "Returns the diff of the pull request", s.getDiff),
+		mcp.NewServerTool("read_file", "Returns the content of a file of the repository", s.readFile),
+		mcp.NewServerTool("post_comment", "Posts a comment on the pull request or issue", s.postComment),
+	)
+
+	return s
+}
+
+func targetFromContext(ctx context.Context) (target, error) {
+	var t target
+	session := mcp.SessionFromContext(ctx)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	if session == nil || !session.Get(targetSessionKey, &t) || t.Repository == "" {
+		return t, fmt.Errorf("this tool is only available in conversations about GitHub pull requests and issues")
+	}
+	return t, nil
+}
+
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}
+}
+
+func (s *Server) getDiff(ctx context.Context, _ struct{}) (*mcp.CallToolResult, error) {
+	t, err := targetFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !t.PullRequest {
+		return nil, fmt.Errorf("#%d is an issue, not a pull request", t.Number)
+	}
+
+	diff, err := s.client.diff(ctx, t.InstallationID, t.Repository, t.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff: %w", err)
+	}
+	return textResult(diff), nil
+}
+
+func (s *Server) readFile(ctx context.Context, data struct {
+	Path string `json:"path" jsonschema:"The path of the file in the repository"`
+	Ref  string `json:"ref,omitempty" jsonschema:"The branch, tag, or commit to read the file from. Defaults to the head of the pull request or the default branch"`
+}) (*mcp.CallToolResult, error) {
+	t, err := targetFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if data.Ref == "" {
+		data.Ref = t.Ref
+	}
+
+	content, err := s.client.file(ctx, t.InstallationID, t.Repository, data.Path, data.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", data.Path, err)
+	}
+	return textResult(content), nil
+}
+
+func (s *Server) postComment(ctx context.Context, data struct {
+	Body string `json:"body" jsonschema:"The markdown of the comment"`
+}) (*mcp.CallToolResult, error) {
+	t, err := targetFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data.Body == "" {
+		return nil, fmt.Errorf("body is required")
+	}
+
+	if s.dryRun {
+		log.Infof(ctx, "dry run, not posting comment on %s#%d: %s", t.Repository, t.Number, data.Body)
+		return textResult("The comment was not posted because nanobot runs in dry-run mode."), nil
+	}
+	if err := s.client.comment(ctx, t.InstallationID, t.Repository, t.Number, data.Body); err != nil {
+		return nil, fmt.Errorf("failed to post comment: %w", err)
+	}
+	return textResult("The comment was posted."), nil
+}
@@ -3,8 +3,11 @@ package gormdsn
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/glebarez/sqlite"
@@ -14,22 +17,79 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// migrationLockID is the key of the PostgreSQL advisory lock held while migrating.
+const migrationLockID = 0x6e616e6f626f74
+
+var (
+	sharedLock sync.Mutex
+	// shared holds the connections to database servers, so that all stores of a process share one
+	// connection pool per database.
+	shared = map[string]*gorm.DB{}
+)
+
+// pool configures the connection pool of a database server. The settings are read from the query
+// parameters max_open_conns, max_idle_conns, conn_max_lifetime, and conn_max_idle_time of the DSN.
+type pool struct {
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	connMaxIdleTime time.Duration
+}
+
+var defaultPool = pool{
+	maxOpenConns:    20,
+	maxIdleConns:    10,
+	connMaxLifetime: 30 * time.Minute,
+	connMaxIdleTime: 5 * time.Minute,
+}
+
 func NewDBFromDSN(dsn string) (*gorm.DB, error) {
-	var dialector gorm.Dialector
+	var newDialector func(string) gorm.Dialector
 
 	switch {
 	case strings.HasPrefix(dsn, "sqlite:") || strings.HasSuffix(dsn, ".db") || strings.Contains(dsn, ":memory:"):
-		dsn = strings.TrimPrefix(dsn, "sqlite:")
-		dialector = sqlite.Open(dsn)
+		return open(sqlite.Open(strings.TrimPrefix(dsn, "sqlite:")))
 	case strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://"):
-		dialector = postgres.Open(dsn)
+		newDialector = postgres.Open
 	case strings.HasPrefix(dsn, "mysql://") || strings.Contains(dsn, "@tcp("):
-		dsn = strings.TrimPrefix(dsn, "mysql://")
-		dialector = mysql.Open(dsn)
+		newDialector = func(dsn string) gorm.Dialector {
+			return mysql.Open(strings.TrimPrefix(dsn, "mysql://"))
+		}
 	default:
 		return nil, fmt.Errorf("unsupported database type in DSN: %s", dsn)
 	}
 
+	sharedLock.Lock()
+	defer sharedLock.Unlock()
+
+	if db, ok := shared[dsn]; ok {
+		return db, nil
+	}
+
+	serverDSN, p, err := parsePool(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := open(newDialector(serverDSN))
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(p.maxOpenConns)
+	sqlDB.SetMaxIdleConns(p.maxIdleConns)
+	sqlDB.SetConnMaxLifetime(p.connMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(p.connMaxIdleTime)
+
+	shared[dsn] = db
+	return db, nil
+}
+
+func open(dialector gorm.Dialector) (*gorm.DB, error) {
 	return gorm.Open(dialector, &gorm.Config{
 		Logger: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
 			SlowThreshold:             200 * time.Millisecond,
@@ -39,3 +99,60 @@ func NewDBFromDSN(dsn string) (*gorm.DB, error) {
 		}),
 	})
 }
+
+// parsePool removes the settings of the connection pool from the query of the DSN, the drivers
+// reject parameters they do not know.
+func parsePool(dsn string) (string, pool, error) {
+	p := defaultPool
+
+	base, rawQuery, ok := strings.Cut(dsn, "?")
+	if !ok {
+		return dsn, p, nil
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", p, fmt.Errorf("invalid query in DSN: %w", err)
+	}
+
+	for name, target := range map[string]*int{
+		"max_open_conns": &p.maxOpenConns,
+		"max_idle_conns": &p.maxIdleConns,
+	} {
+		if value := query.Get(name); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil {
+				return "", p, fmt.Errorf("invalid %s in DSN: %w", name, err)
+			}
+		}
+		query.Del(name)
+	}
+	for name, target := range map[string]*time.Duration{
+		"conn_max_lifetime":  &p.connMaxLifetime,
+		"conn_max_idle_time": &p.connMaxIdleTime,
+	} {
+		if value := query.Get(name); value != "" {
+			if *target, err = time.ParseDuration(value); err != nil {
+				return "", p, fmt.Errorf("invalid %s in DSN: %w", name, err)
+			}
+		}
+		query.Del(name)
+	}
+
+	if len(query) == 0 {
+		return base, p, nil
+	}
+	return base + "?" + query.Encode(), p, nil
+}
+
+// Migrate creates or updates the tables of models. On PostgreSQL the migration holds an advisory
+// lock, so that replicas that start at the same time do not migrate concurrently.
+func Migrate(db *gorm.DB, models ...any) error {
+	if db.Name() != "postgres" {
+		return db.AutoMigrate(models...)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+		return tx.AutoMigrate(models...)
+	})
+}
@@ -0,0 +1,23 @@
+package gormdsn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePool(t *testing.T) {
+	dsn, p, err := parsePool("postgres://nanobot@db:5432/nanobot?sslmode=require&max_open_conns=50&conn_max_lifetime=1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dsn != "postgres://nanobot@db:5432/nanobot?sslmode=require" {
+		t.Errorf("got DSN %q, want the pool settings to be removed", dsn)
+	}
+	if p.maxOpenConns != 50 || p.connMaxLifetime != time.Hour || p.maxIdleConns != defaultPool.maxIdleConns {
+		t.Errorf("unexpected pool %+v", p)
+	}
+
+	if _, _, err := parsePool("postgres://db/nanobot?max_idle_conns=many"); err == nil {
+		t.Error("expected invalid pool settings to be rejected")
+	}
+}
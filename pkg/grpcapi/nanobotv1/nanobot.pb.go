@@ -0,0 +1,1232 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: nanobotv1/nanobot.proto
+
+package nanobotv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateSessionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Agent answers the prompts of the session, the default agent if empty. It must be one of the
+	// entrypoints of the config.
+	Agent         string `protobuf:"bytes,1,opt,name=agent,proto3" json:"agent,omitempty"`
+	Description   string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSessionRequest) Reset() {
+	*x = CreateSessionRequest{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSessionRequest) ProtoMessage() {}
+
+func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSessionRequest) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateSessionRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *CreateSessionRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type Session struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// Agent is the agent selected for the session, empty for the default agent.
+	Agent         string                 `protobuf:"bytes,3,opt,name=agent,proto3" json:"agent,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Session) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Session) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Session) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *Session) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Session) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{2}
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*Session             `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*Session {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type CancelTurnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTurnRequest) Reset() {
+	*x = CancelTurnRequest{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTurnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTurnRequest) ProtoMessage() {}
+
+func (x *CancelTurnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTurnRequest.ProtoReflect.Descriptor instead.
+func (*CancelTurnRequest) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CancelTurnRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type CancelTurnResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Canceled is the number of turns that were canceled.
+	Canceled      int32 `protobuf:"varint,1,opt,name=canceled,proto3" json:"canceled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTurnResponse) Reset() {
+	*x = CancelTurnResponse{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTurnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTurnResponse) ProtoMessage() {}
+
+func (x *CancelTurnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTurnResponse.ProtoReflect.Descriptor instead.
+func (*CancelTurnResponse) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CancelTurnResponse) GetCanceled() int32 {
+	if x != nil {
+		return x.Canceled
+	}
+	return 0
+}
+
+type ChatRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Types that are valid to be assigned to Request:
+	//
+	//	*ChatRequest_Prompt
+	//	*ChatRequest_Cancel
+	Request       isChatRequest_Request `protobuf_oneof:"request"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatRequest) Reset() {
+	*x = ChatRequest{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatRequest) ProtoMessage() {}
+
+func (x *ChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatRequest.ProtoReflect.Descriptor instead.
+func (*ChatRequest) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ChatRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ChatRequest) GetRequest() isChatRequest_Request {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *ChatRequest) GetPrompt() *Prompt {
+	if x != nil {
+		if x, ok := x.Request.(*ChatRequest_Prompt); ok {
+			return x.Prompt
+		}
+	}
+	return nil
+}
+
+func (x *ChatRequest) GetCancel() *Cancel {
+	if x != nil {
+		if x, ok := x.Request.(*ChatRequest_Cancel); ok {
+			return x.Cancel
+		}
+	}
+	return nil
+}
+
+type isChatRequest_Request interface {
+	isChatRequest_Request()
+}
+
+type ChatRequest_Prompt struct {
+	Prompt *Prompt `protobuf:"bytes,2,opt,name=prompt,proto3,oneof"`
+}
+
+type ChatRequest_Cancel struct {
+	Cancel *Cancel `protobuf:"bytes,3,opt,name=cancel,proto3,oneof"`
+}
+
+func (*ChatRequest_Prompt) isChatRequest_Request() {}
+
+func (*ChatRequest_Cancel) isChatRequest_Request() {}
+
+type Prompt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// TurnId identifies the events of the turn, it is generated if empty.
+	TurnId        string        `protobuf:"bytes,1,opt,name=turn_id,json=turnId,proto3" json:"turn_id,omitempty"`
+	Text          string        `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Attachments   []*Attachment `protobuf:"bytes,3,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Prompt) Reset() {
+	*x = Prompt{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Prompt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Prompt) ProtoMessage() {}
+
+func (x *Prompt) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Prompt.ProtoReflect.Descriptor instead.
+func (*Prompt) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Prompt) GetTurnId() string {
+	if x != nil {
+		return x.TurnId
+	}
+	return ""
+}
+
+func (x *Prompt) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Prompt) GetAttachments() []*Attachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+type Attachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	MimeType      string                 `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attachment) Reset() {
+	*x = Attachment{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attachment) ProtoMessage() {}
+
+func (x *Attachment) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Attachment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Attachment) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Attachment) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Cancel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Cancel) Reset() {
+	*x = Cancel{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Cancel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cancel) ProtoMessage() {}
+
+func (x *Cancel) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cancel.ProtoReflect.Descriptor instead.
+func (*Cancel) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{9}
+}
+
+type ChatEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	TurnId    string                 `protobuf:"bytes,2,opt,name=turn_id,json=turnId,proto3" json:"turn_id,omitempty"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*ChatEvent_Progress
+	//	*ChatEvent_Completed
+	//	*ChatEvent_Failed
+	Event         isChatEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatEvent) Reset() {
+	*x = ChatEvent{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatEvent) ProtoMessage() {}
+
+func (x *ChatEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatEvent.ProtoReflect.Descriptor instead.
+func (*ChatEvent) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ChatEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetTurnId() string {
+	if x != nil {
+		return x.TurnId
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetEvent() isChatEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ChatEvent) GetProgress() *ProgressItem {
+	if x != nil {
+		if x, ok := x.Event.(*ChatEvent_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *ChatEvent) GetCompleted() *TurnCompleted {
+	if x != nil {
+		if x, ok := x.Event.(*ChatEvent_Completed); ok {
+			return x.Completed
+		}
+	}
+	return nil
+}
+
+func (x *ChatEvent) GetFailed() *TurnFailed {
+	if x != nil {
+		if x, ok := x.Event.(*ChatEvent_Failed); ok {
+			return x.Failed
+		}
+	}
+	return nil
+}
+
+type isChatEvent_Event interface {
+	isChatEvent_Event()
+}
+
+type ChatEvent_Progress struct {
+	Progress *ProgressItem `protobuf:"bytes,3,opt,name=progress,proto3,oneof"`
+}
+
+type ChatEvent_Completed struct {
+	Completed *TurnCompleted `protobuf:"bytes,4,opt,name=completed,proto3,oneof"`
+}
+
+type ChatEvent_Failed struct {
+	Failed *TurnFailed `protobuf:"bytes,5,opt,name=failed,proto3,oneof"`
+}
+
+func (*ChatEvent_Progress) isChatEvent_Event() {}
+
+func (*ChatEvent_Completed) isChatEvent_Event() {}
+
+func (*ChatEvent_Failed) isChatEvent_Event() {}
+
+// ProgressItem is an item of a message of the agent while it is generated.
+type ProgressItem struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	MessageId string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	ItemId    string                 `protobuf:"bytes,2,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`
+	// Partial is set if the text or the arguments of the tool call are a delta to append to the
+	// item, otherwise they replace it.
+	Partial bool `protobuf:"varint,3,opt,name=partial,proto3" json:"partial,omitempty"`
+	// Types that are valid to be assigned to Item:
+	//
+	//	*ProgressItem_Text
+	//	*ProgressItem_Reasoning
+	//	*ProgressItem_ToolCall
+	//	*ProgressItem_ToolResult
+	Item          isProgressItem_Item `protobuf_oneof:"item"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProgressItem) Reset() {
+	*x = ProgressItem{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProgressItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgressItem) ProtoMessage() {}
+
+func (x *ProgressItem) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgressItem.ProtoReflect.Descriptor instead.
+func (*ProgressItem) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ProgressItem) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ProgressItem) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *ProgressItem) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *ProgressItem) GetItem() isProgressItem_Item {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *ProgressItem) GetText() string {
+	if x != nil {
+		if x, ok := x.Item.(*ProgressItem_Text); ok {
+			return x.Text
+		}
+	}
+	return ""
+}
+
+func (x *ProgressItem) GetReasoning() string {
+	if x != nil {
+		if x, ok := x.Item.(*ProgressItem_Reasoning); ok {
+			return x.Reasoning
+		}
+	}
+	return ""
+}
+
+func (x *ProgressItem) GetToolCall() *ToolCall {
+	if x != nil {
+		if x, ok := x.Item.(*ProgressItem_ToolCall); ok {
+			return x.ToolCall
+		}
+	}
+	return nil
+}
+
+func (x *ProgressItem) GetToolResult() *ToolResult {
+	if x != nil {
+		if x, ok := x.Item.(*ProgressItem_ToolResult); ok {
+			return x.ToolResult
+		}
+	}
+	return nil
+}
+
+type isProgressItem_Item interface {
+	isProgressItem_Item()
+}
+
+type ProgressItem_Text struct {
+	Text string `protobuf:"bytes,4,opt,name=text,proto3,oneof"`
+}
+
+type ProgressItem_Reasoning struct {
+	Reasoning string `protobuf:"bytes,5,opt,name=reasoning,proto3,oneof"`
+}
+
+type ProgressItem_ToolCall struct {
+	ToolCall *ToolCall `protobuf:"bytes,6,opt,name=tool_call,json=toolCall,proto3,oneof"`
+}
+
+type ProgressItem_ToolResult struct {
+	ToolResult *ToolResult `protobuf:"bytes,7,opt,name=tool_result,json=toolResult,proto3,oneof"`
+}
+
+func (*ProgressItem_Text) isProgressItem_Item() {}
+
+func (*ProgressItem_Reasoning) isProgressItem_Item() {}
+
+func (*ProgressItem_ToolCall) isProgressItem_Item() {}
+
+func (*ProgressItem_ToolResult) isProgressItem_Item() {}
+
+type ToolCall struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CallId        string                 `protobuf:"bytes,1,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Arguments     string                 `protobuf:"bytes,3,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCall) Reset() {
+	*x = ToolCall{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCall) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCall) ProtoMessage() {}
+
+func (x *ToolCall) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCall.ProtoReflect.Descriptor instead.
+func (*ToolCall) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ToolCall) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *ToolCall) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToolCall) GetArguments() string {
+	if x != nil {
+		return x.Arguments
+	}
+	return ""
+}
+
+type ToolResult struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	CallId  string                 `protobuf:"bytes,1,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	Name    string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	IsError bool                   `protobuf:"varint,3,opt,name=is_error,json=isError,proto3" json:"is_error,omitempty"`
+	// Text is the text content of the result.
+	Text          string `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolResult) Reset() {
+	*x = ToolResult{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolResult) ProtoMessage() {}
+
+func (x *ToolResult) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolResult.ProtoReflect.Descriptor instead.
+func (*ToolResult) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ToolResult) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *ToolResult) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToolResult) GetIsError() bool {
+	if x != nil {
+		return x.IsError
+	}
+	return false
+}
+
+func (x *ToolResult) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type TurnCompleted struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Text is the markdown of the final response.
+	Text          string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TurnCompleted) Reset() {
+	*x = TurnCompleted{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnCompleted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnCompleted) ProtoMessage() {}
+
+func (x *TurnCompleted) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnCompleted.ProtoReflect.Descriptor instead.
+func (*TurnCompleted) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TurnCompleted) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type TurnFailed struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Canceled      bool                   `protobuf:"varint,2,opt,name=canceled,proto3" json:"canceled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TurnFailed) Reset() {
+	*x = TurnFailed{}
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnFailed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnFailed) ProtoMessage() {}
+
+func (x *TurnFailed) ProtoReflect() protoreflect.Message {
+	mi := &file_nanobotv1_nanobot_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnFailed.ProtoReflect.Descriptor instead.
+func (*TurnFailed) Descriptor() ([]byte, []int) {
+	return file_nanobotv1_nanobot_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TurnFailed) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TurnFailed) GetCanceled() bool {
+	if x != nil {
+		return x.Canceled
+	}
+	return false
+}
+
+var File_nanobotv1_nanobot_proto protoreflect.FileDescriptor
+
+const file_nanobotv1_nanobot_proto_rawDesc = "" +
+	"\n" +
+	"\x17nanobotv1/nanobot.proto\x12\n" +
+	"nanobot.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"N\n" +
+	"\x14CreateSessionRequest\x12\x14\n" +
+	"\x05agent\x18\x01 \x01(\tR\x05agent\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"\xc7\x01\n" +
+	"\aSession\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05agent\x18\x03 \x01(\tR\x05agent\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x15\n" +
+	"\x13ListSessionsRequest\"G\n" +
+	"\x14ListSessionsResponse\x12/\n" +
+	"\bsessions\x18\x01 \x03(\v2\x13.nanobot.v1.SessionR\bsessions\"2\n" +
+	"\x11CancelTurnRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"0\n" +
+	"\x12CancelTurnResponse\x12\x1a\n" +
+	"\bcanceled\x18\x01 \x01(\x05R\bcanceled\"\x93\x01\n" +
+	"\vChatRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12,\n" +
+	"\x06prompt\x18\x02 \x01(\v2\x12.nanobot.v1.PromptH\x00R\x06prompt\x12,\n" +
+	"\x06cancel\x18\x03 \x01(\v2\x12.nanobot.v1.CancelH\x00R\x06cancelB\t\n" +
+	"\arequest\"o\n" +
+	"\x06Prompt\x12\x17\n" +
+	"\aturn_id\x18\x01 \x01(\tR\x06turnId\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x128\n" +
+	"\vattachments\x18\x03 \x03(\v2\x16.nanobot.v1.AttachmentR\vattachments\"Q\n" +
+	"\n" +
+	"Attachment\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\b\n" +
+	"\x06Cancel\"\xf1\x01\n" +
+	"\tChatEvent\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x17\n" +
+	"\aturn_id\x18\x02 \x01(\tR\x06turnId\x126\n" +
+	"\bprogress\x18\x03 \x01(\v2\x18.nanobot.v1.ProgressItemH\x00R\bprogress\x129\n" +
+	"\tcompleted\x18\x04 \x01(\v2\x19.nanobot.v1.TurnCompletedH\x00R\tcompleted\x120\n" +
+	"\x06failed\x18\x05 \x01(\v2\x16.nanobot.v1.TurnFailedH\x00R\x06failedB\a\n" +
+	"\x05event\"\x8e\x02\n" +
+	"\fProgressItem\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x17\n" +
+	"\aitem_id\x18\x02 \x01(\tR\x06itemId\x12\x18\n" +
+	"\apartial\x18\x03 \x01(\bR\apartial\x12\x14\n" +
+	"\x04text\x18\x04 \x01(\tH\x00R\x04text\x12\x1e\n" +
+	"\treasoning\x18\x05 \x01(\tH\x00R\treasoning\x123\n" +
+	"\ttool_call\x18\x06 \x01(\v2\x14.nanobot.v1.ToolCallH\x00R\btoolCall\x129\n" +
+	"\vtool_result\x18\a \x01(\v2\x16.nanobot.v1.ToolResultH\x00R\n" +
+	"toolResultB\x06\n" +
+	"\x04item\"U\n" +
+	"\bToolCall\x12\x17\n" +
+	"\acall_id\x18\x01 \x01(\tR\x06callId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
+	"\targuments\x18\x03 \x01(\tR\targuments\"h\n" +
+	"\n" +
+	"ToolResult\x12\x17\n" +
+	"\acall_id\x18\x01 \x01(\tR\x06callId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x19\n" +
+	"\bis_error\x18\x03 \x01(\bR\aisError\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\"#\n" +
+	"\rTurnCompleted\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\">\n" +
+	"\n" +
+	"TurnFailed\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\x12\x1a\n" +
+	"\bcanceled\x18\x02 \x01(\bR\bcanceled2\xad\x02\n" +
+	"\aNanobot\x12F\n" +
+	"\rCreateSession\x12 .nanobot.v1.CreateSessionRequest\x1a\x13.nanobot.v1.Session\x12:\n" +
+	"\x04Chat\x12\x17.nanobot.v1.ChatRequest\x1a\x15.nanobot.v1.ChatEvent(\x010\x01\x12Q\n" +
+	"\fListSessions\x12\x1f.nanobot.v1.ListSessionsRequest\x1a .nanobot.v1.ListSessionsResponse\x12K\n" +
+	"\n" +
+	"CancelTurn\x12\x1d.nanobot.v1.CancelTurnRequest\x1a\x1e.nanobot.v1.CancelTurnResponseB5Z3github.com/nanobot-ai/nanobot/pkg/grpcapi/nanobotv1b\x06proto3"
+
+var (
+	file_nanobotv1_nanobot_proto_rawDescOnce sync.Once
+	file_nanobotv1_nanobot_proto_rawDescData []byte
+)
+
+func file_nanobotv1_nanobot_proto_rawDescGZIP() []byte {
+	file_nanobotv1_nanobot_proto_rawDescOnce.Do(func() {
+		file_nanobotv1_nanobot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_nanobotv1_nanobot_proto_rawDesc), len(file_nanobotv1_nanobot_proto_rawDesc)))
+	})
+	return file_nanobotv1_nanobot_proto_rawDescData
+}
+
+var file_nanobotv1_nanobot_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_nanobotv1_nanobot_proto_goTypes = []any{
+	(*CreateSessionRequest)(nil),  // 0: nanobot.v1.CreateSessionRequest
+	(*Session)(nil),               // 1: nanobot.v1.Session
+	(*ListSessionsRequest)(nil),   // 2: nanobot.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),  // 3: nanobot.v1.ListSessionsResponse
+	(*CancelTurnRequest)(nil),     // 4: nanobot.v1.CancelTurnRequest
+	(*CancelTurnResponse)(nil),    // 5: nanobot.v1.CancelTurnResponse
+	(*ChatRequest)(nil),           // 6: nanobot.v1.ChatRequest
+	(*Prompt)(nil),                // 7: nanobot.v1.Prompt
+	(*Attachment)(nil),            // 8: nanobot.v1.Attachment
+	(*Cancel)(nil),                // 9: nanobot.v1.Cancel
+	(*ChatEvent)(nil),             // 10: nanobot.v1.ChatEvent
+	(*ProgressItem)(nil),          // 11: nanobot.v1.ProgressItem
+	(*ToolCall)(nil),              // 12: nanobot.v1.ToolCall
+	(*ToolResult)(nil),            // 13: nanobot.v1.ToolResult
+	(*TurnCompleted)(nil),         // 14: nanobot.v1.TurnCompleted
+	(*TurnFailed)(nil),            // 15: nanobot.v1.TurnFailed
+	(*timestamppb.Timestamp)(nil), // 16: google.protobuf.Timestamp
+}
+var file_nanobotv1_nanobot_proto_depIdxs = []int32{
+	16, // 0: nanobot.v1.Session.created_at:type_name -> google.protobuf.Timestamp
+	16, // 1: nanobot.v1.Session.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 2: nanobot.v1.ListSessionsResponse.sessions:type_name -> nanobot.v1.Session
+	7,  // 3: nanobot.v1.ChatRequest.prompt:type_name -> nanobot.v1.Prompt
+	9,  // 4: nanobot.v1.ChatRequest.cancel:type_name -> nanobot.v1.Cancel
+	8,  // 5: nanobot.v1.Prompt.attachments:type_name -> nanobot.v1.Attachment
+	11, // 6: nanobot.v1.ChatEvent.progress:type_name -> nanobot.v1.ProgressItem
+	14, // 7: nanobot.v1.ChatEvent.completed:type_name -> nanobot.v1.TurnCompleted
+	15, // 8: nanobot.v1.ChatEvent.failed:type_name -> nanobot.v1.TurnFailed
+	12, // 9: nanobot.v1.ProgressItem.tool_call:type_name -> nanobot.v1.ToolCall
+	13, // 10: nanobot.v1.ProgressItem.tool_result:type_name -> nanobot.v1.ToolResult
+	0,  // 11: nanobot.v1.Nanobot.CreateSession:input_type -> nanobot.v1.CreateSessionRequest
+	6,  // 12: nanobot.v1.Nanobot.Chat:input_type -> nanobot.v1.ChatRequest
+	2,  // 13: nanobot.v1.Nanobot.ListSessions:input_type -> nanobot.v1.ListSessionsRequest
+	4,  // 14: nanobot.v1.Nanobot.CancelTurn:input_type -> nanobot.v1.CancelTurnRequest
+	1,  // 15: nanobot.v1.Nanobot.CreateSession:output_type -> nanobot.v1.Session
+	10, // 16: nanobot.v1.Nanobot.Chat:output_type -> nanobot.v1.ChatEvent
+	3,  // 17: nanobot.v1.Nanobot.ListSessions:output_type -> nanobot.v1.ListSessionsResponse
+	5,  // 18: nanobot.v1.Nanobot.CancelTurn:output_type -> nanobot.v1.CancelTurnResponse
+	15, // [15:19] is the sub-list for method output_type
+	11, // [11:15] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_nanobotv1_nanobot_proto_init() }
+func file_nanobotv1_nanobot_proto_init() {
+	if File_nanobotv1_nanobot_proto != nil {
+		return
+	}
+	file_nanobotv1_nanobot_proto_msgTypes[6].OneofWrappers = []any{
+		(*ChatRequest_Prompt)(nil),
+		(*ChatRequest_Cancel)(nil),
+	}
+	file_nanobotv1_nanobot_proto_msgTypes[10].OneofWrappers = []any{
+		(*ChatEvent_Progress)(nil),
+		(*ChatEvent_Completed)(nil),
+		(*ChatEvent_Failed)(nil),
+	}
+	file_nanobotv1_nanobot_proto_msgTypes[11].OneofWrappers = []any{
+		(*ProgressItem_Text)(nil),
+		(*ProgressItem_Reasoning)(nil),
+		(*ProgressItem_ToolCall)(nil),
+		(*ProgressItem_ToolResult)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_nanobotv1_nanobot_proto_rawDesc), len(file_nanobotv1_nanobot_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_nanobotv1_nanobot_proto_goTypes,
+		DependencyIndexes: file_nanobotv1_nanobot_proto_depIdxs,
+		MessageInfos:      file_nanobotv1_nanobot_proto_msgTypes,
+	}.Build()
+	File_nanobotv1_nanobot_proto = out.File
+	file_nanobotv1_nanobot_proto_goTypes = nil
+	file_nanobotv1_nanobot_proto_depIdxs = nil
+}
@@ -0,0 +1,248 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: nanobotv1/nanobot.proto
+
+package nanobotv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Nanobot_CreateSession_FullMethodName = "/nanobot.v1.Nanobot/CreateSession"
+	Nanobot_Chat_FullMethodName          = "/nanobot.v1.Nanobot/Chat"
+	Nanobot_ListSessions_FullMethodName  = "/nanobot.v1.Nanobot/ListSessions"
+	Nanobot_CancelTurn_FullMethodName    = "/nanobot.v1.Nanobot/CancelTurn"
+)
+
+// NanobotClient is the client API for Nanobot service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Nanobot runs the agents of a nanobot for integrators that need typed clients and streaming
+// without server-sent events. Calls are authenticated like MCP, sessions belong to the caller.
+type NanobotClient interface {
+	// CreateSession creates a session of the caller.
+	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*Session, error)
+	// Chat runs the turns of sessions. Every prompt sent starts a turn of its session, whose
+	// progress is streamed back until it completes or fails. Turns of the same session run one
+	// after the other, a cancel cancels the turns of the session started on the stream.
+	Chat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ChatRequest, ChatEvent], error)
+	// ListSessions lists the sessions created by the caller, the most recent first.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// CancelTurn cancels the running turns of a session of the caller, from any stream.
+	CancelTurn(ctx context.Context, in *CancelTurnRequest, opts ...grpc.CallOption) (*CancelTurnResponse, error)
+}
+
+type nanobotClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNanobotClient(cc grpc.ClientConnInterface) NanobotClient {
+	return &nanobotClient{cc}
+}
+
+func (c *nanobotClient) CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*Session, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Session)
+	err := c.cc.Invoke(ctx, Nanobot_CreateSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nanobotClient) Chat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ChatRequest, ChatEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Nanobot_ServiceDesc.Streams[0], Nanobot_Chat_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChatRequest, ChatEvent]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Nanobot_ChatClient = grpc.BidiStreamingClient[ChatRequest, ChatEvent]
+
+func (c *nanobotClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, Nanobot_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nanobotClient) CancelTurn(ctx context.Context, in *CancelTurnRequest, opts ...grpc.CallOption) (*CancelTurnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelTurnResponse)
+	err := c.cc.Invoke(ctx, Nanobot_CancelTurn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NanobotServer is the server API for Nanobot service.
+// All implementations must embed UnimplementedNanobotServer
+// for forward compatibility.
+//
+// Nanobot runs the agents of a nanobot for integrators that need typed clients and streaming
+// without server-sent events. Calls are authenticated like MCP, sessions belong to the caller.
+type NanobotServer interface {
+	// CreateSession creates a session of the caller.
+	CreateSession(context.Context, *CreateSessionRequest) (*Session, error)
+	// Chat runs the turns of sessions. Every prompt sent starts a turn of its session, whose
+	// progress is streamed back until it completes or fails. Turns of the same session run one
+	// after the other, a cancel cancels the turns of the session started on the stream.
+	Chat(grpc.BidiStreamingServer[ChatRequest, ChatEvent]) error
+	// ListSessions lists the sessions created by the caller, the most recent first.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// CancelTurn cancels the running turns of a session of the caller, from any stream.
+	CancelTurn(context.Context, *CancelTurnRequest) (*CancelTurnResponse, error)
+	mustEmbedUnimplementedNanobotServer()
+}
+
+// UnimplementedNanobotServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNanobotServer struct{}
+
+func (UnimplementedNanobotServer) CreateSession(context.Context, *CreateSessionRequest) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
+}
+func (UnimplementedNanobotServer) Chat(grpc.BidiStreamingServer[ChatRequest, ChatEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Chat not implemented")
+}
+func (UnimplementedNanobotServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedNanobotServer) CancelTurn(context.Context, *CancelTurnRequest) (*CancelTurnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelTurn not implemented")
+}
+func (UnimplementedNanobotServer) mustEmbedUnimplementedNanobotServer() {}
+func (UnimplementedNanobotServer) testEmbeddedByValue()                 {}
+
+// UnsafeNanobotServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NanobotServer will
+// result in compilation errors.
+type UnsafeNanobotServer interface {
+	mustEmbedUnimplementedNanobotServer()
+}
+
+func RegisterNanobotServer(s grpc.ServiceRegistrar, srv NanobotServer) {
+	// If the following call pancis, it indicates UnimplementedNanobotServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Nanobot_ServiceDesc, srv)
+}
+
+func _Nanobot_CreateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NanobotServer).CreateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Nanobot_CreateSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NanobotServer).CreateSession(ctx, req.(*CreateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Nanobot_Chat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NanobotServer).Chat(&grpc.GenericServerStream[ChatRequest, ChatEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Nanobot_ChatServer = grpc.BidiStreamingServer[ChatRequest, ChatEvent]
+
+func _Nanobot_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NanobotServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Nanobot_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NanobotServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Nanobot_CancelTurn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelTurnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NanobotServer).CancelTurn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Nanobot_CancelTurn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NanobotServer).CancelTurn(ctx, req.(*CancelTurnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Nanobot_ServiceDesc is the grpc.ServiceDesc for Nanobot service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Nanobot_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "nanobot.v1.Nanobot",
+	HandlerType: (*NanobotServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateSession",
+			Handler:    _Nanobot_CreateSession_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _Nanobot_ListSessions_Handler,
+		},
+		{
+			MethodName: "CancelTurn",
+			Handler:    _Nanobot_CancelTurn_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Chat",
+			Handler:       _Nanobot_Chat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "nanobotv1/nanobot.proto",
+}
@@ -0,0 +1,81 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/grpcapi/nanobotv1"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// progressItem converts a progress notification of the turn with the progress token to an item of
+// the response. Other messages, user messages, and items without content are skipped.
+func progressItem(msg *mcp.Message, progressToken string) (*nanobotv1.ProgressItem, bool) {
+	if msg.Method != "notifications/progress" {
+		return nil, false
+	}
+
+	var payload struct {
+		ProgressToken any `json:"progressToken"`
+		Meta          struct {
+			Progress *types.CompletionProgress `json:"ai.nanobot.progress/completion"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(msg.Params, &payload); err != nil || payload.Meta.Progress == nil ||
+		fmt.Sprint(payload.ProgressToken) != progressToken || payload.Meta.Progress.Role == "user" {
+		return nil, false
+	}
+
+	var (
+		progress = payload.Meta.Progress
+		item     = progress.Item
+		result   = &nanobotv1.ProgressItem{
+			MessageId: progress.MessageID,
+			ItemId:    item.ID,
+			Partial:   item.Partial,
+		}
+	)
+	switch {
+	case item.ToolCallResult != nil:
+		toolResult := &nanobotv1.ToolResult{
+			CallId:  item.ToolCallResult.CallID,
+			IsError: item.ToolCallResult.Output.IsError,
+			Text:    contentText(item.ToolCallResult.Output.Content),
+		}
+		if item.ToolCall != nil {
+			toolResult.Name = item.ToolCall.Name
+		}
+		result.Item = &nanobotv1.ProgressItem_ToolResult{ToolResult: toolResult}
+	case item.ToolCall != nil && item.ToolCall.CallID != "":
+		result.Item = &nanobotv1.ProgressItem_ToolCall{
+			ToolCall: &nanobotv1.ToolCall{
+				CallId:    item.ToolCall.CallID,
+				Name:      item.ToolCall.Name,
+				Arguments: item.ToolCall.Arguments,
+			},
+		}
+	case item.Reasoning != nil && len(item.Reasoning.Summary) > 0:
+		var text []string
+		for _, summary := range item.Reasoning.Summary {
+			text = append(text, summary.Text)
+		}
+		result.Item = &nanobotv1.ProgressItem_Reasoning{Reasoning: strings.Join(text, "\n\n")}
+	case item.Content != nil && item.Content.Type == "text":
+		result.Item = &nanobotv1.ProgressItem_Text{Text: item.Content.Text}
+	default:
+		return nil, false
+	}
+	return result, true
+}
+
+func contentText(content []mcp.Content) string {
+	var text []string
+	for _, c := range content {
+		if c.Type == "text" {
+			text = append(text, c.Text)
+		}
+	}
+	return strings.Join(text, "\n\n")
+}
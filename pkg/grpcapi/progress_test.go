@@ -0,0 +1,84 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func progressMessage(token string, progress types.CompletionProgress) *mcp.Message {
+	params, _ := json.Marshal(map[string]any{
+		"progressToken": token,
+		"_meta": map[string]any{
+			"ai.nanobot.progress/completion": progress,
+		},
+	})
+	return &mcp.Message{Method: "notifications/progress", Params: params}
+}
+
+func TestProgressItem(t *testing.T) {
+	item, ok := progressItem(progressMessage("turn", types.CompletionProgress{
+		MessageID: "m1",
+		Item:      types.CompletionItem{ID: "i1", Partial: true, Content: &mcp.Content{Type: "text", Text: "Hel"}},
+	}), "turn")
+	if !ok || item.GetText() != "Hel" || !item.Partial || item.MessageId != "m1" || item.ItemId != "i1" {
+		t.Errorf("unexpected text item %v", item)
+	}
+
+	item, ok = progressItem(progressMessage("turn", types.CompletionProgress{
+		Item: types.CompletionItem{
+			ToolCall: &types.ToolCall{CallID: "c1", Name: "search"},
+			ToolCallResult: &types.ToolCallResult{CallID: "c1", Output: types.CallResult{
+				IsError: true,
+				Content: []mcp.Content{{Type: "text", Text: "not found"}},
+			}},
+		},
+	}), "turn")
+	if result := item.GetToolResult(); !ok || result.GetName() != "search" || !result.GetIsError() || result.GetText() != "not found" {
+		t.Errorf("unexpected tool result %v", item)
+	}
+
+	for name, msg := range map[string]*mcp.Message{
+		"other turn": progressMessage("other", types.CompletionProgress{
+			Item: types.CompletionItem{Content: &mcp.Content{Type: "text", Text: "Hi"}},
+		}),
+		"user message": progressMessage("turn", types.CompletionProgress{
+			Role: "user",
+			Item: types.CompletionItem{Content: &mcp.Content{Type: "text", Text: "Hi"}},
+		}),
+		"other method": {Method: "notifications/message"},
+	} {
+		if item, ok := progressItem(msg, "turn"); ok {
+			t.Errorf("expected %s to be skipped, got %v", name, item)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	s := &Server{turns: map[string]map[*turn]struct{}{}}
+
+	var canceled []string
+	newTurn := func(id string) *turn {
+		return &turn{id: id, cancel: func() { canceled = append(canceled, id) }}
+	}
+	first, second := newTurn("t1"), newTurn("t2")
+	s.add("s1", first)
+	s.add("s1", second)
+	s.add("s2", newTurn("t3"))
+
+	if n := s.cancel("s1", first); n != 1 || len(canceled) != 1 || canceled[0] != "t1" {
+		t.Fatalf("expected only t1 to be canceled, got %d %v", n, canceled)
+	}
+
+	s.remove("s1", first)
+	if n := s.cancel("s1", nil); n != 1 || canceled[1] != "t2" {
+		t.Fatalf("expected t2 to be canceled, got %d %v", n, canceled)
+	}
+
+	s.remove("s1", second)
+	if _, ok := s.turns["s1"]; ok {
+		t.Error("expected the session without turns to be removed")
+	}
+}
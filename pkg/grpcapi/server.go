@@ -0,0 +1,325 @@
+// Package grpcapi serves the nanobot.v1.Nanobot gRPC service, which creates sessions and runs
+// their turns with streamed progress for integrators that need typed clients. The service is
+// generated from nanobotv1/nanobot.proto.
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative nanobotv1/nanobot.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/channel"
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/grpcapi/nanobotv1"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+const (
+	// Path is the prefix of the paths of the methods of the service.
+	Path = "/nanobot.v1.Nanobot/"
+	// SessionType is the type of the sessions created with the service.
+	SessionType = "grpc"
+)
+
+type Options struct {
+	// DSN is the database attachments are stored in.
+	DSN string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.DSN = complete.Last(o.DSN, other.DSN)
+	return
+}
+
+// Server implements the Nanobot service. It is served as an http.Handler, so the calls go through
+// the same auth as MCP, which requires HTTP/2 without TLS to be enabled on the HTTP server.
+type Server struct {
+	nanobotv1.UnimplementedNanobotServer
+
+	runner   *channel.Runner
+	sessions *session.Manager
+	grpc     *grpc.Server
+
+	lock sync.Mutex
+	// turns are the running turns by session.
+	turns map[string]map[*turn]struct{}
+}
+
+// turn is a turn that runs or waits for the previous turn of its session.
+type turn struct {
+	id     string
+	cancel context.CancelFunc
+}
+
+func NewServer(runt *runtime.Runtime, config types.ConfigFactory, sessions *session.Manager, server mcp.MessageHandler, opts ...Options) (*Server, error) {
+	opt := complete.Complete(opts...)
+
+	runner, err := channel.NewRunner(runt, config, sessions, server, channel.Options{
+		DSN: opt.DSN,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		runner:   runner,
+		sessions: sessions,
+		grpc:     grpc.NewServer(),
+		turns:    map[string]map[*turn]struct{}{},
+	}
+	nanobotv1.RegisterNanobotServer(s.grpc, s)
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	s.grpc.ServeHTTP(rw, req.WithContext(mcp.WithRequest(req.Context(), req)))
+}
+
+func (s *Server) CreateSession(ctx context.Context, req *nanobotv1.CreateSessionRequest) (*nanobotv1.Session, error) {
+	user := types.NanobotContext(ctx).User
+	msg := message(user, uuid.String())
+	msg.Description = req.Description
+
+	if err := s.runner.SetAgent(ctx, msg, req.Agent); err != nil {
+		_ = s.sessions.Delete(ctx, msg.SessionID)
+		if req.Agent != "" {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to select agent %s: %v", req.Agent, err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create session: %v", err)
+	}
+
+	stored, err := s.sessions.DB.Get(ctx, msg.SessionID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load session: %v", err)
+	}
+	return toSession(stored), nil
+}
+
+func (s *Server) ListSessions(ctx context.Context, _ *nanobotv1.ListSessionsRequest) (*nanobotv1.ListSessionsResponse, error) {
+	user := types.NanobotContext(ctx).User
+	stored, err := s.sessions.DB.FindByAccount(ctx, SessionType, user.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+
+	resp := &nanobotv1.ListSessionsResponse{}
+	for i := range stored {
+		resp.Sessions = append(resp.Sessions, toSession(&stored[i]))
+	}
+	return resp, nil
+}
+
+func (s *Server) CancelTurn(ctx context.Context, req *nanobotv1.CancelTurnRequest) (*nanobotv1.CancelTurnResponse, error) {
+	if err := s.checkSession(ctx, req.SessionId); err != nil {
+		return nil, err
+	}
+	return &nanobotv1.CancelTurnResponse{
+		Canceled: int32(s.cancel(req.SessionId, nil)),
+	}, nil
+}
+
+func (s *Server) Chat(stream grpc.BidiStreamingServer[nanobotv1.ChatRequest, nanobotv1.ChatEvent]) error {
+	var (
+		ctx      = stream.Context()
+		user     = types.NanobotContext(ctx).User
+		sendLock sync.Mutex
+		wg       sync.WaitGroup
+		// started are the turns started on the stream, for cancel requests.
+		started = map[*turn]string{}
+	)
+	defer wg.Wait()
+
+	send := func(event *nanobotv1.ChatEvent) {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		if err := stream.Send(event); err != nil {
+			log.Debugf(ctx, "failed to send gRPC chat event: %v", err)
+		}
+	}
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch r := req.Request.(type) {
+		case *nanobotv1.ChatRequest_Prompt:
+			t := &turn{id: complete.First(r.Prompt.GetTurnId(), uuid.String())}
+			if err := s.checkSession(ctx, req.SessionId); err != nil {
+				send(failed(req.SessionId, t.id, err, false))
+				continue
+			}
+
+			var turnCtx context.Context
+			turnCtx, t.cancel = context.WithCancel(ctx)
+			s.add(req.SessionId, t)
+			started[t] = req.SessionId
+
+			wg.Add(1)
+			go func(sessionID string, prompt *nanobotv1.Prompt) {
+				defer wg.Done()
+				defer s.remove(sessionID, t)
+				defer t.cancel()
+				send(s.run(turnCtx, user, sessionID, t.id, prompt, send))
+			}(req.SessionId, r.Prompt)
+		case *nanobotv1.ChatRequest_Cancel:
+			for t, sessionID := range started {
+				if sessionID == req.SessionId {
+					s.cancel(sessionID, t)
+					delete(started, t)
+				}
+			}
+		default:
+			send(failed(req.SessionId, "", errors.New("the request has neither a prompt nor a cancel"), false))
+		}
+	}
+}
+
+// run runs a turn and returns its final event. The progress of the turn is sent while it runs.
+func (s *Server) run(ctx context.Context, user types.User, sessionID, turnID string, prompt *nanobotv1.Prompt, send func(*nanobotv1.ChatEvent)) *nanobotv1.ChatEvent {
+	msg := message(user, sessionID)
+	msg.Prompt = prompt.Text
+	msg.ProgressToken = turnID
+	msg.Filter = func(_ context.Context, m *mcp.Message) (*mcp.Message, error) {
+		if item, ok := progressItem(m, turnID); ok {
+			send(&nanobotv1.ChatEvent{
+				SessionId: sessionID,
+				TurnId:    turnID,
+				Event: &nanobotv1.ChatEvent_Progress{
+					Progress: item,
+				},
+			})
+		}
+		return m, nil
+	}
+	for _, attachment := range prompt.Attachments {
+		msg.Files = append(msg.Files, channel.File{
+			Name:     attachment.Name,
+			MimeType: attachment.MimeType,
+			Data:     attachment.Data,
+		})
+	}
+
+	resp, err := s.runner.Run(ctx, msg)
+	if err != nil {
+		return failed(sessionID, turnID, err, ctx.Err() != nil)
+	}
+	return &nanobotv1.ChatEvent{
+		SessionId: sessionID,
+		TurnId:    turnID,
+		Event: &nanobotv1.ChatEvent_Completed{
+			Completed: &nanobotv1.TurnCompleted{
+				Text: resp.Text,
+			},
+		},
+	}
+}
+
+// checkSession returns an error with the status of the call if the session does not belong to the
+// caller.
+func (s *Server) checkSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return status.Error(codes.InvalidArgument, "session_id is required")
+	}
+	user := types.NanobotContext(ctx).User
+	if _, err := s.sessions.DB.GetByIDByAccountID(ctx, sessionID, user.ID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Errorf(codes.NotFound, "session %s not found", sessionID)
+	} else if err != nil {
+		return status.Errorf(codes.Internal, "failed to load session: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) add(sessionID string, t *turn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.turns[sessionID] == nil {
+		s.turns[sessionID] = map[*turn]struct{}{}
+	}
+	s.turns[sessionID][t] = struct{}{}
+}
+
+func (s *Server) remove(sessionID string, t *turn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.turns[sessionID], t)
+	if len(s.turns[sessionID]) == 0 {
+		delete(s.turns, sessionID)
+	}
+}
+
+// cancel cancels the turn t of the session, or all turns of the session if t is nil, and returns
+// the number of turns that were canceled.
+func (s *Server) cancel(sessionID string, t *turn) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var canceled int
+	for running := range s.turns[sessionID] {
+		if t == nil || running == t {
+			running.cancel()
+			canceled++
+		}
+	}
+	return canceled
+}
+
+func message(user types.User, sessionID string) channel.Message {
+	return channel.Message{
+		Channel:     "gRPC",
+		SessionType: SessionType,
+		SessionID:   sessionID,
+		User:        user,
+	}
+}
+
+func toSession(stored *session.Session) *nanobotv1.Session {
+	agent, _ := stored.State.Attributes[types.CurrentAgentSessionKey].(string)
+	return &nanobotv1.Session{
+		Id:          stored.SessionID,
+		Description: stored.Description,
+		Agent:       agent,
+		CreatedAt:   timestamppb.New(stored.CreatedAt),
+		UpdatedAt:   timestamppb.New(stored.UpdatedAt),
+	}
+}
+
+func failed(sessionID, turnID string, err error, canceled bool) *nanobotv1.ChatEvent {
+	msg := err.Error()
+	if s, ok := status.FromError(err); ok {
+		msg = s.Message()
+	}
+	if canceled {
+		msg = fmt.Sprintf("the turn was canceled: %s", msg)
+	}
+	return &nanobotv1.ChatEvent{
+		SessionId: sessionID,
+		TurnId:    turnID,
+		Event: &nanobotv1.ChatEvent_Failed{
+			Failed: &nanobotv1.TurnFailed{
+				Error:    msg,
+				Canceled: canceled,
+			},
+		},
+	}
+}
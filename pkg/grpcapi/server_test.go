@@ -0,0 +1,68 @@
+package grpcapi
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/grpcapi/nanobotv1"
+	"github.com/nanobot-ai/nanobot/pkg/llm"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/server"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestServer(t *testing.T) *Server {
+	dsn := "sqlite:" + filepath.Join(t.TempDir(), "nanobot.db")
+	config := func(context.Context, string) (types.Config, error) {
+		return types.Config{
+			Publish: types.Publish{Entrypoint: []string{"a", "b"}},
+			Agents: map[string]types.Agent{
+				"a": {Name: "A", Model: "mock"},
+				"b": {Name: "B", Model: "mock"},
+			},
+		}, nil
+	}
+
+	runt, err := runtime.NewRuntime(llm.Config{}, runtime.Options{DSN: dsn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := session.NewManager(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(runt, config, sessions, server.NewServer(runt, config, sessions), Options{DSN: dsn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestAllowedAgents(t *testing.T) {
+	s := newTestServer(t)
+	user := types.User{ID: "apikey:ci"}
+	unrestricted := types.WithNanobotContext(t.Context(), types.Context{User: user})
+	restricted := types.WithNanobotContext(t.Context(), types.Context{User: user, Agents: []string{"a"}})
+
+	if _, err := s.CreateSession(restricted, &nanobotv1.CreateSessionRequest{Agent: "b"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected the agent b to be rejected, got %v", err)
+	}
+	if _, err := s.CreateSession(restricted, &nanobotv1.CreateSessionRequest{Agent: "a"}); err != nil {
+		t.Errorf("failed to create a session with the agent a: %v", err)
+	}
+
+	// A session of the same user that selected an agent the key does not allow can not run it.
+	created, err := s.CreateSession(unrestricted, &nanobotv1.CreateSessionRequest{Agent: "b"})
+	if err != nil || created.Agent != "b" {
+		t.Fatalf("CreateSession() = %v, %v", created, err)
+	}
+	event := s.run(restricted, user, created.Id, "t1", &nanobotv1.Prompt{Text: "Hi"}, func(*nanobotv1.ChatEvent) {})
+	if failed := event.GetFailed(); failed == nil || !strings.Contains(failed.Error, "agent b is not allowed") {
+		t.Errorf("expected the turn to fail, got %v", event)
+	}
+}
@@ -0,0 +1,171 @@
+package harness
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/gormdsn"
+)
+
+var (
+	// PostgresImage and RedisImage are the images of the dependencies. Set them, or
+	// NANOBOT_HARNESS_POSTGRES_IMAGE and NANOBOT_HARNESS_REDIS_IMAGE, to test against other versions.
+	PostgresImage = imageFromEnv("NANOBOT_HARNESS_POSTGRES_IMAGE", "postgres:17-alpine")
+	RedisImage    = imageFromEnv("NANOBOT_HARNESS_REDIS_IMAGE", "redis:7-alpine")
+)
+
+// readyTimeout is how long a container has to start accepting connections.
+const readyTimeout = 60 * time.Second
+
+func imageFromEnv(key, image string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return image
+}
+
+// Container is a docker container that is removed when the test ends.
+type Container struct {
+	ID string
+	// Addr is the host:port of the exposed port of the container.
+	Addr string
+}
+
+// ContainerOptions describes a container to start.
+type ContainerOptions struct {
+	Image string
+	Env   map[string]string
+	// Port is the port of the container that is published on a random port of the host, like 5432/tcp.
+	Port string
+	// Ready reports if the container accepts connections on addr, it is polled until it returns nil.
+	Ready func(ctx context.Context, addr string) error
+}
+
+// StartContainer starts a container and waits until it is ready. The test is skipped if docker is
+// not available, so tests that use the harness pass on machines without it.
+func StartContainer(t testing.TB, opts ContainerOptions) *Container {
+	t.Helper()
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not available")
+	}
+	if err := exec.CommandContext(t.Context(), "docker", "info").Run(); err != nil {
+		t.Skipf("docker is not running: %v", err)
+	}
+
+	args := []string{"run", "-d", "--rm", "-p", "127.0.0.1::" + opts.Port}
+	for k, v := range opts.Env {
+		args = append(args, "-e", k+"="+v)
+	}
+	args = append(args, opts.Image)
+
+	out, err := exec.CommandContext(t.Context(), "docker", args...).Output()
+	if err != nil {
+		t.Fatalf("failed to start container %s: %v", opts.Image, commandError(err))
+	}
+	container := &Container{
+		ID: strings.TrimSpace(string(out)),
+	}
+	t.Cleanup(func() {
+		_ = exec.Command("docker", "rm", "-f", container.ID).Run()
+	})
+
+	out, err = exec.CommandContext(t.Context(), "docker", "port", container.ID, opts.Port).Output()
+	if err != nil {
+		t.Fatalf("failed to get the port of container %s: %v", opts.Image, commandError(err))
+	}
+	// The first line is the IPv4 binding, such as 127.0.0.1:55012.
+	container.Addr, _, _ = strings.Cut(strings.TrimSpace(string(out)), "\n")
+
+	if opts.Ready == nil {
+		return container
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), readyTimeout)
+	defer cancel()
+	for {
+		err := opts.Ready(ctx, container.Addr)
+		if err == nil {
+			return container
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("container %s did not get ready: %v", opts.Image, err)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// Postgres starts a PostgreSQL server and returns the DSN of its database.
+func Postgres(t testing.TB) string {
+	t.Helper()
+
+	var dsn string
+	StartContainer(t, ContainerOptions{
+		Image: PostgresImage,
+		Env: map[string]string{
+			"POSTGRES_USER":     "nanobot",
+			"POSTGRES_PASSWORD": "nanobot",
+			"POSTGRES_DB":       "nanobot",
+		},
+		Port: "5432/tcp",
+		Ready: func(_ context.Context, addr string) error {
+			dsn = fmt.Sprintf("postgres://nanobot:nanobot@%s/nanobot?sslmode=disable", addr)
+			db, err := gormdsn.NewDBFromDSN(dsn)
+			if err != nil {
+				return err
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.Ping()
+		},
+	})
+	return dsn
+}
+
+// Redis starts a Redis server and returns its URL.
+func Redis(t testing.TB) string {
+	t.Helper()
+
+	container := StartContainer(t, ContainerOptions{
+		Image: RedisImage,
+		Port:  "6379/tcp",
+		Ready: func(ctx context.Context, addr string) error {
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(time.Second))
+			if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+				return err
+			}
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				return err
+			}
+			if !strings.HasPrefix(line, "+PONG") {
+				return fmt.Errorf("unexpected reply to PING: %q", line)
+			}
+			return nil
+		},
+	})
+	return "redis://" + container.Addr
+}
+
+func commandError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+// Package harness runs a nanobot in a test with its real runtime, MCP server, and session store, so
+// integration tests can exercise complete chat turns, including tool calls and the persistence of
+// the session, over the MCP endpoint that clients use. The model is replaced by scripted responses,
+// and PostgreSQL and Redis can be started in docker with Postgres and Redis.
+//
+// The package is exported for embedders that want to test their own agents the same way:
+//
+//	func TestWeatherAgent(t *testing.T) {
+//		h := harness.New(t, harness.Options{
+//			Config:    weatherConfig,
+//			DSN:       harness.Postgres(t),
+//			Responses: []types.CompletionResponse{replay.Text("It is sunny")},
+//		})
+//		client := h.Connect(t)
+//		result, err := h.Chat(t.Context(), client, "How is the weather?")
+//		...
+//	}
+package harness
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/config"
+	"github.com/nanobot-ai/nanobot/pkg/llm"
+	"github.com/nanobot-ai/nanobot/pkg/llm/replay"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/server"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type Options struct {
+	// Config is the nanobot.yaml of the agents under test.
+	Config string
+	// DSN is the session store, defaults to a SQLite database in a temporary directory.
+	DSN string
+	// Redis is the URL of a Redis server that shares ephemeral sessions and progress, optional.
+	Redis string
+	// Responses are the responses of the model to the completions of the test, in order.
+	Responses []types.CompletionResponse
+	// Env is the environment of the sessions.
+	Env map[string]string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Config = complete.Last(o.Config, other.Config)
+	result.DSN = complete.Last(o.DSN, other.DSN)
+	result.Redis = complete.Last(o.Redis, other.Redis)
+	result.Responses = append(o.Responses, other.Responses...)
+	result.Env = complete.MergeMap(o.Env, other.Env)
+	return
+}
+
+// Harness is a running nanobot.
+type Harness struct {
+	// URL is the MCP endpoint.
+	URL string
+	// Model answers the completions with the scripted responses, and keeps the requests it got.
+	Model *replay.Scripted
+	// Runtime and Sessions are the runtime and the session store of the nanobot.
+	Runtime  *runtime.Runtime
+	Sessions *session.Manager
+}
+
+// New starts a nanobot with the config of the options, it is stopped when the test ends.
+func New(t testing.TB, opts ...Options) *Harness {
+	t.Helper()
+	opt := complete.Complete(opts...)
+
+	dir := t.TempDir()
+	if opt.DSN == "" {
+		opt.DSN = filepath.Join(dir, "nanobot.db")
+	}
+	cfgPath := filepath.Join(dir, "nanobot.yaml")
+	if err := os.WriteFile(cfgPath, []byte(opt.Config), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfgFactory := types.ConfigFactory(func(ctx context.Context, profiles string) (types.Config, error) {
+		var profileList []string
+		if profiles != "" {
+			profileList = strings.Split(profiles, ",")
+		}
+		cfg, _, err := config.Load(ctx, cfgPath, profileList...)
+		if err != nil {
+			return types.Config{}, err
+		}
+		return *cfg, nil
+	})
+	if _, err := cfgFactory(t.Context(), ""); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	h := &Harness{
+		Model: replay.NewScripted(opt.Responses...),
+	}
+
+	var err error
+	h.Runtime, err = runtime.NewRuntime(llm.Config{
+		Middleware: []llm.Middleware{
+			func(types.Completer) types.Completer {
+				return h.Model
+			},
+		},
+	}, runtime.Options{
+		DSN: opt.DSN,
+	})
+	if err != nil {
+		t.Fatalf("failed to create runtime: %v", err)
+	}
+
+	var sessionOptions session.ManagerOptions
+	if opt.Redis != "" {
+		if sessionOptions.Redis, err = session.NewRedis(opt.Redis); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.Sessions, err = session.NewManager(opt.DSN, sessionOptions)
+	if err != nil {
+		t.Fatalf("failed to create session manager: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	httpServer := mcp.NewHTTPServer(opt.Env, server.NewServer(h.Runtime, cfgFactory, h.Sessions), mcp.HTTPServerOptions{
+		SessionStore: h.Sessions,
+		BaseContext:  ctx,
+	})
+	ts := httptest.NewServer(httpServer)
+	t.Cleanup(func() {
+		ts.Close()
+		cancel()
+	})
+
+	h.URL = ts.URL + "/mcp"
+	return h
+}
+
+// Connect starts a session with the nanobot, it is closed when the test ends.
+func (h *Harness) Connect(t testing.TB) *mcp.Client {
+	t.Helper()
+
+	client, err := mcp.NewClient(t.Context(), "harness", mcp.Server{
+		BaseURL: h.URL,
+	}, mcp.ClientOption{
+		ClientName: "harness",
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to nanobot: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Close(false)
+	})
+	return client
+}
+
+// Chat sends the prompt to the agent of the session and returns the result of the turn.
+func (h *Harness) Chat(ctx context.Context, client *mcp.Client, prompt string) (*mcp.CallToolResult, error) {
+	return client.Call(ctx, types.AgentTool, map[string]any{
+		"prompt": prompt,
+	})
+}
+
+// Messages returns the stored messages of the thread of the session, including the older messages
+// that were moved out of the session state.
+func (h *Harness) Messages(ctx context.Context, sessionID string) ([]types.Message, error) {
+	stored, err := h.Sessions.DB.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session %s: %w", sessionID, err)
+	}
+	if err := h.Sessions.DB.ExpandHistory(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	var run types.Execution
+	if err := mcp.JSONCoerce(stored.State.Attributes[types.PreviousExecutionKey], &run); err != nil {
+		return nil, fmt.Errorf("failed to read thread of session %s: %w", sessionID, err)
+	}
+	if run.PopulatedRequest == nil {
+		return nil, nil
+	}
+	return run.PopulatedRequest.Input, nil
+}
@@ -0,0 +1,92 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/replay"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const weatherConfig = `
+agents:
+  main:
+    name: Weather
+    model: gpt-4.1
+    mcpServers: weather
+
+mcpServers:
+  weather:
+    mock:
+      tools:
+      - name: forecast
+        responses:
+        - arguments: {city: Paris}
+          text: sunny
+`
+
+func weatherResponses() []types.CompletionResponse {
+	return []types.CompletionResponse{
+		{
+			Output: types.Message{
+				Role: "assistant",
+				Items: []types.CompletionItem{
+					{
+						ToolCall: &types.ToolCall{
+							CallID:    "call-1",
+							Name:      "forecast",
+							Arguments: `{"city":"Paris"}`,
+						},
+					},
+				},
+			},
+		},
+		replay.Text("It is sunny in Paris"),
+	}
+}
+
+func testChat(t *testing.T, opts Options) {
+	opts.Config = weatherConfig
+	opts.Responses = weatherResponses()
+	h := New(t, opts)
+	client := h.Connect(t)
+
+	result, err := h.Chat(t.Context(), client, "How is the weather in Paris?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError || len(result.Content) == 0 || result.Content[0].Text != "It is sunny in Paris" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// The result of the tool was sent to the model with the second completion.
+	if len(h.Model.Requests) != 2 {
+		t.Fatalf("expected 2 completions, got %d", len(h.Model.Requests))
+	}
+
+	messages, err := h.Messages(t.Context(), client.Session.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var toolResult string
+	for _, msg := range messages {
+		for _, item := range msg.Items {
+			if item.ToolCallResult != nil && len(item.ToolCallResult.Output.Content) > 0 {
+				toolResult = item.ToolCallResult.Output.Content[0].Text
+			}
+		}
+	}
+	if toolResult != "sunny" {
+		t.Fatalf("expected the stored thread to have the result of the tool, got messages %+v", messages)
+	}
+}
+
+func TestChat(t *testing.T) {
+	testChat(t, Options{})
+}
+
+func TestChatWithPostgresAndRedis(t *testing.T) {
+	testChat(t, Options{
+		DSN:   Postgres(t),
+		Redis: Redis(t),
+	})
+}
@@ -0,0 +1,238 @@
+// Package lifecycle accounts for the goroutines and channels that can outlive the request that
+// started them, such as streams, progress subscriptions, and background jobs. Each one is tracked
+// with the session it belongs to, so the live ones can be listed per session and stopped when the
+// session is closed. Tasks that do not stop after their session was closed are reported as leaks.
+package lifecycle
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+)
+
+// Kinds of tasks.
+const (
+	// KindRequest is the handler of a message received from a client.
+	KindRequest = "request"
+	// KindStream reads or writes a stream for as long as it is open.
+	KindStream = "stream"
+	// KindProgress forwards progress notifications to a subscriber.
+	KindProgress = "progress"
+	// KindJob runs in the background of a session, such as an async call.
+	KindJob = "job"
+)
+
+// defaultLeakTimeout is how long a task may keep running after its session was closed before it is
+// reported as leaked.
+const defaultLeakTimeout = 30 * time.Second
+
+// Task is a live goroutine or channel.
+type Task struct {
+	ID        uint64    `json:"id"`
+	SessionID string    `json:"sessionID,omitempty"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Started   time.Time `json:"started"`
+	// Closing is set once the session was closed and the task was asked to stop.
+	Closing bool `json:"closing,omitempty"`
+}
+
+type task struct {
+	Task
+	cancel context.CancelFunc
+}
+
+// SessionTasks are the live tasks of a session. Tasks that belong to no session are listed with an
+// empty session ID.
+type SessionTasks struct {
+	SessionID string `json:"sessionID"`
+	Tasks     []Task `json:"tasks"`
+}
+
+type Report struct {
+	Total    int            `json:"total"`
+	Sessions []SessionTasks `json:"sessions"`
+}
+
+type Registry struct {
+	lock        sync.Mutex
+	next        uint64
+	tasks       map[uint64]*task
+	leakTimeout time.Duration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		tasks:       map[uint64]*task{},
+		leakTimeout: defaultLeakTimeout,
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Observer is notified of the tasks of all registries, such as to count them in metrics.
+type Observer interface {
+	TaskStarted(kind string)
+	TaskStopped(kind string)
+	TaskLeaked(kind string)
+}
+
+type noopObserver struct{}
+
+func (noopObserver) TaskStarted(string) {}
+func (noopObserver) TaskStopped(string) {}
+func (noopObserver) TaskLeaked(string)  {}
+
+var activeObserver atomic.Pointer[Observer]
+
+// SetObserver sets the observer of the tasks. Tasks that are running when it is set are reported as
+// stopped to the previous observer.
+func SetObserver(o Observer) {
+	activeObserver.Store(&o)
+}
+
+func currentObserver() Observer {
+	if o := activeObserver.Load(); o != nil {
+		return *o
+	}
+	return noopObserver{}
+}
+
+// Track registers a task of the session sessionID. The returned context is canceled when the
+// session is closed, and done must be called once the task finished.
+func Track(ctx context.Context, sessionID, kind, name string) (context.Context, func()) {
+	return defaultRegistry.Track(ctx, sessionID, kind, name)
+}
+
+// Go runs f in a goroutine that is tracked as a task of the session sessionID.
+func Go(ctx context.Context, sessionID, kind, name string, f func(ctx context.Context)) {
+	defaultRegistry.Go(ctx, sessionID, kind, name, f)
+}
+
+// CloseSession stops the tasks of the session sessionID and returns how many were running.
+func CloseSession(sessionID string) int {
+	return defaultRegistry.CloseSession(sessionID)
+}
+
+// List returns the live tasks of the session sessionID, or of all sessions if it is empty.
+func List(sessionID string) Report {
+	return defaultRegistry.List(sessionID)
+}
+
+func (r *Registry) Track(ctx context.Context, sessionID, kind, name string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.lock.Lock()
+	r.next++
+	t := &task{
+		Task: Task{
+			ID:        r.next,
+			SessionID: sessionID,
+			Kind:      kind,
+			Name:      name,
+			Started:   time.Now(),
+		},
+		cancel: cancel,
+	}
+	r.tasks[t.ID] = t
+	r.lock.Unlock()
+	observer := currentObserver()
+	observer.TaskStarted(kind)
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			r.lock.Lock()
+			delete(r.tasks, t.ID)
+			r.lock.Unlock()
+			observer.TaskStopped(kind)
+			cancel()
+		})
+	}
+}
+
+func (r *Registry) Go(ctx context.Context, sessionID, kind, name string, f func(ctx context.Context)) {
+	ctx, done := r.Track(ctx, sessionID, kind, name)
+	go func() {
+		defer done()
+		f(ctx)
+	}()
+}
+
+func (r *Registry) CloseSession(sessionID string) int {
+	if sessionID == "" {
+		return 0
+	}
+
+	r.lock.Lock()
+	var closing []uint64
+	for id, t := range r.tasks {
+		if t.SessionID == sessionID && !t.Closing {
+			t.Closing = true
+			t.cancel()
+			closing = append(closing, id)
+		}
+	}
+	r.lock.Unlock()
+
+	if len(closing) > 0 {
+		time.AfterFunc(r.leakTimeout, func() {
+			r.reportLeaks(closing)
+		})
+	}
+	return len(closing)
+}
+
+// reportLeaks logs the tasks that are still running after their session was closed.
+func (r *Registry) reportLeaks(ids []uint64) {
+	r.lock.Lock()
+	var leaked []Task
+	for _, id := range ids {
+		if t, ok := r.tasks[id]; ok {
+			leaked = append(leaked, t.Task)
+		}
+	}
+	r.lock.Unlock()
+
+	observer := currentObserver()
+	for _, t := range leaked {
+		observer.TaskLeaked(t.Kind)
+		log.Errorf(context.Background(), "%s %q of session %s is still running %s after the session was closed",
+			t.Kind, t.Name, t.SessionID, r.leakTimeout)
+	}
+}
+
+func (r *Registry) List(sessionID string) Report {
+	r.lock.Lock()
+	bySession := map[string][]Task{}
+	for _, t := range r.tasks {
+		if sessionID == "" || t.SessionID == sessionID {
+			bySession[t.SessionID] = append(bySession[t.SessionID], t.Task)
+		}
+	}
+	r.lock.Unlock()
+
+	report := Report{
+		Sessions: []SessionTasks{},
+	}
+	for id, tasks := range bySession {
+		slices.SortFunc(tasks, func(a, b Task) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+		report.Total += len(tasks)
+		report.Sessions = append(report.Sessions, SessionTasks{
+			SessionID: id,
+			Tasks:     tasks,
+		})
+	}
+	// Sessions with the most tasks first, they are the likeliest to leak.
+	slices.SortFunc(report.Sessions, func(a, b SessionTasks) int {
+		return cmp.Or(cmp.Compare(len(b.Tasks), len(a.Tasks)), cmp.Compare(a.SessionID, b.SessionID))
+	})
+	return report
+}
@@ -0,0 +1,95 @@
+package lifecycle
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCloseSession(t *testing.T) {
+	r := NewRegistry()
+	r.leakTimeout = 10 * time.Millisecond
+
+	stopped := make(chan struct{})
+	r.Go(context.Background(), "s1", KindStream, "stream", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+	_, done := r.Track(context.Background(), "s1", KindProgress, "events")
+	r.Go(context.Background(), "s2", KindJob, "job", func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	report := r.List("")
+	if report.Total != 3 || len(report.Sessions) != 2 || report.Sessions[0].SessionID != "s1" {
+		t.Fatalf("expected 3 tasks with s1 first, got %+v", report)
+	}
+
+	if closed := r.CloseSession("s1"); closed != 2 {
+		t.Fatalf("expected 2 tasks to be closed, got %d", closed)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to stop when its session is closed")
+	}
+
+	// The tracked channel was not released yet, it is the only task of s1 left.
+	time.Sleep(50 * time.Millisecond)
+	report = r.List("s1")
+	if report.Total != 1 || !report.Sessions[0].Tasks[0].Closing {
+		t.Errorf("expected the unreleased task to be closing, got %+v", report)
+	}
+
+	done()
+	done()
+	if report := r.List("s1"); report.Total != 0 {
+		t.Errorf("expected no tasks of s1, got %+v", report)
+	}
+	if report := r.List("s2"); report.Total != 1 {
+		t.Errorf("expected the task of s2 to keep running, got %+v", report)
+	}
+}
+
+type recordingObserver struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) record(event string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.events = append(o.events, event)
+}
+
+func (o *recordingObserver) TaskStarted(kind string) { o.record("started " + kind) }
+func (o *recordingObserver) TaskStopped(kind string) { o.record("stopped " + kind) }
+func (o *recordingObserver) TaskLeaked(kind string)  { o.record("leaked " + kind) }
+
+func TestObserver(t *testing.T) {
+	r := NewRegistry()
+	r.leakTimeout = 10 * time.Millisecond
+
+	_, before := r.Track(context.Background(), "s1", KindJob, "before")
+	observer := &recordingObserver{}
+	SetObserver(observer)
+	t.Cleanup(func() {
+		activeObserver.Store(nil)
+	})
+
+	_, done := r.Track(context.Background(), "s1", KindStream, "stream")
+	before()
+	r.CloseSession("s1")
+	time.Sleep(50 * time.Millisecond)
+	done()
+
+	observer.lock.Lock()
+	defer observer.lock.Unlock()
+	// The task that started before the observer was set is not reported to it.
+	expected := []string{"started stream", "leaked stream", "stopped stream"}
+	if !slices.Equal(observer.events, expected) {
+		t.Errorf("expected %v, got %v", expected, observer.events)
+	}
+}
@@ -6,15 +6,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
 	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/llm/quota"
 	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/secrets"
 	"github.com/nanobot-ai/nanobot/pkg/types"
 )
 
@@ -80,8 +82,8 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 	if err != nil {
 		return nil, err
 	}
-	for key, value := range c.Headers {
-		httpReq.Header.Set(key, value)
+	if err := secrets.SetHeaders(ctx, httpReq, c.Headers); err != nil {
+		return nil, err
 	}
 
 	httpResp, err := http.DefaultClient.Do(httpReq)
@@ -89,16 +91,20 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		return nil, err
 	}
 	defer httpResp.Body.Close()
+	quota.Observe("anthropic", httpResp.Header)
 	if httpResp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(httpResp.Body)
-		return nil, fmt.Errorf("failed to get response from Anthropic API: %s %q", httpResp.Status, string(body))
+		return nil, apierror.FromResponse("Anthropic API", httpResp)
 	}
 
 	var (
-		lines       = bufio.NewScanner(httpResp.Body)
-		resp        Response
-		partialJSON = ""
+		lines = bufio.NewScanner(httpResp.Body)
+		resp  Response
+		// The deltas are appended to builders, concatenating them to the text of the block would
+		// copy the whole text for every delta.
+		text        strings.Builder
+		partialJSON strings.Builder
 	)
+	lines.Buffer(make([]byte, 0, 1024), 10*1024*1024)
 
 	for lines.Scan() {
 		line := lines.Text()
@@ -119,13 +125,20 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		case "message_start":
 			resp = delta.Message
 		case "content_block_start":
-			partialJSON = ""
+			text.Reset()
+			partialJSON.Reset()
+			if delta.ContentBlock.Text != nil {
+				text.WriteString(*delta.ContentBlock.Text)
+			}
 			resp.Content = append(resp.Content, delta.ContentBlock)
 		case "content_block_delta":
 			switch delta.Delta.Type {
 			case "text_delta":
 				if contentIndex >= 0 {
-					*resp.Content[contentIndex].Text += delta.Delta.Text
+					text.WriteString(delta.Delta.Text)
+					// String does not copy the builder, the text is always up to date.
+					textValue := text.String()
+					resp.Content[contentIndex].Text = &textValue
 					progress.Send(ctx, &types.CompletionProgress{
 						Model:     resp.Model,
 						Agent:     agentName,
@@ -142,7 +155,7 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 					}, opt.ProgressToken)
 				}
 			case "input_json_delta":
-				partialJSON += delta.Delta.PartialJSON
+				partialJSON.WriteString(delta.Delta.PartialJSON)
 				if contentIndex >= 0 {
 					progress.Send(ctx, &types.CompletionProgress{
 						Model:     resp.Model,
@@ -162,9 +175,9 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 				}
 			}
 		case "content_block_stop":
-			if contentIndex >= 0 && partialJSON != "" {
+			if contentIndex >= 0 && partialJSON.Len() > 0 {
 				args := map[string]any{}
-				if err := json.Unmarshal([]byte(partialJSON), &args); err != nil {
+				if err := json.Unmarshal([]byte(partialJSON.String()), &args); err != nil {
 					return nil, fmt.Errorf("failed to unmarshal function call arguments: %w", err)
 				}
 				resp.Content[contentIndex].Input = args
@@ -181,10 +194,17 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 				}, opt.ProgressToken)
 			}
 		case "message_delta":
+			// The output tokens are reported in the usage next to the delta, they are merged into
+			// the usage of message_start.
+			if resp.Usage == nil {
+				resp.Usage = &Usage{}
+			}
 			err := json.Unmarshal([]byte(body), &struct {
 				Delta *Response `json:"delta"`
+				Usage *Usage    `json:"usage"`
 			}{
 				Delta: &resp,
+				Usage: resp.Usage,
 			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to unmarshal message delta: %w", err)
@@ -203,5 +223,9 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		log.Messages(ctx, "anthropic-api", false, respData)
 	}
 
+	if resp.Usage != nil && resp.Usage.CacheReadInputTokens != nil && resp.Usage.CacheCreationInputTokens != nil {
+		log.Debugf(ctx, "anthropic prompt cache: read=%d created=%d", *resp.Usage.CacheReadInputTokens, *resp.Usage.CacheCreationInputTokens)
+	}
+
 	return &resp, nil
 }
@@ -0,0 +1,83 @@
+package anthropic
+
+import (
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/conformance"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+var provider = conformance.Provider{
+	Name: "Anthropic API",
+	Path: "/messages",
+	New: func(baseURL string) types.Completer {
+		return NewClient(Config{APIKey: "test", BaseURL: baseURL})
+	},
+	Stream: func(s *conformance.Stream, script conformance.Script) {
+		s.Event("message_start", map[string]any{
+			"type": "message_start",
+			"message": map[string]any{
+				"id":      script.ID,
+				"type":    "message",
+				"role":    "assistant",
+				"model":   script.Model,
+				"content": []any{},
+				"usage":   map[string]any{"input_tokens": script.InputTokens, "output_tokens": 0},
+			},
+		})
+
+		index := 0
+		if len(script.Text) > 0 {
+			s.Event("content_block_start", map[string]any{
+				"type":          "content_block_start",
+				"index":         index,
+				"content_block": map[string]any{"type": "text", "text": ""},
+			})
+			for _, text := range script.Text {
+				s.Event("content_block_delta", map[string]any{
+					"type":  "content_block_delta",
+					"index": index,
+					"delta": map[string]any{"type": "text_delta", "text": text},
+				})
+			}
+			s.Event("content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+			index++
+		}
+
+		for _, call := range script.ToolCalls {
+			s.Event("content_block_start", map[string]any{
+				"type":          "content_block_start",
+				"index":         index,
+				"content_block": map[string]any{"type": "tool_use", "id": call.ID, "name": call.Name, "input": map[string]any{}},
+			})
+			for _, arguments := range call.Arguments {
+				s.Event("content_block_delta", map[string]any{
+					"type":  "content_block_delta",
+					"index": index,
+					"delta": map[string]any{"type": "input_json_delta", "partial_json": arguments},
+				})
+			}
+			s.Event("content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+			index++
+		}
+
+		stopReason := "end_turn"
+		if len(script.ToolCalls) > 0 {
+			stopReason = "tool_use"
+		}
+		s.Event("message_delta", map[string]any{
+			"type":  "message_delta",
+			"delta": map[string]any{"stop_reason": stopReason},
+			"usage": map[string]any{"output_tokens": script.OutputTokens},
+		})
+		s.Event("message_stop", map[string]any{"type": "message_stop"})
+	},
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, provider)
+}
+
+func BenchmarkConformance(b *testing.B) {
+	conformance.Benchmark(b, provider)
+}
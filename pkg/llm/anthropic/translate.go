@@ -53,6 +53,22 @@ func toResponse(resp *Response, created time.Time) (*types.CompletionResponse, e
 		}
 	}
 
+	if resp.StopReason != nil {
+		result.StopReason = *resp.StopReason
+	}
+	if resp.Usage != nil {
+		result.Usage = &types.Usage{}
+		if resp.Usage.InputTokens != nil {
+			result.Usage.InputTokens = *resp.Usage.InputTokens
+		}
+		if resp.Usage.OutputTokens != nil {
+			result.Usage.OutputTokens = *resp.Usage.OutputTokens
+		}
+		if resp.Usage.CacheReadInputTokens != nil {
+			result.Usage.CachedInputTokens = *resp.Usage.CacheReadInputTokens
+		}
+	}
+
 	return result, nil
 }
 
@@ -81,6 +97,14 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 		})
 	}
 
+	// Tools are sent ahead of the system prompt and messages, so a breakpoint on the last tool
+	// caches the whole tool block across turns.
+	if len(result.Tools) > 0 {
+		result.Tools[len(result.Tools)-1].CacheControl = &CacheControl{
+			Type: "ephemeral",
+		}
+	}
+
 	if req.ToolChoice != "" {
 		switch req.ToolChoice {
 		case "auto":
@@ -91,6 +115,10 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 			result.ToolChoice = &ToolChoice{
 				Type: "none",
 			}
+		case "required":
+			result.ToolChoice = &ToolChoice{
+				Type: "any",
+			}
 		default:
 			result.ToolChoice = &ToolChoice{
 				Type: "tool",
@@ -99,6 +127,16 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 		}
 	}
 
+	if req.ParallelToolCalls != nil && !*req.ParallelToolCalls && len(result.Tools) > 0 {
+		if result.ToolChoice == nil {
+			result.ToolChoice = &ToolChoice{
+				Type: "auto",
+			}
+		}
+		// Parallel tool use can not be disabled if no tools may be used
+		result.ToolChoice.DisableParallelToolUse = result.ToolChoice.Type != "none"
+	}
+
 	for _, msg := range req.Input {
 		for _, input := range msg.Items {
 			if input.Content != nil {
@@ -150,10 +188,19 @@ func contentToContent(content []mcp.Content) (result []Content) {
 				Type: "text",
 				Text: &item.Text,
 			})
+		} else if item.Type == "image" && item.Data == "" && item.URI != "" {
+			result = append(result, Content{
+				Type: "image",
+				Source: ContentSource{
+					Type: "url",
+					URL:  item.URI,
+				},
+			})
 		} else if item.Type == "image" {
 			result = append(result, Content{
 				Type: "image",
 				Source: ContentSource{
+					Type:      "base64",
 					MediaType: item.MIMEType,
 					Data:      item.Data,
 				},
@@ -163,6 +210,7 @@ func contentToContent(content []mcp.Content) (result []Content) {
 				result = append(result, Content{
 					Type: "image",
 					Source: ContentSource{
+						Type:      "base64",
 						MediaType: item.Resource.MIMEType,
 						Data:      item.Resource.Blob,
 					},
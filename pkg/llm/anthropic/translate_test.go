@@ -0,0 +1,59 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestImageSource(t *testing.T) {
+	content := contentToContent([]mcp.Content{
+		{Type: "image", URI: "https://example.com/cat.png"},
+		{Type: "image", MIMEType: "image/png", Data: "aGk="},
+	})
+
+	want := []string{
+		`{"type":"url","url":"https://example.com/cat.png"}`,
+		`{"type":"base64","data":"aGk=","media_type":"image/png"}`,
+	}
+	if len(content) != len(want) {
+		t.Fatalf("got %d contents, want %d", len(content), len(want))
+	}
+	for i, c := range content {
+		if data, _ := json.Marshal(c.Source); c.Type != "image" || string(data) != want[i] {
+			t.Errorf("content %d: got %s %s, want image %s", i, c.Type, data, want[i])
+		}
+	}
+}
+
+func TestToolChoice(t *testing.T) {
+	tools := []types.ToolUseDefinition{{Name: "search", Parameters: []byte(`{"type":"object"}`)}}
+	for _, test := range []struct {
+		toolChoice string
+		parallel   bool
+		tools      []types.ToolUseDefinition
+		want       string
+	}{
+		{"", true, tools, `null`},
+		{"required", true, tools, `{"type":"any"}`},
+		{"search", true, tools, `{"type":"tool","name":"search"}`},
+		{"", false, tools, `{"type":"auto","disable_parallel_tool_use":true}`},
+		{"required", false, tools, `{"type":"any","disable_parallel_tool_use":true}`},
+		{"none", false, tools, `{"type":"none"}`},
+		{"", false, nil, `null`},
+	} {
+		req, err := toRequest(&types.CompletionRequest{
+			ToolChoice:        test.toolChoice,
+			ParallelToolCalls: &test.parallel,
+			Tools:             test.tools,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := json.Marshal(req.ToolChoice); string(data) != test.want {
+			t.Errorf("%q, parallel %v, %d tools: got %s, want %s", test.toolChoice, test.parallel, len(test.tools), data, test.want)
+		}
+	}
+}
@@ -41,8 +41,8 @@ type ServerToolUse struct {
 }
 
 type ToolChoice struct {
-	// Type is either "auto", "tool", or "none"
-	Type                   string
+	// Type is either "auto", "any", "tool", or "none"
+	Type                   string `json:"type"`
 	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
 	Name                   string `json:"name,omitempty"`
 }
@@ -76,19 +76,25 @@ type ContentSource struct {
 	Type string `json:"type"`
 
 	// Type = base64
-	Data      string `json:"data"`
-	MediaType string `json:"media_type"`
+	Data      string `json:"data,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
 
 	// Type = url
-	URL string `json:"url"`
+	URL string `json:"url,omitempty"`
 }
 
 type CustomTool struct {
-	Type        string          `json:"type,omitempty"`
-	Name        string          `json:"name,omitempty"`
-	InputSchema json.RawMessage `json:"input_schema,omitzero"`
-	Description string          `json:"description,omitempty"`
-	Attributes  map[string]any  `json:"-"`
+	Type         string          `json:"type,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	InputSchema  json.RawMessage `json:"input_schema,omitzero"`
+	Description  string          `json:"description,omitempty"`
+	CacheControl *CacheControl   `json:"cache_control,omitempty"`
+	Attributes   map[string]any  `json:"-"`
+}
+
+// CacheControl marks the end of a prompt prefix that Anthropic should cache.
+type CacheControl struct {
+	Type string `json:"type"`
 }
 
 func (c *CustomTool) UnmarshalJSON(data []byte) error {
@@ -106,6 +112,7 @@ func (c *CustomTool) UnmarshalJSON(data []byte) error {
 	delete(c.Attributes, "input_schema")
 	delete(c.Attributes, "strict")
 	delete(c.Attributes, "description")
+	delete(c.Attributes, "cache_control")
 	c.Type = ""
 
 	return nil
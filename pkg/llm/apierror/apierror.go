@@ -0,0 +1,54 @@
+package apierror
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+)
+
+// Error is returned by the LLM provider clients when the API responds with a non-successful
+// HTTP status.
+type Error struct {
+	API    string
+	Code   int
+	Status string
+	Body   string
+}
+
+// FromResponse reads the body of the failed response and wraps it in an Error.
+func FromResponse(api string, resp *http.Response) *Error {
+	body, _ := io.ReadAll(resp.Body)
+	return &Error{
+		API:    api,
+		Code:   resp.StatusCode,
+		Status: resp.Status,
+		Body:   string(body),
+	}
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("failed to get response from %s: %s %q", e.API, e.Status, e.Body)
+	if hint := e.Hint(); hint != "" {
+		msg += ": " + hint
+	}
+	return msg
+}
+
+// RPCError returns the error with its classification as structured data, for MCP clients such as
+// the UI to show the hint separately from the raw provider response.
+func (e *Error) RPCError() *mcp.RPCError {
+	rpcErr := mcp.ErrRPCInternal.WithMessage("%s", e.Error())
+	rpcErr.DataObject = map[string]any{
+		"api":    e.API,
+		"status": e.Code,
+		"kind":   e.Kind(),
+		"hint":   e.Hint(),
+	}
+	return rpcErr.RPCError()
+}
+
+func (e *Error) StatusCode() int {
+	return e.Code
+}
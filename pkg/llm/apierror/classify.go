@@ -0,0 +1,102 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Kind classifies a provider error by its cause, so that users get a remediation hint instead of
+// only the raw response of the provider.
+type Kind string
+
+const (
+	KindUnknown           Kind = ""
+	KindAuthentication    Kind = "authentication"
+	KindInvalidDeployment Kind = "invalid_deployment"
+	KindModelNotFound     Kind = "model_not_found"
+	KindContentPolicy     Kind = "content_policy"
+	KindContextLength     Kind = "context_length_exceeded"
+	KindQuotaExceeded     Kind = "quota_exceeded"
+	KindRateLimited       Kind = "rate_limited"
+	KindOverloaded        Kind = "overloaded"
+)
+
+var hints = map[Kind]string{
+	KindAuthentication:    "The API key was rejected. Check that OPENAI_API_KEY or ANTHROPIC_API_KEY is set to a valid key.",
+	KindInvalidDeployment: "The Azure OpenAI deployment does not exist. Make sure the model name matches a deployment name of the Azure resource and that AZURE_OPENAI_API_VERSION is supported.",
+	KindModelNotFound:     "The model does not exist or the API key has no access to it. Check the model of the agent or the --default-model flag.",
+	KindContentPolicy:     "The request or response was blocked by the content policy of the provider. Rephrase the request or remove the content that triggered the filter.",
+	KindContextLength:     "The conversation is too long for the model. Start a new thread, send less content, or use a model with a larger context window.",
+	KindQuotaExceeded:     "The provider account has run out of quota or credits. Check the plan and billing details of the API key, or switch to another key or model.",
+	KindRateLimited:       "Too many requests were sent to the provider. Wait a moment and retry, or ask the provider for a higher rate limit.",
+	KindOverloaded:        "The provider is temporarily overloaded. Retry later or switch to another model.",
+}
+
+// Kind returns the classification of the error, or KindUnknown if the cause is not recognized.
+func (e *Error) Kind() Kind {
+	var (
+		code    = strings.ToLower(e.providerCode())
+		message = strings.ToLower(e.Body)
+	)
+
+	switch {
+	case e.Code == http.StatusUnauthorized || code == "invalid_api_key" || code == "authentication_error":
+		return KindAuthentication
+	case code == "deploymentnotfound" || strings.Contains(message, "deployment for this resource does not exist"):
+		return KindInvalidDeployment
+	case code == "model_not_found" ||
+		(e.Code == http.StatusNotFound && strings.Contains(message, "model")):
+		return KindModelNotFound
+	case code == "content_filter" || code == "content_policy_violation" ||
+		strings.Contains(message, "responsibleaipolicyviolation") ||
+		strings.Contains(message, "content management policy"):
+		return KindContentPolicy
+	case code == "context_length_exceeded" ||
+		strings.Contains(message, "maximum context length") ||
+		strings.Contains(message, "prompt is too long"):
+		return KindContextLength
+	case code == "insufficient_quota" || strings.Contains(message, "credit balance is too low"):
+		return KindQuotaExceeded
+	case e.Code == http.StatusTooManyRequests || code == "rate_limit_exceeded" || code == "rate_limit_error":
+		return KindRateLimited
+	case e.Code == http.StatusServiceUnavailable || e.Code == 529 || code == "overloaded_error":
+		return KindOverloaded
+	default:
+		return KindUnknown
+	}
+}
+
+// Hint returns how the user can fix the error, or an empty string if the error is not classified.
+func (e *Error) Hint() string {
+	return hints[e.Kind()]
+}
+
+// ForModel reports whether the model calling the failed agent can work around the error itself,
+// for example by rephrasing or shortening its request. Other errors can only be fixed by the user.
+func (e *Error) ForModel() bool {
+	switch e.Kind() {
+	case KindContentPolicy, KindContextLength:
+		return true
+	default:
+		return false
+	}
+}
+
+// providerCode returns the error code from the body of the response. OpenAI and Azure put it in
+// error.code, Anthropic in error.type.
+func (e *Error) providerCode() string {
+	var body struct {
+		Error struct {
+			Code any    `json:"code"`
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(e.Body), &body); err != nil {
+		return ""
+	}
+	if code, ok := body.Error.Code.(string); ok && code != "" {
+		return code
+	}
+	return body.Error.Type
+}
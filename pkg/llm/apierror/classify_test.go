@@ -0,0 +1,39 @@
+package apierror
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		body string
+		kind Kind
+	}{
+		{"openai quota", http.StatusTooManyRequests, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`, KindQuotaExceeded},
+		{"openai rate limit", http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached","code":"rate_limit_exceeded"}}`, KindRateLimited},
+		{"azure deployment", http.StatusNotFound, `{"error":{"code":"DeploymentNotFound","message":"The API deployment for this resource does not exist."}}`, KindInvalidDeployment},
+		{"azure content filter", http.StatusBadRequest, `{"error":{"code":"content_filter","message":"The response was filtered due to the prompt triggering Azure OpenAI's content management policy."}}`, KindContentPolicy},
+		{"openai model", http.StatusNotFound, `{"error":{"message":"The model 'gpt-9' does not exist","code":"model_not_found"}}`, KindModelNotFound},
+		{"anthropic model", http.StatusNotFound, `{"type":"error","error":{"type":"not_found_error","message":"model: claude-9"}}`, KindModelNotFound},
+		{"anthropic prompt too long", http.StatusBadRequest, `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`, KindContextLength},
+		{"anthropic overloaded", 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, KindOverloaded},
+		{"unauthorized", http.StatusUnauthorized, `not json`, KindAuthentication},
+		{"unknown", http.StatusBadRequest, `{"error":{"message":"bad"}}`, KindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &Error{API: "test", Code: tt.code, Body: tt.body}
+			if kind := err.Kind(); kind != tt.kind {
+				t.Errorf("expected %q, got %q", tt.kind, kind)
+			}
+			if tt.kind != KindUnknown && !strings.Contains(err.Error(), err.Hint()) {
+				t.Errorf("expected hint in error %q", err.Error())
+			}
+		})
+	}
+}
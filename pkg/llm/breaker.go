@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/metrics"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// ErrProviderUnavailable is matched by errors.Is for every ProviderUnavailableError.
+var ErrProviderUnavailable = errors.New("LLM provider unavailable")
+
+// ProviderUnavailableError is returned without calling the provider while its circuit breaker is
+// open.
+type ProviderUnavailableError struct {
+	Provider   string
+	RetryAfter time.Duration
+	LastError  error
+}
+
+func (e *ProviderUnavailableError) Error() string {
+	msg := fmt.Sprintf("LLM provider %s is unavailable after repeated failures, try again in %s",
+		e.Provider, e.RetryAfter.Round(time.Second))
+	if e.LastError != nil {
+		msg += ": " + e.LastError.Error()
+	}
+	return msg
+}
+
+func (e *ProviderUnavailableError) Is(target error) bool {
+	return target == ErrProviderUnavailable
+}
+
+func (e *ProviderUnavailableError) Unwrap() error {
+	return e.LastError
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit. Default 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a probe request is let through.
+	// Default 30s.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of concurrent requests let through while probing. Default 1.
+	HalfOpenProbes int
+}
+
+func (b BreakerConfig) Merge(other BreakerConfig) (result BreakerConfig) {
+	result.FailureThreshold = complete.Last(b.FailureThreshold, other.FailureThreshold)
+	result.OpenTimeout = complete.Last(b.OpenTimeout, other.OpenTimeout)
+	result.HalfOpenProbes = complete.Last(b.HalfOpenProbes, other.HalfOpenProbes)
+	return
+}
+
+// CircuitBreaker returns a middleware that tracks failures per provider and, once a provider has
+// failed FailureThreshold times in a row, fails requests to it immediately with a
+// ProviderUnavailableError instead of waiting on it. After OpenTimeout a limited number of probe
+// requests are let through; the circuit closes again on the first success.
+func CircuitBreaker(cfg BreakerConfig) Middleware {
+	cfg = BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenProbes:   1,
+	}.Merge(cfg)
+
+	var (
+		lock     sync.Mutex
+		breakers = map[string]*breaker{}
+	)
+
+	return func(next types.Completer) types.Completer {
+		return CompleterFunc(func(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+			provider := providerName(req.Model)
+
+			lock.Lock()
+			b, ok := breakers[provider]
+			if !ok {
+				b = &breaker{cfg: cfg, provider: provider}
+				breakers[provider] = b
+			}
+			lock.Unlock()
+
+			probe, err := b.allow()
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next.Complete(ctx, req, opts...)
+			b.done(ctx, probe, isProviderFailure(ctx, err), err)
+			return resp, err
+		})
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+type breaker struct {
+	cfg      BreakerConfig
+	provider string
+
+	lock     sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probes   int
+	lastErr  error
+}
+
+// allow reports whether a request may be sent to the provider, and whether it took a probe slot
+// because the circuit is half-open.
+func (b *breaker) allow() (probe bool, _ error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if wait := b.cfg.OpenTimeout - time.Since(b.openedAt); wait > 0 {
+			return false, &ProviderUnavailableError{
+				Provider:   b.provider,
+				RetryAfter: wait,
+				LastError:  b.lastErr,
+			}
+		}
+		b.setState(breakerHalfOpen)
+		fallthrough
+	case breakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return false, &ProviderUnavailableError{
+				Provider:  b.provider,
+				LastError: b.lastErr,
+			}
+		}
+		b.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+// done records the outcome of a request. Only probes release a probe slot, requests that started
+// while the circuit was closed did not take one.
+func (b *breaker) done(ctx context.Context, probe, failed bool, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if probe && b.state == breakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+	if ctx.Err() != nil {
+		// The caller went away, which says nothing about the provider, so the probe slot is released
+		// without changing the state.
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		if b.state != breakerClosed {
+			log.Infof(ctx, "circuit breaker for LLM provider %s closed", b.provider)
+			b.setState(breakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	b.lastErr = err
+	if b.state == breakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		log.Errorf(ctx, "circuit breaker for LLM provider %s opened after %d failures: %v", b.provider, b.failures, err)
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+	}
+}
+
+func (b *breaker) setState(state breakerState) {
+	b.state = state
+	if state != breakerHalfOpen {
+		b.probes = 0
+	}
+	metrics.SetBreakerState(b.provider, state.String())
+}
+
+// isProviderFailure reports if err indicates the provider itself is unhealthy, as opposed to the
+// request being invalid or the caller going away.
+func isProviderFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, ErrDegenerateOutput) {
+		return false
+	}
+	var coder metrics.StatusCoder
+	if errors.As(err, &coder) {
+		code := coder.StatusCode()
+		return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+func providerName(model string) string {
+	if strings.HasPrefix(model, "claude") {
+		return "anthropic"
+	}
+	return "openai"
+}
@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		calls   int
+		failing = true
+		ctx     = context.Background()
+		req     = types.CompletionRequest{Model: "gpt-4.1"}
+	)
+
+	completer := CircuitBreaker(BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+	})(CompleterFunc(func(_ context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+		if req.Model != "gpt-4.1" {
+			return &types.CompletionResponse{}, nil
+		}
+		calls++
+		if failing {
+			return nil, &apierror.Error{Code: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+		}
+		return &types.CompletionResponse{}, nil
+	}))
+
+	for range 2 {
+		if _, err := completer.Complete(ctx, req); errors.Is(err, ErrProviderUnavailable) {
+			t.Fatal("circuit opened before reaching the threshold")
+		}
+	}
+
+	_, err := completer.Complete(ctx, req)
+	if !errors.Is(err, ErrProviderUnavailable) || calls != 2 {
+		t.Fatalf("expected the open circuit to fail fast, got err=%v calls=%d", err, calls)
+	}
+
+	// Other providers are unaffected.
+	if _, err := completer.Complete(ctx, types.CompletionRequest{Model: "claude-sonnet-4"}); err != nil {
+		t.Fatalf("expected other provider to be unaffected, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	failing = false
+	if _, err := completer.Complete(ctx, req); err != nil {
+		t.Fatalf("expected half-open probe to succeed, got %v", err)
+	}
+	if _, err := completer.Complete(ctx, req); err != nil {
+		t.Fatalf("expected circuit to be closed, got %v", err)
+	}
+}
+
+func TestCircuitBreakerCanceledProbe(t *testing.T) {
+	var (
+		ctx = context.Background()
+		req = types.CompletionRequest{Model: "gpt-4.1"}
+	)
+
+	completer := CircuitBreaker(BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+	})(CompleterFunc(func(ctx context.Context, _ types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &apierror.Error{Code: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+	}))
+
+	for range 2 {
+		_, _ = completer.Complete(ctx, req)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	// The probe of a caller that went away neither closes the circuit nor keeps the probe slot.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := completer.Complete(canceled, req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the probe to be canceled, got %v", err)
+	}
+
+	// The circuit is still half-open, so the next probe is let through and its failure opens the
+	// circuit again at once.
+	if _, err := completer.Complete(ctx, req); errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("expected a probe to be let through, got %v", err)
+	}
+	if _, err := completer.Complete(ctx, req); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("expected the failed probe to open the circuit, got %v", err)
+	}
+}
+
+func TestCircuitBreakerProbeSlots(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		started = make(chan string, 2)
+		release = make(chan struct{})
+	)
+
+	completer := CircuitBreaker(BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+	})(CompleterFunc(func(ctx context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+		switch req.Agent {
+		case "slow":
+			started <- req.Agent
+			<-ctx.Done()
+			return nil, ctx.Err()
+		case "probe":
+			started <- req.Agent
+			<-release
+			return &types.CompletionResponse{}, nil
+		}
+		return nil, &apierror.Error{Code: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+	}))
+
+	// A request that starts while the circuit is closed and ends while it is half-open.
+	slowCtx, cancelSlow := context.WithCancel(ctx)
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		_, _ = completer.Complete(slowCtx, types.CompletionRequest{Model: "gpt-4.1", Agent: "slow"})
+	}()
+	<-started
+
+	for range 2 {
+		_, _ = completer.Complete(ctx, types.CompletionRequest{Model: "gpt-4.1"})
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	probeDone := make(chan error)
+	go func() {
+		_, err := completer.Complete(ctx, types.CompletionRequest{Model: "gpt-4.1", Agent: "probe"})
+		probeDone <- err
+	}()
+	<-started
+
+	cancelSlow()
+	<-slowDone
+
+	// The slow request did not take the probe slot, so it must not release it.
+	if _, err := completer.Complete(ctx, types.CompletionRequest{Model: "gpt-4.1"}); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("expected only one probe while half-open, got %v", err)
+	}
+
+	close(release)
+	if err := <-probeDone; err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+}
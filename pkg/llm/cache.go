@@ -0,0 +1,274 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/metrics"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// CacheBackend stores cached completions. Get reports false if there is no live entry for key.
+type CacheBackend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+type CacheConfig struct {
+	Backend CacheBackend
+	// TTL is how long completions are cached unless the agent sets its own TTL. Default 1h.
+	TTL time.Duration
+}
+
+func (c CacheConfig) Merge(other CacheConfig) (result CacheConfig) {
+	result.Backend = complete.Last(c.Backend, other.Backend)
+	result.TTL = complete.Last(c.TTL, other.TTL)
+	return
+}
+
+// cacheEntry is a cached completion together with the progress events that were sent while it
+// was generated, so that a cache hit streams the same way as the original completion.
+type cacheEntry struct {
+	Response *types.CompletionResponse `json:"response"`
+	Progress []json.RawMessage         `json:"progress,omitempty"`
+}
+
+// Cache returns a middleware that answers identical completion requests from the backend instead
+// of calling the provider again. Only successful completions are cached. Agents can change the TTL
+// with cacheTTL, a TTL of zero disables the cache for the agent. Requests whose content is
+// suppressed, those of ephemeral sessions, bypass the cache.
+func Cache(cfg CacheConfig) Middleware {
+	cfg = CacheConfig{
+		TTL: time.Hour,
+	}.Merge(cfg)
+
+	return func(next types.Completer) types.Completer {
+		return CompleterFunc(func(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+			ttl := cfg.TTL
+			if req.CacheTTL != nil {
+				ttl = *req.CacheTTL
+			}
+			// The completions of ephemeral sessions are neither served from nor written to the
+			// cache, they must not leave their content behind.
+			if cfg.Backend == nil || ttl <= 0 || log.ContentSuppressed(ctx) {
+				return next.Complete(ctx, req, opts...)
+			}
+
+			key := cacheKey(req)
+			progressToken := complete.Complete(opts...).ProgressToken
+
+			data, ok, err := cfg.Backend.Get(ctx, key)
+			if err != nil {
+				log.Errorf(ctx, "failed to read cached completion: %v", err)
+			} else if ok {
+				var entry cacheEntry
+				if err := json.Unmarshal(data, &entry); err != nil || entry.Response == nil {
+					log.Errorf(ctx, "failed to decode cached completion %s: %v", key, err)
+				} else {
+					metrics.ObserveCacheLookup(req.Agent, req.Model, true)
+					return replayCacheEntry(ctx, entry, progressToken), nil
+				}
+			}
+			metrics.ObserveCacheLookup(req.Agent, req.Model, false)
+
+			var (
+				entry        cacheEntry
+				progressLock sync.Mutex
+			)
+			ctx = progress.WithListener(ctx, func(p *types.CompletionProgress) {
+				// Events are encoded right away, providers reuse them for the next event.
+				data, err := json.Marshal(p)
+				if err != nil {
+					return
+				}
+				progressLock.Lock()
+				defer progressLock.Unlock()
+				entry.Progress = append(entry.Progress, data)
+			})
+
+			resp, err := next.Complete(ctx, req, opts...)
+			if err != nil || resp == nil {
+				return resp, err
+			}
+
+			progressLock.Lock()
+			entry.Response = resp
+			data, err = json.Marshal(entry)
+			progressLock.Unlock()
+			if err == nil {
+				err = cfg.Backend.Set(ctx, key, data, ttl)
+			}
+			if err != nil {
+				log.Errorf(ctx, "failed to cache completion: %v", err)
+			}
+			return resp, nil
+		})
+	}
+}
+
+// replayCacheEntry sends the cached progress events and returns the cached response. The response
+// gets a new message ID so that it is not confused with the original in the history of a session,
+// and no usage as the provider was not billed for it.
+func replayCacheEntry(ctx context.Context, entry cacheEntry, progressToken any) *types.CompletionResponse {
+	var (
+		resp  = entry.Response
+		oldID = resp.Output.ID
+		now   = time.Now()
+	)
+	resp.Output.ID = uuid.String()
+	resp.Output.Created = &now
+	resp.Usage = nil
+
+	if progressToken == nil {
+		return resp
+	}
+	for _, data := range entry.Progress {
+		var event types.CompletionProgress
+		if err := json.Unmarshal(data, &event); err != nil {
+			continue
+		}
+		if event.MessageID == oldID {
+			event.MessageID = resp.Output.ID
+		}
+		progress.Send(ctx, &event, progressToken)
+	}
+	return resp
+}
+
+// cacheKey is the hash of the parts of the request that determine the completion. The random IDs
+// and timestamps of the messages, and fields that only describe the caller are ignored.
+func cacheKey(req types.CompletionRequest) string {
+	req.Agent = ""
+	req.ThreadName = ""
+	req.NewThread = false
+	req.Metadata = nil
+
+	input := make([]types.Message, 0, len(req.Input))
+	for _, msg := range req.Input {
+		msg.ID = ""
+		msg.Created = nil
+		items := make([]types.CompletionItem, 0, len(msg.Items))
+		for _, item := range msg.Items {
+			// CompletionItem generates a random ID when marshalled without one.
+			item.ID = "-"
+			items = append(items, item)
+		}
+		msg.Items = items
+		input = append(input, msg)
+	}
+	req.Input = input
+
+	data, _ := json.Marshal(req)
+	hash := sha256.Sum256(canonicalJSON(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// MemoryCache is a CacheBackend that keeps the most recently used entries in memory.
+type MemoryCache struct {
+	size int
+
+	lock    sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns a cache that holds at most size entries, evicting the least recently used.
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{
+		size:    max(size, 1),
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (m *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expires) {
+		m.order.Remove(elem)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+func (m *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	entry := &memoryCacheEntry{
+		key:     key,
+		value:   value,
+		expires: time.Now().Add(ttl),
+	}
+	if elem, ok := m.entries[key]; ok {
+		elem.Value = entry
+		m.order.MoveToFront(elem)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(entry)
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// RedisCache is a CacheBackend that stores entries in Redis, so that the cache is shared by all
+// replicas.
+type RedisCache struct {
+	client *redis.Client
+}
+
+const redisCachePrefix = "nanobot:completion:"
+
+// NewRedisCache connects to the Redis server at url, in the form redis://[user:password@]host:port/db.
+func NewRedisCache(url string) (*RedisCache, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+	return &RedisCache{
+		client: redis.NewClient(opts),
+	}, nil
+}
+
+func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := r.client.Get(ctx, redisCachePrefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, redisCachePrefix+key, value, ttl).Err()
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestCache(t *testing.T) {
+	var (
+		calls int
+		ctx   = context.Background()
+	)
+
+	completer := Cache(CacheConfig{
+		Backend: NewMemoryCache(10),
+	})(CompleterFunc(func(_ context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+		calls++
+		return &types.CompletionResponse{
+			Output: types.Message{
+				ID:   "resp-1",
+				Role: "assistant",
+				Items: []types.CompletionItem{
+					{ID: "item-1", Content: &mcp.Content{Type: "text", Text: "hello"}},
+				},
+			},
+			Usage: &types.Usage{InputTokens: 10, OutputTokens: 1},
+		}, nil
+	}))
+
+	request := func(id string) types.CompletionRequest {
+		return types.CompletionRequest{
+			Model: "gpt-4.1",
+			Input: []types.Message{{
+				ID:    id,
+				Role:  "user",
+				Items: []types.CompletionItem{{ID: id, Content: &mcp.Content{Type: "text", Text: "hi"}}},
+			}},
+		}
+	}
+
+	if _, err := completer.Complete(ctx, request("a")); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := completer.Complete(ctx, request("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected identical requests with different message IDs to hit the cache, got %d calls", calls)
+	}
+	if resp.Output.ID == "resp-1" || resp.Usage != nil || resp.Output.Items[0].Content.Text != "hello" {
+		t.Errorf("unexpected cached response %+v", resp)
+	}
+
+	changed := request("c")
+	changed.Temperature = &[]json.Number{"0.5"}[0]
+	if _, err := completer.Complete(ctx, changed); err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := request("d")
+	disabled.CacheTTL = new(time.Duration)
+	if _, err := completer.Complete(ctx, disabled); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("expected changed and uncached requests to call the provider, got %d calls", calls)
+	}
+}
+
+// countingCache counts the reads and writes of the backend.
+type countingCache struct {
+	*MemoryCache
+	gets, sets int
+}
+
+func (c *countingCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.gets++
+	return c.MemoryCache.Get(ctx, key)
+}
+
+func (c *countingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.sets++
+	return c.MemoryCache.Set(ctx, key, value, ttl)
+}
+
+func TestCacheEphemeral(t *testing.T) {
+	var (
+		calls   int
+		backend = &countingCache{MemoryCache: NewMemoryCache(10)}
+	)
+	completer := Cache(CacheConfig{
+		Backend: backend,
+	})(CompleterFunc(func(context.Context, types.CompletionRequest, ...types.CompletionOptions) (*types.CompletionResponse, error) {
+		calls++
+		return &types.CompletionResponse{
+			Output: types.Message{Role: "assistant", Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: "hello"}}}},
+		}, nil
+	}))
+	req := types.CompletionRequest{
+		Model: "gpt-4.1",
+		Input: []types.Message{{Role: "user", Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: "hi"}}}}},
+	}
+
+	// The client suppresses the content of the requests of ephemeral sessions.
+	ctx := log.WithoutContent(context.Background())
+	for range 2 {
+		if _, err := completer.Complete(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 || backend.gets != 0 || backend.sets != 0 {
+		t.Errorf("expected ephemeral requests to bypass the cache, got %d calls, %d gets, %d sets", calls, backend.gets, backend.sets)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+
+	_ = cache.Set(ctx, "a", []byte("a"), time.Hour)
+	_ = cache.Set(ctx, "b", []byte("b"), time.Hour)
+	_, _, _ = cache.Get(ctx, "a")
+	_ = cache.Set(ctx, "c", []byte("c"), time.Hour)
+
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+	if _, ok, _ := cache.Get(ctx, "a"); !ok {
+		t.Error("expected recently used entry to be kept")
+	}
+
+	_ = cache.Set(ctx, "d", []byte("d"), -time.Second)
+	if _, ok, _ := cache.Get(ctx, "d"); ok {
+		t.Error("expected expired entry to be missing")
+	}
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/chaos"
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// maxTruncatedChunks is the most chunks a truncated stream delivers before it is cut off.
+const maxTruncatedChunks = 20
+
+// errChaosTruncated is the cause of a stream that chaos mode cut off.
+var errChaosTruncated = fmt.Errorf("stream truncated by chaos mode: %w", io.ErrUnexpectedEOF)
+
+// Chaos returns a middleware that injects the provider failures of the config: rate limit errors,
+// slow chunks, and truncated streams. It is added last, so the failures look like those of the
+// provider to the other middleware.
+func Chaos(cfg chaos.Config) Middleware {
+	return func(next types.Completer) types.Completer {
+		return CompleterFunc(func(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+			if chaos.Hit(cfg.RateLimit) {
+				log.Infof(ctx, "chaos mode: failing completion of model %s with a rate limit error", req.Model)
+				return nil, &apierror.Error{
+					API:    "chaos",
+					Code:   http.StatusTooManyRequests,
+					Status: "429 Too Many Requests",
+					Body:   `{"error":{"type":"rate_limit_error","message":"injected by chaos mode"}}`,
+				}
+			}
+
+			if chaos.Hit(cfg.SlowChunks) {
+				log.Infof(ctx, "chaos mode: delaying the chunks of model %s by %s", req.Model, cfg.Delay())
+				done := ctx.Done()
+				ctx = progress.WithFilter(ctx, func(*types.CompletionProgress) bool {
+					select {
+					case <-done:
+					case <-time.After(cfg.Delay()):
+					}
+					return true
+				})
+			}
+
+			if !chaos.Hit(cfg.Truncate) {
+				return next.Complete(ctx, req, opts...)
+			}
+
+			var (
+				lock   sync.Mutex
+				chunks int
+				cutAt  = 1 + rand.IntN(maxTruncatedChunks)
+			)
+			log.Infof(ctx, "chaos mode: truncating the stream of model %s after %d chunks", req.Model, cutAt)
+
+			ctx, cancel := context.WithCancelCause(ctx)
+			defer cancel(nil)
+
+			ctx = progress.WithFilter(ctx, func(*types.CompletionProgress) bool {
+				lock.Lock()
+				defer lock.Unlock()
+				if chunks >= cutAt {
+					cancel(errChaosTruncated)
+					return false
+				}
+				chunks++
+				return true
+			})
+
+			// A response that ends before the cut is truncated at its end.
+			if _, err := next.Complete(ctx, req, opts...); err != nil && context.Cause(ctx) != errChaosTruncated {
+				return nil, err
+			}
+			return nil, errChaosTruncated
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/chaos"
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/llm/replay"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestChaos(t *testing.T) {
+	_, err := Chaos(chaos.Config{RateLimit: 1})(replay.NewScripted(replay.Text("fine"))).
+		Complete(context.Background(), types.CompletionRequest{})
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.Kind() != apierror.KindRateLimited {
+		t.Fatalf("expected a rate limit error, got %v", err)
+	}
+
+	_, err = Chaos(chaos.Config{Truncate: 1})(replay.NewScripted(replay.Text("fine"))).
+		Complete(context.Background(), types.CompletionRequest{})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a truncated stream, got %v", err)
+	}
+
+	resp, err := Chaos(chaos.Config{ToolTimeout: 1})(replay.NewScripted(replay.Text("fine"))).
+		Complete(context.Background(), types.CompletionRequest{})
+	if err != nil || resp.Output.Items[0].Content.Text != "fine" {
+		t.Fatalf("expected completions to pass through, got %v %v", resp, err)
+	}
+}
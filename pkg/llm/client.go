@@ -3,15 +3,23 @@ package llm
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/errreport"
+	"github.com/nanobot-ai/nanobot/pkg/events"
 	"github.com/nanobot-ai/nanobot/pkg/llm/anthropic"
 	"github.com/nanobot-ai/nanobot/pkg/llm/completions"
 	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/llm/replay"
 	"github.com/nanobot-ai/nanobot/pkg/llm/responses"
+	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/metrics"
+	"github.com/nanobot-ai/nanobot/pkg/telemetry"
 	"github.com/nanobot-ai/nanobot/pkg/types"
 	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var _ types.Completer = (*Client)(nil)
@@ -20,20 +28,25 @@ type Config struct {
 	DefaultModel string
 	Responses    responses.Config
 	Anthropic    anthropic.Config
+	Replay       replay.Config
+	Middleware   []Middleware
 }
 
 func NewClient(cfg Config) *Client {
-	return &Client{
+	c := &Client{
 		useCompletions: cfg.Responses.ChatCompletionAPI,
 		defaultModel:   cfg.DefaultModel,
 		completions: completions.NewClient(completions.Config{
-			APIKey:  cfg.Responses.APIKey,
-			BaseURL: cfg.Responses.BaseURL,
-			Headers: cfg.Responses.Headers,
+			APIKey:     cfg.Responses.APIKey,
+			BaseURL:    cfg.Responses.BaseURL,
+			Headers:    cfg.Responses.Headers,
+			APIVersion: cfg.Responses.APIVersion,
 		}),
 		responses: responses.NewClient(cfg.Responses),
 		anthropic: anthropic.NewClient(cfg.Anthropic),
 	}
+	c.provider = Chain(replay.Wrap(cfg.Replay, CompleterFunc(c.dispatch)), cfg.Middleware...)
+	return c
 }
 
 type Client struct {
@@ -42,6 +55,7 @@ type Client struct {
 	completions    *completions.Client
 	responses      *responses.Client
 	anthropic      *anthropic.Client
+	provider       types.Completer
 }
 
 func (c *Client) handleAssistantRolesFromTools(req types.CompletionRequest) (_ types.CompletionRequest, resp *types.CompletionResponse) {
@@ -97,10 +111,14 @@ func (c *Client) handleAssistantRolesFromTools(req types.CompletionRequest) (_ t
 }
 
 func (c Client) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (ret *types.CompletionResponse, _ error) {
+	defer errreport.Recover(ctx)
 	defer func() {
 		if ret != nil && ret.Agent == "" {
 			ret.Agent = req.Agent
 		}
+		if ret != nil && ret.Output.Agent == "" {
+			ret.Output.Agent = ret.Agent
+		}
 	}()
 	if req.Model == "default" || req.Model == "" {
 		req.Model = c.defaultModel
@@ -111,6 +129,12 @@ func (c Client) Complete(ctx context.Context, req types.CompletionRequest, opts
 		return resp, nil
 	}
 
+	req = optimizeForPromptCache(req)
+
+	if types.IsEphemeral(mcp.SessionFromContext(ctx)) {
+		ctx = log.WithoutContent(ctx)
+	}
+
 	opt := complete.Complete(opts...)
 	if opt.ProgressToken != nil && len(req.Input) > 0 {
 		lastMsg := req.Input[len(req.Input)-1]
@@ -126,10 +150,56 @@ func (c Client) Complete(ctx context.Context, req types.CompletionRequest, opts
 		}
 	}
 
+	ctx, span := telemetry.Start(ctx, "llm/complete "+req.Model, append(telemetry.SessionAttributes(ctx),
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.request.model", req.Model),
+		attribute.String("nanobot.agent", req.Agent),
+		attribute.Int("gen_ai.request.tool_count", len(req.Tools)))...)
+	start := time.Now()
+	resp, err := c.provider.Complete(ctx, req, opts...)
+	var inputTokens, outputTokens int
+	if resp != nil && resp.Usage != nil {
+		inputTokens, outputTokens = resp.Usage.InputTokens, resp.Usage.OutputTokens
+	}
+	metrics.ObserveCompletion(req.Agent, req.Model, time.Since(start), inputTokens, outputTokens, err)
+	if resp != nil {
+		types.RecordUsage(ctx, resp.Usage)
+	}
+	errreport.Provider(ctx, req.Agent, req.Model, err)
+	events.Provider(ctx, req.Agent, req.Model, err)
+	if resp != nil {
+		span.SetAttributes(attribute.String("gen_ai.response.model", resp.Model))
+		if resp.StopReason != "" {
+			span.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{resp.StopReason}))
+		}
+		if resp.Usage != nil {
+			span.SetAttributes(
+				attribute.Int("gen_ai.usage.input_tokens", resp.Usage.InputTokens),
+				attribute.Int("gen_ai.usage.output_tokens", resp.Usage.OutputTokens),
+				attribute.Int("gen_ai.usage.cached_input_tokens", resp.Usage.CachedInputTokens),
+				attribute.Int("gen_ai.usage.reasoning_tokens", resp.Usage.ReasoningTokens))
+		}
+	}
+	if telemetry.RecordContent(ctx) {
+		span.SetAttributes(contentAttributes(req, resp)...)
+	}
+	telemetry.End(span, err)
+	return resp, err
+}
+
+func (c *Client) dispatch(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	// An API selected by the agent takes precedence over the defaults of the client
+	switch req.API {
+	case types.APICompletions:
+		return c.completions.Complete(ctx, req, opts...)
+	case types.APIResponses:
+		return c.responses.Complete(ctx, req, opts...)
+	}
 	if strings.HasPrefix(req.Model, "claude") {
 		return c.anthropic.Complete(ctx, req, opts...)
 	}
-	if c.useCompletions {
+	// Audio output is only available in the Chat Completions API
+	if c.useCompletions || req.Audio != nil {
 		return c.completions.Complete(ctx, req, opts...)
 	}
 	return c.responses.Complete(ctx, req, opts...)
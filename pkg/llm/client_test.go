@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/anthropic"
+	"github.com/nanobot-ai/nanobot/pkg/llm/responses"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestDispatchAPI(t *testing.T) {
+	var (
+		lock sync.Mutex
+		path string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		path = r.URL.Path
+		lock.Unlock()
+		http.Error(w, `{"error": {"message": "bad request"}}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	for _, test := range []struct {
+		useCompletions bool
+		req            types.CompletionRequest
+		want           string
+	}{
+		{false, types.CompletionRequest{Model: "gpt-4.1"}, "/responses"},
+		{true, types.CompletionRequest{Model: "gpt-4.1"}, "/chat/completions"},
+		{false, types.CompletionRequest{Model: "claude-sonnet-4-5"}, "/messages"},
+		{false, types.CompletionRequest{Model: "gpt-4.1", API: types.APICompletions}, "/chat/completions"},
+		{true, types.CompletionRequest{Model: "gpt-4.1", API: types.APIResponses}, "/responses"},
+		{false, types.CompletionRequest{Model: "claude-sonnet-4-5", API: types.APIResponses}, "/responses"},
+	} {
+		client := NewClient(Config{
+			Responses: responses.Config{ChatCompletionAPI: test.useCompletions, APIKey: "test", BaseURL: srv.URL},
+			Anthropic: anthropic.Config{APIKey: "test", BaseURL: srv.URL},
+		})
+		if _, err := client.Complete(t.Context(), test.req); err == nil {
+			t.Fatal("expected the error of the server")
+		}
+
+		lock.Lock()
+		if path != test.want {
+			t.Errorf("%s with API %q: got request to %s, want %s", test.req.Model, test.req.API, path, test.want)
+		}
+		lock.Unlock()
+	}
+}
+
+func TestCompleteOutputAgent(t *testing.T) {
+	client := NewClient(Config{
+		Middleware: []Middleware{func(types.Completer) types.Completer {
+			return CompleterFunc(func(context.Context, types.CompletionRequest, ...types.CompletionOptions) (*types.CompletionResponse, error) {
+				return &types.CompletionResponse{Output: types.Message{Role: "assistant"}}, nil
+			})
+		}},
+	})
+
+	resp, err := client.Complete(t.Context(), types.CompletionRequest{Agent: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Agent != "b" || resp.Output.Agent != "b" {
+		t.Errorf("expected the output to be attributed to b, got %q and %q", resp.Agent, resp.Output.Agent)
+	}
+}
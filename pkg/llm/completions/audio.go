@@ -0,0 +1,91 @@
+package completions
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+)
+
+// pcm16SampleRate is the sample rate of pcm16 audio output, which is 16-bit little-endian mono.
+const pcm16SampleRate = 24000
+
+// canStream reports whether the response to req can be streamed. Audio output can only be
+// streamed as pcm16.
+func canStream(req Request) bool {
+	return req.Audio == nil || req.Audio.Format == "pcm16"
+}
+
+// inputAudioFormat returns the input_audio format for a mime type, the API only accepts wav and mp3.
+func inputAudioFormat(mimeType string) (string, bool) {
+	switch mimeType {
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return "wav", true
+	case "audio/mpeg", "audio/mp3":
+		return "mp3", true
+	default:
+		return "", false
+	}
+}
+
+func audioMIMEType(format string) string {
+	switch format {
+	case "mp3":
+		return "audio/mpeg"
+	case "flac":
+		return "audio/flac"
+	case "opus":
+		return "audio/ogg"
+	case "pcm16":
+		return "audio/pcm"
+	default:
+		return "audio/wav"
+	}
+}
+
+// audioContent converts the complete audio output of a response to content. Raw pcm16 audio is
+// wrapped in a WAV header so that it can be played back.
+func audioContent(audio *AudioOptions, data string) *mcp.Content {
+	var format string
+	if audio != nil {
+		format = audio.Format
+	}
+
+	if format == "pcm16" {
+		if pcm, err := base64.StdEncoding.DecodeString(data); err == nil {
+			data = base64.StdEncoding.EncodeToString(pcm16ToWAV(pcm))
+			format = "wav"
+		}
+	}
+
+	return &mcp.Content{
+		Type:     "audio",
+		Data:     data,
+		MIMEType: audioMIMEType(format),
+	}
+}
+
+func pcm16ToWAV(pcm []byte) []byte {
+	const (
+		channels      = 1
+		bitsPerSample = 16
+		blockAlign    = channels * bitsPerSample / 8
+	)
+
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+len(pcm)))
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1)
+	binary.LittleEndian.PutUint16(header[22:], channels)
+	binary.LittleEndian.PutUint32(header[24:], pcm16SampleRate)
+	binary.LittleEndian.PutUint32(header[28:], pcm16SampleRate*blockAlign)
+	binary.LittleEndian.PutUint16(header[32:], blockAlign)
+	binary.LittleEndian.PutUint16(header[34:], bitsPerSample)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(len(pcm)))
+
+	return append(header, pcm...)
+}
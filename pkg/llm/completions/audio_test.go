@@ -0,0 +1,136 @@
+package completions
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/conformance"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestAudioRequest(t *testing.T) {
+	req, err := toRequest(&types.CompletionRequest{
+		Audio: &types.AgentAudio{Format: "pcm16"},
+		Input: []types.Message{{
+			Role: "user",
+			Items: []types.CompletionItem{
+				{Content: &mcp.Content{Type: "text", Text: "What does this say?"}},
+				{Content: &mcp.Content{Type: "audio", MIMEType: "audio/mpeg", Data: "aGk="}},
+				{Content: &mcp.Content{Type: "audio", MIMEType: "audio/ogg", Data: "aGk="}},
+			},
+		}, {
+			Role: "assistant",
+			Items: []types.CompletionItem{
+				{Content: &mcp.Content{Type: "text", Text: "Hi"}},
+				{Content: &mcp.Content{Type: "audio", MIMEType: "audio/wav", Data: "aGk="}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(struct {
+		Modalities []string      `json:"modalities"`
+		Audio      *AudioOptions `json:"audio"`
+		Messages   []Message     `json:"messages"`
+	}{req.Modalities, req.Audio, req.Messages})
+	want := `{"modalities":["text","audio"],"audio":{"voice":"alloy","format":"pcm16"},"messages":[` +
+		`{"role":"user","content":[{"type":"text","text":"What does this say?"},{"type":"input_audio","input_audio":{"data":"aGk=","format":"mp3"}}]},` +
+		`{"role":"assistant","content":[{"type":"text","text":"Hi"}]}]}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+// audioStream streams the transcript and the pcm16 audio of the answer in two chunks.
+var audioStream = conformance.Provider{
+	Stream: func(s *conformance.Stream, script conformance.Script) {
+		chunk := func(delta map[string]any, finishReason any) map[string]any {
+			return map[string]any{
+				"id":      script.ID,
+				"object":  "chat.completion.chunk",
+				"model":   script.Model,
+				"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finishReason}},
+			}
+		}
+
+		s.Data(chunk(map[string]any{"role": "assistant", "audio": map[string]any{"id": "audio-1", "transcript": "Hello", "data": "AAEC"}}, nil))
+		s.Data(chunk(map[string]any{"audio": map[string]any{"transcript": " there", "data": "AwQF"}}, nil))
+		s.Data(chunk(map[string]any{}, "stop"))
+		s.Done()
+	},
+}
+
+func TestAudioStream(t *testing.T) {
+	body := audioStream.Render(conformance.Script{ID: "msg-audio", Model: "test-model"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	var (
+		lock       sync.Mutex
+		transcript []string
+		chunks     []string
+	)
+	ctx := progress.WithListener(t.Context(), func(p *types.CompletionProgress) {
+		lock.Lock()
+		defer lock.Unlock()
+		if c := p.Item.Content; c != nil && p.Item.Partial {
+			switch c.Type {
+			case "text":
+				transcript = append(transcript, c.Text)
+			case "audio":
+				chunks = append(chunks, c.Data)
+			}
+		}
+	})
+
+	resp, err := NewClient(Config{APIKey: "test", BaseURL: srv.URL}).Complete(ctx, types.CompletionRequest{
+		Model: "test-model",
+		Audio: &types.AgentAudio{Format: "pcm16"},
+		Input: []types.Message{{
+			Role:  "user",
+			Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: "Say hello"}}},
+		}},
+	}, types.CompletionOptions{ProgressToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(transcript, "|"); got != "Hello| there" {
+		t.Errorf("got transcript deltas %q", got)
+	}
+	if got := strings.Join(chunks, "|"); got != "AAEC|AwQF" {
+		t.Errorf("got audio deltas %q", got)
+	}
+
+	items := resp.Output.Items
+	if len(items) != 2 || items[0].Content == nil || items[1].Content == nil {
+		t.Fatalf("unexpected output %+v", items)
+	}
+	if items[0].Content.Text != "Hello there" {
+		t.Errorf("got transcript %q", items[0].Content.Text)
+	}
+	audio := items[1].Content
+	if audio.Type != "audio" || audio.MIMEType != "audio/wav" {
+		t.Fatalf("expected wav audio, got %s %s", audio.Type, audio.MIMEType)
+	}
+	wav, err := base64.StdEncoding.DecodeString(audio.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wav) != 44+6 || !bytes.HasPrefix(wav, []byte("RIFF")) || !bytes.Equal(wav[44:], []byte{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("expected the pcm16 audio in a WAV header, got %v", wav)
+	}
+}
@@ -4,18 +4,21 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
 	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/llm/quota"
 	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/secrets"
 	"github.com/nanobot-ai/nanobot/pkg/types"
 )
 
@@ -27,6 +30,8 @@ type Config struct {
 	APIKey  string
 	BaseURL string
 	Headers map[string]string
+	// APIVersion is the api-version query parameter of Azure OpenAI.
+	APIVersion string
 }
 
 // NewClient creates a new OpenAI Chat Completions client with the provided API key and base URL.
@@ -61,7 +66,7 @@ func (c *Client) Complete(ctx context.Context, completionRequest types.Completio
 		return nil, err
 	}
 
-	return toResponse(resp, ts)
+	return toResponse(resp, req.Audio, ts)
 }
 
 func (c *Client) complete(ctx context.Context, agentName string, req Request, opts ...types.CompletionOptions) (*Response, error) {
@@ -69,17 +74,18 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		opt = complete.Complete(opts...)
 	)
 
-	req.Stream = true
-	req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	req.Stream = canStream(req)
+	if req.Stream {
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
 
 	data, _ := json.Marshal(req)
 	log.Messages(ctx, "completions-api", true, data)
 
-	// Build the URL with api-version if AZURE_OPENAI_API_VERSION is defined
-    apiVersion := os.Getenv("AZURE_OPENAI_API_VERSION")
+	// Build the URL with api-version if an Azure OpenAI API version is configured
     url := c.BaseURL + "/chat/completions"
-    if apiVersion != "" {
-	    url = url + "?api-version=" + apiVersion
+    if c.APIVersion != "" {
+	    url = url + "?api-version=" + c.APIVersion
     }
     httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
     if err != nil {
@@ -88,8 +94,8 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 	// Log the URL used
     log.Infof(ctx, "OpenAI Chat Completions URL: %s", httpReq.URL.String())
 	
-	for key, value := range c.Headers {
-		httpReq.Header.Set(key, value)
+	if err := secrets.SetHeaders(ctx, httpReq, c.Headers); err != nil {
+		return nil, err
 	}
 
 	httpResp, err := http.DefaultClient.Do(httpReq)
@@ -97,10 +103,10 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		return nil, err
 	}
 	defer httpResp.Body.Close()
+	quota.Observe("openai", httpResp.Header)
 
 	if httpResp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(httpResp.Body)
-		return nil, fmt.Errorf("failed to get response from OpenAI Chat Completions API: %s %q", httpResp.Status, string(body))
+		return nil, apierror.FromResponse("OpenAI Chat Completions API", httpResp)
 	}
 
 	// Peek first bytes to detect if it's SSE or complete JSON
@@ -135,6 +141,10 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		// Send progress for the complete response
 		if opt.ProgressToken != nil && len(resp.Choices) > 0 {
 			choice := resp.Choices[0]
+			if choice.Message != nil && choice.Message.ReasoningContent != nil && *choice.Message.ReasoningContent != "" {
+				progress.Send(ctx, reasoningProgress(&resp, agentName, fmt.Sprintf("%s-reasoning", resp.ID),
+					*choice.Message.ReasoningContent, false, false), opt.ProgressToken)
+			}
 			if choice.Message != nil && choice.Message.Content.Text != nil {
 				progress.Send(ctx, &types.CompletionProgress{
 					Model:     resp.Model,
@@ -151,6 +161,33 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 					},
 				}, opt.ProgressToken)
 			}
+			if choice.Message != nil && choice.Message.Audio != nil {
+				if choice.Message.Content.Text == nil && choice.Message.Audio.Transcript != "" {
+					progress.Send(ctx, &types.CompletionProgress{
+						Model:     resp.Model,
+						Agent:     agentName,
+						MessageID: resp.ID,
+						Item: types.CompletionItem{
+							ID: fmt.Sprintf("%s-content", resp.ID),
+							Content: &mcp.Content{
+								Type: "text",
+								Text: choice.Message.Audio.Transcript,
+							},
+						},
+					}, opt.ProgressToken)
+				}
+				if choice.Message.Audio.Data != "" {
+					progress.Send(ctx, &types.CompletionProgress{
+						Model:     resp.Model,
+						Agent:     agentName,
+						MessageID: resp.ID,
+						Item: types.CompletionItem{
+							ID:      fmt.Sprintf("%s-audio", resp.ID),
+							Content: audioContent(req.Audio, choice.Message.Audio.Data),
+						},
+					}, opt.ProgressToken)
+				}
+			}
 
 			// Send progress for tool calls if any
 			for i, toolCall := range choice.Message.ToolCalls {
@@ -181,7 +218,10 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		resp        Response
 		initialized = false
 		toolCalls   = make(map[int]*ToolCall)
+		audioData   []byte
+		texts       = streamText{}
 	)
+	lines.Buffer(make([]byte, 0, 1024), 10*1024*1024)
 
 	for lines.Scan() {
 		line := lines.Text()
@@ -227,12 +267,22 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 			resp.Usage = chunk.Usage
 		}
 
+		// Azure OpenAI sends the results of the content filter for the prompt in the first chunk
+		resp.PromptFilterResults = append(resp.PromptFilterResults, chunk.PromptFilterResults...)
+		resp.PromptAnnotations = append(resp.PromptAnnotations, chunk.PromptAnnotations...)
+
 		// Process choice deltas
 		for _, choice := range chunk.Choices {
 			if choice.Index >= len(resp.Choices) {
 				continue
 			}
 
+			// Handle the results of the content filter for the output, which are sent with its chunks
+			if choice.ContentFilterResults != nil {
+				current := &resp.Choices[choice.Index]
+				current.ContentFilterResults = current.ContentFilterResults.merge(choice.ContentFilterResults)
+			}
+
 			delta := choice.Delta
 			
 			// Azure OpenAI may send complete message instead of delta
@@ -247,6 +297,10 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 				}
 				
 				// Send progress for complete message
+				if opt.ProgressToken != nil && choice.Message.ReasoningContent != nil && *choice.Message.ReasoningContent != "" {
+					progress.Send(ctx, reasoningProgress(&resp, agentName, fmt.Sprintf("%s-r-%d", resp.ID, choice.Index),
+						*choice.Message.ReasoningContent, false, false), opt.ProgressToken)
+				}
 				if opt.ProgressToken != nil && choice.Message.Content.Text != nil {
 					progress.Send(ctx, &types.CompletionProgress{
 						Model:     resp.Model,
@@ -292,18 +346,36 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 
 			// Determine if this is the final chunk for this choice
 			isFinished := choice.FinishReason != nil
+			if isFinished {
+				resp.Choices[choice.Index].FinishReason = choice.FinishReason
+			}
 
 			// Handle role
 			if delta.Role != "" && resp.Choices[choice.Index].Message != nil {
 				resp.Choices[choice.Index].Message.Role = delta.Role
 			}
 
+			// Handle reasoning, which reasoning models stream before the answer
+			if reasoning := delta.ReasoningText(); reasoning != "" {
+				message := resp.Choices[choice.Index].Message
+				if message.ReasoningContent == nil {
+					message.ReasoningContent = new(string)
+				}
+				*message.ReasoningContent = texts.append(fmt.Sprintf("reasoning-%d", choice.Index), *message.ReasoningContent, reasoning)
+
+				if resp.ID != "" && opt.ProgressToken != nil {
+					progress.Send(ctx, reasoningProgress(&resp, agentName, fmt.Sprintf("%s-r-%d", resp.ID, choice.Index),
+						reasoning, true, !isFinished), opt.ProgressToken)
+				}
+			}
+
 			// Handle content
 			if delta.Content != nil {
 				if resp.Choices[choice.Index].Message.Content.Text == nil {
 					resp.Choices[choice.Index].Message.Content.Text = new(string)
 				}
-				*resp.Choices[choice.Index].Message.Content.Text += *delta.Content
+				text := resp.Choices[choice.Index].Message.Content.Text
+				*text = texts.append(fmt.Sprintf("content-%d", choice.Index), *text, *delta.Content)
 
 				// Only send progress if we have a valid message ID
 				if resp.ID != "" && opt.ProgressToken != nil {
@@ -324,6 +396,64 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 				}
 			}
 
+			// Handle audio, the transcript is streamed as the text content of the message
+			if delta.Audio != nil {
+				message := resp.Choices[choice.Index].Message
+				if message.Audio == nil {
+					message.Audio = &MessageAudio{}
+				}
+				if delta.Audio.ID != "" {
+					message.Audio.ID = delta.Audio.ID
+				}
+				if delta.Audio.ExpiresAt != 0 {
+					message.Audio.ExpiresAt = delta.Audio.ExpiresAt
+				}
+				message.Audio.Transcript = texts.append(fmt.Sprintf("transcript-%d", choice.Index), message.Audio.Transcript, delta.Audio.Transcript)
+
+				if delta.Audio.Data != "" {
+					data, err := base64.StdEncoding.DecodeString(delta.Audio.Data)
+					if err != nil {
+						log.Errorf(ctx, "failed to decode streamed audio: %v", err)
+					} else if choice.Index == 0 {
+						audioData = append(audioData, data...)
+					}
+				}
+
+				if resp.ID != "" && opt.ProgressToken != nil && delta.Audio.Transcript != "" {
+					progress.Send(ctx, &types.CompletionProgress{
+						Model:     resp.Model,
+						Agent:     agentName,
+						MessageID: resp.ID,
+						Item: types.CompletionItem{
+							ID:      fmt.Sprintf("%s-%d", resp.ID, choice.Index),
+							Partial: true,
+							HasMore: !isFinished,
+							Content: &mcp.Content{
+								Type: "text",
+								Text: delta.Audio.Transcript,
+							},
+						},
+					}, opt.ProgressToken)
+				}
+				if resp.ID != "" && opt.ProgressToken != nil && delta.Audio.Data != "" {
+					progress.Send(ctx, &types.CompletionProgress{
+						Model:     resp.Model,
+						Agent:     agentName,
+						MessageID: resp.ID,
+						Item: types.CompletionItem{
+							ID:      fmt.Sprintf("%s-a-%d", resp.ID, choice.Index),
+							Partial: true,
+							HasMore: !isFinished,
+							Content: &mcp.Content{
+								Type:     "audio",
+								Data:     delta.Audio.Data,
+								MIMEType: audioMIMEType("pcm16"),
+							},
+						},
+					}, opt.ProgressToken)
+				}
+			}
+
 			// Handle tool calls
 			if delta.ToolCalls != nil {
 				for i, toolCall := range delta.ToolCalls {
@@ -342,7 +472,8 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 						}
 					} else {
 						// Append to existing tool call arguments
-						toolCalls[index].Function.Arguments += toolCall.Function.Arguments
+						toolCalls[index].Function.Arguments = texts.append(fmt.Sprintf("arguments-%d", index),
+							toolCalls[index].Function.Arguments, toolCall.Function.Arguments)
 					}
 
 					// Only send progress if we have a valid message ID
@@ -366,6 +497,15 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 				}
 			}
 
+			// Handle logprobs, which are sent for the tokens of each chunk
+			if choice.Logprobs != nil {
+				current := &resp.Choices[choice.Index]
+				if current.Logprobs == nil {
+					current.Logprobs = &Logprobs{}
+				}
+				current.Logprobs.Content = append(current.Logprobs.Content, choice.Logprobs.Content...)
+			}
+
 			// Handle finish reason
 			if choice.FinishReason != nil {
 				resp.Choices[choice.Index].FinishReason = choice.FinishReason
@@ -382,6 +522,10 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		return nil, fmt.Errorf("failed to read streaming response: %w", err)
 	}
 
+	if len(audioData) > 0 && resp.Choices[0].Message.Audio != nil {
+		resp.Choices[0].Message.Audio.Data = base64.StdEncoding.EncodeToString(audioData)
+	}
+
 	// Convert tool calls map to slice
 	if len(toolCalls) > 0 {
 		resp.Choices[0].Message.ToolCalls = make([]ToolCall, len(toolCalls))
@@ -397,3 +541,39 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 
 	return &resp, nil
 }
+
+// streamText accumulates the deltas of streamed strings. Concatenating every delta to the string
+// would copy the whole text each time. String does not copy the builder, so the text so far can be
+// read after every delta.
+type streamText map[string]*strings.Builder
+
+// append appends delta to the text of key, which starts with base, and returns the text so far.
+func (s streamText) append(key, base, delta string) string {
+	text, ok := s[key]
+	// The text is started over if it was replaced by a complete message in the meantime.
+	if !ok || text.Len() != len(base) {
+		text = &strings.Builder{}
+		text.WriteString(base)
+		s[key] = text
+	}
+	text.WriteString(delta)
+	return text.String()
+}
+
+// reasoningProgress builds a progress event for reasoning text. It is sent as its own item, separate
+// from the answer, so UIs can show it as a collapsible thinking section.
+func reasoningProgress(resp *Response, agentName, itemID, text string, partial, hasMore bool) *types.CompletionProgress {
+	return &types.CompletionProgress{
+		Model:     resp.Model,
+		Agent:     agentName,
+		MessageID: resp.ID,
+		Item: types.CompletionItem{
+			ID:      itemID,
+			Partial: partial,
+			HasMore: hasMore,
+			Reasoning: &types.Reasoning{
+				Summary: []types.SummaryText{{Text: text}},
+			},
+		},
+	}
+}
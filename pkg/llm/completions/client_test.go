@@ -0,0 +1,41 @@
+package completions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestAPIVersion(t *testing.T) {
+	queries := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.RawQuery
+		http.Error(w, `{"error": {"message": "bad request"}}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	req := types.CompletionRequest{
+		Model: "gpt-4.1",
+		Input: []types.Message{{
+			Role:  "user",
+			Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: "Hi"}}},
+		}},
+	}
+	for _, version := range []string{"2024-10-21", ""} {
+		client := NewClient(Config{APIKey: "test", BaseURL: srv.URL, APIVersion: version})
+		if _, err := client.Complete(t.Context(), req); err == nil {
+			t.Fatal("expected the error of the server")
+		}
+
+		want := ""
+		if version != "" {
+			want = "api-version=" + version
+		}
+		if got := <-queries; got != want {
+			t.Errorf("got query %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package completions
+
+import (
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/conformance"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+var provider = conformance.Provider{
+	Name: "OpenAI Chat Completions API",
+	Path: "/chat/completions",
+	New: func(baseURL string) types.Completer {
+		return NewClient(Config{APIKey: "test", BaseURL: baseURL})
+	},
+	Stream: func(s *conformance.Stream, script conformance.Script) {
+		chunk := func(delta map[string]any, finishReason any) map[string]any {
+			return map[string]any{
+				"id":      script.ID,
+				"object":  "chat.completion.chunk",
+				"model":   script.Model,
+				"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finishReason}},
+			}
+		}
+
+		s.Data(chunk(map[string]any{"role": "assistant", "content": ""}, nil))
+		for _, text := range script.Text {
+			s.Data(chunk(map[string]any{"content": text}, nil))
+		}
+
+		for i, call := range script.ToolCalls {
+			s.Data(chunk(map[string]any{"tool_calls": []any{map[string]any{
+				"index":    i,
+				"id":       call.ID,
+				"type":     "function",
+				"function": map[string]any{"name": call.Name, "arguments": ""},
+			}}}, nil))
+			for _, arguments := range call.Arguments {
+				s.Data(chunk(map[string]any{"tool_calls": []any{map[string]any{
+					"index":    i,
+					"function": map[string]any{"arguments": arguments},
+				}}}, nil))
+			}
+		}
+
+		finishReason := "stop"
+		if len(script.ToolCalls) > 0 {
+			finishReason = "tool_calls"
+		}
+		s.Data(chunk(map[string]any{}, finishReason))
+		s.Data(map[string]any{
+			"id":      script.ID,
+			"object":  "chat.completion.chunk",
+			"model":   script.Model,
+			"choices": []any{},
+			"usage": map[string]any{
+				"prompt_tokens":     script.InputTokens,
+				"completion_tokens": script.OutputTokens,
+				"total_tokens":      script.InputTokens + script.OutputTokens,
+			},
+		})
+		s.Done()
+	},
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, provider)
+}
+
+func BenchmarkConformance(b *testing.B) {
+	conformance.Benchmark(b, provider)
+}
@@ -0,0 +1,78 @@
+package completions
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// UnmarshalJSON skips the entries that are not the result of a category, like the error of the
+// filter or the custom blocklists of older API versions, so they don't fail the whole response.
+func (c *ContentFilterResults) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = make(ContentFilterResults, len(raw))
+	for category, value := range raw {
+		var result ContentFilterResult
+		if category == "error" || json.Unmarshal(value, &result) != nil {
+			continue
+		}
+		(*c)[category] = result
+	}
+	return nil
+}
+
+// merge combines the results of the chunks of a streamed response. A category is filtered or
+// detected if it was in any of the chunks, and has the highest severity of the chunks.
+func (c ContentFilterResults) merge(other ContentFilterResults) ContentFilterResults {
+	if c == nil {
+		c = ContentFilterResults{}
+	}
+	for category, result := range other {
+		existing, ok := c[category]
+		if !ok {
+			c[category] = result
+			continue
+		}
+		existing.Filtered = existing.Filtered || result.Filtered
+		existing.Detected = existing.Detected || result.Detected
+		if slices.Index(types.ContentFilterSeverities, result.Severity) > slices.Index(types.ContentFilterSeverities, existing.Severity) {
+			existing.Severity = result.Severity
+		}
+		c[category] = existing
+	}
+	return c
+}
+
+// toContentFilter returns the results of the content filter for the prompt and the output of the
+// response, which are otherwise lost when the response is normalized.
+func toContentFilter(resp *Response) (result []types.ContentFilterResult) {
+	prompts := resp.PromptFilterResults
+	if len(prompts) == 0 {
+		prompts = resp.PromptAnnotations
+	}
+	for _, prompt := range prompts {
+		result = appendContentFilter(result, types.ContentFilterPrompt, prompt.ContentFilterResults)
+	}
+	if len(resp.Choices) > 0 {
+		result = appendContentFilter(result, types.ContentFilterOutput, resp.Choices[0].ContentFilterResults)
+	}
+	return result
+}
+
+func appendContentFilter(result []types.ContentFilterResult, target string, results ContentFilterResults) []types.ContentFilterResult {
+	for _, category := range slices.Sorted(maps.Keys(results)) {
+		result = append(result, types.ContentFilterResult{
+			Target:   target,
+			Category: category,
+			Filtered: results[category].Filtered,
+			Severity: results[category].Severity,
+			Detected: results[category].Detected,
+		})
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package completions
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestContentFilter(t *testing.T) {
+	var resp Response
+	err := json.Unmarshal([]byte(`{
+		"id": "chatcmpl-1",
+		"prompt_filter_results": [{
+			"prompt_index": 0,
+			"content_filter_results": {
+				"hate": {"filtered": false, "severity": "safe"},
+				"jailbreak": {"filtered": false, "detected": true},
+				"custom_blocklists": []
+			}
+		}],
+		"choices": [{
+			"index": 0,
+			"message": {"role": "assistant", "content": "hello"},
+			"content_filter_results": {
+				"violence": {"filtered": false, "severity": "low"},
+				"error": {"code": "content_filter_error", "message": "timeout"}
+			}
+		}]
+	}`), &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The results of the chunks of a streamed output are combined.
+	resp.Choices[0].ContentFilterResults = resp.Choices[0].ContentFilterResults.merge(ContentFilterResults{
+		"violence": {Filtered: true, Severity: "medium"},
+	})
+
+	result, err := toResponse(&resp, nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []types.ContentFilterResult{
+		{Target: types.ContentFilterPrompt, Category: "hate", Severity: "safe"},
+		{Target: types.ContentFilterPrompt, Category: "jailbreak", Detected: true},
+		{Target: types.ContentFilterOutput, Category: "violence", Filtered: true, Severity: "medium"},
+	}
+	if !reflect.DeepEqual(result.Output.ContentFilter, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, result.Output.ContentFilter)
+	}
+
+	triggered := types.AgentContentFilter{Severity: "low"}.Triggered(result.Output.ContentFilter)
+	if len(triggered) != 2 || triggered[0].Category != "jailbreak" || triggered[1].Category != "violence" {
+		t.Fatalf("unexpected triggered results %+v", triggered)
+	}
+}
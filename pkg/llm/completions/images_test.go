@@ -0,0 +1,51 @@
+package completions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestImages(t *testing.T) {
+	req, err := toRequest(&types.CompletionRequest{
+		Input: []types.Message{{
+			Role:  "assistant",
+			Items: []types.CompletionItem{{ToolCall: &types.ToolCall{CallID: "c1", Name: "chart", Arguments: "{}"}}},
+		}, {
+			Role: "user",
+			Items: []types.CompletionItem{{ToolCallResult: &types.ToolCallResult{
+				CallID: "c1",
+				Output: types.CallResult{Content: []mcp.Content{
+					{Type: "text", Text: "done"},
+					{Type: "image", MIMEType: "image/png", Data: "aGk="},
+				}},
+			}}},
+		}, {
+			Role: "user",
+			Items: []types.CompletionItem{
+				{Content: &mcp.Content{Type: "text", Text: "And this one?"}},
+				{Content: &mcp.Content{Type: "image", URI: "https://example.com/cat.png"}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`{"role":"assistant","content":null,"tool_calls":[{"id":"c1","type":"function","function":{"name":"chart","arguments":"{}"}}]}`,
+		`{"role":"tool","content":"done","tool_call_id":"c1"}`,
+		`{"role":"user","content":[{"type":"text","text":"Images returned by the tool calls above:"},{"type":"image_url","image_url":{"url":"data:image/png;base64,aGk=","detail":"auto"}}]}`,
+		`{"role":"user","content":[{"type":"text","text":"And this one?"},{"type":"image_url","image_url":{"url":"https://example.com/cat.png","detail":"auto"}}]}`,
+	}
+	if len(req.Messages) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(req.Messages), len(want))
+	}
+	for i, msg := range req.Messages {
+		if data, _ := json.Marshal(msg); string(data) != want[i] {
+			t.Errorf("message %d: got %s, want %s", i, data, want[i])
+		}
+	}
+}
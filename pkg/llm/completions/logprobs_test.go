@@ -0,0 +1,74 @@
+package completions
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestLogprobsRequest(t *testing.T) {
+	for _, test := range []struct {
+		req  types.CompletionRequest
+		want string
+	}{
+		{types.CompletionRequest{}, `{}`},
+		{types.CompletionRequest{Logprobs: true}, `{"logprobs":true}`},
+		{types.CompletionRequest{TopLogprobs: 3}, `{"logprobs":true,"top_logprobs":3}`},
+	} {
+		req, err := toRequest(&test.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(struct {
+			Logprobs    bool `json:"logprobs,omitempty"`
+			TopLogprobs *int `json:"top_logprobs,omitempty"`
+		}{req.Logprobs, req.TopLogprobs})
+		if string(data) != test.want {
+			t.Errorf("got %s, want %s", data, test.want)
+		}
+	}
+}
+
+func TestLogprobsResponse(t *testing.T) {
+	var resp Response
+	err := json.Unmarshal([]byte(`{
+		"id": "chatcmpl-1",
+		"choices": [{
+			"index": 0,
+			"message": {"role": "assistant", "content": "Hi"},
+			"logprobs": {"content": [{
+				"token": "Hi",
+				"logprob": -0.1,
+				"bytes": [72, 105],
+				"top_logprobs": [
+					{"token": "Hi", "logprob": -0.1},
+					{"token": "Hello", "logprob": -2.5}
+				]
+			}]}
+		}]
+	}`), &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := toResponse(&resp, nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []types.TokenLogprob{{
+		Token:   "Hi",
+		Logprob: -0.1,
+		Bytes:   []int{72, 105},
+		TopLogprobs: []types.TopLogprob{
+			{Token: "Hi", Logprob: -0.1},
+			{Token: "Hello", Logprob: -2.5},
+		},
+	}}
+	if !reflect.DeepEqual(result.Logprobs, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, result.Logprobs)
+	}
+}
@@ -0,0 +1,92 @@
+package completions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/conformance"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// reasoningStream streams reasoning deltas in both fields servers use for them, followed by the
+// answer.
+var reasoningStream = conformance.Provider{
+	Stream: func(s *conformance.Stream, script conformance.Script) {
+		chunk := func(delta map[string]any, finishReason any) map[string]any {
+			return map[string]any{
+				"id":      script.ID,
+				"object":  "chat.completion.chunk",
+				"model":   script.Model,
+				"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finishReason}},
+			}
+		}
+
+		s.Data(chunk(map[string]any{"role": "assistant", "reasoning_content": "The user asks"}, nil))
+		s.Data(chunk(map[string]any{"reasoning_content": " about Paris."}, nil))
+		s.Data(chunk(map[string]any{"reasoning": " Answer briefly."}, nil))
+		for _, text := range script.Text {
+			s.Data(chunk(map[string]any{"content": text}, nil))
+		}
+		s.Data(chunk(map[string]any{}, "stop"))
+		s.Done()
+	},
+}
+
+func TestReasoningStream(t *testing.T) {
+	body := reasoningStream.Render(conformance.Script{
+		ID:    "msg-reasoning",
+		Model: "test-model",
+		Text:  []string{"It is", " sunny."},
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	var (
+		lock      sync.Mutex
+		reasoning []string
+	)
+	ctx := progress.WithListener(t.Context(), func(p *types.CompletionProgress) {
+		lock.Lock()
+		defer lock.Unlock()
+		if p.Item.Reasoning != nil {
+			if !p.Item.Partial || p.Item.ID != "msg-reasoning-r-0" {
+				t.Errorf("unexpected reasoning progress %+v", p.Item)
+			}
+			reasoning = append(reasoning, p.Item.Reasoning.Summary[0].Text)
+		}
+	})
+
+	resp, err := NewClient(Config{APIKey: "test", BaseURL: srv.URL}).Complete(ctx, types.CompletionRequest{
+		Model: "test-model",
+		Input: []types.Message{{
+			Role:  "user",
+			Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: "What is the weather in Paris?"}}},
+		}},
+	}, types.CompletionOptions{ProgressToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(reasoning, "|"); got != "The user asks| about Paris.| Answer briefly." {
+		t.Errorf("got reasoning deltas %q", got)
+	}
+
+	items := resp.Output.Items
+	if len(items) != 2 || items[0].Reasoning == nil || items[1].Content == nil {
+		t.Fatalf("unexpected output %+v", items)
+	}
+	if text := items[0].Reasoning.Summary[0].Text; text != "The user asks about Paris. Answer briefly." {
+		t.Errorf("got reasoning %q", text)
+	}
+	if items[1].Content.Text != "It is sunny." {
+		t.Errorf("got answer %q", items[1].Content.Text)
+	}
+}
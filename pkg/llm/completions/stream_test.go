@@ -0,0 +1,23 @@
+package completions
+
+import "testing"
+
+func TestStreamText(t *testing.T) {
+	texts := streamText{}
+
+	text := ""
+	for _, delta := range []string{`{"city": `, `"Paris"`, `}`} {
+		text = texts.append("arguments-0", text, delta)
+	}
+	if text != `{"city": "Paris"}` {
+		t.Errorf("got %q", text)
+	}
+
+	// Texts of other keys are independent, and a replaced text starts over from its new value.
+	if got := texts.append("content-0", "", "Hi"); got != "Hi" {
+		t.Errorf("got %q", got)
+	}
+	if got := texts.append("arguments-0", "{}", " "); got != "{} " {
+		t.Errorf("got %q after the text was replaced", got)
+	}
+}
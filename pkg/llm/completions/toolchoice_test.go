@@ -0,0 +1,35 @@
+package completions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestToolChoice(t *testing.T) {
+	tools := []types.ToolUseDefinition{{Name: "search", Parameters: []byte(`{"type":"object"}`)}}
+	parallel := false
+	for _, test := range []struct {
+		req  types.CompletionRequest
+		want string
+	}{
+		{types.CompletionRequest{ToolChoice: "required", Tools: tools}, `{"tool_choice":"required"}`},
+		{types.CompletionRequest{ToolChoice: "search", Tools: tools}, `{"tool_choice":{"type":"function","function":{"name":"search"}}}`},
+		{types.CompletionRequest{ParallelToolCalls: &parallel, Tools: tools}, `{"parallel_tool_calls":false}`},
+		// parallel_tool_calls is rejected without tools
+		{types.CompletionRequest{ParallelToolCalls: &parallel}, `{}`},
+	} {
+		req, err := toRequest(&test.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(struct {
+			ToolChoice        *ToolChoice `json:"tool_choice,omitempty"`
+			ParallelToolCalls *bool       `json:"parallel_tool_calls,omitempty"`
+		}{req.ToolChoice, req.ParallelToolCalls})
+		if string(data) != test.want {
+			t.Errorf("got %s, want %s", data, test.want)
+		}
+	}
+}
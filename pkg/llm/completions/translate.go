@@ -11,7 +11,7 @@ import (
 	"github.com/nanobot-ai/nanobot/pkg/uuid"
 )
 
-func toResponse(resp *Response, created time.Time) (*types.CompletionResponse, error) {
+func toResponse(resp *Response, audio *AudioOptions, created time.Time) (*types.CompletionResponse, error) {
 	// Azure OpenAI returns empty ID, generate one if needed
 	messageID := resp.ID
 	if messageID == "" {
@@ -30,6 +30,16 @@ func toResponse(resp *Response, created time.Time) (*types.CompletionResponse, e
 	if len(resp.Choices) > 0 {
 		choice := resp.Choices[0]
 		if choice.Message != nil {
+			// Handle reasoning
+			if choice.Message.ReasoningContent != nil && *choice.Message.ReasoningContent != "" {
+				result.Output.Items = append(result.Output.Items, types.CompletionItem{
+					ID: fmt.Sprintf("%s-reasoning", messageID),
+					Reasoning: &types.Reasoning{
+						Summary: []types.SummaryText{{Text: *choice.Message.ReasoningContent}},
+					},
+				})
+			}
+
 			// Handle content
 			if choice.Message.Content.Text != nil {
 				result.Output.Items = append(result.Output.Items, types.CompletionItem{
@@ -41,6 +51,25 @@ func toResponse(resp *Response, created time.Time) (*types.CompletionResponse, e
 				})
 			}
 
+			// Handle audio, the transcript is the text content of audio responses
+			if choice.Message.Audio != nil {
+				if choice.Message.Content.Text == nil && choice.Message.Audio.Transcript != "" {
+					result.Output.Items = append(result.Output.Items, types.CompletionItem{
+						ID: fmt.Sprintf("%s-content", messageID),
+						Content: &mcp.Content{
+							Type: "text",
+							Text: choice.Message.Audio.Transcript,
+						},
+					})
+				}
+				if choice.Message.Audio.Data != "" {
+					result.Output.Items = append(result.Output.Items, types.CompletionItem{
+						ID:      fmt.Sprintf("%s-audio", messageID),
+						Content: audioContent(audio, choice.Message.Audio.Data),
+					})
+				}
+			}
+
 			// Handle tool calls
 			for i, toolCall := range choice.Message.ToolCalls {
 				result.Output.Items = append(result.Output.Items, types.CompletionItem{
@@ -64,6 +93,27 @@ func toResponse(resp *Response, created time.Time) (*types.CompletionResponse, e
 				})
 			}
 		}
+		if choice.FinishReason != nil {
+			result.StopReason = *choice.FinishReason
+		}
+		if choice.Logprobs != nil {
+			result.Logprobs = toLogprobs(choice.Logprobs.Content)
+		}
+	}
+
+	result.Output.ContentFilter = toContentFilter(resp)
+
+	if resp.Usage != nil {
+		result.Usage = &types.Usage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+		}
+		if resp.Usage.PromptTokensDetails != nil {
+			result.Usage.CachedInputTokens = resp.Usage.PromptTokensDetails.CachedTokens
+		}
+		if resp.Usage.CompletionTokensDetails != nil {
+			result.Usage.ReasoningTokens = resp.Usage.CompletionTokensDetails.ReasoningTokens
+		}
 	}
 
 	return result, nil
@@ -84,6 +134,32 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 	// Set max tokens (use max_completion_tokens for newer models)
 	result.MaxCompletionTokens = &req.MaxTokens
 
+	// Only set when configured, models without reasoning reject the parameter
+	if req.Reasoning != nil && req.Reasoning.Effort != "" {
+		result.ReasoningEffort = req.Reasoning.Effort
+	}
+
+	if req.Logprobs || req.TopLogprobs > 0 {
+		result.Logprobs = true
+		if req.TopLogprobs > 0 {
+			result.TopLogprobs = &req.TopLogprobs
+		}
+	}
+
+	if req.Audio != nil {
+		result.Modalities = []string{"text", "audio"}
+		result.Audio = &AudioOptions{
+			Voice:  req.Audio.Voice,
+			Format: req.Audio.Format,
+		}
+		if result.Audio.Voice == "" {
+			result.Audio.Voice = "alloy"
+		}
+		if result.Audio.Format == "" {
+			result.Audio.Format = "wav"
+		}
+	}
+
 	// Handle tools
 	for _, tool := range req.Tools {
 		result.Tools = append(result.Tools, Tool{
@@ -116,8 +192,17 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 		}
 	}
 
+	// parallel_tool_calls is rejected unless tools are specified
+	if len(result.Tools) > 0 {
+		result.ParallelToolCalls = req.ParallelToolCalls
+	}
+
 	// Handle output schema
-	if req.OutputSchema != nil {
+	if req.OutputSchema != nil && req.OutputSchema.IsJSONObject() {
+		result.ResponseFormat = &ResponseFormat{
+			Type: "json_object",
+		}
+	} else if req.OutputSchema != nil {
 		result.ResponseFormat = &ResponseFormat{
 			Type: "json_schema",
 			JSONSchema: &JSONSchema{
@@ -132,8 +217,33 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 		}
 	}
 
+	// Images returned by tools can not be put in tool messages, so they are sent in a user message
+	// after the tool messages that answer the same assistant message.
+	var toolImages []ContentPart
+	flushToolImages := func() {
+		if len(toolImages) == 0 {
+			return
+		}
+		result.Messages = append(result.Messages, Message{
+			Role: "user",
+			Content: MessageContent{
+				ContentParts: append([]ContentPart{{
+					Type: "text",
+					Text: "Images returned by the tool calls above:",
+				}}, toolImages...),
+			},
+		})
+		toolImages = nil
+	}
+
 	// Convert messages
 	for _, msg := range req.Input {
+		if !slices.ContainsFunc(msg.Items, func(item types.CompletionItem) bool {
+			return item.ToolCallResult != nil
+		}) {
+			flushToolImages()
+		}
+
 		openAIMsg := Message{
 			Role: msg.Role,
 		}
@@ -153,24 +263,23 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 							Text: item.Content.Text,
 						})
 					case "image":
-						parts = append(parts, ContentPart{
-							Type: "image_url",
-							ImageURL: &ImageURL{
-								URL:    item.Content.ToImageURL(),
-								Detail: "auto",
-							},
-						})
+						parts = append(parts, imagePart(item.Content.ToImageURL()))
+					case "audio":
+						// Audio can only be sent by the user, the transcript of audio responses is
+						// sent as text instead.
+						if format, ok := inputAudioFormat(item.Content.MIMEType); ok && msg.Role == "user" {
+							parts = append(parts, ContentPart{
+								Type: "input_audio",
+								InputAudio: &InputAudio{
+									Data:   item.Content.Data,
+									Format: format,
+								},
+							})
+						}
 					case "resource":
 						if item.Content.Resource != nil && item.Content.Resource.Annotations != nil && slices.Contains(item.Content.Resource.Annotations.Audience, "assistant") {
 							if _, ok := types.ImageMimeTypes[item.Content.Resource.MIMEType]; ok {
-								url := fmt.Sprintf("data:%s;base64,%s", item.Content.Resource.MIMEType, item.Content.Resource.Blob)
-								parts = append(parts, ContentPart{
-									Type: "image_url",
-									ImageURL: &ImageURL{
-										URL:    url,
-										Detail: "auto",
-									},
-								})
+								parts = append(parts, imagePart(item.Content.Resource.ToDataURI()))
 							} else if _, ok := types.TextMimeTypes[item.Content.Resource.MIMEType]; ok {
 								text := item.Content.Resource.Text
 								if item.Content.Resource.Blob != "" {
@@ -218,6 +327,8 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 								resultText += "\n"
 							}
 							resultText += content.Text
+						} else if content.Type == "image" {
+							toolImages = append(toolImages, imagePart(content.ToImageURL()))
 						} else if content.Type == "resource" && content.Resource != nil && content.Resource.Annotations != nil && slices.Contains(content.Resource.Annotations.Audience, "assistant") {
 							if _, ok := types.TextMimeTypes[content.Resource.MIMEType]; ok {
 								text := content.Resource.Text
@@ -230,12 +341,7 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 								}
 								resultText += text
 							} else if _, ok := types.ImageMimeTypes[content.Resource.MIMEType]; ok {
-								// For tool results, we can't include images directly in OpenAI completions API
-								// So we'll just add a reference
-								if resultText != "" {
-									resultText += "\n"
-								}
-								resultText += fmt.Sprintf("[Image: %s]", content.Resource.URI)
+								toolImages = append(toolImages, imagePart(content.Resource.ToDataURI()))
 							} else if _, ok := types.PDFMimeTypes[content.Resource.MIMEType]; ok {
 								if resultText != "" {
 									resultText += "\n"
@@ -264,6 +370,7 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 
 		result.Messages = append(result.Messages, openAIMsg)
 	}
+	flushToolImages()
 
 	// Add system message if present
 	if req.SystemPrompt != "" {
@@ -279,3 +386,33 @@ func toRequest(req *types.CompletionRequest) (Request, error) {
 
 	return result, nil
 }
+
+func imagePart(url string) ContentPart {
+	return ContentPart{
+		Type: "image_url",
+		ImageURL: &ImageURL{
+			URL:    url,
+			Detail: "auto",
+		},
+	}
+}
+
+func toLogprobs(logprobs []TokenLogprob) []types.TokenLogprob {
+	result := make([]types.TokenLogprob, 0, len(logprobs))
+	for _, logprob := range logprobs {
+		token := types.TokenLogprob{
+			Token:   logprob.Token,
+			Logprob: logprob.Logprob,
+			Bytes:   logprob.Bytes,
+		}
+		for _, top := range logprob.TopLogprobs {
+			token.TopLogprobs = append(token.TopLogprobs, types.TopLogprob{
+				Token:   top.Token,
+				Logprob: top.Logprob,
+				Bytes:   top.Bytes,
+			})
+		}
+		result = append(result, token)
+	}
+	return result
+}
@@ -15,10 +15,22 @@ type Request struct {
 	StreamOptions    *StreamOptions        `json:"stream_options,omitempty"`
 	Stop             []string              `json:"stop,omitempty"`
 	ToolChoice       *ToolChoice           `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool                `json:"parallel_tool_calls,omitempty"`
 	Tools            []Tool                `json:"tools,omitempty"`
 	User             string                `json:"user,omitempty"`
 	Metadata         map[string]any        `json:"metadata,omitempty"`
 	ResponseFormat   *ResponseFormat       `json:"response_format,omitempty"`
+	ReasoningEffort  string                `json:"reasoning_effort,omitempty"`
+	Logprobs         bool                  `json:"logprobs,omitempty"`
+	TopLogprobs      *int                  `json:"top_logprobs,omitempty"`
+	Modalities       []string              `json:"modalities,omitempty"`
+	Audio            *AudioOptions         `json:"audio,omitempty"`
+}
+
+// AudioOptions configures the audio output, which must also be requested in the modalities.
+type AudioOptions struct {
+	Voice  string `json:"voice"`
+	Format string `json:"format"`
 }
 
 type StreamOptions struct {
@@ -32,6 +44,19 @@ type Message struct {
 	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
 	ToolCallID   string        `json:"tool_call_id,omitempty"`
 	Refusal      *string       `json:"refusal,omitempty"`
+	// ReasoningContent is returned by reasoning models that expose their thinking, it is never sent.
+	ReasoningContent *string `json:"reasoning_content,omitempty"`
+	// Audio is returned when audio output was requested.
+	Audio *MessageAudio `json:"audio,omitempty"`
+}
+
+// MessageAudio is the audio output of an assistant message. When streamed, Data and Transcript
+// are deltas.
+type MessageAudio struct {
+	ID         string `json:"id,omitempty"`
+	Data       string `json:"data,omitempty"`
+	ExpiresAt  int64  `json:"expires_at,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
 }
 
 type MessageContent struct {
@@ -63,9 +88,15 @@ func (m *MessageContent) UnmarshalJSON(data []byte) error {
 }
 
 type ContentPart struct {
-	Type     string     `json:"type"`
-	Text     string     `json:"text,omitempty"`
-	ImageURL *ImageURL  `json:"image_url,omitempty"`
+	Type       string      `json:"type"`
+	Text       string      `json:"text,omitempty"`
+	ImageURL   *ImageURL   `json:"image_url,omitempty"`
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+}
+
+type InputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
 }
 
 type ImageURL struct {
@@ -145,6 +176,9 @@ type Response struct {
 	Choices           []Choice `json:"choices"`
 	Usage             *Usage   `json:"usage,omitempty"`
 	SystemFingerprint *string  `json:"system_fingerprint,omitempty"`
+	PromptFilterResults []PromptFilterResult `json:"prompt_filter_results,omitempty"`
+	// PromptAnnotations is the name of PromptFilterResults in older Azure OpenAI API versions.
+	PromptAnnotations []PromptFilterResult `json:"prompt_annotations,omitempty"`
 }
 
 type Choice struct {
@@ -153,6 +187,23 @@ type Choice struct {
 	Delta        *ChoiceDelta   `json:"delta,omitempty"`
 	FinishReason *string        `json:"finish_reason,omitempty"`
 	Logprobs     *Logprobs      `json:"logprobs,omitempty"`
+	ContentFilterResults ContentFilterResults `json:"content_filter_results,omitempty"`
+}
+
+// PromptFilterResult holds the results of the content filter of Azure OpenAI for a prompt.
+type PromptFilterResult struct {
+	PromptIndex          int                  `json:"prompt_index"`
+	ContentFilterResults ContentFilterResults `json:"content_filter_results,omitempty"`
+}
+
+// ContentFilterResults are the results of the content filter of Azure OpenAI by category, such as
+// hate, self_harm, sexual, violence, jailbreak or protected_material_text.
+type ContentFilterResults map[string]ContentFilterResult
+
+type ContentFilterResult struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity,omitempty"`
+	Detected bool   `json:"detected,omitempty"`
 }
 
 type ChoiceDelta struct {
@@ -160,6 +211,22 @@ type ChoiceDelta struct {
 	Content      *string       `json:"content,omitempty"`
 	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
 	Refusal      *string       `json:"refusal,omitempty"`
+	// ReasoningContent and Reasoning carry the streamed thinking of reasoning models. Which field
+	// is used depends on the server, reasoning_content is the more common one.
+	ReasoningContent *string `json:"reasoning_content,omitempty"`
+	Reasoning        *string `json:"reasoning,omitempty"`
+	Audio            *MessageAudio `json:"audio,omitempty"`
+}
+
+// ReasoningText returns the reasoning delta, regardless of the field the server used for it.
+func (d ChoiceDelta) ReasoningText() string {
+	if d.ReasoningContent != nil {
+		return *d.ReasoningContent
+	}
+	if d.Reasoning != nil {
+		return *d.Reasoning
+	}
+	return ""
 }
 
 type ToolCall struct {
@@ -216,6 +283,8 @@ type StreamChunk struct {
 	Choices           []Choice `json:"choices"`
 	Usage             *Usage   `json:"usage,omitempty"`
 	SystemFingerprint *string  `json:"system_fingerprint,omitempty"`
+	PromptFilterResults []PromptFilterResult `json:"prompt_filter_results,omitempty"`
+	PromptAnnotations   []PromptFilterResult `json:"prompt_annotations,omitempty"`
 }
 
 // ErrorResponse represents an error from the API
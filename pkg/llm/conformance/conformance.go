@@ -0,0 +1,498 @@
+// Package conformance is the test suite that every LLM provider adapter has to pass. It checks that
+// streamed text and tool calls are reassembled correctly, both in the response and in the progress
+// events, that canceling a completion stops it while it streams, and that failed requests are
+// returned as apierror.Error. An adapter runs the suite from a test of its own package, with a
+// Provider that encodes the scripted responses of the suite in the wire format of its API:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, provider)
+//	}
+//
+//	func BenchmarkConformance(b *testing.B) {
+//		conformance.Benchmark(b, provider)
+//	}
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Provider is the adapter under test.
+type Provider struct {
+	// Name is the API named in the errors of the adapter, such as "Anthropic API".
+	Name string
+	// Path is the path the adapter posts completions to, such as /messages.
+	Path string
+	// New returns the adapter, sending its requests to baseURL.
+	New func(baseURL string) types.Completer
+	// Stream writes the script as the streamed response of the API.
+	Stream func(s *Stream, script Script)
+}
+
+// Script is a response of the model, independent of the API it is streamed with.
+type Script struct {
+	ID    string
+	Model string
+	// Text is streamed with one delta per element.
+	Text []string
+	// ToolCalls are streamed after the text.
+	ToolCalls    []ToolCall
+	InputTokens  int
+	OutputTokens int
+}
+
+// ToolCall is a tool call of a script.
+type ToolCall struct {
+	ID   string
+	Name string
+	// Arguments is streamed with one delta per element, the elements concatenate to a JSON object.
+	Arguments []string
+}
+
+// FullText returns the text of the script.
+func (s Script) FullText() string {
+	return strings.Join(s.Text, "")
+}
+
+// FullArguments returns the arguments of the tool call.
+func (t ToolCall) FullArguments() string {
+	return strings.Join(t.Arguments, "")
+}
+
+// Stream writes server-sent events. Every event is flushed to the client as it is written.
+type Stream struct {
+	w       io.Writer
+	flush   func()
+	written int
+	// before is called before each event is written, it stops the stream by returning false.
+	before  func(written int) bool
+	stopped bool
+}
+
+// Event writes an event with the JSON encoding of data. The event line is left out if event is
+// empty.
+func (s *Stream) Event(event string, data any) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		panic(fmt.Sprintf("failed to encode event: %v", err))
+	}
+	var buf bytes.Buffer
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+	fmt.Fprintf(&buf, "data: %s\n\n", encoded)
+	s.write(buf.Bytes())
+}
+
+// Data writes an event without a name.
+func (s *Stream) Data(data any) {
+	s.Event("", data)
+}
+
+// Done writes the [DONE] message that ends the streams of the OpenAI APIs.
+func (s *Stream) Done() {
+	s.write([]byte("data: [DONE]\n\n"))
+}
+
+func (s *Stream) write(data []byte) {
+	if s.stopped {
+		return
+	}
+	if s.before != nil && !s.before(s.written) {
+		s.stopped = true
+		return
+	}
+	s.written++
+	_, _ = s.w.Write(data)
+	if s.flush != nil {
+		s.flush()
+	}
+}
+
+// Render returns the body of the streamed response of the script.
+func (p Provider) Render(script Script) []byte {
+	var buf bytes.Buffer
+	p.Stream(&Stream{w: &buf}, script)
+	return buf.Bytes()
+}
+
+// server serves the streamed response of the script, or the status and body if status is set.
+func (p Provider) server(t testing.TB, script Script, status int, body string, before func(r *http.Request, written int) bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != p.Path {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		if status != 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		s := &Stream{
+			w:     w,
+			flush: w.(http.Flusher).Flush,
+		}
+		if before != nil {
+			s.before = func(written int) bool {
+				return before(r, written)
+			}
+		}
+		p.Stream(s, script)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+var request = types.CompletionRequest{
+	Model: "test-model",
+	Input: []types.Message{
+		{
+			Role: "user",
+			Items: []types.CompletionItem{
+				{Content: &mcp.Content{Type: "text", Text: "What is the weather in Paris?"}},
+			},
+		},
+	},
+	Tools: []types.ToolUseDefinition{
+		{
+			Name:        "get_weather",
+			Description: "Returns the weather of a city",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		},
+	},
+}
+
+// recorder collects the progress events of a completion.
+type recorder struct {
+	lock   sync.Mutex
+	events []types.CompletionProgress
+}
+
+func (r *recorder) context(ctx context.Context) context.Context {
+	return progress.WithListener(ctx, func(p *types.CompletionProgress) {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.events = append(r.events, *p)
+	})
+}
+
+// deltas returns the concatenated partial text and tool call arguments of the progress events, by
+// item ID.
+func (r *recorder) deltas() (text map[string]string, arguments map[string]string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	text, arguments = map[string]string{}, map[string]string{}
+	for _, event := range r.events {
+		switch {
+		case !event.Item.Partial:
+		case event.Item.Content != nil && event.Item.Content.Type == "text":
+			text[event.Item.ID] += event.Item.Content.Text
+		case event.Item.ToolCall != nil:
+			arguments[event.Item.ID] += event.Item.ToolCall.Arguments
+		}
+	}
+	return text, arguments
+}
+
+// Run runs the conformance tests of the suite against the provider.
+func Run(t *testing.T, p Provider) {
+	t.Run("Text", func(t *testing.T) {
+		testResponse(t, p, Script{
+			ID:           "msg-text",
+			Model:        "test-model",
+			Text:         []string{"The weather", " in Paris", " is sunny", ", 22°C 🌞."},
+			InputTokens:  12,
+			OutputTokens: 9,
+		})
+	})
+	t.Run("ToolCalls", func(t *testing.T) {
+		testResponse(t, p, Script{
+			ID:    "msg-tools",
+			Model: "test-model",
+			ToolCalls: []ToolCall{
+				{ID: "call-1", Name: "get_weather", Arguments: []string{`{"ci`, `ty": "Par`, `is"}`}},
+				{ID: "call-2", Name: "get_weather", Arguments: []string{`{"city":`, ` "Lyon"}`}},
+			},
+			InputTokens:  12,
+			OutputTokens: 20,
+		})
+	})
+	t.Run("TextAndToolCall", func(t *testing.T) {
+		testResponse(t, p, Script{
+			ID:    "msg-mixed",
+			Model: "test-model",
+			Text:  []string{"Let me check", " the weather."},
+			ToolCalls: []ToolCall{
+				{ID: "call-1", Name: "get_weather", Arguments: []string{`{"city": "Paris"}`}},
+			},
+			InputTokens:  12,
+			OutputTokens: 15,
+		})
+	})
+	t.Run("LargeEvent", func(t *testing.T) {
+		// Single events can be larger than the default buffer of bufio.Scanner.
+		testResponse(t, p, Script{
+			ID:    "msg-large",
+			Model: "test-model",
+			Text:  []string{strings.Repeat("All work and no play. ", 20_000)},
+			ToolCalls: []ToolCall{
+				{ID: "call-1", Name: "get_weather", Arguments: []string{`{"city": "` + strings.Repeat("x", 200_000) + `"}`}},
+			},
+		})
+	})
+	t.Run("Cancel", func(t *testing.T) {
+		testCancel(t, p)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		testErrors(t, p)
+	})
+}
+
+func testResponse(t *testing.T, p Provider, script Script) {
+	var (
+		srv = p.server(t, script, 0, "", nil)
+		rec = &recorder{}
+		ctx = rec.context(t.Context())
+	)
+
+	resp, err := p.New(srv.URL).Complete(ctx, request, types.CompletionOptions{ProgressToken: "progress"})
+	if err != nil {
+		t.Fatalf("completion failed: %v", err)
+	}
+
+	var (
+		text  strings.Builder
+		calls []types.ToolCall
+	)
+	for _, item := range resp.Output.Items {
+		if item.Content != nil && item.Content.Type == "text" {
+			text.WriteString(item.Content.Text)
+		}
+		if item.ToolCall != nil {
+			calls = append(calls, *item.ToolCall)
+		}
+	}
+	if text.String() != script.FullText() {
+		t.Errorf("expected the text %q, got %q", abbreviate(script.FullText()), abbreviate(text.String()))
+	}
+	if len(calls) != len(script.ToolCalls) {
+		t.Fatalf("expected %d tool calls, got %d: %+v", len(script.ToolCalls), len(calls), calls)
+	}
+	for i, expected := range script.ToolCalls {
+		if calls[i].CallID != expected.ID || calls[i].Name != expected.Name {
+			t.Errorf("tool call %d: expected %s %s, got %s %s", i, expected.ID, expected.Name, calls[i].CallID, calls[i].Name)
+		}
+		if !sameJSON(calls[i].Arguments, expected.FullArguments()) {
+			t.Errorf("tool call %d: expected the arguments %s, got %s", i, abbreviate(expected.FullArguments()), abbreviate(calls[i].Arguments))
+		}
+	}
+	if script.InputTokens > 0 && (resp.Usage == nil || resp.Usage.InputTokens != script.InputTokens || resp.Usage.OutputTokens != script.OutputTokens) {
+		t.Errorf("expected the usage %d/%d, got %+v", script.InputTokens, script.OutputTokens, resp.Usage)
+	}
+
+	// The deltas of the progress events add up to the same text and arguments as the response.
+	textDeltas, argumentDeltas := rec.deltas()
+	if script.FullText() != "" {
+		if len(textDeltas) != 1 {
+			t.Errorf("expected the text to be streamed as one item, got %d", len(textDeltas))
+		}
+		for _, streamed := range textDeltas {
+			if streamed != script.FullText() {
+				t.Errorf("expected the streamed text %q, got %q", abbreviate(script.FullText()), abbreviate(streamed))
+			}
+		}
+	}
+	streamed := slices.Sorted(maps.Values(argumentDeltas))
+	var expected []string
+	for _, call := range script.ToolCalls {
+		expected = append(expected, call.FullArguments())
+	}
+	slices.Sort(expected)
+	if !slices.Equal(streamed, expected) {
+		t.Errorf("expected the streamed arguments %v, got %v", abbreviateAll(expected), abbreviateAll(streamed))
+	}
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	for _, event := range rec.events {
+		if event.MessageID == "" {
+			t.Fatalf("progress event without a message ID: %+v", event)
+		}
+	}
+}
+
+func testCancel(t *testing.T, p Provider) {
+	var (
+		blocked  = make(chan struct{})
+		canceled = make(chan struct{})
+		once     sync.Once
+	)
+	// The stream stops after its first event until the client goes away.
+	srv := p.server(t, Script{
+		ID:    "msg-cancel",
+		Model: "test-model",
+		Text:  []string{"one", " two", " three"},
+	}, 0, "", func(r *http.Request, written int) bool {
+		if written == 0 {
+			return true
+		}
+		once.Do(func() {
+			close(blocked)
+		})
+		select {
+		case <-r.Context().Done():
+			close(canceled)
+		case <-time.After(10 * time.Second):
+		}
+		return false
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() {
+		<-blocked
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.New(srv.URL).Complete(ctx, request, types.CompletionOptions{ProgressToken: "progress"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the completion to fail with context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the completion did not stop when it was canceled")
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Error("the request was not canceled")
+	}
+}
+
+func testErrors(t *testing.T, p Provider) {
+	for _, tt := range []struct {
+		status int
+		body   string
+		kind   apierror.Kind
+	}{
+		{http.StatusUnauthorized, `{"error":{"type":"authentication_error","code":"invalid_api_key","message":"invalid key"}}`, apierror.KindAuthentication},
+		{http.StatusTooManyRequests, `{"error":{"type":"rate_limit_error","code":"rate_limit_exceeded","message":"slow down"}}`, apierror.KindRateLimited},
+		{http.StatusServiceUnavailable, `{"error":{"type":"overloaded_error","message":"overloaded"}}`, apierror.KindOverloaded},
+		{http.StatusBadRequest, `{"error":{"type":"invalid_request_error","message":"bad request"}}`, apierror.KindUnknown},
+	} {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv := p.server(t, Script{}, tt.status, tt.body, nil)
+
+			_, err := p.New(srv.URL).Complete(t.Context(), request)
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an apierror.Error, got %T: %v", err, err)
+			}
+			if apiErr.API != p.Name || apiErr.Code != tt.status || apiErr.Body != tt.body {
+				t.Errorf("expected %s to return %d %s, got %s %d %s", p.Name, tt.status, tt.body, apiErr.API, apiErr.Code, apiErr.Body)
+			}
+			if apiErr.Kind() != tt.kind {
+				t.Errorf("expected the kind %q, got %q", tt.kind, apiErr.Kind())
+			}
+		})
+	}
+}
+
+// Benchmark measures how fast the provider reassembles long streamed responses.
+func Benchmark(b *testing.B, p Provider) {
+	text := make([]string, 2000)
+	for i := range text {
+		text[i] = fmt.Sprintf("word%d ", i)
+	}
+	arguments := []string{`{"text": "`}
+	for i := range 500 {
+		arguments = append(arguments, fmt.Sprintf("chunk%d ", i))
+	}
+	arguments = append(arguments, `"}`)
+
+	for _, bb := range []struct {
+		name   string
+		script Script
+	}{
+		{"Text", Script{ID: "msg-bench", Model: "test-model", Text: text}},
+		{"ToolCall", Script{ID: "msg-bench", Model: "test-model", ToolCalls: []ToolCall{{ID: "call-1", Name: "get_weather", Arguments: arguments}}}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			body := p.Render(bb.script)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write(body)
+			}))
+			defer srv.Close()
+
+			var (
+				client = p.New(srv.URL)
+				ctx    = progress.WithListener(b.Context(), func(*types.CompletionProgress) {})
+			)
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := client.Complete(ctx, request, types.CompletionOptions{ProgressToken: "progress"}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func sameJSON(a, b string) bool {
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return a == b
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func abbreviate(s string) string {
+	if len(s) > 100 {
+		return fmt.Sprintf("%s...(%d bytes)", s[:100], len(s))
+	}
+	return s
+}
+
+func abbreviateAll(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, abbreviate(v))
+	}
+	return result
+}
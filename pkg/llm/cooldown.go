@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/quota"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type CooldownConfig struct {
+	// Threshold is the share of a rate limit of a provider, between 0 and 1, below which requests
+	// to the provider are slowed down or rerouted. Default 0.1.
+	Threshold float64
+	// MaxDelay caps how long a request is slowed down. Default 30s.
+	MaxDelay time.Duration
+	// Fallback is the model that requests are sent to instead of slowing them down, if its provider
+	// has headroom left.
+	Fallback string
+}
+
+func (c CooldownConfig) Merge(other CooldownConfig) (result CooldownConfig) {
+	result.Threshold = complete.Last(c.Threshold, other.Threshold)
+	result.MaxDelay = complete.Last(c.MaxDelay, other.MaxDelay)
+	result.Fallback = complete.Last(c.Fallback, other.Fallback)
+	return
+}
+
+// QuotaCooldown returns a middleware that watches the rate limits the providers report and, once
+// less than Threshold of a limit is left, sends requests to the Fallback model or delays them, the
+// longer the less is left, so the provider is not pushed into rejecting them with 429s.
+func QuotaCooldown(cfg CooldownConfig) Middleware {
+	cfg = CooldownConfig{
+		Threshold: 0.1,
+		MaxDelay:  30 * time.Second,
+	}.Merge(cfg)
+
+	return func(next types.Completer) types.Completer {
+		return CompleterFunc(func(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+			now := time.Now()
+			provider := providerName(req.Model)
+			headroom, ok := quota.Get(provider)
+			if !ok || headroom.Ratio(now) >= cfg.Threshold {
+				return next.Complete(ctx, req, opts...)
+			}
+
+			if fallback := providerName(cfg.Fallback); cfg.Fallback != "" && fallback != provider && hasHeadroom(fallback, now, cfg.Threshold) {
+				log.Infof(ctx, "rate limit of LLM provider %s is almost exhausted, sending the request for model %s to %s", provider, req.Model, cfg.Fallback)
+				req.Model = cfg.Fallback
+				return next.Complete(ctx, req, opts...)
+			}
+
+			if wait := min(headroom.Wait(now, cfg.Threshold), cfg.MaxDelay); wait > 0 {
+				log.Debugf(ctx, "rate limit of LLM provider %s is almost exhausted, delaying the request by %s", provider, wait)
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+			return next.Complete(ctx, req, opts...)
+		})
+	}
+}
+
+func hasHeadroom(provider string, now time.Time, threshold float64) bool {
+	headroom, ok := quota.Get(provider)
+	return !ok || headroom.Ratio(now) >= threshold
+}
@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/quota"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestQuotaCooldown(t *testing.T) {
+	header := http.Header{}
+	header.Set("x-ratelimit-limit-requests", "100")
+	header.Set("x-ratelimit-remaining-requests", "1")
+	header.Set("x-ratelimit-reset-requests", "1m")
+	quota.Observe("openai", header)
+
+	var models []string
+	next := CompleterFunc(func(_ context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+		models = append(models, req.Model)
+		return &types.CompletionResponse{}, nil
+	})
+
+	// Requests are sent to the fallback model of another provider.
+	completer := QuotaCooldown(CooldownConfig{Fallback: "claude-sonnet-4"})(next)
+	if _, err := completer.Complete(t.Context(), types.CompletionRequest{Model: "gpt-4.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0] != "claude-sonnet-4" {
+		t.Fatalf("expected the request to be rerouted, got models %v", models)
+	}
+
+	// Without a fallback, requests are delayed up to the max delay.
+	completer = QuotaCooldown(CooldownConfig{MaxDelay: 20 * time.Millisecond})(next)
+	start := time.Now()
+	if _, err := completer.Complete(t.Context(), types.CompletionRequest{Model: "gpt-4.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || models[1] != "gpt-4.1" {
+		t.Fatalf("expected the request to be delayed, took %s to model %s", elapsed, models[1])
+	}
+}
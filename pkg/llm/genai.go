@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/telemetry"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// genAIMessage is a message in the format of the gen_ai.input.messages and gen_ai.output.messages
+// attributes of the OpenTelemetry GenAI semantic conventions.
+type genAIMessage struct {
+	Role         string      `json:"role"`
+	Parts        []genAIPart `json:"parts"`
+	FinishReason string      `json:"finish_reason,omitempty"`
+}
+
+type genAIPart struct {
+	Type      string `json:"type"`
+	Content   string `json:"content,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments any    `json:"arguments,omitempty"`
+	Response  any    `json:"response,omitempty"`
+	Modality  string `json:"modality,omitempty"`
+	MIMEType  string `json:"mime_type,omitempty"`
+	URI       string `json:"uri,omitempty"`
+}
+
+// contentAttributes returns the prompt and completion of a request as span attributes.
+func contentAttributes(req types.CompletionRequest, resp *types.CompletionResponse) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		telemetry.JSON("gen_ai.input.messages", genAIMessages(req.Input)),
+	}
+	if req.SystemPrompt != "" {
+		attrs = append(attrs, telemetry.JSON("gen_ai.system_instructions", []genAIPart{
+			{Type: "text", Content: req.SystemPrompt},
+		}))
+	}
+	if resp != nil {
+		output := genAIMessages([]types.Message{resp.Output})
+		if len(output) > 0 {
+			output[0].FinishReason = resp.StopReason
+		}
+		attrs = append(attrs, telemetry.JSON("gen_ai.output.messages", output))
+	}
+	return attrs
+}
+
+func genAIMessages(msgs []types.Message) []genAIMessage {
+	result := make([]genAIMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		// Tool results are sent by nanobot in messages of the user, the conventions give them their
+		// own role.
+		var current *genAIMessage
+		for _, item := range msg.Items {
+			role := msg.Role
+			if item.ToolCallResult != nil {
+				role = "tool"
+			}
+			if current == nil || current.Role != role {
+				result = append(result, genAIMessage{Role: role})
+				current = &result[len(result)-1]
+			}
+			current.Parts = append(current.Parts, genAIParts(item)...)
+		}
+	}
+	return result
+}
+
+func genAIParts(item types.CompletionItem) []genAIPart {
+	switch {
+	case item.Content != nil:
+		return []genAIPart{genAIContent(*item.Content)}
+	case item.ToolCall != nil:
+		var args any = item.ToolCall.Arguments
+		if json.Valid([]byte(item.ToolCall.Arguments)) {
+			args = json.RawMessage(item.ToolCall.Arguments)
+		}
+		return []genAIPart{{
+			Type:      "tool_call",
+			ID:        item.ToolCall.CallID,
+			Name:      item.ToolCall.Name,
+			Arguments: args,
+		}}
+	case item.ToolCallResult != nil:
+		var texts []string
+		for _, content := range item.ToolCallResult.Output.Content {
+			if content.Text != "" {
+				texts = append(texts, content.Text)
+			}
+		}
+		var response any = strings.Join(texts, "\n")
+		if item.ToolCallResult.Output.StructuredContent != nil {
+			response = item.ToolCallResult.Output.StructuredContent
+		}
+		return []genAIPart{{
+			Type:     "tool_call_response",
+			ID:       item.ToolCallResult.CallID,
+			Response: response,
+		}}
+	case item.Reasoning != nil:
+		var parts []genAIPart
+		for _, summary := range item.Reasoning.Summary {
+			parts = append(parts, genAIPart{Type: "reasoning", Content: summary.Text})
+		}
+		return parts
+	}
+	return nil
+}
+
+// genAIContent converts content, the data of images and audio is not included as it would exceed
+// the size limits of the exporters.
+func genAIContent(content mcp.Content) genAIPart {
+	switch content.Type {
+	case "", "text":
+		return genAIPart{Type: "text", Content: content.Text}
+	case "image", "audio":
+		if content.URI != "" {
+			return genAIPart{Type: "uri", Modality: content.Type, MIMEType: content.MIMEType, URI: content.URI}
+		}
+		return genAIPart{Type: "blob", Modality: content.Type, MIMEType: content.MIMEType}
+	case "resource_link":
+		return genAIPart{Type: "uri", MIMEType: content.MIMEType, URI: content.URI}
+	case "resource":
+		if content.Resource != nil {
+			return genAIPart{Type: "text", Content: content.Resource.Text}
+		}
+	}
+	return genAIPart{Type: content.Type}
+}
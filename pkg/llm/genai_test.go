@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestGenAIMessages(t *testing.T) {
+	msgs := genAIMessages([]types.Message{
+		{
+			Role: "user",
+			Items: []types.CompletionItem{
+				{Content: &mcp.Content{Type: "text", Text: "weather in Paris?"}},
+			},
+		},
+		{
+			Role: "assistant",
+			Items: []types.CompletionItem{
+				{ToolCall: &types.ToolCall{CallID: "1", Name: "weather", Arguments: `{"city":"Paris"}`}},
+			},
+		},
+		{
+			Role: "user",
+			Items: []types.CompletionItem{
+				{ToolCallResult: &types.ToolCallResult{CallID: "1", Output: types.CallResult{
+					Content: []mcp.Content{{Type: "text", Text: "sunny"}},
+				}}},
+				{Content: &mcp.Content{Type: "image", Data: "aGVsbG8=", MIMEType: "image/png"}},
+			},
+		},
+	})
+
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"role":"user","parts":[{"type":"text","content":"weather in Paris?"}]},` +
+		`{"role":"assistant","parts":[{"type":"tool_call","id":"1","name":"weather","arguments":{"city":"Paris"}}]},` +
+		`{"role":"tool","parts":[{"type":"tool_call_response","id":"1","response":"sunny"}]},` +
+		`{"role":"user","parts":[{"type":"blob","modality":"image","mime_type":"image/png"}]}]`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Middleware wraps a completer to observe or modify completions. Middleware sees the request after
+// the default model has been resolved and the prompt has been normalized, right before it is sent
+// to the provider, and sees the provider's response before it is returned to the agent.
+type Middleware func(next types.Completer) types.Completer
+
+// CompleterFunc adapts a function to the types.Completer interface.
+type CompleterFunc func(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error)
+
+func (f CompleterFunc) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	return f(ctx, req, opts...)
+}
+
+// Chain wraps the completer with the middleware. The first middleware is the outermost, so it
+// sees the request first and the response last.
+func Chain(completer types.Completer, middleware ...Middleware) types.Completer {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] != nil {
+			completer = middleware[i](completer)
+		}
+	}
+	return completer
+}
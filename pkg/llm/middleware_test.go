@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+
+	named := func(name string) Middleware {
+		return func(next types.Completer) types.Completer {
+			return CompleterFunc(func(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+				calls = append(calls, name+":before")
+				resp, err := next.Complete(ctx, req, opts...)
+				calls = append(calls, name+":after")
+				return resp, err
+			})
+		}
+	}
+
+	completer := Chain(CompleterFunc(func(context.Context, types.CompletionRequest, ...types.CompletionOptions) (*types.CompletionResponse, error) {
+		calls = append(calls, "provider")
+		return &types.CompletionResponse{}, nil
+	}), named("outer"), nil, named("inner"))
+
+	if _, err := completer.Complete(context.Background(), types.CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"outer:before", "inner:before", "provider", "inner:after", "outer:after"}
+	if !slices.Equal(calls, expected) {
+		t.Errorf("expected %v, got %v", expected, calls)
+	}
+}
@@ -0,0 +1,51 @@
+// Package pricing estimates the cost of the tokens used by completions from the prices of the
+// models, which are supplied by the user since providers do not report them.
+package pricing
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Price is the price of the tokens of a model in USD per million tokens.
+type Price struct {
+	Input float64 `json:"input"`
+	// CachedInput is the price of cached input tokens, the price of input tokens if not set.
+	CachedInput float64 `json:"cachedInput,omitempty"`
+	Output      float64 `json:"output"`
+}
+
+// Table holds the prices of models by model name.
+type Table map[string]Price
+
+// Load reads a table from a YAML or JSON file.
+func Load(path string) (Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing %s: %w", path, err)
+	}
+	var table Table
+	if err := yaml.UnmarshalStrict(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing %s: %w", path, err)
+	}
+	return table, nil
+}
+
+// Cost estimates the cost in USD of the usage of a model, zero if its price is unknown. Cached
+// input tokens are counted in the input tokens.
+func (t Table) Cost(model string, usage types.Usage) float64 {
+	price, ok := t[model]
+	if !ok {
+		return 0
+	}
+	cachedPrice := price.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+	uncached := max(usage.InputTokens-usage.CachedInputTokens, 0)
+	return (float64(uncached)*price.Input + float64(usage.CachedInputTokens)*cachedPrice +
+		float64(usage.OutputTokens)*price.Output) / 1_000_000
+}
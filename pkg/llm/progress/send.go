@@ -7,14 +7,58 @@ import (
 	"github.com/nanobot-ai/nanobot/pkg/types"
 )
 
+// Listener receives every completion progress event sent with a context it was registered on.
+type Listener func(progress *types.CompletionProgress)
+
+// Filter decides if a progress event should be delivered. Returning false drops the event.
+type Filter func(progress *types.CompletionProgress) bool
+
+type (
+	listenerKey struct{}
+	filterKey   struct{}
+)
+
+// WithListener returns a context that will pass all progress events sent with it to the listener
+// in addition to the session. Listeners are chained, so registering a new listener does not hide
+// one that was previously registered.
+func WithListener(ctx context.Context, listener Listener) context.Context {
+	if parent, ok := ctx.Value(listenerKey{}).(Listener); ok {
+		next := listener
+		listener = func(progress *types.CompletionProgress) {
+			next(progress)
+			parent(progress)
+		}
+	}
+	return context.WithValue(ctx, listenerKey{}, listener)
+}
+
+// WithFilter returns a context that drops progress events for which the filter returns false.
+// Filters are chained, an event is only delivered if all filters accept it.
+func WithFilter(ctx context.Context, filter Filter) context.Context {
+	if parent, ok := ctx.Value(filterKey{}).(Filter); ok {
+		next := filter
+		filter = func(progress *types.CompletionProgress) bool {
+			return next(progress) && parent(progress)
+		}
+	}
+	return context.WithValue(ctx, filterKey{}, filter)
+}
+
 func Send(ctx context.Context, progress *types.CompletionProgress, progressToken any) {
 	if progressToken == "" || progressToken == nil {
 		return
 	}
+	if filter, ok := ctx.Value(filterKey{}).(Filter); ok && !filter(progress) {
+		return
+	}
+	if listener, ok := ctx.Value(listenerKey{}).(Listener); ok {
+		listener(progress)
+	}
 	session := mcp.SessionFromContext(ctx)
 	if session == nil {
 		return
 	}
+	progress.Ephemeral = progress.Ephemeral || types.IsEphemeral(session)
 
 	_ = session.SendPayload(ctx, "notifications/progress", mcp.NotificationProgressRequest{
 		ProgressToken: progressToken,
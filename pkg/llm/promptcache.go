@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// optimizeForPromptCache arranges the request so that the parts of the prompt that rarely change
+// between turns (system prompt and tool definitions) are serialized byte-for-byte identically on
+// every turn and sit ahead of the volatile conversation history. Providers only reuse a cached
+// prefix if it is an exact match, so any reordering or whitespace drift in the tool block busts
+// the cache for the entire conversation.
+func optimizeForPromptCache(req types.CompletionRequest) types.CompletionRequest {
+	req.SystemPrompt = strings.TrimSpace(req.SystemPrompt)
+
+	// System messages at the start of the history are moved into the system prompt so they become
+	// part of the stable prefix. Later system messages, such as summaries and reminders, stay where
+	// they are: moving them would change their meaning and rewrite the cached prefix every time one
+	// is added.
+	var system []string
+	leading := 0
+	for _, msg := range req.Input {
+		if msg.Role != "system" || !isTextOnly(msg) {
+			break
+		}
+		for _, item := range msg.Items {
+			system = append(system, strings.TrimSpace(item.Content.Text))
+		}
+		leading++
+	}
+	if leading > 0 {
+		req.SystemPrompt = strings.TrimSpace(strings.Join(append([]string{req.SystemPrompt}, system...), "\n\n"))
+		req.Input = req.Input[leading:]
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]types.ToolUseDefinition, len(req.Tools))
+		copy(tools, req.Tools)
+		slices.SortStableFunc(tools, func(a, b types.ToolUseDefinition) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		for i := range tools {
+			tools[i].Parameters = canonicalJSON(tools[i].Parameters)
+			tools[i].Description = strings.TrimSpace(tools[i].Description)
+		}
+		req.Tools = tools
+	}
+
+	return req
+}
+
+func isTextOnly(msg types.Message) bool {
+	if len(msg.Items) == 0 {
+		return false
+	}
+	for _, item := range msg.Items {
+		if item.Content == nil || item.Content.Type != "text" {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalJSON re-encodes the JSON document with sorted object keys and no insignificant
+// whitespace. If the data can not be parsed it is returned as is.
+func canonicalJSON(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return data
+	}
+
+	var obj any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return data
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(obj); err != nil {
+		return data
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
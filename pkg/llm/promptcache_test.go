@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestOptimizeForPromptCache(t *testing.T) {
+	req := optimizeForPromptCache(types.CompletionRequest{
+		SystemPrompt: "  be helpful\n",
+		Input: []types.Message{
+			{
+				Role: "system",
+				Items: []types.CompletionItem{
+					{Content: &mcp.Content{Type: "text", Text: "be brief"}},
+				},
+			},
+			{
+				Role: "user",
+				Items: []types.CompletionItem{
+					{Content: &mcp.Content{Type: "text", Text: "hi"}},
+				},
+			},
+			{
+				Role: "system",
+				Items: []types.CompletionItem{
+					{Content: &mcp.Content{Type: "text", Text: "summary of the conversation"}},
+				},
+			},
+		},
+		Tools: []types.ToolUseDefinition{
+			{Name: "b", Parameters: json.RawMessage(`{"type": "object", "properties": {}}`)},
+			{Name: "a", Parameters: json.RawMessage(`{"properties":{"z":{"type":"string"},"a":{"type":"number"}},"type":"object"}`)},
+		},
+	})
+
+	if req.SystemPrompt != "be helpful\n\nbe brief" {
+		t.Errorf("unexpected system prompt %q", req.SystemPrompt)
+	}
+	if len(req.Input) != 2 || req.Input[0].Role != "user" || req.Input[1].Role != "system" {
+		t.Errorf("expected only the leading system message to be removed from history, got %v", req.Input)
+	}
+	if req.Tools[0].Name != "a" || req.Tools[1].Name != "b" {
+		t.Errorf("expected tools to be sorted by name, got %s, %s", req.Tools[0].Name, req.Tools[1].Name)
+	}
+	if got := string(req.Tools[0].Parameters); got != `{"properties":{"a":{"type":"number"},"z":{"type":"string"}},"type":"object"}` {
+		t.Errorf("unexpected canonical schema %s", got)
+	}
+	if got := string(req.Tools[1].Parameters); got != `{"properties":{},"type":"object"}` {
+		t.Errorf("unexpected canonical schema %s", got)
+	}
+}
@@ -0,0 +1,177 @@
+// Package quota tracks the rate limits that LLM providers report in the headers of their
+// responses, so traffic can be slowed down or moved to another model before a provider starts to
+// reject requests.
+package quota
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/metrics"
+)
+
+const (
+	// RequestsLimit and TokensLimit are the rate limits of a provider.
+	RequestsLimit = "requests"
+	TokensLimit   = "tokens"
+)
+
+// Limit is the state of a rate limit of a provider.
+type Limit struct {
+	Limit     int64 `json:"limit"`
+	Remaining int64 `json:"remaining"`
+	// Reset is when the limit is available again, zero if the provider did not say.
+	Reset time.Time `json:"reset,omitzero"`
+}
+
+// Ratio is the share of the limit that is left, 1 once the limit was reset.
+func (l Limit) Ratio(now time.Time) float64 {
+	if l.Limit <= 0 || !l.Reset.IsZero() && !now.Before(l.Reset) {
+		return 1
+	}
+	return max(0, min(1, float64(l.Remaining)/float64(l.Limit)))
+}
+
+// Headroom is what is left of the rate limits of a provider, as reported by its last response.
+type Headroom struct {
+	Provider string    `json:"provider"`
+	Requests *Limit    `json:"requests,omitempty"`
+	Tokens   *Limit    `json:"tokens,omitempty"`
+	Updated  time.Time `json:"updated"`
+}
+
+// lowest returns the limit with the smallest share left.
+func (h Headroom) lowest(now time.Time) *Limit {
+	switch {
+	case h.Requests == nil:
+		return h.Tokens
+	case h.Tokens == nil || h.Requests.Ratio(now) <= h.Tokens.Ratio(now):
+		return h.Requests
+	default:
+		return h.Tokens
+	}
+}
+
+// Ratio is the share of the most exhausted limit of the provider that is left.
+func (h Headroom) Ratio(now time.Time) float64 {
+	if l := h.lowest(now); l != nil {
+		return l.Ratio(now)
+	}
+	return 1
+}
+
+// Wait is how long a request should wait while the headroom is below threshold. It grows from
+// nothing at the threshold to the time until the limit resets when nothing is left.
+func (h Headroom) Wait(now time.Time, threshold float64) time.Duration {
+	l := h.lowest(now)
+	if l == nil || l.Reset.IsZero() || threshold <= 0 {
+		return 0
+	}
+	ratio := l.Ratio(now)
+	if ratio >= threshold {
+		return 0
+	}
+	return time.Duration(float64(l.Reset.Sub(now)) * (1 - ratio/threshold))
+}
+
+var (
+	lock      sync.Mutex
+	headrooms = map[string]Headroom{}
+)
+
+// Observe records the rate limits in the headers of a response of provider. Responses without
+// rate limit headers are ignored.
+func Observe(provider string, header http.Header) {
+	now := time.Now()
+	headroom, ok := Parse(header, now)
+	if !ok {
+		return
+	}
+	headroom.Provider = provider
+	headroom.Updated = now
+
+	lock.Lock()
+	headrooms[provider] = headroom
+	lock.Unlock()
+
+	if headroom.Requests != nil {
+		metrics.SetQuotaHeadroom(provider, RequestsLimit, headroom.Requests.Ratio(now))
+	}
+	if headroom.Tokens != nil {
+		metrics.SetQuotaHeadroom(provider, TokensLimit, headroom.Tokens.Ratio(now))
+	}
+}
+
+// Get returns the last headroom reported by provider.
+func Get(provider string) (Headroom, bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	headroom, ok := headrooms[provider]
+	return headroom, ok
+}
+
+// List returns the last headroom reported by each provider, by name of the provider.
+func List() []Headroom {
+	lock.Lock()
+	defer lock.Unlock()
+	result := make([]Headroom, 0, len(headrooms))
+	for _, provider := range slices.Sorted(maps.Keys(headrooms)) {
+		result = append(result, headrooms[provider])
+	}
+	return result
+}
+
+// Parse reads the rate limit headers of OpenAI (x-ratelimit-remaining-requests) and Anthropic
+// (anthropic-ratelimit-requests-remaining). It returns false if there are none.
+func Parse(header http.Header, now time.Time) (Headroom, bool) {
+	var headroom Headroom
+	for _, limit := range []string{RequestsLimit, TokensLimit} {
+		l := parseLimit(header, now, "x-ratelimit-limit-"+limit, "x-ratelimit-remaining-"+limit, "x-ratelimit-reset-"+limit)
+		if l == nil {
+			l = parseLimit(header, now, "anthropic-ratelimit-"+limit+"-limit", "anthropic-ratelimit-"+limit+"-remaining", "anthropic-ratelimit-"+limit+"-reset")
+		}
+		if limit == RequestsLimit {
+			headroom.Requests = l
+		} else {
+			headroom.Tokens = l
+		}
+	}
+	return headroom, headroom.Requests != nil || headroom.Tokens != nil
+}
+
+func parseLimit(header http.Header, now time.Time, limitKey, remainingKey, resetKey string) *Limit {
+	limit, err := strconv.ParseInt(header.Get(limitKey), 10, 64)
+	if err != nil {
+		return nil
+	}
+	remaining, err := strconv.ParseInt(header.Get(remainingKey), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &Limit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     parseReset(header.Get(resetKey), now),
+	}
+}
+
+// parseReset reads a duration like 6m0s (OpenAI), a time in RFC 3339 (Anthropic) or seconds.
+func parseReset(value string, now time.Time) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d)
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return now.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	return time.Time{}
+}
@@ -0,0 +1,50 @@
+package quota
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	openai := http.Header{}
+	openai.Set("x-ratelimit-limit-requests", "100")
+	openai.Set("x-ratelimit-remaining-requests", "5")
+	openai.Set("x-ratelimit-reset-requests", "10s")
+	openai.Set("x-ratelimit-limit-tokens", "10000")
+	openai.Set("x-ratelimit-remaining-tokens", "9000")
+
+	headroom, ok := Parse(openai, now)
+	if !ok || headroom.Requests == nil || headroom.Tokens == nil {
+		t.Fatalf("expected both limits, got %+v", headroom)
+	}
+	if ratio := headroom.Ratio(now); ratio != 0.05 {
+		t.Fatalf("expected the requests limit to be the lowest, got ratio %v", ratio)
+	}
+	// Half of the threshold is left, so half of the time until the reset is waited.
+	if wait := headroom.Wait(now, 0.1); wait != 5*time.Second {
+		t.Fatalf("expected to wait 5s, got %s", wait)
+	}
+	if wait := headroom.Wait(now, 0.01); wait != 0 {
+		t.Fatalf("expected no wait above the threshold, got %s", wait)
+	}
+	if ratio := headroom.Ratio(now.Add(time.Minute)); ratio != 0.9 {
+		t.Fatalf("expected the requests limit to be reset, got ratio %v", ratio)
+	}
+
+	anthropic := http.Header{}
+	anthropic.Set("anthropic-ratelimit-tokens-limit", "1000")
+	anthropic.Set("anthropic-ratelimit-tokens-remaining", "0")
+	anthropic.Set("anthropic-ratelimit-tokens-reset", now.Add(time.Minute).Format(time.RFC3339))
+
+	headroom, ok = Parse(anthropic, now)
+	if !ok || headroom.Requests != nil || headroom.Ratio(now) != 0 || headroom.Wait(now, 0.1) != time.Minute {
+		t.Fatalf("unexpected headroom %+v", headroom)
+	}
+
+	if _, ok := Parse(http.Header{}, now); ok {
+		t.Fatal("expected no headroom without rate limit headers")
+	}
+}
@@ -0,0 +1,99 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+// mockWords is the number of words of a mock response, each streamed as a progress event.
+const mockWords = 20
+
+// Mock answers every completion with synthetic text after a fixed latency, so the rest of the
+// runtime can be load tested without a provider.
+type Mock struct {
+	latency time.Duration
+}
+
+func NewMock(latency time.Duration) *Mock {
+	return &Mock{
+		latency: latency,
+	}
+}
+
+func (m *Mock) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	var inputSize int
+	for _, msg := range req.Input {
+		for _, item := range msg.Items {
+			if item.Content != nil {
+				inputSize += len(item.Content.Text)
+			}
+		}
+	}
+
+	words := make([]string, mockWords)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i+1)
+	}
+
+	now := time.Now()
+	resp := &types.CompletionResponse{
+		Model: req.Model,
+		Output: types.Message{
+			ID:      uuid.String(),
+			Created: &now,
+			Role:    "assistant",
+			Items: []types.CompletionItem{
+				{
+					ID: uuid.String(),
+					Content: &mcp.Content{
+						Type: "text",
+						Text: strings.Join(words, " "),
+					},
+				},
+			},
+		},
+		Usage: &types.Usage{
+			// Roughly four characters per token.
+			InputTokens:  (len(req.SystemPrompt)+inputSize)/4 + 1,
+			OutputTokens: len(words),
+		},
+		StopReason: "stop",
+	}
+
+	progressToken := complete.Complete(opts...).ProgressToken
+	interval := m.latency / mockWords
+	for i := range words {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		if progressToken != nil {
+			progress.Send(ctx, &types.CompletionProgress{
+				Model:     resp.Model,
+				Agent:     req.Agent,
+				MessageID: resp.Output.ID,
+				Role:      resp.Output.Role,
+				Item: types.CompletionItem{
+					ID:      resp.Output.Items[0].ID,
+					Partial: true,
+					HasMore: true,
+					Content: &mcp.Content{
+						Type: "text",
+						Text: words[i] + " ",
+					},
+				},
+			}, progressToken)
+		}
+	}
+
+	return resp, nil
+}
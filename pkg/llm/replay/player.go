@@ -0,0 +1,56 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Player answers completions from recordings made by a Recorder. Identical requests are answered
+// with the recorded responses in the order they were recorded; once exhausted the last response
+// is repeated.
+type Player struct {
+	dir   string
+	lock  sync.Mutex
+	count map[string]int
+}
+
+func NewPlayer(dir string) *Player {
+	return &Player{
+		dir:   dir,
+		count: map[string]int{},
+	}
+}
+
+func (p *Player) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	key := Key(req)
+	interactions, err := readInteractions(p.dir, key)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && len(interactions) == 0) {
+		return nil, fmt.Errorf("no recorded completion for request %s (model %q, agent %q) in %s", key, req.Model, req.Agent, p.dir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	i := min(p.count[key], len(interactions)-1)
+	p.count[key]++
+	p.lock.Unlock()
+
+	interaction := interactions[i]
+	if progressToken := complete.Complete(opts...).ProgressToken; progressToken != nil {
+		for _, event := range interaction.Progress {
+			progress.Send(ctx, event, progressToken)
+		}
+	}
+
+	if interaction.Error != "" {
+		return interaction.Response, errors.New(interaction.Error)
+	}
+	return interaction.Response, nil
+}
@@ -0,0 +1,78 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Recorder passes completions through to another completer and saves every interaction to disk.
+type Recorder struct {
+	dir  string
+	next types.Completer
+	lock sync.Mutex
+}
+
+func NewRecorder(dir string, next types.Completer) *Recorder {
+	return &Recorder{
+		dir:  dir,
+		next: next,
+	}
+}
+
+func (r *Recorder) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	var (
+		interaction = Interaction{
+			Request: req,
+		}
+		progressLock sync.Mutex
+	)
+
+	ctx = progress.WithListener(ctx, func(p *types.CompletionProgress) {
+		progressLock.Lock()
+		defer progressLock.Unlock()
+		copied := *p
+		interaction.Progress = append(interaction.Progress, &copied)
+	})
+
+	resp, err := r.next.Complete(ctx, req, opts...)
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	interaction.Response = resp
+
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	if saveErr := r.save(Key(req), interaction); saveErr != nil {
+		return resp, errors.Join(err, fmt.Errorf("failed to record completion: %w", saveErr))
+	}
+	return resp, err
+}
+
+func (r *Recorder) save(key string, interaction Interaction) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+
+	interactions, err := readInteractions(r.dir, key)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	interactions = append(interactions, interaction)
+
+	data, err := json.MarshalIndent(interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename(r.dir, key), data, 0o644)
+}
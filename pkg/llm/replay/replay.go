@@ -0,0 +1,134 @@
+// Package replay provides completers that record real completions to disk and replay them
+// deterministically, so agents can be tested without network access.
+package replay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const (
+	ModeRecord = "record"
+	ModeReplay = "replay"
+	ModeMock   = "mock"
+)
+
+type Config struct {
+	// Mode is either "record", "replay", or "mock". If empty, completions are not intercepted.
+	Mode string
+	// Dir is the directory recordings are written to and read from.
+	Dir string
+	// Latency is how long the mock takes to answer a completion.
+	Latency time.Duration
+}
+
+// ParseConfig parses a MODE:DIR string as accepted by the --llm-replay flag, or mock[:LATENCY] to
+// answer completions with synthetic responses.
+func ParseConfig(s string) (Config, error) {
+	if s == "" {
+		return Config{}, nil
+	}
+	if s == ModeMock || strings.HasPrefix(s, ModeMock+":") {
+		cfg := Config{Mode: ModeMock}
+		if latency := strings.TrimPrefix(strings.TrimPrefix(s, ModeMock), ":"); latency != "" {
+			var err error
+			if cfg.Latency, err = time.ParseDuration(latency); err != nil || cfg.Latency < 0 {
+				return Config{}, fmt.Errorf("invalid mock latency %q, expected a duration such as 500ms", latency)
+			}
+		}
+		return cfg, nil
+	}
+	mode, dir, ok := strings.Cut(s, ":")
+	if !ok || dir == "" {
+		return Config{}, fmt.Errorf("invalid replay config %q, expected record:DIR or replay:DIR", s)
+	}
+	if mode != ModeRecord && mode != ModeReplay {
+		return Config{}, fmt.Errorf("invalid replay mode %q, must be %s or %s", mode, ModeRecord, ModeReplay)
+	}
+	return Config{
+		Mode: mode,
+		Dir:  dir,
+	}, nil
+}
+
+// Wrap returns a completer that records or replays the completions of next depending on the
+// mode of the config. If no mode is set next is returned unchanged.
+func Wrap(cfg Config, next types.Completer) types.Completer {
+	switch cfg.Mode {
+	case "":
+		return next
+	case ModeRecord:
+		return NewRecorder(cfg.Dir, next)
+	case ModeReplay:
+		return NewPlayer(cfg.Dir)
+	case ModeMock:
+		return NewMock(cfg.Latency)
+	default:
+		return errCompleter{err: fmt.Errorf("invalid replay mode %q", cfg.Mode)}
+	}
+}
+
+type errCompleter struct {
+	err error
+}
+
+func (e errCompleter) Complete(context.Context, types.CompletionRequest, ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	return nil, e.err
+}
+
+// Interaction is a single recorded request/response pair, including every progress event that
+// was streamed while the response was generated.
+type Interaction struct {
+	Request  types.CompletionRequest     `json:"request"`
+	Response *types.CompletionResponse   `json:"response,omitempty"`
+	Progress []*types.CompletionProgress `json:"progress,omitempty"`
+	Error    string                      `json:"error,omitempty"`
+}
+
+// Key returns a stable identifier for the request. Generated IDs and timestamps are ignored so
+// the same conversation maps to the same key on every run.
+func Key(req types.CompletionRequest) string {
+	normalized := req
+	normalized.Input = make([]types.Message, 0, len(req.Input))
+	for _, msg := range req.Input {
+		msg.ID = ""
+		msg.Created = nil
+		items := make([]types.CompletionItem, 0, len(msg.Items))
+		for _, item := range msg.Items {
+			// CompletionItem generates a random ID when marshalled without one.
+			item.ID = "-"
+			items = append(items, item)
+		}
+		msg.Items = items
+		normalized.Input = append(normalized.Input, msg)
+	}
+
+	data, _ := json.Marshal(normalized)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+func filename(dir, key string) string {
+	return filepath.Join(dir, key+".json")
+}
+
+func readInteractions(dir, key string) ([]Interaction, error) {
+	data, err := os.ReadFile(filename(dir, key))
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", filename(dir, key), err)
+	}
+	return interactions, nil
+}
@@ -0,0 +1,71 @@
+package replay
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+func request(text string) types.CompletionRequest {
+	return types.CompletionRequest{
+		Model: "test-model",
+		Input: []types.Message{
+			{
+				ID:   uuid.String(),
+				Role: "user",
+				Items: []types.CompletionItem{
+					{
+						ID:      uuid.String(),
+						Content: &mcp.Content{Type: "text", Text: text},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		dir      = t.TempDir()
+		opt      = types.CompletionOptions{ProgressToken: "token"}
+		recorder = NewRecorder(dir, NewScripted(Text("first"), Text("second")))
+	)
+
+	if _, err := recorder.Complete(ctx, request("hi"), opt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.Complete(ctx, request("hi"), opt); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		player = NewPlayer(dir)
+		events []*types.CompletionProgress
+	)
+	ctx = progress.WithListener(ctx, func(p *types.CompletionProgress) {
+		events = append(events, p)
+	})
+
+	for _, expected := range []string{"first", "second", "second"} {
+		resp, err := player.Complete(ctx, request("hi"), opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Output.Items[0].Content.Text; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+
+	if len(events) != 3 {
+		t.Errorf("expected 3 replayed progress events, got %d", len(events))
+	}
+
+	if _, err := player.Complete(ctx, request("unknown"), opt); err == nil {
+		t.Error("expected error for unrecorded request")
+	}
+}
@@ -0,0 +1,102 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+// Scripted returns a fixed sequence of responses regardless of the request. The requests it
+// received are kept so tests can assert on what the agent sent.
+type Scripted struct {
+	lock      sync.Mutex
+	responses []types.CompletionResponse
+	Requests  []types.CompletionRequest
+}
+
+func NewScripted(responses ...types.CompletionResponse) *Scripted {
+	return &Scripted{
+		responses: responses,
+	}
+}
+
+func (s *Scripted) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	s.lock.Lock()
+	s.Requests = append(s.Requests, req)
+	if len(s.responses) == 0 {
+		s.lock.Unlock()
+		return nil, fmt.Errorf("no scripted response left for request %d", len(s.Requests))
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	s.lock.Unlock()
+
+	if resp.Model == "" {
+		resp.Model = req.Model
+	}
+	if resp.Output.ID == "" {
+		resp.Output.ID = uuid.String()
+	}
+	if resp.Output.Created == nil {
+		now := time.Now()
+		resp.Output.Created = &now
+	}
+
+	if progressToken := complete.Complete(opts...).ProgressToken; progressToken != nil {
+		for _, item := range resp.Output.Items {
+			progress.Send(ctx, &types.CompletionProgress{
+				Model:     resp.Model,
+				Agent:     req.Agent,
+				MessageID: resp.Output.ID,
+				Role:      resp.Output.Role,
+				Item:      item,
+			}, progressToken)
+		}
+	}
+
+	return &resp, nil
+}
+
+// Text builds an assistant response containing a single text item.
+func Text(text string) types.CompletionResponse {
+	return types.CompletionResponse{
+		Output: types.Message{
+			Role: "assistant",
+			Items: []types.CompletionItem{
+				{
+					ID: uuid.String(),
+					Content: &mcp.Content{
+						Type: "text",
+						Text: text,
+					},
+				},
+			},
+		},
+	}
+}
+
+// ToolCall builds an assistant response that calls a single tool with the given JSON arguments.
+func ToolCall(name, arguments string) types.CompletionResponse {
+	return types.CompletionResponse{
+		Output: types.Message{
+			Role: "assistant",
+			Items: []types.CompletionItem{
+				{
+					ID: uuid.String(),
+					ToolCall: &types.ToolCall{
+						CallID:    uuid.String(),
+						Name:      name,
+						Arguments: arguments,
+					},
+				},
+			},
+		},
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/apierror"
+	"github.com/nanobot-ai/nanobot/pkg/llm/quota"
 	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/secrets"
 	"github.com/nanobot-ai/nanobot/pkg/types"
 )
 
@@ -22,6 +24,9 @@ type Config struct {
 	APIKey            string
 	BaseURL           string
 	Headers           map[string]string
+	// APIVersion is the api-version query parameter of Azure OpenAI, it is only sent by the Chat
+	// Completions API.
+	APIVersion string
 }
 
 // NewClient creates a new OpenAI client with the provided API key and base URL.
@@ -73,8 +78,8 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 	if err != nil {
 		return nil, err
 	}
-	for key, value := range c.Headers {
-		httpReq.Header.Set(key, value)
+	if err := secrets.SetHeaders(ctx, httpReq, c.Headers); err != nil {
+		return nil, err
 	}
 
 	httpResp, err := http.DefaultClient.Do(httpReq)
@@ -82,9 +87,9 @@ func (c *Client) complete(ctx context.Context, agentName string, req Request, op
 		return nil, err
 	}
 	defer httpResp.Body.Close()
+	quota.Observe("openai", httpResp.Header)
 	if httpResp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(httpResp.Body)
-		return nil, fmt.Errorf("failed to get response from OpenAI Responses API: %s %q", httpResp.Status, string(body))
+		return nil, apierror.FromResponse("OpenAI Responses API", httpResp)
 	}
 
 	response, ok, err := progressResponse(ctx, agentName, req.Model, httpResp, opt.ProgressToken)
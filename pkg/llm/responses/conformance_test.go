@@ -0,0 +1,91 @@
+package responses
+
+import (
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/conformance"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+var provider = conformance.Provider{
+	Name: "OpenAI Responses API",
+	Path: "/responses",
+	New: func(baseURL string) types.Completer {
+		return NewClient(Config{APIKey: "test", BaseURL: baseURL})
+	},
+	Stream: func(s *conformance.Stream, script conformance.Script) {
+		response := map[string]any{
+			"id":     script.ID,
+			"object": "response",
+			"model":  script.Model,
+			"status": "in_progress",
+			"output": []any{},
+		}
+		s.Event("response.created", map[string]any{"type": "response.created", "response": response})
+
+		var output []any
+		if len(script.Text) > 0 {
+			id := "msg_" + script.ID
+			s.Event("response.output_item.added", map[string]any{
+				"type":         "response.output_item.added",
+				"output_index": len(output),
+				"item":         map[string]any{"type": "message", "id": id, "role": "assistant", "status": "in_progress", "content": []any{}},
+			})
+			for _, text := range script.Text {
+				s.Event("response.output_text.delta", map[string]any{
+					"type":          "response.output_text.delta",
+					"item_id":       id,
+					"output_index":  len(output),
+					"content_index": 0,
+					"delta":         text,
+				})
+			}
+			item := map[string]any{
+				"type":    "message",
+				"id":      id,
+				"role":    "assistant",
+				"status":  "completed",
+				"content": []any{map[string]any{"type": "output_text", "text": script.FullText(), "annotations": []any{}}},
+			}
+			s.Event("response.output_item.done", map[string]any{"type": "response.output_item.done", "output_index": len(output), "item": item})
+			output = append(output, item)
+		}
+
+		for _, call := range script.ToolCalls {
+			id := "fc_" + call.ID
+			s.Event("response.output_item.added", map[string]any{
+				"type":         "response.output_item.added",
+				"output_index": len(output),
+				"item":         map[string]any{"type": "function_call", "id": id, "call_id": call.ID, "name": call.Name, "arguments": "", "status": "in_progress"},
+			})
+			for _, arguments := range call.Arguments {
+				s.Event("response.function_call_arguments.delta", map[string]any{
+					"type":         "response.function_call_arguments.delta",
+					"item_id":      id,
+					"output_index": len(output),
+					"delta":        arguments,
+				})
+			}
+			item := map[string]any{"type": "function_call", "id": id, "call_id": call.ID, "name": call.Name, "arguments": call.FullArguments(), "status": "completed"}
+			s.Event("response.output_item.done", map[string]any{"type": "response.output_item.done", "output_index": len(output), "item": item})
+			output = append(output, item)
+		}
+
+		response["status"] = "completed"
+		response["output"] = output
+		response["usage"] = map[string]any{
+			"input_tokens":  script.InputTokens,
+			"output_tokens": script.OutputTokens,
+			"total_tokens":  script.InputTokens + script.OutputTokens,
+		}
+		s.Event("response.completed", map[string]any{"type": "response.completed", "response": response})
+	},
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, provider)
+}
+
+func BenchmarkConformance(b *testing.B) {
+	conformance.Benchmark(b, provider)
+}
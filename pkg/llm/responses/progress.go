@@ -15,6 +15,7 @@ import (
 
 func progressResponse(ctx context.Context, agentName, modelName string, resp *http.Response, progressToken any) (response Response, seen bool, err error) {
 	lines := bufio.NewScanner(resp.Body)
+	lines.Buffer(make([]byte, 0, 1024), 10*1024*1024)
 	defer resp.Body.Close()
 
 	progress := types.CompletionProgress{
@@ -65,8 +66,14 @@ func progressResponse(ctx context.Context, agentName, modelName string, resp *ht
 					}
 				}
 			case "response.function_call_arguments.delta":
-				progress.Item.ToolCall.Arguments = event.Delta
-				llmProgress.Send(ctx, &progress, progressToken)
+				if progress.Item.ToolCall != nil {
+					// Every delta is sent with its own copy of the tool call, listeners may keep the
+					// events they receive.
+					delta, toolCall := progress, *progress.Item.ToolCall
+					toolCall.Arguments = event.Delta
+					delta.Item.ToolCall = &toolCall
+					llmProgress.Send(ctx, &delta, progressToken)
+				}
 			case "response.output_item.done":
 				if progress.Item.ID != "" {
 					progress.Item = types.CompletionItem{
@@ -78,8 +85,10 @@ func progressResponse(ctx context.Context, agentName, modelName string, resp *ht
 				progress.Item = types.CompletionItem{}
 			case "response.output_text.delta":
 				if progress.Item.Content != nil {
-					progress.Item.Content.Text = event.Delta
-					llmProgress.Send(ctx, &progress, progressToken)
+					delta, content := progress, *progress.Item.Content
+					content.Text = event.Delta
+					delta.Item.Content = &content
+					llmProgress.Send(ctx, &delta, progressToken)
 				}
 			}
 
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"slices"
 	"time"
 
@@ -65,6 +66,17 @@ func toResponse(req *types.CompletionRequest, resp *Response) (*types.Completion
 		}
 	}
 
+	result.StopReason = string(resp.Status)
+	if resp.IncompleteDetails != nil && resp.IncompleteDetails.Reason != "" {
+		result.StopReason = resp.IncompleteDetails.Reason
+	}
+	result.Usage = &types.Usage{
+		InputTokens:       resp.Usage.InputTokens,
+		OutputTokens:      resp.Usage.OutputTokens,
+		CachedInputTokens: resp.Usage.InputTokensDetails.CachedTokens,
+		ReasoningTokens:   resp.Usage.OutputTokensDetails.ReasoningTokens,
+	}
+
 	return result, nil
 }
 
@@ -145,7 +157,7 @@ func toRequest(completion *types.CompletionRequest) (req Request, _ error) {
 			req.ToolChoice = &ToolChoice{
 				Mode: completion.ToolChoice,
 			}
-		case "file_search", "web_search_preview", "computer_use_preview":
+		case "file_search", "web_search", "web_search_preview", "computer_use_preview", "code_interpreter":
 			req.ToolChoice = &ToolChoice{
 				HostedTool: &HostedTool{
 					Type: completion.ToolChoice,
@@ -160,7 +172,13 @@ func toRequest(completion *types.CompletionRequest) (req Request, _ error) {
 		}
 	}
 
-	if completion.OutputSchema != nil {
+	if completion.OutputSchema != nil && completion.OutputSchema.IsJSONObject() {
+		req.Text = &TextFormatting{
+			Format: Format{
+				JSONObject: &JSONObject{},
+			},
+		}
+	} else if completion.OutputSchema != nil {
 		req.Text = &TextFormatting{
 			Format: Format{
 				JSONSchema: &JSONSchema{
@@ -176,6 +194,10 @@ func toRequest(completion *types.CompletionRequest) (req Request, _ error) {
 		}
 	}
 
+	if len(completion.Tools) > 0 || len(completion.BuiltinTools) > 0 {
+		req.ParallelToolCalls = completion.ParallelToolCalls
+	}
+
 	for _, tool := range completion.Tools {
 		req.Tools = append(req.Tools, Tool{
 			CustomTool: &CustomTool{
@@ -187,6 +209,15 @@ func toRequest(completion *types.CompletionRequest) (req Request, _ error) {
 		})
 	}
 
+	for _, name := range slices.Sorted(maps.Keys(completion.BuiltinTools)) {
+		req.Tools = append(req.Tools, Tool{
+			BuiltinTool: &BuiltinTool{
+				Type:       name,
+				Attributes: completion.BuiltinTools[name],
+			},
+		})
+	}
+
 	for _, msg := range completion.Input {
 		if msg.Role == "user" {
 			req.Input.Items = append(req.Input.Items, toUserMessageContent(msg)...)
@@ -0,0 +1,88 @@
+package responses
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestParallelToolCalls(t *testing.T) {
+	parallel := false
+	tools := []types.ToolUseDefinition{{Name: "search", Parameters: []byte(`{"type":"object"}`)}}
+
+	req, err := toRequest(&types.CompletionRequest{ParallelToolCalls: &parallel, Tools: tools})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ParallelToolCalls == nil || *req.ParallelToolCalls {
+		t.Errorf("expected parallel tool calls to be disabled, got %v", req.ParallelToolCalls)
+	}
+
+	// parallel_tool_calls is rejected without tools
+	req, err = toRequest(&types.CompletionRequest{ParallelToolCalls: &parallel})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ParallelToolCalls != nil {
+		t.Errorf("expected parallel_tool_calls to be omitted without tools, got %v", *req.ParallelToolCalls)
+	}
+}
+
+func TestBuiltinTools(t *testing.T) {
+	req, err := toRequest(&types.CompletionRequest{
+		ToolChoice: "web_search",
+		BuiltinTools: map[string]map[string]any{
+			"web_search":       {"search_context_size": "low"},
+			"code_interpreter": {},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(struct {
+		Tools      []Tool      `json:"tools"`
+		ToolChoice *ToolChoice `json:"tool_choice"`
+	}{req.Tools, req.ToolChoice})
+	want := `{"tools":[{"container":{"type":"auto"},"type":"code_interpreter"},{"search_context_size":"low","type":"web_search"}],"tool_choice":{"type":"web_search"}}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var tool Tool
+	if err := json.Unmarshal([]byte(`{"type":"web_search","search_context_size":"low"}`), &tool); err != nil {
+		t.Fatal(err)
+	}
+	if tool.BuiltinTool == nil || tool.BuiltinTool.Type != "web_search" || tool.BuiltinTool.Attributes["search_context_size"] != "low" {
+		t.Errorf("unexpected tool %+v", tool.BuiltinTool)
+	}
+}
+
+func TestCodeInterpreterCall(t *testing.T) {
+	var output ResponseOutput
+	if err := json.Unmarshal([]byte(`{
+		"type": "code_interpreter_call",
+		"id": "ci_1",
+		"code": "print(1 + 1)",
+		"container_id": "cntr_1",
+		"outputs": [{"type": "logs", "logs": "2"}],
+		"status": "completed"
+	}`), &output); err != nil {
+		t.Fatal(err)
+	}
+	call := output.CodeInterpreterCall
+	if call == nil || call.ID != "ci_1" || len(call.Outputs) != 1 || call.Outputs[0].Logs != "2" {
+		t.Fatalf("unexpected output %+v", call)
+	}
+
+	// The call is sent back as input of the next request.
+	data, err := json.Marshal(output.ToInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"code_interpreter_call","id":"ci_1","code":"print(1 + 1)","container_id":"cntr_1","outputs":[{"type":"logs","logs":"2"}],"status":"completed"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
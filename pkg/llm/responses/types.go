@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"regexp"
 	"strings"
 
@@ -138,6 +139,7 @@ type Item struct {
 	*ComputerCall
 	*ComputerCallOutput
 	*WebSearchCall
+	*CodeInterpreterCall
 	*FunctionCall
 	*FunctionCallOutput
 	*Reasoning
@@ -162,6 +164,9 @@ func (i Item) MarshalJSON() ([]byte, error) {
 	if i.WebSearchCall != nil {
 		return json.Marshal(i.WebSearchCall)
 	}
+	if i.CodeInterpreterCall != nil {
+		return json.Marshal(i.CodeInterpreterCall)
+	}
 	if i.FunctionCall != nil {
 		return json.Marshal(i.FunctionCall)
 	}
@@ -201,6 +206,9 @@ func (i *Item) UnmarshalJSON(data []byte) error {
 	case "web_search_call":
 		i.WebSearchCall = &WebSearchCall{}
 		return json.Unmarshal(data, i.WebSearchCall)
+	case "code_interpreter_call":
+		i.CodeInterpreterCall = &CodeInterpreterCall{}
+		return json.Unmarshal(data, i.CodeInterpreterCall)
 	case "function_call":
 		i.FunctionCall = &FunctionCall{}
 		return json.Unmarshal(data, i.FunctionCall)
@@ -418,6 +426,35 @@ type Tool struct {
 	*WebSearch   `json:",inline"`
 	*ComputerUse `json:",inline"`
 	*CustomTool  `json:",inline"`
+	*BuiltinTool `json:",inline"`
+}
+
+// BuiltinTool is a tool hosted by OpenAI, such as web_search or code_interpreter, that is configured
+// in the agent. The attributes are sent as is next to the type.
+type BuiltinTool struct {
+	Type       string         `json:"-"`
+	Attributes map[string]any `json:"-"`
+}
+
+func (b BuiltinTool) MarshalJSON() ([]byte, error) {
+	result := make(map[string]any, len(b.Attributes)+1)
+	maps.Copy(result, b.Attributes)
+	result["type"] = b.Type
+	if b.Type == "code_interpreter" && result["container"] == nil {
+		// The container is required, by default a new one is created for the request
+		result["container"] = map[string]any{"type": "auto"}
+	}
+	return json.Marshal(result)
+}
+
+func (b *BuiltinTool) UnmarshalJSON(data []byte) error {
+	b.Attributes = make(map[string]any)
+	if err := json.Unmarshal(data, &b.Attributes); err != nil {
+		return err
+	}
+	b.Type = fmt.Sprint(b.Attributes["type"])
+	delete(b.Attributes, "type")
+	return nil
 }
 
 type CustomTool struct {
@@ -504,6 +541,8 @@ func (t Tool) MarshalJSON() ([]byte, error) {
 		return json.Marshal(t.ComputerUse)
 	} else if t.CustomTool != nil {
 		return json.Marshal(t.CustomTool)
+	} else if t.BuiltinTool != nil {
+		return json.Marshal(t.BuiltinTool)
 	}
 	return []byte("{}"), nil
 }
@@ -522,6 +561,9 @@ func (t *Tool) UnmarshalJSON(data []byte) error {
 	case "computer_use_preview":
 		t.ComputerUse = &ComputerUse{}
 		return json.Unmarshal(data, t.ComputerUse)
+	case "web_search", "code_interpreter":
+		t.BuiltinTool = &BuiltinTool{}
+		return json.Unmarshal(data, t.BuiltinTool)
 	default:
 		t.CustomTool = &CustomTool{}
 		return json.Unmarshal(data, t.CustomTool)
@@ -702,7 +744,7 @@ func (f *ToolChoice) UnmarshalJSON(data []byte) error {
 	case "function":
 		f.FunctionTool = &FunctionTool{}
 		return json.Unmarshal(data, f.FunctionTool)
-	case "file_search", "web_search_preview", "computer_use_preview":
+	case "file_search", "web_search", "web_search_preview", "computer_use_preview", "code_interpreter":
 		f.HostedTool = &HostedTool{}
 		return json.Unmarshal(data, f.HostedTool)
 	}
@@ -812,23 +854,25 @@ type ResponseError struct {
 }
 
 type ResponseOutput struct {
-	*Message        `json:",inline"`
-	*FileSearchCall `json:",inline"`
-	*FunctionCall   `json:",inline"`
-	*WebSearchCall  `json:",inline"`
-	*ComputerCall   `json:",inline"`
-	*Reasoning      `json:",inline"`
+	*Message             `json:",inline"`
+	*FileSearchCall      `json:",inline"`
+	*FunctionCall        `json:",inline"`
+	*WebSearchCall       `json:",inline"`
+	*CodeInterpreterCall `json:",inline"`
+	*ComputerCall        `json:",inline"`
+	*Reasoning           `json:",inline"`
 }
 
 func (r *ResponseOutput) ToInput() InputItem {
 	return InputItem{
 		Item: &Item{
-			Message:        r.Message,
-			FileSearchCall: r.FileSearchCall,
-			FunctionCall:   r.FunctionCall,
-			WebSearchCall:  r.WebSearchCall,
-			ComputerCall:   r.ComputerCall,
-			Reasoning:      r.Reasoning,
+			Message:             r.Message,
+			FileSearchCall:      r.FileSearchCall,
+			FunctionCall:        r.FunctionCall,
+			WebSearchCall:       r.WebSearchCall,
+			CodeInterpreterCall: r.CodeInterpreterCall,
+			ComputerCall:        r.ComputerCall,
+			Reasoning:           r.Reasoning,
 		},
 	}
 }
@@ -846,6 +890,9 @@ func (r ResponseOutput) MarshalJSON() ([]byte, error) {
 	if r.WebSearchCall != nil {
 		return json.Marshal(r.WebSearchCall)
 	}
+	if r.CodeInterpreterCall != nil {
+		return json.Marshal(r.CodeInterpreterCall)
+	}
 	if r.ComputerCall != nil {
 		return json.Marshal(r.ComputerCall)
 	}
@@ -869,6 +916,9 @@ func (r *ResponseOutput) UnmarshalJSON(data []byte) error {
 	case "web_search_call":
 		r.WebSearchCall = &WebSearchCall{}
 		return json.Unmarshal(data, r.WebSearchCall)
+	case "code_interpreter_call":
+		r.CodeInterpreterCall = &CodeInterpreterCall{}
+		return json.Unmarshal(data, r.CodeInterpreterCall)
 	case "computer_call":
 		r.ComputerCall = &ComputerCall{}
 		return json.Unmarshal(data, r.ComputerCall)
@@ -1147,6 +1197,28 @@ func (w WebSearchCall) MarshalJSON() ([]byte, error) {
 	return json.Marshal((Alias)(w))
 }
 
+type CodeInterpreterCall struct {
+	Type        string                  `json:"type,omitempty"`
+	ID          string                  `json:"id,omitempty"`
+	Code        *string                 `json:"code,omitempty"`
+	ContainerID string                  `json:"container_id,omitempty"`
+	Outputs     []CodeInterpreterOutput `json:"outputs,omitempty"`
+	Status      Status                  `json:"status,omitempty"`
+}
+
+func (c CodeInterpreterCall) MarshalJSON() ([]byte, error) {
+	c.Type = "code_interpreter_call"
+	type Alias CodeInterpreterCall
+	return json.Marshal((Alias)(c))
+}
+
+// CodeInterpreterOutput is either the logs of the code, or an image it generated.
+type CodeInterpreterOutput struct {
+	Type string `json:"type,omitempty"`
+	Logs string `json:"logs,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
 type FunctionCall struct {
 	Type      string `json:"type,omitempty"`
 	Arguments string `json:"arguments,omitempty"`
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestCompleteSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	client := NewClient(Config{
+		DefaultModel: "test-model",
+		Middleware: []Middleware{func(types.Completer) types.Completer {
+			return CompleterFunc(func(_ context.Context, req types.CompletionRequest, _ ...types.CompletionOptions) (*types.CompletionResponse, error) {
+				if req.Agent == "failing" {
+					return nil, errors.New("overloaded")
+				}
+				return &types.CompletionResponse{
+					Model:      "test-model-2025",
+					StopReason: "stop",
+					Usage:      &types.Usage{InputTokens: 10, OutputTokens: 5, CachedInputTokens: 2},
+				}, nil
+			})
+		}},
+	})
+
+	if _, err := client.Complete(t.Context(), types.CompletionRequest{Agent: "main"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Complete(t.Context(), types.CompletionRequest{Agent: "failing"}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "llm/complete test-model" {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+	got := map[string]string{}
+	for _, attr := range spans[0].Attributes() {
+		got[string(attr.Key)] = attr.Value.Emit()
+	}
+	for key, want := range map[string]string{
+		"gen_ai.request.model":             "test-model",
+		"gen_ai.response.model":            "test-model-2025",
+		"gen_ai.response.finish_reasons":   `["stop"]`,
+		"nanobot.agent":                    "main",
+		"gen_ai.usage.input_tokens":        "10",
+		"gen_ai.usage.output_tokens":       "5",
+		"gen_ai.usage.cached_input_tokens": "2",
+	} {
+		if got[key] != want {
+			t.Errorf("%s: got %q, want %q", key, got[key], want)
+		}
+	}
+	if status := spans[1].Status(); status.Code != codes.Error || status.Description != "overloaded" {
+		t.Errorf("got status %+v of the failed completion", status)
+	}
+}
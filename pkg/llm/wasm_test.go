@@ -0,0 +1,25 @@
+package llm
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestWasmBuild checks that the provider clients still build for js/wasm, for browser playgrounds
+// that assemble requests and parse the streams client-side.
+func TestWasmBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building for js/wasm is slow")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	cmd := exec.Command(goBin, "build", "./anthropic", "./responses", "./completions", "./progress")
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build for js/wasm: %v\n%s", err, out)
+	}
+}
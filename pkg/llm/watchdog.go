@@ -0,0 +1,209 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// ErrDegenerateOutput is returned when the watchdog aborts a generation that is looping or
+// producing runaway whitespace and no retries are left.
+var ErrDegenerateOutput = errors.New("model produced degenerate output")
+
+type WatchdogConfig struct {
+	// Retries is the number of times a degenerate generation is retried, with a higher temperature if
+	// the request sets one.
+	Retries int
+	// MinRepeatLength is the number of characters a repeated sequence has to cover at the end of
+	// the output before it is considered a loop. Default 400.
+	MinRepeatLength int
+	// MaxWhitespace is the longest run of whitespace allowed at the end of the output. Default 256.
+	MaxWhitespace int
+}
+
+func (w WatchdogConfig) Merge(other WatchdogConfig) (result WatchdogConfig) {
+	result.Retries = complete.Last(w.Retries, other.Retries)
+	result.MinRepeatLength = complete.Last(w.MinRepeatLength, other.MinRepeatLength)
+	result.MaxWhitespace = complete.Last(w.MaxWhitespace, other.MaxWhitespace)
+	return
+}
+
+const (
+	// maxRepeatPeriod is the longest repeated unit, in characters, that is looked for.
+	maxRepeatPeriod = 200
+	// minRepeats is the number of times a unit has to repeat to be considered a loop.
+	minRepeats = 4
+	// checkInterval is how many characters of new output are buffered between checks.
+	checkInterval = 32
+)
+
+// Watchdog returns a middleware that inspects streamed text as it is generated and aborts the
+// completion if the output degenerates into a loop or runaway whitespace. The offending progress
+// is not forwarded, instead a types.ProgressEventDegenerateOutput event is sent for the aborted
+// message and the request is retried, with a higher temperature if it has one, up to the configured
+// retries.
+func Watchdog(cfg WatchdogConfig) Middleware {
+	cfg = WatchdogConfig{
+		MinRepeatLength: 400,
+		MaxWhitespace:   256,
+	}.Merge(cfg)
+
+	return func(next types.Completer) types.Completer {
+		return CompleterFunc(func(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+			for attempt := 0; ; attempt++ {
+				resp, reason, err := watch(ctx, cfg, next, req, opts...)
+				if reason.text == "" {
+					return resp, err
+				}
+
+				retry := attempt < cfg.Retries
+				log.Infof(ctx, "aborted degenerate output from model %s (%s), retry=%v", req.Model, reason.text, retry)
+				progress.Send(ctx, &types.CompletionProgress{
+					Model:     req.Model,
+					Agent:     req.Agent,
+					MessageID: reason.messageID,
+					Event: &types.ProgressEvent{
+						Type:    types.ProgressEventDegenerateOutput,
+						Message: reason.text,
+						Retry:   retry,
+					},
+				}, complete.Complete(opts...).ProgressToken)
+
+				if !retry {
+					return nil, fmt.Errorf("%w: %s", ErrDegenerateOutput, reason.text)
+				}
+				req.Temperature = retryTemperature(req)
+			}
+		})
+	}
+}
+
+type degenerateReason struct {
+	messageID string
+	text      string
+}
+
+func watch(ctx context.Context, cfg WatchdogConfig, next types.Completer, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, degenerateReason, error) {
+	var (
+		lock    sync.Mutex
+		texts   = map[string]*strings.Builder{}
+		checked = map[string]int{}
+		reason  degenerateReason
+	)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	ctx = progress.WithFilter(ctx, func(p *types.CompletionProgress) bool {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if reason.text != "" {
+			return false
+		}
+		if p.Item.Content == nil || p.Item.Content.Type != "text" || !p.Item.Partial {
+			return true
+		}
+
+		text, ok := texts[p.Item.ID]
+		if !ok {
+			text = &strings.Builder{}
+			texts[p.Item.ID] = text
+		}
+		text.WriteString(p.Item.Content.Text)
+		if text.Len()-checked[p.Item.ID] < checkInterval {
+			return true
+		}
+		checked[p.Item.ID] = text.Len()
+
+		if r := degenerate(text.String(), cfg); r != "" {
+			reason = degenerateReason{
+				messageID: p.MessageID,
+				text:      r,
+			}
+			cancel(ErrDegenerateOutput)
+			return false
+		}
+		return true
+	})
+
+	resp, err := next.Complete(ctx, req, opts...)
+
+	lock.Lock()
+	defer lock.Unlock()
+	if reason.text == "" && err == nil && resp != nil {
+		// Non-streaming providers only return the full output, so check it once at the end.
+		for _, item := range resp.Output.Items {
+			if item.Content != nil && item.Content.Type == "text" {
+				if r := degenerate(item.Content.Text, cfg); r != "" {
+					reason = degenerateReason{
+						messageID: resp.Output.ID,
+						text:      r,
+					}
+				}
+			}
+		}
+	}
+	return resp, reason, err
+}
+
+// degenerate returns a description of the problem if the end of text is a loop or runaway
+// whitespace, or an empty string if the text looks fine.
+func degenerate(text string, cfg WatchdogConfig) string {
+	trimmed := strings.TrimRight(text, " \t\r\n")
+	if ws := len(text) - len(trimmed); ws > cfg.MaxWhitespace {
+		return fmt.Sprintf("%d trailing whitespace characters", ws)
+	}
+
+	window := text
+	if limit := 4 * cfg.MinRepeatLength; len(window) > limit {
+		window = window[len(window)-limit:]
+	}
+
+	for period := 1; period <= maxRepeatPeriod && period*minRepeats <= len(window); period++ {
+		// Cheap rejection before counting repetitions of this period.
+		if window[len(window)-1] != window[len(window)-1-period] {
+			continue
+		}
+		unit := window[len(window)-period:]
+		repeats := 1
+		for end := len(window) - period; end >= period && window[end-period:end] == unit; end -= period {
+			repeats++
+		}
+		if repeats >= minRepeats && repeats*period >= cfg.MinRepeatLength && strings.TrimSpace(unit) != "" {
+			return fmt.Sprintf("%q repeated %d times", truncate(unit, 40), repeats)
+		}
+	}
+
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// retryTemperature nudges the sampling temperature up to make it less likely the model falls into
+// the same loop again. It is only raised if the caller set one, since reasoning models reject a
+// temperature and extended thinking requires a temperature of 1, otherwise the retry is unchanged.
+func retryTemperature(req types.CompletionRequest) *json.Number {
+	if req.Temperature == nil || req.Reasoning != nil {
+		return req.Temperature
+	}
+	f, err := req.Temperature.Float64()
+	if err != nil {
+		return req.Temperature
+	}
+	n := json.Number(fmt.Sprintf("%.2f", min(f+0.3, 1.0)))
+	return &n
+}
@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/replay"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestDegenerate(t *testing.T) {
+	cfg := WatchdogConfig{MinRepeatLength: 400, MaxWhitespace: 256}
+
+	for name, tc := range map[string]struct {
+		text       string
+		degenerate bool
+	}{
+		"normal prose":       {text: strings.Repeat("The quick brown fox jumps over the lazy dog, then rests. ", 3), degenerate: false},
+		"short repetition":   {text: "ha ha ha ha ha", degenerate: false},
+		"token loop":         {text: "Sure! " + strings.Repeat("the ", 150), degenerate: true},
+		"repeated phrase":    {text: "Here is the answer. " + strings.Repeat("I will now check the file again. ", 20), degenerate: true},
+		"runaway whitespace": {text: "Done." + strings.Repeat("\n", 300), degenerate: true},
+		"separator line":     {text: strings.Repeat("-", 80), degenerate: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := degenerate(tc.text, cfg) != ""; got != tc.degenerate {
+				t.Fatalf("expected degenerate=%v, got %v", tc.degenerate, got)
+			}
+		})
+	}
+}
+
+func TestWatchdogRetries(t *testing.T) {
+	scripted := replay.NewScripted(
+		replay.Text(strings.Repeat("loop ", 200)),
+		replay.Text("fine"),
+	)
+
+	temperature := json.Number("0.2")
+	resp, err := Watchdog(WatchdogConfig{Retries: 1})(scripted).Complete(context.Background(), types.CompletionRequest{
+		Temperature: &temperature,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Output.Items[0].Content.Text; got != "fine" {
+		t.Fatalf("expected retried output, got %q", got)
+	}
+	if len(scripted.Requests) != 2 || scripted.Requests[1].Temperature == nil || *scripted.Requests[1].Temperature != "0.50" {
+		t.Fatalf("expected a retry with a higher temperature, got %+v", scripted.Requests)
+	}
+
+	_, err = Watchdog(WatchdogConfig{})(replay.NewScripted(replay.Text(strings.Repeat("loop ", 200)))).
+		Complete(context.Background(), types.CompletionRequest{})
+	if !errors.Is(err, ErrDegenerateOutput) {
+		t.Fatalf("expected ErrDegenerateOutput, got %v", err)
+	}
+}
+
+func TestWatchdogRetriesUnchanged(t *testing.T) {
+	temperature := json.Number("1")
+	for name, req := range map[string]types.CompletionRequest{
+		"no temperature":  {Model: "gpt-4.1"},
+		"reasoning model": {Model: "o3", Reasoning: &types.AgentReasoning{Effort: "high"}},
+		"thinking":        {Model: "claude-sonnet-4", Temperature: &temperature, Reasoning: &types.AgentReasoning{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			scripted := replay.NewScripted(
+				replay.Text(strings.Repeat("loop ", 200)),
+				replay.Text("fine"),
+			)
+			if _, err := Watchdog(WatchdogConfig{Retries: 1})(scripted).Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			if len(scripted.Requests) != 2 || scripted.Requests[1].Temperature != req.Temperature {
+				t.Fatalf("expected the retry to keep the temperature %v, got %+v", req.Temperature, scripted.Requests)
+			}
+		})
+	}
+}
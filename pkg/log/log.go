@@ -22,7 +22,23 @@ var (
 	Base64Replacement = []byte(`$1..."`)
 )
 
-func Messages(_ context.Context, server string, out bool, data []byte) {
+type withoutContentKey struct{}
+
+// WithoutContent returns a context for which messages exchanged with servers and LLMs are never
+// logged, regardless of the debug settings.
+func WithoutContent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutContentKey{}, true)
+}
+
+// ContentSuppressed reports whether ctx was created with WithoutContent.
+func ContentSuppressed(ctx context.Context) bool {
+	return ctx != nil && ctx.Value(withoutContentKey{}) != nil
+}
+
+func Messages(ctx context.Context, server string, out bool, data []byte) {
+	if ContentSuppressed(ctx) {
+		return
+	}
 	if !EnableUI && server == "nanobot.ui" {
 		return
 	}
@@ -42,7 +58,10 @@ func Messages(_ context.Context, server string, out bool, data []byte) {
 	printer.Prefix(fmt.Sprintf(prefixFmt, server), strings.ReplaceAll(strings.TrimSpace(string(data)), "\n", " ")+"\n")
 }
 
-func StderrMessages(_ context.Context, server, line string) {
+func StderrMessages(ctx context.Context, server, line string) {
+	if ContentSuppressed(ctx) {
+		return
+	}
 	printer.Prefix(fmt.Sprintf("<-(%s:stderr)", server), line+"\n")
 }
 
@@ -12,6 +12,7 @@ import (
 	"github.com/nanobot-ai/nanobot/pkg/complete"
 	"github.com/nanobot-ai/nanobot/pkg/envvar"
 	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/secrets"
 	"github.com/nanobot-ai/nanobot/pkg/version"
 )
 
@@ -143,6 +144,7 @@ type Server struct {
 	Dockerfile   string            `json:"dockerfile,omitempty"`
 	Source       ServerSource      `json:"source,omitempty"`
 	Sandboxed    bool              `json:"sandboxed,omitempty"`
+	Sandbox      *ServerSandbox    `json:"sandbox,omitempty"`
 	Env          map[string]string `json:"env,omitempty"`
 	Command      string            `json:"command,omitempty"`
 	Args         []string          `json:"args,omitempty"`
@@ -152,6 +154,39 @@ type Server struct {
 	Cwd          string            `json:"cwd,omitempty"`
 	Workdir      string            `json:"workdir,omitempty"`
 	Headers      map[string]string `json:"headers,omitempty"`
+	// MaxConcurrency limits how many calls of the tools of the server run at the same time when
+	// a model calls several tools at once. 0 leaves it unlimited, 1 runs the calls one by one.
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+	// Mock serves canned results of the tools instead of running or connecting to the server.
+	Mock *ServerMock `json:"mock,omitempty"`
+	// Sampling limits the completions the server can request with sampling/createMessage.
+	Sampling *ServerSampling `json:"sampling,omitempty"`
+}
+
+// ServerSampling limits the completions a server can request through the models of nanobot.
+type ServerSampling struct {
+	// Disabled does not offer sampling to the server.
+	Disabled bool `json:"disabled,omitempty"`
+	// Models are the agents the server can sample from, all agents if empty.
+	Models []string `json:"models,omitempty"`
+	// MaxTokens caps the output tokens of each completion.
+	MaxTokens int `json:"maxTokens,omitempty"`
+	// TokenBudget is the number of input and output tokens the server can use in a session,
+	// unlimited if 0.
+	TokenBudget int `json:"tokenBudget,omitempty"`
+}
+
+// ServerSandbox configures the container of a sandboxed server. Setting it runs the server in a
+// container, like Sandboxed, with the image of the server.
+type ServerSandbox struct {
+	// Runtime is the container runtime, docker or podman.
+	Runtime string `json:"runtime,omitempty"`
+	// Mounts are host paths mounted into the container, in the host:container[:ro] format.
+	Mounts []string `json:"mounts,omitempty"`
+	// Network is the network of the container, "none" gives the server no network access.
+	Network string `json:"network,omitempty"`
+	CPUs    string `json:"cpus,omitempty"`
+	Memory  string `json:"memory,omitempty"`
 }
 
 type ServerSource struct {
@@ -251,6 +286,17 @@ func toHandler(opts ClientOption) MessageHandler {
 	})
 }
 
+// httpURL returns the HTTP URL of a WebSocket URL, to check if the server is up.
+func httpURL(u string) string {
+	if rest, ok := strings.CutPrefix(u, "ws://"); ok {
+		return "http://" + rest
+	}
+	if rest, ok := strings.CutPrefix(u, "wss://"); ok {
+		return "https://" + rest
+	}
+	return u
+}
+
 func waitForURL(ctx context.Context, serverName, baseURL string) error {
 	if baseURL == "" {
 		return fmt.Errorf("base URL is empty for server %s", serverName)
@@ -299,18 +345,25 @@ func NewSession(ctx context.Context, serverName string, config Server, opts ...C
 			if err != nil {
 				return nil, err
 			}
-			if err := waitForURL(ctx, serverName, config.BaseURL); err != nil {
+			if err := waitForURL(ctx, serverName, httpURL(config.BaseURL)); err != nil {
 				return nil, err
 			}
 		}
-		headers := envvar.ReplaceMap(opt.Env, config.Headers)
+		headers, err := secrets.ExpandMap(ctx, envvar.ReplaceMap(opt.Env, config.Headers))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the headers of %s: %w", serverName, err)
+		}
 		if opt.SessionState != nil && opt.SessionState.ID != "" {
 			if headers == nil {
 				headers = make(map[string]string)
 			}
 			headers["Mcp-Session-Id"] = opt.SessionState.ID
 		}
-		wire = newHTTPClient(serverName, config, opt.OAuthClientName, opt.OAuthRedirectURL, opt.CallbackHandler, opt.ClientCredLookup, opt.TokenStorage, headers, !opt.ignoreEvents)
+		if IsWebSocketURL(config.BaseURL) {
+			wire = newWebSocketClient(serverName, config, headers)
+		} else {
+			wire = newHTTPClient(serverName, config, opt.OAuthClientName, opt.OAuthRedirectURL, opt.CallbackHandler, opt.ClientCredLookup, opt.TokenStorage, headers, !opt.ignoreEvents)
+		}
 	} else {
 		wire, err = newStdioClient(ctx, opt.Roots, opt.Env, serverName, config, opt.Runner)
 		if err != nil {
@@ -357,7 +410,9 @@ func NewClient(ctx context.Context, serverName string, config Server, opts ...Cl
 		sampling = &struct{}{}
 	}
 	if opt.OnRoots != nil {
-		roots = &RootsCapability{}
+		roots = &RootsCapability{
+			ListChanged: true,
+		}
 	}
 	if opt.OnElicit != nil {
 		elicitations = &struct{}{}
@@ -0,0 +1,53 @@
+// Package mcp implements the Model Context Protocol, the clients nanobot uses to talk to MCP servers
+// and the sessions of the servers nanobot exposes.
+//
+// # In-process servers
+//
+// Servers that run in the process of nanobot, like the agent UI or the GitHub tools, are a
+// ServerHandler with the tools, resources and prompts of the server. Embedders write their own the
+// same way and register a factory for them with tools.Service.AddServer, the config then refers to
+// them by name like to any other MCP server:
+//
+//	type Server struct {
+//		mcp.ServerHandler
+//	}
+//
+//	func NewServer() *Server {
+//		s := &Server{}
+//		s.Tools = mcp.NewServerTools(
+//			mcp.NewServerTool("greet", "Greets a person", s.greet),
+//		)
+//		s.Resources = mcp.NewServerResources(
+//			mcp.NewServerTextResource(mcp.Resource{URI: "example://motd", Name: "motd"}, s.motd),
+//		)
+//		return s
+//	}
+//
+//	func (s *Server) greet(ctx context.Context, in struct {
+//		Name string `json:"name" jsonschema:"The name of the person"`
+//	}) (string, error) {
+//		return "Hello " + in.Name, nil
+//	}
+//
+//	runtime.AddServer("example", func(string) mcp.MessageHandler {
+//		return NewServer()
+//	})
+//
+// The handlers are called with the context of the session of the caller. SessionFromContext returns
+// that session, its Get and Set methods read and write attributes that are kept for the lifetime of
+// the session, and Notify, NotifyResourceUpdated and NotifyListChanged send notifications to its
+// client.
+//
+// # Transports
+//
+// Clients talk to servers over stdio when the server has a command, and over streamable HTTP when
+// it has a URL. HTTPServer numbers the events of the stream of a session and keeps the recent ones,
+// a client that reconnects with the Last-Event-ID header gets the events it missed, including the
+// notifications sent while it was disconnected.
+//
+// A ws:// or wss:// URL uses a WebSocket instead, for networks where long-lived SSE responses are
+// cut off. HTTPServer accepts WebSocket upgrades on the same path as HTTP requests. A WebSocket
+// carries one session, the server returns its ID in the Mcp-Session-Id header of the handshake and
+// the client sends it back when it reconnects to resume the session. Both ends ping idle
+// connections, and stop reading while too many requests are still being handled.
+package mcp
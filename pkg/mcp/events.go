@@ -0,0 +1,61 @@
+package mcp
+
+import (
+	"strconv"
+	"sync"
+)
+
+// eventBufferSize is the number of recent events of a session kept to be replayed.
+const eventBufferSize = 1000
+
+// Event is a message written to the event stream of a session, with the ID a client sends back in
+// the Last-Event-ID header to resume the stream after it.
+type Event struct {
+	ID      string
+	Message Message
+	seq     uint64
+}
+
+// eventBuffer keeps the most recent events of a session.
+type eventBuffer struct {
+	lock   sync.Mutex
+	next   uint64
+	events []Event
+}
+
+func (b *eventBuffer) add(msg Message) Event {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.next++
+	event := Event{
+		ID:      strconv.FormatUint(b.next, 10),
+		Message: msg,
+		seq:     b.next,
+	}
+	if len(b.events) == eventBufferSize {
+		b.events = append(b.events[:0], b.events[1:]...)
+	}
+	b.events = append(b.events, event)
+	return event
+}
+
+// since returns the events after the event lastID. Nothing is returned if lastID is not an event of
+// the session, and all kept events if lastID was already dropped from the buffer.
+func (b *eventBuffer) since(lastID string) []Event {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	last, err := strconv.ParseUint(lastID, 10, 64)
+	if err != nil || last > b.next {
+		return nil
+	}
+
+	var result []Event
+	for _, event := range b.events {
+		if event.seq > last {
+			result = append(result, event)
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamReplaysMissedEvents(t *testing.T) {
+	store := NewInMemorySessionStore()
+	server := NewHTTPServer(nil, &ServerHandler{Name: "example"}, HTTPServerOptions{SessionStore: store, BaseContext: t.Context()})
+
+	session, err := NewServerSession(t.Context(), server.MessageHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close(true)
+	_ = store.Store(t.Context(), session.ID(), session)
+
+	stream := func(ctx context.Context, lastEventID string) string {
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		req.Header.Set(SessionIDHeader, session.ID())
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	// The first event is delivered on a stream that then drops.
+	ctx, cancel := context.WithCancel(t.Context())
+	first := make(chan string)
+	go func() {
+		first <- stream(ctx, "")
+	}()
+	for !session.wire.isReading() {
+		time.Sleep(time.Millisecond)
+	}
+	if err := session.GetSession().SendPayload(t.Context(), "notifications/message", map[string]any{"data": "one"}); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if body := <-first; !strings.Contains(body, "id: 1\ndata:") || !strings.Contains(body, `"one"`) {
+		t.Fatalf("unexpected first stream %q", body)
+	}
+
+	// These are sent while the client is disconnected.
+	for _, data := range []string{"two", "three"} {
+		if err := session.GetSession().SendPayload(t.Context(), "notifications/message", map[string]any{"data": data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done, cancel := context.WithCancel(t.Context())
+	cancel()
+	body := stream(done, "1")
+	if strings.Contains(body, `"one"`) || !strings.Contains(body, "id: 2\n") || !strings.Contains(body, `"two"`) ||
+		!strings.Contains(body, "id: 3\n") || !strings.Contains(body, `"three"`) {
+		t.Fatalf("unexpected replayed stream %q", body)
+	}
+}
@@ -42,6 +42,9 @@ type HTTPClient struct {
 
 	sseLock       sync.RWMutex
 	needReconnect bool
+	// lastEventID is the ID of the last event received on the stream, sent when the stream is
+	// reconnected so the server replays the events that were missed.
+	lastEventID string
 }
 
 func newHTTPClient(serverName string, config Server, oauthClientName, oauthRedirectURL string, callbackHandler CallbackHandler, clientCredLookup ClientCredLookup, tokenStorage TokenStorage, headers map[string]string, watchesEvents bool) *HTTPClient {
@@ -188,6 +191,10 @@ func (s *HTTPClient) ensureSSE(ctx context.Context, msg *Message, lastEventID st
 		return nil
 	}
 
+	if lastEventID == "" {
+		lastEventID = s.lastEventID
+	}
+
 	// Start the SSE stream with the managed context.
 	req, err := s.newRequest(s.ctx, http.MethodGet, nil)
 	if err != nil {
@@ -321,6 +328,9 @@ func (s *HTTPClient) ensureSSE(ctx context.Context, msg *Message, lastEventID st
 			seenID, message, ok := messages.readNextMessage("message")
 			if seenID != "" {
 				lastEventID = seenID
+				s.sseLock.Lock()
+				s.lastEventID = seenID
+				s.sseLock.Unlock()
 			}
 			if !ok {
 				if err := messages.err(); err != nil {
@@ -434,6 +444,11 @@ func (s *HTTPClient) initialize(ctx context.Context, msg Message) error {
 	s.initializeRequest = &msg
 	s.initializeLock.Unlock()
 
+	// The events of a previous session can not be resumed.
+	s.sseLock.Lock()
+	s.lastEventID = ""
+	s.sseLock.Unlock()
+
 	go func() {
 		if err = s.ensureSSE(ctx, nil, ""); err != nil {
 			log.Errorf(context.Background(), "failed to initialize SSE: %v", err)
@@ -98,31 +98,50 @@ func (h *HTTPServer) streamEvents(rw http.ResponseWriter, req *http.Request) {
 		flusher.Flush()
 	}
 
-	session.StartReading()
+	// Events the client missed while its stream was down are replayed first.
+	missed := session.ResumeReading(req.Header.Get("Last-Event-ID"))
 	defer session.StopReading()
 
+	for _, event := range missed {
+		if err := writeEvent(rw, event); err != nil {
+			return
+		}
+	}
+
 	for {
 		msg, ok := session.Read(req.Context())
 		if !ok {
 			return
 		}
 
-		data, _ := json.Marshal(msg)
-		_, err := rw.Write([]byte("data: " + string(data) + "\n\n"))
-		if err != nil {
+		if err := writeEvent(rw, session.RecordEvent(msg)); err != nil {
 			http.Error(rw, "Failed to write message: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if f, ok := rw.(http.Flusher); ok {
-			f.Flush()
-		}
 	}
 }
 
+func writeEvent(rw http.ResponseWriter, event Event) error {
+	data, _ := json.Marshal(event.Message)
+	if _, err := rw.Write([]byte("id: " + event.ID + "\ndata: " + string(data) + "\n\n")); err != nil {
+		return err
+	}
+	if f, ok := rw.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
 type requestKey struct{}
 
 func withRequest(req *http.Request) context.Context {
-	return context.WithValue(req.Context(), requestKey{}, req)
+	return WithRequest(req.Context(), req)
+}
+
+// WithRequest returns a copy of ctx that carries req, so handlers that are not served by the
+// HTTPServer are known to serve remote clients too.
+func WithRequest(ctx context.Context, req *http.Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
 }
 
 func RequestFromContext(ctx context.Context) *http.Request {
@@ -149,6 +168,11 @@ func (h *HTTPServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 			return
 		}
 
+		if IsWebSocketRequest(req) {
+			h.serveWebSocket(rw, req)
+			return
+		}
+
 		h.streamEvents(rw, req)
 		return
 	}
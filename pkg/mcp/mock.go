@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ServerMock replaces a server with canned results of its tools, so agents can be tried without
+// the real server running. It is usually set in a test or dev profile.
+type ServerMock struct {
+	Tools []ToolMock `json:"tools,omitempty"`
+}
+
+// ToolMock is a tool of a mocked server and the results it returns.
+type ToolMock struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+	// Responses are tried in order, the first one whose arguments match a call is returned.
+	Responses []MockResponse `json:"responses,omitempty"`
+}
+
+// MockResponse is a canned result of a tool. It matches a call if every argument it lists is equal
+// to the argument of the call, a response without arguments matches every call.
+type MockResponse struct {
+	Arguments         map[string]any `json:"arguments,omitempty"`
+	Text              string         `json:"text,omitempty"`
+	StructuredContent any            `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
+}
+
+var anyObjectSchema = json.RawMessage(`{"type": "object"}`)
+
+// NewMockServer returns a server with the tools of the mock.
+func NewMockServer(name string, mock ServerMock) *ServerHandler {
+	tools := ServerTools{}
+	for _, tool := range mock.Tools {
+		tools[tool.Name] = mockTool{tool: tool}
+	}
+	return &ServerHandler{
+		Name:  name,
+		Tools: tools,
+	}
+}
+
+type mockTool struct {
+	tool ToolMock
+}
+
+func (m mockTool) Definition() Tool {
+	schema := m.tool.InputSchema
+	if len(schema) == 0 {
+		schema = anyObjectSchema
+	}
+	return Tool{
+		Name:        m.tool.Name,
+		Description: m.tool.Description,
+		InputSchema: schema,
+	}
+}
+
+func (m mockTool) Invoke(_ context.Context, _ Message, call CallToolRequest) (*CallToolResult, error) {
+	for _, response := range m.tool.Responses {
+		if !response.matches(call.Arguments) {
+			continue
+		}
+		result := &CallToolResult{
+			StructuredContent: response.StructuredContent,
+			IsError:           response.IsError,
+			Content:           []Content{},
+		}
+		text := response.Text
+		if text == "" && response.StructuredContent != nil {
+			data, err := json.Marshal(response.StructuredContent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal structured content: %w", err)
+			}
+			text = string(data)
+		}
+		if text != "" {
+			result.Content = append(result.Content, Content{
+				Type: "text",
+				Text: text,
+			})
+		}
+		return result, nil
+	}
+
+	args, _ := json.Marshal(call.Arguments)
+	return &CallToolResult{
+		IsError: true,
+		Content: []Content{
+			{
+				Type: "text",
+				Text: fmt.Sprintf("no mock response of tool %s matches the arguments %s", m.tool.Name, args),
+			},
+		},
+	}, nil
+}
+
+// matches compares the arguments as JSON, so numbers match whether they were read from YAML or
+// sent by a model.
+func (r MockResponse) matches(args map[string]any) bool {
+	for key, want := range r.Arguments {
+		got, ok := args[key]
+		if !ok {
+			return false
+		}
+		wantJSON, err := json.Marshal(want)
+		if err != nil {
+			return false
+		}
+		gotJSON, err := json.Marshal(got)
+		if err != nil || !bytes.Equal(wantJSON, gotJSON) {
+			return false
+		}
+	}
+	return true
+}
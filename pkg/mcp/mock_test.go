@@ -0,0 +1,37 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMockServerMatchesArguments(t *testing.T) {
+	server := NewMockServer("weather", ServerMock{
+		Tools: []ToolMock{
+			{
+				Name: "forecast",
+				Responses: []MockResponse{
+					{Arguments: map[string]any{"city": "Paris", "days": 2}, Text: "sunny"},
+					{Text: "cloudy"},
+				},
+			},
+		},
+	})
+
+	call := func(args map[string]any) string {
+		t.Helper()
+		result, err := server.Tools.Call(context.Background(), Message{}, CallToolRequest{Name: "forecast", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Content[0].Text
+	}
+
+	// Arguments from a model are decoded from JSON, so the number is a float64.
+	if got := call(map[string]any{"city": "Paris", "days": float64(2), "units": "metric"}); got != "sunny" {
+		t.Fatalf("expected the matching response, got %q", got)
+	}
+	if got := call(map[string]any{"city": "Oslo"}); got != "cloudy" {
+		t.Fatalf("expected the response without arguments, got %q", got)
+	}
+}
@@ -0,0 +1,40 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseRoot reads a root in the form name:directory, or a directory that is named after its base.
+// Relative directories are relative to the working directory.
+func ParseRoot(def string) (Root, error) {
+	name, directory, ok := strings.Cut(def, ":")
+	if !ok {
+		name = filepath.Base(def)
+		directory = def
+	}
+	if directory == "" {
+		return Root{}, fmt.Errorf("root %q has no directory", def)
+	}
+	if !filepath.IsAbs(directory) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return Root{}, fmt.Errorf("failed to get current working directory: %w", err)
+		}
+		directory = filepath.Join(wd, directory)
+	}
+	return Root{
+		Name: name,
+		URI:  "file://" + directory,
+	}, nil
+}
+
+// NotifyRootsChanged tells the server that the roots of the client changed, so it lists them again.
+func (c *Client) NotifyRootsChanged(ctx context.Context) error {
+	return c.Session.Send(ctx, Message{
+		Method: "notifications/roots/list_changed",
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/nanobot-ai/nanobot/pkg/envvar"
 	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp/sandbox"
+	"github.com/nanobot-ai/nanobot/pkg/secrets"
 	"github.com/nanobot-ai/nanobot/pkg/supervise"
 	"github.com/nanobot-ai/nanobot/pkg/system"
 )
@@ -65,7 +66,15 @@ func (r *Runner) newCommand(ctx context.Context, currentEnv map[string]string, r
 	config.BaseURL = envvar.ReplaceString(currentEnv, config.BaseURL)
 
 	command, args, env := envvar.ReplaceEnv(currentEnv, config.Command, config.Args, config.Env)
-	if !config.Sandboxed || command == "nanobot" {
+	for i, kv := range env {
+		// Secrets are resolved when the server starts, a restarted server gets the rotated secret.
+		value, err := secrets.Expand(ctx, kv)
+		if err != nil {
+			return config, nil, fmt.Errorf("failed to resolve the env of %s: %w", config.Command, err)
+		}
+		env[i] = value
+	}
+	if (!config.Sandboxed && config.Sandbox == nil) || command == "nanobot" {
 		if command == "nanobot" {
 			command = system.Bin()
 		}
@@ -99,7 +108,7 @@ func (r *Runner) newCommand(ctx context.Context, currentEnv map[string]string, r
 		}
 	}
 
-	cmd, err := sandbox.NewCmd(ctx, sandbox.Command{
+	sandboxCommand := sandbox.Command{
 		PublishPorts: publishPorts,
 		ReversePorts: config.ReversePorts,
 		Roots:        rootPaths,
@@ -110,7 +119,18 @@ func (r *Runner) newCommand(ctx context.Context, currentEnv map[string]string, r
 		BaseImage:    config.Image,
 		Dockerfile:   config.Dockerfile,
 		Source:       sandbox.Source(config.Source),
-	})
+	}
+	if config.Sandbox != nil {
+		sandboxCommand.Runtime = config.Sandbox.Runtime
+		sandboxCommand.Network = config.Sandbox.Network
+		sandboxCommand.CPUs = config.Sandbox.CPUs
+		sandboxCommand.Memory = config.Sandbox.Memory
+		for _, mount := range config.Sandbox.Mounts {
+			sandboxCommand.Mounts = append(sandboxCommand.Mounts, envvar.ReplaceString(currentEnv, mount))
+		}
+	}
+
+	cmd, err := sandbox.NewCmd(ctx, sandboxCommand)
 	if err != nil {
 		return config, nil, fmt.Errorf("failed to create sandbox command: %w", err)
 	}
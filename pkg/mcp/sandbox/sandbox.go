@@ -36,6 +36,17 @@ type Command struct {
 	BaseImage    string
 	Dockerfile   string
 	Source       Source
+	// Runtime is the container runtime, docker or podman. If it is not set docker is used, or podman
+	// if only podman is installed.
+	Runtime string
+	// Mounts are the additional volumes of the container, in the host:container[:ro] format.
+	Mounts []string
+	// Network is the network the container is attached to, "none" isolates it from any network.
+	Network string
+	// CPUs and Memory limit the resources of the container, in the format of the --cpus and
+	// --memory flags of the runtime.
+	CPUs   string
+	Memory string
 }
 
 type Root struct {
@@ -82,6 +93,39 @@ func (c *Cmd) Start() error {
 	return nil
 }
 
+// runtime returns the container runtime of the command.
+func runtime(config Command) (string, error) {
+	switch config.Runtime {
+	case "docker", "podman":
+		return config.Runtime, nil
+	case "":
+		if _, err := exec.LookPath("docker"); err != nil {
+			if _, err := exec.LookPath("podman"); err == nil {
+				return "podman", nil
+			}
+		}
+		return "docker", nil
+	default:
+		return "", fmt.Errorf("invalid container runtime %q, must be docker or podman", config.Runtime)
+	}
+}
+
+// mountArg validates a mount of the host:container[:ro] format and returns it as a volume argument.
+func mountArg(mount string) (string, error) {
+	parts := strings.Split(mount, ":")
+	if len(parts) == 3 && (parts[2] == "ro" || parts[2] == "rw") {
+		parts = parts[:2]
+	} else if len(parts) != 2 {
+		return "", fmt.Errorf("invalid mount %q, must be host:container[:ro]", mount)
+	}
+	for _, path := range parts {
+		if !filepath.IsAbs(path) {
+			return "", fmt.Errorf("invalid mount %q, paths must be absolute", mount)
+		}
+	}
+	return mount, nil
+}
+
 func getBaseImage(ctx context.Context, config Command) (string, error) {
 	baseImage := config.BaseImage
 	if baseImage == "" {
@@ -104,6 +148,12 @@ func getBaseImage(ctx context.Context, config Command) (string, error) {
 }
 
 func NewCmd(ctx context.Context, sandbox Command) (*Cmd, error) {
+	var err error
+	sandbox.Runtime, err = runtime(sandbox)
+	if err != nil {
+		return nil, err
+	}
+
 	baseImage, err := getBaseImage(ctx, sandbox)
 	if err != nil {
 		return nil, err
@@ -138,11 +188,34 @@ func NewCmd(ctx context.Context, sandbox Command) (*Cmd, error) {
 		}
 		dockerArgs = append(dockerArgs, "-v", root.Path+":"+root.Path)
 	}
+	for _, mount := range sandbox.Mounts {
+		volume, err := mountArg(mount)
+		if err != nil {
+			return nil, err
+		}
+		dockerArgs = append(dockerArgs, "-v", volume)
+	}
 	if workdir != "" {
 		dockerArgs = append(dockerArgs, "-w", workdir)
 	}
-	for _, port := range sandbox.PublishPorts {
-		dockerArgs = append(dockerArgs, "-p", "127.0.0.1:"+port+":"+port)
+	if sandbox.Network != "" {
+		if !validChars.MatchString(sandbox.Network) {
+			return nil, fmt.Errorf("invalid network: %s", sandbox.Network)
+		}
+		dockerArgs = append(dockerArgs, "--network", sandbox.Network)
+	}
+	if sandbox.CPUs != "" {
+		dockerArgs = append(dockerArgs, "--cpus", sandbox.CPUs)
+	}
+	if sandbox.Memory != "" {
+		// Without a swap limit the container could use as much swap as memory in addition.
+		dockerArgs = append(dockerArgs, "--memory", sandbox.Memory, "--memory-swap", sandbox.Memory)
+	}
+	// Ports can not be published without a network.
+	if sandbox.Network != "none" {
+		for _, port := range sandbox.PublishPorts {
+			dockerArgs = append(dockerArgs, "-p", "127.0.0.1:"+port+":"+port)
+		}
 	}
 	dockerArgs = append(dockerArgs, "--", baseImage)
 	if sandbox.Command != "" {
@@ -151,13 +224,13 @@ func NewCmd(ctx context.Context, sandbox Command) (*Cmd, error) {
 	dockerArgs = append(dockerArgs, sandbox.Args...)
 
 	ctx, cancel := context.WithCancel(ctx)
-	cmd := supervise.Cmd(ctx, "docker", dockerArgs...)
+	cmd := supervise.Cmd(ctx, sandbox.Runtime, dockerArgs...)
 	return &Cmd{
 		cancel: cancel,
 		Cmd:    cmd,
 		postStart: func() error {
 			for _, port := range sandbox.ReversePorts {
-				if err := startReversePort(ctx, containerName, port, cancel); err != nil {
+				if err := startReversePort(ctx, sandbox.Runtime, containerName, port, cancel); err != nil {
 					return err
 				}
 			}
@@ -202,7 +275,7 @@ func buildImage(ctx context.Context, baseImage string, config Command) (string,
 	var cmd *exec.Cmd
 	if isGit {
 		log.Infof(ctx, "Downloading source: %s", source)
-		cmd = exec.CommandContext(ctx, "docker", "build", "-q", "-")
+		cmd = exec.CommandContext(ctx, config.Runtime, "build", "-q", "-")
 		cmd.Stdin = dockerFileToTar(fmt.Sprintf(`FROM %s
 USER %d:%d
 WORKDIR /mcp
@@ -213,7 +286,7 @@ ADD %s /mcp`, baseImage, uid, gid, source))
 		if srcPath == "" {
 			srcPath = "."
 		}
-		cmd = exec.CommandContext(ctx, "docker", "build", "-q", "-f", "-", config.Source.Repo)
+		cmd = exec.CommandContext(ctx, config.Runtime, "build", "-q", "-f", "-", config.Source.Repo)
 		cmd.Stdin = bytes.NewBufferString(fmt.Sprintf(`FROM %s
 USER %d:%d
 WORKDIR /mcp
@@ -263,7 +336,7 @@ func buildBaseImage(ctx context.Context, config Command) (string, error) {
 	}()
 
 	outBuf := &bytes.Buffer{}
-	cmd := exec.CommandContext(ctx, "docker", "build", "--iidfile", f.Name(), "-")
+	cmd := exec.CommandContext(ctx, config.Runtime, "build", "--iidfile", f.Name(), "-")
 	cmd.Stdin = dockerFileToTar(config.Dockerfile)
 	cmd.Stdout = outBuf
 	stdErr, err := cmd.StderrPipe()
@@ -0,0 +1,56 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCmdLimits(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cmd, err := NewCmd(t.Context(), Command{
+		Runtime:      "podman",
+		PublishPorts: []string{"8080"},
+		Command:      "sh",
+		BaseImage:    "alpine",
+		Mounts:       []string{"/srv/data:/data:ro"},
+		Network:      "none",
+		CPUs:         "0.5",
+		Memory:       "256m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := strings.Join(cmd.Args, " ")
+	for _, expected := range []string{
+		"podman run",
+		"-v /srv/data:/data:ro",
+		"--network none",
+		"--cpus 0.5",
+		"--memory 256m --memory-swap 256m",
+		"-- alpine sh",
+	} {
+		if !strings.Contains(args, expected) {
+			t.Errorf("expected %q in %s", expected, args)
+		}
+	}
+	if strings.Contains(args, "-p ") {
+		t.Errorf("expected no published ports without a network, got %s", args)
+	}
+}
+
+func TestNewCmdInvalid(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	for _, command := range []Command{
+		{Runtime: "lxc"},
+		{Runtime: "docker", Mounts: []string{"data:/data"}},
+		{Runtime: "docker", Mounts: []string{"/data"}},
+		{Runtime: "docker", Network: "bridge; rm"},
+	} {
+		if _, err := NewCmd(t.Context(), command); err == nil {
+			t.Errorf("expected an error for %+v", command)
+		}
+	}
+}
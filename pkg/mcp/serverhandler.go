@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/nanobot-ai/nanobot/pkg/version"
+)
+
+// ServerHandler is a MessageHandler that implements an MCP server from its tools, resources and
+// prompts. It answers initialize with the capabilities of what is set, and the list, read, get and
+// call requests from the registries. A server that handles more methods embeds it and falls back to
+// its OnMessage for the methods it does not handle itself.
+type ServerHandler struct {
+	// Name and Version are the server info returned on initialize, they default to nanobot.
+	Name         string
+	Version      string
+	Instructions string
+	Tools        ServerTools
+	Resources    ServerResources
+	Prompts      ServerPrompts
+}
+
+func (s *ServerHandler) OnMessage(ctx context.Context, msg Message) {
+	switch msg.Method {
+	case "initialize":
+		Invoke(ctx, msg, s.initialize)
+	case "notifications/initialized":
+		// nothing to do
+	case "ping":
+		Invoke(ctx, msg, func(context.Context, Message, PingRequest) (*PingResult, error) {
+			return &PingResult{}, nil
+		})
+	case "tools/list":
+		Invoke(ctx, msg, s.Tools.List)
+	case "tools/call":
+		Invoke(ctx, msg, s.Tools.Call)
+	case "resources/list":
+		Invoke(ctx, msg, s.Resources.List)
+	case "resources/read":
+		Invoke(ctx, msg, s.Resources.Read)
+	case "prompts/list":
+		Invoke(ctx, msg, s.Prompts.List)
+	case "prompts/get":
+		Invoke(ctx, msg, s.Prompts.Get)
+	default:
+		msg.SendError(ctx, ErrRPCMethodNotFound.WithMessage("%s", msg.Method))
+	}
+}
+
+func (s *ServerHandler) initialize(_ context.Context, _ Message, params InitializeRequest) (*InitializeResult, error) {
+	result := &InitializeResult{
+		ProtocolVersion: params.ProtocolVersion,
+		ServerInfo: ServerInfo{
+			Name:    s.Name,
+			Version: s.Version,
+		},
+		Instructions: s.Instructions,
+	}
+	if result.ServerInfo.Name == "" {
+		result.ServerInfo.Name = version.Name
+	}
+	if result.ServerInfo.Version == "" {
+		result.ServerInfo.Version = version.Get().String()
+	}
+	if len(s.Tools) > 0 {
+		result.Capabilities.Tools = &ToolsServerCapability{}
+	}
+	if len(s.Resources) > 0 {
+		result.Capabilities.Resources = &ResourcesServerCapability{}
+	}
+	if len(s.Prompts) > 0 {
+		result.Capabilities.Prompts = &PromptsServerCapability{}
+	}
+	return result, nil
+}
+
+// Notify sends a notification to the client of the session of ctx. It does nothing if ctx has no
+// session.
+func Notify(ctx context.Context, method string, params any) error {
+	session := SessionFromContext(ctx)
+	if session == nil {
+		return nil
+	}
+	if params == nil {
+		params = map[string]any{}
+	}
+	return session.SendPayload(ctx, method, params)
+}
+
+// NotifyResourceUpdated tells the client of the session of ctx that the resource changed.
+func NotifyResourceUpdated(ctx context.Context, uri string) error {
+	return Notify(ctx, "notifications/resources/updated", map[string]any{
+		"uri": uri,
+	})
+}
+
+// NotifyListChanged tells the client of the session of ctx that the list of "tools", "resources" or
+// "prompts" of the server changed.
+func NotifyListChanged(ctx context.Context, kind string) error {
+	return Notify(ctx, "notifications/"+kind+"/list_changed", nil)
+}
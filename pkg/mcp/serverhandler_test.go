@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestServerHandler(t *testing.T) {
+	handler := &ServerHandler{
+		Name: "example",
+		Tools: NewServerTools(
+			NewServerTool("greet", "Greets a person", func(_ context.Context, in struct {
+				Name string `json:"name"`
+			}) (string, error) {
+				return "Hello " + in.Name, nil
+			}),
+		),
+		Resources: NewServerResources(
+			NewServerTextResource(Resource{URI: "example://motd", Name: "motd", MimeType: "text/plain"}, func(context.Context) (string, error) {
+				return "Have a nice day", nil
+			}),
+		),
+		Prompts: NewServerPrompts(
+			NewServerPrompt(Prompt{Name: "review", Arguments: []PromptArgument{{Name: "file", Required: true}}},
+				func(_ context.Context, args map[string]string) (*GetPromptResult, error) {
+					return &GetPromptResult{Messages: []PromptMessage{{Role: "user", Content: Content{Type: "text", Text: "Review " + args["file"]}}}}, nil
+				}),
+		),
+	}
+
+	server, err := NewServerSession(t.Context(), handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(t.Context(), "example", Server{}, ClientOption{Wire: server})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(false)
+
+	if info := client.Session.InitializeResult; info.ServerInfo.Name != "example" || info.Capabilities.Tools == nil ||
+		info.Capabilities.Resources == nil || info.Capabilities.Prompts == nil {
+		t.Fatalf("unexpected initialize result %+v", info)
+	}
+
+	result, err := client.Call(t.Context(), "greet", map[string]any{"name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Hello Ada") {
+		t.Fatalf("unexpected call result %+v", result)
+	}
+
+	resources, err := client.ReadResource(t.Context(), "example://motd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resources.Contents[0].Text != "Have a nice day" {
+		t.Fatalf("unexpected resource %+v", resources)
+	}
+
+	prompt, err := client.GetPrompt(t.Context(), "review", map[string]string{"file": "main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prompt.Messages[0].Content.Text != "Review main.go" {
+		t.Fatalf("unexpected prompt %+v", prompt)
+	}
+
+	if _, err := client.GetPrompt(t.Context(), "review", nil); err == nil {
+		t.Fatal("expected an error for a missing argument")
+	}
+}
@@ -0,0 +1,151 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ServerResources are the resources of a server, by URI.
+type ServerResources map[string]ServerResource
+
+type ServerResource interface {
+	Definition() Resource
+	Read(ctx context.Context) ([]ResourceContent, error)
+}
+
+type serverResource struct {
+	resource Resource
+	read     func(ctx context.Context) ([]ResourceContent, error)
+}
+
+func (s *serverResource) Definition() Resource {
+	return s.resource
+}
+
+func (s *serverResource) Read(ctx context.Context) ([]ResourceContent, error) {
+	return s.read(ctx)
+}
+
+// NewServerResource returns a resource whose contents are returned by read when the resource is read.
+func NewServerResource(resource Resource, read func(ctx context.Context) ([]ResourceContent, error)) ServerResource {
+	return &serverResource{
+		resource: resource,
+		read:     read,
+	}
+}
+
+// NewServerTextResource returns a resource with a text content, returned by read.
+func NewServerTextResource(resource Resource, read func(ctx context.Context) (string, error)) ServerResource {
+	return NewServerResource(resource, func(ctx context.Context) ([]ResourceContent, error) {
+		text, err := read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []ResourceContent{
+			{
+				URI:      resource.URI,
+				Name:     resource.Name,
+				MIMEType: resource.MimeType,
+				Text:     text,
+			},
+		}, nil
+	})
+}
+
+func NewServerResources(resources ...ServerResource) ServerResources {
+	result := make(ServerResources, len(resources))
+	for _, resource := range resources {
+		result[resource.Definition().URI] = resource
+	}
+	return result
+}
+
+func (s ServerResources) List(_ context.Context, _ Message, _ ListResourcesRequest) (*ListResourcesResult, error) {
+	resources := []Resource{}
+	for _, key := range slices.Sorted(maps.Keys(s)) {
+		resources = append(resources, s[key].Definition())
+	}
+
+	return &ListResourcesResult{
+		Resources: resources,
+	}, nil
+}
+
+func (s ServerResources) Read(ctx context.Context, _ Message, payload ReadResourceRequest) (*ReadResourceResult, error) {
+	resource, ok := s[payload.URI]
+	if !ok {
+		return nil, ErrRPCInvalidParams.WithMessage("unknown resource %s", payload.URI)
+	}
+
+	contents, err := resource.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", payload.URI, err)
+	}
+	return &ReadResourceResult{
+		Contents: contents,
+	}, nil
+}
+
+// ServerPrompts are the prompts of a server, by name.
+type ServerPrompts map[string]ServerPrompt
+
+type ServerPrompt interface {
+	Definition() Prompt
+	Get(ctx context.Context, args map[string]string) (*GetPromptResult, error)
+}
+
+type serverPrompt struct {
+	prompt Prompt
+	get    func(ctx context.Context, args map[string]string) (*GetPromptResult, error)
+}
+
+func (s *serverPrompt) Definition() Prompt {
+	return s.prompt
+}
+
+func (s *serverPrompt) Get(ctx context.Context, args map[string]string) (*GetPromptResult, error) {
+	for _, arg := range s.prompt.Arguments {
+		if arg.Required && args[arg.Name] == "" {
+			return nil, ErrRPCInvalidParams.WithMessage("missing argument %s of prompt %s", arg.Name, s.prompt.Name)
+		}
+	}
+	return s.get(ctx, args)
+}
+
+// NewServerPrompt returns a prompt whose messages are returned by get. The required arguments of the
+// prompt are checked before get is called.
+func NewServerPrompt(prompt Prompt, get func(ctx context.Context, args map[string]string) (*GetPromptResult, error)) ServerPrompt {
+	return &serverPrompt{
+		prompt: prompt,
+		get:    get,
+	}
+}
+
+func NewServerPrompts(prompts ...ServerPrompt) ServerPrompts {
+	result := make(ServerPrompts, len(prompts))
+	for _, prompt := range prompts {
+		result[prompt.Definition().Name] = prompt
+	}
+	return result
+}
+
+func (s ServerPrompts) List(_ context.Context, _ Message, _ ListPromptsRequest) (*ListPromptsResult, error) {
+	prompts := []Prompt{}
+	for _, key := range slices.Sorted(maps.Keys(s)) {
+		prompts = append(prompts, s[key].Definition())
+	}
+
+	return &ListPromptsResult{
+		Prompts: prompts,
+	}, nil
+}
+
+func (s ServerPrompts) Get(ctx context.Context, _ Message, payload GetPromptRequest) (*GetPromptResult, error) {
+	prompt, ok := s[payload.Name]
+	if !ok {
+		return nil, ErrRPCInvalidParams.WithMessage("unknown prompt %s", payload.Name)
+	}
+	return prompt.Get(ctx, payload.Arguments)
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
+	"github.com/nanobot-ai/nanobot/pkg/lifecycle"
 	"github.com/nanobot-ai/nanobot/pkg/uuid"
 )
 
@@ -14,6 +16,15 @@ var (
 	_ Wire = (*ServerSession)(nil)
 )
 
+var sessionStarted atomic.Pointer[func(ctx context.Context)]
+
+// OnSessionStarted sets a function that is called with the context of every new server session that
+// is not the child of another session. The context is done once the session is closed, so the caller
+// can track the active sessions, such as in metrics.
+func OnSessionStarted(f func(ctx context.Context)) {
+	sessionStarted.Store(&f)
+}
+
 func NewServerSession(ctx context.Context, handler MessageHandler) (*ServerSession, error) {
 	return NewExistingServerSession(ctx,
 		SessionState{
@@ -29,10 +40,14 @@ func NewExistingServerSession(ctx context.Context, state SessionState, handler M
 	}
 	s.stopReading()
 
-	session, err := newSession(ctx, s, handler, &state, nil, SessionFromContext(ctx))
+	parent := SessionFromContext(ctx)
+	session, err := newSession(ctx, s, handler, &state, nil, parent)
 	if err != nil {
 		return nil, err
 	}
+	if started := sessionStarted.Load(); parent == nil && started != nil {
+		(*started)(session.ctx)
+	}
 	for k, v := range state.Attributes {
 		session.Set(k, v)
 	}
@@ -57,7 +72,7 @@ func (s *ServerSession) Wait() {
 func (s *ServerSession) Start(ctx context.Context, handler WireHandler) error {
 	s.wire.startReading()
 
-	go func() {
+	lifecycle.Go(ctx, s.ID(), lifecycle.KindStream, "read messages", func(ctx context.Context) {
 		defer s.wire.stopReading()
 
 		for {
@@ -71,7 +86,7 @@ func (s *ServerSession) Start(ctx context.Context, handler WireHandler) error {
 				handler(ctx, msg)
 			}
 		}
-	}()
+	})
 	return nil
 }
 
@@ -133,9 +148,28 @@ func (s *ServerSession) StopReading() {
 	s.wire.stopReading()
 }
 
+// ResumeReading starts reading like StartReading and returns the events after lastEventID that
+// the client missed, either because its stream dropped before it received them or because they
+// were sent while it was disconnected.
+func (s *ServerSession) ResumeReading(lastEventID string) []Event {
+	s.wire.startReading()
+	if lastEventID == "" {
+		return nil
+	}
+	return s.wire.events.since(lastEventID)
+}
+
+// RecordEvent assigns an event ID to a message that is written to the stream of the client and
+// keeps it to be replayed.
+func (s *ServerSession) RecordEvent(msg Message) Event {
+	return s.wire.events.add(msg)
+}
+
 func (s *ServerSession) Send(ctx context.Context, req Message) error {
 	req.Session = s.session
-	go s.session.handler.OnMessage(WithSession(ctx, s.session), req)
+	lifecycle.Go(ctx, s.ID(), lifecycle.KindRequest, req.Method, func(ctx context.Context) {
+		s.session.handler.OnMessage(WithSession(ctx, s.session), req)
+	})
 	return nil
 }
 
@@ -159,6 +193,8 @@ type serverWire struct {
 	read       chan Message
 	readerLock sync.RWMutex
 	noReader   chan struct{}
+	hadReader  bool
+	events     eventBuffer
 	handler    WireHandler
 	sessionID  string
 }
@@ -176,10 +212,10 @@ func (s *serverWire) exchange(ctx context.Context, msg Message) (Message, error)
 	ch := s.pending.WaitFor(msg.ID)
 	defer s.pending.Done(msg.ID)
 
-	go func() {
+	lifecycle.Go(ctx, s.sessionID, lifecycle.KindRequest, msg.Method, func(ctx context.Context) {
 		s.handler(ctx, msg)
 		close(ch)
-	}()
+	})
 
 	select {
 	case <-ctx.Done():
@@ -212,23 +248,45 @@ func (s *serverWire) Send(ctx context.Context, req Message) error {
 	if s.pending.Notify(req) {
 		return nil
 	}
+	if req.ID == nil && s.bufferIfDisconnected(req) {
+		return nil
+	}
+
+	s.readerLock.RLock()
+	noReader := s.noReader
+	s.readerLock.RUnlock()
+
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	case <-s.ctx.Done():
 		return s.ctx.Err()
-	case <-s.noReader:
+	case <-noReader:
 		return ErrNoReader
 	case s.read <- req:
 		return nil
 	}
 }
 
+// bufferIfDisconnected keeps a notification for the client to replay if the stream of the client
+// dropped. It returns false if a client is reading or never did.
+func (s *serverWire) bufferIfDisconnected(msg Message) bool {
+	s.readerLock.RLock()
+	defer s.readerLock.RUnlock()
+
+	if s.noReader == nil || !s.hadReader {
+		return false
+	}
+	s.events.add(msg)
+	return true
+}
+
 func (s *serverWire) startReading() {
 	s.readerLock.Lock()
 	defer s.readerLock.Unlock()
 
 	s.noReader = nil
+	s.hadReader = true
 }
 
 func (s *serverWire) stopReading() {
@@ -243,5 +301,5 @@ func (s *serverWire) isReading() bool {
 	s.readerLock.RLock()
 	defer s.readerLock.RUnlock()
 
-	return s.noReader != nil
+	return s.noReader == nil
 }
@@ -0,0 +1,34 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOnSessionStarted(t *testing.T) {
+	var started []context.Context
+	OnSessionStarted(func(ctx context.Context) {
+		started = append(started, ctx)
+	})
+	t.Cleanup(func() {
+		sessionStarted.Store(nil)
+	})
+
+	handler := MessageHandlerFunc(func(context.Context, Message) {})
+	session, err := NewServerSession(t.Context(), handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sessions of the servers of a session are not counted.
+	if _, err := NewServerSession(session.GetSession().Context(), handler); err != nil {
+		t.Fatal(err)
+	}
+	if len(started) != 1 {
+		t.Fatalf("got %d started sessions, want 1", len(started))
+	}
+
+	session.Close(false)
+	if started[0].Err() == nil {
+		t.Error("expected the context of the session to be done once it is closed")
+	}
+}
@@ -42,6 +42,7 @@ func Invoke[T any, R comparable](ctx context.Context, msg Message, handler func(
 	}
 }
 
+// ServerTools are the tools of a server, by name.
 type ServerTools map[string]ServerTool
 
 func (s ServerTools) Call(ctx context.Context, msg Message, payload CallToolRequest) (*CallToolResult, error) {
@@ -53,6 +54,8 @@ func (s ServerTools) Call(ctx context.Context, msg Message, payload CallToolRequ
 	return tool.Invoke(ctx, msg, payload)
 }
 
+// ServerTool is a tool of a server. Most tools are created with NewServerTool, implementing it
+// directly gives access to the message of the call.
 type ServerTool interface {
 	Definition() Tool
 	Invoke(ctx context.Context, msg Message, call CallToolRequest) (*CallToolResult, error)
@@ -95,6 +98,11 @@ func NewServerTools(tools ...ServerTool) ServerTools {
 	return result
 }
 
+// NewServerTool returns a tool that calls handler with the arguments of the call decoded into In. The
+// input schema of the tool is generated from In, so the description of a field is set with its
+// jsonschema tag. The result of handler is returned as the content if it is Content, []Content,
+// *CallToolResult or a Resource, and as structured content otherwise. An error of handler is
+// returned as an error of the call.
 func NewServerTool[In, Out any](name, description string, handler func(ctx context.Context, in In) (Out, error)) ServerTool {
 	inSchema, err := jsonschema.For[In]()
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 	"sync"
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/lifecycle"
 )
 
 var ErrNoResult = errors.New("no result in response")
@@ -97,10 +98,10 @@ func (s *Session) Go(ctx context.Context, f func(ctx context.Context)) {
 	if sm != nil && id != "" {
 		tempSession, ok, sessionErr := sm.Acquire(ctx, nil, id)
 		if sessionErr == nil && ok {
-			go func() {
+			lifecycle.Go(ctx, id, lifecycle.KindJob, "background call", func(ctx context.Context) {
 				defer sm.Release(tempSession)
 				f(WithSession(ctx, s))
-			}()
+			})
 			return
 		}
 	}
@@ -344,6 +345,7 @@ func (s *Session) Close(deleteSession bool) {
 	}
 	s.pendingRequest.Close()
 	s.cancel(fmt.Errorf("session closed: %s, delete=%v", s.ID(), deleteSession))
+	lifecycle.CloseSession(s.ID())
 }
 
 func (s *Session) Wait() {
@@ -405,7 +407,8 @@ func (s *Session) SendPayload(ctx context.Context, method string, payload any) e
 
 func (s *Session) Send(ctx context.Context, req Message) error {
 	if s.wire == nil {
-		return fmt.Errorf("empty session: wire is not initialized")
+		// Nobody reads the messages of an empty session, such as the sessions of the CLI.
+		return fmt.Errorf("empty session: wire is not initialized: %w", ErrNoReader)
 	}
 
 	s.lock.Lock()
@@ -10,6 +10,8 @@ type ClientCapabilities struct {
 	Roots       *RootsCapability `json:"roots,omitempty"`
 	Sampling    *struct{}        `json:"sampling,omitzero"`
 	Elicitation *struct{}        `json:"elicitation,omitzero"`
+	// Experimental are capabilities outside the specification, by name.
+	Experimental map[string]any `json:"experimental,omitempty"`
 }
 
 type RootsCapability struct {
@@ -153,7 +155,8 @@ type Content struct {
 	// Description is used for resource_link
 	Description string `json:"description,omitempty"`
 
-	// URI is used for resource_link
+	// URI is used for resource_link, and for "image" when the image is referenced by URL instead
+	// of being inlined in Data
 	URI string `json:"uri,omitempty"`
 
 	// Text is set when type is "text"
@@ -196,6 +199,9 @@ type CreateMessageResult struct {
 }
 
 func (c *Content) ToImageURL() string {
+	if c.Data == "" && c.URI != "" {
+		return c.URI
+	}
 	return "data:" + c.MIMEType + ";base64," + c.Data
 }
 
@@ -0,0 +1,475 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+)
+
+const (
+	// webSocketReadLimit is the largest message that is read, the same as the line limit of stdio.
+	webSocketReadLimit = 10 * 1024 * 1024
+	// webSocketPingInterval is how often an idle connection is checked with a ping, so that proxies
+	// do not drop it and dead peers are noticed.
+	webSocketPingInterval = 30 * time.Second
+	webSocketPingTimeout  = 10 * time.Second
+	webSocketWriteTimeout = 30 * time.Second
+	// webSocketQueueSize is the number of outgoing messages that are buffered while the connection
+	// is slow or reconnecting, Send blocks once it is full.
+	webSocketQueueSize = 64
+	// webSocketMaxInFlight is the number of received requests that are handled at the same time.
+	// Once it is reached the connection is not read until a request finishes, which pushes back on
+	// the peer.
+	webSocketMaxInFlight = 64
+	// webSocketReconnectAttempts is how often the client tries to reconnect and resume its session
+	// before it gives up.
+	webSocketReconnectAttempts = 5
+	webSocketMaxBackoff        = 30 * time.Second
+)
+
+// IsWebSocketURL reports whether the URL of a server uses the WebSocket transport.
+func IsWebSocketURL(u string) bool {
+	return strings.HasPrefix(u, "ws://") || strings.HasPrefix(u, "wss://")
+}
+
+// IsWebSocketRequest reports whether req asks to upgrade the connection to a WebSocket.
+func IsWebSocketRequest(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
+
+// WebSocket is the client side of the WebSocket transport. Messages are sent as JSON text
+// messages. If the connection drops, it reconnects with the session ID the server assigned, so
+// the server resumes the same session. Messages sent in the meantime are queued.
+type WebSocket struct {
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	url        string
+	serverName string
+	headers    map[string]string
+	handler    WireHandler
+	waiter     *waiter
+	outgoing   chan Message
+	inFlight   chan struct{}
+
+	// unsent is a message whose write failed, it is sent first on the next connection. Only the
+	// writer of the current connection uses it.
+	unsent *Message
+
+	lock      sync.Mutex
+	conn      *websocket.Conn
+	sessionID string
+	closed    bool
+}
+
+func newWebSocketClient(serverName string, config Server, headers map[string]string) *WebSocket {
+	return &WebSocket{
+		url:        config.BaseURL,
+		serverName: serverName,
+		headers:    maps.Clone(headers),
+		sessionID:  headers[SessionIDHeader],
+		waiter:     newWaiter(),
+		outgoing:   make(chan Message, webSocketQueueSize),
+		inFlight:   make(chan struct{}, webSocketMaxInFlight),
+	}
+}
+
+func (w *WebSocket) SessionID() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.sessionID
+}
+
+// Close closes the connection. If deleteSession is set the connection is closed normally, which
+// tells the server to delete the session, otherwise the server keeps it to be resumed.
+func (w *WebSocket) Close(deleteSession bool) {
+	w.lock.Lock()
+	conn := w.conn
+	w.closed = true
+	w.lock.Unlock()
+
+	if conn != nil {
+		if deleteSession {
+			_ = conn.Close(websocket.StatusNormalClosure, "session closed")
+		} else {
+			_ = conn.Close(websocket.StatusGoingAway, "client closed")
+		}
+	}
+	if w.cancel != nil {
+		w.cancel(fmt.Errorf("websocket client closed session: %v, deleteSession=%v", w.SessionID(), deleteSession))
+	}
+	w.waiter.Close()
+}
+
+func (w *WebSocket) isClosed() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.closed || w.ctx.Err() != nil
+}
+
+func (w *WebSocket) Wait() {
+	w.waiter.Wait()
+}
+
+// Send queues the message. It blocks while the queue is full, until ctx is done.
+func (w *WebSocket) Send(ctx context.Context, msg Message) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-w.ctx.Done():
+		return fmt.Errorf("websocket connection to %s is closed: %w", w.serverName, context.Cause(w.ctx))
+	case w.outgoing <- msg:
+		return nil
+	}
+}
+
+func (w *WebSocket) Start(ctx context.Context, handler WireHandler) error {
+	w.ctx, w.cancel = context.WithCancelCause(ctx)
+	w.handler = handler
+
+	conn, err := w.dial(w.ctx)
+	if err != nil {
+		w.cancel(err)
+		w.waiter.Close()
+		return err
+	}
+
+	go w.run(conn)
+	return nil
+}
+
+// run serves connections until the client is closed or it can not reconnect.
+func (w *WebSocket) run(conn *websocket.Conn) {
+	defer w.Close(false)
+
+	for {
+		err := w.serve(conn)
+		if w.isClosed() {
+			return
+		}
+		if status := websocket.CloseStatus(err); status == websocket.StatusNormalClosure {
+			log.Infof(w.ctx, "websocket server %s closed the session", w.serverName)
+			return
+		}
+
+		log.Errorf(w.ctx, "websocket connection to %s lost, reconnecting: %v", w.serverName, err)
+		conn, err = w.reconnect()
+		if err != nil {
+			log.Errorf(w.ctx, "failed to reconnect to websocket server %s: %v", w.serverName, err)
+			return
+		}
+	}
+}
+
+func (w *WebSocket) reconnect() (*websocket.Conn, error) {
+	var (
+		backoff = time.Second
+		err     error
+	)
+	for range webSocketReconnectAttempts {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		var conn *websocket.Conn
+		if conn, err = w.dial(w.ctx); err == nil {
+			return conn, nil
+		}
+		backoff = min(2*backoff, webSocketMaxBackoff)
+	}
+	return nil, err
+}
+
+func (w *WebSocket) dial(ctx context.Context) (*websocket.Conn, error) {
+	header := http.Header{}
+	for k, v := range w.headers {
+		header.Set(k, v)
+	}
+	if id := w.SessionID(); id != "" {
+		header.Set(SessionIDHeader, id)
+	}
+
+	conn, resp, err := websocket.Dial(ctx, w.url, webSocketDialOptions(header))
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("session %s not found on websocket server %s", w.SessionID(), w.serverName)
+		}
+		return nil, fmt.Errorf("failed to connect to websocket server %s: %w", w.serverName, err)
+	}
+	conn.SetReadLimit(webSocketReadLimit)
+
+	w.lock.Lock()
+	w.conn = conn
+	if id := resp.Header.Get(SessionIDHeader); id != "" {
+		w.sessionID = id
+	}
+	w.lock.Unlock()
+	return conn, nil
+}
+
+// serve reads, writes, and pings the connection until one of them fails.
+func (w *WebSocket) serve(conn *websocket.Conn) error {
+	ctx, cancel := context.WithCancel(w.ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 3)
+	)
+	wg.Go(func() {
+		errs <- w.readMessages(ctx, conn)
+	})
+	wg.Go(func() {
+		errs <- w.writeMessages(ctx, conn)
+	})
+	wg.Go(func() {
+		errs <- keepAlive(ctx, conn)
+	})
+
+	err := <-errs
+	cancel()
+	_ = conn.CloseNow()
+	wg.Wait()
+	return err
+}
+
+func (w *WebSocket) readMessages(ctx context.Context, conn *websocket.Conn) error {
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return err
+		}
+		log.Messages(ctx, w.serverName, false, data)
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Errorf(ctx, "failed to unmarshal message: %v", err)
+			continue
+		}
+
+		if msg.Method == "" {
+			// Responses are not limited, requests that are handled may be waiting for them.
+			go w.handler(w.ctx, msg)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case w.inFlight <- struct{}{}:
+		}
+		go func() {
+			defer func() { <-w.inFlight }()
+			w.handler(w.ctx, msg)
+		}()
+	}
+}
+
+func (w *WebSocket) writeMessages(ctx context.Context, conn *websocket.Conn) error {
+	for {
+		var msg Message
+		if w.unsent != nil {
+			msg, w.unsent = *w.unsent, nil
+		} else {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case msg = <-w.outgoing:
+			}
+		}
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			log.Errorf(ctx, "failed to marshal message: %v", err)
+			continue
+		}
+
+		log.Messages(ctx, w.serverName, true, data)
+		if err := writeWebSocket(ctx, conn, data); err != nil {
+			w.unsent = &msg
+			return err
+		}
+	}
+}
+
+func writeWebSocket(ctx context.Context, conn *websocket.Conn, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, webSocketWriteTimeout)
+	defer cancel()
+	return conn.Write(ctx, websocket.MessageText, data)
+}
+
+// keepAlive pings the peer until ctx is done or a ping is not answered in time.
+func keepAlive(ctx context.Context, conn *websocket.Conn) error {
+	ticker := time.NewTicker(webSocketPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, webSocketPingTimeout)
+		err := conn.Ping(pingCtx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("ping failed: %w", err)
+		}
+	}
+}
+
+// serveWebSocket serves a session over a WebSocket. A connection with a session ID resumes that
+// session, otherwise a new session is started and its ID is returned in the handshake. The session
+// is deleted when the client closes the connection normally, and kept to be resumed otherwise.
+func (h *HTTPServer) serveWebSocket(rw http.ResponseWriter, req *http.Request) {
+	var (
+		session *ServerSession
+		stored  atomic.Bool
+		err     error
+	)
+
+	if id := h.sessions.ExtractID(req); id != "" {
+		var ok bool
+		session, ok, err = h.sessions.Acquire(req.Context(), h.MessageHandler, id)
+		if err != nil {
+			http.Error(rw, "Failed to load session: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(rw, "Session not found", http.StatusNotFound)
+			return
+		}
+		stored.Store(true)
+	} else {
+		session, err = NewServerSession(h.ctx, h.MessageHandler)
+		if err != nil {
+			http.Error(rw, "Failed to create session: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	defer h.sessions.Release(session)
+	defer func() {
+		if !stored.Load() {
+			// The connection was closed before the session was initialized.
+			session.Close(true)
+		}
+	}()
+
+	session.session.sessionManager = h.sessions
+	session.session.AddEnv(h.getEnv(req))
+
+	rw.Header().Set(SessionIDHeader, session.ID())
+	conn, err := websocket.Accept(rw, req, nil)
+	if err != nil {
+		// Accept already wrote the error response.
+		return
+	}
+	conn.SetReadLimit(webSocketReadLimit)
+	defer conn.CloseNow()
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	session.StartReading()
+	defer session.StopReading()
+
+	// Messages from the server, such as notifications and requests to the client.
+	go func() {
+		defer cancel()
+		for {
+			msg, ok := session.Read(ctx)
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				log.Errorf(ctx, "failed to marshal message: %v", err)
+				continue
+			}
+			if err := writeWebSocket(ctx, conn, data); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer cancel()
+		_ = keepAlive(ctx, conn)
+	}()
+
+	err = h.readWebSocket(ctx, conn, session, &stored)
+	if websocket.CloseStatus(err) == websocket.StatusNormalClosure {
+		if deleted, ok, _ := h.sessions.LoadAndDelete(context.WithoutCancel(ctx), h.MessageHandler, session.ID()); ok {
+			deleted.Close(true)
+		}
+	}
+}
+
+// readWebSocket handles the messages of the client until the connection is closed. At most
+// webSocketMaxInFlight requests are handled at the same time.
+func (h *HTTPServer) readWebSocket(ctx context.Context, conn *websocket.Conn, session *ServerSession, stored *atomic.Bool) error {
+	inFlight := make(chan struct{}, webSocketMaxInFlight)
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return err
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Errorf(ctx, "failed to unmarshal message: %v", err)
+			continue
+		}
+
+		if msg.Method != "" {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case inFlight <- struct{}{}:
+			}
+		}
+
+		go func() {
+			if msg.Method != "" {
+				defer func() { <-inFlight }()
+			}
+
+			response, err := session.Exchange(ctx, msg)
+			defer func() {
+				if h.sessions.Store(ctx, session.ID(), session) == nil {
+					stored.Store(true)
+				}
+			}()
+			if errors.Is(err, ErrNoResponse) {
+				return
+			} else if err != nil {
+				response = Message{
+					JSONRPC: msg.JSONRPC,
+					ID:      msg.ID,
+					Error:   ErrRPCInternal.WithMessage("%v", err),
+				}
+			}
+
+			data, err := json.Marshal(response)
+			if err != nil {
+				log.Errorf(ctx, "failed to marshal response: %v", err)
+				return
+			}
+			if err := writeWebSocket(ctx, conn, data); err != nil {
+				log.Errorf(ctx, "failed to write response: %v", err)
+			}
+		}()
+	}
+}
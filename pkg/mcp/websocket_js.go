@@ -0,0 +1,13 @@
+package mcp
+
+import (
+	"net/http"
+
+	"github.com/coder/websocket"
+)
+
+// webSocketDialOptions drops the headers in the browser, which does not let WebSocket connections
+// set them. Authentication has to be handled by the proxy the playground connects through.
+func webSocketDialOptions(http.Header) *websocket.DialOptions {
+	return nil
+}
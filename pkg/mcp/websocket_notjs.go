@@ -0,0 +1,15 @@
+//go:build !js
+
+package mcp
+
+import (
+	"net/http"
+
+	"github.com/coder/websocket"
+)
+
+func webSocketDialOptions(header http.Header) *websocket.DialOptions {
+	return &websocket.DialOptions{
+		HTTPHeader: header,
+	}
+}
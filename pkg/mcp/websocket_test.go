@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebSocketResumesSession(t *testing.T) {
+	handler := &ServerHandler{
+		Name: "example",
+		Tools: NewServerTools(
+			NewServerTool("whoami", "Returns the session ID", func(ctx context.Context, _ struct{}) (string, error) {
+				return SessionFromContext(ctx).ID(), nil
+			}),
+		),
+	}
+
+	server := httptest.NewServer(NewHTTPServer(nil, handler, HTTPServerOptions{BaseContext: t.Context()}))
+	defer server.Close()
+
+	wire := newWebSocketClient("example", Server{BaseURL: "ws" + strings.TrimPrefix(server.URL, "http")}, nil)
+	client, err := NewClient(t.Context(), "example", Server{}, ClientOption{Wire: wire})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(true)
+
+	first, err := client.Call(t.Context(), "whoami", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := first.Content[0].Text; wire.SessionID() == "" || !strings.Contains(id, wire.SessionID()) {
+		t.Fatalf("got session %s, want the session %q of the handshake", id, wire.SessionID())
+	}
+
+	// Drop the connection, the next call goes out once the client reconnected.
+	wire.lock.Lock()
+	_ = wire.conn.CloseNow()
+	wire.lock.Unlock()
+
+	second, err := client.Call(t.Context(), "whoami", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Content[0].Text != first.Content[0].Text {
+		t.Fatalf("got session %s after reconnecting, want %s", second.Content[0].Text, first.Content[0].Text)
+	}
+}
@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "nanobot"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	completionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "completion_duration_seconds",
+		Help:      "Latency of LLM completions.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"agent", "model", "status"})
+
+	completionTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "completion_tokens_total",
+		Help:      "Tokens consumed by LLM completions, by direction.",
+	}, []string{"agent", "model", "type"})
+
+	streamedTokensPerSecond = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "completion_output_tokens_per_second",
+		Help:      "Output tokens streamed per second of completion time.",
+		Buckets:   []float64{1, 5, 10, 20, 40, 60, 80, 100, 150, 200, 400},
+	}, []string{"agent", "model"})
+
+	providerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_errors_total",
+		Help:      "Errors returned by LLM providers, by HTTP status code.",
+	}, []string{"agent", "model", "code"})
+
+	toolCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_duration_seconds",
+		Help:      "Duration of tool calls.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2.5, 10),
+	}, []string{"server", "tool", "status"})
+
+	breakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "provider_circuit_breaker_state",
+		Help:      "State of the circuit breaker for each LLM provider, 1 for the current state.",
+	}, []string{"provider", "state"})
+
+	quotaHeadroom = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "provider_quota_headroom_ratio",
+		Help:      "Share of the rate limit of each LLM provider that is left, by limit (requests or tokens), as last reported by the provider.",
+	}, []string{"provider", "limit"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "completion_cache_lookups_total",
+		Help:      "Lookups of the completion cache, by result (hit or miss).",
+	}, []string{"agent", "model", "result"})
+
+	sessionStoreDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "session_store_duration_seconds",
+		Help:      "Duration of loading and saving sessions in the session store, including waiting for locks.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2.5, 10),
+	}, []string{"operation", "status"})
+
+	activeSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Number of MCP sessions currently open.",
+	})
+
+	liveTasks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "live_tasks",
+		Help:      "Number of tracked goroutines and channels currently running, by kind.",
+	}, []string{"kind"})
+
+	leakedTasks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "leaked_tasks_total",
+		Help:      "Tracked goroutines and channels still running a while after their session was closed, by kind.",
+	}, []string{"kind"})
+
+	progressBackpressure = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "progress_backpressure_events_total",
+		Help:      "Progress events that were not delivered on their own because a subscriber fell behind, by outcome (coalesced, dropped).",
+	}, []string{"outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		completionDuration,
+		completionTokens,
+		streamedTokensPerSecond,
+		providerErrors,
+		toolCallDuration,
+		breakerState,
+		quotaHeadroom,
+		cacheLookups,
+		sessionStoreDuration,
+		activeSessions,
+		liveTasks,
+		leakedTasks,
+		progressBackpressure,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// StatusCoder is implemented by errors that carry the HTTP status code returned by a provider.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// ObserveCompletion records a finished completion. inputTokens and outputTokens should be zero
+// when the provider did not report usage.
+func ObserveCompletion(agent, model string, duration time.Duration, inputTokens, outputTokens int, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+		providerErrors.WithLabelValues(agent, model, errorCode(err)).Inc()
+	}
+	completionDuration.WithLabelValues(agent, model, status).Observe(duration.Seconds())
+
+	if inputTokens > 0 {
+		completionTokens.WithLabelValues(agent, model, "input").Add(float64(inputTokens))
+	}
+	if outputTokens > 0 {
+		completionTokens.WithLabelValues(agent, model, "output").Add(float64(outputTokens))
+		if duration > 0 {
+			streamedTokensPerSecond.WithLabelValues(agent, model).Observe(float64(outputTokens) / duration.Seconds())
+		}
+	}
+}
+
+// ObserveToolCall records a finished tool call. isError should be set if the tool returned an error
+// result even though the call itself succeeded.
+func ObserveToolCall(server, tool string, duration time.Duration, isError bool, err error) {
+	status := "ok"
+	if err != nil || isError {
+		status = "error"
+	}
+	toolCallDuration.WithLabelValues(server, tool, status).Observe(duration.Seconds())
+}
+
+// SetBreakerState records the current circuit breaker state (closed, open, or half-open) of provider.
+func SetBreakerState(provider, state string) {
+	for _, s := range []string{"closed", "open", "half-open"} {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		breakerState.WithLabelValues(provider, s).Set(value)
+	}
+}
+
+// SetQuotaHeadroom records the share, between 0 and 1, of the requests or tokens limit of provider
+// that is left.
+func SetQuotaHeadroom(provider, limit string, ratio float64) {
+	quotaHeadroom.WithLabelValues(provider, limit).Set(ratio)
+}
+
+// ObserveCacheLookup records whether a completion was answered from the completion cache.
+func ObserveCacheLookup(agent, model string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(agent, model, result).Inc()
+}
+
+// ObserveSessionStore records a finished load or save of a session in the session store.
+func ObserveSessionStore(operation string, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	sessionStoreDuration.WithLabelValues(operation, status).Observe(duration.Seconds())
+}
+
+// SessionStarted increments the active session gauge and decrements it again once ctx is done.
+func SessionStarted(ctx context.Context) {
+	activeSessions.Inc()
+	context.AfterFunc(ctx, activeSessions.Dec)
+}
+
+// Tasks observes the tasks of the lifecycle package.
+type Tasks struct{}
+
+// TaskStarted increments the live task gauge of kind.
+func (Tasks) TaskStarted(kind string) {
+	liveTasks.WithLabelValues(kind).Inc()
+}
+
+// TaskStopped decrements the live task gauge of kind.
+func (Tasks) TaskStopped(kind string) {
+	liveTasks.WithLabelValues(kind).Dec()
+}
+
+// TaskLeaked counts a task of kind that did not stop after its session was closed.
+func (Tasks) TaskLeaked(kind string) {
+	leakedTasks.WithLabelValues(kind).Inc()
+}
+
+// ProgressCoalesced counts a progress event that was merged into the previous event of a subscriber.
+func ProgressCoalesced() {
+	progressBackpressure.WithLabelValues("coalesced").Inc()
+}
+
+// ProgressDropped counts a progress event that was dropped because a subscriber fell behind.
+func ProgressDropped() {
+	progressBackpressure.WithLabelValues("dropped").Inc()
+}
+
+func errorCode(err error) string {
+	var coder StatusCoder
+	switch {
+	case errors.As(err, &coder):
+		return strconv.Itoa(coder.StatusCode())
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
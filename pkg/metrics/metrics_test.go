@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type statusError int
+
+func (e statusError) Error() string   { return "status " + http.StatusText(int(e)) }
+func (e statusError) StatusCode() int { return int(e) }
+
+// scrape returns the metrics served by the Handler.
+func scrape(t *testing.T) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func expectSeries(t *testing.T, metrics string, series ...string) {
+	t.Helper()
+
+	for _, s := range series {
+		if !strings.Contains(metrics, s+"\n") {
+			t.Errorf("expected series %s", s)
+		}
+	}
+}
+
+func TestObserveCompletion(t *testing.T) {
+	ObserveCompletion("metrics-agent", "gpt-4.1", 2*time.Second, 100, 40, nil)
+	ObserveCompletion("metrics-agent", "gpt-4.1", time.Second, 0, 0, statusError(http.StatusTooManyRequests))
+	ObserveCompletion("metrics-agent", "gpt-4.1", time.Second, 0, 0, context.DeadlineExceeded)
+	ObserveCompletion("metrics-agent", "gpt-4.1", time.Second, 0, 0, errors.New("connection reset"))
+
+	expectSeries(t, scrape(t),
+		`nanobot_completion_duration_seconds_count{agent="metrics-agent",model="gpt-4.1",status="ok"} 1`,
+		`nanobot_completion_duration_seconds_count{agent="metrics-agent",model="gpt-4.1",status="error"} 3`,
+		`nanobot_completion_tokens_total{agent="metrics-agent",model="gpt-4.1",type="input"} 100`,
+		`nanobot_completion_tokens_total{agent="metrics-agent",model="gpt-4.1",type="output"} 40`,
+		`nanobot_completion_output_tokens_per_second_sum{agent="metrics-agent",model="gpt-4.1"} 20`,
+		`nanobot_provider_errors_total{agent="metrics-agent",code="429",model="gpt-4.1"} 1`,
+		`nanobot_provider_errors_total{agent="metrics-agent",code="timeout",model="gpt-4.1"} 1`,
+		`nanobot_provider_errors_total{agent="metrics-agent",code="unknown",model="gpt-4.1"} 1`,
+	)
+}
+
+func TestObserveToolCall(t *testing.T) {
+	ObserveToolCall("metrics-server", "search", 50*time.Millisecond, false, nil)
+	ObserveToolCall("metrics-server", "search", 50*time.Millisecond, true, nil)
+	ObserveToolCall("metrics-server", "search", 50*time.Millisecond, false, context.Canceled)
+
+	expectSeries(t, scrape(t),
+		`nanobot_tool_call_duration_seconds_count{server="metrics-server",status="ok",tool="search"} 1`,
+		`nanobot_tool_call_duration_seconds_count{server="metrics-server",status="error",tool="search"} 2`,
+	)
+}
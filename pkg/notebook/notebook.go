@@ -0,0 +1,272 @@
+package notebook
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const MimeType = "application/x-ipynb+json"
+
+// codeArgumentNames are the tool call argument names that are treated as the source of a code cell.
+// Any tool call with one of these string arguments is considered a code-interpreter call.
+var codeArgumentNames = []string{"code", "source", "script"}
+
+type Notebook struct {
+	Cells         []Cell         `json:"cells"`
+	Metadata      map[string]any `json:"metadata"`
+	NBFormat      int            `json:"nbformat"`
+	NBFormatMinor int            `json:"nbformat_minor"`
+}
+
+type Cell struct {
+	CellType       string         `json:"cell_type"`
+	ID             string         `json:"id,omitempty"`
+	Metadata       map[string]any `json:"metadata"`
+	Source         []string       `json:"source"`
+	ExecutionCount *int           `json:"execution_count,omitempty"`
+	Outputs        []Output       `json:"outputs,omitempty"`
+}
+
+type Output struct {
+	OutputType     string         `json:"output_type"`
+	Name           string         `json:"name,omitempty"`
+	Text           []string       `json:"text,omitempty"`
+	Data           map[string]any `json:"data,omitempty"`
+	Metadata       map[string]any `json:"metadata,omitempty"`
+	ExecutionCount *int           `json:"execution_count,omitempty"`
+	EName          string         `json:"ename,omitempty"`
+	EValue         string         `json:"evalue,omitempty"`
+	Traceback      []string       `json:"traceback,omitempty"`
+}
+
+// MarshalJSON makes sure code cells always carry the execution_count and outputs keys, which
+// nbformat requires even when they are null or empty.
+func (c Cell) MarshalJSON() ([]byte, error) {
+	type Alias Cell
+	if c.CellType != "code" {
+		return json.Marshal(Alias(c))
+	}
+	outputs := c.Outputs
+	if outputs == nil {
+		outputs = []Output{}
+	}
+	return json.Marshal(struct {
+		Alias
+		ExecutionCount *int     `json:"execution_count"`
+		Outputs        []Output `json:"outputs"`
+	}{
+		Alias:          Alias(c),
+		ExecutionCount: c.ExecutionCount,
+		Outputs:        outputs,
+	})
+}
+
+// FromMessages converts a consolidated chat history (see types.ConsolidateTools) into a notebook.
+// Text exchanged between the user and the agent becomes markdown cells and every code-interpreter
+// tool call becomes a code cell with its result attached as the cell outputs. Other tool calls
+// are omitted.
+func FromMessages(messages []types.Message) *Notebook {
+	var (
+		nb = &Notebook{
+			Metadata:      map[string]any{},
+			NBFormat:      4,
+			NBFormatMinor: 5,
+		}
+		language  string
+		execCount int
+	)
+
+	for _, msg := range messages {
+		var text []string
+		flush := func() {
+			if len(text) == 0 {
+				return
+			}
+			body := strings.Join(text, "\n\n")
+			if msg.Role == "user" {
+				body = "**User:** " + body
+			}
+			nb.Cells = append(nb.Cells, Cell{
+				CellType: "markdown",
+				ID:       cellID(len(nb.Cells)),
+				Metadata: map[string]any{},
+				Source:   lines(body),
+			})
+			text = nil
+		}
+
+		for _, item := range msg.Items {
+			switch {
+			case item.Content != nil && item.Content.Type == "text" && strings.TrimSpace(item.Content.Text) != "":
+				text = append(text, strings.TrimSpace(item.Content.Text))
+			case item.ToolCall != nil:
+				code, lang, ok := codeFromArguments(item.ToolCall.Arguments)
+				if !ok {
+					continue
+				}
+				flush()
+				if language == "" {
+					language = lang
+				}
+				execCount++
+				count := execCount
+				cell := Cell{
+					CellType:       "code",
+					ID:             cellID(len(nb.Cells)),
+					Metadata:       map[string]any{"tool": item.ToolCall.Name},
+					Source:         lines(code),
+					ExecutionCount: &count,
+				}
+				if item.ToolCallResult != nil {
+					cell.Outputs = outputs(item.ToolCallResult.Output, count)
+				}
+				nb.Cells = append(nb.Cells, cell)
+			}
+		}
+		flush()
+	}
+
+	if language == "" {
+		language = "python"
+	}
+	nb.Metadata["language_info"] = map[string]any{"name": language}
+	if language == "python" {
+		nb.Metadata["kernelspec"] = map[string]any{
+			"name":         "python3",
+			"display_name": "Python 3",
+			"language":     "python",
+		}
+	}
+
+	return nb
+}
+
+// Marshal converts the chat history to a notebook and encodes it as ipynb JSON.
+func Marshal(messages []types.Message) ([]byte, error) {
+	return json.MarshalIndent(FromMessages(messages), "", " ")
+}
+
+func codeFromArguments(args string) (code, language string, ok bool) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(args), &data); err != nil {
+		return "", "", false
+	}
+	for _, name := range codeArgumentNames {
+		if s, isString := data[name].(string); isString && s != "" {
+			code = s
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return "", "", false
+	}
+	if lang, _ := data["language"].(string); lang != "" {
+		language = strings.ToLower(lang)
+	} else {
+		language = "python"
+	}
+	return code, language, true
+}
+
+func outputs(result types.CallResult, execCount int) (ret []Output) {
+	if structured, ok := result.StructuredContent.(map[string]any); ok {
+		for _, name := range []string{"stdout", "stderr"} {
+			if s, _ := structured[name].(string); s != "" {
+				ret = append(ret, Output{
+					OutputType: "stream",
+					Name:       name,
+					Text:       lines(s),
+				})
+			}
+		}
+		if len(ret) > 0 && !result.IsError {
+			return ret
+		}
+	}
+
+	for _, content := range result.Content {
+		switch {
+		case content.Type == "text" && result.IsError:
+			ret = append(ret, errorOutput(content.Text))
+		case content.Type == "text":
+			ret = append(ret, Output{
+				OutputType: "stream",
+				Name:       "stdout",
+				Text:       lines(content.Text),
+			})
+		case content.Type == "image" && content.Data != "":
+			ret = append(ret, displayData(content.MIMEType, content.Data, execCount))
+		case content.Type == "resource" && content.Resource != nil:
+			ret = append(ret, resourceOutput(content.Resource, execCount))
+		}
+	}
+
+	return ret
+}
+
+func errorOutput(text string) Output {
+	ename, evalue, ok := strings.Cut(lastLine(text), ": ")
+	if !ok {
+		ename, evalue = "Error", lastLine(text)
+	}
+	return Output{
+		OutputType: "error",
+		EName:      ename,
+		EValue:     evalue,
+		Traceback:  strings.Split(strings.TrimRight(text, "\n"), "\n"),
+	}
+}
+
+func displayData(mimeType, data string, execCount int) Output {
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	return Output{
+		OutputType:     "execute_result",
+		Data:           map[string]any{mimeType: data},
+		Metadata:       map[string]any{},
+		ExecutionCount: &execCount,
+	}
+}
+
+func resourceOutput(resource *mcp.EmbeddedResource, execCount int) Output {
+	if resource.Blob != "" {
+		return displayData(resource.MIMEType, resource.Blob, execCount)
+	}
+	mimeType := resource.MIMEType
+	if mimeType == "" {
+		mimeType = "text/plain"
+	}
+	return Output{
+		OutputType:     "execute_result",
+		Data:           map[string]any{mimeType: lines(resource.Text)},
+		Metadata:       map[string]any{},
+		ExecutionCount: &execCount,
+	}
+}
+
+// lines splits text the way nbformat expects multi-line strings, keeping the trailing newline
+// on every line but the last.
+func lines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.SplitAfter(strings.TrimRight(s, "\n"), "\n")
+}
+
+func lastLine(s string) string {
+	s = strings.TrimRight(s, "\n")
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+func cellID(i int) string {
+	return "cell-" + strconv.Itoa(i)
+}
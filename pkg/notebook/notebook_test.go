@@ -0,0 +1,57 @@
+package notebook
+
+import (
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestFromMessages(t *testing.T) {
+	nb := FromMessages([]types.Message{
+		{
+			Role: "user",
+			Items: []types.CompletionItem{
+				{Content: &mcp.Content{Type: "text", Text: "Sum 1 and 2"}},
+			},
+		},
+		{
+			Role: "assistant",
+			Items: []types.CompletionItem{
+				{
+					ToolCall: &types.ToolCall{
+						CallID:    "1",
+						Name:      "run_python",
+						Arguments: `{"code":"x = 1 + 2\nprint(x)"}`,
+					},
+					ToolCallResult: &types.ToolCallResult{
+						CallID: "1",
+						Output: types.CallResult{Content: []mcp.Content{{Type: "text", Text: "3\n"}}},
+					},
+				},
+				{
+					ToolCall: &types.ToolCall{CallID: "2", Name: "search", Arguments: `{"query":"x"}`},
+				},
+				{Content: &mcp.Content{Type: "text", Text: "The answer is 3."}},
+			},
+		},
+	})
+
+	if len(nb.Cells) != 3 {
+		t.Fatalf("expected 3 cells, got %d", len(nb.Cells))
+	}
+	if nb.Cells[0].CellType != "markdown" || nb.Cells[2].CellType != "markdown" {
+		t.Fatalf("expected markdown cells around the code cell, got %s and %s", nb.Cells[0].CellType, nb.Cells[2].CellType)
+	}
+
+	code := nb.Cells[1]
+	if code.CellType != "code" || *code.ExecutionCount != 1 {
+		t.Fatalf("unexpected code cell: %+v", code)
+	}
+	if len(code.Source) != 2 || code.Source[0] != "x = 1 + 2\n" || code.Source[1] != "print(x)" {
+		t.Fatalf("unexpected source: %q", code.Source)
+	}
+	if len(code.Outputs) != 1 || code.Outputs[0].OutputType != "stream" || code.Outputs[0].Text[0] != "3" {
+		t.Fatalf("unexpected outputs: %+v", code.Outputs)
+	}
+}
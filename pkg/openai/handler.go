@@ -0,0 +1,420 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/completions"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+const (
+	ModelsPath          = "/v1/models"
+	ChatCompletionsPath = "/v1/chat/completions"
+
+	// maxRequestSize is the largest request accepted, large enough for a few inlined images.
+	maxRequestSize = 20 << 20
+)
+
+// Runtime runs the agents.
+type Runtime interface {
+	types.Completer
+	WithTempSession(ctx context.Context, config *types.Config) context.Context
+}
+
+// Handler serves the entrypoint agents of the config as models of the OpenAI Chat Completions API, so any
+// OpenAI SDK or UI can talk to them. The API is stateless like the one of OpenAI: every request
+// carries the whole conversation, which is sent to the agent, and the agent runs its own tools
+// until it answers. The system messages of the request are ignored, the agent keeps its own
+// instructions, and so are the tools of the request.
+type Handler struct {
+	runtime Runtime
+	config  types.ConfigFactory
+	mux     *http.ServeMux
+}
+
+func NewHandler(runtime Runtime, config types.ConfigFactory) *Handler {
+	h := &Handler{
+		runtime: runtime,
+		config:  config,
+		mux:     http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET "+ModelsPath, h.listModels)
+	h.mux.HandleFunc("GET "+ModelsPath+"/{model}", h.getModel)
+	h.mux.HandleFunc("POST "+ChatCompletionsPath, h.chatCompletions)
+	return h
+}
+
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	h.mux.ServeHTTP(rw, req.WithContext(mcp.WithRequest(req.Context(), req)))
+}
+
+type model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type modelList struct {
+	Object string  `json:"object"`
+	Data   []model `json:"data"`
+}
+
+func newModel(name string) model {
+	return model{
+		ID:      name,
+		Object:  "model",
+		OwnedBy: "nanobot",
+	}
+}
+
+// models returns the agents served as models, which are the entrypoints of the config that are
+// agents and that the client may call.
+func models(ctx context.Context, cfg types.Config) (result []string) {
+	nctx := types.NanobotContext(ctx)
+	for _, name := range cfg.Publish.Entrypoint {
+		if _, ok := cfg.Agents[name]; ok && nctx.AllowsAgent(name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func (h *Handler) listModels(rw http.ResponseWriter, req *http.Request) {
+	cfg, err := h.config(req.Context(), "")
+	if err != nil {
+		writeError(rw, http.StatusInternalServerError, "server_error", "", fmt.Sprintf("failed to read config: %v", err))
+		return
+	}
+
+	list := modelList{
+		Object: "list",
+		Data:   []model{},
+	}
+	for _, name := range models(req.Context(), cfg) {
+		list.Data = append(list.Data, newModel(name))
+	}
+	writeJSON(rw, http.StatusOK, list)
+}
+
+func (h *Handler) getModel(rw http.ResponseWriter, req *http.Request) {
+	cfg, err := h.config(req.Context(), "")
+	if err != nil {
+		writeError(rw, http.StatusInternalServerError, "server_error", "", fmt.Sprintf("failed to read config: %v", err))
+		return
+	}
+
+	name := req.PathValue("model")
+	if !slices.Contains(models(req.Context(), cfg), name) {
+		writeError(rw, http.StatusNotFound, "invalid_request_error", "model_not_found", fmt.Sprintf("the model %q does not exist", name))
+		return
+	}
+	writeJSON(rw, http.StatusOK, newModel(name))
+}
+
+func (h *Handler) chatCompletions(rw http.ResponseWriter, req *http.Request) {
+	var request completions.Request
+	if err := json.NewDecoder(http.MaxBytesReader(rw, req.Body, maxRequestSize)).Decode(&request); err != nil {
+		writeError(rw, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("failed to decode request: %v", err))
+		return
+	}
+
+	cfg, err := h.config(req.Context(), "")
+	if err != nil {
+		writeError(rw, http.StatusInternalServerError, "server_error", "", fmt.Sprintf("failed to read config: %v", err))
+		return
+	}
+	if !slices.Contains(models(req.Context(), cfg), request.Model) {
+		writeError(rw, http.StatusNotFound, "invalid_request_error", "model_not_found", fmt.Sprintf("the model %q does not exist", request.Model))
+		return
+	}
+
+	input, err := toInput(request.Messages)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+
+	completionRequest := types.CompletionRequest{
+		Model:       request.Model,
+		Input:       input,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+	}
+	if request.MaxCompletionTokens != nil {
+		completionRequest.MaxTokens = *request.MaxCompletionTokens
+	} else if request.MaxTokens != nil {
+		completionRequest.MaxTokens = *request.MaxTokens
+	}
+
+	var (
+		id      = "chatcmpl-" + uuid.String()
+		created = time.Now().Unix()
+		usage   completions.Usage
+		ctx     = h.runtime.WithTempSession(req.Context(), &cfg)
+	)
+	ctx = types.WithUsageListener(ctx, func(u types.Usage) {
+		addUsage(&usage, u)
+	})
+
+	if request.Stream {
+		s := newStream(rw, id, request.Model, created)
+		h.stream(ctx, s, completionRequest, &usage, request.StreamOptions != nil && request.StreamOptions.IncludeUsage)
+		return
+	}
+
+	resp, err := h.runtime.Complete(ctx, completionRequest, types.CompletionOptions{
+		Chat: new(bool),
+	})
+	if err != nil {
+		log.Errorf(ctx, "failed to complete chat completion with agent %s: %v", request.Model, err)
+		writeError(rw, errorStatus(err), "server_error", "", err.Error())
+		return
+	}
+
+	text := outputText(resp)
+	writeJSON(rw, http.StatusOK, completions.Response{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   request.Model,
+		Choices: []completions.Choice{
+			{
+				Message: &completions.Message{
+					Role: "assistant",
+					Content: completions.MessageContent{
+						Text: &text,
+					},
+				},
+				FinishReason: finishReason(resp),
+			},
+		},
+		Usage: &usage,
+	})
+}
+
+// stream runs the agent and sends its answer as server-sent events of chat.completion.chunk
+// objects while it is generated.
+func (h *Handler) stream(ctx context.Context, s *stream, req types.CompletionRequest, usage *completions.Usage, includeUsage bool) {
+	s.start()
+
+	progressToken := uuid.String()
+	resp, err := h.runtime.Complete(withProgress(ctx, s), req, types.CompletionOptions{
+		ProgressToken: progressToken,
+		Chat:          new(bool),
+	})
+	if err != nil {
+		log.Errorf(ctx, "failed to complete chat completion with agent %s: %v", req.Model, err)
+		s.fail(err)
+		return
+	}
+
+	s.finish(outputText(resp), finishReason(resp))
+	if includeUsage {
+		s.usage(usage)
+	}
+	s.done()
+}
+
+// toInput converts the messages of a request to the input of the agent. System and developer
+// messages, tool calls, and tool results are dropped, the agent has its own instructions and
+// tools.
+func toInput(messages []completions.Message) ([]types.Message, error) {
+	var (
+		input   []types.Message
+		hasUser bool
+	)
+	for i, msg := range messages {
+		switch msg.Role {
+		case "system", "developer", "tool":
+			continue
+		case "user":
+			hasUser = true
+		case "assistant":
+		default:
+			return nil, fmt.Errorf("invalid role %q of message %d", msg.Role, i)
+		}
+
+		items, err := toItems(msg.Content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content of message %d: %w", i, err)
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		now := time.Now()
+		input = append(input, types.Message{
+			ID:      uuid.String(),
+			Created: &now,
+			Role:    msg.Role,
+			Items:   items,
+		})
+	}
+
+	if !hasUser {
+		return nil, errors.New("messages must contain at least one user message")
+	}
+	return input, nil
+}
+
+func toItems(content completions.MessageContent) ([]types.CompletionItem, error) {
+	if content.Text != nil {
+		if *content.Text == "" {
+			return nil, nil
+		}
+		return []types.CompletionItem{textItem(*content.Text)}, nil
+	}
+
+	var items []types.CompletionItem
+	for _, part := range content.ContentParts {
+		switch part.Type {
+		case "text":
+			if part.Text != "" {
+				items = append(items, textItem(part.Text))
+			}
+		case "image_url":
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				return nil, errors.New("image_url part without URL")
+			}
+			image, err := imageContent(part.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, types.CompletionItem{
+				ID:      uuid.String(),
+				Content: image,
+			})
+		case "input_audio":
+			if part.InputAudio == nil || part.InputAudio.Data == "" {
+				return nil, errors.New("input_audio part without data")
+			}
+			items = append(items, types.CompletionItem{
+				ID: uuid.String(),
+				Content: &mcp.Content{
+					Type:     "audio",
+					Data:     part.InputAudio.Data,
+					MIMEType: "audio/" + part.InputAudio.Format,
+				},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported content part type %q", part.Type)
+		}
+	}
+	return items, nil
+}
+
+func textItem(text string) types.CompletionItem {
+	return types.CompletionItem{
+		ID: uuid.String(),
+		Content: &mcp.Content{
+			Type: "text",
+			Text: text,
+		},
+	}
+}
+
+// imageContent converts the URL of an image part, a data URL or a URL for the provider to fetch.
+func imageContent(url string) (*mcp.Content, error) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return nil, fmt.Errorf("invalid image URL %q, must be a data, http, or https URL", url)
+		}
+		return &mcp.Content{
+			Type: "image",
+			URI:  url,
+		}, nil
+	}
+
+	mimeType, data, ok := strings.Cut(rest, ",")
+	mimeType, isBase64 := strings.CutSuffix(mimeType, ";base64")
+	if !ok || !isBase64 || !strings.HasPrefix(mimeType, "image/") {
+		return nil, errors.New("invalid image data URL, must be a base64 encoded image")
+	}
+	return &mcp.Content{
+		Type:     "image",
+		Data:     data,
+		MIMEType: mimeType,
+	}, nil
+}
+
+// outputText returns the text of the answer of the agent.
+func outputText(resp *types.CompletionResponse) string {
+	var parts []string
+	for _, item := range resp.Output.Items {
+		if item.Content != nil && item.Content.Type == "text" && item.Content.Text != "" {
+			parts = append(parts, item.Content.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func finishReason(resp *types.CompletionResponse) *string {
+	reason := "stop"
+	switch resp.StopReason {
+	case "length", "max_tokens", "max_output_tokens":
+		reason = "length"
+	}
+	return &reason
+}
+
+func addUsage(usage *completions.Usage, u types.Usage) {
+	usage.PromptTokens += u.InputTokens
+	usage.CompletionTokens += u.OutputTokens
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	if u.CachedInputTokens > 0 {
+		if usage.PromptTokensDetails == nil {
+			usage.PromptTokensDetails = &completions.PromptTokensDetails{}
+		}
+		usage.PromptTokensDetails.CachedTokens += u.CachedInputTokens
+	}
+	if u.ReasoningTokens > 0 {
+		if usage.CompletionTokensDetails == nil {
+			usage.CompletionTokensDetails = &completions.CompletionTokensDetails{}
+		}
+		usage.CompletionTokensDetails.ReasoningTokens += u.ReasoningTokens
+	}
+}
+
+// errorStatus returns the status of the error of the provider, if it failed, or 500.
+func errorStatus(err error) int {
+	var statusErr interface{ StatusCode() int }
+	if errors.As(err, &statusErr) && statusErr.StatusCode() >= 400 {
+		return statusErr.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+func errorResponse(errType, code, message string) completions.ErrorResponse {
+	resp := completions.ErrorResponse{
+		Error: completions.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	}
+	if code != "" {
+		resp.Error.Code = &code
+	}
+	return resp
+}
+
+func writeError(rw http.ResponseWriter, status int, errType, code, message string) {
+	writeJSON(rw, status, errorResponse(errType, code, message))
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(v)
+}
@@ -0,0 +1,176 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/completions"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type fakeRuntime struct {
+	req types.CompletionRequest
+}
+
+func (f *fakeRuntime) WithTempSession(ctx context.Context, _ *types.Config) context.Context {
+	return ctx
+}
+
+func (f *fakeRuntime) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	f.req = req
+	for _, opt := range opts {
+		if opt.ProgressToken != nil {
+			for _, text := range []string{"Hel", "lo"} {
+				progress.Send(ctx, &types.CompletionProgress{
+					Role: "assistant",
+					Item: types.CompletionItem{ID: "i1", Partial: true, Content: &mcp.Content{Type: "text", Text: text}},
+				}, opt.ProgressToken)
+			}
+		}
+	}
+	types.RecordUsage(ctx, &types.Usage{InputTokens: 3, OutputTokens: 2})
+	return &types.CompletionResponse{
+		Output: types.Message{
+			Role:  "assistant",
+			Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: "Hello"}}},
+		},
+	}, nil
+}
+
+func newTestHandler() (*Handler, *fakeRuntime) {
+	runtime := &fakeRuntime{}
+	return NewHandler(runtime, func(context.Context, string) (types.Config, error) {
+		return types.Config{
+			Publish: types.Publish{Entrypoint: []string{"main", "support"}},
+			Agents:  map[string]types.Agent{"main": {}, "support": {}, "sub": {}},
+		}, nil
+	}), runtime
+}
+
+func TestChatCompletions(t *testing.T) {
+	h, runtime := newTestHandler()
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{
+		"model": "main",
+		"messages": [
+			{"role": "system", "content": "ignored"},
+			{"role": "user", "content": "Hi"},
+			{"role": "assistant", "content": "Hello, how can I help?"},
+			{"role": "user", "content": [{"type": "text", "text": "Describe"}, {"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}}]}
+		]
+	}`)))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rw.Code, rw.Body.String())
+	}
+	for _, want := range []string{`"object":"chat.completion"`, `"content":"Hello"`, `"finish_reason":"stop"`, `"total_tokens":5`} {
+		if !strings.Contains(rw.Body.String(), want) {
+			t.Errorf("expected %s in response %s", want, rw.Body.String())
+		}
+	}
+
+	input := runtime.req.Input
+	if len(input) != 3 || input[0].Role != "user" || input[1].Role != "assistant" || len(input[2].Items) != 2 {
+		t.Fatalf("unexpected input %+v", input)
+	}
+	if image := input[2].Items[1].Content; image.Type != "image" || image.MIMEType != "image/png" || image.Data != "AAAA" {
+		t.Errorf("unexpected image %+v", image)
+	}
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{"model": "other", "messages": [{"role": "user", "content": "Hi"}]}`)))
+	if rw.Code != http.StatusNotFound || !strings.Contains(rw.Body.String(), "model_not_found") {
+		t.Errorf("expected an unknown model to be rejected, got %d %s", rw.Code, rw.Body.String())
+	}
+}
+
+func TestChatCompletionsStream(t *testing.T) {
+	h, _ := newTestHandler()
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{
+		"model": "main",
+		"stream": true,
+		"stream_options": {"include_usage": true},
+		"messages": [{"role": "user", "content": "Hi"}]
+	}`)))
+
+	var deltas []string
+	for _, event := range strings.Split(strings.TrimSpace(rw.Body.String()), "\n\n") {
+		data := strings.TrimPrefix(event, "data: ")
+		switch {
+		case data == "[DONE]":
+			deltas = append(deltas, data)
+		case strings.Contains(data, `"usage"`):
+			deltas = append(deltas, "usage")
+		default:
+			var chunk completions.StreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				t.Fatalf("invalid event %q: %v", event, err)
+			}
+			delta := chunk.Choices[0].Delta
+			switch {
+			case delta.Role != "":
+				deltas = append(deltas, "role")
+			case delta.Content != nil:
+				deltas = append(deltas, *delta.Content)
+			case chunk.Choices[0].FinishReason != nil:
+				deltas = append(deltas, *chunk.Choices[0].FinishReason)
+			}
+		}
+	}
+
+	if got, want := strings.Join(deltas, ","), "role,Hel,lo,stop,usage,[DONE]"; got != want {
+		t.Errorf("got events %s, want %s", got, want)
+	}
+}
+
+func TestModels(t *testing.T) {
+	h, _ := newTestHandler()
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, ModelsPath, nil))
+	if want := `{"object":"list","data":[{"id":"main","object":"model","created":0,"owned_by":"nanobot"},{"id":"support","object":"model","created":0,"owned_by":"nanobot"}]}`; strings.TrimSpace(rw.Body.String()) != want {
+		t.Errorf("got models %s, want %s", rw.Body.String(), want)
+	}
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, ModelsPath+"/sub", nil))
+	if rw.Code != http.StatusNotFound {
+		t.Errorf("got status %d for a sub-agent, want 404", rw.Code)
+	}
+}
+
+func TestModelsRestricted(t *testing.T) {
+	h, _ := newTestHandler()
+	restricted := func(req *http.Request) *http.Request {
+		return req.WithContext(types.WithNanobotContext(req.Context(), types.Context{Agents: []string{"support"}}))
+	}
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, restricted(httptest.NewRequest(http.MethodGet, ModelsPath, nil)))
+	if want := `{"object":"list","data":[{"id":"support","object":"model","created":0,"owned_by":"nanobot"}]}`; strings.TrimSpace(rw.Body.String()) != want {
+		t.Errorf("got models %s, want %s", rw.Body.String(), want)
+	}
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, restricted(httptest.NewRequest(http.MethodGet, ModelsPath+"/main", nil)))
+	if rw.Code != http.StatusNotFound {
+		t.Errorf("got status %d for a model that is not allowed, want 404", rw.Code)
+	}
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, restricted(httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{
+		"model": "main",
+		"messages": [{"role": "user", "content": "Hi"}]
+	}`))))
+	if rw.Code != http.StatusNotFound {
+		t.Errorf("got status %d for a chat with a model that is not allowed, want 404", rw.Code)
+	}
+}
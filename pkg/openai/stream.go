@@ -0,0 +1,146 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/completions"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// stream writes the answer of the agent as server-sent events of chat.completion.chunk objects.
+type stream struct {
+	rw      http.ResponseWriter
+	flusher http.Flusher
+	id      string
+	model   string
+	created int64
+
+	lock     sync.Mutex
+	lastItem string
+	streamed bool
+}
+
+func newStream(rw http.ResponseWriter, id, model string, created int64) *stream {
+	flusher, _ := rw.(http.Flusher)
+	return &stream{
+		rw:      rw,
+		flusher: flusher,
+		id:      id,
+		model:   model,
+		created: created,
+	}
+}
+
+// withProgress returns a context that streams the text the agent generates.
+func withProgress(ctx context.Context, s *stream) context.Context {
+	return progress.WithListener(ctx, s.progress)
+}
+
+func (s *stream) start() {
+	s.rw.Header().Set("Content-Type", "text/event-stream")
+	s.rw.Header().Set("Cache-Control", "no-cache")
+	s.rw.Header().Set("Connection", "keep-alive")
+	s.rw.WriteHeader(http.StatusOK)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.chunk(completions.ChoiceDelta{Role: "assistant"}, nil)
+}
+
+func (s *stream) progress(p *types.CompletionProgress) {
+	item := p.Item
+	if p.Role == "user" || !item.Partial || item.Content == nil || item.Content.Type != "text" {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	text := item.Content.Text
+	if s.lastItem != "" && s.lastItem != item.ID {
+		text = "\n\n" + text
+	}
+	s.lastItem, s.streamed = item.ID, true
+	s.chunk(completions.ChoiceDelta{Content: &text}, nil)
+}
+
+// finish sends the answer if it was not streamed, because the provider does not stream, and ends
+// the choice.
+func (s *stream) finish(text string, finishReason *string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.streamed && text != "" {
+		s.chunk(completions.ChoiceDelta{Content: &text}, nil)
+	}
+	s.chunk(completions.ChoiceDelta{}, finishReason)
+}
+
+// usage sends the usage of the whole turn in a chunk without choices, as requested with
+// stream_options.include_usage.
+func (s *stream) usage(usage *completions.Usage) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.write(completions.StreamChunk{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []completions.Choice{},
+		Usage:   usage,
+	})
+}
+
+// fail sends the error, the status was sent with the first chunk already.
+func (s *stream) fail(err error) {
+	s.lock.Lock()
+	s.write(errorResponse("server_error", "", err.Error()))
+	s.lock.Unlock()
+	s.done()
+}
+
+func (s *stream) done() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, _ = fmt.Fprint(s.rw, "data: [DONE]\n\n")
+	s.flush()
+}
+
+// chunk sends a delta of the choice. The caller must hold the lock.
+func (s *stream) chunk(delta completions.ChoiceDelta, finishReason *string) {
+	s.write(completions.StreamChunk{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []completions.Choice{
+			{
+				Delta:        &delta,
+				FinishReason: finishReason,
+			},
+		},
+	})
+}
+
+// write sends an event. The caller must hold the lock.
+func (s *stream) write(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(s.rw, "data: %s\n\n", data)
+	s.flush()
+}
+
+func (s *stream) flush() {
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
@@ -0,0 +1,183 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type blockKind int
+
+const (
+	paragraph blockKind = iota
+	heading
+	code
+	table
+	list
+	rule
+)
+
+// block is a top level markdown element. Only the elements that chat channels render differently
+// are recognized, everything else is kept as paragraph text.
+type block struct {
+	kind     blockKind
+	text     string
+	language string
+	rows     [][]string
+}
+
+var (
+	headingLine   = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	listLine      = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+	ruleLine      = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	tableSepLine  = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	bulletMarker  = regexp.MustCompile(`(?m)^(\s*)[-*+]\s+`)
+	boldText      = regexp.MustCompile(`\*\*([^*\n]+)\*\*|__([^_\n]+)__`)
+	italicText    = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*\n]*)\*`)
+	strikeText    = regexp.MustCompile(`~~([^~\n]+)~~`)
+	markdownLink  = regexp.MustCompile(`\[([^\]\n]+)\]\(([^)\s]+)\)`)
+	inlineCodeTxt = regexp.MustCompile("`[^`\n]+`")
+)
+
+func parse(markdown string) (blocks []block) {
+	var (
+		lines   = strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+		current []string
+		kind    = paragraph
+	)
+
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(current, "\n")); text != "" {
+			blocks = append(blocks, block{kind: kind, text: text})
+		}
+		current, kind = nil, paragraph
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			language := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var body []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				body = append(body, lines[i])
+			}
+			blocks = append(blocks, block{kind: code, text: strings.Join(body, "\n"), language: language})
+		case i+1 < len(lines) && strings.Contains(line, "|") && tableSepLine.MatchString(lines[i+1]):
+			flush()
+			rows := [][]string{tableCells(line)}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|"); i++ {
+				rows = append(rows, tableCells(lines[i]))
+			}
+			i--
+			blocks = append(blocks, block{kind: table, rows: rows})
+		case headingLine.MatchString(trimmed):
+			flush()
+			blocks = append(blocks, block{kind: heading, text: headingLine.FindStringSubmatch(trimmed)[1]})
+		case ruleLine.MatchString(trimmed):
+			flush()
+			blocks = append(blocks, block{kind: rule})
+		case trimmed == "":
+			flush()
+		case listLine.MatchString(line):
+			if kind != list {
+				flush()
+				kind = list
+			}
+			current = append(current, line)
+		default:
+			if kind == list && !strings.HasPrefix(line, " ") {
+				flush()
+			}
+			current = append(current, line)
+		}
+	}
+	flush()
+	return blocks
+}
+
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// tableText lays out a table as aligned plain text, to be shown in a code block on channels that
+// can not render tables.
+func tableText(rows [][]string) string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	var buf strings.Builder
+	for r, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				buf.WriteString(" | ")
+			}
+			buf.WriteString(cell)
+			if i < len(row)-1 {
+				buf.WriteString(strings.Repeat(" ", widths[i]-len([]rune(cell))))
+			}
+		}
+		buf.WriteString("\n")
+		if r == 0 {
+			for i, width := range widths {
+				if i > 0 {
+					buf.WriteString("-+-")
+				}
+				buf.WriteString(strings.Repeat("-", width))
+			}
+			buf.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// chunks splits text into pieces of at most limit bytes, preferring to split at line breaks.
+func chunks(text string, limit int) (result []string) {
+	for len(text) > limit {
+		i := strings.LastIndex(text[:limit], "\n")
+		if i <= 0 {
+			for i = limit; i > 0 && !utf8.RuneStart(text[i]); i-- {
+			}
+		}
+		result = append(result, text[:i])
+		text = strings.TrimPrefix(text[i:], "\n")
+	}
+	return append(result, text)
+}
+
+// pack joins the parts of a message into as few messages of at most limit bytes as possible. Parts
+// must not be longer than limit.
+func pack(parts []string, limit int) (result []string) {
+	var current string
+	for _, part := range parts {
+		if current != "" && len(current)+2+len(part) > limit {
+			result = append(result, current)
+			current = ""
+		}
+		if current != "" {
+			current += "\n\n"
+		}
+		current += part
+	}
+	if current != "" {
+		result = append(result, current)
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = "# Summary\n\nThe **build** failed, see [logs](https://example.com/logs) and *retry*.\n\n" +
+	"- first\n- second\n\n" +
+	"| Tool | Failures |\n|---|---|\n| search | 3 |\n| fetch | 12 |\n\n" +
+	"```go\nfmt.Println(\"a < b\")\n```\n\n---\n\nDone."
+
+func TestSlack(t *testing.T) {
+	msg := Slack(sample, Action{ID: "approve", Text: "Approve", Value: "call-1", Style: "primary"})
+
+	want := []struct {
+		typ, text string
+	}{
+		{"header", "Summary"},
+		{"section", "The *build* failed, see <https://example.com/logs|logs> and _retry_."},
+		{"section", "• first\n• second"},
+		{"section", "```Tool   | Failures\n-------+---------\nsearch | 3\nfetch  | 12```"},
+		{"section", "```fmt.Println(\"a &lt; b\")```"},
+		{"divider", ""},
+		{"section", "Done."},
+		{"actions", ""},
+	}
+	if len(msg.Blocks) != len(want) {
+		t.Fatalf("got %d blocks, want %d: %+v", len(msg.Blocks), len(want), msg.Blocks)
+	}
+	for i, w := range want {
+		block := msg.Blocks[i]
+		if block.Type != w.typ {
+			t.Errorf("block %d: got type %s, want %s", i, block.Type, w.typ)
+		}
+		if w.text != "" && (block.Text == nil || block.Text.Text != w.text) {
+			t.Errorf("block %d: got text %+v, want %q", i, block.Text, w.text)
+		}
+	}
+	if button := msg.Blocks[len(msg.Blocks)-1].Elements[0]; button.ActionID != "approve" || button.Value != "call-1" {
+		t.Errorf("unexpected button %+v", button)
+	}
+}
+
+func TestTeams(t *testing.T) {
+	card := Teams(sample, Action{ID: "reject", Text: "Reject", Value: "call-1", Style: "danger"})
+
+	if len(card.Body) != 6 {
+		t.Fatalf("got %d elements, want 6: %+v", len(card.Body), card.Body)
+	}
+	if card.Body[0].Weight != "Bolder" || card.Body[0].Text != "Summary" {
+		t.Errorf("unexpected heading %+v", card.Body[0])
+	}
+	if card.Body[3].FontType != "Monospace" || card.Body[4].FontType != "Monospace" {
+		t.Errorf("expected table and code to be monospace: %+v", card.Body[3:5])
+	}
+	if !card.Body[5].Separator {
+		t.Errorf("expected separator before %+v", card.Body[5])
+	}
+	if len(card.Actions) != 1 || card.Actions[0].Style != "destructive" || card.Actions[0].Data["actionId"] != "reject" {
+		t.Errorf("unexpected actions %+v", card.Actions)
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	msgs := Telegram(sample)
+	want := "<b>Summary</b>\n\n" +
+		"The <b>build</b> failed, see <a href=\"https://example.com/logs\">logs</a> and <i>retry</i>.\n\n" +
+		"• first\n• second\n\n" +
+		"<pre>Tool   | Failures\n-------+---------\nsearch | 3\nfetch  | 12</pre>\n\n" +
+		"<pre><code class=\"language-go\">fmt.Println(&quot;a &lt; b&quot;)</code></pre>\n\n" +
+		"──────────\n\nDone."
+	if len(msgs) != 1 || msgs[0] != want {
+		t.Errorf("got %q, want %q", msgs, want)
+	}
+
+	long := Telegram(strings.Repeat("word ", 2000))
+	if len(long) < 3 {
+		t.Errorf("expected long text to be split, got %d messages", len(long))
+	}
+	for _, msg := range long {
+		if len(msg) > telegramMessageLimit {
+			t.Errorf("got message of %d bytes, more than the limit", len(msg))
+		}
+	}
+}
+
+func TestWhatsApp(t *testing.T) {
+	msgs := WhatsApp(sample)
+	want := "*Summary*\n\n" +
+		"The *build* failed, see logs (https://example.com/logs) and _retry_.\n\n" +
+		"• first\n• second\n\n" +
+		"```\nTool   | Failures\n-------+---------\nsearch | 3\nfetch  | 12```\n\n" +
+		"```\nfmt.Println(\"a < b\")```\n\n" +
+		"──────────\n\nDone."
+	if len(msgs) != 1 || msgs[0] != want {
+		t.Errorf("got %q, want %q", msgs, want)
+	}
+}
+
+func TestChunks(t *testing.T) {
+	for _, chunk := range chunks("ééééé", 3) {
+		if chunk != "é" {
+			t.Errorf("got chunk %q, want whole runes", chunk)
+		}
+	}
+}
+
+func TestSpeech(t *testing.T) {
+	want := "Summary\n" +
+		"The build failed, see logs and retry.\n" +
+		"first\nsecond\n" +
+		"Tool, Failures.\nsearch, 3.\nfetch, 12.\n" +
+		"I have left out a code sample that is best read on a screen.\n" +
+		"Done."
+	if got := Speech(sample); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package render
+
+import (
+	"strings"
+)
+
+const (
+	slackSectionLimit = 3000
+	slackHeaderLimit  = 150
+)
+
+// Action is a button shown below a rendered message, for example to approve or reject a tool call.
+type Action struct {
+	ID    string
+	Text  string
+	Value string
+	// Style is either empty, "primary", or "danger".
+	Style string
+}
+
+// SlackMessage is the payload of a Slack message using Block Kit. Text is the fallback for
+// notifications and clients that can not show blocks.
+type SlackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []SlackBlock `json:"blocks,omitempty"`
+}
+
+type SlackBlock struct {
+	Type     string         `json:"type"`
+	Text     *SlackText     `json:"text,omitempty"`
+	Elements []SlackElement `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type SlackElement struct {
+	Type     string     `json:"type"`
+	Text     *SlackText `json:"text,omitempty"`
+	ActionID string     `json:"action_id,omitempty"`
+	Value    string     `json:"value,omitempty"`
+	Style    string     `json:"style,omitempty"`
+}
+
+// Slack converts markdown into a Block Kit message. Headings become header blocks, tables are
+// shown as aligned text in a code block since Slack can not render them, and actions become
+// buttons.
+func Slack(markdown string, actions ...Action) SlackMessage {
+	msg := SlackMessage{
+		Text: slackText(markdown),
+	}
+
+	section := func(text string) {
+		for _, chunk := range chunks(text, slackSectionLimit) {
+			msg.Blocks = append(msg.Blocks, SlackBlock{
+				Type: "section",
+				Text: &SlackText{Type: "mrkdwn", Text: chunk},
+			})
+		}
+	}
+
+	for _, b := range parse(markdown) {
+		switch b.kind {
+		case heading:
+			msg.Blocks = append(msg.Blocks, SlackBlock{
+				Type: "header",
+				Text: &SlackText{Type: "plain_text", Text: chunks(b.text, slackHeaderLimit)[0]},
+			})
+		case code:
+			for _, chunk := range chunks(escapeSlack(b.text), slackSectionLimit-8) {
+				section("```" + chunk + "```")
+			}
+		case table:
+			for _, chunk := range chunks(escapeSlack(tableText(b.rows)), slackSectionLimit-8) {
+				section("```" + chunk + "```")
+			}
+		case rule:
+			msg.Blocks = append(msg.Blocks, SlackBlock{Type: "divider"})
+		default:
+			section(slackText(b.text))
+		}
+	}
+
+	if len(actions) > 0 {
+		block := SlackBlock{Type: "actions"}
+		for _, action := range actions {
+			block.Elements = append(block.Elements, SlackElement{
+				Type:     "button",
+				Text:     &SlackText{Type: "plain_text", Text: action.Text},
+				ActionID: action.ID,
+				Value:    action.Value,
+				Style:    action.Style,
+			})
+		}
+		msg.Blocks = append(msg.Blocks, block)
+	}
+
+	return msg
+}
+
+// slackText converts inline markdown to Slack mrkdwn. Inline code is left untouched.
+func slackText(markdown string) string {
+	var (
+		buf  strings.Builder
+		last int
+	)
+	for _, span := range inlineCodeTxt.FindAllStringIndex(markdown, -1) {
+		buf.WriteString(slackInline(markdown[last:span[0]]))
+		buf.WriteString(escapeSlack(markdown[span[0]:span[1]]))
+		last = span[1]
+	}
+	buf.WriteString(slackInline(markdown[last:]))
+	return buf.String()
+}
+
+func slackInline(text string) string {
+	text = escapeSlack(text)
+	text = bulletMarker.ReplaceAllString(text, "$1• ")
+	text = italicText.ReplaceAllString(text, "${1}_${2}_")
+	text = boldText.ReplaceAllString(text, "*$1$2*")
+	text = strikeText.ReplaceAllString(text, "~$1~")
+	text = markdownLink.ReplaceAllString(text, "<$2|$1>")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if m := headingLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			lines[i] = "*" + m[1] + "*"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func escapeSlack(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
@@ -0,0 +1,46 @@
+package render
+
+import (
+	"strings"
+)
+
+// Speech converts markdown into plain text to be read out by a text-to-speech engine. Formatting is
+// removed, links are read by their title, and tables are read row by row. Code blocks can not be
+// read out meaningfully and are left out.
+func Speech(markdown string) string {
+	var parts []string
+	for _, b := range parse(markdown) {
+		switch b.kind {
+		case code:
+			parts = append(parts, "I have left out a code sample that is best read on a screen.")
+		case table:
+			for _, row := range b.rows {
+				parts = append(parts, strings.Join(row, ", ")+".")
+			}
+		case rule:
+		default:
+			parts = append(parts, speechText(b.text))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func speechText(markdown string) string {
+	text := inlineCodeTxt.ReplaceAllStringFunc(markdown, func(code string) string {
+		return strings.Trim(code, "`")
+	})
+	text = bulletMarker.ReplaceAllString(text, "$1")
+	text = italicText.ReplaceAllString(text, "$1$2")
+	text = boldText.ReplaceAllString(text, "$1$2")
+	text = strikeText.ReplaceAllString(text, "$1")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if m := headingLine.FindStringSubmatch(line); m != nil {
+			line = m[1]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"strings"
+)
+
+// AdaptiveCard is a Microsoft Teams Adaptive Card. TextBlocks only support a small subset of
+// markdown, so code and tables are rendered as monospace text.
+type AdaptiveCard struct {
+	Schema  string        `json:"$schema"`
+	Type    string        `json:"type"`
+	Version string        `json:"version"`
+	Body    []CardElement `json:"body"`
+	Actions []CardAction  `json:"actions,omitempty"`
+}
+
+type CardElement struct {
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	Wrap      bool   `json:"wrap,omitempty"`
+	Size      string `json:"size,omitempty"`
+	Weight    string `json:"weight,omitempty"`
+	FontType  string `json:"fontType,omitempty"`
+	Separator bool   `json:"separator,omitempty"`
+}
+
+type CardAction struct {
+	Type  string            `json:"type"`
+	Title string            `json:"title"`
+	Style string            `json:"style,omitempty"`
+	Data  map[string]string `json:"data,omitempty"`
+}
+
+// Teams converts markdown into an Adaptive Card. Actions become submit buttons whose data holds
+// the action ID and value.
+func Teams(markdown string, actions ...Action) AdaptiveCard {
+	card := AdaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+	}
+
+	var separator bool
+	for _, b := range parse(markdown) {
+		element := CardElement{
+			Type:      "TextBlock",
+			Wrap:      true,
+			Separator: separator,
+		}
+		separator = false
+
+		switch b.kind {
+		case heading:
+			element.Text = b.text
+			element.Size = "Large"
+			element.Weight = "Bolder"
+		case code:
+			element.Text = b.text
+			element.FontType = "Monospace"
+		case table:
+			element.Text = tableText(b.rows)
+			element.FontType = "Monospace"
+		case rule:
+			separator = true
+			continue
+		default:
+			element.Text = teamsText(b.text)
+		}
+		card.Body = append(card.Body, element)
+	}
+
+	for _, action := range actions {
+		card.Actions = append(card.Actions, CardAction{
+			Type:  "Action.Submit",
+			Title: action.Text,
+			Style: teamsStyle(action.Style),
+			Data: map[string]string{
+				"actionId": action.ID,
+				"value":    action.Value,
+			},
+		})
+	}
+
+	return card
+}
+
+// TeamsMessage wraps a card in the message payload accepted by Teams incoming webhooks and bots.
+func TeamsMessage(card AdaptiveCard) map[string]any {
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content":     card,
+			},
+		},
+	}
+}
+
+// teamsText converts inline markdown to the subset supported by TextBlocks, which has no
+// strikethrough and uses underscores for italics.
+func teamsText(markdown string) string {
+	markdown = italicText.ReplaceAllString(markdown, "${1}_${2}_")
+	markdown = strikeText.ReplaceAllString(markdown, "$1")
+	return strings.ReplaceAll(markdown, "`", "")
+}
+
+func teamsStyle(style string) string {
+	switch style {
+	case "primary":
+		return "positive"
+	case "danger":
+		return "destructive"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,74 @@
+package render
+
+import (
+	"strings"
+)
+
+const telegramMessageLimit = 4096
+
+// Telegram converts markdown into messages for the Telegram Bot API, to be sent with the HTML parse
+// mode. Long responses are split into several messages, tables are shown as preformatted text.
+func Telegram(markdown string) []string {
+	var parts []string
+	for _, b := range parse(markdown) {
+		switch b.kind {
+		case heading:
+			parts = append(parts, "<b>"+escapeHTML(b.text)+"</b>")
+		case code:
+			open, closing := "<pre>", "</pre>"
+			if b.language != "" {
+				open, closing = `<pre><code class="language-`+escapeHTML(b.language)+`">`, "</code></pre>"
+			}
+			for _, chunk := range chunks(escapeHTML(b.text), telegramMessageLimit-len(open)-len(closing)) {
+				parts = append(parts, open+chunk+closing)
+			}
+		case table:
+			for _, chunk := range chunks(escapeHTML(tableText(b.rows)), telegramMessageLimit-11) {
+				parts = append(parts, "<pre>"+chunk+"</pre>")
+			}
+		case rule:
+			parts = append(parts, "──────────")
+		default:
+			// Split before converting so that no tag spans two messages.
+			for _, chunk := range chunks(b.text, telegramMessageLimit/2) {
+				parts = append(parts, telegramText(chunk))
+			}
+		}
+	}
+	return pack(parts, telegramMessageLimit)
+}
+
+// telegramText converts inline markdown to Telegram HTML.
+func telegramText(markdown string) string {
+	var (
+		buf  strings.Builder
+		last int
+	)
+	for _, span := range inlineCodeTxt.FindAllStringIndex(markdown, -1) {
+		buf.WriteString(telegramInline(markdown[last:span[0]]))
+		buf.WriteString("<code>" + escapeHTML(strings.Trim(markdown[span[0]:span[1]], "`")) + "</code>")
+		last = span[1]
+	}
+	buf.WriteString(telegramInline(markdown[last:]))
+	return buf.String()
+}
+
+func telegramInline(text string) string {
+	text = escapeHTML(text)
+	text = bulletMarker.ReplaceAllString(text, "$1• ")
+	text = italicText.ReplaceAllString(text, "${1}<i>${2}</i>")
+	text = boldText.ReplaceAllString(text, "<b>$1$2</b>")
+	text = strikeText.ReplaceAllString(text, "<s>$1</s>")
+	text = markdownLink.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if m := headingLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			lines[i] = "<b>" + m[1] + "</b>"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func escapeHTML(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(text)
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"strings"
+)
+
+const whatsAppMessageLimit = 4096
+
+// WhatsApp converts markdown into text messages for WhatsApp, which supports bold, italic,
+// strikethrough, and monospace text but no headings, links with titles, or tables. Long responses
+// are split into several messages.
+func WhatsApp(markdown string) []string {
+	var parts []string
+	for _, b := range parse(markdown) {
+		switch b.kind {
+		case heading:
+			parts = append(parts, "*"+b.text+"*")
+		case code:
+			for _, chunk := range chunks(b.text, whatsAppMessageLimit-8) {
+				parts = append(parts, "```\n"+chunk+"```")
+			}
+		case table:
+			for _, chunk := range chunks(tableText(b.rows), whatsAppMessageLimit-8) {
+				parts = append(parts, "```\n"+chunk+"```")
+			}
+		case rule:
+			parts = append(parts, "──────────")
+		default:
+			for _, chunk := range chunks(b.text, whatsAppMessageLimit) {
+				parts = append(parts, whatsAppText(chunk))
+			}
+		}
+	}
+	return pack(parts, whatsAppMessageLimit)
+}
+
+// whatsAppText converts inline markdown to WhatsApp formatting. Inline code is left untouched.
+func whatsAppText(markdown string) string {
+	var (
+		buf  strings.Builder
+		last int
+	)
+	for _, span := range inlineCodeTxt.FindAllStringIndex(markdown, -1) {
+		buf.WriteString(whatsAppInline(markdown[last:span[0]]))
+		buf.WriteString(markdown[span[0]:span[1]])
+		last = span[1]
+	}
+	buf.WriteString(whatsAppInline(markdown[last:]))
+	return buf.String()
+}
+
+func whatsAppInline(text string) string {
+	text = bulletMarker.ReplaceAllString(text, "$1• ")
+	text = italicText.ReplaceAllString(text, "${1}_${2}_")
+	text = boldText.ReplaceAllString(text, "*$1$2*")
+	text = strikeText.ReplaceAllString(text, "~$1~")
+	text = markdownLink.ReplaceAllString(text, "$1 ($2)")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if m := headingLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			lines[i] = "*" + m[1] + "*"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,108 @@
+package rerun
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Turn is a single completion made during an execution: the request exactly as it was sent to the
+// provider and the assistant message it returned.
+type Turn struct {
+	Index   int                     `json:"index"`
+	Request types.CompletionRequest `json:"request"`
+	Output  types.Message           `json:"output"`
+}
+
+// Turns splits a stored execution into the completions that produced it. Every assistant message
+// in the history is the output of one turn whose input is everything before it.
+func Turns(execution types.Execution) []Turn {
+	if execution.PopulatedRequest == nil {
+		return nil
+	}
+
+	var (
+		turns []Turn
+		input = execution.PopulatedRequest.Input
+	)
+	for i, msg := range input {
+		if msg.Role != "assistant" {
+			continue
+		}
+		turns = append(turns, newTurn(len(turns), *execution.PopulatedRequest, input[:i], msg))
+	}
+	if execution.Response != nil {
+		turns = append(turns, newTurn(len(turns), *execution.PopulatedRequest, input, execution.Response.Output))
+	}
+	return turns
+}
+
+func newTurn(index int, req types.CompletionRequest, input []types.Message, output types.Message) Turn {
+	req.Input = append([]types.Message(nil), input...)
+	return Turn{
+		Index:   index,
+		Request: req,
+		Output:  output,
+	}
+}
+
+// Find returns turn i, where a negative i counts back from the last turn.
+func Find(turns []Turn, i int) (Turn, error) {
+	if i < 0 {
+		i += len(turns)
+	}
+	if i < 0 || i >= len(turns) {
+		return Turn{}, fmt.Errorf("turn %d not found, execution has %d turns", i, len(turns))
+	}
+	return turns[i], nil
+}
+
+// Digest returns the SHA-256 of the request of a turn as JSON, so a reconstructed request can be
+// compared with one that was recorded elsewhere, such as in the logs of the provider.
+func Digest(req types.CompletionRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Text renders the content of an assistant message as plain text, one item per paragraph, so
+// that two outputs can be compared.
+func Text(msg types.Message) string {
+	var parts []string
+	for _, item := range msg.Items {
+		switch {
+		case item.Content != nil && item.Content.Type == "text":
+			parts = append(parts, item.Content.Text)
+		case item.Content != nil:
+			parts = append(parts, fmt.Sprintf("[%s content]", item.Content.Type))
+		case item.ToolCall != nil:
+			parts = append(parts, fmt.Sprintf("tool call %s(%s)", item.ToolCall.Name, item.ToolCall.Arguments))
+		case item.Reasoning != nil:
+			for _, summary := range item.Reasoning.Summary {
+				parts = append(parts, "reasoning: "+summary.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// Diff returns a unified diff of the text of the original and new outputs of a turn, or an empty
+// string if they are the same.
+func Diff(original, rerun types.Message) string {
+	before, after := Text(original), Text(rerun)
+	if before == after {
+		return ""
+	}
+	edits := myers.ComputeEdits(span.URIFromPath("original"), before, after)
+	return fmt.Sprint(gotextdiff.ToUnified("original", "rerun", before, edits))
+}
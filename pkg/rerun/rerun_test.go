@@ -0,0 +1,81 @@
+package rerun
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func text(role, s string) types.Message {
+	return types.Message{
+		Role: role,
+		Items: []types.CompletionItem{
+			{Content: &mcp.Content{Type: "text", Text: s}},
+		},
+	}
+}
+
+func TestTurns(t *testing.T) {
+	execution := types.Execution{
+		PopulatedRequest: &types.CompletionRequest{
+			SystemPrompt: "be nice",
+			Input: []types.Message{
+				text("user", "hi"),
+				text("assistant", "hello"),
+				text("user", "bye"),
+			},
+		},
+		Response: &types.CompletionResponse{
+			Output: text("assistant", "goodbye"),
+		},
+	}
+
+	turns := Turns(execution)
+	if len(turns) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(turns))
+	}
+	if len(turns[0].Request.Input) != 1 || Text(turns[0].Output) != "hello\n" {
+		t.Errorf("unexpected first turn: %+v", turns[0])
+	}
+	if len(turns[1].Request.Input) != 3 || turns[1].Request.SystemPrompt != "be nice" {
+		t.Errorf("unexpected last turn: %+v", turns[1])
+	}
+
+	last, err := Find(turns, -1)
+	if err != nil || last.Index != 1 {
+		t.Errorf("expected last turn, got %d: %v", last.Index, err)
+	}
+	if _, err := Find(turns, 2); err == nil {
+		t.Error("expected error for missing turn")
+	}
+
+	// Stored executions have the IDs of their items, which are generated when they are saved.
+	data, err := json.Marshal(execution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored types.Execution
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := Digest(Turns(stored)[0].Request)
+	again, _ := Digest(Turns(stored)[0].Request)
+	second, _ := Digest(Turns(stored)[1].Request)
+	if first == "" || first != again || first == second {
+		t.Errorf("expected a stable digest per turn, got %q, %q, and %q", first, again, second)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if diff := Diff(text("assistant", "same"), text("assistant", "same")); diff != "" {
+		t.Errorf("expected no diff, got %q", diff)
+	}
+
+	diff := Diff(text("assistant", "goodbye"), text("assistant", "see you"))
+	if !strings.Contains(diff, "-goodbye") || !strings.Contains(diff, "+see you") {
+		t.Errorf("unexpected diff: %q", diff)
+	}
+}
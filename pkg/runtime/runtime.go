@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/nanobot-ai/nanobot/pkg/agents"
+	"github.com/nanobot-ai/nanobot/pkg/chaos"
 	"github.com/nanobot-ai/nanobot/pkg/complete"
 	"github.com/nanobot-ai/nanobot/pkg/llm"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
@@ -16,7 +17,10 @@ import (
 	"github.com/nanobot-ai/nanobot/pkg/servers/agent"
 	"github.com/nanobot-ai/nanobot/pkg/servers/agentui"
 	"github.com/nanobot-ai/nanobot/pkg/servers/meta"
+	"github.com/nanobot-ai/nanobot/pkg/servers/office"
+	"github.com/nanobot-ai/nanobot/pkg/servers/outputs"
 	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/servers/tickets"
 	"github.com/nanobot-ai/nanobot/pkg/session"
 	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
 	"github.com/nanobot-ai/nanobot/pkg/tools"
@@ -25,6 +29,7 @@ import (
 
 type Runtime struct {
 	*tools.Service
+	agents    *agents.Agents
 	llmConfig llm.Config
 	opt       Options
 }
@@ -37,6 +42,8 @@ type Options struct {
 	TokenStorage     mcp.TokenStorage
 	OAuthRedirectURL string
 	DSN              string
+	SafeMode         bool
+	Chaos            chaos.Config
 }
 
 func (o Options) Merge(other Options) (result Options) {
@@ -47,6 +54,8 @@ func (o Options) Merge(other Options) (result Options) {
 	result.OAuthRedirectURL = complete.Last(o.OAuthRedirectURL, other.OAuthRedirectURL)
 	result.TokenStorage = complete.Last(o.TokenStorage, other.TokenStorage)
 	result.DSN = complete.Last(o.DSN, other.DSN)
+	result.SafeMode = o.SafeMode || other.SafeMode
+	result.Chaos = complete.Merge(o.Chaos, other.Chaos)
 	return
 }
 
@@ -68,6 +77,7 @@ func NewRuntime(cfg llm.Config, opts ...Options) (*Runtime, error) {
 		CallbackHandler:  opt.CallbackHandler,
 		OAuthRedirectURL: opt.OAuthRedirectURL,
 		TokenStorage:     opt.TokenStorage,
+		Chaos:            opt.Chaos,
 	})
 	agents := agents.New(completer, registry)
 	sampler := sampling.NewSampler(agents)
@@ -77,6 +87,7 @@ func NewRuntime(cfg llm.Config, opts ...Options) (*Runtime, error) {
 
 	r := &Runtime{
 		Service:   registry,
+		agents:    agents,
 		llmConfig: cfg,
 		opt:       opt,
 	}
@@ -89,10 +100,25 @@ func NewRuntime(cfg llm.Config, opts ...Options) (*Runtime, error) {
 		return agent.NewServer(sessiondata.NewData(r), r, name)
 	})
 
+	registry.AddServer(outputs.ServerName, func(string) mcp.MessageHandler {
+		return outputs.NewServer()
+	})
 	registry.AddServer("nanobot.agentui", func(string) mcp.MessageHandler {
 		return agentui.NewServer(sessiondata.NewData(r), r)
 	})
 
+	for _, name := range []string{tickets.JiraServerName, tickets.LinearServerName} {
+		registry.AddServer(name, func(name string) mcp.MessageHandler {
+			return tickets.NewServer(name)
+		})
+	}
+
+	for _, name := range []string{office.GoogleServerName, office.MicrosoftServerName} {
+		registry.AddServer(name, func(name string) mcp.MessageHandler {
+			return office.NewServer(name)
+		})
+	}
+
 	if opt.DSN != "" {
 		var (
 			once  = &sync.Once{}
@@ -106,13 +132,30 @@ func NewRuntime(cfg llm.Config, opts ...Options) (*Runtime, error) {
 					panic(fmt.Errorf("failed to create resources store: %w", err))
 				}
 			})
-			return resources.NewServer(store)
+			return resources.NewServer(store, opt.SafeMode)
 		})
 	}
 
 	return r, nil
 }
 
+// Compact summarizes the older turns of the conversation with the agent in the session of ctx. It
+// returns the number of messages that were summarized.
+func (r *Runtime) Compact(ctx context.Context, agent string) (int, error) {
+	return r.agents.Compact(ctx, agent)
+}
+
+// RefreshSummary rewrites the structured summary of the session of ctx from the whole
+// conversation with the agent.
+func (r *Runtime) RefreshSummary(ctx context.Context, agent string) (*types.SessionSummary, error) {
+	return r.agents.RefreshSummary(ctx, agent)
+}
+
+// Complete runs the completion and tool loop of the agent req.Model.
+func (r *Runtime) Complete(ctx context.Context, req types.CompletionRequest, opts ...types.CompletionOptions) (*types.CompletionResponse, error) {
+	return r.agents.Complete(ctx, req, opts...)
+}
+
 func (r *Runtime) WithTempSession(ctx context.Context, config *types.Config) context.Context {
 	session := mcp.NewEmptySession(ctx)
 	session.Set(types.ConfigSessionKey, config)
@@ -68,7 +68,15 @@ func (s *Sampler) sortModels(config types.Config, preferences mcp.ModelPreferenc
 	return models
 }
 
-func (s *Sampler) getMatchingModel(config types.Config, req *mcp.CreateMessageRequest) (string, bool) {
+// getMatchingModel picks the agent for the request, from the allowed agents if there are any.
+func (s *Sampler) getMatchingModel(config types.Config, req *mcp.CreateMessageRequest, allowed []string) (string, bool) {
+	if len(allowed) > 0 {
+		config.Agents = maps.Clone(config.Agents)
+		maps.DeleteFunc(config.Agents, func(name string, _ types.Agent) bool {
+			return !slices.Contains(allowed, name)
+		})
+	}
+
 	// Agent by name
 	for _, model := range req.ModelPreferences.Hints {
 		if _, ok := config.Agents[model.Name]; ok {
@@ -97,12 +105,18 @@ type SamplerOptions struct {
 	ProgressToken any
 	Continue      bool
 	AgentOverride types.AgentCall
+	// Models are the agents the request can be sampled from, all agents if empty.
+	Models []string
+	// MaxTokens caps the output tokens of the completion.
+	MaxTokens int
 }
 
 func (s SamplerOptions) Merge(other SamplerOptions) (result SamplerOptions) {
 	result.ProgressToken = complete.Last(s.ProgressToken, other.ProgressToken)
 	result.Continue = complete.Last(s.Continue, other.Continue)
 	result.AgentOverride = complete.Merge(s.AgentOverride, other.AgentOverride)
+	result.Models = append(s.Models, other.Models...)
+	result.MaxTokens = complete.Last(s.MaxTokens, other.MaxTokens)
 	return
 }
 
@@ -110,7 +124,7 @@ func (s *Sampler) Sample(ctx context.Context, req mcp.CreateMessageRequest, opts
 	opt := complete.Complete(opts...)
 	config := types.ConfigFromContext(ctx)
 
-	model, ok := s.getMatchingModel(config, &req)
+	model, ok := s.getMatchingModel(config, &req, opt.Models)
 	if !ok {
 		return nil, ErrNoMatchingModel
 	}
@@ -128,6 +142,9 @@ func (s *Sampler) Sample(ctx context.Context, req mcp.CreateMessageRequest, opts
 	if req.MaxTokens != 0 {
 		request.MaxTokens = req.MaxTokens
 	}
+	if opt.MaxTokens > 0 && (request.MaxTokens == 0 || request.MaxTokens > opt.MaxTokens) {
+		request.MaxTokens = opt.MaxTokens
+	}
 	if req.SystemPrompt != "" {
 		request.SystemPrompt = req.SystemPrompt
 	}
@@ -172,8 +189,9 @@ func (s *Sampler) Sample(ctx context.Context, req mcp.CreateMessageRequest, opts
 	}
 
 	completeOptions := types.CompletionOptions{
-		Chat:          opt.AgentOverride.Chat,
-		ProgressToken: opt.ProgressToken,
+		Chat:              opt.AgentOverride.Chat,
+		ProgressToken:     opt.ProgressToken,
+		ParallelToolCalls: opt.AgentOverride.ParallelToolCalls,
 	}
 
 	resp, err := s.completer.Complete(ctx, request, completeOptions)
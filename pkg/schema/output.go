@@ -0,0 +1,105 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ErrInvalidOutput is matched by errors.Is for every OutputError.
+var ErrInvalidOutput = errors.New("output does not conform to the output schema")
+
+// OutputError is returned when the final output of a model does not conform to the output schema
+// the agent requested.
+type OutputError struct {
+	Schema string
+	Output string
+	Err    error
+}
+
+func (e *OutputError) Error() string {
+	return fmt.Sprintf("output does not conform to output schema %s: %v", e.Schema, e.Err)
+}
+
+func (e *OutputError) Is(target error) bool {
+	return target == ErrInvalidOutput
+}
+
+func (e *OutputError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateOutput checks the text of msg against the output schema. Only strict schemas and the
+// json_object format are validated, as non-strict schemas explicitly allow the model to deviate.
+func ValidateOutput(outputSchema *types.OutputSchema, msg types.Message) error {
+	if outputSchema == nil || (!outputSchema.Strict && !outputSchema.IsJSONObject()) {
+		return nil
+	}
+
+	output := OutputText(msg)
+	fail := func(err error) error {
+		return &OutputError{
+			Schema: outputSchema.Name,
+			Output: output,
+			Err:    err,
+		}
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(output), &value); err != nil {
+		return fail(fmt.Errorf("invalid JSON: %w", err))
+	}
+
+	if outputSchema.IsJSONObject() {
+		if _, ok := value.(map[string]any); !ok {
+			return fail(fmt.Errorf("expected a JSON object"))
+		}
+		return nil
+	}
+
+	var schemaObj any
+	if err := json.Unmarshal(outputSchema.ToSchema(), &schemaObj); err != nil {
+		return fmt.Errorf("failed to parse output schema %s: %w", outputSchema.Name, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("output.json", schemaObj); err != nil {
+		return fmt.Errorf("failed to add output schema %s: %w", outputSchema.Name, err)
+	}
+	compiled, err := c.Compile("output.json")
+	if err != nil {
+		return fmt.Errorf("failed to compile output schema %s: %w", outputSchema.Name, err)
+	}
+
+	if err := compiled.Validate(value); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// OutputText returns the text content of msg, without a surrounding markdown code fence that some
+// models add even when asked for JSON.
+func OutputText(msg types.Message) string {
+	var text strings.Builder
+	for _, item := range msg.Items {
+		if item.Content != nil && item.Content.Type == "text" {
+			text.WriteString(item.Content.Text)
+		}
+	}
+
+	result := strings.TrimSpace(text.String())
+	if fenced, ok := strings.CutPrefix(result, "```"); ok {
+		if body, ok := strings.CutSuffix(fenced, "```"); ok {
+			// Drop the language tag, if any, on the opening line.
+			if i := strings.IndexByte(body, '\n'); i >= 0 {
+				body = body[i+1:]
+			}
+			result = strings.TrimSpace(body)
+		}
+	}
+	return result
+}
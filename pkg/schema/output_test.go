@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func textMessage(text string) types.Message {
+	return types.Message{
+		Items: []types.CompletionItem{
+			{
+				Content: &mcp.Content{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	strict := &types.OutputSchema{
+		Name:   "person",
+		Strict: true,
+		Schema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}`),
+	}
+	jsonObject := &types.OutputSchema{
+		Format: types.OutputFormatJSONObject,
+	}
+
+	tests := []struct {
+		name    string
+		schema  *types.OutputSchema
+		output  string
+		invalid bool
+	}{
+		{name: "no schema", output: "hello"},
+		{name: "non-strict is not validated", schema: &types.OutputSchema{Schema: strict.Schema}, output: "hello"},
+		{name: "valid", schema: strict, output: `{"name":"Ada"}`},
+		{name: "fenced", schema: strict, output: "```json\n{\"name\":\"Ada\"}\n```"},
+		{name: "missing field", schema: strict, output: `{"age":3}`, invalid: true},
+		{name: "not JSON", schema: strict, output: "Ada", invalid: true},
+		{name: "json object", schema: jsonObject, output: `{"anything":true}`},
+		{name: "json array", schema: jsonObject, output: `[1,2]`, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOutput(tt.schema, textMessage(tt.output))
+			if tt.invalid != errors.Is(err, ErrInvalidOutput) {
+				t.Fatalf("expected invalid=%v, got %v", tt.invalid, err)
+			}
+			var outputErr *OutputError
+			if tt.invalid && (!errors.As(err, &outputErr) || outputErr.Output == "") {
+				t.Fatalf("expected an OutputError with the output, got %v", err)
+			}
+		})
+	}
+}
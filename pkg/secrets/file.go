@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyEnv is the environment variable with the passphrase of encrypted secret files.
+const KeyEnv = "NANOBOT_SECRETS_KEY"
+
+// encryptedFile reads secrets from a file sealed with Seal, such as encfile://./secrets.enc#openai.
+// The passphrase is read from NANOBOT_SECRETS_KEY.
+type encryptedFile struct{}
+
+func (encryptedFile) Resolve(_ context.Context, ref Ref) (string, error) {
+	passphrase := os.Getenv(KeyEnv)
+	if passphrase == "" {
+		return "", fmt.Errorf("%s must be set to read %s", KeyEnv, ref.Path)
+	}
+
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	values, err := Open(passphrase, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", ref.Path, err)
+	}
+
+	if ref.Key == "" {
+		return "", fmt.Errorf("select a secret of %s with #key", ref.Path)
+	}
+	value, ok := values[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", ref.Path, ref.Key)
+	}
+	return value, nil
+}
+
+func fileCipher(passphrase string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("nanobot-secrets:" + strings.TrimSpace(passphrase)))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts the secrets with AES-256-GCM and a key derived from the passphrase, in the format
+// read by encfile:// references.
+func Seal(passphrase string, values map[string]string) ([]byte, error) {
+	aead, err := fileCipher(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts secrets encrypted by Seal.
+func Open(passphrase string, data []byte) (map[string]string, error) {
+	aead, err := fileCipher(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid secrets file")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong key or corrupted file")
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
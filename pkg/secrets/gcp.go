@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// gcpSecretManager reads secrets from Google Cloud Secret Manager, such as
+// gcp-sm://projects/my-project/secrets/openai#api_key. The latest version is used unless the path
+// ends in /versions/VERSION. The access token is GOOGLE_OAUTH_ACCESS_TOKEN, or the token of the
+// service account from the metadata server when running on Google Cloud.
+type gcpSecretManager struct{}
+
+func (gcpSecretManager) Resolve(ctx context.Context, ref Ref) (string, error) {
+	name := strings.Trim(ref.Path, "/")
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := gcpToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := os.Getenv("GCP_SECRET_MANAGER_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "https://secretmanager.googleapis.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := getJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to access %s in GCP Secret Manager: %w", name, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return field(data, ref)
+}
+
+func gcpToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := getJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get an access token from the metadata server, set GOOGLE_OAUTH_ACCESS_TOKEN outside of Google Cloud: %w", err)
+	}
+	return resp.AccessToken, nil
+}
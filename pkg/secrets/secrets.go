@@ -0,0 +1,252 @@
+// Package secrets resolves references to secrets that are kept in an external backend, so API keys
+// and the env of MCP servers don't have to be in plaintext in the config or the environment.
+//
+// A reference has the form scheme://path#key, for example vault://secret/data/openai#api_key.
+// The key selects a field of the secret if the secret is a JSON object. References can be the
+// whole value or a part of it, such as "Bearer vault://secret/data/openai#api_key".
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+)
+
+// DefaultTTL is how long a resolved secret is used before it is resolved again, which is how a
+// rotated secret is picked up.
+const DefaultTTL = 5 * time.Minute
+
+// Ref is a reference to a secret.
+type Ref struct {
+	Scheme string
+	// Path identifies the secret in the backend, such as the path in Vault or the name of the
+	// secret in AWS Secrets Manager.
+	Path string
+	// Key is the field of the secret to use, empty to use the whole secret.
+	Key string
+}
+
+func (r Ref) String() string {
+	if r.Key == "" {
+		return r.Scheme + "://" + r.Path
+	}
+	return r.Scheme + "://" + r.Path + "#" + r.Key
+}
+
+// Resolver reads secrets from a backend.
+type Resolver interface {
+	Resolve(ctx context.Context, ref Ref) (string, error)
+}
+
+// ResolverFunc adapts a function to a Resolver.
+type ResolverFunc func(ctx context.Context, ref Ref) (string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, ref Ref) (string, error) {
+	return f(ctx, ref)
+}
+
+var refRegexp = regexp.MustCompile(`\b([a-z][a-z0-9-]*)://([^\s"'#]+)(?:#([^\s"']+))?`)
+
+type entry struct {
+	value    string
+	resolved time.Time
+}
+
+var (
+	lock      sync.RWMutex
+	resolvers = map[string]Resolver{
+		"vault":   vault{},
+		"aws-sm":  awsSecretsManager{},
+		"gcp-sm":  gcpSecretManager{},
+		"encfile": encryptedFile{},
+	}
+	ttl   = DefaultTTL
+	cache = map[Ref]entry{}
+	now   = time.Now
+)
+
+// Register adds a backend for the references with the scheme, replacing the backend that was
+// registered for it before.
+func Register(scheme string, resolver Resolver) {
+	lock.Lock()
+	defer lock.Unlock()
+	resolvers[scheme] = resolver
+	for ref := range cache {
+		if ref.Scheme == scheme {
+			delete(cache, ref)
+		}
+	}
+}
+
+// SetTTL sets how long resolved secrets are cached, 0 to resolve them on every use.
+func SetTTL(d time.Duration) {
+	lock.Lock()
+	defer lock.Unlock()
+	ttl = d
+}
+
+// Invalidate drops all cached secrets, so they are resolved again on their next use.
+func Invalidate() {
+	lock.Lock()
+	defer lock.Unlock()
+	clear(cache)
+}
+
+func lookup(scheme string) (Resolver, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	resolver, ok := resolvers[scheme]
+	return resolver, ok
+}
+
+// Contains reports if the value references a secret of a registered backend.
+func Contains(value string) bool {
+	if !strings.Contains(value, "://") {
+		return false
+	}
+	for _, match := range refRegexp.FindAllStringSubmatch(value, -1) {
+		if _, ok := lookup(match[1]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand replaces the references to secrets in the value with the secrets. Values without
+// references, and URLs with schemes that no backend is registered for, are returned unchanged.
+func Expand(ctx context.Context, value string) (string, error) {
+	if !strings.Contains(value, "://") {
+		return value, nil
+	}
+
+	var errs []error
+	result := refRegexp.ReplaceAllStringFunc(value, func(match string) string {
+		parts := refRegexp.FindStringSubmatch(match)
+		ref := Ref{
+			Scheme: parts[1],
+			Path:   parts[2],
+			Key:    parts[3],
+		}
+		if _, ok := lookup(ref.Scheme); !ok {
+			return match
+		}
+		secret, err := Resolve(ctx, ref)
+		if err != nil {
+			errs = append(errs, err)
+			return match
+		}
+		return secret
+	})
+	if len(errs) > 0 {
+		return "", errs[0]
+	}
+	return result, nil
+}
+
+// ExpandMap returns a copy of the map with the references to secrets in the values replaced.
+func ExpandMap(ctx context.Context, values map[string]string) (map[string]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		expanded, err := Expand(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand %s: %w", k, err)
+		}
+		result[k] = expanded
+	}
+	return result, nil
+}
+
+// SetHeaders sets the headers on the request with the references to secrets replaced. Callers
+// set them on every request rather than once, so rotated keys are picked up when the TTL expires.
+func SetHeaders(ctx context.Context, req *http.Request, headers map[string]string) error {
+	for k, v := range headers {
+		expanded, err := Expand(ctx, v)
+		if err != nil {
+			return fmt.Errorf("failed to expand header %s: %w", k, err)
+		}
+		req.Header.Set(k, expanded)
+	}
+	return nil
+}
+
+// Resolve returns the secret of the reference. Secrets are cached for the TTL. If a secret can't
+// be resolved again once its TTL expired, the last value is used until the backend recovers.
+func Resolve(ctx context.Context, ref Ref) (string, error) {
+	resolver, ok := lookup(ref.Scheme)
+	if !ok {
+		return "", fmt.Errorf("no secrets backend for %s", ref)
+	}
+
+	lock.RLock()
+	cached, found := cache[ref]
+	fresh := found && now().Sub(cached.resolved) < ttl
+	lock.RUnlock()
+	if fresh {
+		return cached.value, nil
+	}
+
+	value, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		if found {
+			log.Errorf(ctx, "failed to refresh secret %s, using the cached value: %v", ref, err)
+			return cached.value, nil
+		}
+		return "", fmt.Errorf("failed to resolve secret %s: %w", ref, err)
+	}
+
+	lock.Lock()
+	cache[ref] = entry{
+		value:    value,
+		resolved: now(),
+	}
+	lock.Unlock()
+	return value, nil
+}
+
+// field returns the key of a secret that is a JSON object, or the secret if the key is empty.
+func field(secret []byte, ref Ref) (string, error) {
+	if ref.Key == "" {
+		return string(secret), nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(secret, &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", ref.Path, err)
+	}
+	return stringField(fields, ref)
+}
+
+func stringField(fields map[string]any, ref Ref) (string, error) {
+	value, ok := fields[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", ref.Path, ref.Key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+func getJSON(req *http.Request, out any) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
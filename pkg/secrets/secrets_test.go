@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv(KeyEnv, "passphrase")
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	data, err := Seal("passphrase", map[string]string{"openai": "sk-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Expand(t.Context(), "Bearer encfile://"+path+"#openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Bearer sk-1" {
+		t.Fatalf("expected the secret of the file, got %q", got)
+	}
+	if got, err := Expand(t.Context(), "https://example.com/v1#top"); err != nil || got != "https://example.com/v1#top" {
+		t.Fatalf("expected URLs to be unchanged, got %q, %v", got, err)
+	}
+	if _, err := Expand(t.Context(), "encfile://"+path+"#missing"); err == nil {
+		t.Fatal("expected an error for a missing key")
+	}
+}
+
+func TestResolveRotation(t *testing.T) {
+	clock := time.Now()
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	var (
+		value = "v1"
+		fail  error
+		calls int
+	)
+	Register("test", ResolverFunc(func(context.Context, Ref) (string, error) {
+		calls++
+		return value, fail
+	}))
+	ref := Ref{Scheme: "test", Path: "key"}
+
+	resolve := func() string {
+		t.Helper()
+		got, err := Resolve(t.Context(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if got := resolve(); got != "v1" {
+		t.Fatalf("expected v1, got %q", got)
+	}
+	value = "v2"
+	if got := resolve(); got != "v1" || calls != 1 {
+		t.Fatalf("expected the cached v1, got %q after %d calls", got, calls)
+	}
+
+	clock = clock.Add(DefaultTTL)
+	if got := resolve(); got != "v2" {
+		t.Fatalf("expected the rotated v2 after the TTL, got %q", got)
+	}
+
+	clock = clock.Add(DefaultTTL)
+	fail = errors.New("unavailable")
+	if got := resolve(); got != "v2" {
+		t.Fatalf("expected the stale v2 while the backend fails, got %q", got)
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	Register("headers", ResolverFunc(func(_ context.Context, ref Ref) (string, error) {
+		if ref.Path == "missing" {
+			return "", errors.New("not found")
+		}
+		return "sk-" + ref.Path, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	if err := SetHeaders(t.Context(), req, map[string]string{
+		"Authorization": "Bearer headers://openai",
+		"X-Plain":       "value",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer sk-openai" {
+		t.Errorf("expected the resolved secret, got %q", got)
+	}
+	if got := req.Header.Get("X-Plain"); got != "value" {
+		t.Errorf("expected the plain value, got %q", got)
+	}
+
+	if err := SetHeaders(t.Context(), req, map[string]string{"Authorization": "headers://missing"}); err == nil {
+		t.Error("expected an error for a secret that can't be resolved")
+	}
+}
@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// vault reads secrets from the HTTP API of HashiCorp Vault at VAULT_ADDR with VAULT_TOKEN, such as
+// vault://secret/data/openai#api_key. Both the KV version 1 and 2 secrets engines are supported.
+type vault struct{}
+
+func (vault) Resolve(ctx context.Context, ref Ref) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(ref.Path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := getJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to read %s from vault: %w", ref.Path, err)
+	}
+
+	fields := resp.Data
+	// KV version 2 nests the secret in data and its version in metadata.
+	if data, ok := fields["data"].(map[string]any); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = data
+		}
+	}
+	if ref.Key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret %s has %d keys, select one with #key", ref.Path, len(fields))
+		}
+		for key := range fields {
+			ref.Key = key
+		}
+	}
+	return stringField(fields, ref)
+}
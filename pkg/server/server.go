@@ -9,7 +9,9 @@ import (
 	"slices"
 
 	"github.com/nanobot-ai/nanobot/pkg/expr"
+	"github.com/nanobot-ai/nanobot/pkg/lifecycle"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/metrics"
 	"github.com/nanobot-ai/nanobot/pkg/runtime"
 	"github.com/nanobot-ai/nanobot/pkg/session"
 	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
@@ -26,6 +28,10 @@ type Server struct {
 }
 
 func NewServer(runtime *runtime.Runtime, config types.ConfigFactory, manager *session.Manager) *Server {
+	// Count the active sessions and their tasks in the metrics.
+	mcp.OnSessionStarted(metrics.SessionStarted)
+	lifecycle.SetObserver(metrics.Tasks{})
+
 	s := &Server{
 		runtime: runtime,
 		data:    sessiondata.NewData(runtime),
@@ -57,6 +63,7 @@ func (s *Server) init() {
 	s.handlers = []handler{
 		handle[mcp.InitializeRequest]("initialize", s.handleInitialize),
 		handle[mcp.Notification]("notifications/initialized", s.handleInitialized),
+		handle[mcp.Notification]("notifications/roots/list_changed", s.handleRootsListChanged),
 		handle[mcp.PingRequest]("ping", s.handlePing),
 		handle[mcp.ListToolsRequest]("tools/list", s.handleListTools),
 		handle[mcp.CallToolRequest]("tools/call", s.handleCallTool),
@@ -195,6 +202,9 @@ func (s *Server) handleCallTool(ctx context.Context, msg mcp.Message, payload mc
 			return fmt.Errorf("tool %s not found", payload.Name)
 		}
 	}
+	if !types.AgentAllowed(ctx, toolMapping.MCPServer) {
+		return fmt.Errorf("agent %s is not allowed for this client", toolMapping.MCPServer)
+	}
 
 	result, err := s.runtime.Call(ctx, toolMapping.MCPServer, toolMapping.TargetName, payload.Arguments, tools.CallOptions{
 		ProgressToken: msg.ProgressToken(),
@@ -227,7 +237,9 @@ func (s *Server) handleListTools(ctx context.Context, msg mcp.Message, _ mcp.Lis
 	}
 
 	for _, k := range slices.Sorted(maps.Keys(toolMappings)) {
-		result.Tools = append(result.Tools, toolMappings[k].Target)
+		if types.AgentAllowed(ctx, toolMappings[k].MCPServer) {
+			result.Tools = append(result.Tools, toolMappings[k].Target)
+		}
 	}
 
 	return msg.Reply(ctx, result)
@@ -298,6 +310,12 @@ func (s *Server) handleInitialized(ctx context.Context, msg mcp.Message, payload
 	return nil
 }
 
+// handleRootsListChanged passes the change of the roots of the client on to the servers that were
+// given them.
+func (s *Server) handleRootsListChanged(ctx context.Context, _ mcp.Message, _ mcp.Notification) error {
+	return s.runtime.NotifyRootsChanged(ctx)
+}
+
 func (s *Server) handleInitialize(ctx context.Context, msg mcp.Message, payload mcp.InitializeRequest) error {
 	session := mcp.SessionFromContext(ctx)
 	c := types.ConfigFromContext(ctx)
@@ -328,7 +346,9 @@ func (s *Server) handleInitialize(ctx context.Context, msg mcp.Message, payload
 			Resources: &mcp.ResourcesServerCapability{
 				Subscribe: true,
 			},
-			Tools: &mcp.ToolsServerCapability{},
+			Tools: &mcp.ToolsServerCapability{
+				ListChanged: true,
+			},
 		},
 		ServerInfo: mcp.ServerInfo{
 			Name:    c.Publish.Name,
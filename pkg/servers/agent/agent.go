@@ -20,12 +20,15 @@ type Server struct {
 	agentName  string
 	multiAgent bool
 	runtime    Caller
+	turns      turns
 }
 
 type Caller interface {
 	Call(ctx context.Context, server, tool string, args any, opts ...tools.CallOptions) (ret *types.CallResult, err error)
 	GetClient(ctx context.Context, name string) (*mcp.Client, error)
 	GetPrompt(ctx context.Context, target, prompt string, args map[string]string) (*mcp.GetPromptResult, error)
+	Compact(ctx context.Context, agent string) (int, error)
+	RefreshSummary(ctx context.Context, agent string) (*types.SessionSummary, error)
 }
 
 func NewServer(d *sessiondata.Data, r Caller, name string) *Server {
@@ -37,6 +40,9 @@ func NewServer(d *sessiondata.Data, r Caller, name string) *Server {
 
 	s.tools = mcp.NewServerTools(
 		chatCall{s: s},
+		mcp.NewServerTool("stop", "Stop the response currently being generated, including any running tool calls", s.stop),
+		mcp.NewServerTool("compact", "Summarize the older messages of the conversation to free up the context window of the agent", s.compact),
+		mcp.NewServerTool("refresh_summary", "Rewrite the summary of the topics, decisions, and open questions of the conversation from all of its messages", s.refreshSummary),
 	)
 
 	return s
@@ -63,7 +69,7 @@ func (s *Server) OnMessage(ctx context.Context, msg mcp.Message) {
 	case "prompts/get":
 		mcp.Invoke(ctx, msg, s.promptGet)
 	default:
-		msg.SendError(ctx, mcp.ErrRPCMethodNotFound.WithMessage(msg.Method))
+		msg.SendError(ctx, mcp.ErrRPCMethodNotFound.WithMessage("%s", msg.Method))
 	}
 }
 
@@ -3,12 +3,14 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/tools"
 	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uifeatures"
 )
 
 const progressSessionKey = "progress"
@@ -39,12 +41,20 @@ func closeProgress(ctx context.Context, session *mcp.Session, err error) {
 	response.ProgressToken = nil
 	session.Set(progressSessionKey, &response)
 
+	notifyProgressUpdated(ctx, session)
+}
+
+// notifyProgressUpdated tells clients that resume streams from the progress resource that it changed.
+func notifyProgressUpdated(ctx context.Context, session *mcp.Session) {
+	if !uifeatures.Supported(ctx, uifeatures.ResumableStreams) {
+		return
+	}
 	_ = session.SendPayload(ctx, "notifications/resources/updated", map[string]any{
 		"uri": types.ProgressURI,
 	})
 }
 
-func appendProgress(ctx context.Context, session *mcp.Session, progressMessage *mcp.Message) (*mcp.Message, error) {
+func appendProgress(ctx context.Context, session *mcp.Session, buffer *progressBuffer, progressMessage *mcp.Message) (*mcp.Message, error) {
 	if progressMessage.Method != "notifications/progress" {
 		return progressMessage, nil
 	}
@@ -65,11 +75,7 @@ func appendProgress(ctx context.Context, session *mcp.Session, progressMessage *
 	session.Get(progressSessionKey, &response)
 	defer session.Set(progressSessionKey, &response)
 
-	defer func() {
-		_ = session.SendPayload(ctx, "notifications/resources/updated", map[string]any{
-			"uri": types.ProgressURI,
-		})
-	}()
+	defer notifyProgressUpdated(ctx, session)
 	response.HasMore = true
 
 	if progressItem.ToolCallResult != nil {
@@ -137,17 +143,22 @@ func appendProgress(ctx context.Context, session *mcp.Session, progressMessage *
 
 	currentItem.HasMore = progressItem.HasMore
 	// At this point Partial is always true
-	if progressItem.Content != nil {
-		currentItem.Content.Text += progressItem.Content.Text
+	key := event.Meta.Progress.MessageID + "/" + currentItem.ID
+	if progressItem.Content != nil && progressItem.Content.Type == "audio" {
+		currentItem.Content.Data = buffer.appendAudio(key, currentItem.Content.Data, progressItem.Content.Data)
+	} else if progressItem.Content != nil {
+		currentItem.Content.Text = buffer.appendText(key+"/text", currentItem.Content.Text, progressItem.Content.Text)
 	} else if progressItem.ToolCall != nil && currentItem.ToolCall == nil {
 		currentItem.ToolCall = progressItem.ToolCall
 	} else if progressItem.ToolCall != nil {
-		currentItem.ToolCall.Arguments += progressItem.ToolCall.Arguments
+		currentItem.ToolCall.Arguments = buffer.appendText(key+"/arguments", currentItem.ToolCall.Arguments, progressItem.ToolCall.Arguments)
 	} else if progressItem.Reasoning != nil && len(progressItem.Reasoning.Summary) > 0 {
 		if len(currentItem.Reasoning.Summary) == 0 {
 			currentItem.Reasoning.Summary = append(currentItem.Reasoning.Summary, progressItem.Reasoning.Summary[0])
 		} else {
-			currentItem.Reasoning.Summary[len(currentItem.Reasoning.Summary)-1].Text += progressItem.Reasoning.Summary[0].Text
+			summary := &currentItem.Reasoning.Summary[len(currentItem.Reasoning.Summary)-1]
+			summary.Text = buffer.appendText(fmt.Sprintf("%s/reasoning/%d", key, len(currentItem.Reasoning.Summary)-1),
+				summary.Text, progressItem.Reasoning.Summary[0].Text)
 		}
 	}
 
@@ -183,11 +194,15 @@ func (c chatCall) Invoke(ctx context.Context, msg mcp.Message, payload mcp.CallT
 func (c chatCall) chatInvoke(ctx context.Context, msg mcp.Message, payload mcp.CallToolRequest) (_ *mcp.CallToolResult, retErr error) {
 	session := mcp.SessionFromContext(ctx).Parent
 
+	ctx, done := c.s.turns.start(ctx)
+	defer done()
+
 	defer func() {
 		closeProgress(ctx, session, retErr)
 	}()
+	buffer := newProgressBuffer()
 	defer session.AddFilter(func(ctx context.Context, msg *mcp.Message) (*mcp.Message, error) {
-		return appendProgress(ctx, session, msg)
+		return appendProgress(ctx, session, buffer, msg)
 	})()
 
 	session.Set(progressSessionKey, &types.CompletionResponse{
@@ -200,6 +215,14 @@ func (c chatCall) chatInvoke(ctx context.Context, msg mcp.Message, payload mcp.C
 			"mcpToolName": payload.Name,
 		},
 	})
+	if errors.Is(context.Cause(ctx), ErrStopped) {
+		// The turn's context is cancelled, but the progress still needs to be closed and the
+		// caller told that the response was stopped.
+		ctx = context.WithoutCancel(ctx)
+		sendStopped(ctx, msg.ProgressToken())
+		mcpResult := stoppedResult()
+		return &mcpResult, msg.Reply(ctx, mcpResult)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -219,22 +242,24 @@ func (c chatCall) chatInvoke(ctx context.Context, msg mcp.Message, payload mcp.C
 }
 
 func GetMessages(ctx context.Context) ([]types.Message, error) {
-	var (
-		run         types.Execution
-		allMessages []types.Message
-	)
+	var run types.Execution
 
 	session := mcp.SessionFromContext(ctx)
 	session.Get(types.PreviousExecutionKey, &run)
 
-	if run.PopulatedRequest != nil {
-		allMessages = run.PopulatedRequest.Input
+	// The older messages of long threads are stored outside the session.
+	var loader types.HistoryLoader
+	if run.History == nil || run.History.Messages == 0 || !session.Get(types.ManagerSessionKey, &loader) {
+		return run.Messages(), nil
 	}
-	if run.Response != nil {
-		allMessages = append(allMessages, run.Response.Output)
+	for session.Parent != nil {
+		session = session.Parent
 	}
-
-	return types.ConsolidateTools(allMessages), nil
+	older, err := loader.LoadHistory(ctx, session.ID(), run.History)
+	if err != nil {
+		return nil, err
+	}
+	return append(types.ConsolidateTools(older), run.Messages()...), nil
 }
 
 type progressPayload struct {
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type compactParams struct{}
+
+// compact summarizes the older turns of the conversation with the agent to free up its context
+// window. The session keeps the original history next to the compacted one.
+func (s *Server) compact(ctx context.Context, _ compactParams) (*mcp.CallToolResult, error) {
+	if s.turns.running() {
+		return nil, fmt.Errorf("the conversation can not be compacted while a response is in progress")
+	}
+
+	summarized, err := s.runtime.Compact(ctx, s.agentName)
+	if err != nil {
+		return nil, err
+	}
+
+	text := "The conversation is too short to be compacted"
+	if summarized > 0 {
+		text = fmt.Sprintf("Summarized %d messages of the conversation", summarized)
+	}
+	return &mcp.CallToolResult{
+		StructuredContent: map[string]any{"summarized": summarized},
+		Content: []mcp.Content{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}, nil
+}
+
+type refreshSummaryParams struct{}
+
+// refreshSummary rewrites the summary of the conversation with the agent from all of its messages,
+// instead of waiting for the update after the next turn.
+func (s *Server) refreshSummary(ctx context.Context, _ refreshSummaryParams) (*types.SessionSummary, error) {
+	if s.turns.running() {
+		return nil, fmt.Errorf("the summary can not be refreshed while a response is in progress")
+	}
+	return s.runtime.RefreshSummary(ctx, s.agentName)
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// ErrStopped is the cancellation cause of a turn that was stopped with the stop tool.
+var ErrStopped = errors.New("stopped by user")
+
+// turns tracks the cancel functions of the chat turns currently running in a session.
+type turns struct {
+	lock    sync.Mutex
+	next    int
+	cancels map[int]context.CancelCauseFunc
+}
+
+// start returns a context for a new turn that is cancelled when Stop is called, and a function that
+// must be called when the turn is done.
+func (t *turns) start(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.cancels == nil {
+		t.cancels = map[int]context.CancelCauseFunc{}
+	}
+	id := t.next
+	t.next++
+	t.cancels[id] = cancel
+
+	return ctx, func() {
+		t.lock.Lock()
+		delete(t.cancels, id)
+		t.lock.Unlock()
+		cancel(nil)
+	}
+}
+
+// running reports whether a turn is running.
+func (t *turns) running() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.cancels) > 0
+}
+
+// Stop cancels all running turns, including their in-flight completions and tool calls, and
+// returns how many were stopped.
+func (t *turns) Stop() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stopped := len(t.cancels)
+	for id, cancel := range t.cancels {
+		cancel(ErrStopped)
+		delete(t.cancels, id)
+	}
+	return stopped
+}
+
+// Stop cancels the completions and tool calls of all chat turns running in this agent's session.
+// Each stopped turn replies with a cancelled result and sends a types.ProgressEventCancelled event.
+func (s *Server) Stop() int {
+	return s.turns.Stop()
+}
+
+type stopParams struct{}
+
+func (s *Server) stop(context.Context, stopParams) (*mcp.CallToolResult, error) {
+	stopped := s.Stop()
+
+	text := "There is no response in progress"
+	if stopped > 0 {
+		text = "Stopped the response in progress"
+	}
+	return &mcp.CallToolResult{
+		StructuredContent: map[string]any{"stopped": stopped},
+		Content: []mcp.Content{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}, nil
+}
+
+// sendStopped reports to the UI that the turn streaming to progressToken was cancelled.
+func sendStopped(ctx context.Context, progressToken any) {
+	progress.Send(ctx, &types.CompletionProgress{
+		Role: "assistant",
+		Event: &types.ProgressEvent{
+			Type:    types.ProgressEventCancelled,
+			Message: ErrStopped.Error(),
+		},
+	}, progressToken)
+}
+
+func stoppedResult() mcp.CallToolResult {
+	return mcp.CallToolResult{
+		StructuredContent: map[string]any{"cancelled": true},
+		Content: []mcp.Content{
+			{
+				Type: "text",
+				Text: fmt.Sprintf("The response was %s.", ErrStopped),
+			},
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTurnsStop(t *testing.T) {
+	var turns turns
+	running, done := turns.start(t.Context())
+	finished, finish := turns.start(t.Context())
+	finish()
+
+	if !turns.running() {
+		t.Fatal("expected a running turn")
+	}
+	if stopped := turns.Stop(); stopped != 1 {
+		t.Errorf("got %d stopped turns, want 1", stopped)
+	}
+	if cause := context.Cause(running); !errors.Is(cause, ErrStopped) {
+		t.Errorf("got cause %v, want %v", cause, ErrStopped)
+	}
+	if cause := context.Cause(finished); errors.Is(cause, ErrStopped) {
+		t.Error("expected the finished turn not to be stopped")
+	}
+
+	done()
+	if turns.running() {
+		t.Error("expected no running turn")
+	}
+}
+
+func TestStop(t *testing.T) {
+	var s Server
+	_, done := s.turns.start(t.Context())
+	defer done()
+
+	for _, want := range []struct {
+		stopped int
+		text    string
+	}{
+		{1, "Stopped the response in progress"},
+		{0, "There is no response in progress"},
+	} {
+		result, err := s.stop(t.Context(), stopParams{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stopped := result.StructuredContent.(map[string]any)["stopped"]; stopped != want.stopped || result.Content[0].Text != want.text {
+			t.Errorf("got %v %q, want %d %q", stopped, result.Content[0].Text, want.stopped, want.text)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// progressBuffer accumulates the streamed items of the progress of a turn. Concatenating every
+// delta to its item would copy the whole item each time, which is quadratic in the length of the
+// response. The deltas are appended to builders instead, and the String of a builder does not
+// copy, so the items stay up to date after every delta.
+type progressBuffer struct {
+	texts map[string]*strings.Builder
+	audio map[string]*streamedAudio
+}
+
+func newProgressBuffer() *progressBuffer {
+	return &progressBuffer{
+		texts: map[string]*strings.Builder{},
+		audio: map[string]*streamedAudio{},
+	}
+}
+
+// appendText appends delta to the text of key, which starts with base, and returns the text so far.
+func (p *progressBuffer) appendText(key, base, delta string) string {
+	text, ok := p.texts[key]
+	// The text is started over if the item was replaced in the meantime.
+	if !ok || text.Len() != len(base) {
+		text = &strings.Builder{}
+		text.WriteString(base)
+		p.texts[key] = text
+	}
+	text.WriteString(delta)
+	return text.String()
+}
+
+// appendAudio appends a base64 encoded chunk to the audio of key, which starts with base, and
+// returns the audio so far. The chunks can not simply be concatenated when one is padded.
+func (p *progressBuffer) appendAudio(key, base, chunk string) string {
+	audio, ok := p.audio[key]
+	if !ok || audio.len() != len(base) {
+		data, err := base64.StdEncoding.DecodeString(base)
+		if err != nil {
+			return base + chunk
+		}
+		audio = &streamedAudio{}
+		audio.write(data)
+		p.audio[key] = audio
+	}
+
+	data, err := base64.StdEncoding.DecodeString(chunk)
+	if err != nil {
+		return base + chunk
+	}
+	audio.write(data)
+	return audio.String()
+}
+
+// streamedAudio is base64 encoded audio that is appended to. Only complete groups of three bytes
+// are encoded, the remaining bytes wait for the next chunk so the encoding is never padded in the
+// middle.
+type streamedAudio struct {
+	encoded strings.Builder
+	tail    []byte
+}
+
+func (a *streamedAudio) write(data []byte) {
+	data = append(a.tail, data...)
+	complete := len(data) / 3 * 3
+	a.encoded.WriteString(base64.StdEncoding.EncodeToString(data[:complete]))
+	a.tail = append([]byte(nil), data[complete:]...)
+}
+
+func (a *streamedAudio) len() int {
+	return a.encoded.Len() + base64.StdEncoding.EncodedLen(len(a.tail))
+}
+
+// String returns the audio so far. It only copies the audio if a chunk did not end with a
+// complete group.
+func (a *streamedAudio) String() string {
+	if len(a.tail) == 0 {
+		return a.encoded.String()
+	}
+	return a.encoded.String() + base64.StdEncoding.EncodeToString(a.tail)
+}
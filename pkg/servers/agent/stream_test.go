@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestProgressBufferText(t *testing.T) {
+	buffer := newProgressBuffer()
+
+	text := ""
+	for _, delta := range []string{"It ", "is ", "sunny."} {
+		text = buffer.appendText("msg/item", text, delta)
+	}
+	if text != "It is sunny." {
+		t.Errorf("got %q", text)
+	}
+
+	// The item was replaced by a complete message, so the text starts over from it.
+	if text = buffer.appendText("msg/item", "It was rainy.", " Now"); text != "It was rainy. Now" {
+		t.Errorf("got %q after the item was replaced", text)
+	}
+	if text = buffer.appendText("msg/other", "", "Hi"); text != "Hi" {
+		t.Errorf("got %q for another item", text)
+	}
+}
+
+func TestProgressBufferAudio(t *testing.T) {
+	buffer := newProgressBuffer()
+
+	// Chunks that are not a multiple of three bytes are padded, so they can't be concatenated.
+	var (
+		audio string
+		want  []byte
+	)
+	for _, chunk := range [][]byte{{1, 2}, {3, 4, 5, 6, 7}, {8}, {9, 10, 11}} {
+		audio = buffer.appendAudio("msg/audio", audio, base64.StdEncoding.EncodeToString(chunk))
+		want = append(want, chunk...)
+		if audio != base64.StdEncoding.EncodeToString(want) {
+			t.Fatalf("got %q, want the encoding of %v", audio, want)
+		}
+	}
+
+	if got := buffer.appendAudio("msg/audio", audio, "not base64!"); got != audio+"not base64!" {
+		t.Errorf("expected invalid chunks to be concatenated, got %q", got)
+	}
+}
@@ -2,15 +2,22 @@ package agentui
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/nanobot-ai/nanobot/pkg/elicit"
+	"github.com/nanobot-ai/nanobot/pkg/lifecycle"
 	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	pkgsession "github.com/nanobot-ai/nanobot/pkg/session"
 	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
+	"github.com/nanobot-ai/nanobot/pkg/telemetry"
 	"github.com/nanobot-ai/nanobot/pkg/tools"
 	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uifeatures"
 	"github.com/nanobot-ai/nanobot/pkg/version"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Server struct {
@@ -22,6 +29,7 @@ type Server struct {
 type Caller interface {
 	Call(ctx context.Context, server, tool string, args any, opts ...tools.CallOptions) (ret *types.CallResult, err error)
 	GetClient(ctx context.Context, name string) (*mcp.Client, error)
+	GetPrompt(ctx context.Context, target, prompt string, args map[string]string) (*mcp.GetPromptResult, error)
 }
 
 func NewServer(d *sessiondata.Data, r Caller) *Server {
@@ -32,24 +40,58 @@ func NewServer(d *sessiondata.Data, r Caller) *Server {
 
 	s.tools = mcp.NewServerTools(
 		setCurrentAgentCall{s: s},
+		mcp.NewServerTool("list_agents", "List the agents the user can chat with, with their description, model, capabilities and icons", s.listAgents),
 		chatCall{s: s},
+		mcp.NewServerTool("stop", "Stop the response currently being generated, including any running tool calls", s.stop),
+		mcp.NewServerTool("compact", "Summarize the older messages of the conversation to free up the context window of the agent", s.compact),
+		mcp.NewServerTool("refresh_summary", "Rewrite the summary of the topics, decisions, and open questions of the conversation from all of its messages", s.refreshSummary),
+		mcp.NewServerTool("fork", "Copy the conversation up to and including a message into a new session to explore an alternative continuation. Returns the ID of the new session", s.fork),
+		mcp.NewServerTool("search_sessions", "Search the titles and messages of your sessions, best matches first", s.searchSessions),
+		mcp.NewServerTool("answer_elicitation", "Answer a question a server asked in an elicitation progress event of the chat", s.answerElicitation),
 	)
 
 	return s
 }
 
 func (s *Server) OnMessage(ctx context.Context, msg mcp.Message) {
+	attrs := []attribute.KeyValue{attribute.String("mcp.method", msg.Method)}
+	if msg.Session != nil {
+		attrs = append(attrs, attribute.String("mcp.session.id", msg.Session.ID()))
+	}
+	if msg.Method == "tools/call" {
+		var call struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(msg.Params, &call) == nil {
+			attrs = append(attrs, attribute.String("mcp.tool.name", call.Name))
+		}
+	}
+	ctx, span := telemetry.Start(ctx, "agentui "+msg.Method, attrs...)
+	defer span.End()
+
 	switch msg.Method {
 	case "initialize":
 		mcp.Invoke(ctx, msg, s.initialize)
 	case "notifications/initialized":
 		// nothing to do
 	case "tools/list":
-		mcp.Invoke(ctx, msg, s.tools.List)
+		mcp.Invoke(ctx, msg, s.listTools)
 	case "tools/call":
-		mcp.Invoke(ctx, msg, s.tools.Call)
+		mcp.Invoke(ctx, msg, s.callTool)
+	case "resources/list":
+		mcp.Invoke(ctx, msg, s.listResources)
+	case "resources/read":
+		mcp.Invoke(ctx, msg, s.readResource)
+	case "resources/subscribe":
+		mcp.Invoke(ctx, msg, s.subscribe)
+	case "resources/unsubscribe":
+		mcp.Invoke(ctx, msg, s.unsubscribe)
+	case "prompts/list":
+		mcp.Invoke(ctx, msg, s.listPrompts)
+	case "prompts/get":
+		mcp.Invoke(ctx, msg, s.getPrompt)
 	default:
-		msg.SendError(ctx, mcp.ErrRPCMethodNotFound.WithMessage(msg.Method))
+		msg.SendError(ctx, mcp.ErrRPCMethodNotFound.WithMessage("%s", msg.Method))
 	}
 }
 func (s *Server) describeSession(ctx context.Context, args any) <-chan struct{} {
@@ -60,7 +102,7 @@ func (s *Server) describeSession(ctx context.Context, args any) <-chan struct{}
 	session = session.Parent
 	session.Get(types.DescriptionSessionKey, &description)
 	if description == "" {
-		go func() {
+		lifecycle.Go(ctx, session.ID(), lifecycle.KindJob, "generate title", func(ctx context.Context) {
 			ret, err := s.runtime.Call(ctx, "nanobot.summary", "nanobot.summary", args)
 			if err != nil {
 				log.Errorf(ctx, "Failed to generate title: %v", err)
@@ -70,8 +112,12 @@ func (s *Server) describeSession(ctx context.Context, args any) <-chan struct{}
 			for _, content := range ret.Content {
 				if content.Type == "text" {
 					description = content.Text
-					log.Infof(ctx, "Generated title: %q", description)
 					session.Set(types.DescriptionSessionKey, description)
+					if types.IsEphemeral(session) {
+						// The title stays in memory with the rest of the session.
+						break
+					}
+					log.Infof(ctx, "Generated title: %q", description)
 
 					// Update database with the new description
 					var manager pkgsession.Manager
@@ -109,7 +155,7 @@ func (s *Server) describeSession(ctx context.Context, args any) <-chan struct{}
 			}
 			// Close channel only after DB is updated
 			close(result)
-		}()
+		})
 	} else {
 		close(result)
 	}
@@ -148,7 +194,14 @@ func (s *Server) initialize(ctx context.Context, _ mcp.Message, params mcp.Initi
 	return &mcp.InitializeResult{
 		ProtocolVersion: params.ProtocolVersion,
 		Capabilities: mcp.ServerCapabilities{
-			Tools: &mcp.ToolsServerCapability{},
+			Tools: &mcp.ToolsServerCapability{
+				ListChanged: true,
+			},
+			Resources: &mcp.ResourcesServerCapability{
+				Subscribe: true,
+			},
+			Prompts:      &mcp.PromptsServerCapability{},
+			Experimental: uifeatures.Advertise(uifeatures.Negotiate(params.Capabilities)),
 		},
 		ServerInfo: mcp.ServerInfo{
 			Name:    version.Name,
@@ -156,3 +209,226 @@ func (s *Server) initialize(ctx context.Context, _ mcp.Message, params mcp.Initi
 		},
 	}, nil
 }
+
+// toolFeatures are the tools that are only offered to clients that support a feature.
+var toolFeatures = map[string]string{
+	"stop": uifeatures.Cancellation,
+}
+
+// listTools lists the tools for the features of the client, the chat tool only takes attachments
+// from clients that can send them.
+func (s *Server) listTools(ctx context.Context, msg mcp.Message, payload mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	result, err := s.tools.List(ctx, msg, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := result.Tools[:0]
+	for _, tool := range result.Tools {
+		if feature, ok := toolFeatures[tool.Name]; ok && !uifeatures.Supported(ctx, feature) {
+			continue
+		}
+		if tool.Name == types.AgentTool+"_ui" && !uifeatures.Supported(ctx, uifeatures.Attachments) {
+			tool.InputSchema, err = withoutAttachments(tool.InputSchema)
+			if err != nil {
+				return nil, err
+			}
+		}
+		tools = append(tools, tool)
+	}
+	result.Tools = tools
+	return result, nil
+}
+
+func (s *Server) callTool(ctx context.Context, msg mcp.Message, payload mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if feature, ok := toolFeatures[payload.Name]; ok && !uifeatures.Supported(ctx, feature) {
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("tool %s requires the %s feature, which the client did not negotiate", payload.Name, feature)
+	}
+	return s.tools.Call(ctx, msg, payload)
+}
+
+func withoutAttachments(schema json.RawMessage) (json.RawMessage, error) {
+	var data map[string]any
+	if err := json.Unmarshal(schema, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal input schema: %w", err)
+	}
+	if properties, ok := data["properties"].(map[string]any); ok {
+		delete(properties, "attachments")
+	}
+	return json.Marshal(data)
+}
+
+type listAgentsResult struct {
+	Agents  []types.AgentDisplay `json:"agents"`
+	Current string               `json:"current,omitempty"`
+}
+
+func (s *Server) listAgents(ctx context.Context, _ struct{}) (*listAgentsResult, error) {
+	agents, err := s.data.Agents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &listAgentsResult{
+		Agents:  agents,
+		Current: s.data.CurrentAgent(ctx),
+	}, nil
+}
+
+// checkAgent returns an error that lists the available agents if agent is not one of them.
+func (s *Server) checkAgent(ctx context.Context, agent string) error {
+	agents, err := s.data.Agents(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(agents))
+	for _, display := range agents {
+		if display.ID == agent {
+			return nil
+		}
+		ids = append(ids, display.ID)
+	}
+
+	rpcErr := mcp.ErrRPCInvalidParams.WithMessage("unknown agent %s", agent)
+	rpcErr.DataObject = map[string]any{
+		"kind":   "unknown_agent",
+		"agent":  agent,
+		"agents": ids,
+	}
+	return rpcErr.RPCError()
+}
+
+type stopParams struct {
+	// SessionID must be the session of the request, so that a stale view does not stop the response
+	// of another thread.
+	SessionID string `json:"sessionId,omitempty" jsonschema:"The ID of the session to stop. Defaults to the session of the request"`
+}
+
+// stop forwards to the stop tool of the agent of the running turn, which owns it. That is the
+// current agent unless the message was sent to another one.
+func (s *Server) stop(ctx context.Context, params stopParams) (*mcp.CallToolResult, error) {
+	session := mcp.SessionFromContext(ctx)
+	for session.Parent != nil {
+		session = session.Parent
+	}
+	if params.SessionID != "" && params.SessionID != session.ID() {
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("session %s is not the session of the request", params.SessionID)
+	}
+
+	var agent string
+	if !mcp.SessionFromContext(ctx).Get(turnAgentSessionKey, &agent) {
+		agent = s.data.CurrentAgent(ctx)
+	}
+	client, err := s.runtime.GetClient(ctx, agent)
+	if err != nil {
+		return nil, err
+	}
+	return client.Call(ctx, "stop", map[string]any{})
+}
+
+type compactParams struct{}
+
+// compact forwards to the compact tool of the current agent, which owns the conversation.
+func (s *Server) compact(ctx context.Context, _ compactParams) (*mcp.CallToolResult, error) {
+	client, err := s.runtime.GetClient(ctx, s.data.CurrentAgent(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return client.Call(ctx, "compact", map[string]any{})
+}
+
+type refreshSummaryParams struct{}
+
+// refreshSummary forwards to the refresh_summary tool of the current agent, which owns the
+// conversation.
+func (s *Server) refreshSummary(ctx context.Context, _ refreshSummaryParams) (*mcp.CallToolResult, error) {
+	client, err := s.runtime.GetClient(ctx, s.data.CurrentAgent(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return client.Call(ctx, "refresh_summary", map[string]any{})
+}
+
+type forkParams struct {
+	MessageID string `json:"messageID,omitempty" jsonschema:"The ID of the last message to keep. Defaults to the whole conversation"`
+}
+
+// fork copies the conversation up to a message into a new session and returns the ID of the new
+// session. The current session is not changed.
+func (s *Server) fork(ctx context.Context, args forkParams) (string, error) {
+	var (
+		manager   pkgsession.Manager
+		accountID string
+	)
+
+	session := mcp.SessionFromContext(ctx)
+	if session.Parent != nil {
+		session = session.Parent
+	}
+
+	if !session.Get(pkgsession.ManagerSessionKey, &manager) {
+		return "", fmt.Errorf("session store not found")
+	}
+	if !session.Get(types.AccountIDSessionKey, &accountID) {
+		return "", fmt.Errorf("account ID not found in session")
+	}
+
+	forked, err := manager.Fork(ctx, session.ID(), args.MessageID, accountID)
+	if err != nil {
+		return "", err
+	}
+	return forked.SessionID, nil
+}
+
+type searchSessionsParams struct {
+	Query  string `json:"query" jsonschema:"The words to search for in the titles and messages of the sessions"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"The number of sessions to return. Defaults to 20"`
+	Offset int    `json:"offset,omitempty" jsonschema:"The number of ranked sessions to skip, to page through the results"`
+}
+
+// searchSessions searches the sessions of the account of the current session, best matches
+// first.
+func (s *Server) searchSessions(ctx context.Context, args searchSessionsParams) (*pkgsession.SearchResult, error) {
+	var (
+		manager   pkgsession.Manager
+		accountID string
+	)
+
+	session := mcp.SessionFromContext(ctx)
+	if session.Parent != nil {
+		session = session.Parent
+	}
+
+	if !session.Get(pkgsession.ManagerSessionKey, &manager) {
+		return nil, fmt.Errorf("session store not found")
+	}
+	if !session.Get(types.AccountIDSessionKey, &accountID) {
+		return nil, fmt.Errorf("account ID not found in session")
+	}
+
+	return manager.Search(ctx, args.Query, pkgsession.SearchOptions{
+		AccountID: accountID,
+		Limit:     args.Limit,
+		Offset:    args.Offset,
+	})
+}
+
+type answerElicitationParams struct {
+	ID      string         `json:"id" jsonschema:"The ID of the elicitation of the progress event"`
+	Action  string         `json:"action" jsonschema:"accept to send the content, decline, or cancel"`
+	Content map[string]any `json:"content,omitempty" jsonschema:"The answer in the form of the requested schema of the elicitation, sent with accept"`
+}
+
+// answerElicitation passes the answer of the user to the server that is waiting for it in the
+// tool call of the chat turn.
+func (s *Server) answerElicitation(ctx context.Context, args answerElicitationParams) (*mcp.CallToolResult, error) {
+	if err := elicit.Answer(ctx, args.ID, mcp.ElicitResult{
+		Action:  args.Action,
+		Content: args.Content,
+	}); err != nil {
+		return nil, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{},
+	}, nil
+}
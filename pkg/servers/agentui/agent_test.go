@@ -0,0 +1,210 @@
+package agentui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// restrictedContext returns the context of a UI session of a client whose API key only allows
+// the agent b.
+func restrictedContext(t *testing.T) context.Context {
+	ctx := types.WithNanobotContext(t.Context(), types.Context{Agents: []string{"b"}})
+	session := mcp.NewEmptySession(ctx)
+	session.Set(types.ConfigSessionKey, types.Config{
+		Publish: types.Publish{Entrypoint: []string{"a", "b"}},
+		Agents: map[string]types.Agent{
+			"a": {Name: "A"},
+			"b": {Name: "B"},
+		},
+	})
+	return session.Context()
+}
+
+func TestListAgentsRestricted(t *testing.T) {
+	ctx := restrictedContext(t)
+	s := NewServer(sessiondata.NewData(nil), nil)
+
+	result, err := s.listAgents(ctx, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Agents) != 1 || result.Agents[0].ID != "b" || result.Current != "b" {
+		t.Errorf("listAgents() = %+v", result)
+	}
+
+	// The list cached in the session is filtered too.
+	if result, err = s.listAgents(ctx, struct{}{}); err != nil || len(result.Agents) != 1 {
+		t.Errorf("listAgents() = %+v, %v", result, err)
+	}
+}
+
+func TestSetCurrentAgentRestricted(t *testing.T) {
+	ctx := restrictedContext(t)
+	s := NewServer(sessiondata.NewData(nil), nil)
+	call := setCurrentAgentCall{s: s}
+
+	_, err := call.Invoke(ctx, mcp.Message{}, mcp.CallToolRequest{Arguments: map[string]any{"agent": "a"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown agent a") {
+		t.Errorf("got error %v", err)
+	}
+
+	// An agent selected by an unrestricted client of the session is not used.
+	mcp.SessionFromContext(ctx).Set(types.CurrentAgentSessionKey, mcp.SavedString("a"))
+	if current := s.data.CurrentAgent(ctx); current != "b" {
+		t.Errorf("got current agent %s, want b", current)
+	}
+
+	if _, err := call.Invoke(ctx, mcp.Message{}, mcp.CallToolRequest{Arguments: map[string]any{"agent": "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if current := s.data.CurrentAgent(ctx); current != "b" {
+		t.Errorf("got current agent %s, want b", current)
+	}
+}
+
+type fakeCaller struct {
+	Caller
+	clients []string
+}
+
+func (f *fakeCaller) GetClient(_ context.Context, name string) (*mcp.Client, error) {
+	f.clients = append(f.clients, name)
+	return nil, errors.New("no client")
+}
+
+func TestStop(t *testing.T) {
+	session, err := mcp.NewServerSession(t.Context(), mcp.MessageHandlerFunc(func(context.Context, mcp.Message) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.GetSession().Set(turnAgentSessionKey, "b")
+	caller := &fakeCaller{}
+	s := NewServer(sessiondata.NewData(nil), caller)
+
+	_, err = s.stop(session.GetSession().Context(), stopParams{SessionID: "other"})
+	if err == nil || !strings.Contains(err.Error(), "session other is not the session of the request") {
+		t.Errorf("got error %v", err)
+	}
+	if len(caller.clients) != 0 {
+		t.Errorf("expected no call for another session, got %v", caller.clients)
+	}
+
+	// The stop tool of the agent of the running turn is called.
+	if _, err := s.stop(session.GetSession().Context(), stopParams{SessionID: session.ID()}); err == nil {
+		t.Error("expected the error of the fake client")
+	}
+	if len(caller.clients) != 1 || caller.clients[0] != "b" {
+		t.Errorf("got clients %v, want [b]", caller.clients)
+	}
+
+	// The session of a tool call is a child of the session of the request.
+	child := mcp.NewEmptySession(session.GetSession().Context())
+	child.Parent = session.GetSession()
+	caller.clients = nil
+	_, _ = s.stop(child.Context(), stopParams{SessionID: session.ID()})
+	if len(caller.clients) != 1 {
+		t.Errorf("got clients %v, want one", caller.clients)
+	}
+}
+
+func TestListAgents(t *testing.T) {
+	session := mcp.NewEmptySession(t.Context())
+	session.Set(types.ConfigSessionKey, types.Config{
+		Publish: types.Publish{Entrypoint: []string{"chat", "reader"}},
+		Agents: map[string]types.Agent{
+			"chat":   {Name: "Chat", Model: "gpt-4.1", MCPServers: []string{"search"}},
+			"reader": {Name: "Reader", Model: "gpt-4.1-mini", MimeTypes: []string{"application/pdf"}},
+		},
+	})
+	ctx := session.Context()
+	s := NewServer(sessiondata.NewData(nil), nil)
+
+	result, err := s.listAgents(ctx, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Agents) != 2 || result.Current != "chat" {
+		t.Fatalf("listAgents() = %+v", result)
+	}
+	chat, reader := result.Agents[0], result.Agents[1]
+	if chat.ID != "chat" || chat.Model != "gpt-4.1" || *chat.Capabilities != (types.AgentCapabilities{Vision: true, Tools: true}) {
+		t.Errorf("unexpected agent %+v", chat)
+	}
+	if reader.ID != "reader" || *reader.Capabilities != (types.AgentCapabilities{}) {
+		t.Errorf("unexpected agent %+v", reader)
+	}
+
+	_, err = setCurrentAgentCall{s: s}.Invoke(ctx, mcp.Message{}, mcp.CallToolRequest{Arguments: map[string]any{"agent": "missing"}})
+	var rpcErr *mcp.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected an RPC error, got %v", err)
+	}
+	if string(rpcErr.Data) != `{"agent":"missing","agents":["chat","reader"],"kind":"unknown_agent"}` {
+		t.Errorf("got error data %s", rpcErr.Data)
+	}
+}
+
+func TestChatOtherAgent(t *testing.T) {
+	session := mcp.NewEmptySession(t.Context())
+	session.Set(types.ConfigSessionKey, types.Config{
+		Publish: types.Publish{Entrypoint: []string{"a", "b"}},
+		Agents: map[string]types.Agent{
+			"a": {Name: "A"},
+			"b": {Name: "B"},
+		},
+	})
+	ctx := session.Context()
+	caller := &fakeCaller{}
+	s := NewServer(sessiondata.NewData(nil), caller)
+	call := chatCall{s: s}
+
+	var schema struct {
+		Properties map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(call.Definition().InputSchema, &schema); err != nil || schema.Properties["agent"] == nil {
+		t.Errorf("expected the agent in the input schema, got %v", err)
+	}
+
+	args := map[string]any{"prompt": "Hi", "agent": "b"}
+	if _, err := call.Invoke(ctx, mcp.Message{}, mcp.CallToolRequest{Arguments: args}); err == nil {
+		t.Fatal("expected the error of the fake client")
+	}
+	if len(caller.clients) != 1 || caller.clients[0] != "b" {
+		t.Errorf("got clients %v, want [b]", caller.clients)
+	}
+	if _, ok := args["agent"]; ok {
+		t.Error("expected the agent to be removed from the arguments of the agent")
+	}
+	if current := s.data.CurrentAgent(ctx); current != "a" {
+		t.Errorf("got current agent %s, want a", current)
+	}
+	var agent string
+	if session.Get(turnAgentSessionKey, &agent) {
+		t.Errorf("expected the agent of the turn to be cleared, got %s", agent)
+	}
+
+	caller.clients = nil
+	_, err := call.Invoke(ctx, mcp.Message{}, mcp.CallToolRequest{Arguments: map[string]any{"prompt": "Hi", "agent": "c"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown agent c") || len(caller.clients) != 0 {
+		t.Errorf("got error %v and clients %v", err, caller.clients)
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	s := NewServer(sessiondata.NewData(nil), nil)
+	result, err := s.initialize(t.Context(), mcp.Message{}, mcp.InitializeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clients list the tools again when the tools of a server of the agents change.
+	if result.Capabilities.Tools == nil || !result.Capabilities.Tools.ListChanged {
+		t.Errorf("expected tools/list_changed to be advertised, got %+v", result.Capabilities.Tools)
+	}
+}
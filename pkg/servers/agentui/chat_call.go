@@ -2,14 +2,36 @@ package agentui
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
+	"github.com/nanobot-ai/nanobot/pkg/elicit"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/servers/agent"
 	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uifeatures"
 )
 
+// turnAgentSessionKey is the agent that runs the current turn when it is not the current agent.
+const turnAgentSessionKey = "agentui/turnAgent"
+
+var chatInputSchema = func() json.RawMessage {
+	var schema map[string]any
+	if err := json.Unmarshal(types.ChatInputSchema, &schema); err != nil {
+		panic(fmt.Sprintf("failed to unmarshal chat input schema: %v", err))
+	}
+	schema["properties"].(map[string]any)["agent"] = map[string]any{
+		"description": "The ID of an agent from list_agents that answers this message instead of the current agent, which stays selected (optional)",
+		"type":        "string",
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal chat input schema: %v", err))
+	}
+	return data
+}()
+
 type chatCall struct {
 	s *Server
 }
@@ -18,7 +40,7 @@ func (c chatCall) Definition() mcp.Tool {
 	return mcp.Tool{
 		Name:        types.AgentTool + "_ui",
 		Description: types.AgentToolDescription,
-		InputSchema: types.ChatInputSchema,
+		InputSchema: chatInputSchema,
 	}
 }
 
@@ -67,7 +89,7 @@ attachmentsLoop:
 
 		clientName := c.s.data.CurrentAgent(ctx)
 		if strings.HasPrefix(uri, "nanobot://") {
-			clientName = "nanobot.resources"
+			clientName = resourcesServer
 		}
 
 		client, err := c.s.runtime.GetClient(ctx, clientName)
@@ -95,22 +117,38 @@ attachmentsLoop:
 }
 
 func (c chatCall) Invoke(ctx context.Context, msg mcp.Message, payload mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	description := c.s.describeSession(ctx, payload.Arguments)
 	currentAgent := c.s.data.CurrentAgent(ctx)
+	if agent, _ := payload.Arguments["agent"].(string); agent != "" && agent != currentAgent {
+		// The message goes to another agent, the current agent stays selected for the next ones.
+		if err := c.s.checkAgent(ctx, agent); err != nil {
+			return nil, err
+		}
+		session := mcp.SessionFromContext(ctx)
+		session.Set(turnAgentSessionKey, agent)
+		defer session.Delete(turnAgentSessionKey)
+		currentAgent = agent
+	}
+	delete(payload.Arguments, "agent")
 
-	c.s.data.CurrentAgent(ctx)
+	description := c.s.describeSession(ctx, payload.Arguments)
 	client, err := c.s.runtime.GetClient(ctx, currentAgent)
 	if err != nil {
 		return nil, err
 	}
 
 	if attachments, _ := payload.Arguments["attachments"].([]any); len(attachments) > 0 {
+		if !uifeatures.Supported(ctx, uifeatures.Attachments) {
+			return nil, mcp.ErrRPCInvalidParams.WithMessage("attachments require the %s feature, which the client did not negotiate", uifeatures.Attachments)
+		}
 		payload.Arguments["attachments"], err = c.inlineAttachments(ctx, attachments)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Questions of servers during the turn are sent as forms in its progress.
+	defer elicit.StartTurn(ctx, msg.ProgressToken())()
+
 	result, err := client.Call(ctx, types.AgentTool, payload.Arguments, mcp.CallOption{
 		ProgressToken: msg.ProgressToken(),
 		Meta:          payload.Meta,
@@ -0,0 +1,47 @@
+package agentui
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// listPrompts lists the prompt templates of the config.
+func (s *Server) listPrompts(ctx context.Context, _ mcp.Message, _ mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	config := types.ConfigFromContext(ctx)
+	result := &mcp.ListPromptsResult{
+		Prompts: []mcp.Prompt{},
+	}
+	for _, name := range slices.Sorted(maps.Keys(config.Prompts)) {
+		result.Prompts = append(result.Prompts, config.Prompts[name].ToPrompt(name))
+	}
+	return result, nil
+}
+
+// getPrompt renders a prompt template of the config with the arguments, all required arguments
+// must be given.
+func (s *Server) getPrompt(ctx context.Context, _ mcp.Message, payload mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	prompt, ok := types.ConfigFromContext(ctx).Prompts[payload.Name]
+	if !ok {
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("prompt %s not found", payload.Name)
+	}
+
+	definition := prompt.ToPrompt(payload.Name)
+	for _, arg := range definition.Arguments {
+		if _, ok := payload.Arguments[arg.Name]; arg.Required && !ok {
+			return nil, mcp.ErrRPCInvalidParams.WithMessage("prompt %s requires the argument %s", payload.Name, arg.Name)
+		}
+	}
+
+	result, err := s.runtime.GetPrompt(ctx, payload.Name, payload.Name, payload.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	if result.Description == "" {
+		result.Description = definition.Description
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package agentui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+type promptCaller struct {
+	Caller
+	args map[string]string
+}
+
+func (p *promptCaller) GetPrompt(_ context.Context, _, _ string, args map[string]string) (*mcp.GetPromptResult, error) {
+	p.args = args
+	return &mcp.GetPromptResult{
+		Messages: []mcp.PromptMessage{{Role: "user", Content: mcp.Content{Type: "text", Text: "Review " + args["file"]}}},
+	}, nil
+}
+
+func TestPrompts(t *testing.T) {
+	optional := false
+	session := mcp.NewEmptySession(t.Context())
+	session.Set(types.ConfigSessionKey, types.Config{
+		Prompts: map[string]types.Prompt{
+			"summarize": {Template: "Summarize the conversation"},
+			"review": {
+				Description: "Review a file",
+				Input: map[string]types.Field{
+					"file":  {Description: "The file to review"},
+					"focus": {Description: "What to look at", Required: &optional},
+				},
+				Template: "Review ${file}",
+			},
+		},
+	})
+	ctx := session.Context()
+	caller := &promptCaller{}
+	s := NewServer(sessiondata.NewData(nil), caller)
+
+	list, err := s.listPrompts(ctx, mcp.Message{}, mcp.ListPromptsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Prompts) != 2 || list.Prompts[0].Name != "review" || list.Prompts[1].Name != "summarize" {
+		t.Fatalf("listPrompts() = %+v", list.Prompts)
+	}
+	if args := list.Prompts[0].Arguments; len(args) != 2 || args[0].Name != "file" || !args[0].Required || args[1].Required {
+		t.Errorf("unexpected arguments %+v", args)
+	}
+
+	_, err = s.getPrompt(ctx, mcp.Message{}, mcp.GetPromptRequest{Name: "review", Arguments: map[string]string{"focus": "errors"}})
+	if err == nil || !strings.Contains(err.Error(), "prompt review requires the argument file") || caller.args != nil {
+		t.Errorf("got error %v", err)
+	}
+	if _, err := s.getPrompt(ctx, mcp.Message{}, mcp.GetPromptRequest{Name: "missing"}); err == nil {
+		t.Error("expected an error for a missing prompt")
+	}
+
+	result, err := s.getPrompt(ctx, mcp.Message{}, mcp.GetPromptRequest{Name: "review", Arguments: map[string]string{"file": "main.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Description != "Review a file" || result.Messages[0].Content.Text != "Review main.go" || caller.args["file"] != "main.go" {
+		t.Errorf("getPrompt() = %+v", result)
+	}
+}
@@ -0,0 +1,73 @@
+package agentui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/servers/outputs"
+)
+
+const (
+	// resourcesServer keeps the attachments of the user and the files generated by tools.
+	resourcesServer    = "nanobot.resources"
+	resourcesURIPrefix = "nanobot://resource/"
+)
+
+// listResources lists the artifacts of the session: the attachments and generated files of the
+// resources server followed by the full outputs of tool calls that were truncated. Without a
+// database there is no resources server and only the outputs are listed.
+func (s *Server) listResources(ctx context.Context, _ mcp.Message, _ mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	result := &mcp.ListResourcesResult{
+		Resources: []mcp.Resource{},
+	}
+
+	if client, err := s.runtime.GetClient(ctx, resourcesServer); err != nil {
+		log.Debugf(ctx, "not listing the resources of %s: %v", resourcesServer, err)
+	} else {
+		files, err := client.ListResources(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources of %s: %w", resourcesServer, err)
+		}
+		result.Resources = append(result.Resources, files.Resources...)
+	}
+
+	result.Resources = append(result.Resources, outputs.List(ctx)...)
+	return result, nil
+}
+
+func (s *Server) readResource(ctx context.Context, _ mcp.Message, body mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	switch {
+	case strings.HasPrefix(body.URI, outputs.URIPrefix):
+		return outputs.Read(ctx, body.URI)
+	case strings.HasPrefix(body.URI, resourcesURIPrefix):
+		client, err := s.runtime.GetClient(ctx, resourcesServer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get client for %s: %w", resourcesServer, err)
+		}
+		return client.ReadResource(ctx, body.URI)
+	default:
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("resource %s is not an artifact of the session", body.URI)
+	}
+}
+
+// subscribe records the subscription of the client to an artifact, so notifications about it are
+// passed on to the client. The artifact must exist.
+func (s *Server) subscribe(ctx context.Context, msg mcp.Message, body mcp.SubscribeRequest) (*mcp.SubscribeResult, error) {
+	if _, err := s.readResource(ctx, msg, mcp.ReadResourceRequest{URI: body.URI}); err != nil {
+		return nil, err
+	}
+	if err := s.data.SubscribeToResources(ctx, body.URI); err != nil {
+		return nil, err
+	}
+	return &mcp.SubscribeResult{}, nil
+}
+
+func (s *Server) unsubscribe(ctx context.Context, _ mcp.Message, body mcp.UnsubscribeRequest) (*mcp.UnsubscribeResult, error) {
+	if err := s.data.UnsubscribeFromResources(ctx, body.URI); err != nil {
+		return nil, err
+	}
+	return &mcp.UnsubscribeResult{}, nil
+}
@@ -0,0 +1,65 @@
+package agentui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/servers/outputs"
+	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestResources(t *testing.T) {
+	session := mcp.NewEmptySession(t.Context())
+	ctx := session.Context()
+	// Without a database there is no resources server, the fake client fails like it.
+	s := NewServer(sessiondata.NewData(nil), &fakeCaller{})
+
+	truncated := outputs.Truncate(ctx, "search", &types.CallResult{
+		Content: []mcp.Content{{Type: "text", Text: strings.Repeat("result ", 20)}},
+	}, 10)
+	uri := truncated.Content[2].URI
+
+	list, err := s.listResources(ctx, mcp.Message{}, mcp.ListResourcesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 1 || list.Resources[0].URI != uri {
+		t.Fatalf("listResources() = %+v", list.Resources)
+	}
+
+	read, err := s.readResource(ctx, mcp.Message{}, mcp.ReadResourceRequest{URI: uri})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Contents) != 1 || read.Contents[0].Text != strings.Repeat("result ", 20) {
+		t.Errorf("readResource() = %+v", read.Contents)
+	}
+	if _, err := s.readResource(ctx, mcp.Message{}, mcp.ReadResourceRequest{URI: "file:///etc/passwd"}); err == nil ||
+		!strings.Contains(err.Error(), "is not an artifact of the session") {
+		t.Errorf("got error %v", err)
+	}
+	if _, err := s.readResource(ctx, mcp.Message{}, mcp.ReadResourceRequest{URI: "nanobot://resource/1"}); err == nil {
+		t.Error("expected an error without a resources server")
+	}
+
+	if _, err := s.subscribe(ctx, mcp.Message{}, mcp.SubscribeRequest{URI: outputs.URIPrefix + "missing"}); err == nil {
+		t.Error("expected subscribing to a missing output to fail")
+	}
+	if _, err := s.subscribe(ctx, mcp.Message{}, mcp.SubscribeRequest{URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+	var subscriptions map[string]struct{}
+	if !session.Get(types.ResourceSubscriptionsSessionKey, &subscriptions) || len(subscriptions) != 1 {
+		t.Errorf("expected a subscription to %s, got %v", uri, subscriptions)
+	}
+
+	if _, err := s.unsubscribe(ctx, mcp.Message{}, mcp.UnsubscribeRequest{URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+	subscriptions = nil
+	if session.Get(types.ResourceSubscriptionsSessionKey, &subscriptions) && len(subscriptions) != 0 {
+		t.Errorf("expected no subscriptions, got %v", subscriptions)
+	}
+}
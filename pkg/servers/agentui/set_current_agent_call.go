@@ -32,7 +32,7 @@ type setCurrentAgentCall struct {
 func (c setCurrentAgentCall) Definition() mcp.Tool {
 	return mcp.Tool{
 		Name:        "set_current_agent",
-		Description: "Set the current agent the user is chatting with",
+		Description: "Set the current agent the user is chatting with, one of the IDs returned by list_agents",
 		InputSchema: setCurrentAgentInputSchema,
 	}
 }
@@ -57,6 +57,11 @@ func (c setCurrentAgentCall) Invoke(ctx context.Context, msg mcp.Message, payloa
 
 func (c setCurrentAgentCall) setRemote(ctx context.Context, _ mcp.Message, payload mcp.CallToolRequest) (*types.CallResult, error) {
 	agentName, _ := payload.Arguments["agent"].(string)
+	if agentName != "" {
+		if err := c.s.checkAgent(ctx, agentName); err != nil {
+			return nil, err
+		}
+	}
 	if err := c.s.data.SetCurrentAgent(ctx, agentName); err != nil {
 		return nil, err
 	}
@@ -1,16 +1,13 @@
 package meta
 
 import (
-	"context"
-
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
-	"github.com/nanobot-ai/nanobot/pkg/version"
 )
 
 type Server struct {
-	tools mcp.ServerTools
-	data  *sessiondata.Data
+	mcp.ServerHandler
+	data *sessiondata.Data
 }
 
 func NewServer(data *sessiondata.Data) *Server {
@@ -18,7 +15,7 @@ func NewServer(data *sessiondata.Data) *Server {
 		data: data,
 	}
 
-	s.tools = mcp.NewServerTools(
+	s.Tools = mcp.NewServerTools(
 		mcp.NewServerTool("list_chats", "Returns all previous chat threads", s.listChats),
 		mcp.NewServerTool("update_chat", "Update fields of a give chat thread", s.updateChat),
 		mcp.NewServerTool("create_chat", "Create a new chat thread", s.createChat),
@@ -30,31 +27,3 @@ func NewServer(data *sessiondata.Data) *Server {
 
 	return s
 }
-
-func (s *Server) OnMessage(ctx context.Context, msg mcp.Message) {
-	switch msg.Method {
-	case "initialize":
-		mcp.Invoke(ctx, msg, s.initialize)
-	case "notifications/initialized":
-		// nothing to do
-	case "tools/list":
-		mcp.Invoke(ctx, msg, s.tools.List)
-	case "tools/call":
-		mcp.Invoke(ctx, msg, s.tools.Call)
-	default:
-		msg.SendError(ctx, mcp.ErrRPCMethodNotFound.WithMessage(msg.Method))
-	}
-}
-
-func (s *Server) initialize(_ context.Context, _ mcp.Message, params mcp.InitializeRequest) (*mcp.InitializeResult, error) {
-	return &mcp.InitializeResult{
-		ProtocolVersion: params.ProtocolVersion,
-		Capabilities: mcp.ServerCapabilities{
-			Tools: &mcp.ToolsServerCapability{},
-		},
-		ServerInfo: mcp.ServerInfo{
-			Name:    version.Name,
-			Version: version.Get().String(),
-		},
-	}, nil
-}
@@ -3,6 +3,7 @@ package meta
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/session"
@@ -19,7 +20,15 @@ func (s *Server) deleteChat(ctx context.Context, data struct {
 		return nil, err
 	}
 
-	chatSession, err := manager.DB.GetByIDByAccountID(ctx, data.ID, accountID)
+	if manager.DeleteEphemeral(ctx, data.ID, accountID) {
+		return &types.Chat{
+			ID:         data.ID,
+			Visibility: visibility(false),
+			Ephemeral:  true,
+		}, nil
+	}
+
+	chatSession, err := getChatSession(ctx, manager, data.ID, accountID)
 	if err != nil {
 		return nil, err
 	}
@@ -32,15 +41,26 @@ func (s *Server) deleteChat(ctx context.Context, data struct {
 	return &chat, nil
 }
 
-func (s *Server) createChat(ctx context.Context, _ struct{}) (*types.Chat, error) {
+func (s *Server) createChat(ctx context.Context, data struct {
+	Ephemeral bool `json:"ephemeral,omitempty" jsonschema:"If true the chat is never written to the store or logs, it is only kept in memory and in the session Redis if configured"`
+}) (*types.Chat, error) {
 	mcpSession := mcp.SessionFromContext(ctx)
-	var (
-		manager   session.Manager
-		accountID string
-	)
+	manager, accountID, err := s.getManagerAndAccountID(mcpSession)
+	if err != nil {
+		return nil, err
+	}
 
-	if !mcpSession.Get(session.ManagerSessionKey, &manager) || !mcpSession.Get(types.AccountIDSessionKey, &accountID) {
-		return nil, mcp.ErrRPCInvalidParams.WithMessage("session store or account not found")
+	if data.Ephemeral {
+		id := uuid.String()
+		if err := manager.CreateEphemeral(ctx, id, accountID); err != nil {
+			return nil, err
+		}
+		return &types.Chat{
+			ID:         id,
+			Created:    time.Now(),
+			Visibility: visibility(false),
+			Ephemeral:  true,
+		}, nil
 	}
 
 	var newSession = session.Session{
@@ -80,7 +100,7 @@ func (s *Server) updateChat(ctx context.Context, data struct {
 		return nil, err
 	}
 
-	chatSession, err := manager.DB.GetByIDByAccountID(ctx, data.ID, accountID)
+	chatSession, err := getChatSession(ctx, manager, data.ID, accountID)
 	if err != nil {
 		return nil, err
 	}
@@ -104,14 +124,23 @@ func (s *Server) updateChat(ctx context.Context, data struct {
 
 func (s *Server) getManagerAndAccountID(mcpSession *mcp.Session) (*session.Manager, string, error) {
 	var (
-		manager   session.Manager
+		manager   *session.Manager
 		accountID string
 	)
 
 	if !mcpSession.Get(session.ManagerSessionKey, &manager) || !mcpSession.Get(types.AccountIDSessionKey, &accountID) {
 		return nil, "", mcp.ErrRPCInvalidParams.WithMessage("session store or account not found")
 	}
-	return &manager, accountID, nil
+	return manager, accountID, nil
+}
+
+// getChatSession returns the session of a chat of the account. Admins can get the chats of all
+// accounts.
+func getChatSession(ctx context.Context, manager *session.Manager, id, accountID string) (*session.Session, error) {
+	if types.NanobotContext(ctx).Admin {
+		return manager.DB.Get(ctx, id)
+	}
+	return manager.DB.GetByIDByAccountID(ctx, id, accountID)
 }
 
 func (s *Server) listAgents(ctx context.Context, _ struct{}) (*types.AgentList, error) {
@@ -124,7 +153,9 @@ func (s *Server) listAgents(ctx context.Context, _ struct{}) (*types.AgentList,
 	}, nil
 }
 
-func (s *Server) listChats(ctx context.Context, _ struct{}) (*types.ChatList, error) {
+func (s *Server) listChats(ctx context.Context, data struct {
+	All bool `json:"all,omitempty" jsonschema:"If true the chats of all users are listed, only allowed for admins"`
+}) (*types.ChatList, error) {
 	mcpSession := mcp.SessionFromContext(ctx)
 
 	manager, accountID, err := s.getManagerAndAccountID(mcpSession)
@@ -132,7 +163,15 @@ func (s *Server) listChats(ctx context.Context, _ struct{}) (*types.ChatList, er
 		return nil, err
 	}
 
-	sessions, err := manager.DB.FindByAccount(ctx, "thread", accountID)
+	var sessions []session.Session
+	if data.All {
+		if !types.NanobotContext(ctx).Admin {
+			return nil, mcp.ErrRPCInvalidParams.WithMessage("only admins can list the chats of all users")
+		}
+		sessions, err = manager.DB.FindByType(ctx, "thread")
+	} else {
+		sessions, err = manager.DB.FindByAccount(ctx, "thread", accountID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -148,13 +187,17 @@ func (s *Server) listChats(ctx context.Context, _ struct{}) (*types.ChatList, er
 }
 
 func chatFromSession(session *session.Session, currentAccountID string) types.Chat {
-	return types.Chat{
+	chat := types.Chat{
 		ID:         session.SessionID,
 		Title:      session.Description,
 		Created:    session.CreatedAt,
 		ReadOnly:   session.AccountID != currentAccountID,
 		Visibility: visibility(session.IsPublic),
 	}
+	if summary := types.SessionSummary(session.Summary); !summary.IsZero() {
+		chat.Summary = &summary
+	}
+	return chat
 }
 
 func visibility(isPublic bool) string {
@@ -187,6 +230,9 @@ func (s *Server) clone(ctx context.Context, _ struct{}) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to get session: %w", err)
 	}
+	if err := manager.DB.ExpandHistory(ctx, stored); err != nil {
+		return "", err
+	}
 
 	stored = stored.Clone(accountID)
 	if err := manager.DB.Create(ctx, stored); err != nil {
@@ -0,0 +1,256 @@
+package office
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleCalendarURL = "https://www.googleapis.com/calendar/v3"
+	gmailURL          = "https://gmail.googleapis.com/gmail/v1/users/me"
+)
+
+// google uses Google Calendar and Gmail. The refresh token needs the calendar.readonly and
+// gmail.compose scopes.
+type google struct {
+	http        *http.Client
+	calendarURL string
+	gmailURL    string
+}
+
+func newGoogle(ctx context.Context, env map[string]string) (provider, error) {
+	client, err := httpClient(ctx, oauth2.Config{
+		ClientID:     env["GOOGLE_CLIENT_ID"],
+		ClientSecret: env["GOOGLE_CLIENT_SECRET"],
+		Endpoint: oauth2.Endpoint{
+			TokenURL: googleTokenURL,
+		},
+	}, env["GOOGLE_ACCESS_TOKEN"], env["GOOGLE_REFRESH_TOKEN"])
+	if err != nil {
+		return nil, err
+	}
+	return &google{
+		http:        client,
+		calendarURL: googleCalendarURL,
+		gmailURL:    gmailURL,
+	}, nil
+}
+
+func (g *google) call(ctx context.Context, method, url string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Google: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var googleErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&googleErr)
+		return fmt.Errorf("%s %s failed: %s: %s", method, req.URL.Path, resp.Status, googleErr.Error.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type googleTime struct {
+	DateTime time.Time `json:"dateTime,omitzero"`
+	// Date is set instead of DateTime for all-day events.
+	Date string `json:"date,omitempty"`
+}
+
+func (t googleTime) time() time.Time {
+	if !t.DateTime.IsZero() {
+		return t.DateTime
+	}
+	date, _ := time.Parse(time.DateOnly, t.Date)
+	return date
+}
+
+func (g *google) events(ctx context.Context, calendar string, start, end time.Time) ([]Event, error) {
+	if calendar == "" {
+		calendar = "primary"
+	}
+
+	var result struct {
+		Items []struct {
+			ID        string     `json:"id"`
+			Summary   string     `json:"summary"`
+			Location  string     `json:"location"`
+			HTMLLink  string     `json:"htmlLink"`
+			Start     googleTime `json:"start"`
+			End       googleTime `json:"end"`
+			Organizer struct {
+				Email string `json:"email"`
+			} `json:"organizer"`
+			Attendees []struct {
+				Email string `json:"email"`
+			} `json:"attendees"`
+		} `json:"items"`
+	}
+	query := url.Values{
+		"timeMin":      {start.Format(time.RFC3339)},
+		"timeMax":      {end.Format(time.RFC3339)},
+		"singleEvents": {"true"},
+		"orderBy":      {"startTime"},
+		"maxResults":   {"250"},
+	}
+	if err := g.call(ctx, http.MethodGet, g.calendarURL+"/calendars/"+url.PathEscape(calendar)+"/events?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(result.Items))
+	for _, item := range result.Items {
+		event := Event{
+			ID:        item.ID,
+			Title:     item.Summary,
+			Start:     item.Start.time(),
+			End:       item.End.time(),
+			AllDay:    item.Start.Date != "",
+			Location:  item.Location,
+			Organizer: item.Organizer.Email,
+			URL:       item.HTMLLink,
+		}
+		for _, attendee := range item.Attendees {
+			event.Attendees = append(event.Attendees, attendee.Email)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (g *google) busy(ctx context.Context, attendees []string, start, end time.Time) ([]Slot, error) {
+	items := []map[string]string{{"id": "primary"}}
+	for _, attendee := range attendees {
+		items = append(items, map[string]string{"id": attendee})
+	}
+
+	var result struct {
+		Calendars map[string]struct {
+			Busy   []Slot `json:"busy"`
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"calendars"`
+	}
+	if err := g.call(ctx, http.MethodPost, g.calendarURL+"/freeBusy", map[string]any{
+		"timeMin": start.Format(time.RFC3339),
+		"timeMax": end.Format(time.RFC3339),
+		"items":   items,
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	var busy []Slot
+	for id, calendar := range result.Calendars {
+		if len(calendar.Errors) > 0 {
+			return nil, fmt.Errorf("the calendar of %s is not available: %s", id, calendar.Errors[0].Reason)
+		}
+		busy = append(busy, calendar.Busy...)
+	}
+	return busy, nil
+}
+
+func (g *google) draft(ctx context.Context, draft Draft) (string, error) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(draft.To, ", "))
+	if len(draft.Cc) > 0 {
+		fmt.Fprintf(&buf, "Cc: %s\r\n", strings.Join(draft.Cc, ", "))
+	}
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", draft.Subject))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString([]byte(draft.Body)))
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := g.call(ctx, http.MethodPost, g.gmailURL+"/drafts", map[string]any{
+		"message": map[string]any{
+			"raw": base64.URLEncoding.EncodeToString([]byte(buf.String())),
+		},
+	}, &result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+func (g *google) getDraft(ctx context.Context, draftID string) (*Draft, error) {
+	var result struct {
+		ID      string `json:"id"`
+		Message struct {
+			Payload struct {
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+			} `json:"payload"`
+		} `json:"message"`
+	}
+	if err := g.call(ctx, http.MethodGet, g.gmailURL+"/drafts/"+url.PathEscape(draftID)+"?format=metadata", nil, &result); err != nil {
+		return nil, err
+	}
+
+	draft := &Draft{ID: result.ID}
+	for _, header := range result.Message.Payload.Headers {
+		switch strings.ToLower(header.Name) {
+		case "to":
+			draft.To = splitAddresses(header.Value)
+		case "cc":
+			draft.Cc = splitAddresses(header.Value)
+		case "subject":
+			draft.Subject = header.Value
+		}
+	}
+	return draft, nil
+}
+
+func (g *google) send(ctx context.Context, draftID string) error {
+	return g.call(ctx, http.MethodPost, g.gmailURL+"/drafts/send", map[string]any{
+		"id": draftID,
+	}, nil)
+}
+
+func splitAddresses(value string) (result []string) {
+	for _, address := range strings.Split(value, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			result = append(result, address)
+		}
+	}
+	return result
+}
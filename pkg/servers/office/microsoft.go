@@ -0,0 +1,275 @@
+package office
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	graphURL = "https://graph.microsoft.com/v1.0"
+	// graphTimeLayout is the layout of the dateTime of Graph, which has no offset.
+	graphTimeLayout = "2006-01-02T15:04:05.9999999"
+)
+
+// microsoft uses the calendar and mailbox of Microsoft Graph. The refresh token needs the
+// Calendars.Read, Mail.ReadWrite, and Mail.Send scopes.
+type microsoft struct {
+	http *http.Client
+	url  string
+}
+
+func newMicrosoft(ctx context.Context, env map[string]string) (provider, error) {
+	tenant := env["MICROSOFT_TENANT_ID"]
+	if tenant == "" {
+		tenant = "common"
+	}
+	client, err := httpClient(ctx, oauth2.Config{
+		ClientID:     env["MICROSOFT_CLIENT_ID"],
+		ClientSecret: env["MICROSOFT_CLIENT_SECRET"],
+		Endpoint: oauth2.Endpoint{
+			TokenURL: "https://login.microsoftonline.com/" + url.PathEscape(tenant) + "/oauth2/v2.0/token",
+		},
+	}, env["MICROSOFT_ACCESS_TOKEN"], env["MICROSOFT_REFRESH_TOKEN"])
+	if err != nil {
+		return nil, err
+	}
+	return &microsoft{
+		http: client,
+		url:  graphURL,
+	}, nil
+}
+
+func (m *microsoft) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, m.url+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// Times are returned in UTC, which the dateTime of Graph does not say.
+	req.Header.Set("Prefer", `outlook.timezone="UTC"`)
+
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Microsoft Graph: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var graphErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&graphErr)
+		return fmt.Errorf("%s %s failed: %s: %s", method, req.URL.Path, resp.Status, graphErr.Error.Message)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type graphTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+func newGraphTime(t time.Time) graphTime {
+	return graphTime{
+		DateTime: t.UTC().Format(graphTimeLayout),
+		TimeZone: "UTC",
+	}
+}
+
+func (t graphTime) time() time.Time {
+	result, _ := time.ParseInLocation(graphTimeLayout, t.DateTime, time.UTC)
+	return result
+}
+
+type graphRecipient struct {
+	EmailAddress struct {
+		Address string `json:"address"`
+	} `json:"emailAddress"`
+}
+
+func graphRecipients(addresses []string) []graphRecipient {
+	result := make([]graphRecipient, 0, len(addresses))
+	for _, address := range addresses {
+		var recipient graphRecipient
+		recipient.EmailAddress.Address = address
+		result = append(result, recipient)
+	}
+	return result
+}
+
+func addresses(recipients []graphRecipient) (result []string) {
+	for _, recipient := range recipients {
+		result = append(result, recipient.EmailAddress.Address)
+	}
+	return result
+}
+
+func (m *microsoft) events(ctx context.Context, calendar string, start, end time.Time) ([]Event, error) {
+	path := "/me/calendarView"
+	if calendar != "" {
+		path = "/me/calendars/" + url.PathEscape(calendar) + "/calendarView"
+	}
+	query := url.Values{
+		"startDateTime": {start.UTC().Format(time.RFC3339)},
+		"endDateTime":   {end.UTC().Format(time.RFC3339)},
+		"$orderby":      {"start/dateTime"},
+		"$top":          {"250"},
+	}
+
+	var result struct {
+		Value []struct {
+			ID       string    `json:"id"`
+			Subject  string    `json:"subject"`
+			WebLink  string    `json:"webLink"`
+			IsAllDay bool      `json:"isAllDay"`
+			Start    graphTime `json:"start"`
+			End      graphTime `json:"end"`
+			Location struct {
+				DisplayName string `json:"displayName"`
+			} `json:"location"`
+			Organizer graphRecipient   `json:"organizer"`
+			Attendees []graphRecipient `json:"attendees"`
+		} `json:"value"`
+	}
+	if err := m.call(ctx, http.MethodGet, path+"?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(result.Value))
+	for _, item := range result.Value {
+		events = append(events, Event{
+			ID:        item.ID,
+			Title:     item.Subject,
+			Start:     item.Start.time(),
+			End:       item.End.time(),
+			AllDay:    item.IsAllDay,
+			Location:  item.Location.DisplayName,
+			Organizer: item.Organizer.EmailAddress.Address,
+			Attendees: addresses(item.Attendees),
+			URL:       item.WebLink,
+		})
+	}
+	return events, nil
+}
+
+func (m *microsoft) busy(ctx context.Context, attendees []string, start, end time.Time) ([]Slot, error) {
+	// getSchedule only returns the schedules of the given addresses, which must include the user.
+	var me struct {
+		Mail              string `json:"mail"`
+		UserPrincipalName string `json:"userPrincipalName"`
+	}
+	if err := m.call(ctx, http.MethodGet, "/me?$select=mail,userPrincipalName", nil, &me); err != nil {
+		return nil, err
+	}
+	self := me.Mail
+	if self == "" {
+		self = me.UserPrincipalName
+	}
+
+	var result struct {
+		Value []struct {
+			ScheduleID    string `json:"scheduleId"`
+			ScheduleItems []struct {
+				Status string    `json:"status"`
+				Start  graphTime `json:"start"`
+				End    graphTime `json:"end"`
+			} `json:"scheduleItems"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"value"`
+	}
+	if err := m.call(ctx, http.MethodPost, "/me/calendar/getSchedule", map[string]any{
+		"schedules":                append([]string{self}, attendees...),
+		"startTime":                newGraphTime(start),
+		"endTime":                  newGraphTime(end),
+		"availabilityViewInterval": int(slotStep.Minutes()),
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	var busy []Slot
+	for _, schedule := range result.Value {
+		if schedule.Error != nil {
+			return nil, fmt.Errorf("the calendar of %s is not available: %s", schedule.ScheduleID, schedule.Error.Message)
+		}
+		for _, item := range schedule.ScheduleItems {
+			if item.Status == "free" {
+				continue
+			}
+			busy = append(busy, Slot{
+				Start: item.Start.time(),
+				End:   item.End.time(),
+			})
+		}
+	}
+	return busy, nil
+}
+
+func (m *microsoft) draft(ctx context.Context, draft Draft) (string, error) {
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := m.call(ctx, http.MethodPost, "/me/messages", map[string]any{
+		"subject": draft.Subject,
+		"body": map[string]string{
+			"contentType": "Text",
+			"content":     draft.Body,
+		},
+		"toRecipients": graphRecipients(draft.To),
+		"ccRecipients": graphRecipients(draft.Cc),
+	}, &result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+func (m *microsoft) getDraft(ctx context.Context, draftID string) (*Draft, error) {
+	var result struct {
+		ID           string           `json:"id"`
+		Subject      string           `json:"subject"`
+		IsDraft      bool             `json:"isDraft"`
+		ToRecipients []graphRecipient `json:"toRecipients"`
+		CcRecipients []graphRecipient `json:"ccRecipients"`
+	}
+	if err := m.call(ctx, http.MethodGet, "/me/messages/"+url.PathEscape(draftID)+"?$select=subject,isDraft,toRecipients,ccRecipients", nil, &result); err != nil {
+		return nil, err
+	}
+	if !result.IsDraft {
+		return nil, fmt.Errorf("message %s is not a draft", draftID)
+	}
+	return &Draft{
+		ID:      result.ID,
+		To:      addresses(result.ToRecipients),
+		Cc:      addresses(result.CcRecipients),
+		Subject: result.Subject,
+	}, nil
+}
+
+func (m *microsoft) send(ctx context.Context, draftID string) error {
+	return m.call(ctx, http.MethodPost, "/me/messages/"+url.PathEscape(draftID)+"/send", nil, nil)
+}
@@ -0,0 +1,53 @@
+package office
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWorkingHours(t *testing.T) {
+	hours, err := parseWorkingHours("Europe/Berlin", "08:30-16:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hours.location.String() != "Europe/Berlin" || hours.start != 8*time.Hour+30*time.Minute || hours.end != 16*time.Hour {
+		t.Fatalf("unexpected working hours: %+v", hours)
+	}
+
+	for _, invalid := range []string{"9-5", "17:00-09:00", "09:00"} {
+		if _, err := parseWorkingHours("", invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestFreeSlots(t *testing.T) {
+	hours, err := parseWorkingHours("", "09:00-12:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Friday 2025-01-03 10:10 until Monday 2025-01-06 23:00.
+	start := time.Date(2025, 1, 3, 10, 10, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 6, 23, 0, 0, 0, time.UTC)
+	busy := []Slot{
+		{Start: time.Date(2025, 1, 3, 10, 45, 0, 0, time.UTC), End: time.Date(2025, 1, 3, 11, 15, 0, 0, time.UTC)},
+		{Start: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC), End: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)},
+	}
+
+	slots := freeSlots(busy, start, end, time.Hour, hours, 3)
+	expected := []time.Time{
+		// 10:30 overlaps the busy time, which ends at 11:15 and is rounded up to 11:30. The slot
+		// would end after the working hours.
+		time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 6, 11, 0, 0, 0, time.UTC),
+	}
+	if len(slots) != len(expected) {
+		t.Fatalf("expected %d slots, got %v", len(expected), slots)
+	}
+	for i, slot := range slots {
+		if !slot.Start.Equal(expected[i]) || slot.End.Sub(slot.Start) != time.Hour {
+			t.Errorf("slot %d: expected start %s, got %s-%s", i, expected[i], slot.Start, slot.End)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Package office implements the built-in nanobot.google and nanobot.microsoft MCP servers, which
+// read calendars, propose meeting slots, and draft emails with Google Calendar and Gmail, or with
+// Microsoft Graph. Drafts are only sent when the user approves. The servers are configured with the
+// env of their entry in the mcpServers of the config, secrets are best referenced from the env of
+// nanobot:
+//
+//	mcpServers:
+//	  nanobot.google:
+//	    env:
+//	      GOOGLE_CLIENT_ID: ${GOOGLE_CLIENT_ID}
+//	      GOOGLE_CLIENT_SECRET: ${GOOGLE_CLIENT_SECRET}
+//	      GOOGLE_REFRESH_TOKEN: ${GOOGLE_REFRESH_TOKEN}
+//	      TIMEZONE: Europe/Berlin
+//	  nanobot.microsoft:
+//	    env:
+//	      MICROSOFT_TENANT_ID: ${MICROSOFT_TENANT_ID}
+//	      MICROSOFT_CLIENT_ID: ${MICROSOFT_CLIENT_ID}
+//	      MICROSOFT_CLIENT_SECRET: ${MICROSOFT_CLIENT_SECRET}
+//	      MICROSOFT_REFRESH_TOKEN: ${MICROSOFT_REFRESH_TOKEN}
+//
+// Instead of a refresh token, an access token can be set with GOOGLE_ACCESS_TOKEN or
+// MICROSOFT_ACCESS_TOKEN.
+package office
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/confirm"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"golang.org/x/oauth2"
+)
+
+const (
+	GoogleServerName    = "nanobot.google"
+	MicrosoftServerName = "nanobot.microsoft"
+
+	defaultRange    = 7 * 24 * time.Hour
+	maxRange        = 62 * 24 * time.Hour
+	defaultSlots    = 5
+	defaultDuration = 30 * time.Minute
+)
+
+type Event struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	AllDay    bool      `json:"allDay,omitempty"`
+	Location  string    `json:"location,omitempty"`
+	Organizer string    `json:"organizer,omitempty"`
+	Attendees []string  `json:"attendees,omitempty"`
+	URL       string    `json:"url,omitempty"`
+}
+
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type Draft struct {
+	ID      string   `json:"id,omitempty"`
+	To      []string `json:"to"`
+	Cc      []string `json:"cc,omitempty"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+// provider is the API of a calendar and mailbox.
+type provider interface {
+	events(ctx context.Context, calendar string, start, end time.Time) ([]Event, error)
+	// busy returns the busy times of the user and the attendees.
+	busy(ctx context.Context, attendees []string, start, end time.Time) ([]Slot, error)
+	draft(ctx context.Context, draft Draft) (string, error)
+	// getDraft returns the recipients and subject of a draft.
+	getDraft(ctx context.Context, draftID string) (*Draft, error)
+	send(ctx context.Context, draftID string) error
+}
+
+type Server struct {
+	mcp.ServerHandler
+	name string
+	// newProvider creates the provider from the env of the server.
+	newProvider func(ctx context.Context, env map[string]string) (provider, error)
+}
+
+// NewServer returns the server of name, which is either GoogleServerName or MicrosoftServerName.
+func NewServer(name string) *Server {
+	s := &Server{
+		name: name,
+	}
+
+	switch name {
+	case MicrosoftServerName:
+		s.newProvider = newMicrosoft
+	default:
+		s.newProvider = newGoogle
+	}
+
+	s.Tools = mcp.NewServerTools(
+		mcp.NewServerTool("list_events", "Lists the events of a calendar in a time range", s.listEvents),
+		mcp.NewServerTool("propose_meeting_slots", "Proposes times within working hours when the user and all attendees are free", s.proposeSlots),
+		mcp.NewServerTool("draft_email", "Saves an email as a draft without sending it", s.draftEmail),
+		mcp.NewServerTool("send_draft", "Sends a draft email after the user approved it", s.sendDraft),
+	)
+
+	return s
+}
+
+func (s *Server) provider(ctx context.Context) (provider, map[string]string, error) {
+	env := types.MCPServerEnv(ctx, s.name)
+	p, err := s.newProvider(ctx, env)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not configured: %w", s.name, err)
+	}
+	return p, env, nil
+}
+
+var (
+	tokenSourcesLock sync.Mutex
+	// tokenSources are kept across calls, so that access tokens are only refreshed when they expire.
+	tokenSources = map[string]oauth2.TokenSource{}
+)
+
+// httpClient returns a client authorized with the access token, or with tokens refreshed with the
+// refresh token.
+func httpClient(ctx context.Context, config oauth2.Config, accessToken, refreshToken string) (*http.Client, error) {
+	ctx = context.WithoutCancel(ctx)
+	if accessToken != "" {
+		return oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})), nil
+	}
+	if refreshToken == "" || config.ClientID == "" {
+		return nil, fmt.Errorf("an access token, or a client ID and refresh token are required")
+	}
+
+	tokenSourcesLock.Lock()
+	defer tokenSourcesLock.Unlock()
+
+	key := config.Endpoint.TokenURL + "\x00" + config.ClientID + "\x00" + refreshToken
+	source, ok := tokenSources[key]
+	if !ok {
+		source = config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken})
+		tokenSources[key] = source
+	}
+	return oauth2.NewClient(ctx, source), nil
+}
+
+// timeRange parses the start and end of a range, which default to now and a week from the start.
+func timeRange(start, end string) (time.Time, time.Time, error) {
+	from, to := time.Now(), time.Time{}
+	if start != "" {
+		var err error
+		if from, err = time.Parse(time.RFC3339, start); err != nil {
+			return from, to, fmt.Errorf("invalid start %q, expected RFC 3339 like 2025-01-02T15:04:05Z", start)
+		}
+	}
+	to = from.Add(defaultRange)
+	if end != "" {
+		var err error
+		if to, err = time.Parse(time.RFC3339, end); err != nil {
+			return from, to, fmt.Errorf("invalid end %q, expected RFC 3339 like 2025-01-02T15:04:05Z", end)
+		}
+	}
+	if !to.After(from) {
+		return from, to, fmt.Errorf("the end must be after the start")
+	}
+	if to.Sub(from) > maxRange {
+		return from, to, fmt.Errorf("the range can not be longer than %d days", int(maxRange.Hours()/24))
+	}
+	return from, to, nil
+}
+
+func (s *Server) listEvents(ctx context.Context, params struct {
+	Start    string `json:"start,omitempty" jsonschema:"Start of the range in RFC 3339 format. Defaults to now"`
+	End      string `json:"end,omitempty" jsonschema:"End of the range in RFC 3339 format. Defaults to a week after the start"`
+	Calendar string `json:"calendar,omitempty" jsonschema:"ID of the calendar. Defaults to the primary calendar of the user"`
+}) (map[string]any, error) {
+	start, end, err := timeRange(params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+	p, _, err := s.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := p.events(ctx, params.Calendar, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	return map[string]any{"events": events}, nil
+}
+
+func (s *Server) proposeSlots(ctx context.Context, params struct {
+	Attendees       []string `json:"attendees,omitempty" jsonschema:"Email addresses of the attendees besides the user"`
+	DurationMinutes int      `json:"durationMinutes,omitempty" jsonschema:"Length of the meeting in minutes. Defaults to 30"`
+	Start           string   `json:"start,omitempty" jsonschema:"Earliest start in RFC 3339 format. Defaults to now"`
+	End             string   `json:"end,omitempty" jsonschema:"Latest end in RFC 3339 format. Defaults to a week after the start"`
+	Count           int      `json:"count,omitempty" jsonschema:"The number of slots to propose. Defaults to 5"`
+}) (map[string]any, error) {
+	start, end, err := timeRange(params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+	p, env, err := s.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hours, err := parseWorkingHours(env["TIMEZONE"], env["WORKING_HOURS"])
+	if err != nil {
+		return nil, err
+	}
+
+	duration := defaultDuration
+	if params.DurationMinutes > 0 {
+		duration = time.Duration(params.DurationMinutes) * time.Minute
+	}
+	count := params.Count
+	if count <= 0 {
+		count = defaultSlots
+	}
+
+	busy, err := p.busy(ctx, params.Attendees, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get free/busy times: %w", err)
+	}
+	return map[string]any{
+		"timezone": hours.location.String(),
+		"slots":    freeSlots(busy, start, end, duration, hours, count),
+	}, nil
+}
+
+func (s *Server) draftEmail(ctx context.Context, draft Draft) (*Draft, error) {
+	if len(draft.To) == 0 || strings.TrimSpace(draft.Subject) == "" {
+		return nil, fmt.Errorf("to and subject are required")
+	}
+	p, _, err := s.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := p.draft(ctx, draft)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+	draft.ID = id
+	return &draft, nil
+}
+
+func (s *Server) sendDraft(ctx context.Context, params struct {
+	ID string `json:"id" jsonschema:"ID of the draft returned by draft_email"`
+}) (*mcp.CallToolResult, error) {
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	p, _, err := s.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user approves what is actually in the draft, not what the agent says it is.
+	draft, err := p.getDraft(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+	if err := confirm.Approve(ctx, fmt.Sprintf("send the email %q to %s", draft.Subject, strings.Join(append(draft.To, draft.Cc...), ", "))); err != nil {
+		return nil, err
+	}
+
+	if err := p.send(ctx, params.ID); err != nil {
+		return nil, fmt.Errorf("failed to send draft: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			{
+				Type: "text",
+				Text: "The email was sent.",
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,94 @@
+package office
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// slotStep is the granularity of proposed start times.
+const slotStep = 30 * time.Minute
+
+// workingHours are the hours of the weekdays meetings are proposed in.
+type workingHours struct {
+	location   *time.Location
+	start, end time.Duration
+}
+
+// parseWorkingHours parses the TIMEZONE and WORKING_HOURS of the env, which default to UTC and
+// 09:00-17:00.
+func parseWorkingHours(timezone, hours string) (workingHours, error) {
+	result := workingHours{
+		location: time.UTC,
+		start:    9 * time.Hour,
+		end:      17 * time.Hour,
+	}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return result, fmt.Errorf("invalid TIMEZONE %q: %w", timezone, err)
+		}
+		result.location = loc
+	}
+	if hours != "" {
+		start, end, ok := strings.Cut(hours, "-")
+		from, err1 := time.Parse("15:04", strings.TrimSpace(start))
+		to, err2 := time.Parse("15:04", strings.TrimSpace(end))
+		if !ok || err1 != nil || err2 != nil || !to.After(from) {
+			return result, fmt.Errorf("invalid WORKING_HOURS %q, expected a range like 09:00-17:00", hours)
+		}
+		result.start = time.Duration(from.Hour())*time.Hour + time.Duration(from.Minute())*time.Minute
+		result.end = time.Duration(to.Hour())*time.Hour + time.Duration(to.Minute())*time.Minute
+	}
+	return result, nil
+}
+
+// freeSlots returns up to count slots of duration between start and end within the working hours
+// that do not overlap any busy time. Slots start at full or half hours and do not overlap.
+func freeSlots(busy []Slot, start, end time.Time, duration time.Duration, hours workingHours, count int) []Slot {
+	busy = slices.Clone(busy)
+	slices.SortFunc(busy, func(a, b Slot) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	var result []Slot
+	start = start.In(hours.location)
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, hours.location); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+
+		// Working hours are wall clock times, which differ from an offset of the day on days the
+		// clock changes.
+		from := time.Date(day.Year(), day.Month(), day.Day(), 0, int(hours.start.Minutes()), 0, 0, hours.location)
+		if from.Before(start) {
+			from = day.Add((start.Sub(day) + slotStep - 1) / slotStep * slotStep)
+		}
+		until := time.Date(day.Year(), day.Month(), day.Day(), 0, int(hours.end.Minutes()), 0, 0, hours.location)
+		if until.After(end) {
+			until = end
+		}
+
+		for t := from; !t.Add(duration).After(until); {
+			slot := Slot{Start: t, End: t.Add(duration)}
+			i := slices.IndexFunc(busy, func(b Slot) bool {
+				return b.Start.Before(slot.End) && b.End.After(slot.Start)
+			})
+			if i < 0 {
+				result = append(result, slot)
+				if len(result) == count {
+					return result
+				}
+				t = slot.End
+			} else {
+				// Continue after the busy time that overlaps.
+				t = busy[i].End.In(hours.location)
+			}
+			if rest := t.Sub(day) % slotStep; rest != 0 {
+				t = t.Add(slotStep - rest)
+			}
+		}
+	}
+	return result
+}
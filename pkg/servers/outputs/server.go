@@ -0,0 +1,244 @@
+// Package outputs is the server that keeps the full outputs of tool calls that were too large to be
+// given to the model whole. The model gets a preview of such an output and a link to it, and reads
+// the rest with the read_output tool.
+package outputs
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+const (
+	ServerName = "nanobot.outputs"
+	ReadTool   = "read_output"
+	URIPrefix  = "nanobot://output/"
+
+	// defaultPageSize is the size of a page read when the model does not pass a limit.
+	defaultPageSize  = 16 * 1024
+	sessionKeyPrefix = "outputs/"
+)
+
+// Output is the full text output of a tool call.
+type Output struct {
+	Name string `json:"name,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// Serialize and Deserialize keep the outputs with the session when it is stored.
+func (o *Output) Serialize() (any, error) {
+	return o, nil
+}
+
+func (o *Output) Deserialize(data any) (any, error) {
+	return o, mcp.JSONCoerce(data, o)
+}
+
+type Server struct {
+	mcp.ServerHandler
+}
+
+func NewServer() *Server {
+	s := &Server{}
+	s.Tools = mcp.NewServerTools(
+		mcp.NewServerTool(ReadTool, "Read a page of a tool output that was truncated because it was too large", s.read),
+	)
+	return s
+}
+
+// ReadToolDefinition returns the definition of the tool that pages through outputs.
+func ReadToolDefinition() mcp.Tool {
+	return NewServer().Tools[ReadTool].Definition()
+}
+
+func (s *Server) OnMessage(ctx context.Context, msg mcp.Message) {
+	switch msg.Method {
+	case "resources/list":
+		mcp.Invoke(ctx, msg, s.listResources)
+	case "resources/read":
+		mcp.Invoke(ctx, msg, s.readResource)
+	default:
+		s.ServerHandler.OnMessage(ctx, msg)
+	}
+}
+
+type ReadParams struct {
+	URI    string `json:"uri" jsonschema:"The nanobot://output/ URI of the output"`
+	Offset int    `json:"offset,omitempty" jsonschema:"The byte offset to start reading at, 0 for the start"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"The maximum number of bytes to read. Defaults to 16384"`
+}
+
+func (s *Server) read(ctx context.Context, params ReadParams) (mcp.Content, error) {
+	output, ok := get(ctx, params.URI)
+	if !ok {
+		return mcp.Content{}, mcp.ErrRPCInvalidParams.WithMessage("output %s not found", params.URI)
+	}
+	if params.Offset < 0 || params.Offset > len(output.Text) {
+		return mcp.Content{}, mcp.ErrRPCInvalidParams.WithMessage("offset %d is outside of the output of %d bytes", params.Offset, len(output.Text))
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	page := cut(output.Text[params.Offset:], limit)
+	end := params.Offset + len(page)
+	text := fmt.Sprintf("[bytes %d-%d of %d]\n%s", params.Offset, end, len(output.Text), page)
+	if end < len(output.Text) {
+		text += fmt.Sprintf("\n[call %s with offset %d to read more]", ReadTool, end)
+	}
+	return mcp.Content{
+		Type: "text",
+		Text: text,
+	}, nil
+}
+
+func (s *Server) listResources(ctx context.Context, _ mcp.Message, _ mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	return &mcp.ListResourcesResult{
+		Resources: List(ctx),
+	}, nil
+}
+
+func (s *Server) readResource(ctx context.Context, _ mcp.Message, body mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	return Read(ctx, body.URI)
+}
+
+// List returns the outputs kept in the session of ctx as resources.
+func List(ctx context.Context) []mcp.Resource {
+	session := rootSession(ctx)
+	resources := []mcp.Resource{}
+	if session == nil {
+		return resources
+	}
+	for _, key := range slices.Sorted(maps.Keys(session.Attributes())) {
+		var output Output
+		if id, ok := strings.CutPrefix(key, sessionKeyPrefix); ok && session.Get(key, &output) {
+			resources = append(resources, resource(id, output))
+		}
+	}
+	return resources
+}
+
+// Read returns the full text of the output with the nanobot://output/ URI.
+func Read(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	output, ok := get(ctx, uri)
+	if !ok {
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("output %s not found", uri)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContent{
+			{
+				URI:      uri,
+				Name:     output.Name,
+				MIMEType: "text/plain",
+				Text:     output.Text,
+			},
+		},
+	}, nil
+}
+
+// Truncate returns the result with its text cut to maxSize bytes if it is larger. The full text is
+// kept in the session of ctx and the returned result links to it and tells the model how to read
+// the rest. Results that fit are returned as they are.
+func Truncate(ctx context.Context, name string, result *types.CallResult, maxSize int) *types.CallResult {
+	session := rootSession(ctx)
+	if maxSize <= 0 || result == nil || session == nil {
+		return result
+	}
+
+	var full strings.Builder
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			if full.Len() > 0 {
+				full.WriteString("\n")
+			}
+			full.WriteString(content.Text)
+		}
+	}
+	size := full.Len()
+	if size <= maxSize {
+		return result
+	}
+
+	id := uuid.String()
+	output := Output{
+		Name: name,
+		Text: full.String(),
+	}
+	session.Set(sessionKeyPrefix+id, &output)
+
+	preview := cut(output.Text, maxSize)
+	truncated := *result
+	truncated.StructuredContent = nil
+	truncated.Content = []mcp.Content{
+		{
+			Type: "text",
+			Text: preview,
+		},
+		{
+			Type: "text",
+			Text: fmt.Sprintf("[output truncated to %d of %d bytes, call %s with uri %s and offset %d to read more]",
+				len(preview), size, ReadTool, URIPrefix+id, len(preview)),
+		},
+	}
+	link := resource(id, output)
+	truncated.Content = append(truncated.Content, mcp.Content{
+		Type:        "resource_link",
+		Name:        link.Name,
+		Description: link.Description,
+		URI:         link.URI,
+		MIMEType:    link.MimeType,
+	})
+	for _, content := range result.Content {
+		if content.Type != "text" {
+			truncated.Content = append(truncated.Content, content)
+		}
+	}
+	return &truncated
+}
+
+func resource(id string, output Output) mcp.Resource {
+	return mcp.Resource{
+		URI:         URIPrefix + id,
+		Name:        output.Name,
+		Description: "The full output of " + output.Name,
+		MimeType:    "text/plain",
+		Size:        int64(len(output.Text)),
+	}
+}
+
+func get(ctx context.Context, uri string) (Output, bool) {
+	id, ok := strings.CutPrefix(uri, URIPrefix)
+	if !ok {
+		return Output{}, false
+	}
+	var output Output
+	ok = rootSession(ctx).Get(sessionKeyPrefix+id, &output)
+	return output, ok
+}
+
+func rootSession(ctx context.Context) *mcp.Session {
+	session := mcp.SessionFromContext(ctx)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	return session
+}
+
+// cut returns at most size bytes of the start of text, without splitting a UTF-8 character.
+func cut(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	for size > 0 && !utf8.RuneStart(text[size]) {
+		size--
+	}
+	return text[:size]
+}
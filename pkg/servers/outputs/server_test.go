@@ -0,0 +1,85 @@
+package outputs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestTruncate(t *testing.T) {
+	ctx := mcp.NewEmptySession(context.Background()).Context()
+	text := strings.Repeat("0123456789", 10)
+	result := &types.CallResult{
+		Content: []mcp.Content{{Type: "text", Text: text}},
+	}
+
+	if got := Truncate(ctx, "list", result, 100); got != result {
+		t.Fatalf("Truncate() changed a result that fits")
+	}
+
+	got := Truncate(ctx, "list", result, 25)
+	if len(got.Content) != 3 || got.Content[0].Text != text[:25] || got.Content[2].Type != "resource_link" {
+		t.Fatalf("Truncate() = %+v", got.Content)
+	}
+	uri := got.Content[2].URI
+
+	s := NewServer()
+	page, err := s.read(ctx, ReadParams{URI: uri, Offset: 25, Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page.Text, text[25:75]) || !strings.Contains(page.Text, "offset 75") {
+		t.Errorf("read() = %q", page.Text)
+	}
+
+	page, err = s.read(ctx, ReadParams{URI: uri, Offset: 75})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(page.Text, text[75:]) {
+		t.Errorf("read() of the last page = %q", page.Text)
+	}
+
+	resources, err := s.listResources(ctx, mcp.Message{}, mcp.ListResourcesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources.Resources) != 1 || resources.Resources[0].URI != uri || resources.Resources[0].Size != 100 {
+		t.Errorf("listResources() = %+v", resources.Resources)
+	}
+}
+
+func TestCut(t *testing.T) {
+	if got := cut("héllo", 2); got != "h" {
+		t.Errorf("cut() = %q, want %q", got, "h")
+	}
+}
+
+func TestOutputsAreStored(t *testing.T) {
+	session := mcp.NewEmptySession(context.Background())
+	result := Truncate(session.Context(), "list", &types.CallResult{
+		Content: []mcp.Content{{Type: "text", Text: strings.Repeat("x", 10)}},
+	}, 5)
+
+	// A stored session holds the outputs as decoded JSON.
+	restored := mcp.NewEmptySession(context.Background())
+	for key, value := range session.Attributes() {
+		data, err := value.(mcp.Serializable).Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded any
+		if err := mcp.JSONCoerce(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		restored.Set(key, decoded)
+	}
+
+	output, ok := get(restored.Context(), result.Content[2].URI)
+	if !ok || output.Text != strings.Repeat("x", 10) {
+		t.Errorf("get() = %+v, %v", output, ok)
+	}
+}
@@ -3,9 +3,14 @@ package resources
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/documents"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 	"github.com/nanobot-ai/nanobot/pkg/types"
 	"github.com/nanobot-ai/nanobot/pkg/uuid"
@@ -14,18 +19,33 @@ import (
 )
 
 type Server struct {
-	tools mcp.ServerTools
-	store *Store
+	tools    mcp.ServerTools
+	store    *Store
+	safeMode bool
+
+	// ephemeral holds the resources of an ephemeral session, which are never written to the store.
+	ephemeralLock sync.Mutex
+	ephemeral     []Resource
 }
 
-func NewServer(store *Store) *Server {
+// NewServer creates the resources server. In safe mode the tools that execute external renderers
+// are not available.
+func NewServer(store *Store, safeMode bool) *Server {
 	s := &Server{
-		store: store,
+		store:    store,
+		safeMode: safeMode,
 	}
 
 	s.tools = mcp.NewServerTools(
 		mcp.NewServerTool("create_resource", "Create a resource", s.createResource),
+		mcp.NewServerTool("create_document", "Render markdown into a downloadable HTML, PDF, or DOCX document", s.createDocument),
+		mcp.NewServerTool("render_chart", "Render a Vega-Lite chart specification into an image", s.renderChart),
+		mcp.NewServerTool("render_mermaid", "Render a Mermaid diagram into an image", s.renderMermaid),
 	)
+	if safeMode {
+		delete(s.tools, "render_chart")
+		delete(s.tools, "render_mermaid")
+	}
 
 	return s
 }
@@ -46,11 +66,11 @@ func (s *Server) createResource(ctx context.Context, params CreateArtifactParams
 
 	data, err := base64.StdEncoding.DecodeString(params.Blob)
 	if err != nil {
-		return nil, mcp.ErrRPCInvalidParams.WithMessage("invalid base64 data: " + err.Error())
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("invalid base64 data: %v", err)
 	}
 
 	uuid := uuid.String()
-	err = s.store.Create(ctx, &Resource{
+	err = s.save(ctx, &Resource{
 		UUID:        uuid,
 		SessionID:   sessionID,
 		AccountID:   accountID,
@@ -72,12 +92,112 @@ func (s *Server) createResource(ctx context.Context, params CreateArtifactParams
 	}, nil
 }
 
+type CreateDocumentParams struct {
+	Name     string `json:"name" jsonschema:"The file name of the document, without extension"`
+	Title    string `json:"title,omitempty" jsonschema:"The title of the document"`
+	Markdown string `json:"markdown" jsonschema:"The content of the document in markdown"`
+	Format   string `json:"format,omitempty" jsonschema:"The output format: html, pdf, or docx. Defaults to html"`
+}
+
+func (s *Server) createDocument(ctx context.Context, params CreateDocumentParams) (*mcp.Resource, error) {
+	if strings.TrimSpace(params.Markdown) == "" {
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("markdown is required")
+	}
+	if s.safeMode && documents.NormalizeFormat(params.Format) == documents.FormatPDF {
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("PDF rendering is disabled in safe mode")
+	}
+
+	data, mimeType, err := documents.Render(ctx, params.Format, params.Title, params.Markdown)
+	if err != nil {
+		return nil, err
+	}
+
+	name := complete.First(params.Name, params.Title, "document")
+	if ext := "." + documents.NormalizeFormat(params.Format); !strings.HasSuffix(name, ext) {
+		name += ext
+	}
+
+	return s.createResource(ctx, CreateArtifactParams{
+		Name:        name,
+		Description: params.Title,
+		Blob:        base64.StdEncoding.EncodeToString(data),
+		MimeType:    mimeType,
+	})
+}
+
+type RenderChartParams struct {
+	Name   string         `json:"name,omitempty" jsonschema:"The file name of the image, without extension"`
+	Spec   map[string]any `json:"spec" jsonschema:"The Vega-Lite specification, with the data inlined"`
+	Format string         `json:"format,omitempty" jsonschema:"The output format: png or svg. Defaults to png"`
+}
+
+func (s *Server) renderChart(ctx context.Context, params RenderChartParams) ([]mcp.Content, error) {
+	spec, err := json.Marshal(params.Spec)
+	if err != nil {
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("invalid spec: %v", err)
+	}
+
+	data, mimeType, err := documents.VegaLite(ctx, spec, params.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.imageResult(ctx, complete.First(params.Name, "chart"), data, mimeType)
+}
+
+type RenderMermaidParams struct {
+	Name    string `json:"name,omitempty" jsonschema:"The file name of the image, without extension"`
+	Diagram string `json:"diagram" jsonschema:"The Mermaid diagram definition"`
+	Format  string `json:"format,omitempty" jsonschema:"The output format: png or svg. Defaults to png"`
+}
+
+func (s *Server) renderMermaid(ctx context.Context, params RenderMermaidParams) ([]mcp.Content, error) {
+	if strings.TrimSpace(params.Diagram) == "" {
+		return nil, mcp.ErrRPCInvalidParams.WithMessage("diagram is required")
+	}
+
+	data, mimeType, err := documents.Mermaid(ctx, params.Diagram, params.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.imageResult(ctx, complete.First(params.Name, "diagram"), data, mimeType)
+}
+
+// imageResult saves the rendered image as a resource and returns it inline as image content, so
+// UIs can show it directly, along with a link to the stored resource.
+func (s *Server) imageResult(ctx context.Context, name string, data []byte, mimeType string) ([]mcp.Content, error) {
+	blob := base64.StdEncoding.EncodeToString(data)
+	resource, err := s.createResource(ctx, CreateArtifactParams{
+		Name:     name + "." + strings.TrimSuffix(strings.TrimPrefix(mimeType, "image/"), "+xml"),
+		Blob:     blob,
+		MimeType: mimeType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.Content{
+		{
+			Type:     "image",
+			Data:     blob,
+			MIMEType: mimeType,
+		},
+		{
+			Type:     "resource_link",
+			Name:     resource.Name,
+			URI:      resource.URI,
+			MIMEType: resource.MimeType,
+		},
+	}, nil
+}
+
 func (s *Server) readResource(ctx context.Context, _ mcp.Message, body mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
 	_, accountID := s.getSessionAndAccountID(ctx)
 
 	id := strings.TrimPrefix(body.URI, "nanobot://resource/")
 
-	artifact, err := s.store.GetByUUIDAndAccountID(ctx, id, accountID)
+	artifact, err := s.get(ctx, id, accountID)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, mcp.ErrRPCInvalidParams.WithMessage("artifact not found")
 	} else if err != nil {
@@ -95,6 +215,43 @@ func (s *Server) readResource(ctx context.Context, _ mcp.Message, body mcp.ReadR
 	}, nil
 }
 
+func (s *Server) isEphemeral(ctx context.Context) bool {
+	return types.IsEphemeral(mcp.SessionFromContext(ctx))
+}
+
+func (s *Server) save(ctx context.Context, resource *Resource) error {
+	if !s.isEphemeral(ctx) {
+		return s.store.Create(ctx, resource)
+	}
+	s.ephemeralLock.Lock()
+	defer s.ephemeralLock.Unlock()
+	s.ephemeral = append(s.ephemeral, *resource)
+	return nil
+}
+
+func (s *Server) get(ctx context.Context, uuid, accountID string) (*Resource, error) {
+	if !s.isEphemeral(ctx) {
+		return s.store.GetByUUIDAndAccountID(ctx, uuid, accountID)
+	}
+	s.ephemeralLock.Lock()
+	defer s.ephemeralLock.Unlock()
+	for _, resource := range s.ephemeral {
+		if resource.UUID == uuid && resource.AccountID == accountID {
+			return &resource, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *Server) find(ctx context.Context, sessionID string) ([]Resource, error) {
+	if !s.isEphemeral(ctx) {
+		return s.store.FindBySessionID(ctx, sessionID)
+	}
+	s.ephemeralLock.Lock()
+	defer s.ephemeralLock.Unlock()
+	return slices.Clone(s.ephemeral), nil
+}
+
 func (s *Server) getSessionAndAccountID(ctx context.Context) (string, string) {
 	var (
 		session   = mcp.SessionFromContext(ctx)
@@ -114,7 +271,7 @@ func (s *Server) listResourcesTemplates(_ context.Context, _ mcp.Message, _ mcp.
 func (s *Server) listResources(ctx context.Context, _ mcp.Message, body mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
 	sessionID, _ := s.getSessionAndAccountID(ctx)
 
-	resources, err := s.store.FindBySessionID(ctx, sessionID)
+	resources, err := s.find(ctx, sessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +310,7 @@ func (s *Server) OnMessage(ctx context.Context, msg mcp.Message) {
 	case "tools/call":
 		mcp.Invoke(ctx, msg, s.tools.Call)
 	default:
-		msg.SendError(ctx, mcp.ErrRPCMethodNotFound.WithMessage(msg.Method))
+		msg.SendError(ctx, mcp.ErrRPCMethodNotFound.WithMessage("%s", msg.Method))
 	}
 }
 
@@ -0,0 +1,179 @@
+package resources
+
+import (
+	"context"
+	"encoding/base64"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// testContext returns the context of a tool call, whose session is the child of the session of
+// the account.
+func testContext(t *testing.T) context.Context {
+	parent := mcp.NewEmptySession(t.Context())
+	parent.Set(types.AccountIDSessionKey, "account")
+	child := mcp.NewEmptySession(parent.Context())
+	child.Parent = parent
+	return child.Context()
+}
+
+func testServer(t *testing.T, safeMode bool) *Server {
+	store, err := NewStoreFromDSN("sqlite::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(store, safeMode)
+}
+
+// fakeRenderer writes a script that prints its stdin, or the output for the flag --output.
+func fakeRenderer(t *testing.T, dir, name string) {
+	t.Helper()
+	script := "#!/bin/sh\nout=\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = --output ]; then out=$2; fi\n  shift\ndone\n" +
+		"if [ -n \"$out\" ]; then echo '<svg>mermaid</svg>' > \"$out\"; else cat; fi\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	dir := t.TempDir()
+	fakeRenderer(t, dir, "vl2svg")
+	t.Setenv("NANOBOT_VEGALITE_RENDERER_DIR", dir)
+
+	ctx := testContext(t)
+	s := testServer(t, false)
+
+	content, err := s.renderChart(ctx, RenderChartParams{
+		Name:   "sales",
+		Spec:   map[string]any{"mark": "bar"},
+		Format: "svg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 2 || content[0].Type != "image" || content[0].MIMEType != "image/svg+xml" || content[1].Name != "sales.svg" {
+		t.Fatalf("renderChart() = %+v", content)
+	}
+	if data, _ := base64.StdEncoding.DecodeString(content[0].Data); string(data) != `{"mark":"bar"}` {
+		t.Errorf("got image %q", data)
+	}
+
+	resource, err := s.readResource(ctx, mcp.Message{}, mcp.ReadResourceRequest{URI: content[1].URI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Contents[0].Blob != content[0].Data {
+		t.Errorf("the stored resource does not match the image")
+	}
+}
+
+func TestRenderChartInvalidSpec(t *testing.T) {
+	_, err := testServer(t, false).renderChart(testContext(t), RenderChartParams{
+		Spec: map[string]any{"width": math.NaN()},
+	})
+	if err == nil || !strings.HasSuffix(err.Error(), "invalid params: invalid spec: json: unsupported value: NaN") {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	dir := t.TempDir()
+	fakeRenderer(t, dir, "mmdc")
+	t.Setenv("NANOBOT_MERMAID_RENDERER", filepath.Join(dir, "mmdc"))
+
+	content, err := testServer(t, false).renderMermaid(testContext(t), RenderMermaidParams{
+		Diagram: "graph TD; A-->B",
+		Format:  "svg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := base64.StdEncoding.DecodeString(content[0].Data); string(data) != "<svg>mermaid</svg>\n" || content[1].Name != "diagram.svg" {
+		t.Errorf("renderMermaid() = %+v", content)
+	}
+
+	if _, err := testServer(t, false).renderMermaid(testContext(t), RenderMermaidParams{Diagram: " "}); err == nil {
+		t.Error("expected an error for an empty diagram")
+	}
+}
+
+func TestCreateDocument(t *testing.T) {
+	ctx := testContext(t)
+	s := testServer(t, false)
+
+	for format, want := range map[string]string{
+		"":      "report.html",
+		".HTML": "report.html",
+		"docx":  "report.docx",
+	} {
+		resource, err := s.createDocument(ctx, CreateDocumentParams{
+			Name:     "report",
+			Markdown: "# Report",
+			Format:   format,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resource.Name != want {
+			t.Errorf("format %q: got name %s, want %s", format, resource.Name, want)
+		}
+	}
+
+	if _, err := s.createDocument(ctx, CreateDocumentParams{Markdown: "# Report", Format: "odt"}); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestSafeModeTools(t *testing.T) {
+	// The browser must not be launched in safe mode.
+	t.Setenv("NANOBOT_PDF_RENDERER", "/nonexistent/chromium")
+
+	s := testServer(t, true)
+	for _, format := range []string{"pdf", ".pdf", " PDF", ".Pdf "} {
+		_, err := s.createDocument(testContext(t), CreateDocumentParams{Markdown: "# Report", Format: format})
+		if err == nil || !strings.Contains(err.Error(), "disabled in safe mode") {
+			t.Errorf("format %q: got error %v", format, err)
+		}
+	}
+	for _, name := range []string{"render_chart", "render_mermaid"} {
+		if _, ok := s.tools[name]; ok {
+			t.Errorf("expected %s to be disabled in safe mode", name)
+		}
+	}
+}
+
+func TestEphemeralResources(t *testing.T) {
+	ctx := testContext(t)
+	mcp.SessionFromContext(ctx).Parent.Set(types.EphemeralSessionKey, true)
+	s := testServer(t, false)
+
+	resource, err := s.createResource(ctx, CreateArtifactParams{
+		Name:     "notes.txt",
+		MimeType: "text/plain",
+		Blob:     base64.StdEncoding.EncodeToString([]byte("secret")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored, err := s.store.List(ctx); err != nil || len(stored) != 0 {
+		t.Errorf("expected no resource in the store, got %d, %v", len(stored), err)
+	}
+
+	listed, err := s.listResources(ctx, mcp.Message{}, mcp.ListResourcesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.Resources) != 1 || listed.Resources[0].URI != resource.URI {
+		t.Errorf("listResources() = %+v", listed.Resources)
+	}
+	if _, err := s.readResource(ctx, mcp.Message{}, mcp.ReadResourceRequest{URI: resource.URI}); err != nil {
+		t.Error(err)
+	}
+}
@@ -28,7 +28,7 @@ func NewStoreFromDSN(dsn string) (*Store, error) {
 
 // Init initializes the artifact store by migrating the schema
 func (s *Store) Init() error {
-	return s.db.AutoMigrate(&Resource{})
+	return gormdsn.Migrate(s.db, &Resource{})
 }
 
 // Create creates a new artifact in the database
@@ -46,6 +46,15 @@ func (s *Store) Get(ctx context.Context, id uint) (*Resource, error) {
 	return &artifact, nil
 }
 
+func (s *Store) GetByUUID(ctx context.Context, uuid string) (*Resource, error) {
+	var artifact Resource
+	err := s.db.WithContext(ctx).Where("uuid = ?", uuid).First(&artifact).Error
+	if err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
+
 func (s *Store) GetByUUIDAndAccountID(ctx context.Context, uuid, accountID string) (*Resource, error) {
 	var artifact Resource
 	err := s.db.WithContext(ctx).Where("uuid = ? and account_id = ?", uuid, accountID).First(&artifact).Error
@@ -0,0 +1,161 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/render"
+)
+
+const (
+	defaultAPIURL = "https://slack.com/api"
+	// maxFileSize is the largest file attached to a message that is downloaded.
+	maxFileSize = 20 << 20
+)
+
+// Envelope is a request of the Events API. Only url_verification and event_callback requests are
+// handled.
+type Envelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge,omitempty"`
+	TeamID    string `json:"team_id,omitempty"`
+	EventID   string `json:"event_id,omitempty"`
+	Event     *Event `json:"event,omitempty"`
+}
+
+// Event is an app_mention or message event.
+type Event struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype,omitempty"`
+	User        string `json:"user,omitempty"`
+	BotID       string `json:"bot_id,omitempty"`
+	Text        string `json:"text,omitempty"`
+	TS          string `json:"ts,omitempty"`
+	ThreadTS    string `json:"thread_ts,omitempty"`
+	Channel     string `json:"channel,omitempty"`
+	ChannelType string `json:"channel_type,omitempty"`
+	Files       []File `json:"files,omitempty"`
+}
+
+type File struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name,omitempty"`
+	Mimetype           string `json:"mimetype,omitempty"`
+	Size               int    `json:"size,omitempty"`
+	URLPrivateDownload string `json:"url_private_download,omitempty"`
+}
+
+// chatMessage is a message posted or updated with the Web API.
+type chatMessage struct {
+	Channel  string              `json:"channel"`
+	TS       string              `json:"ts,omitempty"`
+	ThreadTS string              `json:"thread_ts,omitempty"`
+	Text     string              `json:"text"`
+	Blocks   []render.SlackBlock `json:"blocks,omitempty"`
+}
+
+// client is a client of the Slack Web API.
+type client struct {
+	apiURL string
+	token  string
+	http   *http.Client
+}
+
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	TS    string `json:"ts,omitempty"`
+	// UserID is returned by auth.test.
+	UserID string `json:"user_id,omitempty"`
+}
+
+func (c *client) call(ctx context.Context, method string, in any) (*apiResponse, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/"+method, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response of %s: %w", method, err)
+	}
+	if !result.OK {
+		return nil, fmt.Errorf("%s failed: %s", method, result.Error)
+	}
+	return &result, nil
+}
+
+// authTest returns the user ID of the bot.
+func (c *client) authTest(ctx context.Context) (string, error) {
+	resp, err := c.call(ctx, "auth.test", map[string]any{})
+	if err != nil {
+		return "", err
+	}
+	return resp.UserID, nil
+}
+
+// postMessage posts msg and returns its timestamp, which identifies it in the channel.
+func (c *client) postMessage(ctx context.Context, msg chatMessage) (string, error) {
+	msg.TS = ""
+	resp, err := c.call(ctx, "chat.postMessage", msg)
+	if err != nil {
+		return "", err
+	}
+	return resp.TS, nil
+}
+
+// updateMessage replaces the message with the timestamp msg.TS.
+func (c *client) updateMessage(ctx context.Context, msg chatMessage) error {
+	msg.ThreadTS = ""
+	_, err := c.call(ctx, "chat.update", msg)
+	return err
+}
+
+// download returns the content of a file attached to a message.
+func (c *client) download(ctx context.Context, file File) ([]byte, string, error) {
+	if file.Size > maxFileSize {
+		return nil, "", fmt.Errorf("file is larger than %d MB", maxFileSize>>20)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.URLPrivateDownload, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download file %s: %w", file.ID, err)
+	}
+	defer resp.Body.Close()
+
+	// Slack redirects to a sign in page instead of failing if the token lacks the files:read scope.
+	if resp.StatusCode != http.StatusOK || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return nil, "", fmt.Errorf("unexpected response %s downloading file %s, the bot needs the files:read scope", resp.Status, file.ID)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxFileSize {
+		return nil, "", fmt.Errorf("file is larger than %d MB", maxFileSize>>20)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
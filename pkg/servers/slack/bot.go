@@ -0,0 +1,408 @@
+// Package slack implements a Slack app using the Events API and slash commands. Every user gets a
+// nanobot session per direct message conversation or thread the app is mentioned in, which is
+// driven by the messages they send the app.
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/channel"
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/errreport"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+// SessionType is the type of the sessions created for Slack conversations.
+const SessionType = "slack"
+
+// Paths the app is served on, the request URLs of the Events API and of the slash command.
+const (
+	EventsPath   = "/api/slack/events"
+	CommandsPath = "/api/slack/commands"
+)
+
+const (
+	// maxRequestAge is how old the timestamp of a request may be, older requests are rejected as
+	// replays.
+	maxRequestAge = 5 * time.Minute
+	maxBodySize   = 1 << 20
+)
+
+var mention = regexp.MustCompile(`<@([A-Z0-9]+)(\|[^>]*)?>`)
+
+type Options struct {
+	channel.Options
+
+	// BotToken is the bot token of the app, starting with xoxb-.
+	BotToken string
+	// SigningSecret verifies that requests are sent by Slack.
+	SigningSecret string
+	// AllowedUsers are the IDs of the users that may talk to the app. If empty everyone can.
+	AllowedUsers []string
+	// UpdateInterval is how often the reply is edited while the response is generated.
+	UpdateInterval time.Duration
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Options = o.Options.Merge(other.Options)
+	result.BotToken = complete.Last(o.BotToken, other.BotToken)
+	result.SigningSecret = complete.Last(o.SigningSecret, other.SigningSecret)
+	result.AllowedUsers = append(o.AllowedUsers, other.AllowedUsers...)
+	result.UpdateInterval = complete.Last(o.UpdateInterval, other.UpdateInterval)
+	return
+}
+
+func (o Options) Complete() Options {
+	if o.UpdateInterval == 0 {
+		o.UpdateInterval = 1500 * time.Millisecond
+	}
+	return o
+}
+
+// Bot handles the events and slash commands Slack sends to the app.
+type Bot struct {
+	ctx       context.Context
+	opt       Options
+	runner    *channel.Runner
+	client    *client
+	botUserID string
+}
+
+func NewBot(ctx context.Context, runt *runtime.Runtime, config types.ConfigFactory, sessions *session.Manager, server mcp.MessageHandler, opts ...Options) (*Bot, error) {
+	opt := complete.Complete(opts...)
+	if opt.BotToken == "" || opt.SigningSecret == "" {
+		return nil, fmt.Errorf("the bot token and signing secret of the app are required")
+	}
+
+	runner, err := channel.NewRunner(runt, config, sessions, server, opt.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bot{
+		ctx:    ctx,
+		opt:    opt,
+		runner: runner,
+		client: &client{
+			apiURL: defaultAPIURL,
+			token:  opt.BotToken,
+			http:   http.DefaultClient,
+		},
+	}
+
+	b.botUserID, err = b.client.authTest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify Slack bot token: %w", err)
+	}
+	return b, nil
+}
+
+func (b *Bot) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize))
+	if err != nil {
+		http.Error(rw, "Failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	if !b.verify(req.Header, body, time.Now()) {
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch req.URL.Path {
+	case EventsPath:
+		b.serveEvent(rw, req, body)
+	case CommandsPath:
+		b.serveCommand(rw, req, body)
+	default:
+		http.NotFound(rw, req)
+	}
+}
+
+// verify checks the X-Slack-Signature of the request, which signs its timestamp and body.
+func (b *Bot) verify(header http.Header, body []byte, now time.Time) bool {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(b.opt.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte("v0="+hex.EncodeToString(mac.Sum(nil))))
+}
+
+func (b *Bot) serveEvent(rw http.ResponseWriter, req *http.Request, body []byte) {
+	var envelope Envelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		http.Error(rw, "Failed to decode event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if envelope.Type == "url_verification" {
+		rw.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(rw, envelope.Challenge)
+		return
+	}
+
+	// Slack retries events that were not acknowledged within 3 seconds. The turn of the event was
+	// started by the first delivery.
+	if req.Header.Get("X-Slack-Retry-Num") != "" {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	event := envelope.Event
+	if envelope.Type != "event_callback" || event == nil || !b.handles(*event) {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !b.allowed(event.User) {
+		log.Debugf(req.Context(), "ignoring Slack message from user %s that is not allowed", event.User)
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Slack expects the event to be acknowledged within 3 seconds, so the turn runs in the
+	// background.
+	go b.handle(context.WithoutCancel(req.Context()), envelope.TeamID, *event)
+	rw.WriteHeader(http.StatusOK)
+}
+
+// handles returns true for the messages the app answers: mentions of the app, and direct messages.
+// Messages of bots, including the app itself, and edits are ignored.
+func (b *Bot) handles(event Event) bool {
+	if event.User == "" || event.BotID != "" || (event.Subtype != "" && event.Subtype != "file_share") {
+		return false
+	}
+	return event.Type == "app_mention" || (event.Type == "message" && event.ChannelType == "im")
+}
+
+func (b *Bot) allowed(user string) bool {
+	return len(b.opt.AllowedUsers) == 0 || slices.Contains(b.opt.AllowedUsers, user)
+}
+
+func (b *Bot) handle(ctx context.Context, teamID string, event Event) {
+	defer errreport.Recover(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	context.AfterFunc(b.ctx, cancel)
+
+	// Tool calls are always posted in the thread of the message. The response is posted in the
+	// thread too, unless the message is a direct message outside of a thread.
+	toolThread := complete.First(event.ThreadTS, event.TS)
+	thread := conversationThread(event)
+
+	stream := newStream(ctx, b.client, event.Channel, thread, toolThread, uuid.String(), b.opt.UpdateInterval)
+	resp, err := b.turn(ctx, teamID, event, thread, stream)
+	if err != nil {
+		log.Errorf(ctx, "failed to handle Slack message: %v", err)
+		stream.fail(ctx, err)
+		return
+	}
+
+	if len(resp.Images) > 0 {
+		log.Debugf(ctx, "dropped %d images of the response, images are not sent to Slack", len(resp.Images))
+	}
+	stream.finish(ctx, resp.Text)
+}
+
+func (b *Bot) turn(ctx context.Context, teamID string, event Event, thread string, stream *stream) (*channel.Response, error) {
+	files, err := b.files(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	var agent string
+	if thread != "" {
+		// New threads are answered by the agent the user picked for the channel.
+		agent = b.runner.SelectedAgent(ctx, b.sessionID(teamID, event.Channel, "", event.User))
+	}
+
+	prompt := b.prompt(event.Text)
+	return b.runner.Run(ctx, channel.Message{
+		Channel:       "Slack",
+		SessionType:   SessionType,
+		SessionID:     b.sessionID(teamID, event.Channel, thread, event.User),
+		User:          userFromEvent(teamID, event.User),
+		Description:   description(prompt),
+		Prompt:        prompt,
+		Files:         files,
+		ProgressToken: stream.token,
+		Filter:        stream.filter,
+		Agent:         agent,
+	})
+}
+
+// files downloads the files attached to the message.
+func (b *Bot) files(ctx context.Context, event Event) ([]channel.File, error) {
+	var result []channel.File
+	for _, file := range event.Files {
+		if file.URLPrivateDownload == "" {
+			continue
+		}
+		data, contentType, err := b.client.download(ctx, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download attachment %s: %w", file.Name, err)
+		}
+
+		mimeType := file.Mimetype
+		if mimeType == "" {
+			mimeType = mime.TypeByExtension(path.Ext(file.Name))
+		}
+		if mimeType == "" || mimeType == "application/octet-stream" {
+			mimeType = complete.First(contentType, http.DetectContentType(data))
+		}
+		mimeType, _, _ = strings.Cut(mimeType, ";")
+
+		result = append(result, channel.File{
+			Name:     file.Name,
+			MimeType: mimeType,
+			Data:     data,
+		})
+	}
+	return result, nil
+}
+
+// serveCommand answers a slash command, such as /nanobot agent NAME, only to the user who sent it.
+func (b *Bot) serveCommand(rw http.ResponseWriter, req *http.Request, body []byte) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(rw, "Failed to decode command: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	text := "You are not allowed to use this app."
+	if user := form.Get("user_id"); b.allowed(user) {
+		text = b.command(req.Context(), form.Get("command"), form.Get("text"), channel.Message{
+			Channel:     "Slack",
+			SessionType: SessionType,
+			SessionID:   b.sessionID(form.Get("team_id"), form.Get("channel_id"), "", user),
+			User:        userFromEvent(form.Get("team_id"), user),
+			Description: "Slack conversation",
+		})
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(map[string]string{
+		"response_type": "ephemeral",
+		"text":          text,
+	})
+}
+
+// command runs a slash command for the session of the user in the channel and returns the answer.
+// The agent picked in a channel answers the direct messages, or the new threads of the channel.
+func (b *Bot) command(ctx context.Context, command, text string, msg channel.Message) string {
+	name, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "agents":
+		agents, current, err := b.runner.Agents(ctx, msg)
+		if err != nil {
+			return "Failed to list agents: " + err.Error()
+		}
+		if len(agents) == 0 {
+			return "There are no agents to pick from."
+		}
+		var sb strings.Builder
+		sb.WriteString("Agents:\n")
+		for _, agent := range agents {
+			marker := ""
+			if agent.ID == current {
+				marker = " (current)"
+			}
+			fmt.Fprintf(&sb, "• `%s` %s%s\n", agent.ID, escape(complete.First(agent.Name, agent.ID)), marker)
+		}
+		return sb.String()
+	case "agent":
+		if arg == "" {
+			_, current, err := b.runner.Agents(ctx, msg)
+			if err != nil {
+				return "Failed to read the agent: " + err.Error()
+			}
+			return fmt.Sprintf("Messages are answered by `%s`.", current)
+		}
+		if arg == "default" {
+			arg = ""
+		}
+		if err := b.runner.SetAgent(ctx, msg, arg); err != nil {
+			return "Failed to pick the agent: " + err.Error()
+		}
+		if arg == "" {
+			return "Messages are answered by the default agent again."
+		}
+		return fmt.Sprintf("Messages are answered by `%s` from now on.", arg)
+	default:
+		command = complete.First(command, "/nanobot")
+		return fmt.Sprintf("Usage:\n• `%[1]s agents` lists the agents\n• `%[1]s agent NAME` picks the agent that answers your messages in this channel\n• `%[1]s agent default` picks the default agent again", command)
+	}
+}
+
+// prompt returns the text of the message without the mentions of the app. Mentions of other users
+// are kept as their IDs, and the escaped characters of Slack are restored.
+func (b *Bot) prompt(text string) string {
+	text = mention.ReplaceAllStringFunc(text, func(m string) string {
+		if id := mention.FindStringSubmatch(m)[1]; id == b.botUserID {
+			return ""
+		}
+		return m
+	})
+	text = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(text)
+	return strings.TrimSpace(text)
+}
+
+// sessionID derives the ID of the session of the user in the conversation. Threads are separate
+// conversations, so every thread gets its own session.
+func (b *Bot) sessionID(teamID, channelID, thread, user string) string {
+	return channel.SessionID(b.opt.SigningSecret, teamID, channelID, thread, user)
+}
+
+// conversationThread returns the thread of the conversation of the message. Direct messages outside
+// of a thread are one conversation, mentions in a channel start a thread.
+func conversationThread(event Event) string {
+	if event.ChannelType == "im" && event.ThreadTS == "" {
+		return ""
+	}
+	return complete.First(event.ThreadTS, event.TS)
+}
+
+// description is the description of a new session, the start of the first message.
+func description(prompt string) string {
+	line, _, _ := strings.Cut(prompt, "\n")
+	if runes := []rune(line); len(runes) > 80 {
+		line = string(runes[:80]) + "…"
+	}
+	return complete.First(line, "Slack conversation")
+}
+
+func userFromEvent(teamID, user string) types.User {
+	return types.User{
+		ID: "slack:" + teamID + ":" + user,
+	}
+}
@@ -0,0 +1,121 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func signedRequest(secret, path, body string, ts time.Time) *http.Request {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestServeHTTP(t *testing.T) {
+	b := &Bot{opt: Options{SigningSecret: "secret"}}
+	body := `{"type":"url_verification","challenge":"abc"}`
+
+	rw := httptest.NewRecorder()
+	b.ServeHTTP(rw, signedRequest("secret", EventsPath, body, time.Now()))
+	if rw.Code != http.StatusOK || rw.Body.String() != "abc" {
+		t.Errorf("expected the challenge to be answered, got %d %q", rw.Code, rw.Body.String())
+	}
+
+	for name, req := range map[string]*http.Request{
+		"wrong secret": signedRequest("wrong", EventsPath, body, time.Now()),
+		"replay":       signedRequest("secret", EventsPath, body, time.Now().Add(-10*time.Minute)),
+	} {
+		rw := httptest.NewRecorder()
+		b.ServeHTTP(rw, req)
+		if rw.Code != http.StatusUnauthorized {
+			t.Errorf("expected request with %s to be rejected, got %d", name, rw.Code)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	b := &Bot{botUserID: "UBOT"}
+	if got, want := b.prompt("<@UBOT> compare a &lt; b for <@UJANE|jane>\nthanks"), "compare a < b for <@UJANE|jane>\nthanks"; got != want {
+		t.Errorf("got prompt %q, want %q", got, want)
+	}
+
+	for _, tt := range []struct {
+		event Event
+		want  bool
+	}{
+		{Event{Type: "app_mention", User: "U1", ChannelType: "channel"}, true},
+		{Event{Type: "message", User: "U1", ChannelType: "im"}, true},
+		{Event{Type: "message", User: "U1", ChannelType: "channel"}, false},
+		{Event{Type: "message", User: "U1", ChannelType: "im", Subtype: "message_changed"}, false},
+		{Event{Type: "message", User: "U1", ChannelType: "im", BotID: "B1"}, false},
+	} {
+		if got := b.handles(tt.event); got != tt.want {
+			t.Errorf("handles(%+v) = %v, want %v", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		calls []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var msg chatMessage
+		_ = json.NewDecoder(req.Body).Decode(&msg)
+		lock.Lock()
+		calls = append(calls, req.URL.Path+" "+msg.ThreadTS+" "+msg.Text)
+		ts := strconv.Itoa(len(calls))
+		lock.Unlock()
+		_, _ = rw.Write([]byte(`{"ok":true,"ts":"` + ts + `"}`))
+	}))
+	defer srv.Close()
+
+	c := &client{apiURL: srv.URL, token: "xoxb", http: srv.Client()}
+	s := newStream(context.Background(), c, "D1", "", "100.1", "token", time.Hour)
+
+	send := func(item types.CompletionItem) {
+		params, _ := json.Marshal(map[string]any{
+			"progressToken": "token",
+			"_meta": map[string]any{
+				"ai.nanobot.progress/completion": types.CompletionProgress{MessageID: "m1", Item: item},
+			},
+		})
+		if _, err := s.filter(context.Background(), &mcp.Message{Method: "notifications/progress", Params: params}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send(types.CompletionItem{ToolCall: &types.ToolCall{CallID: "c1", Name: "search"}})
+	s.flushTools(context.Background())
+	send(types.CompletionItem{ToolCallResult: &types.ToolCallResult{CallID: "c1", Output: types.CallResult{IsError: true, Content: []mcp.Content{{Type: "text", Text: "not found"}}}}})
+	send(types.CompletionItem{ID: "i1", Partial: true, Content: &mcp.Content{Type: "text", Text: "Hello"}})
+	s.finish(context.Background(), "**Hello**")
+
+	want := []string{
+		"/chat.postMessage 100.1 :hammer_and_wrench: Calling `search` …",
+		"/chat.update  :warning: `search` failed: not found",
+		"/chat.postMessage  *Hello*",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected calls:\n%s", strings.Join(calls, "\n"))
+	}
+}
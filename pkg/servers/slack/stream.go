@@ -0,0 +1,302 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/render"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// maxErrorLength is the length of a tool error shown in a tool call update.
+const maxErrorLength = 200
+
+// stream shows the response of the agent in Slack while it is generated. The first text is posted
+// as a reply, which is then edited in place at most every interval. Tool calls are posted as
+// updates in the thread of the message, which are edited once the call returned. Once the response
+// is complete the reply is replaced with the final response rendered with Block Kit.
+type stream struct {
+	client  *client
+	channel string
+	// thread is the thread the response is posted in, empty to post it in the channel.
+	thread string
+	// toolThread is the thread the tool call updates are posted in.
+	toolThread string
+	token      string
+	interval   time.Duration
+
+	lock    sync.Mutex
+	items   []streamItem
+	changed bool
+	tools   []*toolUpdate
+	replyTS string
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type streamItem struct {
+	messageID, itemID string
+	text              string
+}
+
+// toolUpdate is the message of a tool call in the thread.
+type toolUpdate struct {
+	callID  string
+	name    string
+	text    string
+	ts      string
+	changed bool
+}
+
+func newStream(ctx context.Context, client *client, channel, thread, toolThread, token string, interval time.Duration) *stream {
+	s := &stream{
+		client:     client,
+		channel:    channel,
+		thread:     thread,
+		toolThread: toolThread,
+		token:      token,
+		interval:   interval,
+		done:       make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.run(ctx)
+	return s
+}
+
+func (s *stream) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.flushTools(ctx)
+
+			s.lock.Lock()
+			text, changed := s.text(), s.changed
+			s.changed = false
+			s.lock.Unlock()
+
+			if changed && text != "" {
+				s.send(ctx, chatMessage{Text: render.Slack(text).Text + " …"})
+			}
+		}
+	}
+}
+
+// filter collects the text and tool calls of the progress notifications of the turn, it never
+// modifies messages.
+func (s *stream) filter(_ context.Context, msg *mcp.Message) (*mcp.Message, error) {
+	if msg.Method != "notifications/progress" {
+		return msg, nil
+	}
+
+	var payload struct {
+		ProgressToken any `json:"progressToken"`
+		Meta          struct {
+			Progress *types.CompletionProgress `json:"ai.nanobot.progress/completion"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(msg.Params, &payload); err != nil || payload.Meta.Progress == nil ||
+		fmt.Sprint(payload.ProgressToken) != s.token {
+		return msg, nil
+	}
+
+	progress := payload.Meta.Progress
+	if progress.Role == "user" {
+		return msg, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	item := progress.Item
+	switch {
+	case item.ToolCallResult != nil:
+		update := s.toolUpdate(item.ToolCallResult.CallID)
+		if item.ToolCall != nil {
+			update.name = complete.First(update.name, item.ToolCall.Name)
+		}
+		update.text, update.changed = toolResultText(update.name, &item.ToolCallResult.Output), true
+	case item.ToolCall != nil && item.ToolCall.CallID != "" && item.ToolCall.Name != "":
+		if update := s.toolUpdate(item.ToolCall.CallID); update.text == "" {
+			update.name = item.ToolCall.Name
+			update.text, update.changed = fmt.Sprintf(":hammer_and_wrench: Calling `%s` …", update.name), true
+		}
+	case item.Content != nil && item.Content.Type == "text":
+		s.addText(progress.MessageID, item)
+	}
+	return msg, nil
+}
+
+// toolUpdate returns the update of a tool call, adding it on the first progress of the call. The
+// caller must hold the lock.
+func (s *stream) toolUpdate(callID string) *toolUpdate {
+	for _, update := range s.tools {
+		if update.callID == callID {
+			return update
+		}
+	}
+	update := &toolUpdate{callID: callID}
+	s.tools = append(s.tools, update)
+	return update
+}
+
+// addText adds the text of a progress item. The caller must hold the lock.
+func (s *stream) addText(messageID string, item types.CompletionItem) {
+	s.changed = true
+	for i := range s.items {
+		if s.items[i].messageID == messageID && s.items[i].itemID == item.ID {
+			if item.Partial {
+				s.items[i].text += item.Content.Text
+			} else {
+				s.items[i].text = item.Content.Text
+			}
+			return
+		}
+	}
+	s.items = append(s.items, streamItem{
+		messageID: messageID,
+		itemID:    item.ID,
+		text:      item.Content.Text,
+	})
+}
+
+// text returns the text streamed so far. The caller must hold the lock.
+func (s *stream) text() string {
+	var parts []string
+	for _, item := range s.items {
+		if text := strings.TrimSpace(item.text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// flushTools posts the tool call updates that changed, or edits them if they were posted before.
+func (s *stream) flushTools(ctx context.Context) {
+	s.lock.Lock()
+	var changed []toolUpdate
+	for _, update := range s.tools {
+		if update.changed {
+			changed = append(changed, *update)
+			update.changed = false
+		}
+	}
+	s.lock.Unlock()
+
+	for _, update := range changed {
+		msg := chatMessage{Channel: s.channel, TS: update.ts, ThreadTS: s.toolThread, Text: update.text}
+		if update.ts != "" {
+			if err := s.client.updateMessage(ctx, msg); err != nil {
+				log.Errorf(ctx, "failed to update tool call in Slack: %v", err)
+			}
+			continue
+		}
+
+		ts, err := s.client.postMessage(ctx, msg)
+		if err != nil {
+			log.Errorf(ctx, "failed to post tool call to Slack: %v", err)
+			continue
+		}
+		s.lock.Lock()
+		s.toolUpdate(update.callID).ts = ts
+		s.lock.Unlock()
+	}
+}
+
+// finish replaces the streamed text with the final response.
+func (s *stream) finish(ctx context.Context, markdown string) {
+	s.stop(ctx)
+
+	if strings.TrimSpace(markdown) == "" {
+		s.send(ctx, chatMessage{Text: "The agent did not respond."})
+		return
+	}
+
+	msg := render.Slack(markdown)
+	// Slack rejects messages with invalid or too many blocks, those are sent as text.
+	if err := s.trySend(ctx, chatMessage{Text: msg.Text, Blocks: msg.Blocks}); err != nil {
+		s.send(ctx, chatMessage{Text: msg.Text})
+	}
+}
+
+// fail shows err as the response.
+func (s *stream) fail(ctx context.Context, err error) {
+	s.stop(ctx)
+	s.send(ctx, chatMessage{Text: "Sorry, something went wrong: " + err.Error()})
+}
+
+// stop ends the updates of the streamed text and posts the tool call updates that are left.
+func (s *stream) stop(ctx context.Context) {
+	close(s.done)
+	s.wg.Wait()
+	s.flushTools(ctx)
+}
+
+// send posts the first message of the response and edits it afterward.
+func (s *stream) send(ctx context.Context, msg chatMessage) {
+	if err := s.trySend(ctx, msg); err != nil {
+		log.Errorf(ctx, "failed to send response to Slack: %v", err)
+	}
+}
+
+func (s *stream) trySend(ctx context.Context, msg chatMessage) error {
+	msg.Channel = s.channel
+	if s.replyTS != "" {
+		msg.TS = s.replyTS
+		return s.client.updateMessage(ctx, msg)
+	}
+
+	msg.ThreadTS = s.thread
+	ts, err := s.client.postMessage(ctx, msg)
+	if err != nil {
+		return err
+	}
+	s.replyTS = ts
+	return nil
+}
+
+// toolResultText is the update of a tool call that returned.
+func toolResultText(name string, result *types.CallResult) string {
+	if !result.IsError {
+		return fmt.Sprintf(":white_check_mark: Called `%s`", name)
+	}
+
+	var text string
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			text = strings.TrimSpace(content.Text)
+			break
+		}
+	}
+	text, _, _ = strings.Cut(text, "\n")
+	if utf8.RuneCountInString(text) > maxErrorLength {
+		text = string([]rune(text)[:maxErrorLength]) + "…"
+	}
+	if text == "" {
+		return fmt.Sprintf(":warning: `%s` failed", name)
+	}
+	return fmt.Sprintf(":warning: `%s` failed: %s", name, escape(text))
+}
+
+// escape escapes the characters that Slack treats as markup in text.
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
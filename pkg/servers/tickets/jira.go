@@ -0,0 +1,242 @@
+package tickets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// jira is a client of the REST API v2 of Jira Cloud or Jira Data Center. Version 2 is used because
+// it accepts and returns descriptions and comments as plain text.
+type jira struct {
+	url   string
+	email string
+	token string
+}
+
+func newJira(env map[string]string) (tracker, []string, error) {
+	j := &jira{
+		url:   strings.TrimSuffix(env["JIRA_URL"], "/"),
+		email: env["JIRA_EMAIL"],
+		token: env["JIRA_API_TOKEN"],
+	}
+	if j.url == "" || j.token == "" {
+		return nil, nil, fmt.Errorf("JIRA_URL and JIRA_API_TOKEN are required")
+	}
+	return j, splitList(env["JIRA_PROJECTS"]), nil
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Project     struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Status *struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		Assignee *struct {
+			DisplayName string `json:"displayName"`
+		} `json:"assignee"`
+		Comment *struct {
+			Comments []struct {
+				Author struct {
+					DisplayName string `json:"displayName"`
+				} `json:"author"`
+				Body    string `json:"body"`
+				Created string `json:"created"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+func (j *jira) ticket(issue jiraIssue) *Ticket {
+	t := &Ticket{
+		Key:         issue.Key,
+		Project:     issue.Fields.Project.Key,
+		Title:       issue.Fields.Summary,
+		Description: issue.Fields.Description,
+		URL:         j.url + "/browse/" + issue.Key,
+	}
+	if issue.Fields.Status != nil {
+		t.Status = issue.Fields.Status.Name
+	}
+	if issue.Fields.Assignee != nil {
+		t.Assignee = issue.Fields.Assignee.DisplayName
+	}
+	if issue.Fields.Comment != nil {
+		for _, c := range issue.Fields.Comment.Comments {
+			created, _ := time.Parse("2006-01-02T15:04:05.000-0700", c.Created)
+			t.Comments = append(t.Comments, Comment{
+				Author:  c.Author.DisplayName,
+				Body:    c.Body,
+				Created: created,
+			})
+		}
+	}
+	return t
+}
+
+func (j *jira) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, j.url+path, body)
+	if err != nil {
+		return err
+	}
+	if j.email != "" {
+		req.SetBasicAuth(j.email, j.token)
+	} else {
+		// Personal access tokens of Jira Data Center are sent without an email.
+		req.Header.Set("Authorization", "Bearer "+j.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Jira: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var jiraErr struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&jiraErr)
+		messages := jiraErr.ErrorMessages
+		for field, msg := range jiraErr.Errors {
+			messages = append(messages, field+": "+msg)
+		}
+		return fmt.Errorf("%s %s failed: %s: %s", method, path, resp.Status, strings.Join(messages, ", "))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jqlString quotes a value for JQL.
+func jqlString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func (j *jira) search(ctx context.Context, opts SearchOptions) ([]Ticket, error) {
+	var clauses []string
+	if len(opts.Projects) > 0 {
+		var projects []string
+		for _, project := range opts.Projects {
+			projects = append(projects, jqlString(project))
+		}
+		clauses = append(clauses, "project in ("+strings.Join(projects, ", ")+")")
+	}
+	if opts.Text != "" {
+		clauses = append(clauses, "text ~ "+jqlString(opts.Text))
+	}
+	if opts.Status != "" {
+		clauses = append(clauses, "status = "+jqlString(opts.Status))
+	}
+	jql := strings.Join(clauses, " AND ") + " ORDER BY updated DESC"
+
+	var result struct {
+		Issues []jiraIssue `json:"issues"`
+	}
+	query := url.Values{
+		"jql":        {strings.TrimSpace(jql)},
+		"maxResults": {fmt.Sprint(opts.Limit)},
+		"fields":     {"summary,status,assignee,project"},
+	}
+	if err := j.call(ctx, http.MethodGet, "/rest/api/2/search/jql?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+
+	tickets := make([]Ticket, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		tickets = append(tickets, *j.ticket(issue))
+	}
+	return tickets, nil
+}
+
+func (j *jira) get(ctx context.Context, key string) (*Ticket, error) {
+	var issue jiraIssue
+	if err := j.call(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"?fields=summary,description,status,assignee,project,comment", nil, &issue); err != nil {
+		return nil, err
+	}
+	return j.ticket(issue), nil
+}
+
+func (j *jira) create(ctx context.Context, project, title, description, issueType string) (*Ticket, error) {
+	if issueType == "" {
+		issueType = "Task"
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.call(ctx, http.MethodPost, "/rest/api/2/issue", map[string]any{
+		"fields": map[string]any{
+			"project":     map[string]any{"key": project},
+			"summary":     title,
+			"description": description,
+			"issuetype":   map[string]any{"name": issueType},
+		},
+	}, &created); err != nil {
+		return nil, err
+	}
+	return j.get(ctx, created.Key)
+}
+
+// transition moves the ticket with the transition whose name or target status matches status.
+func (j *jira) transition(ctx context.Context, key, status string) (*Ticket, error) {
+	var transitions struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	path := "/rest/api/2/issue/" + url.PathEscape(key) + "/transitions"
+	if err := j.call(ctx, http.MethodGet, path, nil, &transitions); err != nil {
+		return nil, err
+	}
+
+	var available []string
+	for _, t := range transitions.Transitions {
+		if strings.EqualFold(t.To.Name, status) || strings.EqualFold(t.Name, status) {
+			if err := j.call(ctx, http.MethodPost, path, map[string]any{
+				"transition": map[string]any{"id": t.ID},
+			}, nil); err != nil {
+				return nil, err
+			}
+			return j.get(ctx, key)
+		}
+		available = append(available, t.To.Name)
+	}
+	return nil, fmt.Errorf("%s can not be moved to %s, available statuses are %s", key, status, strings.Join(available, ", "))
+}
+
+func (j *jira) comment(ctx context.Context, key, body string) error {
+	return j.call(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", map[string]any{
+		"body": body,
+	}, nil)
+}
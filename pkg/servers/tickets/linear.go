@@ -0,0 +1,284 @@
+package tickets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultLinearURL = "https://api.linear.app/graphql"
+
+// linear is a client of the GraphQL API of Linear. Tickets are Linear issues and projects are teams.
+type linear struct {
+	url    string
+	apiKey string
+}
+
+func newLinear(env map[string]string) (tracker, []string, error) {
+	l := &linear{
+		url:    defaultLinearURL,
+		apiKey: env["LINEAR_API_KEY"],
+	}
+	if u := env["LINEAR_URL"]; u != "" {
+		l.url = u
+	}
+	if l.apiKey == "" {
+		return nil, nil, fmt.Errorf("LINEAR_API_KEY is required")
+	}
+	return l, splitList(env["LINEAR_TEAMS"]), nil
+}
+
+const linearIssueFields = `id identifier title url state { name } assignee { name } team { key }`
+
+type linearIssue struct {
+	ID          string `json:"id"`
+	Identifier  string `json:"identifier"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	State       *struct {
+		Name string `json:"name"`
+	} `json:"state"`
+	Assignee *struct {
+		Name string `json:"name"`
+	} `json:"assignee"`
+	Team struct {
+		Key    string `json:"key"`
+		States *struct {
+			Nodes []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"nodes"`
+		} `json:"states,omitempty"`
+	} `json:"team"`
+	Comments *struct {
+		Nodes []struct {
+			Body      string    `json:"body"`
+			CreatedAt time.Time `json:"createdAt"`
+			User      *struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"nodes"`
+	} `json:"comments,omitempty"`
+}
+
+func (i linearIssue) ticket() *Ticket {
+	t := &Ticket{
+		Key:         i.Identifier,
+		Project:     i.Team.Key,
+		Title:       i.Title,
+		Description: i.Description,
+		URL:         i.URL,
+	}
+	if i.State != nil {
+		t.Status = i.State.Name
+	}
+	if i.Assignee != nil {
+		t.Assignee = i.Assignee.Name
+	}
+	if i.Comments != nil {
+		for _, c := range i.Comments.Nodes {
+			comment := Comment{
+				Body:    c.Body,
+				Created: c.CreatedAt,
+			}
+			if c.User != nil {
+				comment.Author = c.User.Name
+			}
+			t.Comments = append(t.Comments, comment)
+		}
+	}
+	return t
+}
+
+func (l *linear) call(ctx context.Context, query string, variables map[string]any, out any) error {
+	data, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", l.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Linear: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response of Linear: %s: %w", resp.Status, err)
+	}
+	if len(result.Errors) > 0 {
+		var messages []string
+		for _, e := range result.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("linear request failed: %s", strings.Join(messages, ", "))
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("linear request failed: %s", resp.Status)
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+func (l *linear) search(ctx context.Context, opts SearchOptions) ([]Ticket, error) {
+	filter := map[string]any{}
+	if len(opts.Projects) > 0 {
+		filter["team"] = map[string]any{"key": map[string]any{"in": opts.Projects}}
+	}
+	if opts.Text != "" {
+		filter["or"] = []map[string]any{
+			{"title": map[string]any{"containsIgnoreCase": opts.Text}},
+			{"description": map[string]any{"containsIgnoreCase": opts.Text}},
+		}
+	}
+	if opts.Status != "" {
+		filter["state"] = map[string]any{"name": map[string]any{"eqIgnoreCase": opts.Status}}
+	}
+
+	var result struct {
+		Issues struct {
+			Nodes []linearIssue `json:"nodes"`
+		} `json:"issues"`
+	}
+	if err := l.call(ctx, `query($filter: IssueFilter, $first: Int) {
+  issues(filter: $filter, first: $first, orderBy: updatedAt) { nodes { `+linearIssueFields+` } }
+}`, map[string]any{
+		"filter": filter,
+		"first":  opts.Limit,
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	tickets := make([]Ticket, 0, len(result.Issues.Nodes))
+	for _, issue := range result.Issues.Nodes {
+		tickets = append(tickets, *issue.ticket())
+	}
+	return tickets, nil
+}
+
+func (l *linear) issue(ctx context.Context, key, fields string) (*linearIssue, error) {
+	var result struct {
+		Issue *linearIssue `json:"issue"`
+	}
+	if err := l.call(ctx, `query($id: String!) { issue(id: $id) { `+fields+` } }`, map[string]any{
+		"id": key,
+	}, &result); err != nil {
+		return nil, err
+	}
+	if result.Issue == nil {
+		return nil, fmt.Errorf("issue %s not found", key)
+	}
+	return result.Issue, nil
+}
+
+func (l *linear) get(ctx context.Context, key string) (*Ticket, error) {
+	issue, err := l.issue(ctx, key, linearIssueFields+` description comments { nodes { body createdAt user { name } } }`)
+	if err != nil {
+		return nil, err
+	}
+	return issue.ticket(), nil
+}
+
+func (l *linear) create(ctx context.Context, project, title, description, _ string) (*Ticket, error) {
+	var teams struct {
+		Teams struct {
+			Nodes []struct {
+				ID string `json:"id"`
+			} `json:"nodes"`
+		} `json:"teams"`
+	}
+	if err := l.call(ctx, `query($key: String!) { teams(filter: { key: { eqIgnoreCase: $key } }) { nodes { id } } }`, map[string]any{
+		"key": project,
+	}, &teams); err != nil {
+		return nil, err
+	}
+	if len(teams.Teams.Nodes) == 0 {
+		return nil, fmt.Errorf("team %s not found", project)
+	}
+
+	var created struct {
+		IssueCreate struct {
+			Issue linearIssue `json:"issue"`
+		} `json:"issueCreate"`
+	}
+	if err := l.call(ctx, `mutation($input: IssueCreateInput!) { issueCreate(input: $input) { issue { `+linearIssueFields+` description } } }`, map[string]any{
+		"input": map[string]any{
+			"teamId":      teams.Teams.Nodes[0].ID,
+			"title":       title,
+			"description": description,
+		},
+	}, &created); err != nil {
+		return nil, err
+	}
+	return created.IssueCreate.Issue.ticket(), nil
+}
+
+// transition moves the issue to the workflow state of its team named status.
+func (l *linear) transition(ctx context.Context, key, status string) (*Ticket, error) {
+	issue, err := l.issue(ctx, key, `id team { key states { nodes { id name } } }`)
+	if err != nil {
+		return nil, err
+	}
+
+	var available []string
+	if issue.Team.States != nil {
+		for _, state := range issue.Team.States.Nodes {
+			if !strings.EqualFold(state.Name, status) {
+				available = append(available, state.Name)
+				continue
+			}
+
+			var updated struct {
+				IssueUpdate struct {
+					Issue linearIssue `json:"issue"`
+				} `json:"issueUpdate"`
+			}
+			if err := l.call(ctx, `mutation($id: String!, $input: IssueUpdateInput!) { issueUpdate(id: $id, input: $input) { issue { `+linearIssueFields+` } } }`, map[string]any{
+				"id":    issue.ID,
+				"input": map[string]any{"stateId": state.ID},
+			}, &updated); err != nil {
+				return nil, err
+			}
+			return updated.IssueUpdate.Issue.ticket(), nil
+		}
+	}
+	return nil, fmt.Errorf("%s can not be moved to %s, available statuses are %s", key, status, strings.Join(available, ", "))
+}
+
+func (l *linear) comment(ctx context.Context, key, body string) error {
+	issue, err := l.issue(ctx, key, `id`)
+	if err != nil {
+		return err
+	}
+
+	var created struct {
+		CommentCreate struct {
+			Success bool `json:"success"`
+		} `json:"commentCreate"`
+	}
+	return l.call(ctx, `mutation($input: CommentCreateInput!) { commentCreate(input: $input) { success } }`, map[string]any{
+		"input": map[string]any{
+			"issueId": issue.ID,
+			"body":    body,
+		},
+	}, &created)
+}
@@ -0,0 +1,311 @@
+// Package tickets implements the built-in nanobot.jira and nanobot.linear MCP servers, which search,
+// read, create, transition, and comment on tickets. The servers are configured with the env of
+// their entry in the mcpServers of the config:
+//
+//	mcpServers:
+//	  nanobot.jira:
+//	    env:
+//	      JIRA_URL: https://example.atlassian.net
+//	      JIRA_EMAIL: bot@example.com
+//	      JIRA_API_TOKEN: ${JIRA_API_TOKEN}
+//	      JIRA_PROJECTS: OPS,SUP
+//	  nanobot.linear:
+//	    env:
+//	      LINEAR_API_KEY: ${LINEAR_API_KEY}
+//	      LINEAR_TEAMS: ENG
+//
+// Tickets of other projects or teams than the allowed ones can not be read or changed, if none are
+// configured all are allowed. Changes must be approved by the user unless REQUIRE_APPROVAL is false.
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/confirm"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const (
+	JiraServerName   = "nanobot.jira"
+	LinearServerName = "nanobot.linear"
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// Ticket is a Jira issue or Linear issue.
+type Ticket struct {
+	Key         string    `json:"key"`
+	Project     string    `json:"project,omitempty"`
+	Title       string    `json:"title"`
+	Status      string    `json:"status,omitempty"`
+	Assignee    string    `json:"assignee,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Comments    []Comment `json:"comments,omitempty"`
+}
+
+type Comment struct {
+	Author  string    `json:"author,omitempty"`
+	Body    string    `json:"body"`
+	Created time.Time `json:"created,omitzero"`
+}
+
+// SearchOptions narrow a search, the zero value matches all tickets of the allowed projects.
+type SearchOptions struct {
+	Text     string
+	Projects []string
+	Status   string
+	Limit    int
+}
+
+// tracker is the API of an issue tracker. Projects are Jira projects or Linear teams, identified by
+// their key.
+type tracker interface {
+	search(ctx context.Context, opts SearchOptions) ([]Ticket, error)
+	get(ctx context.Context, key string) (*Ticket, error)
+	create(ctx context.Context, project, title, description, issueType string) (*Ticket, error)
+	transition(ctx context.Context, key, status string) (*Ticket, error)
+	comment(ctx context.Context, key, body string) error
+}
+
+// settings are read from the env of the server for every call, so that changes of the config apply
+// without restarting.
+type settings struct {
+	tracker         tracker
+	projects        []string
+	requireApproval bool
+}
+
+type Server struct {
+	mcp.ServerHandler
+	name string
+	// newTracker creates the tracker from the env of the server.
+	newTracker func(env map[string]string) (tracker, []string, error)
+}
+
+// NewServer returns the server of name, which is either JiraServerName or LinearServerName.
+func NewServer(name string) *Server {
+	s := &Server{
+		name: name,
+	}
+
+	switch name {
+	case LinearServerName:
+		s.newTracker = newLinear
+	default:
+		s.newTracker = newJira
+	}
+
+	s.Tools = mcp.NewServerTools(
+		mcp.NewServerTool("search_tickets", "Searches tickets by text and status", s.search),
+		mcp.NewServerTool("get_ticket", "Returns a ticket with its description and comments", s.get),
+		mcp.NewServerTool("create_ticket", "Creates a ticket", s.create),
+		mcp.NewServerTool("transition_ticket", "Moves a ticket to another status", s.transition),
+		mcp.NewServerTool("comment_ticket", "Adds a comment to a ticket", s.comment),
+	)
+
+	return s
+}
+
+// settings reads the settings from the env of the server.
+func (s *Server) settings(ctx context.Context) (*settings, error) {
+	env := types.MCPServerEnv(ctx, s.name)
+	t, projects, err := s.newTracker(env)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not configured: %w", s.name, err)
+	}
+	return &settings{
+		tracker:         t,
+		projects:        projects,
+		requireApproval: !strings.EqualFold(env["REQUIRE_APPROVAL"], "false"),
+	}, nil
+}
+
+// allowed reports whether the project is in the allowlist.
+func (s *settings) allowed(project string) bool {
+	return len(s.projects) == 0 || slices.ContainsFunc(s.projects, func(p string) bool {
+		return strings.EqualFold(p, project)
+	})
+}
+
+// checkKey returns an error if the ticket does not belong to an allowed project.
+func (s *settings) checkKey(key string) error {
+	project, _, ok := strings.Cut(key, "-")
+	if !ok || project == "" {
+		return fmt.Errorf("invalid ticket key %q, expected a key like ABC-123", key)
+	}
+	if !s.allowed(project) {
+		return fmt.Errorf("project %s is not allowed, allowed projects are %s", project, strings.Join(s.projects, ", "))
+	}
+	return nil
+}
+
+// approve asks the user to approve a change. Without an approval from the user the change is not
+// made.
+func (s *settings) approve(ctx context.Context, action string) error {
+	if !s.requireApproval {
+		return nil
+	}
+	return confirm.Approve(ctx, action)
+}
+
+type searchParams struct {
+	Text     string   `json:"text,omitempty" jsonschema:"Text to search for in the title and description"`
+	Projects []string `json:"projects,omitempty" jsonschema:"Keys of the projects or teams to search in. Defaults to all allowed ones"`
+	Status   string   `json:"status,omitempty" jsonschema:"Only return tickets with this status"`
+	Limit    int      `json:"limit,omitempty" jsonschema:"The maximum number of tickets to return. Defaults to 20"`
+}
+
+func (s *Server) search(ctx context.Context, params searchParams) (map[string]any, error) {
+	settings, err := s.settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := params.Projects
+	for _, project := range projects {
+		if !settings.allowed(project) {
+			return nil, fmt.Errorf("project %s is not allowed, allowed projects are %s", project, strings.Join(settings.projects, ", "))
+		}
+	}
+	if len(projects) == 0 {
+		projects = settings.projects
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+
+	tickets, err := settings.tracker.search(ctx, SearchOptions{
+		Text:     params.Text,
+		Projects: projects,
+		Status:   params.Status,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %w", err)
+	}
+	return map[string]any{"tickets": tickets}, nil
+}
+
+func (s *Server) get(ctx context.Context, params struct {
+	Key string `json:"key" jsonschema:"The key of the ticket, such as ABC-123"`
+}) (*Ticket, error) {
+	settings, err := s.settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.checkKey(params.Key); err != nil {
+		return nil, err
+	}
+
+	ticket, err := settings.tracker.get(ctx, params.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket %s: %w", params.Key, err)
+	}
+	return ticket, nil
+}
+
+func (s *Server) create(ctx context.Context, params struct {
+	Project     string `json:"project" jsonschema:"The key of the project or team to create the ticket in"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty" jsonschema:"The issue type of a Jira ticket. Defaults to Task"`
+}) (*Ticket, error) {
+	settings, err := s.settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if params.Project == "" || params.Title == "" {
+		return nil, fmt.Errorf("project and title are required")
+	}
+	if !settings.allowed(params.Project) {
+		return nil, fmt.Errorf("project %s is not allowed, allowed projects are %s", params.Project, strings.Join(settings.projects, ", "))
+	}
+	if err := settings.approve(ctx, fmt.Sprintf("create the ticket %q in %s", params.Title, params.Project)); err != nil {
+		return nil, err
+	}
+
+	ticket, err := settings.tracker.create(ctx, params.Project, params.Title, params.Description, params.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ticket: %w", err)
+	}
+	return ticket, nil
+}
+
+func (s *Server) transition(ctx context.Context, params struct {
+	Key    string `json:"key" jsonschema:"The key of the ticket, such as ABC-123"`
+	Status string `json:"status" jsonschema:"The name of the status to move the ticket to"`
+}) (*Ticket, error) {
+	settings, err := s.settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.checkKey(params.Key); err != nil {
+		return nil, err
+	}
+	if params.Status == "" {
+		return nil, fmt.Errorf("status is required")
+	}
+	if err := settings.approve(ctx, fmt.Sprintf("move %s to %s", params.Key, params.Status)); err != nil {
+		return nil, err
+	}
+
+	ticket, err := settings.tracker.transition(ctx, params.Key, params.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transition ticket %s: %w", params.Key, err)
+	}
+	return ticket, nil
+}
+
+func (s *Server) comment(ctx context.Context, params struct {
+	Key  string `json:"key" jsonschema:"The key of the ticket, such as ABC-123"`
+	Body string `json:"body" jsonschema:"The text of the comment"`
+}) (*mcp.CallToolResult, error) {
+	settings, err := s.settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.checkKey(params.Key); err != nil {
+		return nil, err
+	}
+	if params.Body == "" {
+		return nil, fmt.Errorf("body is required")
+	}
+	if err := settings.approve(ctx, fmt.Sprintf("comment on %s: %q", params.Key, params.Body)); err != nil {
+		return nil, err
+	}
+
+	if err := settings.tracker.comment(ctx, params.Key, params.Body); err != nil {
+		return nil, fmt.Errorf("failed to comment on ticket %s: %w", params.Key, err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			{
+				Type: "text",
+				Text: "Added the comment to " + params.Key,
+			},
+		},
+	}, nil
+}
+
+// splitList splits a comma separated list of the env.
+func splitList(value string) (result []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
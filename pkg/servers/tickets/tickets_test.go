@@ -0,0 +1,63 @@
+package tickets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJiraSearch(t *testing.T) {
+	var jql string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if user, _, _ := req.BasicAuth(); user != "bot@example.com" {
+			t.Errorf("unexpected user %q", user)
+		}
+		jql = req.URL.Query().Get("jql")
+		_, _ = rw.Write([]byte(`{"issues": [{"key": "OPS-1", "fields": {"summary": "Disk full", "project": {"key": "OPS"}, "status": {"name": "Open"}}}]}`))
+	}))
+	defer srv.Close()
+
+	tracker, projects, err := newJira(map[string]string{
+		"JIRA_URL":       srv.URL,
+		"JIRA_EMAIL":     "bot@example.com",
+		"JIRA_API_TOKEN": "token",
+		"JIRA_PROJECTS":  "OPS, SUP",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tickets, err := tracker.search(context.Background(), SearchOptions{Text: `say "hi"`, Projects: projects, Status: "Open", Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `project in ("OPS", "SUP") AND text ~ "say \"hi\"" AND status = "Open" ORDER BY updated DESC`; jql != want {
+		t.Errorf("got JQL %q, want %q", jql, want)
+	}
+	if len(tickets) != 1 || tickets[0].Key != "OPS-1" || tickets[0].Status != "Open" || tickets[0].URL != srv.URL+"/browse/OPS-1" {
+		t.Errorf("unexpected tickets %+v", tickets)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	s := &settings{projects: []string{"OPS"}, requireApproval: true}
+
+	if err := s.checkKey("ops-12"); err != nil {
+		t.Errorf("expected tickets of allowed projects to be allowed: %v", err)
+	}
+	if err := s.checkKey("SEC-3"); err == nil {
+		t.Error("expected tickets of other projects to be rejected")
+	}
+	if err := s.checkKey("12"); err == nil {
+		t.Error("expected invalid keys to be rejected")
+	}
+
+	if err := s.approve(context.Background(), "comment on OPS-12"); err == nil {
+		t.Error("expected changes to be rejected without a client that can approve them")
+	}
+	s.requireApproval = false
+	if err := s.approve(context.Background(), "comment on OPS-12"); err != nil {
+		t.Errorf("expected changes to be allowed without approval: %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package session
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"gorm.io/gorm"
+)
+
+// ArchiveVersion is the version of the archive format written by Export.
+const ArchiveVersion = 1
+
+const (
+	archiveSessionFile  = "session.json"
+	archiveResourcesDir = "resources/"
+	// maxArchiveEntrySize limits the size of a single file read from an archive.
+	maxArchiveEntrySize = 256 << 20
+)
+
+// archivedSession is the session.json of an archive. The state holds the history of the session
+// with its messages, tool calls, and usage.
+type archivedSession struct {
+	Version     int           `json:"version"`
+	SessionID   string        `json:"sessionID"`
+	Type        string        `json:"type,omitempty"`
+	Description string        `json:"description,omitempty"`
+	AccountID   string        `json:"accountID,omitempty"`
+	Cwd         string        `json:"cwd,omitempty"`
+	IsPublic    bool          `json:"isPublic,omitempty"`
+	Created     time.Time     `json:"created"`
+	Updated     time.Time     `json:"updated"`
+	State       State         `json:"state"`
+	Config      ConfigWrapper `json:"config,omitzero"`
+	Summary     Summary       `json:"summary,omitzero"`
+}
+
+// archivedResource is a file in the resources directory of an archive, such as an attachment.
+type archivedResource struct {
+	UUID        string    `json:"uuid"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	MimeType    string    `json:"mimeType,omitempty"`
+	Blob        string    `json:"blob"`
+	Created     time.Time `json:"created"`
+}
+
+type ImportOptions struct {
+	// AccountID is the owner of the imported session, it defaults to the owner in the archive.
+	AccountID string
+}
+
+func (i ImportOptions) Merge(other ImportOptions) (result ImportOptions) {
+	result.AccountID = complete.Last(i.AccountID, other.AccountID)
+	return
+}
+
+// Export writes the session id and its resources to w as a gzipped tar archive, which Import reads
+// into another nanobot.
+func (m *Manager) Export(ctx context.Context, id string, w io.Writer) error {
+	stored, err := m.DB.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get session %s: %w", id, err)
+	}
+	if err := m.DB.ExpandHistory(ctx, stored); err != nil {
+		return err
+	}
+
+	resourceStore, err := m.resources()
+	if err != nil {
+		return err
+	}
+	sessionResources, err := resourceStore.FindBySessionID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get resources of session %s: %w", id, err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeArchiveFile(tw, archiveSessionFile, archivedSession{
+		Version:     ArchiveVersion,
+		SessionID:   stored.SessionID,
+		Type:        stored.Type,
+		Description: stored.Description,
+		AccountID:   stored.AccountID,
+		Cwd:         stored.Cwd,
+		IsPublic:    stored.IsPublic,
+		Created:     stored.CreatedAt,
+		Updated:     stored.UpdatedAt,
+		State:       stored.State,
+		Summary:     stored.Summary,
+		Config:      stored.Config,
+	}); err != nil {
+		return err
+	}
+
+	for _, resource := range sessionResources {
+		if err := writeArchiveFile(tw, archiveResourcesDir+resource.UUID+".json", archivedResource{
+			UUID:        resource.UUID,
+			Name:        resource.Name,
+			Description: resource.Description,
+			MimeType:    resource.MimeType,
+			Blob:        resource.Blob,
+			Created:     resource.CreatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return gz.Close()
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Import reads an archive written by Export and stores the session and its resources. The IDs of
+// the archive are kept unless a session or resource with the same ID exists, then a new ID is
+// assigned and the references in the session are updated. It returns the imported session.
+func (m *Manager) Import(ctx context.Context, r io.Reader, opts ...ImportOptions) (*Session, error) {
+	opt := complete.Complete(opts...)
+
+	archived, archivedResources, err := readArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID := archived.AccountID
+	if opt.AccountID != "" {
+		accountID = opt.AccountID
+	}
+
+	// The resources are migrated outside the transaction.
+	if _, err := m.resources(); err != nil {
+		return nil, err
+	}
+
+	var result *Session
+	err = m.DB.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		store, resourceStore := NewStore(tx), resources.NewStore(tx)
+
+		sessionID := archived.SessionID
+		if _, err := store.Get(ctx, sessionID); err == nil {
+			sessionID = uuid.String()
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// References to resources in the state are rewritten if a resource gets a new ID.
+		var replacements []string
+		for i, resource := range archivedResources {
+			if _, err := resourceStore.GetByUUID(ctx, resource.UUID); err == nil {
+				newUUID := uuid.String()
+				replacements = append(replacements, "nanobot://resource/"+resource.UUID, "nanobot://resource/"+newUUID)
+				archivedResources[i].UUID = newUUID
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		state := archived.State
+		if len(replacements) > 0 {
+			data, err := json.Marshal(state)
+			if err != nil {
+				return err
+			}
+			state = State{}
+			if err := json.Unmarshal([]byte(strings.NewReplacer(replacements...).Replace(string(data))), &state); err != nil {
+				return err
+			}
+		}
+		state.ID = sessionID
+
+		result = &Session{
+			Type:        archived.Type,
+			SessionID:   sessionID,
+			Description: archived.Description,
+			AccountID:   accountID,
+			State:       state,
+			Config:      archived.Config,
+			Cwd:         archived.Cwd,
+			IsPublic:    archived.IsPublic,
+			Summary:     archived.Summary,
+		}
+		result.CreatedAt = archived.Created
+		if err := store.Create(ctx, result); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+
+		for _, resource := range archivedResources {
+			record := &resources.Resource{
+				UUID:        resource.UUID,
+				SessionID:   sessionID,
+				AccountID:   accountID,
+				Blob:        resource.Blob,
+				MimeType:    resource.MimeType,
+				Name:        resource.Name,
+				Description: resource.Description,
+			}
+			record.CreatedAt = resource.Created
+			if err := resourceStore.Create(ctx, record); err != nil {
+				return fmt.Errorf("failed to create resource %s: %w", resource.UUID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import session %s: %w", archived.SessionID, err)
+	}
+	return result, nil
+}
+
+func readArchive(r io.Reader) (*archivedSession, []archivedResource, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	var (
+		archived          *archivedSession
+		archivedResources []archivedResource
+		tr                = tar.NewReader(gz)
+	)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > maxArchiveEntrySize {
+			return nil, nil, fmt.Errorf("%s in archive is larger than %d bytes", header.Name, maxArchiveEntrySize)
+		}
+
+		name := path.Clean(header.Name)
+		switch {
+		case name == archiveSessionFile:
+			archived = &archivedSession{}
+			if err := json.NewDecoder(tr).Decode(archived); err != nil {
+				return nil, nil, fmt.Errorf("failed to decode %s: %w", name, err)
+			}
+		case strings.HasPrefix(name, archiveResourcesDir) && strings.HasSuffix(name, ".json"):
+			var resource archivedResource
+			if err := json.NewDecoder(tr).Decode(&resource); err != nil {
+				return nil, nil, fmt.Errorf("failed to decode %s: %w", name, err)
+			}
+			if resource.UUID == "" {
+				return nil, nil, fmt.Errorf("resource %s in archive has no uuid", name)
+			}
+			archivedResources = append(archivedResources, resource)
+		}
+	}
+
+	if archived == nil {
+		return nil, nil, fmt.Errorf("archive has no %s", archiveSessionFile)
+	}
+	if archived.Version != ArchiveVersion {
+		return nil, nil, fmt.Errorf("unsupported archive version %d, expected %d", archived.Version, ArchiveVersion)
+	}
+	if archived.SessionID == "" {
+		return nil, nil, fmt.Errorf("archive has no session ID")
+	}
+	return archived, archivedResources, nil
+}
+
+// resources returns the store of the resources of the sessions, which shares the database of the
+// sessions.
+func (m *Manager) resources() (*resources.Store, error) {
+	store := resources.NewStore(m.DB.db)
+	if err := store.Init(); err != nil {
+		return nil, fmt.Errorf("failed to migrate resources: %w", err)
+	}
+	return store, nil
+}
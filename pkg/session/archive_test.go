@@ -0,0 +1,84 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	source, err := NewManager(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := source.DB.Create(ctx, &Session{
+		SessionID:   "s1",
+		AccountID:   "alice",
+		Description: "trip planning",
+		State: State{
+			Attributes: map[string]any{
+				"thread": "see nanobot://resource/r1",
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resourceStore, err := source.resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resourceStore.Create(ctx, &resources.Resource{UUID: "r1", SessionID: "s1", AccountID: "alice", Blob: "aGVsbG8="}); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if err := source.Export(ctx, "s1", &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := NewManager(filepath.Join(dir, "target.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := target.Import(ctx, bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.SessionID != "s1" || imported.AccountID != "alice" || imported.Description != "trip planning" {
+		t.Fatalf("unexpected session: %+v", imported)
+	}
+	if thread := imported.State.Attributes["thread"]; thread != "see nanobot://resource/r1" {
+		t.Errorf("expected the reference to be kept, got %v", thread)
+	}
+
+	// Importing again collides with the IDs of the first import.
+	again, err := target.Import(ctx, bytes.NewReader(archive.Bytes()), ImportOptions{AccountID: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.SessionID == "s1" || again.State.ID != again.SessionID || again.AccountID != "bob" {
+		t.Fatalf("expected a new session ID owned by bob, got %+v", again)
+	}
+
+	targetResources, err := target.resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := targetResources.FindBySessionID(ctx, again.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copied) != 1 || copied[0].UUID == "r1" || copied[0].Blob != "aGVsbG8=" {
+		t.Fatalf("expected the resource with a new ID, got %+v", copied)
+	}
+	if thread := again.State.Attributes["thread"]; thread != "see nanobot://resource/"+copied[0].UUID {
+		t.Errorf("expected the reference to be rewritten, got %v", thread)
+	}
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"gorm.io/gorm"
+)
+
+func testEphemeralSession(t *testing.T) (context.Context, *Manager, *mcp.ServerSession) {
+	t.Helper()
+
+	ctx := types.WithNanobotContext(t.Context(), types.Context{User: types.User{ID: "account"}})
+	m, err := NewManager(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := uuid.String()
+	if err := m.CreateEphemeral(ctx, id, "account"); err != nil {
+		t.Fatal(err)
+	}
+	session, ok, err := m.Acquire(ctx, mcp.MessageHandlerFunc(func(context.Context, mcp.Message) {}), id)
+	if err != nil || !ok {
+		t.Fatalf("failed to acquire session: %v %v", ok, err)
+	}
+	if !types.IsEphemeral(session.GetSession()) {
+		t.Fatal("expected the session to be ephemeral")
+	}
+	return ctx, m, session
+}
+
+func TestEphemeralSessionNotStored(t *testing.T) {
+	ctx, m, session := testEphemeralSession(t)
+
+	session.GetSession().Set(types.DescriptionSessionKey, "secret")
+	if err := m.Store(ctx, session.ID(), session); err != nil {
+		t.Fatal(err)
+	}
+	m.Release(session)
+
+	if _, err := m.DB.Get(ctx, session.ID()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected the session to never be written to the store, got %v", err)
+	}
+
+	// The session stays in memory until it is closed.
+	again, ok, err := m.Acquire(ctx, nil, session.ID())
+	if err != nil || !ok || again != session {
+		t.Fatalf("expected the live session, got %v %v", ok, err)
+	}
+	var description string
+	if again.GetSession().Get(types.DescriptionSessionKey, &description); description != "secret" {
+		t.Errorf("got description %q", description)
+	}
+}
+
+func TestEphemeralSessionClose(t *testing.T) {
+	ctx, m, session := testEphemeralSession(t)
+
+	if m.DeleteEphemeral(ctx, session.ID(), "other") {
+		t.Error("expected the session of another account not to be deleted")
+	}
+	if !m.DeleteEphemeral(ctx, session.ID(), "account") {
+		t.Fatal("expected the session to be deleted")
+	}
+	if session.GetSession().Context().Err() == nil {
+		t.Error("expected the session to be closed")
+	}
+	if _, ok, err := m.Acquire(ctx, nil, session.ID()); err != nil || ok {
+		t.Errorf("expected the session to be dropped, got %v %v", ok, err)
+	}
+}
+
+func TestCloseAccountDropsEphemeralSessions(t *testing.T) {
+	ctx, m, session := testEphemeralSession(t)
+
+	unused := uuid.String()
+	if err := m.CreateEphemeral(ctx, unused, "account"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CloseAccount(ctx, "account"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{session.ID(), unused} {
+		if _, ok, err := m.Acquire(ctx, nil, id); err != nil || ok {
+			t.Errorf("expected session %s to be dropped, got %v %v", id, ok, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"gorm.io/gorm"
+)
+
+// Fork copies the session id into a new session owned by accountID. The history of the copy ends
+// with the message messageID, or holds the whole history if messageID is empty, so an alternative
+// continuation can be explored without changing the original. The resources of the session are
+// copied with new IDs. It returns the new session.
+func (m *Manager) Fork(ctx context.Context, id, messageID, accountID string) (*Session, error) {
+	stored, err := m.DB.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session %s: %w", id, err)
+	}
+	if err := m.DB.ExpandHistory(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	forked := stored.Clone(accountID)
+	if messageID != "" {
+		thread, ok := forked.State.Attributes[types.PreviousExecutionKey]
+		if !ok || thread == nil {
+			return nil, fmt.Errorf("session %s has no messages", id)
+		}
+		var run types.Execution
+		if err := mcp.JSONCoerce(thread, &run); err != nil {
+			return nil, fmt.Errorf("failed to decode the history of session %s: %w", id, err)
+		}
+		truncated, err := truncateExecution(&run, messageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fork session %s: %w", id, err)
+		}
+		forked.State.Attributes[types.PreviousExecutionKey] = truncated
+	}
+
+	// The resources are migrated outside the transaction.
+	if _, err := m.resources(); err != nil {
+		return nil, err
+	}
+
+	err = m.DB.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		resourceStore := resources.NewStore(tx)
+
+		sessionResources, err := resourceStore.FindBySessionID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to list resources: %w", err)
+		}
+
+		// References to the resources in the state are rewritten to the copies.
+		var replacements []string
+		for _, resource := range sessionResources {
+			newUUID := uuid.String()
+			replacements = append(replacements, "nanobot://resource/"+resource.UUID, "nanobot://resource/"+newUUID)
+
+			record := &resources.Resource{
+				UUID:        newUUID,
+				SessionID:   forked.SessionID,
+				AccountID:   accountID,
+				Blob:        resource.Blob,
+				MimeType:    resource.MimeType,
+				Name:        resource.Name,
+				Description: resource.Description,
+			}
+			if err := resourceStore.Create(ctx, record); err != nil {
+				return fmt.Errorf("failed to copy resource %s: %w", resource.UUID, err)
+			}
+		}
+
+		if len(replacements) > 0 {
+			data, err := json.Marshal(forked.State)
+			if err != nil {
+				return err
+			}
+			state := State{}
+			if err := json.Unmarshal([]byte(strings.NewReplacer(replacements...).Replace(string(data))), &state); err != nil {
+				return err
+			}
+			forked.State = state
+		}
+
+		if err := NewStore(tx).Create(ctx, forked); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork session %s: %w", id, err)
+	}
+	return forked, nil
+}
+
+// truncateExecution returns a finished execution whose history ends with the message messageID.
+// The results of the tool calls of that message are kept, so no call is left unanswered.
+func truncateExecution(run *types.Execution, messageID string) (*types.Execution, error) {
+	var messages []types.Message
+	if run.PopulatedRequest != nil {
+		messages = append(messages, run.PopulatedRequest.Input...)
+	}
+	if run.Response != nil {
+		messages = append(messages, run.Response.InternalMessages...)
+		messages = append(messages, run.Response.Output)
+	}
+	for _, callID := range slices.Sorted(maps.Keys(run.ToolOutputs)) {
+		if output := run.ToolOutputs[callID]; output.Done {
+			messages = append(messages, output.Output)
+		}
+	}
+
+	cut := -1
+	for i, msg := range messages {
+		if msg.ID == messageID {
+			cut = i + 1
+			break
+		}
+	}
+	if cut < 0 {
+		return nil, fmt.Errorf("message %s not found", messageID)
+	}
+	for cut < len(messages) && isToolResults(messages[cut]) {
+		cut++
+	}
+
+	req := types.CompletionRequest{}
+	if run.PopulatedRequest != nil {
+		req = *run.PopulatedRequest
+	}
+	req.Input = messages[:cut]
+
+	// An empty response keeps the history in the input, where the next turn reads it from.
+	resp := &types.CompletionResponse{}
+	if run.Response != nil {
+		resp.Model = run.Response.Model
+	}
+
+	return &types.Execution{
+		Request:          run.Request,
+		PopulatedRequest: &req,
+		ToolToMCPServer:  run.ToolToMCPServer,
+		Response:         resp,
+		Done:             true,
+	}, nil
+}
+
+func isToolResults(msg types.Message) bool {
+	if len(msg.Items) == 0 {
+		return false
+	}
+	for _, item := range msg.Items {
+		if item.ToolCallResult == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestFork(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewManager(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := func(id, role, text string) types.Message {
+		return types.Message{ID: id, Role: role, Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: text}}}}
+	}
+	run := &types.Execution{
+		PopulatedRequest: &types.CompletionRequest{
+			Input: []types.Message{
+				text("m1", "user", "plan a trip, see nanobot://resource/r1"),
+				{ID: "m2", Role: "assistant", Items: []types.CompletionItem{{ToolCall: &types.ToolCall{CallID: "c1", Name: "search"}}}},
+				{ID: "m3", Role: "user", Items: []types.CompletionItem{{ToolCallResult: &types.ToolCallResult{CallID: "c1"}}}},
+				text("m4", "assistant", "Lisbon"),
+				text("m5", "user", "somewhere colder"),
+			},
+		},
+		Response: &types.CompletionResponse{Output: text("m6", "assistant", "Oslo")},
+		Done:     true,
+	}
+	if err := m.DB.Create(ctx, &Session{
+		SessionID: "s1",
+		AccountID: "alice",
+		State: State{
+			Attributes: map[string]any{types.PreviousExecutionKey: run},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resourceStore, err := m.resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resourceStore.Create(ctx, &resources.Resource{UUID: "r1", SessionID: "s1", AccountID: "alice", Blob: "aGVsbG8="}); err != nil {
+		t.Fatal(err)
+	}
+
+	forked, err := m.Fork(ctx, "s1", "m2", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := m.DB.Get(ctx, forked.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AccountID != "bob" {
+		t.Errorf("expected the fork to be owned by bob, got %q", stored.AccountID)
+	}
+
+	var history types.Execution
+	if err := mcp.JSONCoerce(stored.State.Attributes[types.PreviousExecutionKey], &history); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, msg := range history.Messages() {
+		ids = append(ids, msg.ID)
+	}
+	// The result of the tool call of m2 is merged into m2.
+	if strings.Join(ids, ",") != "m1,m2" {
+		t.Errorf("expected the history to end with m2, got %v", ids)
+	}
+
+	copies, err := resourceStore.FindBySessionID(ctx, forked.SessionID)
+	if err != nil || len(copies) != 1 {
+		t.Fatalf("expected one copied resource, got %d: %v", len(copies), err)
+	}
+	if !strings.Contains(history.PopulatedRequest.Input[0].Items[0].Content.Text, "nanobot://resource/"+copies[0].UUID) {
+		t.Errorf("expected the reference to point to the copied resource, got %q", history.PopulatedRequest.Input[0].Items[0].Content.Text)
+	}
+
+	if _, err := m.Fork(ctx, "s1", "missing", "alice"); err == nil {
+		t.Error("expected an error for a missing message")
+	}
+}
@@ -0,0 +1,343 @@
+package session
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"gorm.io/gorm"
+)
+
+type MessageWrapper types.Message
+
+func (m MessageWrapper) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *MessageWrapper) Scan(value interface{}) error {
+	return scan(value, m)
+}
+
+// HistoryMessage is an older message of a thread that is stored outside the session state, so that
+// long sessions are not loaded completely for every turn.
+type HistoryMessage struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	SessionID string         `json:"sessionID" gorm:"index;not null"`
+	AccountID string         `json:"accountID,omitempty" gorm:"index"`
+	HistoryID string         `json:"historyID" gorm:"index;not null"`
+	Seq       int            `json:"seq"`
+	Message   MessageWrapper `json:"message" gorm:"type:json"`
+	// Encoding is the serializer of Payload, messages stored as JSON are in Message instead.
+	Encoding string `json:"encoding,omitempty"`
+	Payload  []byte `json:"payload,omitempty"`
+}
+
+// newHistoryMessage returns the row of a message, encoded with the serializer.
+func newHistoryMessage(serializer Serializer, msg types.Message) (HistoryMessage, error) {
+	if serializer == nil || serializer.Name() == SerializerJSON {
+		return HistoryMessage{
+			Message: MessageWrapper(msg),
+		}, nil
+	}
+	payload, err := serializer.Marshal(msg)
+	if err != nil {
+		return HistoryMessage{}, fmt.Errorf("failed to encode message %s as %s: %w", msg.ID, serializer.Name(), err)
+	}
+	return HistoryMessage{
+		Encoding: serializer.Name(),
+		Payload:  payload,
+	}, nil
+}
+
+// decode returns the message of the row, whichever serializer stored it.
+func (h HistoryMessage) decode() (types.Message, error) {
+	if h.Encoding == "" || h.Encoding == SerializerJSON {
+		return types.Message(h.Message), nil
+	}
+	serializer, err := NewSerializer(h.Encoding)
+	if err != nil {
+		return types.Message{}, err
+	}
+	var msg types.Message
+	if err := serializer.Unmarshal(h.Payload, &msg); err != nil {
+		return types.Message{}, err
+	}
+	return msg, nil
+}
+
+var _ types.HistoryLoader = (*Manager)(nil)
+
+// LoadHistory returns the stored older messages of a thread of the session, oldest first.
+func (m *Manager) LoadHistory(ctx context.Context, sessionID string, history *types.ExecutionHistory) ([]types.Message, error) {
+	return m.DB.LoadHistory(ctx, sessionID, history)
+}
+
+// LoadHistory returns the stored older messages of a thread of the session, oldest first.
+func (s *Store) LoadHistory(ctx context.Context, sessionID string, history *types.ExecutionHistory) ([]types.Message, error) {
+	if history == nil || history.ID == "" || history.Messages == 0 {
+		return nil, nil
+	}
+
+	var rows []HistoryMessage
+	err := s.db.WithContext(ctx).
+		Where("session_id = ? and history_id = ? and seq < ?", sessionID, history.ID, history.Messages).
+		Order("seq").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history of session %s: %w", sessionID, err)
+	}
+
+	messages := make([]types.Message, 0, len(rows))
+	for _, row := range rows {
+		msg, err := row.decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode history of session %s: %w", sessionID, err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// ExpandHistory puts the stored older messages back into the threads of the session, for uses that
+// need the complete history, like exporting or copying a session.
+func (s *Store) ExpandHistory(ctx context.Context, session *Session) error {
+	return s.expandHistory(ctx, session.SessionID, &session.State)
+}
+
+func (s *Store) expandHistory(ctx context.Context, sessionID string, state *State) error {
+	for key, value := range state.Attributes {
+		if !isThreadKey(key) || value == nil {
+			continue
+		}
+
+		var run types.Execution
+		if err := mcp.JSONCoerce(value, &run); err != nil || run.History == nil || run.PopulatedRequest == nil {
+			continue
+		}
+
+		older, err := s.LoadHistory(ctx, sessionID, run.History)
+		if err != nil {
+			return err
+		}
+
+		req := *run.PopulatedRequest
+		req.Input = joinHistory(req.Input, older, run.History.Next)
+		run.PopulatedRequest = &req
+		run.History = nil
+		state.Attributes[key] = &run
+	}
+	return nil
+}
+
+// joinHistory inserts the older messages into input before the message next, after the system
+// messages that were kept.
+func joinHistory(input, older []types.Message, next string) []types.Message {
+	i := slices.IndexFunc(input, func(msg types.Message) bool {
+		return msg.ID == next
+	})
+	if i < 0 {
+		i = 0
+		for i < len(input) && input[i].Role == "system" {
+			i++
+		}
+	}
+
+	result := make([]types.Message, 0, len(input)+len(older))
+	result = append(result, input[:i]...)
+	result = append(result, older...)
+	return append(result, input[i:]...)
+}
+
+// offloadHistory moves the older messages of the threads of the session into the history table, so
+// that the state keeps at most window messages of every thread besides its system messages.
+// Threads are only changed in the state that is stored, previous is the state that was stored
+// before and tells which messages were already moved.
+func (s *Store) offloadHistory(ctx context.Context, session *Session, previous map[string]any, window int) error {
+	if window <= 0 {
+		return nil
+	}
+
+	offloaded := map[string]types.ExecutionHistory{}
+	for key, value := range previous {
+		var run types.Execution
+		if !isThreadKey(key) || value == nil || mcp.JSONCoerce(value, &run) != nil || run.History == nil {
+			continue
+		}
+		if run.History.Messages >= offloaded[run.History.ID].Messages {
+			offloaded[run.History.ID] = *run.History
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(session.State.Attributes)) {
+		value := session.State.Attributes[key]
+		if !isThreadKey(key) || value == nil {
+			continue
+		}
+
+		var run types.Execution
+		if err := mcp.JSONCoerce(value, &run); err != nil || run.History == nil || run.History.ID == "" || run.PopulatedRequest == nil {
+			continue
+		}
+
+		// The live thread does not know about the messages that were moved when it was stored
+		// before, but it still holds them.
+		history := *run.History
+		if stored, ok := offloaded[history.ID]; ok && stored.Messages > history.Messages &&
+			slices.ContainsFunc(run.PopulatedRequest.Input, func(msg types.Message) bool {
+				return msg.ID == stored.Next
+			}) {
+			history = stored
+		}
+
+		kept, moved, next := splitHistory(run.PopulatedRequest.Input, history.Next, window)
+		if len(kept) == len(run.PopulatedRequest.Input) {
+			continue
+		}
+
+		rows := make([]HistoryMessage, 0, len(moved))
+		for _, msg := range moved {
+			row, err := newHistoryMessage(s.serializer, msg)
+			if err != nil {
+				return err
+			}
+			row.SessionID = session.SessionID
+			row.AccountID = session.AccountID
+			row.HistoryID = history.ID
+			row.Seq = history.Messages + len(rows)
+			rows = append(rows, row)
+		}
+		if len(rows) > 0 {
+			if err := s.db.WithContext(ctx).CreateInBatches(rows, 100).Error; err != nil {
+				return fmt.Errorf("failed to store history of session %s: %w", session.SessionID, err)
+			}
+		}
+
+		history.Messages += len(rows)
+		history.Next = next
+		offloaded[history.ID] = history
+
+		req := *run.PopulatedRequest
+		req.Input = kept
+		run.PopulatedRequest = &req
+		run.History = &history
+		session.State.Attributes[key] = &run
+	}
+	return nil
+}
+
+// splitHistory splits input into the messages that are kept in the session and the ones that are
+// moved to the history table. Messages before next were moved before and are dropped. The cut is
+// made at the start of a turn so that tool calls stay with their results, and system messages are
+// always kept. It returns the first message that is kept after the system messages.
+func splitHistory(input []types.Message, next string, window int) (kept, moved []types.Message, _ string) {
+	start := 0
+	if next != "" {
+		start = max(0, slices.IndexFunc(input, func(msg types.Message) bool {
+			return msg.ID == next
+		}))
+	}
+
+	cut := start
+	if len(input)-start > window {
+		for i := len(input) - window; i < len(input); i++ {
+			if isTurnStart(input[i]) {
+				cut, next = i, input[i].ID
+				break
+			}
+		}
+	}
+	if cut == 0 {
+		return input, nil, next
+	}
+
+	for i, msg := range input[:cut] {
+		if msg.Role == "system" {
+			kept = append(kept, msg)
+		} else if i >= start {
+			moved = append(moved, msg)
+		}
+	}
+	return append(kept, input[cut:]...), moved, next
+}
+
+func isTurnStart(msg types.Message) bool {
+	return msg.ID != "" && msg.Role == "user" && !slices.ContainsFunc(msg.Items, func(item types.CompletionItem) bool {
+		return item.ToolCallResult != nil
+	})
+}
+
+func isThreadKey(key string) bool {
+	return key == types.PreviousExecutionKey || strings.HasPrefix(key, types.PreviousExecutionKey+"/")
+}
+
+// MigrateHistory encodes the stored messages that were written with another serializer with the
+// serializer of the store, in batches so tables with millions of messages are not loaded at once.
+// It returns the number of messages that were changed.
+func (s *Store) MigrateHistory(ctx context.Context) (int, error) {
+	target := SerializerJSON
+	if s.serializer != nil {
+		target = s.serializer.Name()
+	}
+
+	query := s.db.WithContext(ctx).Model(&HistoryMessage{})
+	if target == SerializerJSON {
+		query = query.Where("encoding <> '' and encoding <> ?", SerializerJSON)
+	} else {
+		query = query.Where("(encoding <> ? or encoding is null)", target)
+	}
+
+	migrated := 0
+	var lastID uint
+	for {
+		var rows []HistoryMessage
+		if err := query.Session(&gorm.Session{}).Where("id > ?", lastID).Order("id").Limit(historyMigrationBatch).Find(&rows).Error; err != nil {
+			return migrated, fmt.Errorf("failed to read history: %w", err)
+		}
+		if len(rows) == 0 {
+			return migrated, nil
+		}
+
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				msg, err := row.decode()
+				if err != nil {
+					return fmt.Errorf("failed to decode history message %d: %w", row.ID, err)
+				}
+				encoded, err := newHistoryMessage(s.serializer, msg)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&row).Select("message", "encoding", "payload").Updates(HistoryMessage{
+					Message:  encoded.Message,
+					Encoding: encoded.Encoding,
+					Payload:  encoded.Payload,
+				}).Error; err != nil {
+					return fmt.Errorf("failed to update history message %d: %w", row.ID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return migrated, err
+		}
+
+		migrated += len(rows)
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// historyMigrationBatch is the number of messages that MigrateHistory encodes in a transaction.
+const historyMigrationBatch = 500
+
+// deleteHistory deletes the stored older messages of expired sessions.
+func deleteHistory(_ context.Context, tx *gorm.DB, sessionIDs []string) error {
+	if err := tx.Where("session_id IN ?", sessionIDs).Delete(&HistoryMessage{}).Error; err != nil {
+		return fmt.Errorf("failed to delete history: %w", err)
+	}
+	return nil
+}
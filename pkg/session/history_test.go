@@ -0,0 +1,86 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestOffloadHistory(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStoreFromDSN(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := []types.Message{{ID: "system", Role: "system"}}
+	turn := func(i int) {
+		input = append(input,
+			types.Message{ID: fmt.Sprintf("user-%d", i), Role: "user"},
+			types.Message{ID: fmt.Sprintf("assistant-%d", i), Role: "assistant"})
+	}
+	for i := range 6 {
+		turn(i)
+	}
+
+	live := func() *Session {
+		return &Session{
+			SessionID: "s1",
+			State: State{
+				Attributes: map[string]any{
+					types.PreviousExecutionKey: &types.Execution{
+						PopulatedRequest: &types.CompletionRequest{Input: input},
+						History:          &types.ExecutionHistory{ID: "h1"},
+					},
+				},
+			},
+		}
+	}
+	thread := func(s *Session) (run types.Execution) {
+		t.Helper()
+		if err := mcp.JSONCoerce(s.State.Attributes[types.PreviousExecutionKey], &run); err != nil {
+			t.Fatal(err)
+		}
+		return run
+	}
+
+	first := live()
+	if err := store.offloadHistory(ctx, first, nil, 4); err != nil {
+		t.Fatal(err)
+	}
+	run := thread(first)
+	if len(run.PopulatedRequest.Input) != 5 || run.PopulatedRequest.Input[1].ID != "user-4" {
+		t.Fatalf("unexpected kept messages %+v", run.PopulatedRequest.Input)
+	}
+	if *run.History != (types.ExecutionHistory{ID: "h1", Messages: 8, Next: "user-4"}) {
+		t.Fatalf("unexpected history %+v", run.History)
+	}
+
+	// The live thread still holds the moved messages, they are not stored twice.
+	turn(6)
+	second := live()
+	if err := store.offloadHistory(ctx, second, first.State.Attributes, 4); err != nil {
+		t.Fatal(err)
+	}
+	run = thread(second)
+	if *run.History != (types.ExecutionHistory{ID: "h1", Messages: 10, Next: "user-5"}) {
+		t.Fatalf("unexpected history %+v", run.History)
+	}
+
+	if err := store.ExpandHistory(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	run = thread(second)
+	if run.History != nil || len(run.PopulatedRequest.Input) != len(input) {
+		t.Fatalf("expected %d messages, got %d", len(input), len(run.PopulatedRequest.Input))
+	}
+	for i, msg := range run.PopulatedRequest.Input {
+		if msg.ID != input[i].ID {
+			t.Fatalf("message %d is %s, expected %s", i, msg.ID, input[i].ID)
+		}
+	}
+}
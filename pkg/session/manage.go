@@ -0,0 +1,59 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"gorm.io/gorm"
+)
+
+// Rename sets the description of a session, which is its name in the list of chats. Renaming is
+// not activity, so the session keeps its update time.
+func (m *Manager) Rename(ctx context.Context, id, description string) error {
+	result := m.DB.db.WithContext(ctx).Model(&Session{}).Where("session_id = ?", id).
+		UpdateColumn("description", description)
+	if result.Error != nil {
+		return fmt.Errorf("failed to rename session %s: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("session %s: %w", id, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// Delete permanently deletes the sessions with their attachments and stored history.
+func (m *Manager) Delete(ctx context.Context, ids ...string) error {
+	// The resources are migrated outside the transaction.
+	if _, err := m.resources(); err != nil {
+		return err
+	}
+	if err := m.deleteSessions(ctx, ids, nil); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return nil
+}
+
+// Transcript returns the messages of the current thread of a session, including the older
+// messages that are stored separately. System messages are left out.
+func (m *Manager) Transcript(ctx context.Context, stored *Session) ([]types.Message, error) {
+	if err := m.DB.ExpandHistory(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	var execution types.Execution
+	if thread, ok := stored.State.Attributes[types.PreviousExecutionKey]; ok {
+		if err := mcp.JSONCoerce(thread, &execution); err != nil {
+			return nil, fmt.Errorf("failed to decode history of session %s: %w", stored.SessionID, err)
+		}
+	}
+
+	var messages []types.Message
+	for _, msg := range execution.Messages() {
+		if msg.Role != "system" {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
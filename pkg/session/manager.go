@@ -12,35 +12,84 @@ import (
 	"sync"
 	"time"
 
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/events"
+	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/metrics"
 	"github.com/nanobot-ai/nanobot/pkg/types"
 	"gorm.io/gorm"
 )
 
-func NewManager(dsn string) (*Manager, error) {
+type ManagerOptions struct {
+	// Redis shares ephemeral sessions and progress with other replicas. Without it they are only
+	// known to this process. The state of ephemeral sessions is then kept in Redis until they are
+	// closed or unused for 30 minutes.
+	Redis *Redis
+	// HistoryWindow is the number of recent messages of a thread that are kept in the session, older
+	// messages are stored separately and only loaded when needed. Zero keeps the whole thread.
+	HistoryWindow int
+	// Serializer is the format of the messages written to the history table, one of Serializers.
+	// Messages are read in the format they were written with. Defaults to JSON.
+	Serializer string
+}
+
+func (m ManagerOptions) Merge(other ManagerOptions) (result ManagerOptions) {
+	result.Redis = complete.Last(m.Redis, other.Redis)
+	result.HistoryWindow = complete.Last(m.HistoryWindow, other.HistoryWindow)
+	result.Serializer = complete.Last(m.Serializer, other.Serializer)
+	return
+}
+
+func NewManager(dsn string, opts ...ManagerOptions) (*Manager, error) {
+	opt := complete.Complete(opts...)
+
+	serializer, err := NewSerializer(opt.Serializer)
+	if err != nil {
+		return nil, err
+	}
+
 	store, err := NewStoreFromDSN(dsn)
 	if err != nil {
 		return nil, err
 	}
+	store.serializer = serializer
 
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Manager{
-		ctx:          ctx,
-		close:        cancel,
-		DB:           store,
-		root:         &Session{},
-		liveSessions: make(map[string]liveSession),
-	}, nil
+	m := &Manager{
+		ctx:           ctx,
+		close:         cancel,
+		DB:            store,
+		root:          &Session{},
+		redis:         opt.Redis,
+		historyWindow: opt.HistoryWindow,
+		liveSessions:  make(map[string]liveSession),
+		ephemeral:     make(map[string]string),
+	}
+	if m.redis != nil {
+		go m.redis.run(ctx, m.forwardProgress)
+	}
+	return m, nil
 }
 
+// ephemeralIdleTimeout is how long an ephemeral session is kept in memory, and in Redis if
+// configured, after its last use. As it is never written to the store it is gone for good once
+// evicted.
+const ephemeralIdleTimeout = 30 * time.Minute
+
 type Manager struct {
 	ctx   context.Context
 	close context.CancelFunc
 	DB    *Store
 	root  *Session
+	redis *Redis
+	// historyWindow is the number of messages of a thread that are kept in the session state.
+	historyWindow int
 
 	liveSessionsLock sync.Mutex
 	liveSessions     map[string]liveSession
+	// ephemeral holds the account IDs of ephemeral sessions that were created but not used yet.
+	ephemeral map[string]string
 }
 
 type liveSession struct {
@@ -64,24 +113,42 @@ func (m *Manager) loadAttributesFromRecord(stored *Session, session *mcp.ServerS
 	session.GetSession().Set(types.DescriptionSessionKey, stored.Description)
 	session.GetSession().Set(types.PublicSessionKey, stored.IsPublic)
 	session.GetSession().Set(types.AccountIDSessionKey, stored.AccountID)
+	if summary := types.SessionSummary(stored.Summary); !summary.IsZero() {
+		session.GetSession().Set(types.SummarySessionKey, summary)
+	}
 }
 
 func (m *Manager) saveAttributesToRecord(stored *Session, session *mcp.ServerSession) error {
-	var config types.Config
+	var (
+		config  types.Config
+		summary types.SessionSummary
+	)
 
 	session.GetSession().Get(types.DescriptionSessionKey, &stored.Description)
 	session.GetSession().Get(types.PublicSessionKey, &stored.IsPublic)
 	session.GetSession().Get(types.ConfigSessionKey, &config)
+	if session.GetSession().Get(types.SummarySessionKey, &summary) {
+		stored.Summary = Summary(summary)
+	}
 
 	stored.Config = ConfigWrapper(config)
 	return nil
 }
 
-func (m *Manager) Store(ctx context.Context, id string, session *mcp.ServerSession) error {
+func (m *Manager) Store(ctx context.Context, id string, session *mcp.ServerSession) (retErr error) {
 	if id == "" {
 		return nil
 	}
 
+	if types.IsEphemeral(session.GetSession()) {
+		return m.storeEphemeral(ctx, id, session)
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.ObserveSessionStore("save", time.Since(start), retErr)
+	}()
+
 	var accountID string
 	session.GetSession().Get(types.AccountIDSessionKey, &accountID)
 
@@ -106,40 +173,256 @@ func (m *Manager) Store(ctx context.Context, id string, session *mcp.ServerSessi
 	if err != nil {
 		return fmt.Errorf("failed to get session state: %w", err)
 	}
+	previous := stored.State.Attributes
 	stored.State = *(*State)(state)
 
-	if create {
-		if err := m.DB.Create(ctx, stored); err != nil {
-			return fmt.Errorf("failed to create session record: %w", err)
+	err = m.DB.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		store := NewStore(tx)
+		if err := store.offloadHistory(ctx, stored, previous, m.historyWindow); err != nil {
+			return err
+		}
+		if create {
+			if err := store.Create(ctx, stored); err != nil {
+				return fmt.Errorf("failed to create session record: %w", err)
+			}
+			return nil
 		}
+		return store.Update(ctx, stored)
+	})
+	if err != nil {
+		return err
+	}
 
+	if create {
 		m.liveSessionsLock.Lock()
-		live, ok := m.liveSessions[id]
-		if ok {
-			if live.session != nil {
-				live.session.Close(false)
-			}
-			live.count++
-			live.session = session
+		m.setLive(id, session)
+		m.liveSessionsLock.Unlock()
+		events.Session(ctx, id, accountID)
+	}
 
-			m.liveSessions[id] = live
-		} else {
-			m.liveSessions[id] = liveSession{
-				session: session,
-				count:   1,
-			}
+	m.loadAttributesFromRecord(stored, session)
+	return nil
+}
+
+// setLive makes session the live session for id. The caller must hold liveSessionsLock.
+func (m *Manager) setLive(id string, session *mcp.ServerSession) {
+	m.publishProgress(session)
+
+	live, ok := m.liveSessions[id]
+	if ok {
+		if live.session != nil {
+			live.session.Close(false)
 		}
-		m.liveSessionsLock.Unlock()
+		live.count++
+		live.session = session
+
+		m.liveSessions[id] = live
 	} else {
-		if err := m.DB.Update(ctx, stored); err != nil {
+		m.liveSessions[id] = liveSession{
+			session: session,
+			count:   1,
+		}
+	}
+}
+
+// storeEphemeral keeps an ephemeral session in memory, and in Redis until ephemeralIdleTimeout if
+// configured. It is never written to the store.
+func (m *Manager) storeEphemeral(ctx context.Context, id string, session *mcp.ServerSession) error {
+	m.liveSessionsLock.Lock()
+	delete(m.ephemeral, id)
+	if live, ok := m.liveSessions[id]; !ok || live.session != session {
+		m.setLive(id, session)
+	}
+	m.liveSessionsLock.Unlock()
+
+	if m.redis == nil {
+		return nil
+	}
+
+	var accountID string
+	session.GetSession().Get(types.AccountIDSessionKey, &accountID)
+	state, err := session.GetSession().State()
+	if err != nil {
+		return fmt.Errorf("failed to get session state: %w", err)
+	}
+	return m.redis.saveEphemeral(ctx, id, accountID, *state)
+}
+
+// ephemeralState is the state of a new ephemeral session.
+func ephemeralState(id, accountID string) mcp.SessionState {
+	return mcp.SessionState{
+		ID: id,
+		InitializeRequest: mcp.InitializeRequest{
+			Capabilities: mcp.ClientCapabilities{
+				Elicitation: &struct{}{},
+			},
+		},
+		Attributes: map[string]any{
+			types.AccountIDSessionKey: accountID,
+			types.EphemeralSessionKey: true,
+		},
+	}
+}
+
+// CreateEphemeral reserves a session ID for an ephemeral session owned by accountID. The session is
+// created in memory on first use and is never written to the store, only to Redis if configured.
+func (m *Manager) CreateEphemeral(ctx context.Context, id, accountID string) error {
+	if m.redis != nil {
+		if err := m.redis.saveEphemeral(ctx, id, accountID, ephemeralState(id, accountID)); err != nil {
 			return err
 		}
 	}
 
-	m.loadAttributesFromRecord(stored, session)
+	m.liveSessionsLock.Lock()
+	m.ephemeral[id] = accountID
+	m.liveSessionsLock.Unlock()
+
+	events.Session(ctx, id, accountID)
+	return nil
+}
+
+// DeleteEphemeral closes and forgets the ephemeral session id if it is owned by accountID. It returns
+// false if there is no such ephemeral session.
+func (m *Manager) DeleteEphemeral(ctx context.Context, id, accountID string) bool {
+	var shared bool
+	if m.redis != nil {
+		record, ok, err := m.redis.loadEphemeral(ctx, id)
+		if err != nil {
+			log.Errorf(ctx, "failed to load ephemeral session %s: %v", id, err)
+		} else if ok && record.AccountID == accountID {
+			if err := m.redis.deleteEphemeral(ctx, id, accountID); err != nil {
+				log.Errorf(ctx, "failed to delete ephemeral session %s: %v", id, err)
+			}
+			shared = true
+		}
+	}
+
+	return m.deleteLocalEphemeral(id, accountID) || shared
+}
+
+func (m *Manager) deleteLocalEphemeral(id, accountID string) bool {
+	m.liveSessionsLock.Lock()
+	defer m.liveSessionsLock.Unlock()
+
+	// A session that was used is live and may still be reserved.
+	var reserved bool
+	if owner, ok := m.ephemeral[id]; ok {
+		if owner != accountID {
+			return false
+		}
+		delete(m.ephemeral, id)
+		reserved = true
+	}
+
+	live, ok := m.liveSessions[id]
+	if !ok || !types.IsEphemeral(live.session.GetSession()) {
+		return reserved
+	}
+
+	var owner string
+	live.session.GetSession().Get(types.AccountIDSessionKey, &owner)
+	if owner != accountID {
+		return reserved
+	}
+
+	delete(m.liveSessions, id)
+	m.unsubscribeProgress(id)
+	live.session.Close(true)
+	return true
+}
+
+// CloseAccount closes and forgets all live and ephemeral sessions owned by accountID, so that none
+// of them is written back to the store after the data of the account was erased.
+func (m *Manager) CloseAccount(ctx context.Context, accountID string) error {
+	if m.redis != nil {
+		if err := m.redis.deleteAccount(ctx, accountID); err != nil {
+			return fmt.Errorf("failed to delete ephemeral sessions: %w", err)
+		}
+	}
+
+	m.liveSessionsLock.Lock()
+	defer m.liveSessionsLock.Unlock()
+
+	for id, owner := range m.ephemeral {
+		if owner == accountID {
+			delete(m.ephemeral, id)
+		}
+	}
+
+	for id, live := range m.liveSessions {
+		var owner string
+		live.session.GetSession().Get(types.AccountIDSessionKey, &owner)
+		if owner == accountID {
+			delete(m.liveSessions, id)
+			m.unsubscribeProgress(id)
+			live.session.Close(true)
+		}
+	}
 	return nil
 }
 
+func (m *Manager) newEphemeralSession(ctx context.Context, server mcp.MessageHandler, id string) (*mcp.ServerSession, bool, error) {
+	m.liveSessionsLock.Lock()
+	accountID, ok := m.ephemeral[id]
+	m.liveSessionsLock.Unlock()
+
+	state := ephemeralState(id, accountID)
+	if !ok {
+		if m.redis == nil {
+			return nil, false, nil
+		}
+		// The session may have been created or used on another replica.
+		record, ok, err := m.redis.loadEphemeral(ctx, id)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		state = record.State
+		if state.Attributes == nil {
+			state.Attributes = make(map[string]any)
+		} else {
+			state.Attributes[".keys"] = slices.Collect(maps.Keys(state.Attributes))
+		}
+		state.Attributes[types.AccountIDSessionKey] = record.AccountID
+		state.Attributes[types.EphemeralSessionKey] = true
+	}
+
+	serverSession, err := mcp.NewExistingServerSession(m.ctx, state, server)
+	if err != nil {
+		return nil, false, err
+	}
+	return serverSession, true, nil
+}
+
+// publishProgress publishes the progress sent to the client of the session to the other replicas,
+// and receives the progress they publish for it.
+func (m *Manager) publishProgress(session *mcp.ServerSession) {
+	if m.redis != nil {
+		session.GetSession().AddFilter(m.redis.publishFilter(session.ID()))
+		m.redis.subscribe(session.ID())
+	}
+}
+
+// unsubscribeProgress stops receiving the progress of a session that is no longer live.
+func (m *Manager) unsubscribeProgress(id string) {
+	if m.redis != nil {
+		m.redis.unsubscribe(id)
+	}
+}
+
+// forwardProgress sends progress published by another replica to the client of the session, if its
+// client is connected to this replica.
+func (m *Manager) forwardProgress(ctx context.Context, sessionID string, msg mcp.Message) {
+	m.liveSessionsLock.Lock()
+	live, ok := m.liveSessions[sessionID]
+	m.liveSessionsLock.Unlock()
+	if !ok {
+		return
+	}
+
+	// Without a reader the message is dropped, the client is connected to another replica.
+	_ = live.session.GetSession().Send(ctx, msg)
+}
+
 func (m *Manager) ExtractID(req *http.Request) string {
 	id := req.Header.Get("Mcp-Session-Id")
 	if id != "" {
@@ -161,11 +444,16 @@ func (m *Manager) ExtractID(req *http.Request) string {
 	return ""
 }
 
+// checkAccount returns whether the user of ctx can resume the session: its owner, an admin, or
+// anyone if the session is public.
 func checkAccount(ctx context.Context, serverSession *mcp.ServerSession) bool {
 	var (
 		account        string
 		nanobotContext = types.NanobotContext(ctx)
 	)
+	if nanobotContext.Admin {
+		return true
+	}
 	serverSession.GetSession().Get(types.AccountIDSessionKey, &account)
 	if account != nanobotContext.User.ID {
 		var isPublic bool
@@ -201,6 +489,9 @@ func (m *Manager) Acquire(ctx context.Context, server mcp.MessageHandler, id str
 	m.liveSessionsLock.Unlock()
 
 	serverSession, ok, err := m.loadSessionFromDatabase(ctx, server, id)
+	if err == nil && !ok {
+		serverSession, ok, err = m.newEphemeralSession(ctx, server, id)
+	}
 	if err != nil || !ok {
 		return nil, false, err
 	}
@@ -218,6 +509,7 @@ func (m *Manager) Acquire(ctx context.Context, server mcp.MessageHandler, id str
 		m.liveSessionsLock.Unlock()
 		return live.session, true, nil
 	}
+	m.publishProgress(serverSession)
 	m.liveSessions[id] = liveSession{
 		session: serverSession,
 		count:   1,
@@ -235,8 +527,12 @@ func (m *Manager) Release(session *mcp.ServerSession) {
 	if ok {
 		live.count--
 		if live.count == 0 {
+			idleTimeout := 10 * time.Second
+			if types.IsEphemeral(session.GetSession()) {
+				idleTimeout = ephemeralIdleTimeout
+			}
 			go func(sessionID string) {
-				time.Sleep(10 * time.Second)
+				time.Sleep(idleTimeout)
 
 				m.liveSessionsLock.Lock()
 				defer m.liveSessionsLock.Unlock()
@@ -244,6 +540,7 @@ func (m *Manager) Release(session *mcp.ServerSession) {
 				live, ok := m.liveSessions[sessionID]
 				if ok && live.count == 0 {
 					delete(m.liveSessions, sessionID)
+					m.unsubscribeProgress(sessionID)
 					live.session.Close(false)
 				}
 			}(session.ID())
@@ -256,7 +553,11 @@ func (m *Manager) Release(session *mcp.ServerSession) {
 }
 
 func (m *Manager) loadSessionFromDatabase(ctx context.Context, server mcp.MessageHandler, id string) (*mcp.ServerSession, bool, error) {
+	start := time.Now()
 	storedSession, err := m.DB.Get(ctx, id)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		metrics.ObserveSessionStore("load", time.Since(start), err)
+	}
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, false, nil
 	} else if err != nil {
@@ -294,3 +595,16 @@ func (m *Manager) LoadAndDelete(ctx context.Context, server mcp.MessageHandler,
 	}
 	return session, true, nil
 }
+
+var _ types.SummaryStore = (*Manager)(nil)
+
+// StoreSummary saves the summary of a session that was updated after the session was stored.
+func (m *Manager) StoreSummary(ctx context.Context, sessionID string, summary types.SessionSummary) error {
+	err := m.DB.db.WithContext(ctx).Model(&Session{}).
+		Where("session_id = ?", sessionID).
+		Update("summary", Summary(summary)).Error
+	if err != nil {
+		return fmt.Errorf("failed to store summary of session %s: %w", sessionID, err)
+	}
+	return nil
+}
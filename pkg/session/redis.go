@@ -0,0 +1,292 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisSessionPrefix  = "nanobot:session:"
+	redisAccountPrefix  = "nanobot:account:"
+	redisProgressPrefix = "nanobot:progress:"
+
+	// redisQueueSize is the number of progress notifications waiting to be published, more are
+	// dropped.
+	redisQueueSize = 4096
+	// redisBatchSize is the maximum number of progress notifications published at once.
+	redisBatchSize = 128
+)
+
+// Redis shares the state of ephemeral sessions and their progress between the replicas of a
+// nanobot server, so that every replica can serve a session and stream its progress to the replica
+// that holds the connection of the client. Sessions that are not ephemeral are shared through the
+// database.
+//
+// Unlike the store, Redis holds the state of ephemeral sessions, including their messages, until
+// they are closed or unused for ephemeralIdleTimeout.
+type Redis struct {
+	client *redis.Client
+	// node identifies this replica, it does not forward the progress it published itself.
+	node string
+	// queue holds the progress waiting to be published, so that requests do not wait for Redis.
+	queue  chan queuedProgress
+	pubsub *redis.PubSub
+
+	subscriptionsLock sync.Mutex
+	// subscriptions holds the IDs of the sessions whose progress this replica receives.
+	subscriptions        map[string]bool
+	subscriptionsChanged chan struct{}
+}
+
+// NewRedis connects to the Redis server at url, in the form redis://[user:password@]host:port/db.
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	return &Redis{
+		client:               client,
+		node:                 uuid.String(),
+		queue:                make(chan queuedProgress, redisQueueSize),
+		pubsub:               client.Subscribe(context.Background()),
+		subscriptions:        make(map[string]bool),
+		subscriptionsChanged: make(chan struct{}, 1),
+	}, nil
+}
+
+// ephemeralRecord is the state of an ephemeral session as stored in Redis.
+type ephemeralRecord struct {
+	AccountID string           `json:"accountID"`
+	State     mcp.SessionState `json:"state"`
+}
+
+// progressEnvelope is a progress notification as published to Redis.
+type progressEnvelope struct {
+	Node    string      `json:"node"`
+	Message mcp.Message `json:"message"`
+}
+
+type queuedProgress struct {
+	sessionID string
+	data      []byte
+}
+
+func (r *Redis) saveEphemeral(ctx context.Context, id, accountID string, state mcp.SessionState) error {
+	data, err := json.Marshal(ephemeralRecord{
+		AccountID: accountID,
+		State:     state,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal session %s: %w", id, err)
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, redisSessionPrefix+id, data, ephemeralIdleTimeout)
+		pipe.SAdd(ctx, redisAccountPrefix+accountID, id)
+		pipe.Expire(ctx, redisAccountPrefix+accountID, ephemeralIdleTimeout)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save session %s: %w", id, err)
+	}
+	return nil
+}
+
+func (r *Redis) loadEphemeral(ctx context.Context, id string) (*ephemeralRecord, bool, error) {
+	data, err := r.client.GetEx(ctx, redisSessionPrefix+id, ephemeralIdleTimeout).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("failed to load session %s: %w", id, err)
+	}
+
+	var record ephemeralRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal session %s: %w", id, err)
+	}
+	return &record, true, nil
+}
+
+func (r *Redis) deleteEphemeral(ctx context.Context, id, accountID string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, redisSessionPrefix+id)
+		pipe.SRem(ctx, redisAccountPrefix+accountID, id)
+		return nil
+	})
+	return err
+}
+
+// deleteAccount deletes all ephemeral sessions of the account.
+func (r *Redis) deleteAccount(ctx context.Context, accountID string) error {
+	ids, err := r.client.SMembers(ctx, redisAccountPrefix+accountID).Result()
+	if err != nil {
+		return err
+	}
+	keys := []string{redisAccountPrefix + accountID}
+	for _, id := range ids {
+		keys = append(keys, redisSessionPrefix+id)
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// publishFilter returns a filter that queues the progress notifications sent to the client of the
+// session to be published.
+func (r *Redis) publishFilter(sessionID string) mcp.MessageFilter {
+	return func(ctx context.Context, msg *mcp.Message) (*mcp.Message, error) {
+		if msg.Method != "notifications/progress" || ctx.Value(forwardedKey{}) != nil {
+			return msg, nil
+		}
+		data, err := json.Marshal(progressEnvelope{
+			Node:    r.node,
+			Message: *msg,
+		})
+		if err != nil {
+			return msg, nil
+		}
+		select {
+		case r.queue <- queuedProgress{sessionID: sessionID, data: data}:
+		default:
+			log.Errorf(ctx, "failed to publish progress of session %s: queue is full", sessionID)
+		}
+		return msg, nil
+	}
+}
+
+// forwardedKey marks the context of progress that was received from another replica, so that it is
+// not published again.
+type forwardedKey struct{}
+
+// run publishes the queued progress and passes the progress published by other replicas for the
+// subscribed sessions to forward until the context is done.
+func (r *Redis) run(ctx context.Context, forward func(ctx context.Context, sessionID string, msg mcp.Message)) {
+	go r.publishQueued(ctx)
+	go r.syncSubscriptions(ctx)
+	r.receiveProgress(ctx, forward)
+}
+
+// publishQueued publishes the queued progress in batches, in the order it was queued.
+func (r *Redis) publishQueued(ctx context.Context) {
+	for {
+		var batch []queuedProgress
+		select {
+		case <-ctx.Done():
+			return
+		case progress := <-r.queue:
+			batch = append(batch, progress)
+		}
+	fill:
+		for len(batch) < redisBatchSize {
+			select {
+			case progress := <-r.queue:
+				batch = append(batch, progress)
+			default:
+				break fill
+			}
+		}
+
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, progress := range batch {
+				pipe.Publish(ctx, redisProgressPrefix+progress.sessionID, progress.data)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf(ctx, "failed to publish progress of %d sessions: %v", len(batch), err)
+		}
+	}
+}
+
+// subscribe starts receiving the progress of the session from other replicas.
+func (r *Redis) subscribe(sessionID string) {
+	r.subscriptionsLock.Lock()
+	r.subscriptions[sessionID] = true
+	r.subscriptionsLock.Unlock()
+	r.subscriptionsUpdated()
+}
+
+// unsubscribe stops receiving the progress of the session.
+func (r *Redis) unsubscribe(sessionID string) {
+	r.subscriptionsLock.Lock()
+	delete(r.subscriptions, sessionID)
+	r.subscriptionsLock.Unlock()
+	r.subscriptionsUpdated()
+}
+
+func (r *Redis) subscriptionsUpdated() {
+	select {
+	case r.subscriptionsChanged <- struct{}{}:
+	default:
+	}
+}
+
+// syncSubscriptions subscribes to the channels of the sessions in subscriptions and unsubscribes from
+// the others, so that subscribe and unsubscribe do not wait for Redis.
+func (r *Redis) syncSubscriptions(ctx context.Context) {
+	subscribed := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.subscriptionsChanged:
+		}
+
+		var add, remove []string
+		r.subscriptionsLock.Lock()
+		for id := range r.subscriptions {
+			if !subscribed[id] {
+				subscribed[id] = true
+				add = append(add, redisProgressPrefix+id)
+			}
+		}
+		for id := range subscribed {
+			if !r.subscriptions[id] {
+				delete(subscribed, id)
+				remove = append(remove, redisProgressPrefix+id)
+			}
+		}
+		r.subscriptionsLock.Unlock()
+
+		// On errors the channels are still (un)subscribed when the connection is reestablished.
+		if len(add) > 0 {
+			if err := r.pubsub.Subscribe(ctx, add...); err != nil {
+				log.Errorf(ctx, "failed to subscribe to progress: %v", err)
+			}
+		}
+		if len(remove) > 0 {
+			if err := r.pubsub.Unsubscribe(ctx, remove...); err != nil {
+				log.Errorf(ctx, "failed to unsubscribe from progress: %v", err)
+			}
+		}
+	}
+}
+
+// receiveProgress passes the progress published by other replicas to forward until the context is
+// done.
+func (r *Redis) receiveProgress(ctx context.Context, forward func(ctx context.Context, sessionID string, msg mcp.Message)) {
+	context.AfterFunc(ctx, func() {
+		_ = r.pubsub.Close()
+	})
+
+	ctx = context.WithValue(ctx, forwardedKey{}, true)
+	for published := range r.pubsub.Channel() {
+		var envelope progressEnvelope
+		if err := json.Unmarshal([]byte(published.Payload), &envelope); err != nil {
+			log.Errorf(ctx, "failed to unmarshal progress from %s: %v", published.Channel, err)
+			continue
+		}
+		if envelope.Node == r.node {
+			continue
+		}
+		forward(ctx, strings.TrimPrefix(published.Channel, redisProgressPrefix), envelope.Message)
+	}
+}
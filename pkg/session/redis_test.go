@@ -0,0 +1,113 @@
+package session_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/harness"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+var noopHandler = mcp.MessageHandlerFunc(func(context.Context, mcp.Message) {})
+
+// replicas returns managers that share the Redis server at url, each with its own store.
+func replicas(t *testing.T, url string, n int) []*session.Manager {
+	t.Helper()
+
+	var managers []*session.Manager
+	for range n {
+		redis, err := session.NewRedis(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := session.NewManager(filepath.Join(t.TempDir(), "sessions.db"), session.ManagerOptions{Redis: redis})
+		if err != nil {
+			t.Fatal(err)
+		}
+		managers = append(managers, m)
+	}
+	return managers
+}
+
+func TestRedisEphemeralSessions(t *testing.T) {
+	managers := replicas(t, harness.Redis(t), 3)
+	ctx := types.WithNanobotContext(t.Context(), types.Context{User: types.User{ID: "account"}})
+	id := uuid.String()
+
+	if err := managers[0].CreateEphemeral(ctx, id, "account"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, ok, err := managers[1].Acquire(ctx, noopHandler, id)
+	if err != nil || !ok {
+		t.Fatalf("expected the session on the other replica, got %v %v", ok, err)
+	}
+	if !types.IsEphemeral(s.GetSession()) {
+		t.Error("expected the session to be ephemeral")
+	}
+	s.GetSession().Set(types.DescriptionSessionKey, "secret")
+	if err := managers[1].Store(ctx, id, s); err != nil {
+		t.Fatal(err)
+	}
+	managers[1].Release(s)
+
+	for i, m := range managers {
+		if _, err := m.DB.Get(ctx, id); err == nil {
+			t.Errorf("expected the session to never be written to the store of replica %d", i)
+		}
+	}
+
+	if !managers[0].DeleteEphemeral(ctx, id, "account") {
+		t.Fatal("expected the session to be deleted")
+	}
+	if _, ok, err := managers[2].Acquire(ctx, noopHandler, id); err != nil || ok {
+		t.Errorf("expected the session to be dropped on close, got %v %v", ok, err)
+	}
+}
+
+func TestRedisProgress(t *testing.T) {
+	managers := replicas(t, harness.Redis(t), 2)
+	ctx := types.WithNanobotContext(t.Context(), types.Context{User: types.User{ID: "account"}})
+	id := uuid.String()
+
+	if err := managers[0].CreateEphemeral(ctx, id, "account"); err != nil {
+		t.Fatal(err)
+	}
+	sender, ok, err := managers[0].Acquire(ctx, noopHandler, id)
+	if err != nil || !ok {
+		t.Fatalf("failed to acquire session: %v %v", ok, err)
+	}
+	receiver, ok, err := managers[1].Acquire(ctx, noopHandler, id)
+	if err != nil || !ok {
+		t.Fatalf("failed to acquire session on the other replica: %v %v", ok, err)
+	}
+	receiver.StartReading()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Subscriptions are made in the background, so send until the first progress is received.
+	go func() {
+		for ctx.Err() == nil {
+			_ = sender.GetSession().Send(ctx, mcp.Message{
+				Method: "notifications/progress",
+				Params: json.RawMessage(`{"progressToken": "token", "progress": 1}`),
+			})
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+
+	msg, ok := receiver.Read(ctx)
+	if !ok {
+		t.Fatal("expected the progress to be forwarded to the other replica")
+	}
+	if msg.Method != "notifications/progress" || string(msg.Params) != `{"progressToken":"token","progress":1}` {
+		t.Errorf("unexpected message %s %s", msg.Method, msg.Params)
+	}
+}
@@ -0,0 +1,315 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultRetentionInterval is how often the janitor expires sessions.
+	DefaultRetentionInterval = time.Hour
+	// expireBatchSize is the number of sessions deleted in one transaction.
+	expireBatchSize = 100
+)
+
+// RetentionPolicy limits the sessions that are kept. Count and storage are limited per account and
+// agent, the most recently updated sessions are kept. Zero or negative values disable a limit.
+type RetentionPolicy struct {
+	MaxAge     time.Duration
+	MaxCount   int
+	MaxStorage int64
+}
+
+func (r RetentionPolicy) enabled() bool {
+	return r.MaxAge > 0 || r.MaxCount > 0 || r.MaxStorage > 0
+}
+
+// override applies the retention settings of an agent.
+func (r RetentionPolicy) override(agent *types.AgentRetention) RetentionPolicy {
+	if agent == nil {
+		return r
+	}
+	if agent.MaxAge != "" {
+		if maxAge, err := time.ParseDuration(agent.MaxAge); err == nil {
+			r.MaxAge = maxAge
+		}
+	}
+	if agent.MaxCount != 0 {
+		r.MaxCount = agent.MaxCount
+	}
+	if agent.MaxStorageMB != 0 {
+		r.MaxStorage = int64(agent.MaxStorageMB) << 20
+	}
+	return r
+}
+
+// DeleteHook deletes the rows that belong to expired sessions, in the transaction that deletes the
+// sessions.
+type DeleteHook func(ctx context.Context, tx *gorm.DB, sessionIDs []string) error
+
+type RetentionOptions struct {
+	// Policy applies to all sessions unless the agent of a session overrides it.
+	Policy RetentionPolicy
+	// Interval is how often the janitor runs, defaults to DefaultRetentionInterval.
+	Interval time.Duration
+	// DryRun reports the sessions that would expire without deleting them.
+	DryRun bool
+	// Override applies Policy to all sessions, also to those whose agent sets its own retention.
+	Override bool
+	// Hooks delete data stored with the sessions, in addition to their attachments.
+	Hooks []DeleteHook
+}
+
+func (r RetentionOptions) Merge(other RetentionOptions) (result RetentionOptions) {
+	result.Policy = complete.Last(r.Policy, other.Policy)
+	result.Interval = complete.Last(r.Interval, other.Interval)
+	result.DryRun = complete.Last(r.DryRun, other.DryRun)
+	result.Override = complete.Last(r.Override, other.Override)
+	result.Hooks = append(r.Hooks, other.Hooks...)
+	return
+}
+
+func (r RetentionOptions) Complete() RetentionOptions {
+	if r.Interval <= 0 {
+		r.Interval = DefaultRetentionInterval
+	}
+	return r
+}
+
+// ExpiredSession is a session that was, or for a dry run would be, deleted.
+type ExpiredSession struct {
+	SessionID string `json:"sessionID"`
+	AccountID string `json:"accountID,omitempty"`
+	Agent     string `json:"agent,omitempty"`
+	Reason    string `json:"reason"`
+	Size      int64  `json:"size"`
+}
+
+type RetentionReport struct {
+	DryRun   bool             `json:"dryRun"`
+	Sessions []ExpiredSession `json:"sessions,omitempty"`
+}
+
+// retentionRecord is the part of a session read by the janitor.
+type retentionRecord struct {
+	SessionID string
+	AccountID string
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+	State     string
+	Config    string
+}
+
+// StartJanitor expires sessions in the background until ctx is done.
+func (m *Manager) StartJanitor(ctx context.Context, opts ...RetentionOptions) {
+	opt := complete.Complete(opts...)
+	go func() {
+		ticker := time.NewTicker(opt.Interval)
+		defer ticker.Stop()
+		for {
+			report, err := m.Expire(ctx, opt)
+			if err != nil {
+				log.Errorf(ctx, "failed to expire sessions: %v", err)
+			} else if len(report.Sessions) > 0 {
+				for _, expired := range report.Sessions {
+					log.Debugf(ctx, "session %s of account %q expired: %s", expired.SessionID, expired.AccountID, expired.Reason)
+				}
+				if report.DryRun {
+					log.Infof(ctx, "%d sessions would expire, they are kept as this is a dry run", len(report.Sessions))
+				} else {
+					log.Infof(ctx, "Deleted %d expired sessions", len(report.Sessions))
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Expire deletes the sessions that exceed the retention policy, along with their attachments and
+// the rows of the delete hooks. Sessions that are in use are kept, sessions that were deleted
+// before are purged.
+func (m *Manager) Expire(ctx context.Context, opts ...RetentionOptions) (*RetentionReport, error) {
+	opt := complete.Complete(opts...)
+	report := &RetentionReport{DryRun: opt.DryRun}
+
+	// The resources are migrated outside the transaction.
+	if _, err := m.resources(); err != nil {
+		return nil, err
+	}
+
+	sizes, err := m.attachmentSizes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.db.WithContext(ctx).Unscoped().Model(&Session{}).
+		Select("session_id, account_id, updated_at, deleted_at, state, config").
+		Order("updated_at desc").Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	type usage struct {
+		count int
+		size  int64
+	}
+	var (
+		now      = time.Now()
+		groups   = map[[2]string]*usage{}
+		policies = map[string]RetentionPolicy{}
+	)
+	for rows.Next() {
+		var record retentionRecord
+		if err := m.DB.db.ScanRows(rows, &record); err != nil {
+			return nil, fmt.Errorf("failed to read session: %w", err)
+		}
+
+		agent, policy := sessionPolicy(record, opt.Policy, policies)
+		if opt.Override {
+			policy = opt.Policy
+		}
+		expired := ExpiredSession{
+			SessionID: record.SessionID,
+			AccountID: record.AccountID,
+			Agent:     agent,
+			Size:      int64(len(record.State)) + sizes[record.SessionID],
+		}
+		group := groups[[2]string{record.AccountID, agent}]
+		if group == nil {
+			group = &usage{}
+			groups[[2]string{record.AccountID, agent}] = group
+		}
+
+		switch {
+		case record.DeletedAt.Valid:
+			expired.Reason = "deleted"
+		case !policy.enabled() || m.isLive(record.SessionID):
+		case policy.MaxAge > 0 && now.Sub(record.UpdatedAt) > policy.MaxAge:
+			expired.Reason = fmt.Sprintf("not updated for more than %s", policy.MaxAge)
+		case policy.MaxCount > 0 && group.count >= policy.MaxCount:
+			expired.Reason = fmt.Sprintf("more than %d sessions", policy.MaxCount)
+		case policy.MaxStorage > 0 && group.size+expired.Size > policy.MaxStorage:
+			expired.Reason = fmt.Sprintf("more than %d MB of sessions", policy.MaxStorage>>20)
+		}
+
+		if expired.Reason == "" {
+			group.count++
+			group.size += expired.Size
+			continue
+		}
+		report.Sessions = append(report.Sessions, expired)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	rows.Close()
+
+	if opt.DryRun {
+		return report, nil
+	}
+
+	ids := make([]string, 0, len(report.Sessions))
+	for _, expired := range report.Sessions {
+		ids = append(ids, expired.SessionID)
+	}
+	if err := m.deleteSessions(ctx, ids, opt.Hooks); err != nil {
+		return nil, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return report, nil
+}
+
+// deleteSessions deletes the sessions with their attachments, stored history, and the rows of the
+// hooks, in batches.
+func (m *Manager) deleteSessions(ctx context.Context, sessionIDs []string, hooks []DeleteHook) error {
+	hooks = append([]DeleteHook{deleteAttachments, deleteHistory}, hooks...)
+	for ids := range slices.Chunk(sessionIDs, expireBatchSize) {
+		err := m.DB.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, hook := range hooks {
+				if err := hook(ctx, tx, ids); err != nil {
+					return err
+				}
+			}
+			return tx.Unscoped().Where("session_id IN ?", ids).Delete(&Session{}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionPolicy returns the agent of a session and the policy that applies to it. As most sessions
+// store the same config, the policies are cached by agent and config.
+func sessionPolicy(record retentionRecord, policy RetentionPolicy, cache map[string]RetentionPolicy) (string, RetentionPolicy) {
+	var state struct {
+		Attributes struct {
+			CurrentAgent string `json:"currentAgent"`
+		} `json:"attributes"`
+	}
+	if record.State != "" {
+		_ = json.Unmarshal([]byte(record.State), &state)
+	}
+	agent := state.Attributes.CurrentAgent
+	if agent == "" || record.Config == "" || record.Config == "null" {
+		return agent, policy
+	}
+
+	key := agent + "\x00" + record.Config
+	if cached, ok := cache[key]; ok {
+		return agent, cached
+	}
+	var config types.Config
+	if err := json.Unmarshal([]byte(record.Config), &config); err == nil {
+		policy = policy.override(config.Agents[agent].Retention)
+	}
+	cache[key] = policy
+	return agent, policy
+}
+
+func (m *Manager) isLive(sessionID string) bool {
+	m.liveSessionsLock.Lock()
+	defer m.liveSessionsLock.Unlock()
+	_, ok := m.liveSessions[sessionID]
+	return ok
+}
+
+// attachmentSizes returns the size of the resources of each session.
+func (m *Manager) attachmentSizes(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		SessionID string
+		Size      int64
+	}
+	err := m.DB.db.WithContext(ctx).Model(&resources.Resource{}).
+		Select("session_id, SUM(LENGTH(blob)) AS size").Group("session_id").Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the size of attachments: %w", err)
+	}
+	sizes := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		sizes[row.SessionID] = row.Size
+	}
+	return sizes, nil
+}
+
+// deleteAttachments deletes the resources of the sessions.
+func deleteAttachments(_ context.Context, tx *gorm.DB, sessionIDs []string) error {
+	if err := tx.Unscoped().Where("session_id IN ?", sessionIDs).Delete(&resources.Resource{}).Error; err != nil {
+		return fmt.Errorf("failed to delete attachments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/servers/resources"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestExpire(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewManager(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	create := func(id, accountID, agent string, age time.Duration) {
+		t.Helper()
+		s := &Session{
+			SessionID: id,
+			AccountID: accountID,
+			State: State{
+				Attributes: map[string]any{types.CurrentAgentSessionKey: agent},
+			},
+			Config: ConfigWrapper{
+				Agents: map[string]types.Agent{
+					"support": {Retention: &types.AgentRetention{MaxAge: "1h"}},
+				},
+			},
+		}
+		s.CreatedAt, s.UpdatedAt = now.Add(-age), now.Add(-age)
+		if err := m.DB.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	create("alice-1", "alice", "chat", time.Minute)
+	create("alice-2", "alice", "chat", 2*time.Minute)
+	create("alice-3", "alice", "chat", 3*time.Minute)
+	create("bob-1", "bob", "chat", 10*24*time.Hour)
+	create("bob-2", "bob", "support", 2*time.Hour)
+	create("bob-3", "bob", "support", 30*time.Minute)
+
+	resourceStore, err := m.resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resourceStore.Create(ctx, &resources.Resource{UUID: "r1", SessionID: "alice-3", AccountID: "alice", Blob: "aGVsbG8="}); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := RetentionOptions{Policy: RetentionPolicy{MaxAge: 7 * 24 * time.Hour, MaxCount: 2}}
+
+	report, err := m.Expire(ctx, policy, RetentionOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"alice-3": "more than 2 sessions",
+		"bob-1":   "not updated for more than 168h0m0s",
+		"bob-2":   "not updated for more than 1h0m0s",
+	}
+	if len(report.Sessions) != len(expected) {
+		t.Fatalf("expected %d expired sessions, got %+v", len(expected), report.Sessions)
+	}
+	for _, expired := range report.Sessions {
+		if expected[expired.SessionID] != expired.Reason {
+			t.Errorf("expected %s to expire with %q, got %q", expired.SessionID, expected[expired.SessionID], expired.Reason)
+		}
+	}
+	if _, err := m.DB.Get(ctx, "alice-3"); err != nil {
+		t.Fatalf("expected a dry run to keep the session: %v", err)
+	}
+
+	if _, err := m.Expire(ctx, policy); err != nil {
+		t.Fatal(err)
+	}
+	remaining, err := m.DB.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 3 {
+		t.Errorf("expected 3 sessions to remain, got %d", len(remaining))
+	}
+	if attachments, err := resourceStore.FindBySessionID(ctx, "alice-3"); err != nil || len(attachments) != 0 {
+		t.Errorf("expected the attachments to be deleted, got %d: %v", len(attachments), err)
+	}
+}
+
+func TestPruneRenameDelete(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewManager(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for id, age := range map[string]time.Duration{"old": 48 * time.Hour, "new": time.Hour, "other": time.Minute} {
+		s := &Session{
+			SessionID: id,
+			State: State{
+				Attributes: map[string]any{types.CurrentAgentSessionKey: "support"},
+			},
+			Config: ConfigWrapper{
+				Agents: map[string]types.Agent{
+					"support": {Retention: &types.AgentRetention{MaxAge: "720h"}},
+				},
+			},
+		}
+		s.CreatedAt, s.UpdatedAt = now.Add(-age), now.Add(-age)
+		if err := m.DB.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without override the retention of the agent keeps the session.
+	prune := RetentionOptions{Policy: RetentionPolicy{MaxAge: 24 * time.Hour}}
+	if report, err := m.Expire(ctx, prune); err != nil || len(report.Sessions) != 0 {
+		t.Fatalf("expected the agent retention to apply, got %+v, %v", report, err)
+	}
+	report, err := m.Expire(ctx, prune, RetentionOptions{Override: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Sessions) != 1 || report.Sessions[0].SessionID != "old" {
+		t.Fatalf("expected only the old session to be pruned, got %+v", report.Sessions)
+	}
+
+	if err := m.Rename(ctx, "new", "Quarterly report"); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := m.DB.Get(ctx, "new"); err != nil || s.Description != "Quarterly report" || now.Sub(s.UpdatedAt) < 59*time.Minute {
+		t.Fatalf("expected the session to be renamed without activity, got %+v, %v", s, err)
+	}
+	if err := m.Rename(ctx, "old", "gone"); err == nil {
+		t.Fatal("expected renaming a deleted session to fail")
+	}
+
+	if err := m.Delete(ctx, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, err := m.DB.List(ctx); err != nil || len(sessions) != 1 || sessions[0].SessionID != "other" {
+		t.Fatalf("expected only the other session to be left, got %+v, %v", sessions, err)
+	}
+}
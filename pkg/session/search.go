@@ -0,0 +1,350 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// descriptionWeight is how much more a term in the description counts than a term in a
+	// message.
+	descriptionWeight = 3
+	snippetLength     = 160
+)
+
+type SearchOptions struct {
+	// AccountID limits the search to the sessions of an account. All sessions are searched if it
+	// is empty.
+	AccountID string
+	// Limit is the number of results returned, defaults to 20.
+	Limit int
+	// Offset is the number of ranked results skipped.
+	Offset int
+}
+
+func (s SearchOptions) Merge(other SearchOptions) (result SearchOptions) {
+	result.AccountID = complete.Last(s.AccountID, other.AccountID)
+	result.Limit = complete.Last(s.Limit, other.Limit)
+	result.Offset = complete.Last(s.Offset, other.Offset)
+	return
+}
+
+func (s SearchOptions) Complete() SearchOptions {
+	if s.Limit <= 0 {
+		s.Limit = defaultSearchLimit
+	}
+	s.Limit = min(s.Limit, maxSearchLimit)
+	s.Offset = max(s.Offset, 0)
+	return s
+}
+
+type SearchResult struct {
+	// Total is the number of matching sessions, of which Sessions is a page.
+	Total    int         `json:"total"`
+	Sessions []SearchHit `json:"sessions"`
+}
+
+type SearchHit struct {
+	SessionID   string    `json:"sessionID"`
+	AccountID   string    `json:"accountID,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Updated     time.Time `json:"updated"`
+	Score       float64   `json:"score"`
+	// MessageID is the message that matches the query best, if a message matched.
+	MessageID string `json:"messageID,omitempty"`
+	// Snippet is the text around the first match in that message, or in the description.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type searchRecord struct {
+	SessionID   string
+	AccountID   string
+	Description string
+	UpdatedAt   time.Time
+	State       State
+}
+
+// searchDocument is the searchable text of a session, the terms of the query it contains and how
+// often.
+type searchDocument struct {
+	record      searchRecord
+	description map[string]int
+	messages    map[string]int
+	best        searchMessage
+}
+
+type searchMessage struct {
+	id      string
+	text    string
+	matches int
+}
+
+// Search finds the sessions whose description or messages contain the terms of query. A term
+// matches the words it is a prefix of. The sessions are ranked by how many of the terms they
+// contain, how rare the terms are across the sessions, and how often they occur, with terms in the
+// description counting more. Sessions with the same score are ordered by the last update.
+func (m *Manager) Search(ctx context.Context, query string, opts ...SearchOptions) (*SearchResult, error) {
+	opt := complete.Complete(opts...)
+
+	terms := uniqueTerms(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query %q has no words", query)
+	}
+
+	db := m.DB.db.WithContext(ctx).Model(&Session{}).
+		Select("session_id, account_id, description, updated_at, state")
+	if opt.AccountID != "" {
+		db = db.Where("account_id = ?", opt.AccountID)
+	}
+	rows, err := db.Order("updated_at desc").Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []searchRecord
+	for rows.Next() {
+		var record searchRecord
+		if err := m.DB.db.ScanRows(rows, &record); err != nil {
+			return nil, fmt.Errorf("failed to read session: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	rows.Close()
+
+	var (
+		docs []searchDocument
+		// frequency is the number of matching sessions that contain a term.
+		frequency = map[string]int{}
+	)
+	for _, record := range records {
+		// The older messages of long threads are stored separately.
+		if err := m.DB.expandHistory(ctx, record.SessionID, &record.State); err != nil {
+			return nil, err
+		}
+		doc, ok := newSearchDocument(record, terms)
+		if !ok {
+			continue
+		}
+		for _, term := range terms {
+			if doc.description[term]+doc.messages[term] > 0 {
+				frequency[term]++
+			}
+		}
+		docs = append(docs, doc)
+	}
+
+	hits := make([]SearchHit, 0, len(docs))
+	for _, doc := range docs {
+		hits = append(hits, doc.hit(terms, frequency, len(docs)))
+	}
+	slices.SortStableFunc(hits, func(a, b SearchHit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return b.Updated.Compare(a.Updated)
+	})
+
+	result := &SearchResult{
+		Total:    len(hits),
+		Sessions: []SearchHit{},
+	}
+	if opt.Offset < len(hits) {
+		result.Sessions = hits[opt.Offset:min(opt.Offset+opt.Limit, len(hits))]
+	}
+	return result, nil
+}
+
+func newSearchDocument(record searchRecord, terms []string) (searchDocument, bool) {
+	doc := searchDocument{
+		record:      record,
+		description: countTerms(record.Description, terms),
+		messages:    map[string]int{},
+	}
+	matched := len(doc.description) > 0
+
+	seen := map[string]bool{}
+	for _, key := range slices.Sorted(maps.Keys(record.State.Attributes)) {
+		if key != types.PreviousExecutionKey && !strings.HasPrefix(key, types.PreviousExecutionKey+"/") {
+			continue
+		}
+		var run types.Execution
+		if err := mcp.JSONCoerce(record.State.Attributes[key], &run); err != nil {
+			continue
+		}
+		for _, msg := range run.Messages() {
+			// Compacted and archived threads repeat messages of the current thread.
+			if msg.Role == "system" || (msg.ID != "" && seen[msg.ID]) {
+				continue
+			}
+			seen[msg.ID] = true
+
+			text := messageText(msg)
+			counts := countTerms(text, terms)
+			if len(counts) == 0 {
+				continue
+			}
+			matched = true
+
+			var matches int
+			for term, count := range counts {
+				doc.messages[term] += count
+				matches += count
+			}
+			if matches > doc.best.matches {
+				doc.best = searchMessage{id: msg.ID, text: text, matches: matches}
+			}
+		}
+	}
+	return doc, matched
+}
+
+func (d searchDocument) hit(terms []string, frequency map[string]int, total int) SearchHit {
+	var (
+		score   float64
+		covered int
+	)
+	for _, term := range terms {
+		count := d.description[term]*descriptionWeight + d.messages[term]
+		if count == 0 {
+			continue
+		}
+		covered++
+		idf := math.Log(1 + float64(total)/float64(frequency[term]))
+		score += idf * (1 + math.Log(float64(count)))
+	}
+	// Sessions that contain all terms rank before sessions that contain some.
+	score *= float64(covered) / float64(len(terms))
+
+	hit := SearchHit{
+		SessionID:   d.record.SessionID,
+		AccountID:   d.record.AccountID,
+		Description: d.record.Description,
+		Updated:     d.record.UpdatedAt,
+		Score:       math.Round(score*1000) / 1000,
+	}
+	if d.best.matches > 0 {
+		hit.MessageID = d.best.id
+		hit.Snippet = snippet(d.best.text, terms)
+	} else {
+		hit.Snippet = snippet(d.record.Description, terms)
+	}
+	return hit
+}
+
+func messageText(msg types.Message) string {
+	var parts []string
+	for _, item := range msg.Items {
+		if item.Content != nil && item.Content.Text != "" {
+			parts = append(parts, item.Content.Text)
+		}
+		if item.ToolCallResult != nil {
+			for _, content := range item.ToolCallResult.Output.Content {
+				if content.Text != "" {
+					parts = append(parts, content.Text)
+				}
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// words splits text into lower case words of letters and digits.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func uniqueTerms(query string) []string {
+	var terms []string
+	for _, word := range words(query) {
+		if !slices.Contains(terms, word) {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// countTerms returns how many words of text each term is a prefix of, without the terms that
+// match no word.
+func countTerms(text string, terms []string) map[string]int {
+	counts := map[string]int{}
+	if text == "" {
+		return counts
+	}
+	for _, word := range words(text) {
+		for _, term := range terms {
+			if strings.HasPrefix(word, term) {
+				counts[term]++
+			}
+		}
+	}
+	return counts
+}
+
+// snippet returns the text around the first term found in text, on a single line.
+func snippet(text string, terms []string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return ""
+	}
+
+	start := -1
+	// Lower casing may change the length of some runes, then the snippet starts at the beginning.
+	if lower := strings.ToLower(text); len(lower) == len(text) {
+		for _, term := range terms {
+			if i := strings.Index(lower, term); i >= 0 && (start < 0 || i < start) {
+				start = i
+			}
+		}
+	}
+	start = max(start-snippetLength/4, 0)
+	end := min(start+snippetLength, len(text))
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	// Cut at spaces so no word is split.
+	if start > 0 {
+		if i := strings.IndexByte(text[start:end], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[start:end], ' '); i > 0 {
+			end = start + i
+		}
+	}
+
+	result := text[start:end]
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(text) {
+		result += "…"
+	}
+	return result
+}
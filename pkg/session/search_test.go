@@ -0,0 +1,85 @@
+package session
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewManager(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(id, accountID, description string, messages ...string) {
+		t.Helper()
+		run := &types.Execution{PopulatedRequest: &types.CompletionRequest{}, Response: &types.CompletionResponse{}}
+		for i, text := range messages {
+			run.PopulatedRequest.Input = append(run.PopulatedRequest.Input, types.Message{
+				ID:    id + "-" + string(rune('a'+i)),
+				Role:  "user",
+				Items: []types.CompletionItem{{Content: &mcp.Content{Type: "text", Text: text}}},
+			})
+		}
+		if err := m.DB.Create(ctx, &Session{
+			SessionID:   id,
+			AccountID:   accountID,
+			Description: description,
+			State: State{
+				Attributes: map[string]any{types.PreviousExecutionKey: run},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	create("s1", "alice", "Billing migration", "Let's plan the migration of billing to the new provider")
+	create("s2", "alice", "Lunch", "Where should we eat?", "The billing address is wrong")
+	create("s3", "alice", "Roadmap", "We talked about migrations last week")
+	create("s4", "bob", "Billing migration", "billing migration for bob")
+
+	result, err := m.Search(ctx, "the billing MIGRATION", SearchOptions{AccountID: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 {
+		t.Fatalf("expected 3 matches, got %+v", result)
+	}
+	if result.Sessions[0].SessionID != "s1" {
+		t.Errorf("expected s1 to rank first, got %+v", result.Sessions)
+	}
+	if result.Sessions[0].MessageID != "s1-a" {
+		t.Errorf("expected the best message to be s1-a, got %q", result.Sessions[0].MessageID)
+	}
+
+	page, err := m.Search(ctx, "the billing MIGRATION", SearchOptions{AccountID: "alice", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || len(page.Sessions) != 1 || page.Sessions[0].SessionID != result.Sessions[1].SessionID {
+		t.Errorf("expected the second result, got %+v", page)
+	}
+
+	if _, err := m.Search(ctx, "  ?! "); err == nil {
+		t.Error("expected an error for a query without words")
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen sixteen " +
+		"seventeen eighteen nineteen twenty billing twenty-one twenty-two twenty-three twenty-four twenty-five " +
+		"twenty-six twenty-seven twenty-eight twenty-nine thirty thirty-one thirty-two thirty-three"
+	got := snippet(text, []string{"billing"})
+	if len(got) > snippetLength+2*len("…") {
+		t.Errorf("expected the snippet to be at most %d bytes, got %q", snippetLength, got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.Contains(got, "billing") {
+		t.Errorf("expected a snippet around billing, got %q", got)
+	}
+}
@@ -0,0 +1,343 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// SerializerJSON, SerializerCBOR and SerializerProtobuf are the formats of the messages of the
+	// history table. JSON is the default and the only format that is stored in the JSON column.
+	SerializerJSON     = "json"
+	SerializerCBOR     = "cbor"
+	SerializerProtobuf = "protobuf"
+)
+
+// Serializers are the names of the supported serializers.
+var Serializers = []string{SerializerJSON, SerializerCBOR, SerializerProtobuf}
+
+// Serializer encodes the messages that are stored in the history table. The binary formats are
+// smaller than JSON, which matters for deployments that keep millions of messages.
+type Serializer interface {
+	Name() string
+	Marshal(msg types.Message) ([]byte, error)
+	Unmarshal(data []byte, msg *types.Message) error
+}
+
+// NewSerializer returns the serializer with the name, an empty name is JSON.
+func NewSerializer(name string) (Serializer, error) {
+	switch name {
+	case "", SerializerJSON:
+		return jsonSerializer{}, nil
+	case SerializerCBOR:
+		return cborSerializer{}, nil
+	case SerializerProtobuf:
+		return protobufSerializer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown session serializer %q, must be one of %v", name, Serializers)
+	}
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Name() string {
+	return SerializerJSON
+}
+
+func (jsonSerializer) Marshal(msg types.Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func (jsonSerializer) Unmarshal(data []byte, msg *types.Message) error {
+	return json.Unmarshal(data, msg)
+}
+
+// toValue converts the message to the generic value of its JSON, so the binary formats follow the
+// JSON encoding of the types, including their custom marshalers.
+func toValue(msg types.Message) (any, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func fromValue(value any, msg *types.Message) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, msg)
+}
+
+type protobufSerializer struct{}
+
+func (protobufSerializer) Name() string {
+	return SerializerProtobuf
+}
+
+// Marshal encodes the message as a google.protobuf.Value. Numbers are stored as doubles.
+func (protobufSerializer) Marshal(msg types.Message) ([]byte, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var value structpb.Value
+	if err := value.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("failed to convert message to protobuf: %w", err)
+	}
+	return proto.Marshal(&value)
+}
+
+func (protobufSerializer) Unmarshal(data []byte, msg *types.Message) error {
+	var value structpb.Value
+	if err := proto.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+	}
+	return fromValue(value.AsInterface(), msg)
+}
+
+// cborSerializer encodes messages in CBOR (RFC 8949), using the subset of it that JSON values need:
+// definite length strings, arrays and maps, integers, doubles, booleans and null.
+type cborSerializer struct{}
+
+func (cborSerializer) Name() string {
+	return SerializerCBOR
+}
+
+func (cborSerializer) Marshal(msg types.Message) ([]byte, error) {
+	value, err := toValue(msg)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := cborEncode(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (cborSerializer) Unmarshal(data []byte, msg *types.Message) error {
+	value, rest, err := cborDecode(data, 0)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal CBOR message: %w", err)
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("failed to unmarshal CBOR message: %d trailing bytes", len(rest))
+	}
+	return fromValue(value, msg)
+}
+
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborString = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborSimple = 7
+
+	cborFalse   = 0xf4
+	cborTrue    = 0xf5
+	cborNull    = 0xf6
+	cborFloat64 = 0xfb
+
+	// cborMaxDepth limits the nesting of decoded values, so corrupted data can not exhaust the stack.
+	cborMaxDepth = 1000
+)
+
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+func cborEncode(buf *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(cborNull)
+	case bool:
+		if v {
+			buf.WriteByte(cborTrue)
+		} else {
+			buf.WriteByte(cborFalse)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			if i >= 0 {
+				cborHead(buf, cborUint, uint64(i))
+			} else {
+				cborHead(buf, cborNegInt, uint64(-1-i))
+			}
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid number %s: %w", v, err)
+		}
+		buf.WriteByte(cborFloat64)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	case string:
+		cborHead(buf, cborString, uint64(len(v)))
+		buf.WriteString(v)
+	case []any:
+		cborHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			if err := cborEncode(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		cborHead(buf, cborMap, uint64(len(v)))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			cborHead(buf, cborString, uint64(len(key)))
+			buf.WriteString(key)
+			if err := cborEncode(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("can not encode %T as CBOR", value)
+	}
+	return nil
+}
+
+func cborDecode(data []byte, depth int) (any, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, fmt.Errorf("values are nested too deep")
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of data")
+	}
+
+	major, info := data[0]>>5, data[0]&0x1f
+	if major == cborSimple {
+		switch data[0] {
+		case cborNull:
+			return nil, data[1:], nil
+		case cborFalse:
+			return false, data[1:], nil
+		case cborTrue:
+			return true, data[1:], nil
+		case cborFloat64:
+			if len(data) < 9 {
+				return nil, nil, fmt.Errorf("unexpected end of data")
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(data[1:9])), data[9:], nil
+		default:
+			return nil, nil, fmt.Errorf("unsupported simple value 0x%x", data[0])
+		}
+	}
+
+	n, data, err := cborArgument(info, data[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if n > math.MaxInt64 {
+			return n, data, nil
+		}
+		return int64(n), data, nil
+	case cborNegInt:
+		if n > math.MaxInt64 {
+			return nil, nil, fmt.Errorf("negative integer out of range")
+		}
+		return -1 - int64(n), data, nil
+	case cborString:
+		if uint64(len(data)) < n {
+			return nil, nil, fmt.Errorf("unexpected end of data")
+		}
+		return string(data[:n]), data[n:], nil
+	case cborArray:
+		// Every item takes at least a byte, this bounds the allocation for corrupted lengths.
+		if uint64(len(data)) < n {
+			return nil, nil, fmt.Errorf("unexpected end of data")
+		}
+		result := make([]any, 0, n)
+		for range n {
+			var item any
+			if item, data, err = cborDecode(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			result = append(result, item)
+		}
+		return result, data, nil
+	case cborMap:
+		if uint64(len(data)) < n {
+			return nil, nil, fmt.Errorf("unexpected end of data")
+		}
+		result := make(map[string]any, n)
+		for range n {
+			var key, value any
+			if key, data, err = cborDecode(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("map key is %T, not a string", key)
+			}
+			if value, data, err = cborDecode(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			result[name] = value
+		}
+		return result, data, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported major type %d", major)
+	}
+}
+
+func cborArgument(info byte, data []byte) (uint64, []byte, error) {
+	size := 0
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, nil, fmt.Errorf("unsupported length encoding %d", info)
+	}
+	if len(data) < size {
+		return 0, nil, fmt.Errorf("unexpected end of data")
+	}
+	var n uint64
+	for _, b := range data[:size] {
+		n = n<<8 | uint64(b)
+	}
+	return n, data[size:], nil
+}
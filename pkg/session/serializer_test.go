@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func testMessage() types.Message {
+	return types.Message{
+		ID:    "m1",
+		Role:  "assistant",
+		Agent: "weather",
+		Items: []types.CompletionItem{
+			{
+				ID:      "i1",
+				Content: &mcp.Content{Type: "text", Text: "It is sunny in Paris"},
+			},
+			{
+				ID: "i2",
+				ToolCall: &types.ToolCall{
+					CallID:    "call-1",
+					Name:      "forecast",
+					Arguments: `{"city":"Paris","days":-3,"precision":0.5}`,
+				},
+			},
+		},
+	}
+}
+
+func TestSerializers(t *testing.T) {
+	msg := testMessage()
+	want, _ := json.Marshal(msg)
+
+	for _, name := range Serializers {
+		t.Run(name, func(t *testing.T) {
+			serializer, err := NewSerializer(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := serializer.Marshal(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded types.Message
+			if err := serializer.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := json.Marshal(decoded); string(got) != string(want) {
+				t.Fatalf("expected %s, got %s", want, got)
+			}
+		})
+	}
+
+	if _, err := NewSerializer("xml"); err == nil {
+		t.Fatal("expected an unknown serializer to fail")
+	}
+}
+
+func TestMigrateHistory(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStoreFromDSN(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := testMessage()
+	row, err := newHistoryMessage(nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row.SessionID, row.HistoryID = "s1", "h1"
+	if err := store.db.Create(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	history := &types.ExecutionHistory{ID: "h1", Messages: 1}
+	for _, name := range []string{SerializerCBOR, SerializerProtobuf, SerializerJSON} {
+		store.serializer, _ = NewSerializer(name)
+		migrated, err := store.MigrateHistory(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if migrated != 1 {
+			t.Fatalf("expected 1 message to be migrated to %s, got %d", name, migrated)
+		}
+		if migrated, _ := store.MigrateHistory(ctx); migrated != 0 {
+			t.Fatalf("expected nothing left to migrate to %s, got %d", name, migrated)
+		}
+
+		messages, err := store.LoadHistory(ctx, "s1", history)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 1 || !reflect.DeepEqual(messages[0], msg) {
+			t.Fatalf("unexpected messages after migrating to %s: %+v", name, messages)
+		}
+	}
+}
@@ -15,11 +15,13 @@ import (
 )
 
 const (
-	ManagerSessionKey = "sessionManager"
+	ManagerSessionKey = types.ManagerSessionKey
 )
 
 type Store struct {
 	db *gorm.DB
+	// serializer encodes the messages written to the history table, nil writes JSON.
+	serializer Serializer
 }
 
 func NewStore(db *gorm.DB) *Store {
@@ -32,7 +34,7 @@ func NewStoreFromDSN(dsn string) (*Store, error) {
 		return nil, fmt.Errorf("failed to create database connection: %w", err)
 	}
 
-	if err := db.AutoMigrate(&Session{}, &Token{}); err != nil {
+	if err := gormdsn.Migrate(db, &Session{}, &Token{}, &HistoryMessage{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate schema: %w", err)
 	}
 
@@ -102,6 +104,17 @@ func (s *Store) FindByAccount(ctx context.Context, sessionType, accountID string
 	return sessions, nil
 }
 
+// FindByType returns the sessions of a type of all accounts.
+func (s *Store) FindByType(ctx context.Context, sessionType string) ([]Session, error) {
+	var sessions []Session
+	err := s.db.WithContext(ctx).Where("type = ?", sessionType).
+		Order("created_at desc").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
 func (s *Store) List(ctx context.Context) ([]Session, error) {
 	var sessions []Session
 	err := s.db.WithContext(ctx).Order("updated_at desc").Find(&sessions).Error
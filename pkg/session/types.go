@@ -42,6 +42,16 @@ func (m *State) Scan(value interface{}) error {
 	return scan(value, m)
 }
 
+type Summary types.SessionSummary
+
+func (s Summary) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *Summary) Scan(value interface{}) error {
+	return scan(value, s)
+}
+
 func scan(value interface{}, obj any) error {
 	if value == nil {
 		return nil
@@ -65,6 +75,7 @@ type Session struct {
 	Config      ConfigWrapper `json:"config,omitempty" gorm:"type:json"`
 	Cwd         string        `json:"cwd,omitempty"`
 	IsPublic    bool          `json:"isPublic"`
+	Summary     Summary       `json:"summary" gorm:"type:json"`
 }
 
 type Token struct {
@@ -59,9 +59,14 @@ func WithAllowMissing() GetOption {
 	}
 }
 
-func (d *Data) getEntrypoints(ctx context.Context) []string {
-	c := types.ConfigFromContext(ctx)
-	return c.Publish.Entrypoint
+// getEntrypoints returns the entrypoints the client of ctx may use.
+func (d *Data) getEntrypoints(ctx context.Context) (result []string) {
+	for _, entrypoint := range types.ConfigFromContext(ctx).Publish.Entrypoint {
+		if types.AgentAllowed(ctx, entrypoint) {
+			result = append(result, entrypoint)
+		}
+	}
+	return result
 }
 
 func (d *Data) SetCurrentAgent(ctx context.Context, newAgent string) error {
@@ -96,13 +101,17 @@ func (d *Data) Agents(ctx context.Context) ([]types.AgentDisplay, error) {
 		c       types.Config
 	)
 
-	if found := session.Get(agentsSessionKey, &agents); found {
-		return agents, nil
+	// Lists cached before agents had IDs are built again.
+	if found := session.Get(agentsSessionKey, &agents); found && !slices.ContainsFunc(agents, func(agent types.AgentDisplay) bool {
+		return agent.ID == ""
+	}) {
+		return allowedAgents(ctx, agents), nil
 	}
+	agents = nil
 
 	session.Get(types.ConfigSessionKey, &c)
 
-	for _, key := range d.getEntrypoints(ctx) {
+	for _, key := range c.Publish.Entrypoint {
 		var (
 			agentDisplay types.AgentDisplay
 		)
@@ -137,26 +146,34 @@ func (d *Data) Agents(ctx context.Context) ([]types.AgentDisplay, error) {
 			continue
 		}
 
+		agentDisplay.ID = key
 		agents = append(agents, agentDisplay)
 	}
 
 	session.Set(agentsSessionKey, &agents)
-	return agents, nil
+	return allowedAgents(ctx, agents), nil
+}
+
+// allowedAgents returns the agents the client of ctx may use. The list of all agents is cached in
+// the session, which clients with different API keys can share.
+func allowedAgents(ctx context.Context, agents []types.AgentDisplay) []types.AgentDisplay {
+	return slices.DeleteFunc(slices.Clone(agents), func(agent types.AgentDisplay) bool {
+		return !types.AgentAllowed(ctx, agent.ID)
+	})
 }
 
 func (d *Data) CurrentAgent(ctx context.Context) string {
 	var (
 		session      = mcp.SessionFromContext(ctx)
 		currentAgent string
-		c            types.Config
 	)
-	if !session.Get(types.CurrentAgentSessionKey, &currentAgent) {
-		session.Get(types.ConfigSessionKey, &c)
-		if len(c.Publish.Entrypoint) > 0 {
-			currentAgent = c.Publish.Entrypoint[0]
-		}
+	if session.Get(types.CurrentAgentSessionKey, &currentAgent) && types.AgentAllowed(ctx, currentAgent) {
+		return currentAgent
+	}
+	if entrypoints := d.getEntrypoints(ctx); len(entrypoints) > 0 {
+		return entrypoints[0]
 	}
-	return currentAgent
+	return ""
 }
 
 func (d *Data) setURL(ctx context.Context) {
@@ -333,7 +350,11 @@ func (d *Data) Sync(ctx context.Context, defaultConfig types.ConfigFactory) erro
 	)
 
 	if nctx.User.ID != "" {
-		session.Set(types.AccountIDSessionKey, nctx.User.ID)
+		var owner string
+		// The sessions of other users resumed by an admin keep their owner.
+		if !nctx.Admin || !session.Get(types.AccountIDSessionKey, &owner) || owner == "" {
+			session.Set(types.AccountIDSessionKey, nctx.User.ID)
+		}
 	}
 
 	initSubscriptions(session)
@@ -370,6 +391,12 @@ func (d *Data) Refresh(ctx context.Context) {
 	session.Delete(currentAgentTargetSessionKey)
 }
 
+// RefreshTools drops the tool mappings of the session of ctx, so they are built again from the
+// current tools of the servers the next time they are needed.
+func RefreshTools(ctx context.Context) {
+	mcp.SessionFromContext(ctx).Delete(toolMappingKey)
+}
+
 func (d *Data) getPublishedMCPServers(ctx context.Context) (result []string) {
 	var (
 		c       types.Config
@@ -1,3 +1,5 @@
+//go:build windows || js
+
 package supervise
 
 import (
@@ -7,6 +9,8 @@ import (
 	"github.com/nanobot-ai/nanobot/pkg/system"
 )
 
+// Cmd starts command supervised by nanobot. Windows and js have no process groups, only the
+// process itself is killed when ctx is done.
 func Cmd(ctx context.Context, command string, args ...string) *exec.Cmd {
 	args = append([]string{"_exec", command}, args...)
 	cmd := exec.CommandContext(ctx, system.Bin(), args...)
@@ -0,0 +1,114 @@
+package teams
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+)
+
+const (
+	fileDownloadInfoType = "application/vnd.microsoft.teams.file.download.info"
+	adaptiveCardType     = "application/vnd.microsoft.card.adaptive"
+)
+
+// Activity is the subset of a Bot Framework activity that is used by the bot.
+type Activity struct {
+	Type         string               `json:"type"`
+	ID           string               `json:"id,omitempty"`
+	ServiceURL   string               `json:"serviceUrl,omitempty"`
+	ChannelID    string               `json:"channelId,omitempty"`
+	From         *ChannelAccount      `json:"from,omitempty"`
+	Recipient    *ChannelAccount      `json:"recipient,omitempty"`
+	Conversation *ConversationAccount `json:"conversation,omitempty"`
+	ReplyToID    string               `json:"replyToId,omitempty"`
+	Text         string               `json:"text,omitempty"`
+	TextFormat   string               `json:"textFormat,omitempty"`
+	Attachments  []Attachment         `json:"attachments,omitempty"`
+	Entities     []Entity             `json:"entities,omitempty"`
+	ChannelData  *ChannelData         `json:"channelData,omitempty"`
+	Value        any                  `json:"value,omitempty"`
+}
+
+type ChannelAccount struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// AADObjectID is the Microsoft Entra ID object ID of the user, which is what the user signed in
+	// to Teams with.
+	AADObjectID string `json:"aadObjectId,omitempty"`
+}
+
+type ConversationAccount struct {
+	ID               string `json:"id"`
+	Name             string `json:"name,omitempty"`
+	ConversationType string `json:"conversationType,omitempty"`
+	TenantID         string `json:"tenantId,omitempty"`
+	IsGroup          bool   `json:"isGroup,omitempty"`
+}
+
+type Attachment struct {
+	ContentType string `json:"contentType"`
+	ContentURL  string `json:"contentUrl,omitempty"`
+	Content     any    `json:"content,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+type Entity struct {
+	Type      string          `json:"type"`
+	Mentioned *ChannelAccount `json:"mentioned,omitempty"`
+	Text      string          `json:"text,omitempty"`
+}
+
+type ChannelData struct {
+	Tenant *struct {
+		ID string `json:"id"`
+	} `json:"tenant,omitempty"`
+}
+
+// fileDownloadInfo is the content of a file attachment in a personal chat.
+type fileDownloadInfo struct {
+	DownloadURL string `json:"downloadUrl"`
+	UniqueID    string `json:"uniqueId"`
+	FileType    string `json:"fileType"`
+}
+
+var mentionTag = regexp.MustCompile(`(?s)<at>.*?</at>`)
+
+// TenantID returns the ID of the Microsoft Entra tenant the activity was sent from.
+func (a Activity) TenantID() string {
+	if a.ChannelData != nil && a.ChannelData.Tenant != nil && a.ChannelData.Tenant.ID != "" {
+		return a.ChannelData.Tenant.ID
+	}
+	if a.Conversation != nil {
+		return a.Conversation.TenantID
+	}
+	return ""
+}
+
+// Prompt returns the text of a message without the mention of the bot, which is required to address
+// it in channels. Submitting an Adaptive Card sends its data as the value of the activity instead of
+// text, in that case the submitted value is the prompt.
+func (a Activity) Prompt() string {
+	text := a.Text
+	for _, entity := range a.Entities {
+		if entity.Type == "mention" && entity.Mentioned != nil && a.Recipient != nil && entity.Mentioned.ID == a.Recipient.ID {
+			text = strings.ReplaceAll(text, entity.Text, "")
+		}
+	}
+	text = strings.TrimSpace(mentionTag.ReplaceAllStringFunc(text, func(tag string) string {
+		return strings.TrimSuffix(strings.TrimPrefix(tag, "<at>"), "</at>")
+	}))
+	if text != "" || a.Value == nil {
+		return text
+	}
+
+	var submitted struct {
+		Value string `json:"value"`
+	}
+	if err := mcp.JSONCoerce(a.Value, &submitted); err == nil && submitted.Value != "" {
+		return submitted.Value
+	}
+	var value string
+	_ = mcp.JSONCoerce(a.Value, &value)
+	return value
+}
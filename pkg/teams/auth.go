@@ -0,0 +1,161 @@
+package teams
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	openIDMetadataURL  = "https://login.botframework.com/v1/.well-known/openidconfiguration"
+	botFrameworkIssuer = "https://api.botframework.com"
+
+	// keysTTL is how long the signing keys of the Bot Framework are cached. Keys are refreshed
+	// earlier if a token is signed with an unknown key, but at most once per keysMinRefresh.
+	keysTTL        = 24 * time.Hour
+	keysMinRefresh = 5 * time.Minute
+)
+
+var errUnauthorized = errors.New("unauthorized")
+
+// verifier checks that requests to the messaging endpoint are sent by the Bot Framework on behalf
+// of this bot.
+type verifier struct {
+	appID       string
+	metadataURL string
+	client      *http.Client
+
+	lock    sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+func newVerifier(appID string) *verifier {
+	return &verifier{
+		appID:       appID,
+		metadataURL: openIDMetadataURL,
+		client:      http.DefaultClient,
+	}
+}
+
+// verify validates the bearer token of req, which must be issued by the Bot Framework for the app
+// ID of the bot and the service URL of the activity.
+func (v *verifier) verify(req *http.Request, serviceURL string) error {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return errUnauthorized
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(req.Context(), kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(botFrameworkIssuer),
+		jwt.WithAudience(v.appID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(5*time.Minute))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUnauthorized, err)
+	}
+
+	claimed, _ := claims["serviceurl"].(string)
+	if claimed == "" || !strings.EqualFold(strings.TrimSuffix(claimed, "/"), strings.TrimSuffix(serviceURL, "/")) {
+		return fmt.Errorf("%w: token was issued for service URL %q, not %q", errUnauthorized, claimed, serviceURL)
+	}
+	return nil
+}
+
+func (v *verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	key, ok := v.keys[kid]
+	if ok && time.Since(v.fetched) < keysTTL {
+		return key, nil
+	}
+	if !ok && time.Since(v.fetched) < keysMinRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetched = keys, time.Now()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (v *verifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	var metadata struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, v.metadataURL, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to get Bot Framework OpenID metadata: %w", err)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, metadata.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to get Bot Framework signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			continue
+		}
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func (v *verifier) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
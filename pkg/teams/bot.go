@@ -0,0 +1,218 @@
+// Package teams implements a Microsoft Teams bot on top of the Bot Framework. Every user gets a
+// nanobot session per chat or channel thread, which is driven by the messages they send the bot.
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/channel"
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/errreport"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+// SessionType is the type of the sessions created for Teams conversations.
+const SessionType = "teams"
+
+type Options struct {
+	channel.Options
+
+	// AppID and AppPassword are the credentials of the Azure Bot registration.
+	AppID       string
+	AppPassword string
+	// TenantID is the Microsoft Entra tenant of a single tenant bot. If set, messages from other
+	// tenants are rejected.
+	TenantID string
+	// UpdateInterval is how often the reply is updated while the response is generated.
+	UpdateInterval time.Duration
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Options = o.Options.Merge(other.Options)
+	result.AppID = complete.Last(o.AppID, other.AppID)
+	result.AppPassword = complete.Last(o.AppPassword, other.AppPassword)
+	result.TenantID = complete.Last(o.TenantID, other.TenantID)
+	result.UpdateInterval = complete.Last(o.UpdateInterval, other.UpdateInterval)
+	return
+}
+
+func (o Options) Complete() Options {
+	if o.UpdateInterval == 0 {
+		o.UpdateInterval = 1500 * time.Millisecond
+	}
+	return o
+}
+
+// Bot handles the activities Teams sends to the messaging endpoint.
+type Bot struct {
+	ctx       context.Context
+	opt       Options
+	runner    *channel.Runner
+	connector *connector
+	verifier  *verifier
+}
+
+func NewBot(ctx context.Context, runt *runtime.Runtime, config types.ConfigFactory, sessions *session.Manager, server mcp.MessageHandler, opts ...Options) (*Bot, error) {
+	opt := complete.Complete(opts...)
+	if opt.AppID == "" || opt.AppPassword == "" {
+		return nil, fmt.Errorf("the app ID and password of the bot are required")
+	}
+
+	runner, err := channel.NewRunner(runt, config, sessions, server, opt.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bot{
+		ctx:       ctx,
+		opt:       opt,
+		runner:    runner,
+		connector: newConnector(ctx, opt.AppID, opt.AppPassword, opt.TenantID),
+		verifier:  newVerifier(opt.AppID),
+	}, nil
+}
+
+func (b *Bot) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var activity Activity
+	if err := json.NewDecoder(req.Body).Decode(&activity); err != nil {
+		http.Error(rw, "Failed to decode activity: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := b.verifier.verify(req, activity.ServiceURL); err != nil {
+		log.Debugf(req.Context(), "rejected Teams activity: %v", err)
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if b.opt.TenantID != "" && activity.TenantID() != b.opt.TenantID {
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if activity.Type != "message" || activity.From == nil || activity.Conversation == nil {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Teams retries requests that take longer than 15 seconds, so the turn runs in the background
+	// and the response is sent through the Bot Connector.
+	go b.handle(context.WithoutCancel(req.Context()), activity)
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func (b *Bot) handle(ctx context.Context, activity Activity) {
+	defer errreport.Recover(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	context.AfterFunc(b.ctx, cancel)
+
+	stream := newStream(ctx, b.connector, activity, uuid.String(), b.opt.UpdateInterval)
+	resp, err := b.turn(ctx, activity, stream)
+	if err != nil {
+		log.Errorf(ctx, "failed to handle Teams message: %v", err)
+		stream.fail(ctx, err)
+		return
+	}
+
+	var images []Attachment
+	for _, image := range resp.Images {
+		images = append(images, Attachment{
+			ContentType: image.MIMEType,
+			ContentURL:  image.ToImageURL(),
+		})
+	}
+	stream.finish(ctx, resp.Text, images)
+}
+
+func (b *Bot) turn(ctx context.Context, activity Activity, stream *stream) (*channel.Response, error) {
+	files, err := b.attachments(ctx, activity)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.runner.Run(ctx, channel.Message{
+		Channel:       "Microsoft Teams",
+		SessionType:   SessionType,
+		SessionID:     b.sessionID(activity),
+		User:          userFromActivity(activity),
+		Description:   activity.Conversation.Name,
+		Prompt:        activity.Prompt(),
+		Files:         files,
+		ProgressToken: stream.token,
+		Filter:        stream.filter,
+	})
+}
+
+// attachments downloads the files of the message.
+func (b *Bot) attachments(ctx context.Context, activity Activity) ([]channel.File, error) {
+	var result []channel.File
+	for _, attachment := range activity.Attachments {
+		var (
+			contentURL string
+			mimeType   string
+		)
+		switch {
+		case attachment.ContentType == fileDownloadInfoType:
+			var info fileDownloadInfo
+			if err := mcp.JSONCoerce(attachment.Content, &info); err != nil {
+				return nil, fmt.Errorf("failed to read attachment %s: %w", attachment.Name, err)
+			}
+			contentURL = info.DownloadURL
+			mimeType = mime.TypeByExtension("." + info.FileType)
+		case strings.HasPrefix(attachment.ContentType, "image/") && attachment.ContentURL != "":
+			contentURL = attachment.ContentURL
+			mimeType = attachment.ContentType
+		default:
+			// Teams also sends the message as HTML and cards as attachments, those are not files.
+			continue
+		}
+
+		data, contentType, err := b.connector.download(ctx, activity.ServiceURL, contentURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download attachment %s: %w", attachment.Name, err)
+		}
+		if mimeType == "" || strings.Contains(mimeType, "*") {
+			mimeType = contentType
+		}
+		if mimeType == "" || strings.Contains(mimeType, "*") {
+			mimeType = http.DetectContentType(data)
+		}
+		mimeType, _, _ = strings.Cut(mimeType, ";")
+
+		result = append(result, channel.File{
+			Name:     attachment.Name,
+			MimeType: mimeType,
+			Data:     data,
+		})
+	}
+	return result, nil
+}
+
+// sessionID derives the ID of the session of the sender in the conversation of activity. Channel
+// threads are separate conversations, so every thread gets its own session.
+func (b *Bot) sessionID(activity Activity) string {
+	return channel.SessionID(b.opt.AppPassword, activity.TenantID(), activity.Conversation.ID, userFromActivity(activity).ID)
+}
+
+// userFromActivity returns the identity of the sender. Teams users are signed in with Microsoft
+// Entra ID, so the object ID of their account is used as the user ID, which matches the oid claim
+// of tokens issued to the same user for the web UI.
+func userFromActivity(activity Activity) types.User {
+	return types.User{
+		ID:   complete.First(activity.From.AADObjectID, "teams:"+activity.From.ID),
+		Sub:  activity.From.AADObjectID,
+		Name: activity.From.Name,
+	}
+}
@@ -0,0 +1,132 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	botFrameworkScope = "https://api.botframework.com/.default"
+	// defaultTenant is used to get tokens for multi-tenant bots.
+	defaultTenant = "botframework.com"
+	// maxAttachmentSize is the largest file that is downloaded from a message.
+	maxAttachmentSize = 25 << 20
+)
+
+// connector is a client of the Bot Connector API, used to send replies to Teams.
+type connector struct {
+	client *http.Client
+	plain  *http.Client
+}
+
+func newConnector(ctx context.Context, appID, appPassword, tenantID string) *connector {
+	if tenantID == "" {
+		tenantID = defaultTenant
+	}
+	cfg := clientcredentials.Config{
+		ClientID:     appID,
+		ClientSecret: appPassword,
+		TokenURL:     fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", tenantID),
+		Scopes:       []string{botFrameworkScope},
+	}
+	return &connector{
+		client: cfg.Client(ctx),
+		plain:  http.DefaultClient,
+	}
+}
+
+// reply sends activity as a reply to the activity with replyToID and returns the ID of the new activity.
+func (c *connector) reply(ctx context.Context, serviceURL, conversationID, replyToID string, activity Activity) (string, error) {
+	path := "v3/conversations/" + url.PathEscape(conversationID) + "/activities"
+	if replyToID != "" {
+		path += "/" + url.PathEscape(replyToID)
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, serviceURL, path, activity, &resp); err != nil {
+		return "", fmt.Errorf("failed to send activity: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// update replaces the content of an activity the bot sent before.
+func (c *connector) update(ctx context.Context, serviceURL, conversationID, activityID string, activity Activity) error {
+	activity.ID = activityID
+	path := "v3/conversations/" + url.PathEscape(conversationID) + "/activities/" + url.PathEscape(activityID)
+	if err := c.do(ctx, http.MethodPut, serviceURL, path, activity, nil); err != nil {
+		return fmt.Errorf("failed to update activity: %w", err)
+	}
+	return nil
+}
+
+func (c *connector) do(ctx context.Context, method, serviceURL, path string, in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(serviceURL, "/")+"/"+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("bot connector returned %s: %s", resp.Status, body)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// download returns the content of an attachment. Files shared in personal chats have a
+// pre-authenticated download URL, inline images are served by the Bot Connector and need the token
+// of the bot, which is only sent to the service URL of the activity.
+func (c *connector) download(ctx context.Context, serviceURL, contentURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, contentURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := c.plain
+	if serviceURL != "" && strings.HasPrefix(contentURL, strings.TrimSuffix(serviceURL, "/")+"/") {
+		client = c.client
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxAttachmentSize {
+		return nil, "", fmt.Errorf("file is larger than %d MB", maxAttachmentSize>>20)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,185 @@
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/render"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// stream shows the response of the agent in Teams while it is generated. The first text is sent as
+// a reply, which is then updated in place at most every interval. Once the response is complete the
+// reply is replaced with the final response rendered as an Adaptive Card.
+type stream struct {
+	connector *connector
+	activity  Activity
+	token     string
+	interval  time.Duration
+
+	lock    sync.Mutex
+	items   []streamItem
+	changed bool
+	replyID string
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type streamItem struct {
+	messageID, itemID string
+	text              string
+}
+
+func newStream(ctx context.Context, connector *connector, activity Activity, token string, interval time.Duration) *stream {
+	s := &stream{
+		connector: connector,
+		activity:  activity,
+		token:     token,
+		interval:  interval,
+		done:      make(chan struct{}),
+	}
+
+	s.send(ctx, Activity{Type: "typing"})
+
+	s.wg.Add(1)
+	go s.run(ctx)
+	return s
+}
+
+func (s *stream) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.lock.Lock()
+			text, changed := s.text(), s.changed
+			s.changed = false
+			s.lock.Unlock()
+
+			if changed && text != "" {
+				s.send(ctx, Activity{Type: "message", Text: text + " …", TextFormat: "markdown"})
+			}
+		}
+	}
+}
+
+// filter collects the text of the progress notifications of the turn, it never modifies messages.
+func (s *stream) filter(_ context.Context, msg *mcp.Message) (*mcp.Message, error) {
+	if msg.Method != "notifications/progress" {
+		return msg, nil
+	}
+
+	var payload struct {
+		ProgressToken any `json:"progressToken"`
+		Meta          struct {
+			Progress *types.CompletionProgress `json:"ai.nanobot.progress/completion"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(msg.Params, &payload); err != nil || payload.Meta.Progress == nil ||
+		fmt.Sprint(payload.ProgressToken) != s.token {
+		return msg, nil
+	}
+
+	progress := payload.Meta.Progress
+	if progress.Role == "user" || progress.Item.Content == nil || progress.Item.Content.Type != "text" {
+		return msg, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.changed = true
+	for i := range s.items {
+		if s.items[i].messageID == progress.MessageID && s.items[i].itemID == progress.Item.ID {
+			if progress.Item.Partial {
+				s.items[i].text += progress.Item.Content.Text
+			} else {
+				s.items[i].text = progress.Item.Content.Text
+			}
+			return msg, nil
+		}
+	}
+	s.items = append(s.items, streamItem{
+		messageID: progress.MessageID,
+		itemID:    progress.Item.ID,
+		text:      progress.Item.Content.Text,
+	})
+	return msg, nil
+}
+
+// text returns the text streamed so far. The caller must hold the lock.
+func (s *stream) text() string {
+	var parts []string
+	for _, item := range s.items {
+		if text := strings.TrimSpace(item.text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// finish replaces the streamed text with the final response.
+func (s *stream) finish(ctx context.Context, markdown string, images []Attachment) {
+	close(s.done)
+	s.wg.Wait()
+
+	activity := Activity{Type: "message"}
+	if strings.TrimSpace(markdown) != "" {
+		activity.Attachments = append(activity.Attachments, Attachment{
+			ContentType: adaptiveCardType,
+			Content:     render.Teams(markdown),
+		})
+	}
+	activity.Attachments = append(activity.Attachments, images...)
+	if len(activity.Attachments) == 0 {
+		activity.Text = "The agent did not respond."
+	}
+
+	s.send(ctx, activity)
+}
+
+// fail shows err as the response.
+func (s *stream) fail(ctx context.Context, err error) {
+	close(s.done)
+	s.wg.Wait()
+
+	s.send(ctx, Activity{Type: "message", Text: "Sorry, something went wrong: " + err.Error()})
+}
+
+// send posts the first message of the response as a reply and updates it afterward. Typing
+// indicators are always sent as new activities.
+func (s *stream) send(ctx context.Context, activity Activity) {
+	activity.From = s.activity.Recipient
+	activity.Recipient = s.activity.From
+	activity.Conversation = s.activity.Conversation
+
+	var err error
+	if s.replyID != "" && activity.Type == "message" {
+		err = s.connector.update(ctx, s.activity.ServiceURL, s.activity.Conversation.ID, s.replyID, activity)
+	} else {
+		var id string
+		activity.ReplyToID = s.activity.ID
+		id, err = s.connector.reply(ctx, s.activity.ServiceURL, s.activity.Conversation.ID, s.activity.ID, activity)
+		if activity.Type == "message" {
+			s.replyID = id
+		}
+	}
+	if err != nil {
+		log.Errorf(ctx, "failed to send response to Teams: %v", err)
+	}
+}
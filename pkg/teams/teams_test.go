@@ -0,0 +1,106 @@
+package teams
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestPrompt(t *testing.T) {
+	activity := Activity{
+		Text:      "<at>Nanobot</at> summarize this for <at>Jane Doe</at>",
+		Recipient: &ChannelAccount{ID: "28:bot"},
+		Entities: []Entity{
+			{Type: "mention", Mentioned: &ChannelAccount{ID: "28:bot"}, Text: "<at>Nanobot</at>"},
+			{Type: "mention", Mentioned: &ChannelAccount{ID: "29:jane"}, Text: "<at>Jane Doe</at>"},
+		},
+	}
+	if got, want := activity.Prompt(), "summarize this for Jane Doe"; got != want {
+		t.Errorf("got prompt %q, want %q", got, want)
+	}
+
+	submitted := Activity{Value: map[string]any{"actionId": "approve", "value": "yes"}}
+	if got := submitted.Prompt(); got != "yes" {
+		t.Errorf("got prompt %q from card submit, want %q", got, "yes")
+	}
+}
+
+func TestSessionID(t *testing.T) {
+	b := &Bot{opt: Options{AppPassword: "secret"}}
+	activity := Activity{
+		From:         &ChannelAccount{ID: "29:jane", AADObjectID: "aad-jane"},
+		Conversation: &ConversationAccount{ID: "19:thread;messageid=1", TenantID: "tenant"},
+	}
+
+	id := b.sessionID(activity)
+	if len(id) != 36 || id != b.sessionID(activity) {
+		t.Fatalf("expected a stable UUID formatted ID, got %q", id)
+	}
+
+	activity.From = &ChannelAccount{ID: "29:john", AADObjectID: "aad-john"}
+	if b.sessionID(activity) == id {
+		t.Errorf("expected different users to get different sessions")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/metadata" {
+			_ = json.NewEncoder(rw).Encode(map[string]string{"jwks_uri": srv.URL + "/keys"})
+			return
+		}
+		_ = json.NewEncoder(rw).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	v := newVerifier("app-id")
+	v.metadataURL = srv.URL + "/metadata"
+
+	sign := func(audience, serviceURL string) *http.Request {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":        botFrameworkIssuer,
+			"aud":        audience,
+			"exp":        time.Now().Add(time.Hour).Unix(),
+			"serviceurl": serviceURL,
+		})
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/teams/messages", nil)
+		req.Header.Set("Authorization", "Bearer "+signed)
+		return req
+	}
+
+	if err := v.verify(sign("app-id", "https://smba.example.com/"), "https://smba.example.com"); err != nil {
+		t.Errorf("expected valid token, got %v", err)
+	}
+	if err := v.verify(sign("other-app", "https://smba.example.com/"), "https://smba.example.com"); err == nil {
+		t.Error("expected token for another app to be rejected")
+	}
+	if err := v.verify(sign("app-id", "https://smba.example.com/"), "https://attacker.example.com"); err == nil {
+		t.Error("expected token for another service URL to be rejected")
+	}
+}
@@ -0,0 +1,218 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultAPIURL = "https://api.telegram.org"
+	// maxFileSize is the largest file bots can download from the Bot API.
+	maxFileSize = 20 << 20
+)
+
+// Update is an update sent to the webhook of the bot. Only messages are handled.
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message,omitempty"`
+}
+
+type Message struct {
+	MessageID       int64       `json:"message_id"`
+	MessageThreadID int64       `json:"message_thread_id,omitempty"`
+	From            *User       `json:"from,omitempty"`
+	Chat            Chat        `json:"chat"`
+	Text            string      `json:"text,omitempty"`
+	Caption         string      `json:"caption,omitempty"`
+	Photo           []PhotoSize `json:"photo,omitempty"`
+	Document        *File       `json:"document,omitempty"`
+	Audio           *File       `json:"audio,omitempty"`
+	Voice           *File       `json:"voice,omitempty"`
+	Video           *File       `json:"video,omitempty"`
+}
+
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Username  string `json:"username,omitempty"`
+}
+
+type Chat struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+type PhotoSize struct {
+	FileID   string `json:"file_id"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	FileSize int    `json:"file_size,omitempty"`
+}
+
+// File is a document, audio, voice, or video message.
+type File struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int    `json:"file_size,omitempty"`
+}
+
+// client is a client of the Telegram Bot API.
+type client struct {
+	apiURL string
+	token  string
+	http   *http.Client
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Description string          `json:"description,omitempty"`
+}
+
+func (c *client) call(ctx context.Context, method string, in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/bot"+c.token+"/"+method, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, method, out)
+}
+
+func (c *client) do(req *http.Request, method string, out any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// The URL contains the token of the bot, so it is not included in the error.
+		return fmt.Errorf("failed to call %s", method)
+	}
+	defer resp.Body.Close()
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", method, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("%s failed: %s", method, result.Description)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Result, out)
+}
+
+// sendMessage sends text to the chat, formatted as HTML unless html is false.
+func (c *client) sendMessage(ctx context.Context, chatID, threadID, replyTo int64, text string, html bool) error {
+	msg := map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if html {
+		msg["parse_mode"] = "HTML"
+	}
+	if threadID != 0 {
+		msg["message_thread_id"] = threadID
+	}
+	if replyTo != 0 {
+		msg["reply_parameters"] = map[string]any{
+			"message_id":                  replyTo,
+			"allow_sending_without_reply": true,
+		}
+	}
+	return c.call(ctx, "sendMessage", msg, nil)
+}
+
+func (c *client) sendChatAction(ctx context.Context, chatID, threadID int64, action string) error {
+	msg := map[string]any{
+		"chat_id": chatID,
+		"action":  action,
+	}
+	if threadID != 0 {
+		msg["message_thread_id"] = threadID
+	}
+	return c.call(ctx, "sendChatAction", msg, nil)
+}
+
+// sendPhoto uploads an image to the chat.
+func (c *client) sendPhoto(ctx context.Context, chatID, threadID int64, mimeType string, data []byte) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("chat_id", fmt.Sprint(chatID))
+	if threadID != 0 {
+		_ = w.WriteField("message_thread_id", fmt.Sprint(threadID))
+	}
+	ext := "png"
+	if _, subtype, ok := strings.Cut(mimeType, "/"); ok {
+		ext = subtype
+	}
+	part, err := w.CreateFormFile("photo", "image."+ext)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/bot"+c.token+"/sendPhoto", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return c.do(req, "sendPhoto", nil)
+}
+
+// setWebhook registers url to receive the messages sent to the bot.
+func (c *client) setWebhook(ctx context.Context, url, secret string) error {
+	return c.call(ctx, "setWebhook", map[string]any{
+		"url":             url,
+		"secret_token":    secret,
+		"allowed_updates": []string{"message"},
+	}, nil)
+}
+
+// download returns the content of a file sent to the bot.
+func (c *client) download(ctx context.Context, fileID string) ([]byte, string, error) {
+	var file struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := c.call(ctx, "getFile", map[string]any{"file_id": fileID}, &file); err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+"/file/bot"+c.token+"/"+file.FilePath, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download file %s", fileID)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s downloading file %s", resp.Status, fileID)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxFileSize {
+		return nil, "", fmt.Errorf("file is larger than %d MB", maxFileSize>>20)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,278 @@
+// Package telegram implements a Telegram bot using the webhook of the Bot API. Every user gets a
+// nanobot session per chat, which is driven by the messages they send the bot.
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/channel"
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/errreport"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/render"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// SessionType is the type of the sessions created for Telegram chats.
+const SessionType = "telegram"
+
+// typingInterval is how often the typing indicator is refreshed, Telegram shows it for 5 seconds.
+const typingInterval = 4 * time.Second
+
+type Options struct {
+	channel.Options
+
+	// Token is the token of the bot issued by @BotFather.
+	Token string
+	// WebhookSecret is sent by Telegram with every update, updates without it are rejected.
+	WebhookSecret string
+	// WebhookURL is registered as the webhook of the bot on startup if set.
+	WebhookURL string
+	// AllowedUsers are the IDs or usernames of the users that may talk to the bot. If empty
+	// everyone can.
+	AllowedUsers []string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Options = o.Options.Merge(other.Options)
+	result.Token = complete.Last(o.Token, other.Token)
+	result.WebhookSecret = complete.Last(o.WebhookSecret, other.WebhookSecret)
+	result.WebhookURL = complete.Last(o.WebhookURL, other.WebhookURL)
+	result.AllowedUsers = append(o.AllowedUsers, other.AllowedUsers...)
+	return
+}
+
+// Bot handles the updates Telegram sends to the webhook.
+type Bot struct {
+	ctx    context.Context
+	opt    Options
+	runner *channel.Runner
+	client *client
+}
+
+func NewBot(ctx context.Context, runt *runtime.Runtime, config types.ConfigFactory, sessions *session.Manager, server mcp.MessageHandler, opts ...Options) (*Bot, error) {
+	opt := complete.Complete(opts...)
+	if opt.Token == "" || opt.WebhookSecret == "" {
+		return nil, fmt.Errorf("the token and webhook secret of the bot are required")
+	}
+
+	runner, err := channel.NewRunner(runt, config, sessions, server, opt.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bot{
+		ctx:    ctx,
+		opt:    opt,
+		runner: runner,
+		client: &client{
+			apiURL: defaultAPIURL,
+			token:  opt.Token,
+			http:   http.DefaultClient,
+		},
+	}
+
+	if opt.WebhookURL != "" {
+		if err := b.client.setWebhook(ctx, opt.WebhookURL, opt.WebhookSecret); err != nil {
+			return nil, fmt.Errorf("failed to register Telegram webhook: %w", err)
+		}
+	}
+	return b, nil
+}
+
+func (b *Bot) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	secret := req.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(b.opt.WebhookSecret)) != 1 {
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var update Update
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		http.Error(rw, "Failed to decode update: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msg := update.Message
+	if msg == nil || msg.From == nil || msg.From.IsBot {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !b.allowed(msg.From) {
+		log.Debugf(req.Context(), "ignoring Telegram message from user %d that is not allowed", msg.From.ID)
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Telegram waits for the response before sending the next update of the bot, so the turn
+	// runs in the background.
+	go b.handle(context.WithoutCancel(req.Context()), *msg)
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (b *Bot) allowed(user *User) bool {
+	if len(b.opt.AllowedUsers) == 0 {
+		return true
+	}
+	return slices.Contains(b.opt.AllowedUsers, strconv.FormatInt(user.ID, 10)) ||
+		(user.Username != "" && slices.Contains(b.opt.AllowedUsers, user.Username))
+}
+
+func (b *Bot) handle(ctx context.Context, msg Message) {
+	defer errreport.Recover(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	context.AfterFunc(b.ctx, cancel)
+
+	typingCtx, stopTyping := context.WithCancel(ctx)
+	go b.typing(typingCtx, msg)
+
+	resp, err := b.turn(ctx, msg)
+	stopTyping()
+	if err != nil {
+		log.Errorf(ctx, "failed to handle Telegram message: %v", err)
+		b.reply(ctx, msg, "Sorry, something went wrong: "+err.Error(), false)
+		return
+	}
+
+	for _, image := range resp.Images {
+		data, err := base64.StdEncoding.DecodeString(image.Data)
+		if err != nil {
+			continue
+		}
+		if err := b.client.sendPhoto(ctx, msg.Chat.ID, msg.MessageThreadID, image.MIMEType, data); err != nil {
+			log.Errorf(ctx, "failed to send image to Telegram: %v", err)
+		}
+	}
+
+	if strings.TrimSpace(resp.Text) == "" && len(resp.Images) == 0 {
+		b.reply(ctx, msg, "The agent did not respond.", false)
+		return
+	}
+	for i, text := range render.Telegram(resp.Text) {
+		replyTo := msg
+		if i > 0 {
+			replyTo.MessageID = 0
+		}
+		b.reply(ctx, replyTo, text, true)
+	}
+}
+
+// typing shows the typing indicator in the chat until ctx is done.
+func (b *Bot) typing(ctx context.Context, msg Message) {
+	ticker := time.NewTicker(typingInterval)
+	defer ticker.Stop()
+
+	for {
+		_ = b.client.sendChatAction(ctx, msg.Chat.ID, msg.MessageThreadID, "typing")
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reply sends text as a reply to msg. If Telegram can not parse the HTML the text is sent as is.
+func (b *Bot) reply(ctx context.Context, msg Message, text string, html bool) {
+	err := b.client.sendMessage(ctx, msg.Chat.ID, msg.MessageThreadID, msg.MessageID, text, html)
+	if err != nil && html {
+		err = b.client.sendMessage(ctx, msg.Chat.ID, msg.MessageThreadID, msg.MessageID, text, false)
+	}
+	if err != nil {
+		log.Errorf(ctx, "failed to send response to Telegram: %v", err)
+	}
+}
+
+func (b *Bot) turn(ctx context.Context, msg Message) (*channel.Response, error) {
+	files, err := b.files(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	user := userFromMessage(msg)
+	return b.runner.Run(ctx, channel.Message{
+		Channel:     "Telegram",
+		SessionType: SessionType,
+		SessionID:   channel.SessionID(b.opt.Token, strconv.FormatInt(msg.Chat.ID, 10), strconv.FormatInt(msg.MessageThreadID, 10), user.ID),
+		User:        user,
+		Description: complete.First(msg.Chat.Title, "Telegram chat with "+user.Name),
+		Prompt:      msg.prompt(),
+		Files:       files,
+	})
+}
+
+// files downloads the media of the message. Only the largest size of a photo is used.
+func (b *Bot) files(ctx context.Context, msg Message) ([]channel.File, error) {
+	var attached []File
+	if len(msg.Photo) > 0 {
+		attached = append(attached, File{
+			FileID:   msg.Photo[len(msg.Photo)-1].FileID,
+			FileName: "photo.jpg",
+			MimeType: "image/jpeg",
+		})
+	}
+	for _, file := range []*File{msg.Document, msg.Audio, msg.Voice, msg.Video} {
+		if file != nil {
+			attached = append(attached, *file)
+		}
+	}
+
+	var result []channel.File
+	for _, file := range attached {
+		data, contentType, err := b.client.download(ctx, file.FileID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download attachment %s: %w", file.FileName, err)
+		}
+
+		mimeType := file.MimeType
+		if mimeType == "" {
+			mimeType = mime.TypeByExtension(path.Ext(file.FileName))
+		}
+		if mimeType == "" || mimeType == "application/octet-stream" {
+			mimeType = complete.First(contentType, http.DetectContentType(data))
+		}
+		mimeType, _, _ = strings.Cut(mimeType, ";")
+
+		result = append(result, channel.File{
+			Name:     file.FileName,
+			MimeType: mimeType,
+			Data:     data,
+		})
+	}
+	return result, nil
+}
+
+// prompt returns the text or caption of the message. Commands addressed to the bot in groups, such
+// as /start@nanobot, are sent without the name of the bot.
+func (m Message) prompt() string {
+	text := strings.TrimSpace(complete.First(m.Text, m.Caption))
+	if strings.HasPrefix(text, "/") {
+		command, rest, _ := strings.Cut(text, " ")
+		command, _, _ = strings.Cut(command, "@")
+		text = strings.TrimSpace(command + " " + rest)
+	}
+	return text
+}
+
+func userFromMessage(msg Message) types.User {
+	return types.User{
+		ID:   "telegram:" + strconv.FormatInt(msg.From.ID, 10),
+		Name: strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName),
+	}
+}
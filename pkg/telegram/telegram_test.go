@@ -0,0 +1,39 @@
+package telegram
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrompt(t *testing.T) {
+	for _, tt := range []struct {
+		msg  Message
+		want string
+	}{
+		{Message{Text: " hello "}, "hello"},
+		{Message{Caption: "what is this?"}, "what is this?"},
+		{Message{Text: "/summarize@nanobot_bot the thread"}, "/summarize the thread"},
+	} {
+		if got := tt.msg.prompt(); got != tt.want {
+			t.Errorf("got prompt %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	b := &Bot{opt: Options{WebhookSecret: "secret", AllowedUsers: []string{"alice"}}}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/telegram/webhook", strings.NewReader(`{}`))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "wrong")
+	rw := httptest.NewRecorder()
+	b.ServeHTTP(rw, req)
+	if rw.Code != http.StatusUnauthorized {
+		t.Errorf("expected update with wrong secret to be rejected, got %d", rw.Code)
+	}
+
+	if b.allowed(&User{ID: 1, Username: "mallory"}) || !b.allowed(&User{ID: 2, Username: "alice"}) {
+		t.Error("expected only allowed users to talk to the bot")
+	}
+}
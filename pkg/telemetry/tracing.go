@@ -0,0 +1,186 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/flags"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/nanobot-ai/nanobot"
+	defaultLangfuseHost = "https://cloud.langfuse.com"
+)
+
+// recordContent is set if prompts, completions, and tool calls are added to spans.
+var recordContent bool
+
+type Config struct {
+	// Endpoint is the OTLP/HTTP endpoint to export spans to, for example http://localhost:4318.
+	// /v1/traces is appended unless the endpoint already has that path.
+	Endpoint string
+	Headers  map[string]string
+	// Content adds prompts, completions, and the arguments and results of tool calls to the spans,
+	// following the OpenTelemetry GenAI semantic conventions. Content of ephemeral sessions is never
+	// exported.
+	Content bool
+	// LangfusePublicKey and LangfuseSecretKey export spans to the OTLP endpoint of Langfuse at
+	// LangfuseHost, which defaults to Langfuse Cloud. Exporting to Langfuse always includes content.
+	LangfuseHost      string
+	LangfusePublicKey string
+	LangfuseSecretKey string
+	ServiceName       string
+}
+
+// Setup installs a global tracer provider exporting to the configured OTLP endpoint and Langfuse.
+// Tracing is disabled if neither is configured. The returned function flushes and stops the
+// exporters and must be called before the process exits.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = version.Name
+	}
+
+	var providerOpts []sdktrace.TracerProviderOption
+	if cfg.Endpoint != "" {
+		exporter, err := newExporter(ctx, cfg.Endpoint, cfg.Headers)
+		if err != nil {
+			return nil, err
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+	if cfg.LangfusePublicKey != "" || cfg.LangfuseSecretKey != "" {
+		if cfg.LangfusePublicKey == "" || cfg.LangfuseSecretKey == "" {
+			return nil, fmt.Errorf("both the Langfuse public and secret key are required")
+		}
+		host := cfg.LangfuseHost
+		if host == "" {
+			host = defaultLangfuseHost
+		}
+		exporter, err := newExporter(ctx, strings.TrimSuffix(host, "/")+"/api/public/otel", map[string]string{
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.LangfusePublicKey+":"+cfg.LangfuseSecretKey)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+		cfg.Content = true
+	}
+	if len(providerOpts) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+	recordContent = cfg.Content
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(version.Get().String()),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(append(providerOpts, sdktrace.WithResource(res))...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, endpoint string, headers map[string]string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+	if !strings.HasSuffix(u.Path, "/v1/traces") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/traces"
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(u.String()),
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(headers))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+// RecordContent reports whether content such as prompts and tool arguments should be added to the
+// spans started with ctx.
+func RecordContent(ctx context.Context) bool {
+	return recordContent && !log.ContentSuppressed(ctx)
+}
+
+// JSON returns an attribute with v marshalled as JSON, which is how the GenAI semantic conventions
+// record structured content.
+func JSON(key string, v any) attribute.KeyValue {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return attribute.String(key, fmt.Sprint(v))
+	}
+	return attribute.String(key, string(data))
+}
+
+// Start starts a span using the global tracer provider. When tracing is not configured the span
+// is a no-op.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span, if not nil, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SessionAttributes identify the session and user of ctx, which observability platforms such as
+// Langfuse use to group traces, and the feature flags of its request.
+func SessionAttributes(ctx context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if enabled := flags.List(ctx); len(enabled) > 0 {
+		attrs = append(attrs, attribute.StringSlice("nanobot.flags", enabled))
+	}
+
+	session := mcp.SessionFromContext(ctx)
+	if session == nil {
+		return attrs
+	}
+
+	var accountID string
+	if session.Get(types.AccountIDSessionKey, &accountID) && accountID != "" {
+		attrs = append(attrs, attribute.String("user.id", accountID))
+	}
+	for session.Parent != nil {
+		session = session.Parent
+	}
+	if id := session.ID(); id != "" {
+		attrs = append(attrs, attribute.String("session.id", id))
+	}
+	return attrs
+}
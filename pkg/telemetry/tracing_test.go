@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []*http.Request
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests = append(requests, r)
+	}))
+	defer srv.Close()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		recordContent = false
+	})
+
+	shutdown, err := Setup(t.Context(), Config{
+		Endpoint:          srv.URL,
+		Headers:           map[string]string{"X-Tenant": "a"},
+		LangfuseHost:      srv.URL + "/langfuse/",
+		LangfusePublicKey: "pk",
+		LangfuseSecretKey: "sk",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := Start(t.Context(), "test")
+	End(span, nil)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Langfuse always records content.
+	if !RecordContent(t.Context()) || RecordContent(log.WithoutContent(t.Context())) {
+		t.Error("expected content to be recorded, except for contexts without content")
+	}
+
+	got := map[string]string{}
+	for _, r := range requests {
+		got[r.URL.Path] = r.Header.Get("X-Tenant") + r.Header.Get("Authorization")
+	}
+	want := map[string]string{
+		"/v1/traces":                          "a",
+		"/langfuse/api/public/otel/v1/traces": "Basic " + base64.StdEncoding.EncodeToString([]byte("pk:sk")),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got requests %v, want %v", got, want)
+	}
+	for path, header := range want {
+		if got[path] != header {
+			t.Errorf("%s: got header %q, want %q", path, got[path], header)
+		}
+	}
+}
+
+func TestSetupErrors(t *testing.T) {
+	shutdown, err := Setup(t.Context(), Config{})
+	if err != nil || shutdown(t.Context()) != nil {
+		t.Errorf("expected tracing to be disabled without exporters, got %v", err)
+	}
+	if _, err := Setup(t.Context(), Config{LangfusePublicKey: "pk"}); err == nil {
+		t.Error("expected an error without the Langfuse secret key")
+	}
+	if _, err := Setup(t.Context(), Config{Endpoint: "localhost"}); err == nil {
+		t.Error("expected an error for an endpoint without a host")
+	}
+}
+
+func TestSessionAttributes(t *testing.T) {
+	parent, err := mcp.NewServerSession(t.Context(), mcp.MessageHandlerFunc(func(context.Context, mcp.Message) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := mcp.NewEmptySession(parent.GetSession().Context())
+	child.Parent = parent.GetSession()
+	child.Set(types.AccountIDSessionKey, "account")
+
+	got := map[string]string{}
+	for _, attr := range SessionAttributes(child.Context()) {
+		got[string(attr.Key)] = attr.Value.Emit()
+	}
+	if len(got) != 2 || got["user.id"] != "account" || got["session.id"] != parent.ID() {
+		t.Errorf("got attributes %v", got)
+	}
+}
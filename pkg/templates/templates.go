@@ -0,0 +1,91 @@
+// Package templates scaffolds example nanobot configs for new projects. Every template runs
+// as it is: tools that need external services are replaced with flows that return sample data,
+// and --llm-replay mock answers completions without a provider.
+package templates
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+)
+
+//go:embed files
+var files embed.FS
+
+// Template is an example config that can be scaffolded.
+type Template struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+var descriptions = map[string]string{
+	"rag-assistant":     "Answers questions from a knowledge base and cites its sources",
+	"code-reviewer":     "Reviews the diffs of pull requests for bugs, security problems, and missing tests",
+	"slack-bot":         "Answers the questions of a team and posts updates to Slack channels",
+	"workflow-pipeline": "Triages support tickets with a flow of agents and routing steps",
+}
+
+// List returns the templates sorted by name.
+func List() []Template {
+	result := make([]Template, 0, len(descriptions))
+	for _, name := range slices.Sorted(maps.Keys(descriptions)) {
+		result = append(result, Template{
+			Name:        name,
+			Description: descriptions[name],
+		})
+	}
+	return result
+}
+
+// Scaffold writes the files of the template to dir, which is created if it does not exist. Existing
+// files are only overwritten if force is set. It returns the paths of the written files.
+func Scaffold(name, dir string, force bool) ([]string, error) {
+	if _, ok := descriptions[name]; !ok {
+		return nil, fmt.Errorf("unknown template %q, run \"nanobot init\" to list the templates", name)
+	}
+
+	root := path.Join("files", name)
+	var targets []string
+	err := fs.WalkDir(files, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+		if _, err := os.Stat(target); err == nil && !force {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", target)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		targets = append(targets, target)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// All files are checked before any is written, so a conflict leaves dir unchanged.
+	for _, target := range targets {
+		rel, _ := filepath.Rel(dir, target)
+		data, err := files.ReadFile(path.Join(root, filepath.ToSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", target, err)
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,36 @@
+package templates
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/config"
+)
+
+func TestScaffold(t *testing.T) {
+	for _, template := range List() {
+		t.Run(template.Name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), template.Name)
+			if _, err := Scaffold(template.Name, dir, false); err != nil {
+				t.Fatal(err)
+			}
+
+			problems, err := config.Validate(t.Context(), filepath.Join(dir, "nanobot.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, problem := range problems {
+				if !problem.Warning {
+					t.Errorf("unexpected problem %s", problem)
+				}
+			}
+
+			if _, err := Scaffold(template.Name, dir, false); err == nil {
+				t.Fatal("expected existing files not to be overwritten")
+			}
+			if _, err := Scaffold(template.Name, dir, true); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
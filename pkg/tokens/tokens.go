@@ -0,0 +1,242 @@
+// Package tokens estimates the number of tokens the models count for a prompt, so that requests can
+// be made to fit the context window of a model before they are sent.
+//
+// The counts follow the tiktoken encodings of OpenAI. Text is split with the pre-tokenization pattern
+// of the encoding and every piece is counted the way the vocabulary usually encodes it, which is
+// close to the exact count for prose and code without having to ship the vocabularies. Claude models
+// use a different tokenizer, their counts are in the same range.
+package tokens
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Encoding is the name of a tiktoken encoding.
+type Encoding string
+
+const (
+	CL100KBase Encoding = "cl100k_base"
+	O200KBase  Encoding = "o200k_base"
+)
+
+const (
+	// tokensPerMessage is the overhead of the role and separators of every message.
+	tokensPerMessage = 3
+	// tokensPerReply primes the reply of the assistant.
+	tokensPerReply = 3
+	// tokensPerTool is the overhead of the definition of a tool besides its name and schema.
+	tokensPerTool = 8
+	// tokensPerImage is what OpenAI charges for a 1024x1024 image in high detail.
+	tokensPerImage = 765
+	// tokensPerFile is a rough estimate for audio and documents, their size in tokens depends on
+	// their length and not on the number of bytes.
+	tokensPerFile = 1000
+)
+
+// pieces is the pre-tokenization pattern of cl100k_base without the lookahead RE2 does not support,
+// which only changes how trailing whitespace is grouped.
+var pieces = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// maxCachedTools bounds the cache of the counts of tool definitions.
+const maxCachedTools = 4096
+
+var (
+	// encodings caches the encoding of each model, which is looked up for every text counted.
+	encodings sync.Map
+
+	toolCountsLock sync.Mutex
+	toolCounts     = map[toolKey]toolCount{}
+)
+
+// toolKey identifies the definition of a tool counted with an encoding. The definition itself is
+// compared on lookup, so a tool that changes is counted again.
+type toolKey struct {
+	encoding Encoding
+	name     string
+}
+
+type toolCount struct {
+	description string
+	parameters  string
+	count       int
+}
+
+// EncodingForModel returns the encoding used by the model. Models that are not known to use o200k_base
+// get cl100k_base.
+func EncodingForModel(model string) Encoding {
+	if encoding, ok := encodings.Load(model); ok {
+		return encoding.(Encoding)
+	}
+
+	encoding := CL100KBase
+	normalized := normalize(model)
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "chatgpt-4o", "o1", "o3", "o4", "gpt-oss"} {
+		if strings.HasPrefix(normalized, prefix) {
+			encoding = O200KBase
+			break
+		}
+	}
+	encodings.Store(model, encoding)
+	return encoding
+}
+
+// Count returns the number of tokens of text for the model.
+func Count(model, text string) int {
+	return countText(EncodingForModel(model), text)
+}
+
+func countText(encoding Encoding, text string) int {
+	if text == "" {
+		return 0
+	}
+
+	var count int
+	for _, piece := range pieces.FindAllString(text, -1) {
+		count += countPiece(encoding, piece)
+	}
+	return count
+}
+
+func countPiece(encoding Encoding, piece string) int {
+	var (
+		letters, other int
+		ascii          = true
+	)
+	for _, r := range piece {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+			if r >= utf8.RuneSelf {
+				ascii = false
+			}
+		case !unicode.IsSpace(r):
+			other++
+		}
+	}
+
+	switch {
+	case letters == 0 && other == 0:
+		// Runs of whitespace, such as indentation, are a single token.
+		return 1
+	case letters == 0:
+		if unicode.IsDigit([]rune(strings.TrimSpace(piece))[0]) {
+			// Numbers are split into groups of up to three digits, which are all in the vocabulary.
+			return 1
+		}
+		return ceilDiv(other, 3)
+	case ascii:
+		// Common words are a single token, longer or rare words are split into parts of about
+		// six letters.
+		return ceilDiv(letters, 6)
+	case encoding == O200KBase:
+		return ceilDiv(letters*3, 4)
+	default:
+		return letters
+	}
+}
+
+// CountMessages returns the number of tokens of the messages for the model.
+func CountMessages(model string, msgs ...types.Message) int {
+	var count int
+	for _, msg := range msgs {
+		count += tokensPerMessage
+		for _, item := range msg.Items {
+			count += countItem(model, item)
+		}
+	}
+	return count
+}
+
+func countItem(model string, item types.CompletionItem) int {
+	var count int
+	if item.Content != nil {
+		count += countContent(model, *item.Content)
+	}
+	if item.ToolCall != nil {
+		count += Count(model, item.ToolCall.Name) + Count(model, item.ToolCall.Arguments) + tokensPerMessage
+	}
+	if item.ToolCallResult != nil {
+		for _, content := range item.ToolCallResult.Output.Content {
+			count += countContent(model, content)
+		}
+	}
+	if item.Reasoning != nil {
+		for _, summary := range item.Reasoning.Summary {
+			count += Count(model, summary.Text)
+		}
+		// The encrypted reasoning is expanded by the provider, it is about as long as the
+		// reasoning itself.
+		count += len(item.Reasoning.EncryptedContent) / 4
+	}
+	return count
+}
+
+func countContent(model string, content mcp.Content) int {
+	switch {
+	case content.Text != "":
+		return Count(model, content.Text)
+	case content.Type == "image":
+		return tokensPerImage
+	case content.Resource != nil && content.Resource.Blob == "":
+		return Count(model, content.Resource.Text)
+	case content.Type == "resource_link":
+		return Count(model, content.Name) + Count(model, content.URI)
+	default:
+		return tokensPerFile
+	}
+}
+
+// CountRequest returns the number of tokens of the prompt of the request, which is the system
+// prompt, the definitions of the tools, and the input messages.
+func CountRequest(req types.CompletionRequest) int {
+	count := tokensPerReply
+	if req.SystemPrompt != "" {
+		count += tokensPerMessage + Count(req.Model, req.SystemPrompt)
+	}
+	for _, tool := range req.Tools {
+		count += countTool(req.Model, tool)
+	}
+	return count + CountMessages(req.Model, req.Input...)
+}
+
+// countTool returns the number of tokens of the definition of tool. The definitions of the tools
+// are the same on every turn of an agent, their counts are cached.
+func countTool(model string, tool types.ToolUseDefinition) int {
+	key := toolKey{
+		encoding: EncodingForModel(model),
+		name:     tool.Name,
+	}
+
+	toolCountsLock.Lock()
+	cached, ok := toolCounts[key]
+	toolCountsLock.Unlock()
+	if ok && cached.description == tool.Description && cached.parameters == string(tool.Parameters) {
+		return cached.count
+	}
+
+	result := tokensPerTool + countText(key.encoding, tool.Name) + countText(key.encoding, tool.Description) +
+		countText(key.encoding, string(tool.Parameters))
+
+	toolCountsLock.Lock()
+	defer toolCountsLock.Unlock()
+	if len(toolCounts) >= maxCachedTools {
+		clear(toolCounts)
+	}
+	toolCounts[key] = toolCount{
+		description: tool.Description,
+		parameters:  string(tool.Parameters),
+		count:       result,
+	}
+	return result
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,84 @@
+package tokens
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		model, text string
+		min, max    int
+	}{
+		// tiktoken counts 9 tokens for both encodings
+		{"gpt-4o", "The quick brown fox jumps over the lazy dog.", 8, 11},
+		{"gpt-3.5-turbo", "The quick brown fox jumps over the lazy dog.", 8, 11},
+		// tiktoken counts 2 tokens, 12 and 345
+		{"gpt-4o", "12345", 2, 2},
+		{"gpt-4", "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := Count(tt.model, tt.text); got < tt.min || got > tt.max {
+			t.Errorf("Count(%q, %q) = %d, want between %d and %d", tt.model, tt.text, got, tt.min, tt.max)
+		}
+	}
+
+	long := strings.Repeat("hello world ", 1000)
+	if got := Count("gpt-4o", long); got < 1800 || got > 2200 {
+		t.Errorf("expected about 2000 tokens for a long text, got %d", got)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"gpt-4":                      8_192,
+		"gpt-4-turbo-2024-04-09":     128_000,
+		"gpt-4.1-mini":               1_047_576,
+		"openai/gpt-4o":              128_000,
+		"claude-sonnet-4-5-20250929": 200_000,
+		"my-azure-deployment":        0,
+	}
+	for model, want := range tests {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestCountRequestTools(t *testing.T) {
+	tool := types.ToolUseDefinition{
+		Name:        "search",
+		Description: "Search the web",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"query":{"type":"string"}}}`),
+	}
+	req := types.CompletionRequest{Model: "gpt-4o", Tools: []types.ToolUseDefinition{tool}}
+
+	first := CountRequest(req)
+	if second := CountRequest(req); second != first {
+		t.Errorf("expected the same count for the same tools, got %d and %d", first, second)
+	}
+
+	// A tool with the same name and a different definition is counted again.
+	req.Tools[0].Description = strings.Repeat("Search the web for pages about the query. ", 20)
+	if changed := CountRequest(req); changed <= first {
+		t.Errorf("expected a longer description to count more than %d tokens, got %d", first, changed)
+	}
+}
+
+func BenchmarkCountRequestTools(b *testing.B) {
+	req := types.CompletionRequest{Model: "gpt-4o"}
+	for i := range 50 {
+		req.Tools = append(req.Tools, types.ToolUseDefinition{
+			Name:        "tool" + strings.Repeat("x", i),
+			Description: strings.Repeat("Does something useful with the input. ", 5),
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"path":{"type":"string","description":"The path of the file"},"recursive":{"type":"boolean"}},"required":["path"]}`),
+		})
+	}
+	for b.Loop() {
+		CountRequest(req)
+	}
+}
@@ -0,0 +1,50 @@
+package tokens
+
+import (
+	"strings"
+)
+
+// contextWindows are the number of tokens the prompt of a model can hold, by model name prefix. The
+// longest matching prefix wins.
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo": 16_385,
+	"gpt-4":         8_192,
+	"gpt-4-32k":     32_768,
+	"gpt-4-turbo":   128_000,
+	"gpt-4o":        128_000,
+	"chatgpt-4o":    128_000,
+	"gpt-4.1":       1_047_576,
+	"gpt-4.5":       128_000,
+	"gpt-5":         272_000,
+	"gpt-oss":       131_072,
+	"o1":            200_000,
+	"o1-mini":       128_000,
+	"o3":            200_000,
+	"o4-mini":       200_000,
+	"claude":        200_000,
+}
+
+// ContextWindow returns the number of tokens the prompt of the model can hold, or 0 if the model
+// is not known.
+func ContextWindow(model string) int {
+	model = normalize(model)
+
+	var (
+		match  string
+		window int
+	)
+	for prefix, size := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(match) {
+			match, window = prefix, size
+		}
+	}
+	return window
+}
+
+// normalize strips the provider of model names such as openai/gpt-4o.
+func normalize(model string) string {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	return strings.ToLower(model)
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// checkAccess returns an error if the user of the request may not call the tool of server. A tool
+// is restricted by the tool access rules that match it, and can be called if any of them allows the
+// user. Local calls, like those of the CLI and stdio, are not restricted, while anonymous requests
+// over HTTP can not call restricted tools.
+func checkAccess(ctx context.Context, config types.Config, server, tool string) error {
+	if config.Auth == nil || len(config.Auth.ToolAccess) == 0 {
+		return nil
+	}
+
+	nctx := types.NanobotContext(ctx)
+	anonymous := nctx.User.ID == ""
+	if anonymous && mcp.RequestFromContext(ctx) == nil {
+		return nil
+	}
+
+	target := server
+	if tool != "" {
+		target = server + "/" + tool
+	}
+
+	var restricted bool
+	for _, rule := range config.Auth.ToolAccess {
+		if !slices.ContainsFunc(rule.Tools, func(pattern string) bool {
+			return matchTool(pattern, server, target)
+		}) {
+			continue
+		}
+		if !anonymous && allows(rule, nctx) {
+			return nil
+		}
+		restricted = true
+	}
+	if restricted && anonymous {
+		return fmt.Errorf("anonymous users are not allowed to call %s", target)
+	} else if restricted {
+		return fmt.Errorf("user %s is not allowed to call %s", nctx.User.ID, target)
+	}
+	return nil
+}
+
+// matchTool reports if pattern matches the server or the server/tool target.
+func matchTool(pattern, server, target string) bool {
+	for _, name := range []string{server, target} {
+		if matched, err := path.Match(pattern, name); matched || (err != nil && pattern == name) {
+			return true
+		}
+	}
+	return false
+}
+
+func allows(rule types.ToolAccess, nctx types.Context) bool {
+	if slices.Contains(rule.Users, nctx.User.ID) || (nctx.User.Email != "" && slices.Contains(rule.Users, nctx.User.Email)) {
+		return true
+	}
+	return slices.ContainsFunc(rule.Roles, func(role string) bool {
+		return role == "*" || (role == "admin" && nctx.Admin) || slices.Contains(nctx.Roles, role)
+	})
+}
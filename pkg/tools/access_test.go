@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestCheckAccess(t *testing.T) {
+	config := types.Config{
+		Auth: &types.Auth{
+			ToolAccess: []types.ToolAccess{
+				{Tools: []string{"shell", "filesystem/*"}, Roles: []string{"admin"}},
+				{Tools: []string{"filesystem/read_*", "search"}, Roles: []string{"*"}},
+				{Tools: []string{"deploy/*"}, Roles: []string{"ops"}, Users: []string{"carol@example.com"}},
+			},
+		},
+	}
+
+	user := func(id string, nctx types.Context) context.Context {
+		nctx.User.ID = id
+		nctx.User.Email = id + "@example.com"
+		return types.WithNanobotContext(context.Background(), nctx)
+	}
+
+	// An HTTP request without an authenticated user.
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	anonymous := mcp.WithRequest(context.Background(), req)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		server  string
+		tool    string
+		allowed bool
+	}{
+		{"cli", context.Background(), "shell", "exec", true},
+		{"anonymous shell", anonymous, "shell", "exec", false},
+		{"anonymous read", anonymous, "filesystem", "read_file", false},
+		{"anonymous unrestricted", anonymous, "weather", "forecast", true},
+		{"user shell", user("alice", types.Context{}), "shell", "exec", false},
+		{"admin shell", user("alice", types.Context{Admin: true}), "shell", "exec", true},
+		{"user write", user("alice", types.Context{}), "filesystem", "write_file", false},
+		{"user read", user("alice", types.Context{}), "filesystem", "read_file", true},
+		{"user search", user("alice", types.Context{}), "search", "query", true},
+		{"unrestricted", user("alice", types.Context{}), "weather", "forecast", true},
+		{"role", user("bob", types.Context{Roles: []string{"ops"}}), "deploy", "run", true},
+		{"email", user("carol", types.Context{}), "deploy", "run", true},
+		{"other", user("dave", types.Context{}), "deploy", "run", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAccess(tt.ctx, config, tt.server, tt.tool)
+			if allowed := err == nil; allowed != tt.allowed {
+				t.Fatalf("expected allowed %v, got %v", tt.allowed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const resultCacheSessionKey = "tools/resultCache"
+
+// resultCacheLock guards the creation of the result caches of the sessions.
+var resultCacheLock sync.Mutex
+
+// resultCache holds the results of the calls of tools with a cache TTL for a session. It only lives
+// in memory, a restarted session calls the tools again.
+type resultCache struct {
+	lock    sync.Mutex
+	entries map[string]cachedResult
+}
+
+type cachedResult struct {
+	server, target string
+	result         types.CallResult
+	expires        time.Time
+}
+
+// cachedCall returns the cached result of the call if the policy caches it, or runs call. Results of
+// calls that succeed are cached, and they drop the cached results of the tools the policy
+// invalidates.
+func cachedCall(ctx context.Context, config types.Config, policy types.ToolPolicy, server, tool string, args any, bypass bool, call func() (*types.CallResult, error)) (*types.CallResult, error) {
+	// The TTL was checked when the config was validated.
+	ttl, _ := time.ParseDuration(policy.CacheTTL)
+	if ttl <= 0 && len(policy.Invalidates) == 0 {
+		return call()
+	}
+
+	cache := getResultCache(ctx)
+	if cache == nil {
+		return call()
+	}
+
+	target := server
+	if tool != "" {
+		target = server + "/" + tool
+	}
+
+	key, err := cacheKey(target, args)
+	if err != nil {
+		log.Debugf(ctx, "not caching the call of %s: %v", target, err)
+		ttl = 0
+	}
+
+	if ttl > 0 && !bypass {
+		if result, ok := cache.get(key); ok {
+			log.Debugf(ctx, "using the cached result of %s", target)
+			return result, nil
+		}
+	}
+
+	result, err := call()
+	if err != nil || result == nil || result.IsError {
+		return result, err
+	}
+
+	cache.invalidate(policy.Invalidates)
+	if ttl > 0 {
+		cache.set(key, cachedResult{
+			server:  server,
+			target:  target,
+			result:  *copyResult(result),
+			expires: time.Now().Add(ttl),
+		})
+	}
+	return result, nil
+}
+
+func getResultCache(ctx context.Context) *resultCache {
+	session := mcp.SessionFromContext(ctx)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	if session == nil {
+		return nil
+	}
+
+	resultCacheLock.Lock()
+	defer resultCacheLock.Unlock()
+
+	var cache *resultCache
+	if !session.Get(resultCacheSessionKey, &cache) {
+		cache = &resultCache{
+			entries: map[string]cachedResult{},
+		}
+		session.Set(resultCacheSessionKey, cache)
+	}
+	return cache
+}
+
+// cacheKey identifies a call by its target and its arguments in canonical JSON, objects are
+// encoded with sorted keys.
+func cacheKey(target string, args any) (string, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	var canonical any
+	if err := json.Unmarshal(data, &canonical); err != nil {
+		return "", err
+	}
+	data, err = json.Marshal(canonical)
+	if err != nil {
+		return "", err
+	}
+	return target + " " + string(data), nil
+}
+
+func (c *resultCache) get(key string) (*types.CallResult, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return copyResult(&entry.result), true
+}
+
+func (c *resultCache) set(key string, entry cachedResult) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[key] = entry
+}
+
+func (c *resultCache) invalidate(patterns []string) {
+	if len(patterns) == 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, entry := range c.entries {
+		if slices.ContainsFunc(patterns, func(pattern string) bool {
+			return matchTool(pattern, entry.server, entry.target)
+		}) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// copyResult copies the result so that callers that change theirs do not change the cached one.
+func copyResult(result *types.CallResult) *types.CallResult {
+	cp := *result
+	cp.Content = slices.Clone(result.Content)
+	return &cp
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestCachedCall(t *testing.T) {
+	var (
+		ctx    = mcp.NewEmptySession(context.Background()).Context()
+		config = types.Config{
+			ToolPolicies: []types.ToolPolicy{
+				{Tools: []string{"docs/lookup"}, CacheTTL: "1m"},
+				{Tools: []string{"docs/update"}, Invalidates: []string{"docs/lookup"}},
+			},
+		}
+		calls int
+	)
+
+	call := func(tool string, args any, bypass bool) string {
+		t.Helper()
+		ret, err := cachedCall(ctx, config, Policy(config, "docs", tool), "docs", tool, args, bypass, func() (*types.CallResult, error) {
+			calls++
+			return &types.CallResult{Content: []mcp.Content{{Type: "text", Text: tool}}}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ret.Content[0].Text
+	}
+
+	call("lookup", map[string]any{"a": 1, "b": 2}, false)
+	call("lookup", map[string]any{"b": 2, "a": 1}, false)
+	if calls != 1 {
+		t.Fatalf("expected the second call with the same arguments to be cached, got %d calls", calls)
+	}
+
+	call("lookup", map[string]any{"a": 2}, false)
+	call("lookup", map[string]any{"a": 1, "b": 2}, true)
+	if calls != 3 {
+		t.Fatalf("expected other arguments and bypassing the cache to call the tool, got %d calls", calls)
+	}
+
+	call("update", nil, false)
+	call("lookup", map[string]any{"a": 1, "b": 2}, false)
+	if calls != 5 {
+		t.Fatalf("expected update to invalidate the cached lookups, got %d calls", calls)
+	}
+}
@@ -70,8 +70,8 @@ func (s *Service) newGlobals(ctx context.Context, vars map[string]any, opt ...Ca
 			continue
 		}
 		var instructions string
-		if cf.client != nil && cf.client.Session != nil {
-			instructions = cf.client.Session.InitializeResult.Instructions
+		if client := cf.current(); client != nil && client.Session != nil {
+			instructions = client.Session.InitializeResult.Instructions
 		}
 		servers[serverName] = map[string]any{
 			"instructions": instructions,
@@ -228,9 +228,10 @@ func (s *Service) runStepEach(ctx flowContext, step types.Step, forEachData iter
 		itemVarName = "item"
 		resultLock  sync.Mutex
 		eg          errgroup.Group
+		parallel    = step.Parallel
 	)
 
-	if step.Parallel {
+	if parallel {
 		eg.SetLimit(s.concurrency)
 	} else {
 		eg.SetLimit(1)
@@ -243,13 +244,15 @@ func (s *Service) runStepEach(ctx flowContext, step types.Step, forEachData iter
 	oldVar, hadOldVar := ctx.data[itemVarName]
 	step.ForEach = nil
 	step.While = ""
+	// The loop is parallel, not the nested steps of each loop.
+	step.Parallel = false
 
 	for item := range forEachData {
 		newCtx := ctx
-		if step.Parallel {
+		if parallel {
 			newCtx.data = maps.Clone(ctx.data)
 		}
-		ctx.data[itemVarName] = item
+		newCtx.data[itemVarName] = item
 		eg.Go(func() error {
 			result, err := s.runStep(newCtx, step)
 			if err != nil {
@@ -448,7 +451,7 @@ func (s *Service) runStep(ctx flowContext, step types.Step) (ret *types.CallResu
 	}
 
 	if step.If != "" {
-		isTrue, err := expr.EvalBool(ctx.ctx, ctx.env, ctx.data, step.ForEach)
+		isTrue, err := expr.EvalBool(ctx.ctx, ctx.env, ctx.data, step.If)
 		if err != nil {
 			return nil, fmt.Errorf("failed to evaluate if condition for step %s: %w", step.ID, err)
 		}
@@ -504,5 +507,61 @@ func (s *Service) runStep(ctx flowContext, step types.Step) (ret *types.CallResu
 		})
 	}
 
+	if step.Parallel {
+		return s.runStepsParallel(ctx, step.Steps)
+	}
+
 	return s.runSteps(ctx, step.Steps)
 }
+
+// runStepsParallel runs the steps at the same time, each with a copy of the data of the flow. Once
+// all of them are done, their outputs are added to the data by the IDs of the steps, so the
+// following steps can use them, and the output of the step is the outputs of the steps by ID.
+func (s *Service) runStepsParallel(ctx flowContext, steps []types.Step) (*types.CallResult, error) {
+	var (
+		eg      errgroup.Group
+		ids     = make([]string, len(steps))
+		results = make([]*types.CallResult, len(steps))
+	)
+	eg.SetLimit(s.concurrency)
+
+	for i, step := range steps {
+		if step.ID == "" {
+			step.ID = uuid.String()
+		}
+		ids[i] = step.ID
+
+		branch := ctx
+		branch.data = maps.Clone(ctx.data)
+		eg.Go(func() error {
+			result, err := s.runStep(branch, step)
+			if err != nil {
+				return fmt.Errorf("failed to run parallel step %d (%s): %w", i, step.ID, err)
+			}
+			results[i] = result
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	var (
+		outputs = map[string]any{}
+		isError bool
+	)
+	for i, id := range ids {
+		output := toOutput(results[i])
+		ctx.data[id] = output
+		outputs[id] = output
+		isError = isError || results[i] != nil && results[i].IsError
+	}
+
+	ret, err := objectToResult(outputs)
+	if err != nil {
+		return nil, err
+	}
+	ret.IsError = isError
+	return ret, nil
+}
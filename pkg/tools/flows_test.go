@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestParallelFlow(t *testing.T) {
+	config := types.Config{
+		Flows: map[string]types.Flow{
+			"sum": {
+				Steps: []types.Step{
+					{
+						Parallel: true,
+						Steps: []types.Step{
+							{ID: "a", Evaluate: map[string]any{"v": "${input.x + 1}"}},
+							{ID: "b", Evaluate: map[string]any{"v": "${input.x * 2}"}},
+						},
+					},
+					{
+						If:       "${b.output.v > 5}",
+						Evaluate: map[string]any{"sum": "${a.output.v + b.output.v}"},
+						Else: []types.Step{
+							{Evaluate: map[string]any{"sum": 0}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		x    int
+		want string
+	}{
+		{x: 3, want: "map[sum:10]"},
+		{x: 1, want: "map[sum:0]"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.x), func(t *testing.T) {
+			ctx := mcp.NewEmptySession(context.Background()).Context()
+			result, err := NewToolsService().startFlow(ctx, config, "sum", map[string]any{"x": tt.x}, CallOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(result.StructuredContent); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
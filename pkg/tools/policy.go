@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// Policy returns the tool policy that applies to the tool of server, the first policy of the config
+// that matches it.
+func Policy(config types.Config, server, tool string) types.ToolPolicy {
+	target := server
+	if tool != "" {
+		target = server + "/" + tool
+	}
+	for _, policy := range config.ToolPolicies {
+		if slices.ContainsFunc(policy.Tools, func(pattern string) bool {
+			return matchTool(pattern, server, target)
+		}) {
+			return policy
+		}
+	}
+	return types.ToolPolicy{}
+}
+
+// callWithPolicy runs call with the timeout and retries of the policy.
+func callWithPolicy(ctx context.Context, policy types.ToolPolicy, target string, call func(ctx context.Context) (*types.CallResult, error)) (*types.CallResult, error) {
+	// The durations were checked when the config was validated.
+	timeout, _ := time.ParseDuration(policy.Timeout)
+	backoff, _ := time.ParseDuration(policy.Backoff)
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		ret, err := callWithTimeout(ctx, timeout, target, call)
+		if err == nil || attempt >= policy.MaxRetries || ctx.Err() != nil {
+			return ret, err
+		}
+
+		delay := backoff << attempt
+		log.Infof(ctx, "call of %s failed, retrying in %s: %v", target, delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func callWithTimeout(ctx context.Context, timeout time.Duration, target string, call func(ctx context.Context) (*types.CallResult, error)) (*types.CallResult, error) {
+	if timeout <= 0 {
+		return call(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ret, err := call(callCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("call of %s timed out after %s", target, timeout)
+	}
+	return ret, err
+}
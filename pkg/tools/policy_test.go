@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestPolicy(t *testing.T) {
+	config := types.Config{
+		ToolPolicies: []types.ToolPolicy{
+			{Tools: []string{"shell/exec"}, Timeout: "5m"},
+			{Tools: []string{"shell", "search"}, Timeout: "10s"},
+		},
+	}
+
+	for _, tt := range []struct {
+		server, tool, timeout string
+	}{
+		{"shell", "exec", "5m"},
+		{"shell", "ls", "10s"},
+		{"search", "query", "10s"},
+		{"weather", "forecast", ""},
+	} {
+		if got := Policy(config, tt.server, tt.tool).Timeout; got != tt.timeout {
+			t.Errorf("%s/%s: expected timeout %q, got %q", tt.server, tt.tool, tt.timeout, got)
+		}
+	}
+}
+
+func TestCallWithPolicy(t *testing.T) {
+	policy := types.ToolPolicy{Timeout: "20ms", MaxRetries: 2, Backoff: "1ms"}
+
+	var attempts int
+	ret, err := callWithPolicy(t.Context(), policy, "flaky/call", func(context.Context) (*types.CallResult, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, fmt.Errorf("connection reset")
+		}
+		return &types.CallResult{}, nil
+	})
+	if err != nil || ret == nil || attempts != 3 {
+		t.Fatalf("expected the call to succeed on the third attempt, got %d attempts: %v", attempts, err)
+	}
+
+	attempts = 0
+	_, err = callWithPolicy(t.Context(), policy, "hanging/call", func(ctx context.Context) (*types.CallResult, error) {
+		attempts++
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 20ms") || attempts != 3 {
+		t.Fatalf("expected the call to time out three times, got %d attempts: %v", attempts, err)
+	}
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// agentRoots returns the workspace roots of the agents that use the server, either as one of their
+// MCP servers or through a reference to its tools.
+func agentRoots(config types.Config, server string) ([]mcp.Root, error) {
+	var result []mcp.Root
+	for _, name := range slices.Sorted(maps.Keys(config.Agents)) {
+		agent := config.Agents[name]
+		uses := slices.Contains(agent.MCPServers, server) || slices.ContainsFunc(agent.Tools, func(ref string) bool {
+			return types.ParseToolRef(ref).Server == server
+		})
+		if !uses {
+			continue
+		}
+		for _, def := range agent.Roots {
+			root, err := mcp.ParseRoot(def)
+			if err != nil {
+				return nil, fmt.Errorf("invalid root of agent %s: %w", name, err)
+			}
+			if !slices.Contains(result, root) {
+				result = append(result, root)
+			}
+		}
+	}
+	return result, nil
+}
+
+// NotifyRootsChanged tells the servers that are connected in the session that the roots changed,
+// so they list them again.
+func (s *Service) NotifyRootsChanged(ctx context.Context) error {
+	session := mcp.SessionFromContext(ctx)
+	if session == nil {
+		return fmt.Errorf("session not found in context")
+	}
+	for session.Parent != nil {
+		session = session.Parent
+	}
+
+	var errs []error
+	for key, value := range session.Attributes() {
+		factory, ok := value.(*clientFactory)
+		if !ok || !strings.HasPrefix(key, "clients/") {
+			continue
+		}
+		client := factory.current()
+		if client == nil || client.Session.InitializeRequest.Capabilities.Roots == nil {
+			continue
+		}
+		if err := client.NotifyRootsChanged(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to notify %s of changed roots: %w", strings.TrimPrefix(key, "clients/"), err))
+		}
+	}
+	return errors.Join(errs...)
+}
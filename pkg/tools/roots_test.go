@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestAgentRoots(t *testing.T) {
+	config := types.Config{
+		Agents: map[string]types.Agent{
+			"coder": {
+				MCPServers: []string{"filesystem"},
+				Roots:      []string{"src:/work/src", "/work/docs"},
+			},
+			"writer": {
+				Tools: []string{"filesystem/read_file"},
+				Roots: []string{"/work/docs"},
+			},
+			"other": {
+				MCPServers: []string{"search"},
+				Roots:      []string{"/tmp"},
+			},
+		},
+	}
+
+	roots, err := agentRoots(config, "filesystem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []mcp.Root{
+		{Name: "src", URI: "file:///work/src"},
+		{Name: "docs", URI: "file:///work/docs"},
+	}
+	if len(roots) != len(want) {
+		t.Fatalf("expected roots %v, got %v", want, roots)
+	}
+	for i := range want {
+		if roots[i] != want[i] {
+			t.Errorf("expected root %d to be %v, got %v", i, want[i], roots[i])
+		}
+	}
+
+	if roots, err := agentRoots(config, "unused"); err != nil || len(roots) != 0 {
+		t.Errorf("expected no roots for an unused server, got %v, %v", roots, err)
+	}
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/events"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/sampling"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+const samplingUsageKeyPrefix = "samplingUsage/"
+
+// samplingUsage is the number of tokens a server used with sampling in a session.
+type samplingUsage struct {
+	Tokens int `json:"tokens,omitempty"`
+}
+
+// Serialize and Deserialize keep the usage with the session when it is stored, so the budget is not
+// reset when the session is resumed.
+func (u *samplingUsage) Serialize() (any, error) {
+	return u, nil
+}
+
+func (u *samplingUsage) Deserialize(data any) (any, error) {
+	return u, mcp.JSONCoerce(data, u)
+}
+
+// samplingLock guards the sampling usage of the sessions, servers can sample concurrently.
+var samplingLock sync.Mutex
+
+// samplingLimits returns the options that restrict a sampling request of the server to its allowed
+// agents and output tokens, and a context that counts the tokens of the request against the budget
+// of the server in the root session. It fails if the budget is used up.
+func samplingLimits(ctx context.Context, session *mcp.Session, server string, limits *mcp.ServerSampling) (context.Context, sampling.SamplerOptions, error) {
+	if limits == nil {
+		return ctx, sampling.SamplerOptions{}, nil
+	}
+
+	opt := sampling.SamplerOptions{
+		Models:    limits.Models,
+		MaxTokens: limits.MaxTokens,
+	}
+	if limits.TokenBudget <= 0 {
+		return ctx, opt, nil
+	}
+
+	key := samplingUsageKeyPrefix + server
+
+	samplingLock.Lock()
+	var usage samplingUsage
+	session.Get(key, &usage)
+	samplingLock.Unlock()
+
+	left := limits.TokenBudget - usage.Tokens
+	if left <= 0 {
+		events.SamplingBudget(ctx, server, limits.TokenBudget)
+		return ctx, opt, fmt.Errorf("server %s used up its sampling budget of %d tokens in this session", server, limits.TokenBudget)
+	}
+	if opt.MaxTokens == 0 || opt.MaxTokens > left {
+		opt.MaxTokens = left
+	}
+
+	return types.WithUsageListener(ctx, func(used types.Usage) {
+		samplingLock.Lock()
+		defer samplingLock.Unlock()
+
+		var usage samplingUsage
+		session.Get(key, &usage)
+		usage.Tokens += used.InputTokens + used.OutputTokens
+		session.Set(key, &usage)
+	}), opt, nil
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestSamplingLimits(t *testing.T) {
+	var (
+		session = mcp.NewEmptySession(context.Background())
+		limits  = &mcp.ServerSampling{
+			Models:      []string{"small"},
+			MaxTokens:   500,
+			TokenBudget: 1000,
+		}
+	)
+
+	ctx, opt, err := samplingLimits(session.Context(), session, "search", limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.MaxTokens != 500 || len(opt.Models) != 1 {
+		t.Fatalf("expected the limits of the server, got %+v", opt)
+	}
+	types.RecordUsage(ctx, &types.Usage{InputTokens: 600, OutputTokens: 200})
+
+	// Only 200 tokens are left, which caps the output of the next request.
+	ctx, opt, err = samplingLimits(session.Context(), session, "search", limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.MaxTokens != 200 {
+		t.Fatalf("expected the output to be capped to the budget that is left, got %d", opt.MaxTokens)
+	}
+	types.RecordUsage(ctx, &types.Usage{InputTokens: 150, OutputTokens: 50})
+
+	if _, _, err := samplingLimits(session.Context(), session, "search", limits); err == nil {
+		t.Fatal("expected the request to fail once the budget is used up")
+	}
+	if _, _, err := samplingLimits(session.Context(), session, "other", limits); err != nil {
+		t.Fatalf("expected the budget to be kept per server, got %v", err)
+	}
+}
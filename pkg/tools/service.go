@@ -6,18 +6,34 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"mime"
 	"net/url"
+	"path"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/nanobot-ai/nanobot/pkg/chaos"
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/elicit"
 	"github.com/nanobot-ai/nanobot/pkg/envvar"
+	"github.com/nanobot-ai/nanobot/pkg/errreport"
+	"github.com/nanobot-ai/nanobot/pkg/events"
 	"github.com/nanobot-ai/nanobot/pkg/expr"
+	"github.com/nanobot-ai/nanobot/pkg/failures"
+	"github.com/nanobot-ai/nanobot/pkg/flags"
+	"github.com/nanobot-ai/nanobot/pkg/llm/progress"
+	"github.com/nanobot-ai/nanobot/pkg/log"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/metrics"
 	"github.com/nanobot-ai/nanobot/pkg/sampling"
+	"github.com/nanobot-ai/nanobot/pkg/sessiondata"
+	"github.com/nanobot-ai/nanobot/pkg/telemetry"
 	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uifeatures"
 	"github.com/nanobot-ai/nanobot/pkg/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Service struct {
@@ -29,6 +45,7 @@ type Service struct {
 	tokenStorage     mcp.TokenStorage
 	concurrency      int
 	serverFactories  map[string]func(name string) mcp.MessageHandler
+	chaos            chaos.Config
 }
 
 type Sampler interface {
@@ -41,6 +58,8 @@ type Options struct {
 	CallbackHandler  mcp.CallbackHandler
 	OAuthRedirectURL string
 	TokenStorage     mcp.TokenStorage
+	// Chaos fails tool calls with timeouts at its rate.
+	Chaos chaos.Config
 }
 
 func (r Options) Merge(other Options) (result Options) {
@@ -49,6 +68,7 @@ func (r Options) Merge(other Options) (result Options) {
 	result.CallbackHandler = complete.Last(r.CallbackHandler, other.CallbackHandler)
 	result.OAuthRedirectURL = complete.Last(r.OAuthRedirectURL, other.OAuthRedirectURL)
 	result.TokenStorage = complete.Last(r.TokenStorage, other.TokenStorage)
+	result.Chaos = complete.Merge(r.Chaos, other.Chaos)
 	return result
 }
 
@@ -67,6 +87,7 @@ func NewToolsService(opts ...Options) *Service {
 		oauthRedirectURL: opt.OAuthRedirectURL,
 		callbackHandler:  opt.CallbackHandler,
 		tokenStorage:     opt.TokenStorage,
+		chaos:            opt.Chaos,
 	}
 }
 
@@ -140,47 +161,61 @@ func (s *Service) GetPrompt(ctx context.Context, target, prompt string, args map
 	return c.GetPrompt(ctx, prompt, args)
 }
 
+// clientFactory creates the client of a server on first use. It is copied out of the session by
+// value, so the client is kept in shared state that the copies point to.
 type clientFactory struct {
-	clientLock *sync.Mutex
-	client     *mcp.Client
-	oldState   *mcp.SessionState
-	new        func(client *mcp.SessionState) (*mcp.Client, error)
+	shared   *sharedClient
+	oldState *mcp.SessionState
+	new      func(client *mcp.SessionState) (*mcp.Client, error)
+}
+
+type sharedClient struct {
+	lock   sync.Mutex
+	client *mcp.Client
 }
 
 func newClientFactory(f func(state *mcp.SessionState) (*mcp.Client, error)) clientFactory {
 	return clientFactory{
-		clientLock: &sync.Mutex{},
-		new:        f,
+		shared: &sharedClient{},
+		new:    f,
 	}
 }
 
 func (c *clientFactory) get() (*mcp.Client, error) {
-	c.clientLock.Lock()
-	defer c.clientLock.Unlock()
+	c.shared.lock.Lock()
+	defer c.shared.lock.Unlock()
 
-	if c.client != nil {
-		return c.client, nil
+	if c.shared.client != nil {
+		return c.shared.client, nil
 	}
 	newClient, err := c.new(c.oldState)
 	if err != nil {
 		return nil, err
 	}
-	c.client = newClient
-	return c.client, nil
+	c.shared.client = newClient
+	return c.shared.client, nil
+}
+
+// current returns the client if it was created.
+func (c *clientFactory) current() *mcp.Client {
+	c.shared.lock.Lock()
+	defer c.shared.lock.Unlock()
+	return c.shared.client
 }
 
 func (c *clientFactory) Serialize() (any, error) {
-	if c.client == nil || c.client.Session.ID() == "" {
+	client := c.current()
+	if client == nil || client.Session.ID() == "" {
 		return nil, nil
 	}
-	return c.client.Session.State()
+	return client.Session.State()
 }
 
 func (c *clientFactory) Deserialize(data any) (_ any, err error) {
 	if data == nil {
 		return &clientFactory{
-			clientLock: &sync.Mutex{},
-			new:        c.new,
+			shared: &sharedClient{},
+			new:    c.new,
 		}, nil
 	}
 
@@ -192,9 +227,9 @@ func (c *clientFactory) Deserialize(data any) (_ any, err error) {
 	}
 
 	return &clientFactory{
-		clientLock: &sync.Mutex{},
-		oldState:   &state,
-		new:        c.new,
+		shared:   &sharedClient{},
+		oldState: &state,
+		new:      c.new,
 	}, nil
 }
 
@@ -221,6 +256,18 @@ func (s *Service) GetClient(ctx context.Context, name string) (*mcp.Client, erro
 	return factory.get()
 }
 
+// toolsListChanged handles a change of the tools of a server. The tool mappings of the session are
+// dropped, so the next tools/list and the next turn of the agents see the new tools, and the
+// clients of the session are told that the tools of nanobot changed.
+func toolsListChanged(ctx context.Context, session *mcp.Session) error {
+	for session.Parent != nil {
+		session = session.Parent
+	}
+	ctx = mcp.WithSession(ctx, session)
+	sessiondata.RefreshTools(ctx)
+	return mcp.NotifyListChanged(ctx, "tools")
+}
+
 func (s *Service) newClient(ctx context.Context, name string, state *mcp.SessionState) (*mcp.Client, error) {
 	session := mcp.SessionFromContext(ctx)
 	if session == nil {
@@ -256,6 +303,12 @@ func (s *Service) newClient(ctx context.Context, name string, state *mcp.Session
 		return nil, fmt.Errorf("MCP server %s not found in config", name)
 	}
 
+	if mcpConfig.Mock != nil {
+		serverFactory = func(name string) mcp.MessageHandler {
+			return mcp.NewMockServer(name, *mcpConfig.Mock)
+		}
+	}
+
 	if serverFactory != nil {
 		serverSession, err := mcp.NewExistingServerSession(session.Context(), mcp.SessionState{}, serverFactory(name))
 		if err != nil {
@@ -277,7 +330,12 @@ func (s *Service) newClient(ctx context.Context, name string, state *mcp.Session
 			roots.Roots = append(roots.Roots, s.roots...)
 		}
 
-		return roots.Roots, nil
+		workspace, err := agentRoots(config, name)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(roots.Roots, workspace...), nil
 	}
 
 	var oauthRedirectURL string
@@ -305,6 +363,9 @@ func (s *Service) newClient(ctx context.Context, name string, state *mcp.Session
 			})
 		},
 		OnNotify: func(ctx context.Context, msg mcp.Message) error {
+			if msg.Method == "notifications/tools/list_changed" {
+				return toolsListChanged(ctx, session)
+			}
 			return session.Send(ctx, msg)
 		},
 		OnLogging: func(ctx context.Context, logMsg mcp.LoggingMessage) error {
@@ -337,6 +398,11 @@ func (s *Service) newClient(ctx context.Context, name string, state *mcp.Session
 
 	if session.InitializeRequest.Capabilities.Elicitation == nil {
 		clientOpts.OnElicit = func(ctx context.Context, _ mcp.Message, elicitation mcp.ElicitRequest) (result mcp.ElicitResult, _ error) {
+			ctx = mcp.WithSession(ctx, session)
+			if uifeatures.Supported(ctx, uifeatures.Forms) {
+				// The question is sent as a form in the progress of the chat turn of the agent UI.
+				return elicit.Ask(ctx, name, elicitation)
+			}
 			return mcp.ElicitResult{
 				Action: "cancel",
 			}, nil
@@ -347,11 +413,15 @@ func (s *Service) newClient(ctx context.Context, name string, state *mcp.Session
 			return result, err
 		}
 	}
-	if s.sampler != nil {
+	if s.sampler != nil && (mcpConfig.Sampling == nil || !mcpConfig.Sampling.Disabled) {
 		clientOpts.OnSampling = func(ctx context.Context, samplingRequest mcp.CreateMessageRequest) (mcp.CreateMessageResult, error) {
+			ctx, limits, err := samplingLimits(ctx, session, name, mcpConfig.Sampling)
+			if err != nil {
+				return mcp.CreateMessageResult{}, err
+			}
 			result, err := s.sampler.Sample(ctx, samplingRequest, sampling.SamplerOptions{
 				ProgressToken: uuid.String(),
-			})
+			}, limits)
 			if err != nil {
 				if !errors.Is(err, sampling.ErrNoMatchingModel) || session.InitializeRequest.Capabilities.Sampling == nil {
 					return mcp.CreateMessageResult{}, err
@@ -403,6 +473,8 @@ type CallOptions struct {
 	Target             any
 	ToolCallInvocation *ToolCallInvocation
 	Meta               map[string]any
+	// BypassCache runs the call even if its result is cached, the new result is cached.
+	BypassCache bool
 }
 
 type ToolCallInvocation struct {
@@ -420,6 +492,7 @@ func (o CallOptions) Merge(other CallOptions) (result CallOptions) {
 	result.Target = complete.Last(o.Target, other.Target)
 	result.ToolCallInvocation = complete.Last(o.ToolCallInvocation, other.ToolCallInvocation)
 	result.Meta = complete.MergeMap(o.Meta, other.Meta)
+	result.BypassCache = o.BypassCache || other.BypassCache
 	return
 }
 
@@ -514,6 +587,33 @@ func (s *Service) runBefore(ctx context.Context, config types.Config, target, se
 }
 
 func (s *Service) Call(ctx context.Context, server, tool string, args any, opts ...CallOptions) (ret *types.CallResult, err error) {
+	defer errreport.Recover(ctx)
+	start := time.Now()
+	ctx, span := telemetry.Start(ctx, "tools/call "+tool, append(telemetry.SessionAttributes(ctx),
+		attribute.String("gen_ai.operation.name", "execute_tool"),
+		attribute.String("gen_ai.tool.name", tool),
+		attribute.String("nanobot.tool.server", server),
+		attribute.String("nanobot.tool.name", tool))...)
+	defer func() {
+		isError := ret != nil && ret.IsError
+		span.SetAttributes(attribute.Bool("nanobot.tool.is_error", isError))
+		if ret != nil && telemetry.RecordContent(ctx) {
+			span.SetAttributes(telemetry.JSON("gen_ai.tool.call.result", ret))
+		}
+		telemetry.End(span, err)
+		metrics.ObserveToolCall(server, tool, time.Since(start), isError, err)
+		failures.Record(ctx, server, tool, ret, err)
+		errreport.Tool(ctx, server, tool, ret, err)
+		events.Tool(ctx, server, tool, start, ret, err)
+	}()
+
+	if types.IsEphemeral(mcp.SessionFromContext(ctx)) {
+		ctx = log.WithoutContent(ctx)
+	}
+	if telemetry.RecordContent(ctx) {
+		span.SetAttributes(telemetry.JSON("gen_ai.tool.call.arguments", args))
+	}
+
 	defer func() {
 		if ret == nil {
 			return
@@ -579,19 +679,14 @@ func (s *Service) Call(ctx context.Context, server, tool string, args any, opts
 		tc.TargetType = targetType
 
 		if logProgressStart {
-			_ = session.SendPayload(ctx, "notifications/progress", mcp.NotificationProgressRequest{
-				ProgressToken: opt.ProgressToken,
-				Meta: map[string]any{
-					types.CompletionProgressMetaKey: types.CompletionProgress{
-						MessageID: messageID,
-						Item: types.CompletionItem{
-							HasMore:  true,
-							ID:       itemID,
-							ToolCall: &tc,
-						},
-					},
+			progress.Send(ctx, &types.CompletionProgress{
+				MessageID: messageID,
+				Item: types.CompletionItem{
+					HasMore:  true,
+					ID:       itemID,
+					ToolCall: &tc,
 				},
-			})
+			}, opt.ProgressToken)
 		}
 
 		if logProgressDone {
@@ -613,28 +708,38 @@ func (s *Service) Call(ctx context.Context, server, tool string, args any, opts
 						},
 					}
 				}
-				_ = session.SendPayload(ctx, "notifications/progress", mcp.NotificationProgressRequest{
-					ProgressToken: opt.ProgressToken,
-					Meta: map[string]any{
-						types.CompletionProgressMetaKey: types.CompletionProgress{
-							MessageID: messageID,
-							Item: types.CompletionItem{
-								ID:             itemID,
-								ToolCall:       &tc,
-								ToolCallResult: &tcResult,
-							},
-						},
+				// Listeners of the context see the results, such as the CLI.
+				progress.Send(ctx, &types.CompletionProgress{
+					MessageID: messageID,
+					Item: types.CompletionItem{
+						ID:             itemID,
+						ToolCall:       &tc,
+						ToolCallResult: &tcResult,
 					},
-				})
+				}, opt.ProgressToken)
 			}()
 		}
 	}
 
+	if err := checkAccess(ctx, config, server, tool); err != nil {
+		return nil, err
+	}
+
 	args, ret, err = s.runBefore(ctx, config, target, server, tool, args, opt)
 	if err != nil || ret != nil {
 		return ret, err
 	}
 
+	policy := Policy(config, server, tool)
+	return cachedCall(ctx, config, policy, server, tool, args, opt.BypassCache, func() (*types.CallResult, error) {
+		return callWithPolicy(ctx, policy, target, func(ctx context.Context) (*types.CallResult, error) {
+			return s.call(ctx, config, server, tool, args, opt)
+		})
+	})
+}
+
+// call runs a single attempt of a call of the tool, after its before hooks.
+func (s *Service) call(ctx context.Context, config types.Config, server, tool string, args any, opt CallOptions) (*types.CallResult, error) {
 	if _, ok := config.Agents[server]; ok && tool != types.AgentTool {
 		return s.sampleCall(ctx, server, args, SampleCallOptions{
 			ProgressToken: opt.ProgressToken,
@@ -646,14 +751,27 @@ func (s *Service) Call(ctx context.Context, server, tool string, args any, opts
 		return s.startFlow(ctx, config, server, args, opt)
 	}
 
+	if chaos.Hit(s.chaos.ToolTimeout) {
+		log.Infof(ctx, "chaos mode: failing call of %s/%s with a timeout", server, tool)
+		return nil, fmt.Errorf("call of %s/%s timed out, injected by chaos mode: %w", server, tool, context.DeadlineExceeded)
+	}
+
 	c, err := s.GetClient(ctx, server)
 	if err != nil {
 		return nil, err
 	}
 
+	meta := map[string]any{
+		types.AttributionMetaKey: attribution(ctx),
+	}
+	if enabled := flags.List(ctx); len(enabled) > 0 {
+		meta[flags.MetaKey] = enabled
+	}
+
 	mcpCallResult, err := c.Call(ctx, tool, args, mcp.CallOption{
 		ProgressToken: opt.ProgressToken,
-		Meta:          opt.Meta,
+		// The metadata of the caller wins over the attribution and flags.
+		Meta: complete.MergeMap(meta, opt.Meta),
 	})
 	if err != nil {
 		return nil, err
@@ -665,6 +783,24 @@ func (s *Service) Call(ctx context.Context, server, tool string, args any, opts
 	}, nil
 }
 
+// attribution returns on whose behalf a tool is called in ctx: the agent and turn set by the agent
+// that calls it, the root session, and the user that owns the session.
+func attribution(ctx context.Context) types.CallAttribution {
+	result := types.CallAttributionFromContext(ctx)
+	session := mcp.SessionFromContext(ctx)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	if session != nil {
+		result.SessionID = session.ID()
+		session.Get(types.AccountIDSessionKey, &result.UserID)
+	}
+	if result.UserID == "" {
+		result.UserID = types.NanobotContext(ctx).User.ID
+	}
+	return result
+}
+
 type ListToolsOptions struct {
 	Servers []string
 	Tools   []string
@@ -847,6 +983,12 @@ func (s *Service) listToolsForReferences(ctx context.Context, toolList []string)
 	})
 }
 
+// Concurrency is the maximum number of tasks run in parallel, such as the steps of a parallel loop
+// or the tool calls of a turn.
+func (s *Service) Concurrency() int {
+	return s.concurrency
+}
+
 func (s *Service) BuildToolMappings(ctx context.Context, toolList []string, opts ...types.BuildToolMappingsOptions) (types.ToolMappings, error) {
 	tools, err := s.listToolsForReferences(ctx, toolList)
 	if err != nil {
@@ -919,8 +1061,27 @@ func (s *Service) convertToSampleRequest(config types.Config, agent string, args
 	}
 
 	for _, attachment := range sampleArgs.Attachments {
+		if strings.HasPrefix(attachment.URL, "http://") || strings.HasPrefix(attachment.URL, "https://") {
+			mimeType := attachment.MimeType
+			if u, err := url.Parse(attachment.URL); err == nil && mimeType == "" {
+				mimeType = mime.TypeByExtension(path.Ext(u.Path))
+			}
+			if mimeType != "" && !strings.HasPrefix(mimeType, "image/") {
+				return nil, fmt.Errorf("invalid attachment URL: %s, only images can be attached by URL", attachment.URL)
+			}
+			// Images referenced by URL are passed on as is for the provider to fetch.
+			sampleRequest.Messages = append(sampleRequest.Messages, mcp.SamplingMessage{
+				Role: "user",
+				Content: mcp.Content{
+					Type:     "image",
+					URI:      attachment.URL,
+					MIMEType: mimeType,
+				},
+			})
+			continue
+		}
 		if !strings.HasPrefix(attachment.URL, "data:") {
-			return nil, fmt.Errorf("invalid attachment URL: %s, only data URI are supported", attachment.URL)
+			return nil, fmt.Errorf("invalid attachment URL: %s, only data URI and image URLs are supported", attachment.URL)
 		}
 		parts := strings.Split(strings.TrimPrefix(attachment.URL, "data:"), "base64,")
 		if len(parts) != 2 {
@@ -940,6 +1101,15 @@ func (s *Service) convertToSampleRequest(config types.Config, agent string, args
 					MIMEType: mimeType,
 				},
 			})
+		} else if strings.HasPrefix(mimeType, "audio/") {
+			sampleRequest.Messages = append(sampleRequest.Messages, mcp.SamplingMessage{
+				Role: "user",
+				Content: mcp.Content{
+					Type:     "audio",
+					Data:     data,
+					MIMEType: mimeType,
+				},
+			})
 		} else {
 			sampleRequest.Messages = append(sampleRequest.Messages, mcp.SamplingMessage{
 				Role: "user",
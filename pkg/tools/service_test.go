@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+func TestAttribution(t *testing.T) {
+	root := mcp.NewEmptySession(context.Background())
+	root.Set(types.AccountIDSessionKey, "owner")
+	child := mcp.NewEmptySession(root.Context())
+	child.Parent = root
+
+	ctx := types.WithCallAttribution(child.Context(), types.CallAttribution{
+		Agent:  "helper",
+		TurnID: "turn-1",
+	})
+	ctx = types.WithNanobotContext(ctx, types.Context{User: types.User{ID: "admin"}})
+
+	got := attribution(ctx)
+	want := types.CallAttribution{
+		Agent:     "helper",
+		SessionID: root.ID(),
+		UserID:    "owner",
+		TurnID:    "turn-1",
+	}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestAttachmentURLs(t *testing.T) {
+	s := &Service{}
+	req, err := s.convertToSampleRequest(types.Config{}, "main", &types.SampleCallRequest{
+		Prompt: "What is this?",
+		Attachments: []types.Attachment{
+			{URL: "https://example.com/cat.png"},
+			{URL: "data:image/jpeg;base64,aGk="},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []mcp.Content{
+		{Type: "text", Text: "What is this?"},
+		{Type: "image", URI: "https://example.com/cat.png", MIMEType: "image/png"},
+		{Type: "image", Data: "aGk=", MIMEType: "image/jpeg"},
+	}
+	if len(req.Messages) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(req.Messages), len(want))
+	}
+	for i, msg := range req.Messages {
+		if msg.Content.Type != want[i].Type || msg.Content.Text != want[i].Text || msg.Content.URI != want[i].URI ||
+			msg.Content.Data != want[i].Data || msg.Content.MIMEType != want[i].MIMEType {
+			t.Errorf("message %d: got %+v, want %+v", i, msg.Content, want[i])
+		}
+	}
+
+	if _, err := s.convertToSampleRequest(types.Config{}, "main", &types.SampleCallRequest{
+		Attachments: []types.Attachment{{URL: "https://example.com/report.pdf"}},
+	}); err == nil {
+		t.Error("expected only images to be attached by URL")
+	}
+}
+
+func TestToolsListChanged(t *testing.T) {
+	root, err := mcp.NewServerSession(t.Context(), mcp.MessageHandlerFunc(func(context.Context, mcp.Message) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.StartReading()
+	// The tool mappings are cached in the root session by sessiondata.
+	root.GetSession().Set("toolMapping", types.ToolMappings{"search": {}})
+	if _, ok := root.GetSession().Attributes()["toolMapping"]; !ok {
+		t.Fatal("expected the tool mappings to be set")
+	}
+	child := mcp.NewEmptySession(root.GetSession().Context())
+	child.Parent = root.GetSession()
+
+	// The notification is sent synchronously, so it is read while the change is handled.
+	errs := make(chan error, 1)
+	go func() {
+		errs <- toolsListChanged(t.Context(), child)
+	}()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	msg, ok := root.Read(ctx)
+	if !ok || msg.Method != "notifications/tools/list_changed" {
+		t.Errorf("expected tools/list_changed to be sent to the client of the root session, got %+v", msg)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := root.GetSession().Attributes()["toolMapping"]; ok {
+		t.Error("expected the tool mappings of the root session to be dropped")
+	}
+}
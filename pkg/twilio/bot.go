@@ -0,0 +1,345 @@
+// Package twilio connects phone calls to agents with Twilio Voice. Calls are answered with a
+// ConversationRelay, Twilio transcribes the speech of the caller and speaks the response of the
+// agent. Every call is a nanobot session, so the transcript of the call is kept with the session.
+package twilio
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/nanobot-ai/nanobot/pkg/channel"
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	"github.com/nanobot-ai/nanobot/pkg/uuid"
+)
+
+// SessionType is the type of the sessions created for phone calls.
+const SessionType = "twilio"
+
+const (
+	// VoicePath receives the webhook of incoming calls, RelayPath the WebSocket of the call.
+	VoicePath = "/api/twilio/voice"
+	RelayPath = "/api/twilio/voice/relay"
+
+	// writeTimeout limits how long sending text to a call may take before the call is considered
+	// gone.
+	writeTimeout = 10 * time.Second
+)
+
+type Options struct {
+	channel.Options
+
+	// AuthToken is the auth token of the Twilio account, used to verify that requests come from
+	// Twilio.
+	AuthToken string
+	// PublicURL is the URL nanobot is reachable at from Twilio, such as https://bot.example.com. The
+	// voice webhook of the phone number must be set to PublicURL/api/twilio/voice.
+	PublicURL string
+	// AllowedNumbers are the phone numbers, in E.164 format, that may call. If empty everyone can.
+	AllowedNumbers []string
+	// Greeting is spoken when the call is answered.
+	Greeting string
+	// Language of the speech recognition and synthesis. Default en-US.
+	Language string
+	// Voice of the speech synthesis, the default voice of Twilio is used if not set.
+	Voice string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Options = o.Options.Merge(other.Options)
+	result.AuthToken = complete.Last(o.AuthToken, other.AuthToken)
+	result.PublicURL = complete.Last(o.PublicURL, other.PublicURL)
+	result.AllowedNumbers = append(o.AllowedNumbers, other.AllowedNumbers...)
+	result.Greeting = complete.Last(o.Greeting, other.Greeting)
+	result.Language = complete.Last(o.Language, other.Language)
+	result.Voice = complete.Last(o.Voice, other.Voice)
+	return
+}
+
+func (o Options) Complete() Options {
+	if o.Greeting == "" {
+		o.Greeting = "Hello, how can I help you?"
+	}
+	if o.Language == "" {
+		o.Language = "en-US"
+	}
+	o.PublicURL = strings.TrimSuffix(o.PublicURL, "/")
+	return o
+}
+
+// Bot answers calls on VoicePath and runs the conversation of a call on RelayPath.
+type Bot struct {
+	ctx    context.Context
+	opt    Options
+	runner *channel.Runner
+}
+
+func NewBot(ctx context.Context, runt *runtime.Runtime, config types.ConfigFactory, sessions *session.Manager, server mcp.MessageHandler, opts ...Options) (*Bot, error) {
+	opt := complete.Complete(opts...)
+	if opt.AuthToken == "" || opt.PublicURL == "" {
+		return nil, fmt.Errorf("the auth token and public URL are required")
+	}
+	if u, err := url.Parse(opt.PublicURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("invalid public URL %q", opt.PublicURL)
+	}
+
+	runner, err := channel.NewRunner(runt, config, sessions, server, opt.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bot{
+		ctx:    ctx,
+		opt:    opt,
+		runner: runner,
+	}, nil
+}
+
+func (b *Bot) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == RelayPath {
+		b.relay(rw, req)
+		return
+	}
+	b.answer(rw, req)
+}
+
+// answer responds to the webhook of an incoming call with TwiML that connects the call to the
+// relay.
+func (b *Bot) answer(rw http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		http.Error(rw, "Failed to parse request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	want := signature(b.opt.AuthToken, b.opt.PublicURL+req.URL.RequestURI(), req.PostForm)
+	if subtle.ConstantTimeCompare([]byte(req.Header.Get("X-Twilio-Signature")), []byte(want)) != 1 {
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var response twiml
+	if callSid := req.PostForm.Get("CallSid"); callSid == "" || !b.allowed(req.PostForm.Get("From")) {
+		log.Debugf(req.Context(), "rejecting call from a number that is not allowed")
+		response.Reject = &struct{}{}
+	} else {
+		response.Connect = &connect{
+			ConversationRelay: conversationRelay{
+				URL:             b.relayURL(callSid),
+				WelcomeGreeting: b.opt.Greeting,
+				Language:        b.opt.Language,
+				Voice:           b.opt.Voice,
+				DTMFDetection:   true,
+			},
+		}
+	}
+
+	data, err := response.marshal()
+	if err != nil {
+		http.Error(rw, "Failed to render TwiML: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/xml")
+	_, _ = rw.Write(data)
+}
+
+// relayURL returns the URL of the WebSocket of the call. It carries a token derived from the call
+// SID, so that only the call that was answered can connect.
+func (b *Bot) relayURL(callSid string) string {
+	u, _ := url.Parse(b.opt.PublicURL + RelayPath)
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	u.RawQuery = url.Values{
+		"call":  {callSid},
+		"token": {b.callToken(callSid)},
+	}.Encode()
+	return u.String()
+}
+
+func (b *Bot) callToken(callSid string) string {
+	return channel.SessionID(b.opt.AuthToken, "relay", callSid)
+}
+
+func (b *Bot) allowed(number string) bool {
+	return len(b.opt.AllowedNumbers) == 0 || slices.Contains(b.opt.AllowedNumbers, number)
+}
+
+// relay runs the conversation of a call over the WebSocket of the ConversationRelay.
+func (b *Bot) relay(rw http.ResponseWriter, req *http.Request) {
+	callSid := req.URL.Query().Get("call")
+	if callSid == "" || !hmac.Equal([]byte(req.URL.Query().Get("token")), []byte(b.callToken(callSid))) {
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := websocket.Accept(rw, req, nil)
+	if err != nil {
+		log.Errorf(req.Context(), "failed to accept Twilio relay connection: %v", err)
+		return
+	}
+	defer conn.CloseNow()
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+	defer cancel()
+	context.AfterFunc(b.ctx, cancel)
+
+	c := &call{
+		bot:  b,
+		conn: conn,
+		sid:  callSid,
+	}
+	if err := c.run(ctx); err != nil && websocket.CloseStatus(err) == -1 && !errors.Is(err, context.Canceled) {
+		log.Errorf(ctx, "Twilio call %s failed: %v", callSid, err)
+	}
+	c.wait()
+	_ = conn.Close(websocket.StatusNormalClosure, "")
+}
+
+// call is the conversation of a phone call. The prompts of the caller are answered one after the
+// other, a new prompt interrupts the response to the previous one.
+type call struct {
+	bot  *Bot
+	conn *websocket.Conn
+	sid  string
+
+	setup relayMessage
+
+	writeLock sync.Mutex
+	turns     sync.WaitGroup
+
+	lock        sync.Mutex
+	speaker     *speaker
+	interrupted string
+}
+
+func (c *call) run(ctx context.Context) error {
+	for {
+		var msg relayMessage
+		if err := wsjson.Read(ctx, c.conn, &msg); err != nil {
+			return err
+		}
+
+		switch msg.Type {
+		case "setup":
+			if msg.CallSid != c.sid {
+				return fmt.Errorf("setup is for call %s", msg.CallSid)
+			}
+			c.setup = msg
+		case "prompt":
+			if msg.Last && strings.TrimSpace(msg.VoicePrompt) != "" {
+				c.respond(ctx, msg.VoicePrompt)
+			}
+		case "dtmf":
+			c.respond(ctx, fmt.Sprintf("The caller pressed %s on the keypad.", msg.Digit))
+		case "interrupt":
+			c.interrupt(msg.UtteranceUntilInterrupt)
+		case "error":
+			log.Errorf(ctx, "Twilio call %s reported an error: %s", c.sid, msg.Description)
+		}
+	}
+}
+
+// respond answers a prompt of the caller in the background, so that interruptions are received
+// while the response is generated.
+func (c *call) respond(ctx context.Context, prompt string) {
+	if c.setup.CallSid == "" {
+		log.Errorf(ctx, "Twilio call %s sent a prompt before the setup", c.sid)
+		return
+	}
+
+	s := newSpeaker(uuid.String(), func(text string, last bool) {
+		c.send(ctx, textMessage{Type: "text", Token: text, Last: last})
+	})
+
+	c.lock.Lock()
+	if c.speaker != nil {
+		c.speaker.mute()
+	}
+	c.speaker = s
+	if c.interrupted != "" {
+		// The caller only heard part of the previous response, which the model does not know.
+		prompt = fmt.Sprintf("(The caller interrupted your previous response after hearing: %q)\n\n%s", c.interrupted, prompt)
+		c.interrupted = ""
+	}
+	c.lock.Unlock()
+
+	c.turns.Add(1)
+	go func() {
+		defer c.turns.Done()
+
+		resp, err := c.bot.runner.Run(ctx, channel.Message{
+			Channel:       "phone call",
+			SessionType:   SessionType,
+			SessionID:     channel.SessionID(c.bot.opt.AuthToken, c.sid),
+			User:          c.user(),
+			Description:   "Phone call with " + complete.First(c.setup.CallerName, c.setup.From),
+			Prompt:        prompt,
+			ProgressToken: s.token,
+			Filter:        s.filter,
+		})
+		if err != nil {
+			log.Errorf(ctx, "failed to answer Twilio call %s: %v", c.sid, err)
+			s.finish("Sorry, something went wrong. Please try again.")
+			return
+		}
+		s.finish(resp.Text)
+	}()
+}
+
+// interrupt mutes the response that is being spoken. The response is still generated so that the
+// transcript is complete, the next prompt tells the model what the caller heard.
+func (c *call) interrupt(heard string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.speaker != nil {
+		c.speaker.mute()
+	}
+	c.interrupted = heard
+}
+
+func (c *call) send(ctx context.Context, msg textMessage) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := wsjson.Write(ctx, c.conn, msg); err != nil {
+		log.Debugf(ctx, "failed to send text to Twilio call %s: %v", c.sid, err)
+	}
+}
+
+// wait waits for the responses that are still generated after the call ended, so that their
+// transcripts are stored.
+func (c *call) wait() {
+	c.lock.Lock()
+	if c.speaker != nil {
+		c.speaker.mute()
+	}
+	c.lock.Unlock()
+	c.turns.Wait()
+}
+
+func (c *call) user() types.User {
+	return types.User{
+		ID:   "twilio:" + c.setup.From,
+		Name: c.setup.CallerName,
+	}
+}
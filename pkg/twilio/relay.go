@@ -0,0 +1,94 @@
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// relayMessage is a message ConversationRelay sends over the WebSocket of a call. The fields that
+// are set depend on the type.
+type relayMessage struct {
+	Type string `json:"type"`
+
+	// setup
+	CallSid          string            `json:"callSid,omitempty"`
+	From             string            `json:"from,omitempty"`
+	To               string            `json:"to,omitempty"`
+	CallerName       string            `json:"callerName,omitempty"`
+	Direction        string            `json:"direction,omitempty"`
+	CustomParameters map[string]string `json:"customParameters,omitempty"`
+
+	// prompt
+	VoicePrompt string `json:"voicePrompt,omitempty"`
+	Lang        string `json:"lang,omitempty"`
+	Last        bool   `json:"last,omitempty"`
+
+	// dtmf
+	Digit string `json:"digit,omitempty"`
+
+	// interrupt
+	UtteranceUntilInterrupt  string `json:"utteranceUntilInterrupt,omitempty"`
+	DurationUntilInterruptMs int    `json:"durationUntilInterruptMs,omitempty"`
+
+	// error
+	Description string `json:"description,omitempty"`
+}
+
+// textMessage is spoken to the caller. Tokens are spoken as they arrive, last marks the end of the
+// response.
+type textMessage struct {
+	Type  string `json:"type"`
+	Token string `json:"token"`
+	Last  bool   `json:"last"`
+}
+
+type twiml struct {
+	XMLName xml.Name  `xml:"Response"`
+	Reject  *struct{} `xml:"Reject,omitempty"`
+	Connect *connect  `xml:"Connect,omitempty"`
+}
+
+type connect struct {
+	ConversationRelay conversationRelay `xml:"ConversationRelay"`
+}
+
+// conversationRelay connects the call to a WebSocket. Twilio transcribes the speech of the caller,
+// sends it as prompts, and speaks the text sent back. Callers can interrupt the spoken response.
+type conversationRelay struct {
+	URL             string `xml:"url,attr"`
+	WelcomeGreeting string `xml:"welcomeGreeting,attr,omitempty"`
+	Language        string `xml:"language,attr,omitempty"`
+	Voice           string `xml:"voice,attr,omitempty"`
+	DTMFDetection   bool   `xml:"dtmfDetection,attr,omitempty"`
+}
+
+func (t twiml) marshal() ([]byte, error) {
+	data, err := xml.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// signature computes the X-Twilio-Signature of a request, which is the HMAC-SHA1 of the URL followed
+// by the sorted names and values of the POST parameters.
+func signature(authToken, requestURL string, params url.Values) string {
+	var buf strings.Builder
+	buf.WriteString(requestURL)
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		for _, value := range params[name] {
+			buf.WriteString(name)
+			buf.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(buf.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
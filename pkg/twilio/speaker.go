@@ -0,0 +1,158 @@
+package twilio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/render"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// speaker speaks the response of the agent to a prompt while it is generated. Text is collected
+// from the progress notifications of the turn and sent sentence by sentence, so that the caller
+// hears the beginning of the response while the rest is generated. Once the caller interrupts, the
+// speaker is muted and the rest of the response is not spoken.
+type speaker struct {
+	token string
+	send  func(text string, last bool)
+
+	lock   sync.Mutex
+	items  []speakerItem
+	spoken int
+	muted  bool
+}
+
+type speakerItem struct {
+	messageID, itemID string
+	text              string
+}
+
+func newSpeaker(token string, send func(text string, last bool)) *speaker {
+	return &speaker{
+		token: token,
+		send:  send,
+	}
+}
+
+// filter collects the text of the progress notifications of the turn, it never modifies messages.
+func (s *speaker) filter(_ context.Context, msg *mcp.Message) (*mcp.Message, error) {
+	if msg.Method != "notifications/progress" {
+		return msg, nil
+	}
+
+	var payload struct {
+		ProgressToken any `json:"progressToken"`
+		Meta          struct {
+			Progress *types.CompletionProgress `json:"ai.nanobot.progress/completion"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(msg.Params, &payload); err != nil || payload.Meta.Progress == nil ||
+		fmt.Sprint(payload.ProgressToken) != s.token {
+		return msg, nil
+	}
+
+	progress := payload.Meta.Progress
+	if progress.Role == "user" || progress.Item.Content == nil || progress.Item.Content.Type != "text" {
+		return msg, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.add(progress.MessageID, progress.Item.ID, progress.Item.Content.Text, progress.Item.Partial)
+	s.speak(false)
+	return msg, nil
+}
+
+// add appends streamed text to the item it belongs to. The caller must hold the lock.
+func (s *speaker) add(messageID, itemID, text string, partial bool) {
+	for i := range s.items {
+		if s.items[i].messageID == messageID && s.items[i].itemID == itemID {
+			if partial {
+				s.items[i].text += text
+			} else {
+				s.items[i].text = text
+			}
+			return
+		}
+	}
+	s.items = append(s.items, speakerItem{
+		messageID: messageID,
+		itemID:    itemID,
+		text:      text,
+	})
+}
+
+// speak sends the complete sentences that have not been spoken yet, or all remaining text if final
+// is set. The caller must hold the lock.
+func (s *speaker) speak(final bool) {
+	if s.muted {
+		return
+	}
+
+	var parts []string
+	for _, item := range s.items {
+		parts = append(parts, item.text)
+	}
+	text := strings.Join(parts, "\n\n")
+	if len(text) < s.spoken {
+		// The final text of an item differs from the streamed text, nothing sensible can be
+		// spoken from it.
+		return
+	}
+
+	end := len(text)
+	if !final {
+		end = s.spoken + sentenceEnd(text[s.spoken:])
+		if strings.Count(text[:end], "```")%2 == 1 {
+			// Wait for the end of the code block, it is left out as a whole.
+			return
+		}
+	}
+	if end <= s.spoken {
+		return
+	}
+
+	if speech := strings.TrimSpace(render.Speech(text[s.spoken:end])); speech != "" {
+		s.send(speech+" ", false)
+	}
+	s.spoken = end
+}
+
+// finish speaks the rest of the response. If nothing was streamed, for example because the model
+// does not support streaming, the final response is spoken instead.
+func (s *speaker) finish(markdown string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.items) == 0 {
+		s.add("", "", markdown, false)
+	}
+	s.speak(true)
+	if !s.muted {
+		s.send("", true)
+	}
+	s.muted = true
+}
+
+// mute stops speaking the response.
+func (s *speaker) mute() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.muted = true
+}
+
+// sentenceEnd returns the length of the complete sentences or lines at the start of text.
+func sentenceEnd(text string) int {
+	end := strings.LastIndexByte(text, '\n') + 1
+	for _, sep := range []string{". ", "! ", "? "} {
+		if i := strings.LastIndex(text, sep); i >= 0 && i+len(sep) > end {
+			end = i + len(sep)
+		}
+	}
+	return end
+}
@@ -0,0 +1,72 @@
+package twilio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAnswer(t *testing.T) {
+	b := &Bot{opt: Options{AuthToken: "secret", PublicURL: "https://bot.example.com"}.Complete()}
+	form := url.Values{"CallSid": {"CA123"}, "From": {"+15551234567"}}
+
+	call := func(sig string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, VoicePath, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Twilio-Signature", sig)
+		rw := httptest.NewRecorder()
+		b.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := call(signature("secret", "https://bot.example.com"+VoicePath, form))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("expected signed request to be accepted, got %d", rw.Code)
+	}
+	if body := rw.Body.String(); !strings.Contains(body, `<ConversationRelay url="wss://bot.example.com/api/twilio/voice/relay?call=CA123&amp;token=`) {
+		t.Errorf("expected the call to be connected to the relay, got %s", body)
+	}
+
+	if rw := call(signature("other", "https://bot.example.com"+VoicePath, form)); rw.Code != http.StatusUnauthorized {
+		t.Errorf("expected wrong signature to be rejected, got %d", rw.Code)
+	}
+
+	b.opt.AllowedNumbers = []string{"+15550000000"}
+	if body := call(signature("secret", "https://bot.example.com"+VoicePath, form)).Body.String(); !strings.Contains(body, "<Reject></Reject>") {
+		t.Errorf("expected call from a number that is not allowed to be rejected, got %s", body)
+	}
+}
+
+func TestSpeaker(t *testing.T) {
+	var spoken []string
+	s := newSpeaker("token", func(text string, last bool) {
+		if last {
+			text = "<last>"
+		}
+		spoken = append(spoken, text)
+	})
+
+	s.add("m1", "i1", "Hello **there**. How", true)
+	s.speak(false)
+	s.add("m1", "i1", " are you?", true)
+	s.speak(false)
+	s.finish("")
+
+	want := []string{"Hello there. ", "How are you? ", "<last>"}
+	if strings.Join(spoken, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", spoken, want)
+	}
+
+	spoken = nil
+	s = newSpeaker("token", func(text string, last bool) {
+		spoken = append(spoken, text)
+	})
+	s.add("m1", "i1", "One. Two", true)
+	s.mute()
+	s.finish("")
+	if len(spoken) != 0 {
+		t.Errorf("expected muted speaker to be silent, got %q", spoken)
+	}
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 )
@@ -77,6 +78,27 @@ type Chat struct {
 	Created    time.Time `json:"created"`
 	ReadOnly   bool      `json:"readonly,omitempty"`
 	Visibility string    `json:"visibility,omitempty"`
+	Ephemeral  bool      `json:"ephemeral,omitempty"`
+	// Summary is the structured summary of the conversation, if the agent keeps one.
+	Summary *SessionSummary `json:"summary,omitempty"`
+}
+
+// SessionSummary is a rolling summary of a conversation that is updated after each turn.
+type SessionSummary struct {
+	Topics        []string  `json:"topics,omitempty"`
+	Decisions     []string  `json:"decisions,omitempty"`
+	OpenQuestions []string  `json:"openQuestions,omitempty"`
+	Updated       time.Time `json:"updated"`
+}
+
+// IsZero reports whether the summary was never written.
+func (s SessionSummary) IsZero() bool {
+	return s.Updated.IsZero()
+}
+
+// SummaryStore saves the summary of a session when it changes outside a request to the session.
+type SummaryStore interface {
+	StoreSummary(ctx context.Context, sessionID string, summary SessionSummary) error
 }
 
 type AgentList struct {
@@ -91,4 +113,16 @@ type AgentDisplay struct {
 	Icon            string   `json:"icon"`
 	IconDark        string   `json:"iconDark"`
 	StarterMessages []string `json:"starterMessages"`
+	Ephemeral       bool     `json:"ephemeral,omitempty"`
+	Model           string   `json:"model,omitempty"`
+	// Capabilities are nil when they are not known, as for MCP servers that act as agents.
+	Capabilities *AgentCapabilities `json:"capabilities,omitempty"`
+}
+
+// AgentCapabilities are what an agent can handle, for clients to adapt to the selected agent.
+type AgentCapabilities struct {
+	// Vision is set if the agent accepts images.
+	Vision bool `json:"vision"`
+	// Tools is set if the agent can call tools.
+	Tools bool `json:"tools"`
 }
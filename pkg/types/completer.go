@@ -8,6 +8,7 @@ import (
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	v1 "github.com/nanobot-ai/nanobot/pkg/types/v1"
 	"github.com/nanobot-ai/nanobot/pkg/uuid"
 )
 
@@ -18,16 +19,25 @@ type Completer interface {
 type CompletionOptions struct {
 	ProgressToken any
 	Chat          *bool
+	// ToolChoice and ParallelToolCalls override the configuration of the agent for the first
+	// completion of this call. Follow-up completions after tool calls always use the default.
+	ToolChoice        string
+	ParallelToolCalls *bool
 }
 
 func (c CompletionOptions) Merge(other CompletionOptions) (result CompletionOptions) {
 	result.ProgressToken = complete.Last(c.ProgressToken, other.ProgressToken)
 	result.Chat = complete.Last(c.Chat, other.Chat)
+	result.ToolChoice = complete.Last(c.ToolChoice, other.ToolChoice)
+	result.ParallelToolCalls = complete.Last(c.ParallelToolCalls, other.ParallelToolCalls)
 	return
 }
 
 type CompletionRequest struct {
-	Model             string               `json:"model,omitempty"`
+	Model string `json:"model,omitempty"`
+	// API selects the OpenAI API used for the request, "responses" or "completions". If not set
+	// the API is chosen by the client.
+	API               string               `json:"api,omitempty"`
 	Agent             string               `json:"agent,omitempty"`
 	ThreadName        string               `json:"threadName,omitempty"`
 	NewThread         bool                 `json:"newThread,omitempty"`
@@ -36,20 +46,33 @@ type CompletionRequest struct {
 	SystemPrompt      string               `json:"systemPrompt,omitzero"`
 	MaxTokens         int                  `json:"maxTokens,omitempty"`
 	ToolChoice        string               `json:"toolChoice,omitempty"`
+	ParallelToolCalls *bool                `json:"parallelToolCalls,omitempty"`
 	OutputSchema      *OutputSchema        `json:"outputSchema,omitempty"`
 	Temperature       *json.Number         `json:"temperature,omitempty"`
 	Truncation        string               `json:"truncation,omitempty"`
 	TopP              *json.Number         `json:"topP,omitempty"`
 	Metadata          map[string]any       `json:"metadata,omitempty"`
 	Tools             []ToolUseDefinition  `json:"tools,omitzero"`
-	InputAsToolResult *bool                `json:"inputAsToolResult,omitempty"`
-	Reasoning         *AgentReasoning      `json:"reasoning,omitempty"`
+	// BuiltinTools are the hosted tools of the provider, such as web_search or code_interpreter,
+	// keyed by type with their configuration. They are only supported by the Responses API.
+	BuiltinTools      map[string]map[string]any `json:"builtinTools,omitempty"`
+	InputAsToolResult *bool                     `json:"inputAsToolResult,omitempty"`
+	Reasoning         *AgentReasoning           `json:"reasoning,omitempty"`
+	Audio             *AgentAudio               `json:"audio,omitempty"`
+	// Logprobs requests the log probability of every output token, with the TopLogprobs most likely
+	// alternatives at each position.
+	Logprobs    bool `json:"logprobs,omitempty"`
+	TopLogprobs int  `json:"topLogprobs,omitempty"`
+	// CacheTTL overrides how long the response is cached, zero disables caching. It is not part
+	// of the request sent to the provider.
+	CacheTTL *time.Duration `json:"-"`
 }
 
 func (r CompletionRequest) Reset() CompletionRequest {
 	r.Input = nil
 	r.InputAsToolResult = &[]bool{false}[0]
 	r.NewThread = false
+	r.ToolChoice = ""
 	return r
 }
 
@@ -66,9 +89,40 @@ type CompletionProgress struct {
 	MessageID string         `json:"messageID,omitempty"`
 	Role      string         `json:"role,omitempty"`
 	Item      CompletionItem `json:"item,omitempty"`
+	Event     *ProgressEvent `json:"event,omitempty"`
+	// Ephemeral is set when the session the progress belongs to is not persisted.
+	Ephemeral bool `json:"ephemeral,omitempty"`
 }
 
-const CompletionProgressMetaKey = "ai.nanobot.progress/completion"
+const (
+	ProgressEventDegenerateOutput = "degenerate_output"
+	ProgressEventCancelled        = "cancelled"
+	// ProgressEventElicitation asks the user a question of a server as a form, the answer is sent
+	// back with the answer_elicitation tool of the agent UI server.
+	ProgressEventElicitation = "elicitation"
+)
+
+// ProgressEvent reports something that happened to the completion itself, as opposed to content
+// being streamed. For example, the generation of MessageID being aborted.
+type ProgressEvent struct {
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+	Retry   bool   `json:"retry,omitempty"`
+	// Elicitation is the form of a ProgressEventElicitation event.
+	Elicitation *ProgressElicitation `json:"elicitation,omitempty"`
+}
+
+// ProgressElicitation is a question a server asks the user during a tool call, the form of the
+// answer is described by RequestedSchema.
+type ProgressElicitation struct {
+	ID              string              `json:"id"`
+	Server          string              `json:"server,omitempty"`
+	RequestedSchema mcp.PrimitiveSchema `json:"requestedSchema"`
+}
+
+// CompletionProgressMetaKey is the key of the progress in the _meta of progress notifications, it is
+// part of the v1 API.
+const CompletionProgressMetaKey = v1.ProgressMetaKey
 
 type Message struct {
 	ID      string           `json:"id,omitempty"`
@@ -76,6 +130,31 @@ type Message struct {
 	Role    string           `json:"role,omitempty"`
 	Items   []CompletionItem `json:"items,omitempty"`
 	HasMore bool             `json:"hasMore,omitempty"`
+	// Agent is the agent that generated the message, for the outputs of models.
+	Agent string `json:"agent,omitempty"`
+	// ContentFilter holds the results of the content filter of the provider for the prompt and the
+	// output of the completion, for the providers that report them, like Azure OpenAI.
+	ContentFilter []ContentFilterResult `json:"contentFilter,omitempty"`
+}
+
+const (
+	// ContentFilterPrompt and ContentFilterOutput are the targets of the results of a content filter.
+	ContentFilterPrompt = "prompt"
+	ContentFilterOutput = "output"
+)
+
+// ContentFilterResult is the verdict of the content filter of a provider for one category of
+// content, such as hate, violence or jailbreak.
+type ContentFilterResult struct {
+	// Target is what was checked, ContentFilterPrompt or ContentFilterOutput.
+	Target   string `json:"target"`
+	Category string `json:"category"`
+	// Filtered is set if the provider removed or blocked the content.
+	Filtered bool `json:"filtered,omitempty"`
+	// Severity is safe, low, medium or high for the categories that are rated.
+	Severity string `json:"severity,omitempty"`
+	// Detected is set for the categories that are detected rather than rated, like jailbreak.
+	Detected bool `json:"detected,omitempty"`
 }
 
 type CompletionItem struct {
@@ -86,6 +165,7 @@ type CompletionItem struct {
 	ToolCall       *ToolCall       `json:"toolCall,omitempty"`
 	ToolCallResult *ToolCallResult `json:"toolCallResult,omitempty"`
 	Reasoning      *Reasoning      `json:"reasoning,omitempty"`
+	Suggestions    *Suggestions    `json:"suggestions,omitempty"`
 }
 
 func (c *CompletionItem) UnmarshalJSON(data []byte) error {
@@ -150,6 +230,9 @@ func (c *CompletionItem) UnmarshalJSON(data []byte) error {
 	case "reasoning":
 		c.Reasoning = &Reasoning{}
 		return json.Unmarshal(data, c.Reasoning)
+	case "suggestions":
+		c.Suggestions = &Suggestions{}
+		return json.Unmarshal(data, c.Suggestions)
 	}
 
 	return nil
@@ -230,6 +313,20 @@ func (c CompletionItem) MarshalJSON() ([]byte, error) {
 			Partial:   c.Partial,
 			Reasoning: c.Reasoning,
 		})
+	} else if c.Suggestions != nil {
+		return json.Marshal(struct {
+			ID      string `json:"id,omitempty"`
+			Type    string `json:"type,omitempty"`
+			HasMore bool   `json:"hasMore,omitempty"`
+			Partial bool   `json:"partial,omitempty"`
+			*Suggestions
+		}{
+			ID:          c.ID,
+			Type:        "suggestions",
+			HasMore:     c.HasMore,
+			Partial:     c.Partial,
+			Suggestions: c.Suggestions,
+		})
 	}
 	type Alias CompletionItem
 	return json.Marshal(Alias(c))
@@ -240,6 +337,11 @@ type Reasoning struct {
 	Summary          []SummaryText `json:"summary,omitempty"`
 }
 
+// Suggestions are prompts the user may want to send next, offered after a turn as quick replies.
+type Suggestions struct {
+	Prompts []string `json:"prompts,omitempty"`
+}
+
 type SummaryText struct {
 	Text string `json:"text,omitempty"`
 }
@@ -253,6 +355,60 @@ type CompletionResponse struct {
 	HasMore          bool      `json:"hasMore,omitempty"`
 	Error            string    `json:"error,omitempty"`
 	ProgressToken    any       `json:"progressToken,omitempty"`
+	Usage            *Usage    `json:"usage,omitempty"`
+	StopReason       string    `json:"stopReason,omitempty"`
+	// Logprobs holds the log probabilities of the output tokens, if they were requested and the
+	// provider supports them.
+	Logprobs []TokenLogprob `json:"logprobs,omitempty"`
+}
+
+// TokenLogprob is the log probability of a single output token.
+type TokenLogprob struct {
+	Token       string       `json:"token"`
+	Logprob     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogprobs []TopLogprob `json:"topLogprobs,omitempty"`
+}
+
+// TopLogprob is one of the most likely tokens at the position of a TokenLogprob.
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
+}
+
+// Usage is the token accounting reported by the provider for a single completion.
+type Usage struct {
+	InputTokens       int `json:"inputTokens,omitempty"`
+	OutputTokens      int `json:"outputTokens,omitempty"`
+	CachedInputTokens int `json:"cachedInputTokens,omitempty"`
+	ReasoningTokens   int `json:"reasoningTokens,omitempty"`
+}
+
+// UsageListener receives the usage of every completion made with a context it was registered on.
+type UsageListener func(usage Usage)
+
+type usageListenerKey struct{}
+
+// WithUsageListener returns a context that passes the usage of its completions to the listener.
+// Listeners are chained, so registering a new listener does not hide one that was previously
+// registered.
+func WithUsageListener(ctx context.Context, listener UsageListener) context.Context {
+	if parent, ok := ctx.Value(usageListenerKey{}).(UsageListener); ok {
+		next := listener
+		listener = func(usage Usage) {
+			next(usage)
+			parent(usage)
+		}
+	}
+	return context.WithValue(ctx, usageListenerKey{}, listener)
+}
+
+// RecordUsage passes the usage of a completion to the listeners of ctx.
+func RecordUsage(ctx context.Context, usage *Usage) {
+	if listener, ok := ctx.Value(usageListenerKey{}).(UsageListener); ok && usage != nil {
+		listener(*usage)
+	}
 }
 
 func (c *CompletionResponse) Serialize() (any, error) {
@@ -287,6 +443,14 @@ type CallResult struct {
 	Model             string        `json:"model,omitempty"`
 	StopReason        string        `json:"stopReason,omitempty"`
 	StructuredContent any           `json:"structuredContent,omitempty"`
+	// Approval records who approved or rejected the call, for tools that require confirmation.
+	Approval *ToolApproval `json:"approval,omitempty"`
+}
+
+type ToolApproval struct {
+	Approved bool      `json:"approved"`
+	User     string    `json:"user,omitempty"`
+	Time     time.Time `json:"time"`
 }
 
 type AsyncCallResult struct {
@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/envvar"
 	"github.com/nanobot-ai/nanobot/pkg/mcp"
 )
 
@@ -21,6 +25,11 @@ const (
 	PublicSessionKey                = "public"
 	ResourceSubscriptionsSessionKey = "resourceSubscriptions"
 	PublicURLSessionKey             = "publicURL"
+	EphemeralSessionKey             = "ephemeral"
+	SummarySessionKey               = "summary"
+	// ManagerSessionKey holds the session manager, which is also the HistoryLoader and SummaryStore
+	// of the session.
+	ManagerSessionKey = "sessionManager"
 )
 
 func ConfigFromContext(ctx context.Context) (result Config) {
@@ -28,6 +37,37 @@ func ConfigFromContext(ctx context.Context) (result Config) {
 	return
 }
 
+// MCPServerEnv returns the env of a built-in MCP server, which is the env of its entry in the config
+// on top of the env of the session. Values in the config may refer to the env of the session.
+func MCPServerEnv(ctx context.Context, name string) map[string]string {
+	env := mcp.SessionFromContext(ctx).GetEnvMap()
+	maps.Copy(env, envvar.ReplaceMap(env, ConfigFromContext(ctx).MCPServers[name].Env))
+	return env
+}
+
+// IsEphemeral reports whether nothing about the session may be written to the store or the logs.
+// A flag set explicitly on the session wins, otherwise the default of the current agent applies.
+func IsEphemeral(session *mcp.Session) bool {
+	if session == nil {
+		return false
+	}
+
+	var ephemeral bool
+	if session.Get(EphemeralSessionKey, &ephemeral) {
+		return ephemeral
+	}
+
+	var (
+		config       Config
+		currentAgent string
+	)
+	session.Get(ConfigSessionKey, &config)
+	if !session.Get(CurrentAgentSessionKey, &currentAgent) && len(config.Publish.Entrypoint) > 0 {
+		currentAgent = config.Publish.Entrypoint[0]
+	}
+	return config.Agents[currentAgent].Ephemeral
+}
+
 type Config struct {
 	Auth       *Auth                 `json:"auth,omitempty"`
 	Extends    StringList            `json:"extends,omitempty"`
@@ -38,6 +78,64 @@ type Config struct {
 	Flows      map[string]Flow       `json:"flows,omitempty"`
 	Profiles   map[string]Config     `json:"profiles,omitempty"`
 	Prompts    map[string]Prompt     `json:"prompts,omitempty"`
+	// ToolPolicies set the timeout, retries and error handling of tool calls.
+	ToolPolicies []ToolPolicy `json:"toolPolicies,omitempty"`
+}
+
+// ToolPolicy sets how the calls of the tools it matches are run. The first policy that matches a
+// tool applies to it, so policies of single tools are listed before those of whole servers.
+type ToolPolicy struct {
+	// Tools are the servers or server/tool names the policy applies to, as patterns such as
+	// filesystem/*.
+	Tools StringList `json:"tools,omitempty"`
+	// Timeout limits each attempt of a call, as a duration such as 30s.
+	Timeout string `json:"timeout,omitempty"`
+	// MaxRetries is the number of times a call that failed is retried.
+	MaxRetries int `json:"maxRetries,omitempty"`
+	// Backoff is the delay before the first retry, it doubles for every further retry. Defaults to 1s.
+	Backoff string `json:"backoff,omitempty"`
+	// OnError is what happens when a call still fails after the retries, ToolErrorReturn or
+	// ToolErrorFail.
+	OnError string `json:"onError,omitempty"`
+	// MaxOutputSize is the size in bytes above which the text output of a call is truncated before
+	// it is given to the model, which can page through the full output with the read_output tool.
+	MaxOutputSize int `json:"maxOutputSize,omitempty"`
+	// CacheTTL caches the results of the calls for the duration, such as 10m, within a session.
+	// Only set it for tools whose results only depend on their arguments.
+	CacheTTL string `json:"cacheTTL,omitempty"`
+	// Invalidates are the tools, as patterns like Tools, whose cached results are dropped when a
+	// call of the tools of this policy succeeds, such as filesystem/read_* for a write tool.
+	Invalidates StringList `json:"invalidates,omitempty"`
+}
+
+const (
+	// ToolErrorReturn returns the error of a failed tool call to the model, which can react to it.
+	// This is the default.
+	ToolErrorReturn = "return-error"
+	// ToolErrorFail fails the turn of the agent that called the tool.
+	ToolErrorFail = "fail-turn"
+)
+
+func (p ToolPolicy) validate(index int) error {
+	var errs []error
+	if len(p.Tools) == 0 {
+		errs = append(errs, fmt.Errorf("tool policy %d does not list any tools", index))
+	}
+	for _, field := range []struct{ name, value string }{{"timeout", p.Timeout}, {"backoff", p.Backoff}, {"cacheTTL", p.CacheTTL}} {
+		if d, err := time.ParseDuration(field.value); field.value != "" && (err != nil || d <= 0) {
+			errs = append(errs, fmt.Errorf("tool policy %d has invalid %s %q, must be a duration such as 30s", index, field.name, field.value))
+		}
+	}
+	if p.MaxRetries < 0 || p.MaxRetries > 10 {
+		errs = append(errs, fmt.Errorf("tool policy %d has invalid maxRetries %d, must be between 0 and 10", index, p.MaxRetries))
+	}
+	if p.OnError != "" && p.OnError != ToolErrorReturn && p.OnError != ToolErrorFail {
+		errs = append(errs, fmt.Errorf("tool policy %d has invalid onError %q, must be %q or %q", index, p.OnError, ToolErrorReturn, ToolErrorFail))
+	}
+	if p.MaxOutputSize < 0 {
+		errs = append(errs, fmt.Errorf("tool policy %d has invalid maxOutputSize %d, must not be negative", index, p.MaxOutputSize))
+	}
+	return errors.Join(errs...)
 }
 
 type ConfigFactory func(ctx context.Context, profiles string) (Config, error)
@@ -74,6 +172,19 @@ func (c Config) Validate(allowLocal bool) error {
 		if err := validateMCPServer(mcpServerName, mcpServer, allowLocal); err != nil {
 			errs = append(errs, err)
 		}
+		if mcpServer.Sampling != nil {
+			for _, model := range mcpServer.Sampling.Models {
+				if _, ok := c.Agents[model]; !ok {
+					errs = append(errs, fmt.Errorf("mcpServer %q can sample from agent %q, which does not exist", mcpServerName, model))
+				}
+			}
+		}
+	}
+
+	for i, policy := range c.ToolPolicies {
+		if err := policy.validate(i); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	for flowName, flow := range c.Flows {
@@ -89,6 +200,34 @@ func (c Config) Validate(allowLocal bool) error {
 }
 
 func validateMCPServer(mcpServerName string, mcpServer mcp.Server, allowLocal bool) error {
+	if mcpServer.MaxConcurrency < 0 {
+		return fmt.Errorf("mcpServer %q has invalid maxConcurrency %d, must not be negative", mcpServerName, mcpServer.MaxConcurrency)
+	}
+	if sandbox := mcpServer.Sandbox; sandbox != nil {
+		if sandbox.Network == "none" && (mcpServer.BaseURL != "" || len(mcpServer.ReversePorts) > 0) {
+			return fmt.Errorf("mcpServer %q can not use url or reversePorts without a sandbox network", mcpServerName)
+		}
+		if len(sandbox.Mounts) > 0 && !allowLocal {
+			return fmt.Errorf("mcpServer %q can not mount host paths into its sandbox", mcpServerName)
+		}
+	}
+
+	if sampling := mcpServer.Sampling; sampling != nil && (sampling.MaxTokens < 0 || sampling.TokenBudget < 0) {
+		return fmt.Errorf("mcpServer %q has invalid sampling limits, maxTokens and tokenBudget must not be negative", mcpServerName)
+	}
+	if mock := mcpServer.Mock; mock != nil {
+		seen := map[string]bool{}
+		for i, tool := range mock.Tools {
+			if tool.Name == "" {
+				return fmt.Errorf("mcpServer %q has a mock tool %d without a name", mcpServerName, i)
+			}
+			if seen[tool.Name] {
+				return fmt.Errorf("mcpServer %q has more than one mock of tool %q", mcpServerName, tool.Name)
+			}
+			seen[tool.Name] = true
+		}
+	}
+
 	if allowLocal {
 		return nil
 	}
@@ -116,7 +255,8 @@ func (p Prompt) ToPrompt(name string) mcp.Prompt {
 		Name:        name,
 		Description: p.Description,
 	}
-	for fieldName, field := range p.Input {
+	for _, fieldName := range slices.Sorted(maps.Keys(p.Input)) {
+		field := p.Input[fieldName]
 		result.Arguments = append(result.Arguments, mcp.PromptArgument{
 			Name:        fieldName,
 			Description: field.Description,
@@ -133,6 +273,79 @@ type Auth struct {
 	OAuthScopes                      StringList     `json:"oauthScopes"`
 	OAuthAuthorizationServerMetadata map[string]any `json:"oauthAuthorizationServerMetadata"`
 	EncryptionKey                    string         `json:"encryptionKey"`
+	// Tokens are static bearer tokens, each of which authenticates a user.
+	Tokens []AuthToken `json:"tokens,omitempty"`
+	// Admins are the IDs or emails of the users who can list, resume, and delete the sessions of
+	// all users.
+	Admins StringList `json:"admins,omitempty"`
+	// OIDC logs the users of the web UI in with an OpenID Connect provider.
+	OIDC *AuthOIDC `json:"oidc,omitempty"`
+	// APIKeys authenticate the clients of the MCP endpoints.
+	APIKeys *AuthAPIKeys `json:"apiKeys,omitempty"`
+	// ToolAccess restricts who can call the tools of MCP servers. Tools that match no rule can be
+	// called by all users.
+	ToolAccess []ToolAccess `json:"toolAccess,omitempty"`
+}
+
+type ToolAccess struct {
+	// Tools are the servers or tools the rule applies to, as server or server/tool patterns such as
+	// shell or filesystem/write_*.
+	Tools StringList `json:"tools"`
+	// Roles are the roles that can call the tools, * allows all users. Admins have the admin role.
+	Roles StringList `json:"roles,omitempty"`
+	// Users are the IDs or emails of the users that can call the tools.
+	Users StringList `json:"users,omitempty"`
+}
+
+type AuthAPIKeys struct {
+	Keys []APIKey `json:"keys,omitempty"`
+	// File is a JSON or YAML file with a list of keys, it is reloaded when it changes.
+	File string `json:"file,omitempty"`
+	// Env is the environment variable with a comma separated list of keys, each either a key or
+	// name=key. Defaults to NANOBOT_API_KEYS.
+	Env string `json:"env,omitempty"`
+	// Paths are the path prefixes that require a key, defaults to /mcp, /v1/, and
+	// /nanobot.v1.Nanobot/.
+	Paths StringList `json:"paths,omitempty"`
+}
+
+type APIKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	// User is the ID of the user the key authenticates, defaults to apikey:<name>.
+	User string `json:"user,omitempty"`
+	// RateLimit is the number of requests per minute allowed with the key, unlimited if zero.
+	RateLimit int `json:"rateLimit,omitempty"`
+	// Agents are the agents that can be called with the key, all agents if empty.
+	Agents StringList `json:"agents,omitempty"`
+}
+
+type AuthOIDC struct {
+	// Issuer is the URL of the provider, its endpoints are discovered from the issuer.
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	// RedirectURL is the callback URL registered with the provider, defaults to /oidc/callback on
+	// the host of the request.
+	RedirectURL string `json:"redirectUrl,omitempty"`
+	// Scopes defaults to openid, profile, and email.
+	Scopes StringList `json:"scopes,omitempty"`
+	// GroupsClaim is the claim of the ID token that lists the groups of the user, defaults to
+	// groups.
+	GroupsClaim string `json:"groupsClaim,omitempty"`
+	// Roles maps roles to the groups granted them. The admin role can access the sessions of all
+	// users, the auditor role can read the turns of all sessions. If the user role is mapped, only
+	// the members of its groups, auditors, and admins can log in.
+	Roles map[string]StringList `json:"roles,omitempty"`
+}
+
+type AuthToken struct {
+	Token string `json:"token"`
+	// User is the ID of the authenticated user, the owner of the sessions created with the token.
+	User  string `json:"user"`
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Admin bool   `json:"admin,omitempty"`
 }
 
 type EnvDef struct {
@@ -190,9 +403,10 @@ type Step struct {
 	Evaluate   any            `json:"evaluate,omitempty"`
 	Return     map[string]any `json:"return,omitempty"`
 	Input      any            `json:"input,omitempty"`
-	Parallel   bool
-	Steps      []Step `json:"steps,omitzero"`
-	Else       []Step `json:"else,omitzero"`
+	// Parallel runs the loops of ForEach, or else the Steps, at the same time.
+	Parallel bool   `json:"parallel,omitempty"`
+	Steps    []Step `json:"steps,omitzero"`
+	Else     []Step `json:"else,omitzero"`
 }
 
 type Elicit struct {
@@ -233,6 +447,9 @@ func ignoreEmptyStringList(s string) []string {
 
 func (s Step) validate(c Config) error {
 	_, _, errs := validateReferences(c, ignoreEmptyStringList(s.Tool), ignoreEmptyStringList(s.Agent.Name), ignoreEmptyStringList(s.Flow))
+	if s.Parallel && s.ForEach == nil && len(s.Steps) == 0 {
+		errs = append(errs, fmt.Errorf("step %q is parallel but has no forEach or steps to run in parallel", s.ID))
+	}
 	for i, step := range s.Steps {
 		if err := step.validate(c); err != nil {
 			errs = append(errs, fmt.Errorf("error validating nested step %d: %w", i, err))
@@ -246,6 +463,7 @@ type AgentCall struct {
 	Output            *OutputSchema `json:"output,omitempty"`
 	Chat              *bool         `json:"chat,omitempty"`
 	ToolChoice        string        `json:"toolChoice,omitempty"`
+	ParallelToolCalls *bool         `json:"parallelToolCalls,omitempty"`
 	Temperature       *json.Number  `json:"temperature,omitempty"`
 	TopP              *json.Number  `json:"topP,omitempty"`
 	NewThread         *bool         `json:"newThread,omitempty"`
@@ -258,6 +476,7 @@ func (a AgentCall) Merge(other AgentCall) (result AgentCall) {
 	result.Output = complete.Last(a.Output, other.Output)
 	result.Chat = complete.Last(a.Chat, other.Chat)
 	result.ToolChoice = complete.Last(a.ToolChoice, other.ToolChoice)
+	result.ParallelToolCalls = complete.Last(a.ParallelToolCalls, other.ParallelToolCalls)
 	result.Temperature = complete.Last(a.Temperature, other.Temperature)
 	result.TopP = complete.Last(a.TopP, other.TopP)
 	result.NewThread = complete.Last(a.NewThread, other.NewThread)
@@ -266,7 +485,7 @@ func (a AgentCall) Merge(other AgentCall) (result AgentCall) {
 }
 
 func (a AgentCall) MarshalJSON() ([]byte, error) {
-	if a.Output == nil && a.Chat == nil && a.ToolChoice == "" && a.Temperature == nil && a.TopP == nil && a.NewThread == nil {
+	if a.Output == nil && a.Chat == nil && a.ToolChoice == "" && a.ParallelToolCalls == nil && a.Temperature == nil && a.TopP == nil && a.NewThread == nil {
 		return json.Marshal(a.Name)
 	}
 	type Alias AgentCall
@@ -446,33 +665,56 @@ func (s *StringList) UnmarshalJSON(data []byte) error {
 }
 
 type Agent struct {
-	Name            string                    `json:"name,omitempty"`
-	ShortName       string                    `json:"shortName,omitempty"`
-	Description     string                    `json:"description,omitempty"`
-	Icon            string                    `json:"icon,omitempty"`
-	IconDark        string                    `json:"iconDark,omitempty"`
-	StarterMessages StringList                `json:"starterMessages,omitempty"`
-	Instructions    DynamicInstructions       `json:"instructions,omitempty"`
-	Model           string                    `json:"model,omitempty"`
-	Before          StringList                `json:"before,omitempty"`
-	After           StringList                `json:"after,omitempty"`
-	MCPServers      StringList                `json:"mcpServers,omitempty"`
-	Tools           StringList                `json:"tools,omitempty"`
-	Agents          StringList                `json:"agents,omitempty"`
-	Flows           StringList                `json:"flows,omitempty"`
-	Prompts         StringList                `json:"prompts,omitzero"`
-	Resources       StringList                `json:"resources,omitzero"`
-	Reasoning       *AgentReasoning           `json:"reasoning,omitempty"`
-	ThreadName      string                    `json:"threadName,omitempty"`
-	Chat            *bool                     `json:"chat,omitempty"`
-	ToolExtensions  map[string]map[string]any `json:"toolExtensions,omitempty"`
-	ToolChoice      string                    `json:"toolChoice,omitempty"`
-	Temperature     *json.Number              `json:"temperature,omitempty"`
-	TopP            *json.Number              `json:"topP,omitempty"`
-	Output          *OutputSchema             `json:"output,omitempty"`
-	Truncation      string                    `json:"truncation,omitempty"`
-	MaxTokens       int                       `json:"maxTokens,omitempty"`
-	MimeTypes       []string                  `json:"mimeTypes,omitempty"`
+	Name              string                    `json:"name,omitempty"`
+	ShortName         string                    `json:"shortName,omitempty"`
+	Description       string                    `json:"description,omitempty"`
+	Icon              string                    `json:"icon,omitempty"`
+	IconDark          string                    `json:"iconDark,omitempty"`
+	StarterMessages   StringList                `json:"starterMessages,omitempty"`
+	Instructions      DynamicInstructions       `json:"instructions,omitempty"`
+	Model             string                    `json:"model,omitempty"`
+	API               string                    `json:"api,omitempty"`
+	Before            StringList                `json:"before,omitempty"`
+	After             StringList                `json:"after,omitempty"`
+	MCPServers        StringList                `json:"mcpServers,omitempty"`
+	Tools             StringList                `json:"tools,omitempty"`
+	Agents            StringList                `json:"agents,omitempty"`
+	Flows             StringList                `json:"flows,omitempty"`
+	Prompts           StringList                `json:"prompts,omitzero"`
+	Resources         StringList                `json:"resources,omitzero"`
+	Reasoning         *AgentReasoning           `json:"reasoning,omitempty"`
+	Audio             *AgentAudio               `json:"audio,omitempty"`
+	ThreadName        string                    `json:"threadName,omitempty"`
+	Chat              *bool                     `json:"chat,omitempty"`
+	ToolExtensions    map[string]map[string]any `json:"toolExtensions,omitempty"`
+	BuiltinTools      map[string]map[string]any `json:"builtinTools,omitempty"`
+	ToolChoice        string                    `json:"toolChoice,omitempty"`
+	ParallelToolCalls *bool                     `json:"parallelToolCalls,omitempty"`
+	Temperature       *json.Number              `json:"temperature,omitempty"`
+	TopP              *json.Number              `json:"topP,omitempty"`
+	Output            *OutputSchema             `json:"output,omitempty"`
+	Truncation        string                    `json:"truncation,omitempty"`
+	MaxTokens         int                       `json:"maxTokens,omitempty"`
+	Logprobs          bool                      `json:"logprobs,omitempty"`
+	TopLogprobs       int                       `json:"topLogprobs,omitempty"`
+	MimeTypes         []string                  `json:"mimeTypes,omitempty"`
+	Ephemeral         bool                      `json:"ephemeral,omitempty"`
+	CacheTTL          string                    `json:"cacheTTL,omitempty"`
+	Constraints       *AgentConstraints         `json:"constraints,omitempty"`
+	ContextWindow     *AgentContextWindow       `json:"contextWindow,omitempty"`
+	Compaction        *AgentCompaction          `json:"compaction,omitempty"`
+	Summary           *AgentSummary             `json:"summary,omitempty"`
+	Suggestions       *AgentSuggestions         `json:"suggestions,omitempty"`
+	Retention         *AgentRetention           `json:"retention,omitempty"`
+	// Confirm lists the tools, as server or server/tool, that only run after the user approves the
+	// call.
+	Confirm StringList `json:"confirm,omitempty"`
+	// Roots are the workspace directories of the agent, as name:directory or directory. They are
+	// passed as roots to the MCP servers of the agent, so their tools stay in these directories.
+	Roots StringList `json:"roots,omitempty"`
+	// ContentFilter is what the agent does when the content filter of the provider flags its
+	// prompt or response.
+	ContentFilter *AgentContentFilter `json:"contentFilter,omitempty"`
 
 	// Selection criteria fields
 
@@ -482,11 +724,153 @@ type Agent struct {
 	Intelligence float64  `json:"intelligence,omitempty"`
 }
 
+const (
+	// APIResponses and APICompletions select the OpenAI API used for the models of an agent.
+	APIResponses   = "responses"
+	APICompletions = "completions"
+)
+
+// BuiltinTools are the tools that are hosted by OpenAI and can be enabled for agents that use the
+// Responses API.
+var BuiltinTools = []string{
+	"code_interpreter",
+	"file_search",
+	"web_search",
+	"web_search_preview",
+}
+
 type AgentReasoning struct {
 	Effort  string `json:"effort,omitempty"`
 	Summary string `json:"summary,omitempty"`
 }
 
+// AgentConstraints limit the length and formatting of the responses of an agent, for channels such
+// as SMS or chat apps that can not render everything a model produces.
+type AgentConstraints struct {
+	MaxTokens        int  `json:"maxTokens,omitempty"`
+	MaxLength        int  `json:"maxLength,omitempty"`
+	NoMarkdown       bool `json:"noMarkdown,omitempty"`
+	NoMarkdownTables bool `json:"noMarkdownTables,omitempty"`
+	BulletPoints     bool `json:"bulletPoints,omitempty"`
+}
+
+// AgentContextWindow controls how the history of a session is truncated to fit the context window
+// of the model of an agent.
+type AgentContextWindow struct {
+	// MaxTokens is the size of the context window, default is the known size for the model.
+	MaxTokens int `json:"maxTokens,omitempty"`
+	// Strategy is how messages are dropped, one of TruncationStrategies. Default keep-system.
+	Strategy string `json:"strategy,omitempty"`
+	// Messages is the number of messages kept by the sliding-window strategy.
+	Messages int `json:"messages,omitempty"`
+}
+
+const (
+	// TruncateDropOldest drops the oldest turns of the history until the prompt fits.
+	TruncateDropOldest = "drop-oldest"
+	// TruncateKeepSystem drops the oldest turns like TruncateDropOldest, but keeps system messages
+	// and the first turn of the session, which usually states the task.
+	TruncateKeepSystem = "keep-system"
+	// TruncateSlidingWindow only keeps the most recent messages, then drops the oldest turns if
+	// they still do not fit.
+	TruncateSlidingWindow = "sliding-window"
+	// TruncateDisabled sends the full history.
+	TruncateDisabled = "disabled"
+)
+
+var TruncationStrategies = []string{
+	TruncateDropOldest,
+	TruncateKeepSystem,
+	TruncateSlidingWindow,
+	TruncateDisabled,
+}
+
+// AgentCompaction enables summarizing the older turns of a session once its history fills most of
+// the context window of the model.
+type AgentCompaction struct {
+	// Threshold is the fraction of the context window the history may fill before it is compacted.
+	// Default 0.8.
+	Threshold float64 `json:"threshold,omitempty"`
+	// KeepTurns is the number of most recent turns that are kept as they are. Default 2.
+	KeepTurns int `json:"keepTurns,omitempty"`
+	// Agent writes the summary, by default the model of the agent is asked to summarize.
+	Agent string `json:"agent,omitempty"`
+}
+
+// AgentSummary keeps a structured summary of the topics, decisions and open questions of the
+// sessions of an agent, updated after each turn.
+type AgentSummary struct {
+	// Agent writes the summary with built-in instructions, by default the model of the agent is
+	// asked to summarize. A small and cheap model is usually enough.
+	Agent string `json:"agent,omitempty"`
+}
+
+// AgentSuggestions offers a few prompts the user may want to send next after each turn of a chat,
+// which UIs show as quick replies.
+type AgentSuggestions struct {
+	// Agent writes the suggestions with built-in instructions, by default the model of the agent is
+	// asked. A small and cheap model is usually enough.
+	Agent string `json:"agent,omitempty"`
+	// Count is the number of suggestions, between 1 and 5. Default 3.
+	Count int `json:"count,omitempty"`
+	// MaxTokens caps the output of the model that writes the suggestions. Default 100.
+	MaxTokens int `json:"maxTokens,omitempty"`
+}
+
+// AgentRetention overrides the retention policy of the sessions of an agent. Unset fields use the
+// policy of the deployment, negative values disable a limit.
+type AgentRetention struct {
+	// MaxAge is how long a session is kept after its last update, such as 720h.
+	MaxAge string `json:"maxAge,omitempty"`
+	// MaxCount is the number of sessions kept per account, the most recently updated are kept.
+	MaxCount int `json:"maxCount,omitempty"`
+	// MaxStorageMB is the size of the sessions and their attachments kept per account.
+	MaxStorageMB int `json:"maxStorageMB,omitempty"`
+}
+
+const (
+	// ContentFilterAnnotate only keeps the results of the content filter on the response.
+	ContentFilterAnnotate = "annotate"
+	// ContentFilterWarn also logs a warning.
+	ContentFilterWarn = "warn"
+	// ContentFilterBlock fails the turn instead of returning the response.
+	ContentFilterBlock = "block"
+)
+
+// ContentFilterActions are the actions of AgentContentFilter.
+var ContentFilterActions = []string{ContentFilterAnnotate, ContentFilterWarn, ContentFilterBlock}
+
+// ContentFilterSeverities are the severities of the content filter results, from the lowest.
+var ContentFilterSeverities = []string{"safe", "low", "medium", "high"}
+
+// AgentContentFilter is the guardrail action of an agent for the results of the content filter of
+// the provider. The results are always kept on the messages, whatever the action is.
+type AgentContentFilter struct {
+	// Action is one of ContentFilterActions. Default annotate.
+	Action string `json:"action,omitempty"`
+	// Severity is the lowest severity that triggers the action. By default the action is triggered
+	// by the results the provider filtered or detected.
+	Severity string `json:"severity,omitempty"`
+}
+
+// Triggered returns the results that trigger the action of the filter.
+func (f AgentContentFilter) Triggered(results []ContentFilterResult) (triggered []ContentFilterResult) {
+	minSeverity := slices.Index(ContentFilterSeverities, f.Severity)
+	for _, result := range results {
+		if result.Filtered || result.Detected ||
+			minSeverity >= 0 && slices.Index(ContentFilterSeverities, result.Severity) >= minSeverity {
+			triggered = append(triggered, result)
+		}
+	}
+	return
+}
+
+// AgentAudio enables spoken responses in addition to text for models that support it.
+type AgentAudio struct {
+	Voice  string `json:"voice,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
 func (a Agent) ToDisplay() AgentDisplay {
 	return AgentDisplay{
 		Name:            a.Name,
@@ -495,6 +879,14 @@ func (a Agent) ToDisplay() AgentDisplay {
 		Icon:            a.Icon,
 		IconDark:        a.IconDark,
 		StarterMessages: a.StarterMessages,
+		Ephemeral:       a.Ephemeral,
+		Model:           a.Model,
+		Capabilities: &AgentCapabilities{
+			Vision: len(a.MimeTypes) == 0 || slices.ContainsFunc(a.MimeTypes, func(mimeType string) bool {
+				return strings.HasPrefix(mimeType, "image/")
+			}),
+			Tools: len(a.MCPServers) > 0 || len(a.Tools) > 0 || len(a.Agents) > 0 || len(a.Flows) > 0 || len(a.BuiltinTools) > 0,
+		},
 	}
 }
 
@@ -575,8 +967,98 @@ func (a Agent) validate(agentName string, c Config) error {
 		}
 	}
 
-	if !unknownNames && a.ToolChoice != "" && a.ToolChoice != "none" && a.ToolChoice != "auto" {
-		if _, ok := resolvedToolNames[a.ToolChoice]; !ok {
+	for _, root := range a.Roots {
+		if _, err := mcp.ParseRoot(root); err != nil {
+			errs = append(errs, fmt.Errorf("agent %q has invalid root: %w", agentName, err))
+		}
+	}
+
+	switch a.API {
+	case "", APIResponses:
+	case APICompletions:
+		if len(a.BuiltinTools) > 0 {
+			errs = append(errs, fmt.Errorf("agent %q has built-in tools which are only available in the %q API", agentName, APIResponses))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("agent %q has invalid API %q, must be %q or %q", agentName, a.API, APIResponses, APICompletions))
+	}
+
+	if a.API == APIResponses && a.Audio != nil {
+		errs = append(errs, fmt.Errorf("agent %q has audio output which is only available in the %q API", agentName, APICompletions))
+	}
+
+	if a.CacheTTL != "" {
+		if ttl, err := time.ParseDuration(a.CacheTTL); err != nil || ttl < 0 {
+			errs = append(errs, fmt.Errorf("agent %q has invalid cache TTL %q, must be a duration such as 10m or 0 to disable caching", agentName, a.CacheTTL))
+		}
+	}
+
+	if cw := a.ContextWindow; cw != nil {
+		if cw.Strategy != "" && !slices.Contains(TruncationStrategies, cw.Strategy) {
+			errs = append(errs, fmt.Errorf("agent %q has unknown context window strategy %q, must be one of %s", agentName, cw.Strategy, strings.Join(TruncationStrategies, ", ")))
+		}
+		if cw.MaxTokens < 0 || cw.Messages < 0 {
+			errs = append(errs, fmt.Errorf("agent %q has a negative context window size", agentName))
+		}
+		if cw.Strategy == TruncateSlidingWindow && cw.Messages == 0 {
+			errs = append(errs, fmt.Errorf("agent %q uses the %s strategy but does not set the number of messages to keep", agentName, TruncateSlidingWindow))
+		}
+	}
+
+	if cp := a.Compaction; cp != nil {
+		if cp.Threshold < 0 || cp.Threshold > 1 {
+			errs = append(errs, fmt.Errorf("agent %q has invalid compaction threshold %v, must be between 0 and 1", agentName, cp.Threshold))
+		}
+		if cp.KeepTurns < 0 {
+			errs = append(errs, fmt.Errorf("agent %q has a negative number of turns to keep when compacting", agentName))
+		}
+		if _, ok := c.Agents[cp.Agent]; cp.Agent != "" && !ok {
+			errs = append(errs, fmt.Errorf("agent %q compacts with agent %q which does not exist", agentName, cp.Agent))
+		}
+	}
+
+	if sm := a.Summary; sm != nil {
+		if _, ok := c.Agents[sm.Agent]; sm.Agent != "" && !ok {
+			errs = append(errs, fmt.Errorf("agent %q summarizes with agent %q which does not exist", agentName, sm.Agent))
+		}
+	}
+
+	if sg := a.Suggestions; sg != nil {
+		if sg.Count < 0 || sg.Count > 5 {
+			errs = append(errs, fmt.Errorf("agent %q has invalid number of suggestions %d, must be between 1 and 5", agentName, sg.Count))
+		}
+		if sg.MaxTokens < 0 {
+			errs = append(errs, fmt.Errorf("agent %q has a negative max tokens for suggestions", agentName))
+		}
+		if _, ok := c.Agents[sg.Agent]; sg.Agent != "" && !ok {
+			errs = append(errs, fmt.Errorf("agent %q writes suggestions with agent %q which does not exist", agentName, sg.Agent))
+		}
+	}
+
+	if r := a.Retention; r != nil && r.MaxAge != "" {
+		if _, err := time.ParseDuration(r.MaxAge); err != nil {
+			errs = append(errs, fmt.Errorf("agent %q has invalid retention max age %q, must be a duration such as 720h", agentName, r.MaxAge))
+		}
+	}
+
+	if cf := a.ContentFilter; cf != nil {
+		if cf.Action != "" && !slices.Contains(ContentFilterActions, cf.Action) {
+			errs = append(errs, fmt.Errorf("agent %q has invalid content filter action %q, must be one of %s", agentName, cf.Action, strings.Join(ContentFilterActions, ", ")))
+		}
+		if cf.Severity != "" && !slices.Contains(ContentFilterSeverities, cf.Severity) {
+			errs = append(errs, fmt.Errorf("agent %q has invalid content filter severity %q, must be one of %s", agentName, cf.Severity, strings.Join(ContentFilterSeverities, ", ")))
+		}
+	}
+
+	for name := range a.BuiltinTools {
+		if !slices.Contains(BuiltinTools, name) {
+			errs = append(errs, fmt.Errorf("agent %q has unknown built-in tool %q, must be one of %s", agentName, name, strings.Join(BuiltinTools, ", ")))
+		}
+	}
+
+	if !unknownNames && a.ToolChoice != "" && a.ToolChoice != "none" && a.ToolChoice != "auto" && a.ToolChoice != "required" {
+		_, builtin := a.BuiltinTools[a.ToolChoice]
+		if _, ok := resolvedToolNames[a.ToolChoice]; !ok && !builtin {
 			errs = append(errs, fmt.Errorf("agent %q has tool choice %q that is not defined in tools", agentName, a.ToolChoice))
 		}
 	}
@@ -620,12 +1102,25 @@ func (a DynamicInstructions) MarshalJSON() ([]byte, error) {
 	return json.Marshal(Alias(a))
 }
 
+const (
+	OutputFormatJSONSchema = "json_schema"
+	OutputFormatJSONObject = "json_object"
+)
+
 type OutputSchema struct {
 	Name        string           `json:"name,omitempty"`
 	Description string           `json:"description,omitempty"`
 	Schema      json.RawMessage  `json:"schema,omitzero"`
 	Strict      bool             `json:"strict,omitempty"`
 	Fields      map[string]Field `json:"fields,omitempty"`
+	// Format is the response format requested from the LLM, OutputFormatJSONSchema by default.
+	// OutputFormatJSONObject only asks for a JSON object and needs no schema.
+	Format string `json:"format,omitempty"`
+}
+
+// IsJSONObject reports if only a JSON object is requested, without a schema.
+func (o OutputSchema) IsJSONObject() bool {
+	return o.Format == OutputFormatJSONObject
 }
 
 type Field struct {
@@ -2,16 +2,39 @@ package types
 
 import (
 	"context"
+	"slices"
 
 	"github.com/obot-platform/mcp-oauth-proxy/pkg/providers"
 )
 
 type Context struct {
-	User    User
+	User User
+	// Admin is set if the user can access the sessions of all users.
+	Admin bool
+	// Roles are the roles mapped from the groups of a user who logged in with OIDC.
+	Roles []string
+	// Agents are the agents the client may call, all agents if empty.
+	Agents  []string
 	Config  ConfigFactory
 	Profile []string
 }
 
+// AllowsAgent reports if the client may call the agent name.
+func (c Context) AllowsAgent(name string) bool {
+	return len(c.Agents) == 0 || slices.Contains(c.Agents, name)
+}
+
+// AgentAllowed reports if the client of ctx may use the server name. Only agents are restricted,
+// by the agents allowed for the API key of the client, so MCP servers and the agents an allowed
+// agent calls itself are not.
+func AgentAllowed(ctx context.Context, name string) bool {
+	if NanobotContext(ctx).AllowsAgent(name) {
+		return true
+	}
+	_, isAgent := ConfigFromContext(ctx).Agents[name]
+	return !isAgent
+}
+
 type User providers.UserInfo
 
 type contextKey struct{}
@@ -24,3 +47,28 @@ func NanobotContext(ctx context.Context) Context {
 	c, _ := ctx.Value(contextKey{}).(Context)
 	return c
 }
+
+// CallAttribution tells tool servers on whose behalf a tool is called, so they can do their own
+// attribution and quota enforcement.
+type CallAttribution struct {
+	// Agent is the agent that called the tool.
+	Agent string `json:"agent,omitempty"`
+	// SessionID is the ID of the root session the call is made in.
+	SessionID string `json:"sessionId,omitempty"`
+	// UserID is the ID of the user that owns the session.
+	UserID string `json:"userId,omitempty"`
+	// TurnID is the ID of the message of the user that started the turn.
+	TurnID string `json:"turnId,omitempty"`
+}
+
+type callAttributionKey struct{}
+
+// WithCallAttribution returns a context whose tool calls are attributed to the agent and turn of a.
+func WithCallAttribution(ctx context.Context, a CallAttribution) context.Context {
+	return context.WithValue(ctx, callAttributionKey{}, a)
+}
+
+func CallAttributionFromContext(ctx context.Context) CallAttribution {
+	a, _ := ctx.Value(callAttributionKey{}).(CallAttribution)
+	return a
+}
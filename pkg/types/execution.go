@@ -1,6 +1,10 @@
 package types
 
-import "github.com/nanobot-ai/nanobot/pkg/mcp"
+import (
+	"context"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+)
 
 const PreviousExecutionKey = "thread"
 
@@ -11,6 +15,36 @@ type Execution struct {
 	ToolToMCPServer  ToolMappings          `json:"toolToMCPServer,omitempty"`
 	Response         *CompletionResponse   `json:"response,omitempty"`
 	ToolOutputs      map[string]ToolOutput `json:"toolOutputs,omitempty"`
+	History          *ExecutionHistory     `json:"history,omitempty"`
+}
+
+// ExecutionHistory refers to the older messages of an execution that are not kept in the session
+// but stored separately, and are only loaded when they are needed.
+type ExecutionHistory struct {
+	// ID identifies the stored messages, it is the same for all executions of a thread.
+	ID string `json:"id,omitempty"`
+	// Messages is the number of messages that are stored.
+	Messages int `json:"messages,omitempty"`
+	// Next is the ID of the first message that is kept in the session.
+	Next string `json:"next,omitempty"`
+}
+
+// HistoryLoader loads the stored older messages of an execution.
+type HistoryLoader interface {
+	LoadHistory(ctx context.Context, sessionID string, history *ExecutionHistory) ([]Message, error)
+}
+
+// Messages returns the full chat history of the execution with tool call results merged into
+// their tool calls.
+func (e *Execution) Messages() []Message {
+	var allMessages []Message
+	if e.PopulatedRequest != nil {
+		allMessages = e.PopulatedRequest.Input
+	}
+	if e.Response != nil {
+		allMessages = append(allMessages, e.Response.Output)
+	}
+	return ConsolidateTools(allMessages)
 }
 
 func (e *Execution) Serialize() (any, error) {
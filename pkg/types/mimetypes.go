@@ -11,6 +11,8 @@ const (
 	ProgressURI = "chat://progress"
 
 	AsyncMetaKey = "ai.nanobot.async"
+	// AttributionMetaKey is the key of the CallAttribution in the _meta of the tool calls.
+	AttributionMetaKey = "ai.nanobot.attribution"
 )
 
 var (
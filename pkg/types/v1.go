@@ -0,0 +1,370 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	v1 "github.com/nanobot-ai/nanobot/pkg/types/v1"
+)
+
+// The conversions between the internal types and the stable types of the v1 package. Fields that
+// v1 does not have are dropped when converting to v1 and left unset when converting from it.
+
+func (r CompletionRequest) ToV1() v1.CompletionRequest {
+	result := v1.CompletionRequest{
+		Model:             r.Model,
+		Agent:             r.Agent,
+		ThreadName:        r.ThreadName,
+		NewThread:         r.NewThread,
+		SystemPrompt:      r.SystemPrompt,
+		MaxTokens:         r.MaxTokens,
+		ToolChoice:        r.ToolChoice,
+		ParallelToolCalls: r.ParallelToolCalls,
+		Temperature:       r.Temperature,
+		TopP:              r.TopP,
+		Metadata:          r.Metadata,
+	}
+	for _, msg := range r.Input {
+		result.Input = append(result.Input, msg.ToV1())
+	}
+	for _, tool := range r.Tools {
+		result.Tools = append(result.Tools, v1.Tool{
+			Name:        tool.Name,
+			Parameters:  tool.Parameters,
+			Description: tool.Description,
+		})
+	}
+	return result
+}
+
+func CompletionRequestFromV1(r v1.CompletionRequest) CompletionRequest {
+	result := CompletionRequest{
+		Model:             r.Model,
+		Agent:             r.Agent,
+		ThreadName:        r.ThreadName,
+		NewThread:         r.NewThread,
+		SystemPrompt:      r.SystemPrompt,
+		MaxTokens:         r.MaxTokens,
+		ToolChoice:        r.ToolChoice,
+		ParallelToolCalls: r.ParallelToolCalls,
+		Temperature:       r.Temperature,
+		TopP:              r.TopP,
+		Metadata:          r.Metadata,
+	}
+	for _, msg := range r.Input {
+		result.Input = append(result.Input, MessageFromV1(msg))
+	}
+	for _, tool := range r.Tools {
+		result.Tools = append(result.Tools, ToolUseDefinition{
+			Name:        tool.Name,
+			Parameters:  tool.Parameters,
+			Description: tool.Description,
+		})
+	}
+	return result
+}
+
+func (c CompletionResponse) ToV1() v1.CompletionResponse {
+	result := v1.CompletionResponse{
+		Output:     c.Output.ToV1(),
+		Agent:      c.Agent,
+		Model:      c.Model,
+		HasMore:    c.HasMore,
+		Error:      c.Error,
+		StopReason: c.StopReason,
+	}
+	for _, msg := range c.InternalMessages {
+		result.InternalMessages = append(result.InternalMessages, msg.ToV1())
+	}
+	if c.Usage != nil {
+		usage := v1.Usage(*c.Usage)
+		result.Usage = &usage
+	}
+	return result
+}
+
+func CompletionResponseFromV1(c v1.CompletionResponse) CompletionResponse {
+	result := CompletionResponse{
+		Output:     MessageFromV1(c.Output),
+		Agent:      c.Agent,
+		Model:      c.Model,
+		HasMore:    c.HasMore,
+		Error:      c.Error,
+		StopReason: c.StopReason,
+	}
+	for _, msg := range c.InternalMessages {
+		result.InternalMessages = append(result.InternalMessages, MessageFromV1(msg))
+	}
+	if c.Usage != nil {
+		usage := Usage(*c.Usage)
+		result.Usage = &usage
+	}
+	return result
+}
+
+func (m Message) ToV1() v1.Message {
+	result := v1.Message{
+		ID:      m.ID,
+		Created: m.Created,
+		Role:    m.Role,
+		HasMore: m.HasMore,
+		Agent:   m.Agent,
+	}
+	for _, item := range m.Items {
+		result.Items = append(result.Items, item.ToV1())
+	}
+	for _, filter := range m.ContentFilter {
+		result.ContentFilter = append(result.ContentFilter, v1.ContentFilterResult(filter))
+	}
+	return result
+}
+
+func MessageFromV1(m v1.Message) Message {
+	result := Message{
+		ID:      m.ID,
+		Created: m.Created,
+		Role:    m.Role,
+		HasMore: m.HasMore,
+		Agent:   m.Agent,
+	}
+	for _, item := range m.Items {
+		result.Items = append(result.Items, CompletionItemFromV1(item))
+	}
+	for _, filter := range m.ContentFilter {
+		result.ContentFilter = append(result.ContentFilter, ContentFilterResult(filter))
+	}
+	return result
+}
+
+func (c CompletionItem) ToV1() v1.Item {
+	result := v1.Item{
+		ID:      c.ID,
+		HasMore: c.HasMore,
+		Partial: c.Partial,
+	}
+	switch {
+	case c.Content != nil:
+		content := contentToV1(*c.Content)
+		result.Type = content.Type
+		result.Name = content.Name
+		result.Description = content.Description
+		result.URI = content.URI
+		result.Text = content.Text
+		result.Data = content.Data
+		result.MIMEType = content.MIMEType
+		result.Resource = content.Resource
+	case c.ToolCall != nil || c.ToolCallResult != nil:
+		result.Type = v1.ItemTool
+		if c.ToolCall != nil {
+			result.Name = c.ToolCall.Name
+			result.CallID = c.ToolCall.CallID
+			result.Arguments = c.ToolCall.Arguments
+			result.Target = c.ToolCall.Target
+			result.TargetType = c.ToolCall.TargetType
+		}
+		if c.ToolCallResult != nil {
+			output := c.ToolCallResult.Output.ToV1()
+			result.CallID = c.ToolCallResult.CallID
+			result.Output = &output
+			result.OutputRole = c.ToolCallResult.OutputRole
+		}
+	case c.Reasoning != nil:
+		result.Type = v1.ItemReasoning
+		result.EncryptedContent = c.Reasoning.EncryptedContent
+		for _, summary := range c.Reasoning.Summary {
+			result.Summary = append(result.Summary, v1.SummaryText(summary))
+		}
+	case c.Suggestions != nil:
+		result.Type = v1.ItemSuggestions
+		result.Prompts = c.Suggestions.Prompts
+	}
+	return result
+}
+
+func CompletionItemFromV1(i v1.Item) CompletionItem {
+	result := CompletionItem{
+		ID:      i.ID,
+		HasMore: i.HasMore,
+		Partial: i.Partial,
+	}
+	switch i.Type {
+	case v1.ItemTool:
+		if i.Name != "" {
+			result.ToolCall = &ToolCall{
+				Name:       i.Name,
+				CallID:     i.CallID,
+				Arguments:  i.Arguments,
+				Target:     i.Target,
+				TargetType: i.TargetType,
+			}
+		}
+		if i.Output != nil {
+			result.ToolCallResult = &ToolCallResult{
+				CallID:     i.CallID,
+				Output:     CallResultFromV1(*i.Output),
+				OutputRole: i.OutputRole,
+			}
+		}
+	case v1.ItemReasoning:
+		result.Reasoning = &Reasoning{
+			EncryptedContent: i.EncryptedContent,
+		}
+		for _, summary := range i.Summary {
+			result.Reasoning.Summary = append(result.Reasoning.Summary, SummaryText(summary))
+		}
+	case v1.ItemSuggestions:
+		result.Suggestions = &Suggestions{
+			Prompts: i.Prompts,
+		}
+	case "":
+	default:
+		content := contentFromV1(v1.Content{
+			Type:        i.Type,
+			Name:        i.Name,
+			Description: i.Description,
+			URI:         i.URI,
+			Text:        i.Text,
+			Data:        i.Data,
+			MIMEType:    i.MIMEType,
+			Resource:    i.Resource,
+		})
+		result.Content = &content
+	}
+	return result
+}
+
+func (c CallResult) ToV1() v1.CallResult {
+	result := v1.CallResult{
+		IsError:           c.IsError,
+		StructuredContent: c.StructuredContent,
+		Agent:             c.Agent,
+		Model:             c.Model,
+		StopReason:        c.StopReason,
+	}
+	for _, content := range c.Content {
+		result.Content = append(result.Content, contentToV1(content))
+	}
+	return result
+}
+
+func CallResultFromV1(c v1.CallResult) CallResult {
+	result := CallResult{
+		IsError:           c.IsError,
+		StructuredContent: c.StructuredContent,
+		Agent:             c.Agent,
+		Model:             c.Model,
+		StopReason:        c.StopReason,
+	}
+	for _, content := range c.Content {
+		result.Content = append(result.Content, contentFromV1(content))
+	}
+	return result
+}
+
+func (p CompletionProgress) ToV1() v1.Progress {
+	result := v1.Progress{
+		Model:     p.Model,
+		Agent:     p.Agent,
+		MessageID: p.MessageID,
+		Role:      p.Role,
+		Item:      p.Item.ToV1(),
+	}
+	if p.Event != nil {
+		result.Event = &v1.ProgressEvent{
+			Type:    p.Event.Type,
+			Message: p.Event.Message,
+			Retry:   p.Event.Retry,
+		}
+		if e := p.Event.Elicitation; e != nil {
+			schema, _ := json.Marshal(e.RequestedSchema)
+			result.Event.Elicitation = &v1.ProgressElicitation{
+				ID:              e.ID,
+				Server:          e.Server,
+				RequestedSchema: schema,
+			}
+		}
+	}
+	return result
+}
+
+func CompletionProgressFromV1(p v1.Progress) CompletionProgress {
+	result := CompletionProgress{
+		Model:     p.Model,
+		Agent:     p.Agent,
+		MessageID: p.MessageID,
+		Role:      p.Role,
+		Item:      CompletionItemFromV1(p.Item),
+	}
+	if p.Event != nil {
+		result.Event = &ProgressEvent{
+			Type:    p.Event.Type,
+			Message: p.Event.Message,
+			Retry:   p.Event.Retry,
+		}
+		if e := p.Event.Elicitation; e != nil {
+			result.Event.Elicitation = &ProgressElicitation{
+				ID:     e.ID,
+				Server: e.Server,
+			}
+			_ = json.Unmarshal(e.RequestedSchema, &result.Event.Elicitation.RequestedSchema)
+		}
+	}
+	return result
+}
+
+func contentToV1(c mcp.Content) v1.Content {
+	if c.Type == "" {
+		// The type is derived from the content when it is sent, like mcp.Content does.
+		switch {
+		case c.Resource != nil:
+			c.Type = v1.ItemResource
+		case c.Text != "":
+			c.Type = v1.ItemText
+		case c.Data != "":
+			c.Type = v1.ItemImage
+		case c.URI != "":
+			c.Type = v1.ItemResourceLink
+		}
+	}
+	result := v1.Content{
+		Type:        c.Type,
+		Name:        c.Name,
+		Description: c.Description,
+		URI:         c.URI,
+		Text:        c.Text,
+		Data:        c.Data,
+		MIMEType:    c.MIMEType,
+	}
+	if c.Resource != nil {
+		result.Resource = &v1.Resource{
+			URI:      c.Resource.URI,
+			Name:     c.Resource.Name,
+			MIMEType: c.Resource.MIMEType,
+			Text:     c.Resource.Text,
+			Blob:     c.Resource.Blob,
+		}
+	}
+	return result
+}
+
+func contentFromV1(c v1.Content) mcp.Content {
+	result := mcp.Content{
+		Type:        c.Type,
+		Name:        c.Name,
+		Description: c.Description,
+		URI:         c.URI,
+		Text:        c.Text,
+		Data:        c.Data,
+		MIMEType:    c.MIMEType,
+	}
+	if c.Resource != nil {
+		result.Resource = &mcp.EmbeddedResource{
+			URI:      c.Resource.URI,
+			Name:     c.Resource.Name,
+			MIMEType: c.Resource.MIMEType,
+			Text:     c.Resource.Text,
+			Blob:     c.Resource.Blob,
+		}
+	}
+	return result
+}
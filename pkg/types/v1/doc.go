@@ -0,0 +1,14 @@
+// Package v1 is the stable, versioned form of the completion requests and responses, messages,
+// progress events and tool calls of nanobot, for integrations that are built outside of this
+// repository. The JSON of its types is the JSON nanobot sends and accepts, so they decode the
+// results of the agent tools, the progress notifications and the stored messages directly.
+//
+// The package only depends on the standard library. Within v1 fields and types are only added,
+// never removed, renamed or changed in meaning, and new fields are optional. Changes that break
+// these rules go into a new package, v2, while v1 keeps being converted to and from the internal
+// types. The internal types in the types package are converted with their ToV1 methods and the
+// FromV1 functions.
+package v1
+
+// Version is the version of the package.
+const Version = "v1"
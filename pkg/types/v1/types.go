@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProgressMetaKey is the key of the Progress in the _meta of the progress notifications of a
+// completion.
+const ProgressMetaKey = "ai.nanobot.progress/completion"
+
+const (
+	// ItemText, ItemImage, ItemAudio, ItemResource and ItemResourceLink are the types of content
+	// items.
+	ItemText         = "text"
+	ItemImage        = "image"
+	ItemAudio        = "audio"
+	ItemResource     = "resource"
+	ItemResourceLink = "resource_link"
+	// ItemTool is the type of tool calls and their results.
+	ItemTool = "tool"
+	// ItemReasoning is the type of the reasoning of a model.
+	ItemReasoning = "reasoning"
+	// ItemSuggestions is the type of the follow-up prompts suggested after a turn.
+	ItemSuggestions = "suggestions"
+)
+
+// CompletionRequest asks an agent or model to continue a conversation.
+type CompletionRequest struct {
+	Model             string         `json:"model,omitempty"`
+	Agent             string         `json:"agent,omitempty"`
+	ThreadName        string         `json:"threadName,omitempty"`
+	NewThread         bool           `json:"newThread,omitempty"`
+	Input             []Message      `json:"input,omitzero"`
+	SystemPrompt      string         `json:"systemPrompt,omitzero"`
+	MaxTokens         int            `json:"maxTokens,omitempty"`
+	ToolChoice        string         `json:"toolChoice,omitempty"`
+	ParallelToolCalls *bool          `json:"parallelToolCalls,omitempty"`
+	Temperature       *json.Number   `json:"temperature,omitempty"`
+	TopP              *json.Number   `json:"topP,omitempty"`
+	Metadata          map[string]any `json:"metadata,omitempty"`
+	Tools             []Tool         `json:"tools,omitzero"`
+}
+
+// Tool is a tool the model can call, with the JSON schema of its arguments.
+type Tool struct {
+	Name        string          `json:"name,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Description string          `json:"description,omitempty"`
+}
+
+// CompletionResponse is the result of a CompletionRequest.
+type CompletionResponse struct {
+	Output Message `json:"output,omitempty"`
+	// InternalMessages are the messages of the turn before Output, like tool calls and their
+	// results.
+	InternalMessages []Message `json:"internalMessages,omitempty"`
+	Agent            string    `json:"agent,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	HasMore          bool      `json:"hasMore,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	Usage            *Usage    `json:"usage,omitempty"`
+	StopReason       string    `json:"stopReason,omitempty"`
+}
+
+// Usage is the token accounting of a completion.
+type Usage struct {
+	InputTokens       int `json:"inputTokens,omitempty"`
+	OutputTokens      int `json:"outputTokens,omitempty"`
+	CachedInputTokens int `json:"cachedInputTokens,omitempty"`
+	ReasoningTokens   int `json:"reasoningTokens,omitempty"`
+}
+
+// Message is a message of a conversation.
+type Message struct {
+	ID      string     `json:"id,omitempty"`
+	Created *time.Time `json:"created,omitempty"`
+	Role    string     `json:"role,omitempty"`
+	Items   []Item     `json:"items,omitempty"`
+	HasMore bool       `json:"hasMore,omitempty"`
+	// Agent is the agent that generated the message, for the outputs of models.
+	Agent string `json:"agent,omitempty"`
+	// ContentFilter holds the results of the content filter of the provider, if it reports them.
+	ContentFilter []ContentFilterResult `json:"contentFilter,omitempty"`
+}
+
+// ContentFilterResult is the verdict of the content filter of a provider for one category of the
+// "prompt" or the "output" of a completion.
+type ContentFilterResult struct {
+	Target   string `json:"target"`
+	Category string `json:"category"`
+	Filtered bool   `json:"filtered,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Detected bool   `json:"detected,omitempty"`
+}
+
+// Item is a part of a message. Its Type selects the fields that are set: the content fields for
+// the content types, the tool fields for ItemTool, the reasoning fields for ItemReasoning and
+// Prompts for ItemSuggestions. A tool item is the call of a tool, the result of a call once Output
+// is set, or both.
+type Item struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type,omitempty"`
+	HasMore bool   `json:"hasMore,omitempty"`
+	Partial bool   `json:"partial,omitempty"`
+
+	// Name is the name of a resource link or of the called tool.
+	Name string `json:"name,omitempty"`
+
+	// Content fields.
+	Description string    `json:"description,omitempty"`
+	URI         string    `json:"uri,omitempty"`
+	Text        string    `json:"text,omitempty"`
+	Data        string    `json:"data,omitempty"`
+	MIMEType    string    `json:"mimeType,omitempty"`
+	Resource    *Resource `json:"resource,omitempty"`
+
+	// Tool fields.
+	CallID     string      `json:"callID,omitempty"`
+	Arguments  string      `json:"arguments,omitempty"`
+	Target     string      `json:"target,omitempty"`
+	TargetType string      `json:"targetType,omitempty"`
+	Output     *CallResult `json:"output,omitempty"`
+	OutputRole string      `json:"outputRole,omitempty"`
+
+	// Reasoning fields.
+	EncryptedContent string        `json:"encryptedContent,omitempty"`
+	Summary          []SummaryText `json:"summary,omitempty"`
+
+	// Suggestion fields.
+	Prompts []string `json:"prompts,omitempty"`
+}
+
+// Content is the content of a tool result, one of the content item types.
+type Content struct {
+	Type        string    `json:"type,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	URI         string    `json:"uri,omitempty"`
+	Text        string    `json:"text,omitempty"`
+	Data        string    `json:"data,omitempty"`
+	MIMEType    string    `json:"mimeType,omitempty"`
+	Resource    *Resource `json:"resource,omitempty"`
+}
+
+// Resource is a resource embedded in content, with either Text or the base64 encoded Blob set.
+type Resource struct {
+	URI      string `json:"uri,omitempty"`
+	Name     string `json:"name,omitempty"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// SummaryText is a part of the summary of the reasoning of a model.
+type SummaryText struct {
+	Text string `json:"text,omitempty"`
+}
+
+// CallResult is the result of a tool call.
+type CallResult struct {
+	Content           []Content `json:"content,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	Agent             string    `json:"agent,omitempty"`
+	Model             string    `json:"model,omitempty"`
+	StopReason        string    `json:"stopReason,omitempty"`
+}
+
+// Progress is a progress event of a completion, the partial items of the message that is being
+// generated and events of the completion itself.
+type Progress struct {
+	Model     string         `json:"model,omitempty"`
+	Agent     string         `json:"agent,omitempty"`
+	MessageID string         `json:"messageID,omitempty"`
+	Role      string         `json:"role,omitempty"`
+	Item      Item           `json:"item,omitempty"`
+	Event     *ProgressEvent `json:"event,omitempty"`
+}
+
+// ProgressEvent reports something that happened to the completion, like its generation being
+// canceled.
+type ProgressEvent struct {
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+	Retry   bool   `json:"retry,omitempty"`
+	// Elicitation is the form of an event of type "elicitation", a question of a server that is
+	// answered with the answer_elicitation tool.
+	Elicitation *ProgressElicitation `json:"elicitation,omitempty"`
+}
+
+// ProgressElicitation is a question a server asks the user during a tool call. RequestedSchema is
+// the JSON schema of the answer, an object with primitive properties.
+type ProgressElicitation struct {
+	ID              string          `json:"id"`
+	Server          string          `json:"server,omitempty"`
+	RequestedSchema json.RawMessage `json:"requestedSchema"`
+}
@@ -0,0 +1,86 @@
+package v1_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+	v1 "github.com/nanobot-ai/nanobot/pkg/types/v1"
+)
+
+func message() types.Message {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return types.Message{
+		ID:      "msg-1",
+		Created: &created,
+		Role:    "assistant",
+		Items: []types.CompletionItem{
+			{ID: "item-1", Content: &mcp.Content{Type: "text", Text: "Let me check"}},
+			{ID: "item-2", Reasoning: &types.Reasoning{Summary: []types.SummaryText{{Text: "The user wants the weather"}}}},
+			{ID: "item-3", ToolCall: &types.ToolCall{CallID: "call-1", Name: "weather", Arguments: `{"city":"Paris"}`}},
+			{ID: "item-4", ToolCallResult: &types.ToolCallResult{CallID: "call-1", Output: types.CallResult{
+				Content: []mcp.Content{{Type: "text", Text: "sunny"}},
+			}}},
+			{ID: "item-5", Content: &mcp.Content{Type: "resource", Resource: &mcp.EmbeddedResource{URI: "file:///report.md", MIMEType: "text/markdown", Text: "# Report"}}},
+		},
+	}
+}
+
+func TestMessageJSON(t *testing.T) {
+	msg := message()
+
+	// The internal JSON decodes into the v1 types.
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded v1.Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, msg.ToV1()) {
+		t.Fatalf("the internal JSON\n%s\ndecodes to\n%+v\nexpected\n%+v", data, decoded, msg.ToV1())
+	}
+
+	// And the v1 JSON into the internal types.
+	data, err = json.Marshal(msg.ToV1())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var internal types.Message
+	if err := json.Unmarshal(data, &internal); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(internal, msg) {
+		t.Fatalf("the v1 JSON\n%s\ndecodes to\n%+v\nexpected\n%+v", data, internal, msg)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	msg := message()
+	if converted := types.MessageFromV1(msg.ToV1()); !reflect.DeepEqual(converted, msg) {
+		t.Fatalf("expected the message to survive the conversion, got %+v", converted)
+	}
+
+	response := types.CompletionResponse{
+		Output:           msg,
+		InternalMessages: []types.Message{msg},
+		Agent:            "weather",
+		Usage:            &types.Usage{InputTokens: 10, OutputTokens: 5},
+	}
+	if converted := types.CompletionResponseFromV1(response.ToV1()); !reflect.DeepEqual(converted, response) {
+		t.Fatalf("expected the response to survive the conversion, got %+v", converted)
+	}
+
+	progress := types.CompletionProgress{
+		MessageID: "msg-1",
+		Item:      msg.Items[2],
+		Event:     &types.ProgressEvent{Type: types.ProgressEventCancelled},
+	}
+	if converted := types.CompletionProgressFromV1(progress.ToV1()); !reflect.DeepEqual(converted, progress) {
+		t.Fatalf("expected the progress to survive the conversion, got %+v", converted)
+	}
+}
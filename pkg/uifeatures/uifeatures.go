@@ -0,0 +1,97 @@
+// Package uifeatures negotiates the optional features of the agent UI server with its clients.
+//
+// A client lists the features it supports in the experimental capability named Capability of its
+// initialize request, as {"features": ["approvals", ...]}, and the server answers with the features
+// both sides support in the same capability. Clients that do not list any features, like the
+// reference UI, are assumed to support all of them.
+package uifeatures
+
+import (
+	"context"
+	"slices"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+)
+
+// Capability is the name of the experimental capability that lists the features.
+const Capability = "ai.nanobot/ui"
+
+const (
+	// Approvals is asking the user to approve tool calls.
+	Approvals = "approvals"
+	// Attachments is sending files along with a prompt.
+	Attachments = "attachments"
+	// Reasoning is displaying the reasoning of the model while it streams.
+	Reasoning = "reasoning"
+	// Cancellation is stopping the response being generated.
+	Cancellation = "cancellation"
+	// ResumableStreams is following the progress resource to resume a response after reconnecting.
+	ResumableStreams = "resumableStreams"
+	// Suggestions is showing follow-up prompts as quick replies after a turn.
+	Suggestions = "suggestions"
+	// Forms is answering the questions of servers with the forms of elicitation progress events.
+	Forms = "forms"
+)
+
+// All are the features supported by the server.
+var All = []string{Approvals, Attachments, Reasoning, Cancellation, ResumableStreams, Suggestions, Forms}
+
+// Negotiate returns the features supported by both the server and a client with the capabilities.
+func Negotiate(capabilities mcp.ClientCapabilities) []string {
+	requested, ok := requested(capabilities)
+	if !ok {
+		return slices.Clone(All)
+	}
+
+	var result []string
+	for _, feature := range All {
+		if slices.Contains(requested, feature) {
+			result = append(result, feature)
+		}
+	}
+	return result
+}
+
+// Advertise returns the experimental server capabilities that tell the client the features.
+func Advertise(features []string) map[string]any {
+	if features == nil {
+		features = []string{}
+	}
+	return map[string]any{
+		Capability: map[string]any{
+			"features": features,
+		},
+	}
+}
+
+// Supported reports if the client of the root session of ctx supports the feature. It is true when
+// ctx has no session.
+func Supported(ctx context.Context, feature string) bool {
+	session := mcp.SessionFromContext(ctx)
+	for session != nil && session.Parent != nil {
+		session = session.Parent
+	}
+	if session == nil {
+		return true
+	}
+	return slices.Contains(Negotiate(session.InitializeRequest.Capabilities), feature)
+}
+
+func requested(capabilities mcp.ClientCapabilities) ([]string, bool) {
+	capability, ok := capabilities.Experimental[Capability].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	features, ok := capability["features"].([]any)
+	if !ok {
+		return nil, false
+	}
+
+	var result []string
+	for _, feature := range features {
+		if name, ok := feature.(string); ok {
+			result = append(result, name)
+		}
+	}
+	return result, true
+}
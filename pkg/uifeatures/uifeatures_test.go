@@ -0,0 +1,49 @@
+package uifeatures
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities mcp.ClientCapabilities
+		want         []string
+	}{
+		{
+			name: "legacy client",
+			want: All,
+		},
+		{
+			name: "subset",
+			capabilities: mcp.ClientCapabilities{
+				Experimental: map[string]any{
+					Capability: map[string]any{
+						"features": []any{Cancellation, "unknown", Approvals},
+					},
+				},
+			},
+			want: []string{Approvals, Cancellation},
+		},
+		{
+			name: "none",
+			capabilities: mcp.ClientCapabilities{
+				Experimental: map[string]any{
+					Capability: map[string]any{
+						"features": []any{},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Negotiate(tt.capabilities); !slices.Equal(got, tt.want) {
+				t.Errorf("Negotiate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
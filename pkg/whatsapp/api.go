@@ -0,0 +1,208 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+const (
+	defaultGraphURL   = "https://graph.facebook.com"
+	defaultAPIVersion = "v21.0"
+	// maxMediaSize is the largest media file that is downloaded from a message.
+	maxMediaSize = 25 << 20
+)
+
+// notification is the payload of the webhook of the WhatsApp Business Account.
+type notification struct {
+	Object string `json:"object"`
+	Entry  []struct {
+		ID      string `json:"id"`
+		Changes []struct {
+			Field string `json:"field"`
+			Value value  `json:"value"`
+		} `json:"changes"`
+	} `json:"entry"`
+}
+
+type value struct {
+	Metadata struct {
+		DisplayPhoneNumber string `json:"display_phone_number"`
+		PhoneNumberID      string `json:"phone_number_id"`
+	} `json:"metadata"`
+	Contacts []Contact `json:"contacts,omitempty"`
+	Messages []Message `json:"messages,omitempty"`
+}
+
+type Contact struct {
+	WaID    string `json:"wa_id"`
+	Profile struct {
+		Name string `json:"name"`
+	} `json:"profile"`
+}
+
+type Message struct {
+	ID        string `json:"id"`
+	From      string `json:"from"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Type      string `json:"type"`
+	Text      *struct {
+		Body string `json:"body"`
+	} `json:"text,omitempty"`
+	Image    *Media `json:"image,omitempty"`
+	Document *Media `json:"document,omitempty"`
+	Audio    *Media `json:"audio,omitempty"`
+	Video    *Media `json:"video,omitempty"`
+	Button   *struct {
+		Text    string `json:"text"`
+		Payload string `json:"payload"`
+	} `json:"button,omitempty"`
+}
+
+type Media struct {
+	ID       string `json:"id"`
+	MimeType string `json:"mime_type,omitempty"`
+	Caption  string `json:"caption,omitempty"`
+	Filename string `json:"filename,omitempty"`
+}
+
+// client is a client of the WhatsApp Cloud API.
+type client struct {
+	baseURL     string
+	accessToken string
+	http        *http.Client
+}
+
+func (c *client) post(ctx context.Context, path string, in any, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, out)
+}
+
+func (c *client) do(req *http.Request, out any) error {
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("WhatsApp API returned %s: %s", resp.Status, body)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends a message from the business phone number to the user.
+func (c *client) send(ctx context.Context, phoneNumberID, to, replyTo string, msg map[string]any) error {
+	msg["messaging_product"] = "whatsapp"
+	msg["recipient_type"] = "individual"
+	msg["to"] = to
+	if replyTo != "" {
+		msg["context"] = map[string]string{"message_id": replyTo}
+	}
+	if err := c.post(ctx, phoneNumberID+"/messages", msg, nil); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// markRead marks the message as read and shows the typing indicator until the response is sent.
+func (c *client) markRead(ctx context.Context, phoneNumberID, messageID string) error {
+	return c.post(ctx, phoneNumberID+"/messages", map[string]any{
+		"messaging_product": "whatsapp",
+		"status":            "read",
+		"message_id":        messageID,
+		"typing_indicator": map[string]string{
+			"type": "text",
+		},
+	}, nil)
+}
+
+// upload uploads media to be sent in a message and returns its ID.
+func (c *client) upload(ctx context.Context, phoneNumberID, mimeType string, data []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("messaging_product", "whatsapp")
+	_ = w.WriteField("type", mimeType)
+	part, err := w.CreatePart(map[string][]string{
+		"Content-Disposition": {`form-data; name="file"; filename="file"`},
+		"Content-Type":        {mimeType},
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+phoneNumberID+"/media", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return "", fmt.Errorf("failed to upload media: %w", err)
+	}
+	return result.ID, nil
+}
+
+// download returns the content and type of media sent by a user.
+func (c *client) download(ctx context.Context, mediaID string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+mediaID, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	var media struct {
+		URL      string `json:"url"`
+		MimeType string `json:"mime_type"`
+	}
+	if err := c.do(req, &media); err != nil {
+		return nil, "", err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, media.URL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxMediaSize {
+		return nil, "", fmt.Errorf("file is larger than %d MB", maxMediaSize>>20)
+	}
+	return data, media.MimeType, nil
+}
@@ -0,0 +1,294 @@
+// Package whatsapp implements a WhatsApp bot on top of the WhatsApp Cloud API. Every user gets a
+// nanobot session per business phone number, which is driven by the messages they send.
+package whatsapp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nanobot-ai/nanobot/pkg/channel"
+	"github.com/nanobot-ai/nanobot/pkg/complete"
+	"github.com/nanobot-ai/nanobot/pkg/errreport"
+	"github.com/nanobot-ai/nanobot/pkg/log"
+	"github.com/nanobot-ai/nanobot/pkg/mcp"
+	"github.com/nanobot-ai/nanobot/pkg/render"
+	"github.com/nanobot-ai/nanobot/pkg/runtime"
+	"github.com/nanobot-ai/nanobot/pkg/session"
+	"github.com/nanobot-ai/nanobot/pkg/types"
+)
+
+// SessionType is the type of the sessions created for WhatsApp conversations.
+const SessionType = "whatsapp"
+
+type Options struct {
+	channel.Options
+
+	// AccessToken is a system user access token with the whatsapp_business_messaging permission.
+	AccessToken string
+	// AppSecret is the secret of the Meta app, used to verify the signature of notifications.
+	AppSecret string
+	// VerifyToken is the token entered when the webhook is configured in the Meta app.
+	VerifyToken string
+	// AllowedNumbers are the phone numbers, in international format without "+", that may talk to
+	// the bot. If empty everyone can.
+	AllowedNumbers []string
+	// APIVersion is the version of the Graph API. Default v21.0.
+	APIVersion string
+}
+
+func (o Options) Merge(other Options) (result Options) {
+	result.Options = o.Options.Merge(other.Options)
+	result.AccessToken = complete.Last(o.AccessToken, other.AccessToken)
+	result.AppSecret = complete.Last(o.AppSecret, other.AppSecret)
+	result.VerifyToken = complete.Last(o.VerifyToken, other.VerifyToken)
+	result.AllowedNumbers = append(o.AllowedNumbers, other.AllowedNumbers...)
+	result.APIVersion = complete.Last(o.APIVersion, other.APIVersion)
+	return
+}
+
+func (o Options) Complete() Options {
+	if o.APIVersion == "" {
+		o.APIVersion = defaultAPIVersion
+	}
+	return o
+}
+
+// Bot handles the verification requests and notifications of the webhook.
+type Bot struct {
+	ctx    context.Context
+	opt    Options
+	runner *channel.Runner
+	client *client
+}
+
+func NewBot(ctx context.Context, runt *runtime.Runtime, config types.ConfigFactory, sessions *session.Manager, server mcp.MessageHandler, opts ...Options) (*Bot, error) {
+	opt := complete.Complete(opts...)
+	if opt.AccessToken == "" || opt.AppSecret == "" || opt.VerifyToken == "" {
+		return nil, fmt.Errorf("the access token, app secret, and verify token are required")
+	}
+
+	runner, err := channel.NewRunner(runt, config, sessions, server, opt.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bot{
+		ctx:    ctx,
+		opt:    opt,
+		runner: runner,
+		client: &client{
+			baseURL:     defaultGraphURL + "/" + opt.APIVersion,
+			accessToken: opt.AccessToken,
+			http:        http.DefaultClient,
+		},
+	}, nil
+}
+
+func (b *Bot) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		b.verifyWebhook(rw, req)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, 1<<20))
+	if err != nil {
+		http.Error(rw, "Failed to read notification: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !b.validSignature(req.Header.Get("X-Hub-Signature-256"), body) {
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var n notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		http.Error(rw, "Failed to decode notification: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, entry := range n.Entry {
+		for _, change := range entry.Changes {
+			if change.Field != "messages" {
+				continue
+			}
+			for _, msg := range change.Value.Messages {
+				if !b.allowed(msg.From) {
+					log.Debugf(req.Context(), "ignoring WhatsApp message from a number that is not allowed")
+					continue
+				}
+				// Meta retries notifications that are not acknowledged quickly, so the turn runs
+				// in the background.
+				go b.handle(context.WithoutCancel(req.Context()), change.Value.Metadata.PhoneNumberID, contactName(change.Value.Contacts, msg.From), msg)
+			}
+		}
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// verifyWebhook answers the request Meta sends when the webhook is configured.
+func (b *Bot) verifyWebhook(rw http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	if q.Get("hub.mode") != "subscribe" ||
+		subtle.ConstantTimeCompare([]byte(q.Get("hub.verify_token")), []byte(b.opt.VerifyToken)) != 1 {
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/plain")
+	_, _ = rw.Write([]byte(q.Get("hub.challenge")))
+}
+
+// validSignature checks the signature of a notification, which is the HMAC of the body keyed with
+// the app secret.
+func (b *Bot) validSignature(signature string, body []byte) bool {
+	signature, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(b.opt.AppSecret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func (b *Bot) allowed(number string) bool {
+	return len(b.opt.AllowedNumbers) == 0 || slices.Contains(b.opt.AllowedNumbers, number)
+}
+
+func (b *Bot) handle(ctx context.Context, phoneNumberID, name string, msg Message) {
+	defer errreport.Recover(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	context.AfterFunc(b.ctx, cancel)
+
+	if err := b.client.markRead(ctx, phoneNumberID, msg.ID); err != nil {
+		log.Debugf(ctx, "failed to mark WhatsApp message as read: %v", err)
+	}
+
+	resp, err := b.turn(ctx, phoneNumberID, name, msg)
+	if err != nil {
+		log.Errorf(ctx, "failed to handle WhatsApp message: %v", err)
+		b.sendText(ctx, phoneNumberID, msg.From, msg.ID, "Sorry, something went wrong: "+err.Error())
+		return
+	}
+
+	for _, image := range resp.Images {
+		data, err := base64.StdEncoding.DecodeString(image.Data)
+		if err != nil {
+			continue
+		}
+		id, err := b.client.upload(ctx, phoneNumberID, image.MIMEType, data)
+		if err == nil {
+			err = b.client.send(ctx, phoneNumberID, msg.From, "", map[string]any{
+				"type":  "image",
+				"image": map[string]string{"id": id},
+			})
+		}
+		if err != nil {
+			log.Errorf(ctx, "failed to send image to WhatsApp: %v", err)
+		}
+	}
+
+	if strings.TrimSpace(resp.Text) == "" && len(resp.Images) == 0 {
+		b.sendText(ctx, phoneNumberID, msg.From, msg.ID, "The agent did not respond.")
+		return
+	}
+	for i, text := range render.WhatsApp(resp.Text) {
+		replyTo := msg.ID
+		if i > 0 {
+			replyTo = ""
+		}
+		b.sendText(ctx, phoneNumberID, msg.From, replyTo, text)
+	}
+}
+
+func (b *Bot) sendText(ctx context.Context, phoneNumberID, to, replyTo, text string) {
+	if err := b.client.send(ctx, phoneNumberID, to, replyTo, map[string]any{
+		"type": "text",
+		"text": map[string]any{"body": text},
+	}); err != nil {
+		log.Errorf(ctx, "failed to send response to WhatsApp: %v", err)
+	}
+}
+
+func (b *Bot) turn(ctx context.Context, phoneNumberID, name string, msg Message) (*channel.Response, error) {
+	files, err := b.files(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	user := types.User{
+		ID:   "whatsapp:" + msg.From,
+		Name: name,
+	}
+	return b.runner.Run(ctx, channel.Message{
+		Channel:     "WhatsApp",
+		SessionType: SessionType,
+		SessionID:   channel.SessionID(b.opt.AppSecret, phoneNumberID, msg.From),
+		User:        user,
+		Description: "WhatsApp chat with " + complete.First(name, msg.From),
+		Prompt:      msg.prompt(),
+		Files:       files,
+	})
+}
+
+// files downloads the media of the message.
+func (b *Bot) files(ctx context.Context, msg Message) ([]channel.File, error) {
+	var result []channel.File
+	for _, media := range []*Media{msg.Image, msg.Document, msg.Audio, msg.Video} {
+		if media == nil {
+			continue
+		}
+		data, mimeType, err := b.client.download(ctx, media.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download attachment %s: %w", complete.First(media.Filename, media.ID), err)
+		}
+		mimeType = complete.First(media.MimeType, mimeType, http.DetectContentType(data))
+		mimeType, _, _ = strings.Cut(mimeType, ";")
+
+		result = append(result, channel.File{
+			Name:     media.Filename,
+			MimeType: mimeType,
+			Data:     data,
+		})
+	}
+	return result, nil
+}
+
+// prompt returns the text of the message, the caption of media, or the text of a quick reply button.
+func (m Message) prompt() string {
+	switch {
+	case m.Text != nil:
+		return strings.TrimSpace(m.Text.Body)
+	case m.Button != nil:
+		return m.Button.Text
+	}
+	for _, media := range []*Media{m.Image, m.Document, m.Video} {
+		if media != nil && media.Caption != "" {
+			return strings.TrimSpace(media.Caption)
+		}
+	}
+	return ""
+}
+
+func contactName(contacts []Contact, waID string) string {
+	for _, contact := range contacts {
+		if contact.WaID == waID {
+			return contact.Profile.Name
+		}
+	}
+	return ""
+}
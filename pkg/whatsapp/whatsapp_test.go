@@ -0,0 +1,49 @@
+package whatsapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	b := &Bot{opt: Options{VerifyToken: "verify"}}
+
+	rw := httptest.NewRecorder()
+	b.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/whatsapp/webhook?hub.mode=subscribe&hub.verify_token=verify&hub.challenge=42", nil))
+	if rw.Code != http.StatusOK || rw.Body.String() != "42" {
+		t.Errorf("expected challenge to be echoed, got %d %q", rw.Code, rw.Body.String())
+	}
+
+	rw = httptest.NewRecorder()
+	b.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/whatsapp/webhook?hub.mode=subscribe&hub.verify_token=wrong&hub.challenge=42", nil))
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("expected wrong verify token to be rejected, got %d", rw.Code)
+	}
+}
+
+func TestValidSignature(t *testing.T) {
+	b := &Bot{opt: Options{AppSecret: "secret"}}
+	body := []byte(`{"object":"whatsapp_business_account"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !b.validSignature(signature, body) {
+		t.Error("expected valid signature to be accepted")
+	}
+	if b.validSignature(signature, []byte(`{}`)) || b.validSignature("", body) {
+		t.Error("expected invalid signature to be rejected")
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	msg := Message{Image: &Media{ID: "1", Caption: " what is this? "}}
+	if got := msg.prompt(); got != "what is this?" {
+		t.Errorf("got prompt %q, want the caption", got)
+	}
+}
@@ -201,8 +201,12 @@ export class ChatAPI {
 		).chats;
 	}
 
-	async createThread(): Promise<Chat> {
-		return await this.callMCPTool<Chat>('create_chat');
+	async createThread(opts?: { ephemeral?: boolean }): Promise<Chat> {
+		return await this.callMCPTool<Chat>('create_chat', {
+			payload: {
+				...(opts?.ephemeral && { ephemeral: true })
+			}
+		});
 	}
 
 	async createResource(
@@ -236,6 +240,15 @@ export class ChatAPI {
 		});
 	}
 
+	async stopMessage(threadId: string): Promise<void> {
+		await this.callMCPTool('stop', {
+			payload: {
+				sessionId: threadId
+			},
+			sessionId: threadId
+		});
+	}
+
 	async sendMessage(request: ChatRequest): Promise<ChatResult> {
 		await this.callMCPTool<CallToolResult>('chat_ui', {
 			payload: {
@@ -488,8 +501,8 @@ export class ChatService {
 		this.elicitations = this.elicitations.filter((e) => e.id !== elicitation.id);
 	};
 
-	newChat = async () => {
-		const thread = await this.api.createThread();
+	newChat = async (opts?: { ephemeral?: boolean }) => {
+		const thread = await this.api.createThread(opts);
 		await this.setChatId(thread.id);
 	};
 
@@ -542,6 +555,11 @@ export class ChatService {
 		}
 	};
 
+	stop = async () => {
+		if (!this.chatId || !this.isLoading) return;
+		await this.api.stopMessage(this.chatId);
+	};
+
 	cancelUpload = (fileId: string) => {
 		this.uploadingFiles = this.uploadingFiles.filter((f) => {
 			if (f.id !== fileId) {
@@ -1,9 +1,16 @@
 export interface Agent {
+	id?: string;
 	name?: string;
 	description?: string;
 	icon?: string;
 	iconDark?: string;
 	starterMessages?: string[];
+	ephemeral?: boolean;
+	model?: string;
+	capabilities?: {
+		vision: boolean;
+		tools: boolean;
+	};
 }
 
 export interface Agents {
@@ -16,6 +23,15 @@ export interface Chat {
 	created: string;
 	visibility?: 'public' | 'private';
 	readonly?: boolean;
+	ephemeral?: boolean;
+	summary?: SessionSummary;
+}
+
+export interface SessionSummary {
+	topics?: string[];
+	decisions?: string[];
+	openQuestions?: string[];
+	updated: string;
 }
 
 export interface ChatMessage {
@@ -24,9 +40,14 @@ export interface ChatMessage {
 	role: 'user' | 'assistant';
 	items?: ChatMessageItem[];
 	hasMore?: boolean;
+	agent?: string;
 }
 
-export type ChatMessageItem = ToolOutputItem | ChatMessageItemToolCall | ChatMessageItemReasoning;
+export type ChatMessageItem =
+	| ToolOutputItem
+	| ChatMessageItemToolCall
+	| ChatMessageItemReasoning
+	| ChatMessageItemSuggestions;
 
 export type ToolOutputItem =
 	| ChatMessageItemImage
@@ -78,6 +99,11 @@ export interface ChatMessageItemReasoning extends ChatMessageItemBase {
 	}[];
 }
 
+export interface ChatMessageItemSuggestions extends ChatMessageItemBase {
+	type: 'suggestions';
+	prompts?: string[];
+}
+
 export interface ChatMessageItemResource extends ChatMessageItemBase {
 	type: 'resource';
 	resource: {
@@ -109,7 +135,8 @@ export interface ChatMessageItemBase {
 		| 'resource'
 		| 'resource_link'
 		| 'tool'
-		| 'reasoning';
+		| 'reasoning'
+		| 'suggestions';
 }
 
 export interface ChatRequest {